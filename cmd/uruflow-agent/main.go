@@ -20,6 +20,8 @@ package main
 
 import (
 	"bufio"
+	"errors"
+	"flag"
 	"fmt"
 	"os"
 	"os/exec"
@@ -41,24 +43,28 @@ const (
 	colorGray  = "\033[38;2;107;114;128m"
 )
 
+const (
+	exitFailure = 1
+	exitConfig  = 2
+	exitConnect = 3
+	exitAuth    = 4
+)
+
 var configPath string
 
 func main() {
-	parseFlags()
+	cmd, args := parseFlags()
 
-	if len(os.Args) < 2 {
+	if cmd == "" {
 		printUsage()
 		os.Exit(1)
 	}
 
-	cmd := os.Args[1]
-	if strings.HasPrefix(cmd, "-") {
-		cmd = os.Args[len(os.Args)-1]
-	}
-
 	switch cmd {
 	case "init":
-		cmdInit()
+		cmdInit(args)
+	case "test":
+		cmdTest()
 	case "start":
 		cmdStart()
 	case "stop":
@@ -80,20 +86,35 @@ func main() {
 	}
 }
 
-func parseFlags() {
-	for i, arg := range os.Args {
-		if arg == "-c" || arg == "--config" {
+func parseFlags() (string, []string) {
+	var rest []string
+	for i := 1; i < len(os.Args); i++ {
+		arg := os.Args[i]
+		switch {
+		case arg == "-c" || arg == "--config":
 			if i+1 < len(os.Args) {
 				configPath = os.Args[i+1]
+				i++
 			}
-		}
-		if strings.HasPrefix(arg, "--config=") {
+		case strings.HasPrefix(arg, "--config="):
 			configPath = strings.TrimPrefix(arg, "--config=")
+		default:
+			rest = append(rest, arg)
 		}
 	}
 	if configPath == "" {
 		configPath = config.DefaultConfigPath
 	}
+
+	for i, arg := range rest {
+		if !strings.HasPrefix(arg, "-") {
+			return arg, rest[i+1:]
+		}
+	}
+	if len(rest) > 0 {
+		return rest[len(rest)-1], nil
+	}
+	return "", nil
 }
 
 func printUsage() {
@@ -108,20 +129,52 @@ func printUsage() {
 	fmt.Println()
 	fmt.Println("  Commands:")
 	fmt.Printf("    init      %sSetup agent configuration%s\n", colorGray, colorReset)
+	fmt.Printf("    test      %sCheck the connection and token against the server%s\n", colorGray, colorReset)
 	fmt.Printf("    start     %sStart the agent daemon%s\n", colorGray, colorReset)
 	fmt.Printf("    stop      %sStop the agent daemon%s\n", colorGray, colorReset)
 	fmt.Printf("    restart   %sRestart the agent daemon%s\n", colorGray, colorReset)
 	fmt.Printf("    status    %sShow agent status%s\n", colorGray, colorReset)
 	fmt.Printf("    version   %sShow version%s\n", colorGray, colorReset)
 	fmt.Println()
+	fmt.Println("  Init options:")
+	fmt.Printf("    --token <token>        %sAgent token from the server%s\n", colorGray, colorReset)
+	fmt.Printf("    --server-host <host>   %sServer address%s\n", colorGray, colorReset)
+	fmt.Printf("    --server-port <port>   %sServer TCP port (default: 9001)%s\n", colorGray, colorReset)
+	fmt.Printf("    --tls                  %sConnect over TLS%s\n", colorGray, colorReset)
+	fmt.Printf("    --non-interactive      %sFail instead of prompting for missing values%s\n", colorGray, colorReset)
+	fmt.Printf("    --force                %sOverwrite an existing config%s\n", colorGray, colorReset)
+	fmt.Printf("    --test                 %sTest the connection after saving%s\n", colorGray, colorReset)
+	fmt.Println()
+	fmt.Println("  Exit codes:")
+	fmt.Printf("    1  %sgeneral failure%s\n", colorGray, colorReset)
+	fmt.Printf("    2  %sinvalid or missing configuration%s\n", colorGray, colorReset)
+	fmt.Printf("    3  %scould not connect to the server%s\n", colorGray, colorReset)
+	fmt.Printf("    4  %sserver rejected the token%s\n", colorGray, colorReset)
+	fmt.Println()
 	fmt.Println("  Examples:")
 	fmt.Printf("    uruflow-agent init\n")
+	fmt.Printf("    uruflow-agent init --non-interactive --token <token> --server-host deploy.example.com --test\n")
 	fmt.Printf("    uruflow-agent start\n")
 	fmt.Printf("    uruflow-agent --config /custom/path/agent.yaml status\n")
 	fmt.Println()
 }
 
-func cmdInit() {
+func cmdInit(args []string) {
+	fs := flag.NewFlagSet("init", flag.ContinueOnError)
+	token := fs.String("token", "", "agent token")
+	host := fs.String("server-host", "", "server address")
+	port := fs.Int("server-port", 0, "server TCP port")
+	useTLS := fs.Bool("tls", false, "connect over TLS")
+	nonInteractive := fs.Bool("non-interactive", false, "fail instead of prompting")
+	force := fs.Bool("force", false, "overwrite an existing config")
+	test := fs.Bool("test", false, "test the connection after saving")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return
+		}
+		os.Exit(exitConfig)
+	}
+
 	fmt.Println()
 	fmt.Printf("  %s┬ ┬┬─┐┬ ┬┌─┐┬  ┌─┐┬ ┬%s\n", colorBlue, colorReset)
 	fmt.Printf("  %s│ │├┬┘│ │├┤ │  │ ││││%s\n", colorBlue, colorReset)
@@ -131,10 +184,15 @@ func cmdInit() {
 	fmt.Printf("  %sConfig: %s%s\n", colorGray, configPath, colorReset)
 	fmt.Println()
 
-	if config.Exists(configPath) {
+	reader := bufio.NewReader(os.Stdin)
+
+	if config.Exists(configPath) && !*force {
+		if *nonInteractive {
+			fmt.Printf("  %s✗%s Config already exists at %s (use --force to overwrite)\n", colorRed, colorReset, configPath)
+			os.Exit(exitConfig)
+		}
 		fmt.Printf("  %s!%s Config already exists at %s\n", colorRed, colorReset, configPath)
 		fmt.Print("  Overwrite? [y/N]: ")
-		reader := bufio.NewReader(os.Stdin)
 		answer, _ := reader.ReadString('\n')
 		if strings.ToLower(strings.TrimSpace(answer)) != "y" {
 			fmt.Println("  Aborted.")
@@ -144,22 +202,47 @@ func cmdInit() {
 	}
 
 	cfg := config.Default()
-	reader := bufio.NewReader(os.Stdin)
+	cfg.Token = *token
+	cfg.Server.Host = *host
+	cfg.Server.TLS = *useTLS
+	if *port != 0 {
+		cfg.Server.Port = *port
+	}
 
-	fmt.Print("  Agent token: ")
-	cfg.Token, _ = reader.ReadString('\n')
-	cfg.Token = strings.TrimSpace(cfg.Token)
+	if *nonInteractive {
+		var missing []string
+		if cfg.Token == "" {
+			missing = append(missing, "--token")
+		}
+		if cfg.Server.Host == "" {
+			missing = append(missing, "--server-host")
+		}
+		if len(missing) > 0 {
+			fmt.Printf("  %s✗%s Missing %s\n", colorRed, colorReset, strings.Join(missing, ", "))
+			os.Exit(exitConfig)
+		}
+	} else {
+		if cfg.Token == "" {
+			fmt.Print("  Agent token: ")
+			cfg.Token, _ = reader.ReadString('\n')
+			cfg.Token = strings.TrimSpace(cfg.Token)
+		}
 
-	fmt.Print("  Server host: ")
-	cfg.Server.Host, _ = reader.ReadString('\n')
-	cfg.Server.Host = strings.TrimSpace(cfg.Server.Host)
+		if cfg.Server.Host == "" {
+			fmt.Print("  Server host: ")
+			cfg.Server.Host, _ = reader.ReadString('\n')
+			cfg.Server.Host = strings.TrimSpace(cfg.Server.Host)
+		}
 
-	fmt.Print("  Server port [9001]: ")
-	portStr, _ := reader.ReadString('\n')
-	portStr = strings.TrimSpace(portStr)
-	if portStr != "" {
-		if p, err := strconv.Atoi(portStr); err == nil {
-			cfg.Server.Port = p
+		if *port == 0 {
+			fmt.Print("  Server port [9001]: ")
+			portStr, _ := reader.ReadString('\n')
+			portStr = strings.TrimSpace(portStr)
+			if portStr != "" {
+				if p, err := strconv.Atoi(portStr); err == nil {
+					cfg.Server.Port = p
+				}
+			}
 		}
 	}
 
@@ -167,23 +250,67 @@ func cmdInit() {
 
 	if err := cfg.Validate(); err != nil {
 		fmt.Printf("  %s✗%s %s\n", colorRed, colorReset, err.Error())
-		os.Exit(1)
+		os.Exit(exitConfig)
 	}
 
 	os.MkdirAll(cfg.DataDir, 0755)
 
 	if err := cfg.Save(configPath); err != nil {
 		fmt.Printf("  %s✗%s Failed to save config: %v\n", colorRed, colorReset, err)
-		os.Exit(1)
+		os.Exit(exitConfig)
 	}
 
 	fmt.Printf("  %s✓%s Config saved to %s\n", colorGreen, colorReset, configPath)
+
+	if *test {
+		testConnection(cfg)
+	}
+
 	fmt.Println()
 	fmt.Println("  Next steps:")
 	fmt.Printf("    %suruflow-agent start%s\n", colorBlue, colorReset)
 	fmt.Println()
 }
 
+func cmdTest() {
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		fmt.Printf("  %s✗%s %v\n", colorRed, colorReset, err)
+		fmt.Printf("  %s→%s Run: uruflow-agent init\n", colorGray, colorReset)
+		os.Exit(exitConfig)
+	}
+	if err := cfg.Validate(); err != nil {
+		fmt.Printf("  %s✗%s %v\n", colorRed, colorReset, err)
+		os.Exit(exitConfig)
+	}
+
+	testConnection(cfg)
+}
+
+func testConnection(cfg *config.Config) {
+	fmt.Printf("  %s…%s Connecting to %s\n", colorGray, colorReset, serverList(cfg))
+
+	result, err := daemon.Probe(cfg)
+	if errors.Is(err, daemon.ErrAuthRejected) {
+		fmt.Printf("  %s✗%s %v\n", colorRed, colorReset, err)
+		os.Exit(exitAuth)
+	}
+	if err != nil {
+		fmt.Printf("  %s✗%s Connection failed: %v\n", colorRed, colorReset, err)
+		os.Exit(exitConnect)
+	}
+
+	fmt.Printf("  %s✓%s Connected to %s as '%s' (ID: %s)\n", colorGreen, colorReset, result.Server, result.Name, result.AgentID)
+}
+
+func serverList(cfg *config.Config) string {
+	var addrs []string
+	for _, ep := range cfg.Server.Targets() {
+		addrs = append(addrs, ep.Addr())
+	}
+	return strings.Join(addrs, ", ")
+}
+
 func cmdStart() {
 	cfg, err := config.Load(configPath)
 	if err != nil {
@@ -207,6 +334,7 @@ func cmdStart() {
 	cmd.Stdout = nil
 	cmd.Stderr = nil
 	cmd.Stdin = nil
+	cmd.SysProcAttr = daemon.DetachAttr()
 
 	if err := cmd.Start(); err != nil {
 		fmt.Printf("  %s✗%s Failed to start: %v\n", colorRed, colorReset, err)
@@ -242,12 +370,22 @@ func cmdStop() {
 		os.Exit(1)
 	}
 
-	for i := 0; i < 10; i++ {
+	grace := time.Duration(cfg.Deploy.ShutdownGrace) * time.Second
+	deadline := time.Now().Add(grace + 30*time.Second)
+	start := time.Now()
+	lastReport := start
+
+	for time.Now().Before(deadline) {
 		time.Sleep(500 * time.Millisecond)
 		if running, _ := daemon.IsRunning(cfg.PidFile); !running {
 			fmt.Printf("  %s✓%s Agent stopped (was pid %d)\n", colorGreen, colorReset, pid)
 			return
 		}
+		if time.Since(lastReport) >= 5*time.Second {
+			lastReport = time.Now()
+			fmt.Printf("  %s…%s Waiting for in-flight deployments to finish (%ds elapsed, grace %s)\n",
+				colorGray, colorReset, int(time.Since(start).Seconds()), grace)
+		}
 	}
 
 	fmt.Printf("  %s!%s Agent may still be running\n", colorRed, colorReset)
@@ -279,7 +417,10 @@ func cmdStatus() {
 		fmt.Printf("  Status   %s○ stopped%s\n", colorGray, colorReset)
 	}
 
-	fmt.Printf("  Server   %s:%d\n", cfg.Server.Host, cfg.Server.Port)
+	fmt.Printf("  Server   %s\n", serverList(cfg))
+	if connected := daemon.ConnectedServer(cfg); running && connected != "" {
+		fmt.Printf("  Active   %s%s%s\n", colorGreen, connected, colorReset)
+	}
 	fmt.Printf("  Config   %s\n", configPath)
 	fmt.Printf("  PID      %s\n", cfg.PidFile)
 	fmt.Printf("  Logs     %s\n", cfg.LogFile)
@@ -22,11 +22,12 @@ import (
 	"os"
 
 	"github.com/urustack/uruflow/internal/cli"
+	"github.com/urustack/uruflow/internal/config"
 	"github.com/urustack/uruflow/pkg/logger"
 )
 
 func main() {
-	if err := logger.Init("/var/log/uruflow-server.log", "info"); err != nil {
+	if err := logger.Init(config.DefaultLogFile, "info"); err != nil {
 		fmt.Fprintf(os.Stderr, "Failed to initialize logger: %v\n", err)
 		os.Exit(1)
 	}
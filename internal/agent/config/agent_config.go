@@ -19,11 +19,20 @@
 package config
 
 import (
+	"encoding/hex"
 	"errors"
+	"fmt"
+	"net"
+	"net/url"
 	"os"
 	"path/filepath"
+	"regexp"
 	"runtime"
+	"strconv"
+	"strings"
 
+	"github.com/urustack/uruflow/internal/models"
+	"github.com/urustack/uruflow/pkg/logger"
 	"gopkg.in/yaml.v3"
 )
 
@@ -58,12 +67,19 @@ func init() {
 }
 
 type Config struct {
-	Token   string       `yaml:"token"`
-	DataDir string       `yaml:"data_dir"`
-	PidFile string       `yaml:"pid_file"`
-	LogFile string       `yaml:"log_file"`
-	Server  ServerConfig `yaml:"server"`
-	Docker  DockerConfig `yaml:"docker"`
+	Token   string        `yaml:"token"`
+	DataDir string        `yaml:"data_dir"`
+	PidFile string        `yaml:"pid_file"`
+	LogFile string        `yaml:"log_file"`
+	Server  ServerConfig  `yaml:"server"`
+	Docker  DockerConfig  `yaml:"docker"`
+	Deploy  DeployConfig  `yaml:"deploy"`
+	Cleanup CleanupConfig `yaml:"cleanup"`
+	Log     LogConfig     `yaml:"log"`
+
+	Credentials map[string]Credential `yaml:"credentials,omitempty"`
+	Exec        map[string][]string   `yaml:"exec,omitempty"`
+	Registries  []Registry            `yaml:"registries,omitempty"`
 }
 
 type ServerConfig struct {
@@ -71,13 +87,151 @@ type ServerConfig struct {
 	Port          int    `yaml:"port"`
 	TLS           bool   `yaml:"tls"`
 	TLSSkipVerify bool   `yaml:"tls_skip_verify"`
+	Fingerprint   string `yaml:"tls_fingerprint,omitempty"`
+	CAFile        string `yaml:"ca_file,omitempty"`
+	CertFile      string `yaml:"cert_file,omitempty"`
+	KeyFile       string `yaml:"key_file,omitempty"`
 	ReconnectSec  int    `yaml:"reconnect_sec"`
 	MetricsSec    int    `yaml:"metrics_sec"`
+	Compression   bool   `yaml:"compression"`
+
+	Proxy           ProxyConfig      `yaml:"proxy,omitempty"`
+	Endpoints       []EndpointConfig `yaml:"endpoints,omitempty"`
+	PrimaryResetSec int              `yaml:"primary_reset_sec"`
+}
+
+type EndpointConfig struct {
+	Host string `yaml:"host"`
+	Port int    `yaml:"port,omitempty"`
+	TLS  *bool  `yaml:"tls,omitempty"`
+}
+
+type Endpoint struct {
+	Host string
+	Port int
+	TLS  bool
+}
+
+func (e Endpoint) Addr() string {
+	return net.JoinHostPort(strings.Trim(e.Host, "[]"), strconv.Itoa(e.Port))
+}
+
+func (s ServerConfig) Targets() []Endpoint {
+	var targets []Endpoint
+	if s.Host != "" {
+		targets = append(targets, Endpoint{Host: s.Host, Port: s.Port, TLS: s.TLS})
+	}
+	for _, ep := range s.Endpoints {
+		target := Endpoint{Host: ep.Host, Port: ep.Port, TLS: s.TLS}
+		if target.Port == 0 {
+			target.Port = s.Port
+		}
+		if ep.TLS != nil {
+			target.TLS = *ep.TLS
+		}
+		targets = append(targets, target)
+	}
+	return targets
 }
 
 type DockerConfig struct {
-	Enabled bool   `yaml:"enabled"`
-	Socket  string `yaml:"socket"`
+	Enabled           bool   `yaml:"enabled"`
+	Runtime           string `yaml:"runtime"`
+	Socket            string `yaml:"socket"`
+	Endpoint          string `yaml:"endpoint,omitempty"`
+	TLSCert           string `yaml:"tls_cert,omitempty"`
+	TLSKey            string `yaml:"tls_key,omitempty"`
+	TLSCA             string `yaml:"tls_ca,omitempty"`
+	BuilderCacheMaxGB int    `yaml:"builder_cache_max_gb"`
+	BuilderPruneHours int    `yaml:"builder_prune_hours"`
+	MaxLogStreams     int    `yaml:"max_log_streams"`
+}
+
+func (d DockerConfig) EndpointURL() string {
+	if d.Endpoint != "" {
+		return d.Endpoint
+	}
+	return "unix://" + d.Socket
+}
+
+type DeployConfig struct {
+	WorkDir        string  `yaml:"workdir,omitempty"`
+	DirtyWorkspace string  `yaml:"dirty_workspace"`
+	DriftCheckSec  int     `yaml:"drift_check_sec"`
+	MaxQueue       int     `yaml:"max_queue"`
+	MaxConcurrent  int     `yaml:"max_concurrent_deploys"`
+	ShutdownGrace  int     `yaml:"shutdown_grace_sec"`
+	MinFreeGB      float64 `yaml:"min_free_gb"`
+	MinFreePercent float64 `yaml:"min_free_percent"`
+
+	Limits LimitsConfig `yaml:"limits,omitempty"`
+}
+
+func (c *Config) WorkDir() string {
+	if c.Deploy.WorkDir != "" {
+		return filepath.Clean(c.Deploy.WorkDir)
+	}
+	return filepath.Join(c.DataDir, "repos")
+}
+
+var memoryMaxPattern = regexp.MustCompile(`^[0-9]+[KMGT]?$`)
+
+type LimitsConfig struct {
+	Nice      int     `yaml:"nice,omitempty"`
+	IOClass   string  `yaml:"ionice,omitempty"`
+	CPUs      float64 `yaml:"cpus,omitempty"`
+	MemoryMax string  `yaml:"memory_max,omitempty"`
+}
+
+const (
+	PruneOff      = "off"
+	PruneDeploy   = "deploy"
+	PruneSchedule = "schedule"
+)
+
+type CleanupConfig struct {
+	IntervalHours   int    `yaml:"interval_hours"`
+	StaleRepoDays   int    `yaml:"stale_repo_days"`
+	PruneImages     string `yaml:"prune_images"`
+	ReportReclaimed bool   `yaml:"report_reclaimed"`
+}
+
+func (l LimitsConfig) Validate() error {
+	if l.Nice < -20 || l.Nice > 19 {
+		return errors.New("deploy.limits.nice must be between -20 and 19")
+	}
+	switch l.IOClass {
+	case "", "idle", "best-effort":
+	default:
+		return errors.New("deploy.limits.ionice must be idle or best-effort")
+	}
+	if l.CPUs < 0 {
+		return errors.New("deploy.limits.cpus must not be negative")
+	}
+	if l.MemoryMax != "" && !memoryMaxPattern.MatchString(l.MemoryMax) {
+		return errors.New("deploy.limits.memory_max must be a size such as 512M or 2G")
+	}
+	return nil
+}
+
+type LogConfig struct {
+	Level      string `yaml:"level"`
+	Format     string `yaml:"format"`
+	MaxSizeMB  int    `yaml:"max_size_mb"`
+	MaxBackups int    `yaml:"max_backups"`
+}
+
+type Credential struct {
+	SSHKeyFile string `yaml:"ssh_key_file,omitempty"`
+	SSHKey     string `yaml:"ssh_key,omitempty"`
+	Username   string `yaml:"username,omitempty"`
+	Token      string `yaml:"token,omitempty"`
+}
+
+type Registry struct {
+	Server       string `yaml:"server"`
+	Username     string `yaml:"username"`
+	PasswordFile string `yaml:"password_file"`
 }
 
 func Default() *Config {
@@ -93,10 +247,35 @@ func Default() *Config {
 			TLSSkipVerify: false,
 			ReconnectSec:  5,
 			MetricsSec:    10,
+			Compression:   true,
+
+			PrimaryResetSec: 300,
 		},
 		Docker: DockerConfig{
-			Enabled: true,
-			Socket:  "/var/run/docker.sock",
+			Enabled:           true,
+			Runtime:           "docker",
+			Socket:            "/var/run/docker.sock",
+			BuilderPruneHours: 24,
+			MaxLogStreams:     5,
+		},
+		Deploy: DeployConfig{
+			DirtyWorkspace: "proceed",
+			DriftCheckSec:  300,
+			MaxQueue:       5,
+			MaxConcurrent:  2,
+			ShutdownGrace:  120,
+			MinFreeGB:      2,
+			MinFreePercent: 5,
+		},
+		Cleanup: CleanupConfig{
+			IntervalHours: 24,
+			PruneImages:   PruneOff,
+		},
+		Log: LogConfig{
+			Level:      "info",
+			Format:     logger.FormatText,
+			MaxSizeMB:  100,
+			MaxBackups: 5,
 		},
 	}
 }
@@ -141,12 +320,133 @@ func (c *Config) Save(path string) error {
 	return os.WriteFile(path, data, 0600)
 }
 
+func NormalizeFingerprint(s string) string {
+	return strings.ToLower(strings.NewReplacer(":", "", " ", "").Replace(strings.TrimSpace(s)))
+}
+
 func (c *Config) Validate() error {
 	if c.Token == "" {
 		return errors.New("token is required")
 	}
-	if c.Server.Host == "" {
-		return errors.New("server.host is required")
+	if c.Server.Host == "" && len(c.Server.Endpoints) == 0 {
+		return errors.New("server.host or server.endpoints is required")
+	}
+	for i, ep := range c.Server.Endpoints {
+		if ep.Host == "" {
+			return fmt.Errorf("server.endpoints[%d]: host is required", i)
+		}
+		if ep.Port < 0 || ep.Port > 65535 {
+			return fmt.Errorf("server.endpoints[%d]: port must be between 1 and 65535", i)
+		}
+	}
+	if c.Server.PrimaryResetSec < 0 {
+		return errors.New("server.primary_reset_sec must not be negative")
+	}
+	if c.Deploy.WorkDir != "" {
+		if !filepath.IsAbs(c.Deploy.WorkDir) {
+			return errors.New("deploy.workdir must be an absolute path")
+		}
+		if filepath.Clean(c.Deploy.WorkDir) == "/" {
+			return errors.New("deploy.workdir must not be /")
+		}
+	}
+	switch c.Deploy.DirtyWorkspace {
+	case "", "proceed", "abort", "stash":
+	default:
+		return errors.New("deploy.dirty_workspace must be proceed, abort or stash")
+	}
+	if (c.Server.CertFile == "") != (c.Server.KeyFile == "") {
+		return errors.New("server.cert_file and server.key_file must be set together")
+	}
+	if c.Server.Fingerprint != "" {
+		fp := NormalizeFingerprint(c.Server.Fingerprint)
+		if _, err := hex.DecodeString(fp); err != nil || len(fp) != 64 {
+			return errors.New("server.tls_fingerprint must be a sha256 fingerprint (64 hex digits, colons optional)")
+		}
+	}
+	switch c.Docker.Runtime {
+	case "", "docker", "podman":
+	default:
+		return errors.New("docker.runtime must be docker or podman")
+	}
+	if c.Docker.Endpoint != "" {
+		u, err := url.Parse(c.Docker.Endpoint)
+		if err != nil {
+			return fmt.Errorf("docker.endpoint: %w", err)
+		}
+		switch u.Scheme {
+		case "unix", "tcp", "http", "https":
+		default:
+			return errors.New("docker.endpoint must be a unix://, tcp://, http:// or https:// url")
+		}
+	}
+	if (c.Docker.TLSCert == "") != (c.Docker.TLSKey == "") {
+		return errors.New("docker.tls_cert and docker.tls_key must be set together")
+	}
+	if c.Deploy.MaxQueue < 0 {
+		return errors.New("deploy.max_queue must not be negative")
+	}
+	switch c.Cleanup.PruneImages {
+	case "", PruneOff, PruneDeploy, PruneSchedule:
+	default:
+		return errors.New("cleanup.prune_images must be off, deploy or schedule")
+	}
+	if c.Cleanup.IntervalHours < 0 || c.Cleanup.StaleRepoDays < 0 {
+		return errors.New("cleanup.interval_hours and cleanup.stale_repo_days must not be negative")
+	}
+	if !logger.ValidLevel(c.Log.Level) {
+		return errors.New("log.level must be debug, info, warn or error")
+	}
+	if !logger.ValidFormat(c.Log.Format) {
+		return errors.New("log.format must be text or json")
+	}
+	if c.Log.MaxSizeMB < 0 || c.Log.MaxBackups < 0 {
+		return errors.New("log.max_size_mb and log.max_backups must not be negative")
+	}
+	if c.Deploy.MaxConcurrent < 0 {
+		return errors.New("deploy.max_concurrent_deploys must not be negative")
+	}
+	if c.Deploy.ShutdownGrace < 0 {
+		return errors.New("deploy.shutdown_grace_sec must not be negative")
+	}
+	if c.Deploy.MinFreeGB < 0 {
+		return errors.New("deploy.min_free_gb must not be negative")
+	}
+	if c.Deploy.MinFreePercent < 0 || c.Deploy.MinFreePercent > 100 {
+		return errors.New("deploy.min_free_percent must be between 0 and 100")
+	}
+	if err := c.Deploy.Limits.Validate(); err != nil {
+		return err
+	}
+	if err := c.Server.Proxy.Validate(); err != nil {
+		return err
+	}
+	for name, cred := range c.Credentials {
+		ssh := cred.SSHKeyFile != "" || cred.SSHKey != ""
+		if cred.SSHKeyFile != "" && cred.SSHKey != "" {
+			return fmt.Errorf("credentials.%s: set ssh_key_file or ssh_key, not both", name)
+		}
+		if ssh == (cred.Token != "") {
+			return fmt.Errorf("credentials.%s: set either an ssh key or a token", name)
+		}
+	}
+	seen := make(map[string]bool)
+	for i, reg := range c.Registries {
+		if reg.Server == "" || reg.Username == "" || reg.PasswordFile == "" {
+			return fmt.Errorf("registries[%d]: server, username and password_file are required", i)
+		}
+		if strings.Contains(reg.Server, "://") || strings.Contains(reg.Server, "/") {
+			return fmt.Errorf("registries[%d]: server must be a host, e.g. registry.example.com:5000", i)
+		}
+		if seen[reg.Server] {
+			return fmt.Errorf("registries: %s is listed more than once", reg.Server)
+		}
+		seen[reg.Server] = true
+	}
+	for name, argv := range c.Exec {
+		if err := models.ValidateExec(name, argv); err != nil {
+			return err
+		}
 	}
 	return nil
 }
@@ -0,0 +1,40 @@
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+
+package config
+
+import "testing"
+
+func TestEndpointAddr(t *testing.T) {
+	cases := []struct {
+		host string
+		port int
+		want string
+	}{
+		{"uruflow.example.com", 9001, "uruflow.example.com:9001"},
+		{"10.0.0.5", 9001, "10.0.0.5:9001"},
+		{"::1", 9001, "[::1]:9001"},
+		{"[::1]", 9001, "[::1]:9001"},
+		{"2001:db8::10", 9443, "[2001:db8::10]:9443"},
+	}
+	for _, tc := range cases {
+		if got := (Endpoint{Host: tc.host, Port: tc.port}).Addr(); got != tc.want {
+			t.Errorf("Endpoint{%q, %d}.Addr() = %q, want %q", tc.host, tc.port, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+)
+
+const (
+	ProxySOCKS5      = "socks5"
+	ProxyHTTPConnect = "http-connect"
+	ProxyNone        = "none"
+)
+
+type ProxyConfig struct {
+	Type     string `yaml:"type,omitempty"`
+	Address  string `yaml:"address,omitempty"`
+	Username string `yaml:"username,omitempty"`
+	Password string `yaml:"password,omitempty"`
+}
+
+func (p ProxyConfig) Enabled() bool {
+	return p.Type == ProxySOCKS5 || p.Type == ProxyHTTPConnect
+}
+
+func (p ProxyConfig) Validate() error {
+	switch p.Type {
+	case "", ProxyNone:
+		return nil
+	case ProxySOCKS5, ProxyHTTPConnect:
+	default:
+		return errors.New("server.proxy.type must be socks5, http-connect or none")
+	}
+	if _, port, err := net.SplitHostPort(p.Address); err != nil || port == "" {
+		return errors.New("server.proxy.address must be host:port")
+	}
+	if p.Password != "" && p.Username == "" {
+		return errors.New("server.proxy.password needs server.proxy.username")
+	}
+	if p.Type == ProxySOCKS5 && (len(p.Username) > 255 || len(p.Password) > 255) {
+		return errors.New("server.proxy.username and password must be at most 255 bytes for socks5")
+	}
+	return nil
+}
+
+func (s ServerConfig) ProxyFor(host string) (ProxyConfig, string, error) {
+	if s.Proxy.Type != "" {
+		if s.Proxy.Type == ProxyNone {
+			return ProxyConfig{}, "", nil
+		}
+		return s.Proxy, "server.proxy", nil
+	}
+	for _, name := range []string{"ALL_PROXY", "all_proxy", "HTTPS_PROXY", "https_proxy"} {
+		raw := os.Getenv(name)
+		if raw == "" {
+			continue
+		}
+		if noProxy(host) {
+			return ProxyConfig{}, "", nil
+		}
+		p, err := ParseProxyURL(raw)
+		if err != nil {
+			return ProxyConfig{}, name, fmt.Errorf("%s: %w", name, err)
+		}
+		return p, name, nil
+	}
+	return ProxyConfig{}, "", nil
+}
+
+func ParseProxyURL(raw string) (ProxyConfig, error) {
+	if !strings.Contains(raw, "://") {
+		raw = "http://" + raw
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return ProxyConfig{}, err
+	}
+	p := ProxyConfig{Address: u.Host}
+	switch u.Scheme {
+	case "socks5", "socks5h":
+		p.Type = ProxySOCKS5
+		if u.Port() == "" {
+			p.Address = net.JoinHostPort(u.Hostname(), "1080")
+		}
+	case "http":
+		p.Type = ProxyHTTPConnect
+		if u.Port() == "" {
+			p.Address = net.JoinHostPort(u.Hostname(), "80")
+		}
+	default:
+		return ProxyConfig{}, fmt.Errorf("unsupported proxy scheme %q, use socks5:// or http://", u.Scheme)
+	}
+	if u.User != nil {
+		p.Username = u.User.Username()
+		p.Password, _ = u.User.Password()
+	}
+	return p, p.Validate()
+}
+
+func noProxy(host string) bool {
+	list := os.Getenv("NO_PROXY")
+	if list == "" {
+		list = os.Getenv("no_proxy")
+	}
+	host = strings.ToLower(strings.Trim(host, "[]"))
+	for _, entry := range strings.Split(list, ",") {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		if entry == "" {
+			continue
+		}
+		if entry == "*" {
+			return true
+		}
+		if h, _, err := net.SplitHostPort(entry); err == nil {
+			entry = h
+		}
+		entry = strings.TrimPrefix(strings.TrimPrefix(entry, "*"), ".")
+		if host == entry || strings.HasSuffix(host, "."+entry) {
+			return true
+		}
+		if _, cidr, err := net.ParseCIDR(entry); err == nil {
+			if ip := net.ParseIP(host); ip != nil && cidr.Contains(ip) {
+				return true
+			}
+		}
+	}
+	return false
+}
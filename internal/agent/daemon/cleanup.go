@@ -0,0 +1,395 @@
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/urustack/uruflow/internal/agent/config"
+	"github.com/urustack/uruflow/internal/agent/deploy"
+	"github.com/urustack/uruflow/pkg/helper"
+	"github.com/urustack/uruflow/pkg/logger"
+)
+
+type cleanupState struct {
+	mu        sync.Mutex
+	pruning   sync.Mutex
+	reclaimed uint64
+}
+
+func (d *Daemon) workDir() string {
+	return d.cfg.WorkDir()
+}
+
+func (d *Daemon) workDirFile() string {
+	return filepath.Join(d.stateDir(), "workdir")
+}
+
+func checkWorkDir(dir string) error {
+	if filepath.Clean(dir) == "/" {
+		return errors.New("must not be /")
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("create %s: %w", dir, err)
+	}
+	f, err := os.CreateTemp(dir, ".uruflow-write-*")
+	if err != nil {
+		return fmt.Errorf("%s is not writable: %w", dir, err)
+	}
+	f.Close()
+	os.Remove(f.Name())
+	return nil
+}
+
+func (d *Daemon) noteWorkDir(dir string) {
+	data, _ := os.ReadFile(d.workDirFile())
+	previous := strings.TrimSpace(string(data))
+	if previous == dir {
+		return
+	}
+
+	if previous != "" {
+		entries, _ := os.ReadDir(previous)
+		var left []string
+		for _, entry := range entries {
+			if entry.IsDir() {
+				left = append(left, entry.Name())
+			}
+		}
+		if len(left) > 0 {
+			logger.Warn("[AGENT] work directory changed from %s to %s, %d checkout(s) left behind in the old location: %s",
+				previous, dir, len(left), strings.Join(left, ", "))
+		}
+	}
+
+	os.MkdirAll(d.stateDir(), 0755)
+	if err := os.WriteFile(d.workDirFile(), []byte(dir+"\n"), 0644); err != nil {
+		logger.Warn("[AGENT] failed to save work directory state: %v", err)
+	}
+}
+
+func (d *Daemon) workdirsFile() string {
+	return filepath.Join(d.stateDir(), "workdirs")
+}
+
+func (d *Daemon) cleanupStampFile() string {
+	return filepath.Join(d.stateDir(), "cleanup")
+}
+
+func (d *Daemon) loadWorkdirUsage() map[string]time.Time {
+	usage := make(map[string]time.Time)
+	data, err := os.ReadFile(d.workdirsFile())
+	if err != nil {
+		return usage
+	}
+	if err := json.Unmarshal(data, &usage); err != nil {
+		logger.Warn("[AGENT] ignoring unreadable working directory state: %v", err)
+	}
+	return usage
+}
+
+func (d *Daemon) saveWorkdirUsage(usage map[string]time.Time) {
+	data, err := json.MarshalIndent(usage, "", "  ")
+	if err != nil {
+		return
+	}
+	os.MkdirAll(d.stateDir(), 0755)
+	if err := os.WriteFile(d.workdirsFile(), data, 0644); err != nil {
+		logger.Warn("[AGENT] failed to save working directory state: %v", err)
+	}
+}
+
+func (d *Daemon) touchWorkdir(name string) {
+	d.cleanup.mu.Lock()
+	defer d.cleanup.mu.Unlock()
+	usage := d.loadWorkdirUsage()
+	usage[name] = time.Now()
+	d.saveWorkdirUsage(usage)
+}
+
+func (d *Daemon) forgetWorkdir(name string) {
+	d.cleanup.mu.Lock()
+	defer d.cleanup.mu.Unlock()
+	usage := d.loadWorkdirUsage()
+	delete(usage, name)
+	d.saveWorkdirUsage(usage)
+}
+
+func (d *Daemon) workdirLastUsed(name string, info fs.FileInfo) time.Time {
+	d.cleanup.mu.Lock()
+	last := d.loadWorkdirUsage()[name]
+	d.cleanup.mu.Unlock()
+	if !last.IsZero() {
+		return last
+	}
+	for _, st := range d.loadDeployStates() {
+		if st.Name == name {
+			return st.DeployedAt
+		}
+	}
+	return info.ModTime()
+}
+
+func (d *Daemon) knownWorkdirs() map[string]bool {
+	known := make(map[string]bool)
+	d.cleanup.mu.Lock()
+	for name := range d.loadWorkdirUsage() {
+		known[name] = true
+	}
+	d.cleanup.mu.Unlock()
+
+	d.checkouts.mu.Lock()
+	d.loadCheckouts()
+	for name := range d.checkouts.items {
+		known[name] = true
+	}
+	d.checkouts.mu.Unlock()
+
+	for _, st := range d.loadDeployStates() {
+		if st.Name != "" {
+			known[st.Name] = true
+		}
+	}
+	return known
+}
+
+func (d *Daemon) customWorkdirs() map[string]string {
+	root := d.workDir()
+	custom := make(map[string]string)
+	for _, st := range d.loadDeployStates() {
+		rel, err := filepath.Rel(root, st.RepoDir)
+		if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+			continue
+		}
+		if top := strings.SplitN(filepath.ToSlash(rel), "/", 2)[0]; top != st.Name {
+			custom[top] = st.Name
+		}
+	}
+	return custom
+}
+
+func (d *Daemon) addReclaimed(bytes uint64) {
+	if !d.cfg.Cleanup.ReportReclaimed || bytes == 0 {
+		return
+	}
+	d.cleanup.mu.Lock()
+	d.cleanup.reclaimed += bytes
+	d.cleanup.mu.Unlock()
+}
+
+func (d *Daemon) takeReclaimed() uint64 {
+	d.cleanup.mu.Lock()
+	defer d.cleanup.mu.Unlock()
+	n := d.cleanup.reclaimed
+	d.cleanup.reclaimed = 0
+	return n
+}
+
+func (d *Daemon) cleanupLoop() {
+	cfg := d.cfg.Cleanup
+	interval := time.Duration(cfg.IntervalHours) * time.Hour
+	if interval <= 0 || (cfg.StaleRepoDays <= 0 && cfg.PruneImages != config.PruneSchedule) {
+		return
+	}
+
+	logger.Info("[AGENT] cleanup enabled: stale repos after %d days, image prune %s, every %s",
+		cfg.StaleRepoDays, cfg.PruneImages, interval)
+
+	for {
+		wait := nextPrune(readStamp(d.cleanupStampFile()), interval, time.Now())
+		logger.Debug("[AGENT] next cleanup in %s", wait.Round(time.Second))
+
+		select {
+		case <-d.stopChan:
+			return
+		case <-time.After(wait):
+			d.runCleanup()
+		}
+	}
+}
+
+func (d *Daemon) runCleanup() {
+	cfg := d.cfg.Cleanup
+	var reclaimed uint64
+	if cfg.StaleRepoDays > 0 {
+		reclaimed += d.removeStaleWorkdirs(time.Duration(cfg.StaleRepoDays) * 24 * time.Hour)
+	}
+	if cfg.PruneImages == config.PruneSchedule && d.docker != nil {
+		reclaimed += d.pruneImages()
+	}
+	d.writeStamp(d.cleanupStampFile())
+	d.addReclaimed(reclaimed)
+}
+
+func (d *Daemon) pruneAfterDeploy() {
+	if d.cfg.Cleanup.PruneImages != config.PruneDeploy || d.docker == nil {
+		return
+	}
+	if !d.cleanup.pruning.TryLock() {
+		return
+	}
+	defer d.cleanup.pruning.Unlock()
+
+	reclaimed := d.pruneImages()
+	if d.cfg.Docker.BuilderCacheMaxGB > 0 {
+		reclaimed += d.pruneBuilderCache()
+	}
+	d.addReclaimed(reclaimed)
+}
+
+func (d *Daemon) removeStaleWorkdirs(maxAge time.Duration) uint64 {
+	entries, err := os.ReadDir(d.workDir())
+	if err != nil {
+		if !os.IsNotExist(err) {
+			logger.Warn("[AGENT] failed to read work directory: %v", err)
+		}
+		return 0
+	}
+
+	running := d.projectsWithContainers()
+	custom := d.customWorkdirs()
+	known := d.knownWorkdirs()
+	var reclaimed uint64
+
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		name := entry.Name()
+		if !known[name] {
+			logger.Debug("[AGENT] keeping %s: not a working directory created by uruflow", name)
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		if time.Since(d.workdirLastUsed(name, info)) < maxAge {
+			continue
+		}
+		if owner, ok := custom[name]; ok {
+			logger.Debug("[AGENT] keeping %s: holds the custom path of %s", name, owner)
+			continue
+		}
+		if running[deploy.ProjectName(name)] {
+			logger.Debug("[AGENT] keeping working directory of %s: containers still present", name)
+			continue
+		}
+
+		release, ok := d.queue.tryAcquire(name)
+		if !ok {
+			logger.Info("[AGENT] skipping cleanup of %s: deployment in flight", name)
+			continue
+		}
+		last := d.workdirLastUsed(name, info)
+		if time.Since(last) < maxAge {
+			release()
+			continue
+		}
+
+		dir := filepath.Join(d.workDir(), name)
+		size := dirSize(dir)
+		err = os.RemoveAll(dir)
+		release()
+
+		if err != nil {
+			logger.Error("[AGENT] failed to remove working directory %s: %v", dir, err)
+			d.sendEvent("workdir_cleanup_failed", fmt.Sprintf("failed to remove working directory of %s: %v", name, err))
+			continue
+		}
+
+		d.forgetWorkdir(name)
+		d.forgetCheckout(name)
+		d.removeDeployState(name)
+		reclaimed += size
+
+		days := int(time.Since(last).Hours() / 24)
+		logger.Info("[AGENT] removed working directory %s (unused for %d days, reclaimed %s)", dir, days, helper.FormatBytes(size))
+		d.sendEvent("workdir_cleanup", fmt.Sprintf("removed working directory of %s, unused for %d days, reclaimed %s", name, days, helper.FormatBytes(size)))
+	}
+	return reclaimed
+}
+
+func (d *Daemon) projectsWithContainers() map[string]bool {
+	projects := make(map[string]bool)
+	if d.docker == nil {
+		return projects
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	containers, err := d.docker.ListContainers(ctx)
+	if err != nil {
+		logger.Warn("[AGENT] failed to list containers before cleanup: %v", err)
+		return projects
+	}
+	for _, c := range containers {
+		if c.Project != "" {
+			projects[c.Project] = true
+		}
+		projects[c.Name] = true
+	}
+	return projects
+}
+
+func imagePruneArgs() []string {
+	return []string{"image", "prune", "-f", "--filter", "label=io.uruflow.managed=true"}
+}
+
+func (d *Daemon) pruneImages() uint64 {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	args := imagePruneArgs()
+	logger.Info("[AGENT] running %s %s", d.cli.Runtime, strings.Join(args, " "))
+
+	output, err := d.cli.Command(ctx, args...).CombinedOutput()
+	if err != nil {
+		logger.Error("[AGENT] image prune failed: %v: %s", err, strings.TrimSpace(string(output)))
+		d.sendEvent("image_prune_failed", fmt.Sprintf("image prune failed: %v", err))
+		return 0
+	}
+
+	reclaimed, bytes := reclaimedSpace(string(output))
+	logger.Info("[AGENT] dangling images pruned, reclaimed %s", reclaimed)
+	d.sendEvent("image_prune", fmt.Sprintf("dangling images pruned, reclaimed %s", reclaimed))
+	return bytes
+}
+
+func dirSize(dir string) uint64 {
+	var size uint64
+	filepath.WalkDir(dir, func(_ string, entry fs.DirEntry, err error) error {
+		if err != nil || !entry.Type().IsRegular() {
+			return nil
+		}
+		if info, err := entry.Info(); err == nil {
+			size += uint64(info.Size())
+		}
+		return nil
+	})
+	return size
+}
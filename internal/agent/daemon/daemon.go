@@ -21,12 +21,14 @@ package daemon
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net"
 	"os"
 	"os/signal"
 	"path/filepath"
 	"strconv"
+	"strings"
 	"sync"
 	"syscall"
 	"time"
@@ -35,26 +37,46 @@ import (
 	"github.com/urustack/uruflow/internal/agent/deploy"
 	"github.com/urustack/uruflow/internal/agent/docker"
 	"github.com/urustack/uruflow/internal/agent/metrics"
+	"github.com/urustack/uruflow/internal/models"
 	"github.com/urustack/uruflow/internal/tcp/protocol"
 	"github.com/urustack/uruflow/pkg/logger"
 )
 
 const Version = "1.1.0"
 
+var deployTimeout = 10 * time.Minute
+
 type Daemon struct {
 	cfg           *config.Config
 	conn          net.Conn
 	reader        *protocol.Reader
 	writer        *protocol.Writer
 	docker        *docker.Service
+	cli           docker.CLI
 	metrics       *metrics.Collector
 	deployer      *deploy.Executor
+	queue         *deployQueue
 	agentID       string
 	name          string
+	machineID     string
+	probe         bool
 	stopChan      chan struct{}
+	doneChan      chan struct{}
+	abortCtx      context.Context
+	abort         context.CancelFunc
+	inflight      sync.WaitGroup
+	inflightMu    sync.Mutex
+	stopping      bool
 	writeMu       sync.Mutex
+	outbox        outbox
 	streamCancels map[string]context.CancelFunc
 	streamMu      sync.Mutex
+	dockerDisk    diskUsageCache
+	stats         statsCache
+	preflight     preflightState
+	cleanup       cleanupState
+	checkouts     checkoutState
+	servers       failoverState
 }
 
 func New(cfg *config.Config) (*Daemon, error) {
@@ -63,43 +85,109 @@ func New(cfg *config.Config) (*Daemon, error) {
 		return nil, fmt.Errorf("create log directory: %w", err)
 	}
 
-	if err := logger.Init(cfg.LogFile, "info"); err != nil {
+	err := logger.Configure(logger.Options{
+		Path:       cfg.LogFile,
+		Level:      cfg.Log.Level,
+		Format:     cfg.Log.Format,
+		MaxSizeMB:  cfg.Log.MaxSizeMB,
+		MaxBackups: cfg.Log.MaxBackups,
+	})
+	if err != nil {
 		return nil, fmt.Errorf("initialize logger: %w", err)
 	}
 
 	logger.Info("[AGENT] initializing uruflow-agent v%s", Version)
 
+	dockerOpts := docker.Options{
+		Endpoint: cfg.Docker.EndpointURL(),
+		TLSCert:  cfg.Docker.TLSCert,
+		TLSKey:   cfg.Docker.TLSKey,
+		TLSCA:    cfg.Docker.TLSCA,
+	}
+	cli := docker.NewCLI(cfg.Docker.Runtime, dockerOpts)
+
 	var dockerSvc *docker.Service
 	if cfg.Docker.Enabled {
 		var err error
-		dockerSvc, err = docker.New(cfg.Docker.Socket)
+		dockerSvc, err = docker.New(dockerOpts)
 		if err != nil {
 			logger.Warn("[AGENT] docker unavailable: %v", err)
 		} else {
-			logger.Info("[AGENT] docker connection established on %s", cfg.Docker.Socket)
+			engine := dockerSvc.Engine()
+			logger.Info("[AGENT] container runtime %s on %s, cli %s", engine, dockerSvc.Endpoint(), cli.Runtime)
+			if engine.Podman() != cli.Podman() {
+				logger.Warn("[AGENT] endpoint reports %s but docker.runtime is %s", engine.Name, cli.Runtime)
+			}
 		}
 	}
+	if cli.Podman() && (cfg.Docker.TLSCert != "" || cfg.Docker.TLSCA != "") {
+		logger.Warn("[AGENT] podman cli does not use docker.tls_* settings, only the api client does")
+	}
 
 	if err := os.MkdirAll(cfg.DataDir, 0755); err != nil {
 		return nil, fmt.Errorf("create data directory: %w", err)
 	}
 
-	workDir := filepath.Join(cfg.DataDir, "repos")
-	logger.Debug("[AGENT] work directory: %s", workDir)
+	machineID, err := loadMachineID(cfg.DataDir)
+	if err != nil {
+		return nil, fmt.Errorf("load machine id: %w", err)
+	}
+	logger.Debug("[AGENT] machine id: %s", machineID)
+
+	workDir := cfg.WorkDir()
+	if err := checkWorkDir(workDir); err != nil {
+		return nil, fmt.Errorf("deploy.workdir: %w", err)
+	}
+	logger.Info("[AGENT] work directory: %s", workDir)
+
+	deployer := deploy.NewExecutor(workDir)
+	deployer.SetDirtyPolicy(cfg.Deploy.DirtyWorkspace)
+	deployer.SetRuntime(cli)
+	if len(cfg.Registries) > 0 {
+		registries := make([]deploy.Registry, len(cfg.Registries))
+		for i, reg := range cfg.Registries {
+			registries[i] = deploy.Registry{Server: reg.Server, Username: reg.Username, PasswordFile: reg.PasswordFile}
+		}
+		deployer.SetRegistries(registries)
+	}
+	limits := cfg.Deploy.Limits
+	applied, skipped := deployer.SetLimits(deploy.Limits{
+		Nice: limits.Nice, IOClass: limits.IOClass, CPUs: limits.CPUs, MemoryMax: limits.MemoryMax,
+	})
+	if applied != "" {
+		logger.Info("[AGENT] deploy limits: %s", applied)
+	}
+	for _, s := range skipped {
+		logger.Warn("[AGENT] deploy limit not applied: %s", s)
+	}
+
+	abortCtx, abort := context.WithCancel(context.Background())
 
-	return &Daemon{
+	d := &Daemon{
 		cfg:           cfg,
 		docker:        dockerSvc,
+		cli:           cli,
+		machineID:     machineID,
 		metrics:       metrics.NewCollector(),
-		deployer:      deploy.NewExecutor(workDir),
+		deployer:      deployer,
+		queue:         newDeployQueue(cfg.Deploy.MaxQueue, cfg.Deploy.MaxConcurrent),
 		stopChan:      make(chan struct{}),
+		doneChan:      make(chan struct{}),
+		abortCtx:      abortCtx,
+		abort:         abort,
 		streamCancels: make(map[string]context.CancelFunc),
-	}, nil
+	}
+	d.noteWorkDir(workDir)
+	return d, nil
 }
 
 func (d *Daemon) Run() error {
 	logger.Info("[AGENT] starting agent")
 
+	if len(d.endpoints()) == 0 {
+		return errors.New("no server configured")
+	}
+
 	if err := d.writePid(); err != nil {
 		return fmt.Errorf("write pid: %w", err)
 	}
@@ -111,40 +199,69 @@ func (d *Daemon) Run() error {
 	go func() {
 		<-sigChan
 		logger.Info("[AGENT] shutdown signal received")
-		close(d.stopChan)
+		d.shutdown()
 	}()
 
+	go d.pruneLoop()
+	go d.cleanupLoop()
+
+	d.logProxy()
+
+	d.servers.init(len(d.endpoints()))
+	defer d.clearConnectedServer()
+
 	for {
 		select {
-		case <-d.stopChan:
+		case <-d.doneChan:
+			if n := d.outbox.len(); n > 0 {
+				logger.Warn("[AGENT] exiting with %d undelivered message(s)", n)
+			}
 			logger.Info("[AGENT] Agent stopped")
 			return nil
 		default:
-			if err := d.connect(); err != nil {
-				logger.Error("[AGENT] connection failed: %v", err)
-				logger.Info("[AGENT] reconnecting in %d seconds...", d.cfg.Server.ReconnectSec)
-				time.Sleep(time.Duration(d.cfg.Server.ReconnectSec) * time.Second)
+			i := d.servers.next()
+			ep := d.endpoints()[i]
+			if err := d.connect(ep); err != nil {
+				var proxyErr *proxyError
+				if errors.As(err, &proxyErr) {
+					logger.Error("[AGENT] connection to %s failed at the proxy: %v", ep.Addr(), err)
+				} else {
+					logger.Error("[AGENT] connection to %s failed: %v", ep.Addr(), err)
+				}
+				d.servers.failed(i, time.Duration(d.cfg.Server.ReconnectSec)*time.Second)
+				if wait := d.servers.wait(); wait > 0 {
+					logger.Info("[AGENT] reconnecting in %d seconds...", int(wait.Round(time.Second).Seconds()))
+					select {
+					case <-time.After(wait):
+					case <-d.doneChan:
+					}
+				}
 				continue
 			}
 
+			d.servers.connected(i)
+			d.saveConnectedServer(ep.Addr())
+			d.flushOutbox()
 			d.runLoop()
+			d.servers.disconnected()
+			d.clearConnectedServer()
 		}
 	}
 }
 
-func (d *Daemon) connect() error {
-	addr := fmt.Sprintf("%s:%d", d.cfg.Server.Host, d.cfg.Server.Port)
+func (d *Daemon) connect(ep config.Endpoint) error {
+	addr := ep.Addr()
 	logger.Info("[AGENT] connecting to %s", addr)
 
 	var conn net.Conn
 	var err error
 
-	if d.cfg.Server.TLS {
+	if ep.TLS {
 		logger.Debug("[AGENT] using TLS connection")
 		conn, err = d.connectTLS(addr)
 	} else {
 		logger.Debug("[AGENT] using plain TCP connection")
-		conn, err = net.DialTimeout("tcp", addr, 10*time.Second)
+		conn, err = d.dial(addr, 10*time.Second)
 	}
 
 	if err != nil {
@@ -160,7 +277,8 @@ func (d *Daemon) connect() error {
 		return fmt.Errorf("auth: %w", err)
 	}
 
-	logger.Info("[AGENT] connected as '%s' (ID: %s)", d.name, d.agentID)
+	logger.Info("[AGENT] connected to %s as '%s' (ID: %s)", addr, d.name, d.agentID)
+	d.resendCheckouts()
 	return nil
 }
 
@@ -169,11 +287,20 @@ func (d *Daemon) authenticate() error {
 
 	logger.Debug("[AGENT] authenticating with token")
 
-	authMsg, err := protocol.NewMessage(protocol.TypeAuth, protocol.AuthPayload{
-		Token:    d.cfg.Token,
-		Hostname: hostname,
-		Version:  Version,
-	})
+	auth := protocol.AuthPayload{
+		Token:     d.cfg.Token,
+		Hostname:  hostname,
+		MachineID: d.machineID,
+		Version:   Version,
+		Time:      time.Now().Unix(),
+		Exec:      d.execNames(),
+		Probe:     d.probe,
+	}
+	if d.cfg.Server.Compression {
+		auth.Compression = protocol.SupportedCompression()
+	}
+
+	authMsg, err := protocol.NewMessage(protocol.TypeAuth, auth)
 	if err != nil {
 		return err
 	}
@@ -184,14 +311,14 @@ func (d *Daemon) authenticate() error {
 
 	resp, err := d.reader.ReadWithTimeout(10 * time.Second)
 	if err != nil {
-		return err
+		return fmt.Errorf("read auth response: %w", err)
 	}
 
 	if resp.Type == protocol.TypeAuthFail {
 		var fail protocol.AuthFailPayload
 		resp.Decode(&fail)
 		logger.Error("[AGENT] authentication rejected: %s", fail.Reason)
-		return fmt.Errorf("auth rejected: %s", fail.Reason)
+		return fmt.Errorf("%w: %s", ErrAuthRejected, fail.Reason)
 	}
 
 	if resp.Type != protocol.TypeAuthOK {
@@ -206,6 +333,14 @@ func (d *Daemon) authenticate() error {
 	d.agentID = ok.AgentID
 	d.name = ok.Name
 
+	if ok.Compression != "" {
+		if protocol.NegotiateCompression(auth.Compression) != ok.Compression {
+			return fmt.Errorf("server chose unsupported compression %q", ok.Compression)
+		}
+		d.writer.SetCompression(ok.Compression)
+		logger.Debug("[AGENT] using %s compression", ok.Compression)
+	}
+
 	logger.Info("[AGENT] authentication successful")
 	return nil
 }
@@ -217,18 +352,39 @@ func (d *Daemon) runLoop() {
 	msgChan := make(chan *protocol.Message, 10)
 	errChan := make(chan error, 1)
 
-	go d.readMessages(ctx, msgChan, errChan)
+	go d.readMessages(ctx, d.conn, d.reader, msgChan, errChan)
 
 	metricsTicker := time.NewTicker(time.Duration(d.cfg.Server.MetricsSec) * time.Second)
 	defer metricsTicker.Stop()
 
+	var driftTick <-chan time.Time
+	if d.cfg.Deploy.DriftCheckSec > 0 {
+		driftTicker := time.NewTicker(time.Duration(d.cfg.Deploy.DriftCheckSec) * time.Second)
+		defer driftTicker.Stop()
+		driftTick = driftTicker.C
+	}
+
+	var primaryTick <-chan time.Time
+	if d.servers.onStandby() && d.cfg.Server.PrimaryResetSec > 0 {
+		primaryTicker := time.NewTicker(time.Duration(d.cfg.Server.PrimaryResetSec) * time.Second)
+		defer primaryTicker.Stop()
+		primaryTick = primaryTicker.C
+	}
+
+	primaryUp := make(chan bool, 1)
+	probing := false
+
 	logger.Debug("[AGENT] starting metrics collection (interval: %ds)", d.cfg.Server.MetricsSec)
 	d.sendMetrics()
 
+	stop := d.stopChan
 	for {
 		select {
-		case <-d.stopChan:
-			logger.Debug("[AGENT] stop signal received in run loop")
+		case <-stop:
+			logger.Debug("[AGENT] stop signal received in run loop, draining")
+			stop = nil
+
+		case <-d.doneChan:
 			cancel()
 			d.disconnect()
 			return
@@ -236,6 +392,25 @@ func (d *Daemon) runLoop() {
 		case <-metricsTicker.C:
 			d.sendMetrics()
 
+		case <-driftTick:
+			go d.checkDrift("")
+
+		case <-primaryTick:
+			if !probing && d.queue.idle() {
+				probing = true
+				go func() { primaryUp <- d.primaryReachable() }()
+			}
+
+		case up := <-primaryUp:
+			probing = false
+			if up && d.queue.idle() {
+				logger.Info("[AGENT] primary server %s is reachable again, switching back", d.endpoints()[0].Addr())
+				d.servers.retryNow(0)
+				cancel()
+				d.disconnect()
+				return
+			}
+
 		case msg := <-msgChan:
 			d.handleMessage(msg)
 
@@ -248,14 +423,14 @@ func (d *Daemon) runLoop() {
 	}
 }
 
-func (d *Daemon) readMessages(ctx context.Context, msgChan chan<- *protocol.Message, errChan chan<- error) {
+func (d *Daemon) readMessages(ctx context.Context, conn net.Conn, reader *protocol.Reader, msgChan chan<- *protocol.Message, errChan chan<- error) {
 	for {
 		select {
 		case <-ctx.Done():
 			return
 		default:
-			d.conn.SetReadDeadline(time.Now().Add(1 * time.Second))
-			msg, err := d.reader.Read()
+			conn.SetReadDeadline(time.Now().Add(1 * time.Second))
+			msg, err := reader.Read()
 			if err != nil {
 				if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
 					continue
@@ -287,11 +462,23 @@ func (d *Daemon) handleMessage(msg *protocol.Message) {
 			logger.Error("[AGENT] failed to decode command: %v", err)
 			return
 		}
-		go d.handleCommand(cmd)
+		if !d.track() {
+			logger.Warn("[AGENT] rejecting command %s: agent shutting down", cmd.ID)
+			d.sendCommandDone(cmd.ID, "failed", 1, errShuttingDown)
+			return
+		}
+		go func() {
+			defer d.inflight.Done()
+			d.handleCommand(cmd)
+		}()
 
 	case protocol.TypeMetricsAck:
 		logger.Debug("[AGENT] metrics acknowledged by server")
 
+	case protocol.TypeInventoryRequest:
+		logger.Debug("[AGENT] inventory requested by server")
+		go d.sendMetrics()
+
 	case protocol.TypeDisconnect:
 		logger.Info("[AGENT] disconnect request received from server")
 		d.disconnect()
@@ -320,7 +507,41 @@ func (d *Daemon) handleMessage(msg *protocol.Message) {
 func (d *Daemon) safeWrite(msg *protocol.Message) error {
 	d.writeMu.Lock()
 	defer d.writeMu.Unlock()
-	return d.writer.Write(msg)
+
+	var err error
+	if d.conn == nil || d.writer == nil {
+		err = errors.New("not connected")
+	} else {
+		err = d.writer.Write(msg)
+	}
+
+	if err != nil && bufferable(msg.Type) {
+		d.outbox.push(msg)
+		return nil
+	}
+	return err
+}
+
+func (d *Daemon) flushOutbox() {
+	d.writeMu.Lock()
+	defer d.writeMu.Unlock()
+
+	msgs, dropped := d.outbox.drain()
+	if len(msgs) == 0 {
+		return
+	}
+	if dropped > 0 {
+		logger.Warn("[AGENT] outbox overflowed while disconnected, %d message(s) dropped", dropped)
+	}
+	logger.Info("[AGENT] resending %d buffered message(s)", len(msgs))
+
+	for i, msg := range msgs {
+		if err := d.writer.Write(msg); err != nil {
+			logger.Warn("[AGENT] resend failed, keeping %d message(s) buffered: %v", len(msgs)-i, err)
+			d.outbox.requeue(msgs[i:])
+			return
+		}
+	}
 }
 
 func (d *Daemon) sendMetrics() {
@@ -334,7 +555,8 @@ func (d *Daemon) sendMetrics() {
 		sysMetrics.CPUPercent, sysMetrics.MemoryPercent, sysMetrics.DiskPercent)
 
 	payload := protocol.MetricsPayload{
-		Timestamp: time.Now().Unix(),
+		Timestamp:     time.Now().Unix(),
+		QueuedDeploys: d.queue.pending(),
 		System: protocol.SystemMetrics{
 			CPUPercent:    sysMetrics.CPUPercent,
 			MemoryPercent: sysMetrics.MemoryPercent,
@@ -354,44 +576,44 @@ func (d *Daemon) sendMetrics() {
 		cancel()
 
 		if err == nil {
+			payload.Inventory = true
+			payload.Containers = []protocol.Container{}
+			var ids []string
 			for _, c := range containers {
 				if !c.IsManaged {
 					logger.Debug("[AGENT] skipping non-uruflow container: %s", c.Name)
 					continue
 				}
 
-				cm := protocol.Container{
+				payload.Containers = append(payload.Containers, protocol.Container{
 					ID:           c.ID,
 					Name:         c.Name,
 					Image:        c.Image,
+					Project:      c.Project,
+					Service:      c.Service,
 					Status:       c.State,
 					Health:       c.Health,
 					RestartCount: c.RestartCount,
 					StartedAt:    c.StartedAt,
-				}
-
-				if c.State == "running" {
-					ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
-					stats, err := d.docker.GetContainerStats(ctx, c.FullID)
-					cancel()
-					if err == nil {
-						cm.CPUPercent = stats.CPUPercent
-						cm.MemoryUsage = stats.MemoryUsage
-						cm.MemoryLimit = stats.MemoryLimit
-						cm.NetworkRx = stats.NetworkRx
-						cm.NetworkTx = stats.NetworkTx
-					}
-				}
-
-				payload.Containers = append(payload.Containers, cm)
+				})
+				ids = append(ids, c.FullID)
 			}
+			d.collectContainerStats(payload.Containers, ids)
 			if len(payload.Containers) > 0 {
 				logger.Debug("[AGENT] reporting %d uruflow-managed containers", len(payload.Containers))
 			}
 		} else {
 			logger.Warn("[AGENT] failed to list containers: %v", err)
 		}
+
+		payload.DockerDiskUsage = d.dockerDiskUsage()
+	}
+	payload.ReclaimedBytes = d.takeReclaimed()
+	if err := d.checkDiskSpace(); err != nil {
+		payload.LowDiskForDeploys = true
+		logger.Debug("[AGENT] %v", err)
 	}
+	payload.Checkouts, payload.CheckoutInventory = d.checkoutReport()
 
 	msg, err := protocol.NewMessage(protocol.TypeMetrics, payload)
 	if err != nil {
@@ -401,38 +623,83 @@ func (d *Daemon) sendMetrics() {
 
 	if err := d.safeWrite(msg); err != nil {
 		logger.Error("[AGENT] failed to send metrics: %v", err)
+		if payload.CheckoutInventory {
+			d.resendCheckouts()
+		}
 	}
 }
 
 func (d *Daemon) handleCommand(cmd protocol.CommandPayload) {
 	logger.Info("[AGENT] received command: %s (ID: %s)", cmd.Type, cmd.ID)
 
-	ackMsg, _ := protocol.NewMessage(protocol.TypeCommandAck, protocol.CommandAckPayload{
-		CommandID: cmd.ID,
-		Status:    "received",
-	})
-	d.safeWrite(ackMsg)
+	d.sendAck(cmd.ID, protocol.AckReceived, "")
 
 	switch cmd.Type {
 	case "deploy":
 		d.handleDeploy(cmd)
+	case "teardown":
+		d.handleTeardown(cmd)
+	case "container_action":
+		d.handleContainerAction(cmd)
+	case "exec":
+		d.handleExec(cmd)
+	case "prefetch":
+		d.handlePrefetch(cmd)
+	case "drift_check":
+		name, _ := cmd.Payload["name"].(string)
+		d.checkDrift(name)
+		d.sendCommandDone(cmd.ID, "success", 0, "")
 	default:
 		logger.Warn("[AGENT] unknown command type: %s", cmd.Type)
 		d.sendCommandDone(cmd.ID, "failed", 1, fmt.Sprintf("unknown command type: %s", cmd.Type))
 	}
 }
 
+func (d *Daemon) sendAck(commandID, status, detail string) {
+	msg, _ := protocol.NewMessage(protocol.TypeCommandAck, protocol.CommandAckPayload{
+		CommandID: commandID,
+		Status:    status,
+		Detail:    detail,
+	})
+	d.safeWrite(msg)
+}
+
+func queuedBehind(ahead int, behind string) string {
+	if behind == "" {
+		return fmt.Sprintf("queued behind %d deployment(s)", ahead)
+	}
+	if ahead > 1 {
+		return fmt.Sprintf("queued behind %s (+%d)", behind, ahead-1)
+	}
+	return "queued behind " + behind
+}
+
 func (d *Daemon) handleDeploy(cmd protocol.CommandPayload) {
 	payloadBytes, _ := json.Marshal(cmd.Payload)
 	var deployPayload struct {
-		URL         string `json:"url"`
-		Name        string `json:"name"`
-		Branch      string `json:"branch"`
-		Commit      string `json:"commit"`
-		Path        string `json:"path"`
-		BuildSystem string `json:"build_system"`
-		BuildFile   string `json:"build_file"`
-		BuildCmd    string `json:"build_cmd"`
+		URL          string            `json:"url"`
+		Name         string            `json:"name"`
+		Branch       string            `json:"branch"`
+		Tag          string            `json:"tag"`
+		Commit       string            `json:"commit"`
+		VerifyBranch bool              `json:"verify_branch"`
+		Path         string            `json:"path"`
+		BuildSystem  string            `json:"build_system"`
+		BuildFile    string            `json:"build_file"`
+		BuildCmd     string            `json:"build_cmd"`
+		Image        string            `json:"image"`
+		Container    string            `json:"container"`
+		Ports        []string          `json:"ports"`
+		NoCache      bool              `json:"no_cache"`
+		Builder      string            `json:"builder"`
+		PreDeploy    string            `json:"pre_deploy"`
+		PostDeploy   string            `json:"post_deploy"`
+		Strategy     string            `json:"strategy"`
+		KeepReleases int               `json:"keep_releases"`
+		Env          map[string]string `json:"env"`
+		Credential   string            `json:"credential"`
+		HealthCheck  *healthCheck      `json:"health_check"`
+		DryRun       bool              `json:"dry_run"`
 	}
 
 	if err := json.Unmarshal(payloadBytes, &deployPayload); err != nil {
@@ -445,16 +712,7 @@ func (d *Daemon) handleDeploy(cmd protocol.CommandPayload) {
 	if len(commitShort) > 7 {
 		commitShort = commitShort[:7]
 	}
-	logger.Info("[AGENT] starting deployment: repo=%s branch=%s commit=%s build_system=%s",
-		deployPayload.Name, deployPayload.Branch, commitShort, deployPayload.BuildSystem)
-
-	startMsg, _ := protocol.NewMessage(protocol.TypeCommandStart, protocol.CommandStartPayload{
-		CommandID: cmd.ID,
-		StartedAt: time.Now().Unix(),
-	})
-	d.safeWrite(startMsg)
-
-	d.deployer.OnLog(func(stream, line string) {
+	sendLog := func(stream, line string) {
 		logMsg, _ := protocol.NewMessage(protocol.TypeCommandLog, protocol.CommandLogPayload{
 			CommandID: cmd.ID,
 			Line:      line,
@@ -462,23 +720,136 @@ func (d *Daemon) handleDeploy(cmd protocol.CommandPayload) {
 			Timestamp: time.Now().Unix(),
 		})
 		d.safeWrite(logMsg)
+	}
+
+	timeout := deployTimeout
+	if deployPayload.HealthCheck != nil {
+		timeout += deployPayload.HealthCheck.timeout()
+	}
+	queueCtx, queueCancel := context.WithTimeout(d.abortCtx, timeout)
+	defer queueCancel()
+	d.cancelOnStop(queueCtx, queueCancel)
+
+	release, err := d.queue.acquire(queueCtx, deployPayload.Name, cmd.ID, func(ahead int, behind string) {
+		logger.Info("[AGENT] deployment %s queued behind %d deployment(s) of %s", cmd.ID, ahead, deployPayload.Name)
+		sendLog("stdout", fmt.Sprintf("› waiting for previous deployment to finish (%d ahead)", ahead))
+		d.sendAck(cmd.ID, protocol.AckQueued, queuedBehind(ahead, behind))
 	})
+	if err != nil {
+		logger.Warn("[AGENT] rejected deployment %s: %v", cmd.ID, err)
+		d.sendCommandDone(cmd.ID, "failed", 1, d.queueError(err, timeout))
+		return
+	}
+	defer release()
+	d.touchWorkdir(deployPayload.Name)
 
-	cfg := deploy.Config{
-		URL:         deployPayload.URL,
-		Name:        deployPayload.Name,
-		Branch:      deployPayload.Branch,
-		Commit:      deployPayload.Commit,
-		Path:        deployPayload.Path,
-		BuildSystem: deployPayload.BuildSystem,
-		BuildFile:   deployPayload.BuildFile,
-		BuildCmd:    deployPayload.BuildCmd,
+	releaseSlot, err := d.queue.acquireSlot(queueCtx, func(position int) {
+		logger.Info("[AGENT] deployment %s queued (position %d)", cmd.ID, position)
+		sendLog("stdout", fmt.Sprintf("› queued (position %d)", position))
+		d.sendAck(cmd.ID, protocol.AckQueued, fmt.Sprintf("waiting for a deploy slot (position %d)", position))
+	})
+	if err != nil {
+		logger.Warn("[AGENT] deployment %s left the queue: %v", cmd.ID, err)
+		d.sendCommandDone(cmd.ID, "failed", 1, d.queueError(err, timeout))
+		return
 	}
+	defer releaseSlot()
+	queueCancel()
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	ctx, cancel := context.WithTimeout(d.abortCtx, timeout)
 	defer cancel()
 
-	result, err := d.deployer.Execute(ctx, cfg)
+	if err := d.checkDiskSpace(); err != nil {
+		logger.Warn("[AGENT] refusing deployment %s: %v", cmd.ID, err)
+		sendLog("stderr", "› "+err.Error())
+		d.sendCommandDone(cmd.ID, "failed", 1, err.Error())
+		return
+	}
+
+	ref := "branch=" + deployPayload.Branch
+	if deployPayload.Tag != "" {
+		ref = "tag=" + deployPayload.Tag
+	}
+	logger.Info("[AGENT] starting deployment: repo=%s %s commit=%s build_system=%s dry_run=%v",
+		deployPayload.Name, ref, commitShort, deployPayload.BuildSystem, deployPayload.DryRun)
+
+	startMsg, _ := protocol.NewMessage(protocol.TypeCommandStart, protocol.CommandStartPayload{
+		CommandID: cmd.ID,
+		StartedAt: time.Now().Unix(),
+	})
+	d.safeWrite(startMsg)
+
+	sendStep := func(step deploy.Step) {
+		stepMsg, _ := protocol.NewMessage(protocol.TypeCommandStep, protocol.CommandStepPayload{
+			CommandID:  cmd.ID,
+			Step:       step.Name,
+			Status:     step.Status,
+			StartedAt:  step.StartedAt.Unix(),
+			DurationMs: step.Duration.Milliseconds(),
+		})
+		d.safeWrite(stepMsg)
+	}
+	deployer := d.deployer.WithLog(sendLog).WithStep(sendStep)
+
+	cfg := deploy.Config{
+		URL:          deployPayload.URL,
+		Name:         deployPayload.Name,
+		Branch:       deployPayload.Branch,
+		Tag:          deployPayload.Tag,
+		Commit:       deployPayload.Commit,
+		VerifyBranch: deployPayload.VerifyBranch,
+		Path:         deployPayload.Path,
+		BuildSystem:  deployPayload.BuildSystem,
+		BuildFile:    deployPayload.BuildFile,
+		BuildCmd:     deployPayload.BuildCmd,
+		Image:        deployPayload.Image,
+		Container:    deployPayload.Container,
+		Ports:        deployPayload.Ports,
+		NoCache:      deployPayload.NoCache,
+		Builder:      deployPayload.Builder,
+		PreDeploy:    deployPayload.PreDeploy,
+		PostDeploy:   deployPayload.PostDeploy,
+		Strategy:     deployPayload.Strategy,
+		KeepReleases: deployPayload.KeepReleases,
+		Env:          deployPayload.Env,
+		DryRun:       deployPayload.DryRun,
+	}
+	if deployPayload.Credential != "" {
+		cred, ok := d.cfg.Credentials[deployPayload.Credential]
+		if !ok {
+			msg := fmt.Sprintf("credential %q is not configured on this agent", deployPayload.Credential)
+			logger.Error("[AGENT] deployment %s: %s", cmd.ID, msg)
+			sendLog("stderr", "› "+msg)
+			d.sendCommandDone(cmd.ID, "failed", 1, msg)
+			return
+		}
+		cfg.Auth = &deploy.Auth{
+			SSHKeyFile: cred.SSHKeyFile,
+			SSHKey:     cred.SSHKey,
+			Username:   cred.Username,
+			Token:      cred.Token,
+		}
+	}
+
+	result, err := deployer.Execute(ctx, cfg)
+	if cfg.DryRun {
+		d.finishDryRun(ctx, deployer, cmd.ID, cfg, result, err)
+		return
+	}
+	if err == nil && deployPayload.HealthCheck != nil {
+		project := result.Project
+		if project == "" {
+			project = deploy.ContainerName(cfg)
+		}
+		start := time.Now()
+		sendStep(deploy.Step{Name: "verify", Status: deploy.StepRunning, StartedAt: start})
+		err = d.verifyHealth(ctx, deployPayload.HealthCheck, project, sendLog)
+		stepStatus := deploy.StepDone
+		if err != nil {
+			stepStatus = deploy.StepFailed
+		}
+		sendStep(deploy.Step{Name: "verify", Status: stepStatus, StartedAt: start, Duration: time.Since(start)})
+	}
 
 	status := "success"
 	exitCode := 0
@@ -486,14 +857,50 @@ func (d *Daemon) handleDeploy(cmd protocol.CommandPayload) {
 
 	if err != nil {
 		status = "failed"
-		exitCode = 1
-		output = err.Error()
+		exitCode = failedExitCode(ctx, result)
+		output = d.abortReason(err)
 		logger.Error("[AGENT] deployment %s failed: %v", cmd.ID, err)
+	} else if hookErr := deployer.PostDeploy(ctx, cfg, result); hookErr != nil {
+		status = string(models.DeployWarning)
+		output = hookErr.Error()
+		logger.Warn("[AGENT] deployment %s succeeded with warnings: %v", cmd.ID, hookErr)
 	} else {
 		logger.Info("[AGENT] deployment %s succeeded (duration: %v)", cmd.ID, result.Duration)
 	}
 
-	d.sendCommandDone(cmd.ID, status, exitCode, output)
+	done := protocol.CommandDonePayload{
+		CommandID: cmd.ID,
+		Status:    status,
+		ExitCode:  exitCode,
+		Output:    output,
+	}
+	if result != nil {
+		done.Commit = result.Commit
+		done.ChangeSummary = result.ChangeSummary
+		done.BuildFile = result.BuildFile
+		if result.Commit != "" && deployPayload.Tag == "" && deployPayload.BuildSystem != "image" {
+			d.recordCheckout(protocol.Checkout{
+				Repository: deployPayload.Name,
+				URL:        deployPayload.URL,
+				Branch:     deployPayload.Branch,
+				Commit:     result.Commit,
+				FetchedAt:  time.Now().Unix(),
+			})
+		}
+	}
+	if err == nil && result != nil {
+		done.ConfigHash = result.ConfigHash
+		project := result.Project
+		if project == "" {
+			project = deploy.ContainerName(cfg)
+		}
+		done.Containers = d.deployedContainers(project)
+		d.saveDeployState(deployPayload.Name, result)
+		d.sendArtifacts(cmd.ID, deployer.Artifacts(ctx, cfg, result))
+	}
+	deployer.LogSummary(result, status, exitCode)
+	d.sendDone(done)
+	go d.pruneAfterDeploy()
 
 	if result != nil && result.Commit != "" {
 		commitShort := result.Commit
@@ -505,11 +912,160 @@ func (d *Daemon) handleDeploy(cmd protocol.CommandPayload) {
 	}
 }
 
+func (d *Daemon) finishDryRun(ctx context.Context, deployer *deploy.Executor, id string, cfg deploy.Config, result *deploy.Result, err error) {
+	done := protocol.CommandDonePayload{CommandID: id, Status: string(models.DeployDryRun)}
+	if err != nil {
+		done.Status = "failed"
+		done.ExitCode = failedExitCode(ctx, result)
+		done.Output = d.abortReason(err)
+		logger.Warn("[AGENT] dry run %s failed: %v", id, err)
+	} else {
+		logger.Info("[AGENT] dry run %s completed (duration: %v)", id, result.Duration)
+	}
+	if result != nil {
+		done.Commit = result.Commit
+		done.ChangeSummary = result.ChangeSummary
+		done.BuildFile = result.BuildFile
+		if result.Commit != "" && cfg.Tag == "" && cfg.BuildSystem != "image" {
+			d.recordCheckout(protocol.Checkout{
+				Repository: cfg.Name,
+				URL:        cfg.URL,
+				Branch:     cfg.Branch,
+				Commit:     result.Commit,
+				FetchedAt:  time.Now().Unix(),
+			})
+		}
+	}
+	deployer.LogSummary(result, done.Status, done.ExitCode)
+	d.sendDone(done)
+}
+
+func (d *Daemon) handleTeardown(cmd protocol.CommandPayload) {
+	payloadBytes, _ := json.Marshal(cmd.Payload)
+	var payload struct {
+		Name        string `json:"name"`
+		Path        string `json:"path"`
+		BuildSystem string `json:"build_system"`
+		BuildFile   string `json:"build_file"`
+		Container   string `json:"container"`
+		Strategy    string `json:"strategy"`
+		RemoveDir   bool   `json:"remove_dir"`
+	}
+	if err := json.Unmarshal(payloadBytes, &payload); err != nil || payload.Name == "" {
+		d.sendCommandDone(cmd.ID, "failed", 1, "invalid teardown payload")
+		return
+	}
+
+	release, err := d.queue.acquire(d.abortCtx, payload.Name, cmd.ID, func(ahead int, behind string) {
+		d.sendAck(cmd.ID, protocol.AckQueued, queuedBehind(ahead, behind))
+	})
+	if err != nil {
+		d.sendCommandDone(cmd.ID, "failed", 1, d.abortReason(err))
+		return
+	}
+	defer release()
+
+	logger.Info("[AGENT] tearing down %s (remove_dir=%v)", payload.Name, payload.RemoveDir)
+
+	startMsg, _ := protocol.NewMessage(protocol.TypeCommandStart, protocol.CommandStartPayload{
+		CommandID: cmd.ID,
+		StartedAt: time.Now().Unix(),
+	})
+	d.safeWrite(startMsg)
+
+	deployer := d.deployer.WithLog(func(stream, line string) {
+		logMsg, _ := protocol.NewMessage(protocol.TypeCommandLog, protocol.CommandLogPayload{
+			CommandID: cmd.ID,
+			Line:      line,
+			Stream:    stream,
+			Timestamp: time.Now().Unix(),
+		})
+		d.safeWrite(logMsg)
+	})
+
+	ctx, cancel := context.WithTimeout(d.abortCtx, 5*time.Minute)
+	defer cancel()
+
+	err = deployer.Teardown(ctx, deploy.Config{
+		Name:        payload.Name,
+		Path:        payload.Path,
+		BuildSystem: payload.BuildSystem,
+		BuildFile:   payload.BuildFile,
+		Container:   payload.Container,
+		Strategy:    payload.Strategy,
+	}, payload.RemoveDir)
+	if err != nil {
+		logger.Error("[AGENT] teardown of %s failed: %v", payload.Name, err)
+		d.sendCommandDone(cmd.ID, "failed", 1, d.abortReason(err))
+		return
+	}
+
+	d.removeDeployState(payload.Name)
+	if payload.RemoveDir {
+		d.forgetWorkdir(payload.Name)
+		d.forgetCheckout(payload.Name)
+	}
+	d.sendCommandDone(cmd.ID, "success", 0, "")
+	go d.sendMetrics()
+}
+
+func (d *Daemon) handleContainerAction(cmd protocol.CommandPayload) {
+	containerID, _ := cmd.Payload["container_id"].(string)
+	action, _ := cmd.Payload["action"].(string)
+
+	switch action {
+	case "start", "stop", "restart":
+	default:
+		d.sendCommandDone(cmd.ID, "failed", 1, fmt.Sprintf("unsupported container action: %s", action))
+		return
+	}
+
+	if d.docker == nil {
+		d.sendCommandDone(cmd.ID, "failed", 1, "docker is not available on this agent")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	managed, err := d.docker.IsUruflowManaged(ctx, containerID)
+	if err != nil {
+		d.sendCommandDone(cmd.ID, "failed", 1, fmt.Sprintf("inspect container: %v", err))
+		return
+	}
+	if !managed {
+		logger.Warn("[AGENT] rejected %s for unmanaged container %s", action, containerID)
+		d.sendCommandDone(cmd.ID, "failed", 1, fmt.Sprintf("container %s is not managed by uruflow", containerID))
+		return
+	}
+
+	logger.Info("[AGENT] container %s: %s", containerID, action)
+	if err := d.docker.ContainerAction(ctx, containerID, action); err != nil {
+		logger.Error("[AGENT] container %s %s failed: %v", containerID, action, err)
+		d.sendCommandDone(cmd.ID, "failed", 1, err.Error())
+		return
+	}
+
+	d.sendCommandDone(cmd.ID, "success", 0, fmt.Sprintf("container %s: %s ok", containerID, action))
+	go d.sendMetrics()
+}
+
 func (d *Daemon) handleContainerLogsRequest(req protocol.ContainerLogsRequestPayload) {
 	d.stopContainerStream(req.ContainerID)
 
+	if d.docker == nil {
+		d.refuseLogStream(req.ContainerID, "docker unavailable on agent")
+		return
+	}
+
 	ctx, cancel := context.WithCancel(context.Background())
 	d.streamMu.Lock()
+	if max := d.cfg.Docker.MaxLogStreams; max > 0 && len(d.streamCancels) >= max {
+		d.streamMu.Unlock()
+		cancel()
+		d.refuseLogStream(req.ContainerID, fmt.Sprintf("too many log streams (limit %d)", max))
+		return
+	}
 	d.streamCancels[req.ContainerID] = cancel
 	d.streamMu.Unlock()
 
@@ -547,6 +1103,16 @@ func (d *Daemon) handleContainerLogsRequest(req protocol.ContainerLogsRequestPay
 	d.stopContainerStream(req.ContainerID)
 }
 
+func (d *Daemon) refuseLogStream(containerID, reason string) {
+	logger.Warn("[AGENT] refusing log stream for %s: %s", containerID, reason)
+	msg, _ := protocol.NewMessage(protocol.TypeContainerLogsData, protocol.ContainerLogsDataPayload{
+		ContainerID: containerID,
+		Timestamp:   time.Now().Unix(),
+		Error:       reason,
+	})
+	d.safeWrite(msg)
+}
+
 func (d *Daemon) stopContainerStream(containerID string) {
 	d.streamMu.Lock()
 	defer d.streamMu.Unlock()
@@ -561,24 +1127,62 @@ func (d *Daemon) stopContainerStream(containerID string) {
 	}
 }
 
-func (d *Daemon) sendCommandDone(cmdID, status string, exitCode int, output string) {
-	logger.Debug("[AGENT] sending command done: id=%s status=%s exit_code=%d", cmdID, status, exitCode)
+func failedExitCode(ctx context.Context, result *deploy.Result) int {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return deploy.ExitTimeout
+	}
+	if result != nil && result.ExitCode != 0 {
+		return result.ExitCode
+	}
+	return 1
+}
 
-	doneMsg, _ := protocol.NewMessage(protocol.TypeCommandDone, protocol.CommandDonePayload{
+func (d *Daemon) sendCommandDone(cmdID, status string, exitCode int, output string) {
+	d.sendDone(protocol.CommandDonePayload{
 		CommandID: cmdID,
 		Status:    status,
 		ExitCode:  exitCode,
 		Output:    output,
 	})
+}
+
+func (d *Daemon) sendDone(done protocol.CommandDonePayload) {
+	logger.Debug("[AGENT] sending command done: id=%s status=%s exit_code=%d", done.CommandID, done.Status, done.ExitCode)
+
+	doneMsg, _ := protocol.NewMessage(protocol.TypeCommandDone, done)
 	d.safeWrite(doneMsg)
 }
 
+func (d *Daemon) sendArtifacts(commandID string, artifacts []deploy.Artifact) {
+	for _, a := range artifacts {
+		chunks := deploy.ArtifactChunks(a.Data)
+		for i, chunk := range chunks {
+			msg, err := protocol.NewMessage(protocol.TypeCommandArtifact, protocol.CommandArtifactPayload{
+				CommandID: commandID,
+				Name:      a.Name,
+				Seq:       i,
+				Final:     i == len(chunks)-1,
+				Truncated: a.Truncated,
+				Data:      chunk,
+			})
+			if err != nil {
+				logger.Warn("[AGENT] artifact %s: %v", a.Name, err)
+				break
+			}
+			d.safeWrite(msg)
+		}
+		logger.Debug("[AGENT] sent artifact %s for %s (%d bytes, %d chunks)", a.Name, commandID, len(a.Data), len(chunks))
+	}
+}
+
 func (d *Daemon) disconnect() {
 	if d.conn != nil {
 		logger.Info("[AGENT] disconnecting from server")
 		d.safeWrite(protocol.Disconnect())
+		d.writeMu.Lock()
 		d.conn.Close()
 		d.conn = nil
+		d.writeMu.Unlock()
 	}
 
 	d.streamMu.Lock()
@@ -617,42 +1221,15 @@ func (d *Daemon) removePid() {
 	}
 }
 
-func IsRunning(pidFile string) (bool, int) {
+func readPid(pidFile string) (int, error) {
 	data, err := os.ReadFile(pidFile)
 	if err != nil {
-		return false, 0
+		return 0, fmt.Errorf("read pid file: %w", err)
 	}
 
-	pid, err := strconv.Atoi(string(data))
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
 	if err != nil {
-		return false, 0
+		return 0, fmt.Errorf("parse pid: %w", err)
 	}
-
-	process, err := os.FindProcess(pid)
-	if err != nil {
-		return false, 0
-	}
-
-	err = process.Signal(syscall.Signal(0))
-	return err == nil, pid
-}
-
-func Stop(pidFile string) error {
-	data, err := os.ReadFile(pidFile)
-	if err != nil {
-		return fmt.Errorf("read pid file: %w", err)
-	}
-
-	pid, err := strconv.Atoi(string(data))
-	if err != nil {
-		return fmt.Errorf("parse pid: %w", err)
-	}
-
-	process, err := os.FindProcess(pid)
-	if err != nil {
-		return fmt.Errorf("find process: %w", err)
-	}
-
-	logger.Info("[AGENT] sending SIGTERM to process %d", pid)
-	return process.Signal(syscall.SIGTERM)
+	return pid, nil
 }
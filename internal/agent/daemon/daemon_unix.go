@@ -0,0 +1,63 @@
+//go:build !windows
+
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+
+package daemon
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+
+	"github.com/urustack/uruflow/pkg/logger"
+)
+
+func IsRunning(pidFile string) (bool, int) {
+	pid, err := readPid(pidFile)
+	if err != nil {
+		return false, 0
+	}
+
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false, 0
+	}
+
+	err = process.Signal(syscall.Signal(0))
+	return err == nil, pid
+}
+
+func Stop(pidFile string) error {
+	pid, err := readPid(pidFile)
+	if err != nil {
+		return err
+	}
+
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return fmt.Errorf("find process: %w", err)
+	}
+
+	logger.Info("[AGENT] sending SIGTERM to process %d", pid)
+	return process.Signal(syscall.SIGTERM)
+}
+
+func DetachAttr() *syscall.SysProcAttr {
+	return &syscall.SysProcAttr{Setsid: true}
+}
@@ -0,0 +1,91 @@
+//go:build !windows
+
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+
+package daemon
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"syscall"
+	"testing"
+
+	"golang.org/x/sys/unix"
+)
+
+func writePidFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "agent.pid")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestIsRunningReadsPidFile(t *testing.T) {
+	if running, pid := IsRunning(writePidFile(t, strconv.Itoa(os.Getpid())+"\n")); !running || pid != os.Getpid() {
+		t.Fatalf("IsRunning(own pid) = %v, %d", running, pid)
+	}
+	if running, pid := IsRunning(filepath.Join(t.TempDir(), "missing.pid")); running || pid != 0 {
+		t.Fatalf("IsRunning(missing file) = %v, %d", running, pid)
+	}
+	if running, pid := IsRunning(writePidFile(t, "not a pid")); running || pid != 0 {
+		t.Fatalf("IsRunning(garbage) = %v, %d", running, pid)
+	}
+}
+
+func TestStopTerminatesDetachedProcess(t *testing.T) {
+	cmd := exec.Command("sleep", "30")
+	cmd.SysProcAttr = DetachAttr()
+	if err := cmd.Start(); err != nil {
+		t.Skipf("start sleep: %v", err)
+	}
+	t.Cleanup(func() { cmd.Process.Kill() })
+
+	pid := cmd.Process.Pid
+	if sid, err := unix.Getsid(pid); err != nil || sid != pid {
+		t.Fatalf("detached process session = %d, %v, want its own session %d", sid, err, pid)
+	}
+
+	pidFile := writePidFile(t, strconv.Itoa(pid))
+	if running, got := IsRunning(pidFile); !running || got != pid {
+		t.Fatalf("IsRunning = %v, %d, want running %d", running, got, pid)
+	}
+	if err := Stop(pidFile); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+
+	err := cmd.Wait()
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		t.Fatalf("wait = %v, want the process terminated", err)
+	}
+	if status, ok := exitErr.Sys().(syscall.WaitStatus); !ok || status.Signal() != syscall.SIGTERM {
+		t.Fatalf("process ended with %v, want SIGTERM", exitErr)
+	}
+	if running, _ := IsRunning(pidFile); running {
+		t.Fatal("IsRunning reports a stopped process")
+	}
+	if err := Stop(filepath.Join(t.TempDir(), "missing.pid")); err == nil {
+		t.Fatal("Stop without a pid file succeeded")
+	}
+}
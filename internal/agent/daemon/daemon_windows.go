@@ -0,0 +1,82 @@
+//go:build windows
+
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+
+package daemon
+
+import (
+	"fmt"
+	"syscall"
+
+	"github.com/urustack/uruflow/pkg/logger"
+)
+
+const (
+	processQueryLimitedInformation = 0x1000
+	processTerminate               = 0x0001
+	stillActive                    = 259
+
+	createNewProcessGroup = 0x00000200
+	detachedProcess       = 0x00000008
+)
+
+func IsRunning(pidFile string) (bool, int) {
+	pid, err := readPid(pidFile)
+	if err != nil {
+		return false, 0
+	}
+
+	h, err := syscall.OpenProcess(processQueryLimitedInformation, false, uint32(pid))
+	if err != nil {
+		return false, 0
+	}
+	defer syscall.CloseHandle(h)
+
+	var code uint32
+	if err := syscall.GetExitCodeProcess(h, &code); err != nil {
+		return false, 0
+	}
+	return code == stillActive, pid
+}
+
+func Stop(pidFile string) error {
+	pid, err := readPid(pidFile)
+	if err != nil {
+		return err
+	}
+
+	h, err := syscall.OpenProcess(processTerminate, false, uint32(pid))
+	if err != nil {
+		return fmt.Errorf("open process: %w", err)
+	}
+	defer syscall.CloseHandle(h)
+
+	logger.Info("[AGENT] terminating process %d", pid)
+	if err := syscall.TerminateProcess(h, 1); err != nil {
+		return fmt.Errorf("terminate process: %w", err)
+	}
+	return nil
+}
+
+func DetachAttr() *syscall.SysProcAttr {
+	return &syscall.SysProcAttr{
+		CreationFlags: createNewProcessGroup | detachedProcess,
+		HideWindow:    true,
+	}
+}
@@ -0,0 +1,81 @@
+//go:build windows
+
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+
+package daemon
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+func writePidFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "agent.pid")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestIsRunningReadsPidFile(t *testing.T) {
+	if running, pid := IsRunning(writePidFile(t, strconv.Itoa(os.Getpid())+"\r\n")); !running || pid != os.Getpid() {
+		t.Fatalf("IsRunning(own pid) = %v, %d", running, pid)
+	}
+	if running, pid := IsRunning(filepath.Join(t.TempDir(), "missing.pid")); running || pid != 0 {
+		t.Fatalf("IsRunning(missing file) = %v, %d", running, pid)
+	}
+	if running, pid := IsRunning(writePidFile(t, "not a pid")); running || pid != 0 {
+		t.Fatalf("IsRunning(garbage) = %v, %d", running, pid)
+	}
+}
+
+func TestStopTerminatesDetachedProcess(t *testing.T) {
+	cmd := exec.Command("cmd", "/c", "ping -n 30 127.0.0.1 >NUL")
+	cmd.SysProcAttr = DetachAttr()
+	if err := cmd.Start(); err != nil {
+		t.Skipf("start ping: %v", err)
+	}
+	t.Cleanup(func() { cmd.Process.Kill() })
+
+	pid := cmd.Process.Pid
+	pidFile := writePidFile(t, strconv.Itoa(pid))
+	if running, got := IsRunning(pidFile); !running || got != pid {
+		t.Fatalf("IsRunning = %v, %d, want running %d", running, got, pid)
+	}
+	if err := Stop(pidFile); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+
+	err := cmd.Wait()
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) || exitErr.ExitCode() != 1 {
+		t.Fatalf("wait = %v, want exit code 1 from TerminateProcess", err)
+	}
+	if running, _ := IsRunning(pidFile); running {
+		t.Fatal("IsRunning reports a stopped process")
+	}
+	if err := Stop(filepath.Join(t.TempDir(), "missing.pid")); err == nil {
+		t.Fatal("Stop without a pid file succeeded")
+	}
+}
@@ -0,0 +1,71 @@
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+
+package daemon
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/urustack/uruflow/internal/tcp/protocol"
+	"github.com/urustack/uruflow/pkg/logger"
+)
+
+const dockerDiskInterval = 5 * time.Minute
+
+type diskUsageCache struct {
+	mu         sync.Mutex
+	at         time.Time
+	refreshing bool
+	usage      *protocol.DockerDiskUsage
+}
+
+func (d *Daemon) dockerDiskUsage() *protocol.DockerDiskUsage {
+	c := &d.dockerDisk
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.refreshing && (c.at.IsZero() || time.Since(c.at) >= dockerDiskInterval) {
+		c.refreshing = true
+		c.at = time.Now()
+		go d.refreshDockerDiskUsage()
+	}
+	return c.usage
+}
+
+func (d *Daemon) refreshDockerDiskUsage() {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	usage, err := d.docker.GetDiskUsage(ctx)
+
+	c := &d.dockerDisk
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.refreshing = false
+	if err != nil {
+		logger.Warn("[AGENT] failed to read docker disk usage: %v", err)
+		return
+	}
+	c.usage = &protocol.DockerDiskUsage{
+		Images:     usage.Images,
+		Containers: usage.Containers,
+		Volumes:    usage.Volumes,
+		BuildCache: usage.BuildCache,
+	}
+}
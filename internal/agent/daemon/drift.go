@@ -0,0 +1,218 @@
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/urustack/uruflow/internal/agent/deploy"
+	"github.com/urustack/uruflow/internal/tcp/protocol"
+	"github.com/urustack/uruflow/pkg/logger"
+)
+
+type deployState struct {
+	Name        string            `json:"name"`
+	RepoDir     string            `json:"repo_dir"`
+	Project     string            `json:"project"`
+	ComposeFile string            `json:"compose_file"`
+	ConfigHash  string            `json:"config_hash"`
+	Env         map[string]string `json:"env,omitempty"`
+	Commit      string            `json:"commit"`
+	Images      map[string]string `json:"images"`
+	DeployedAt  time.Time         `json:"deployed_at"`
+}
+
+func (d *Daemon) stateDir() string {
+	return filepath.Join(d.cfg.DataDir, "state")
+}
+
+func (d *Daemon) saveDeployState(name string, result *deploy.Result) {
+	if result.Project == "" {
+		return
+	}
+
+	state := deployState{
+		Name:        name,
+		RepoDir:     result.RepoDir,
+		Project:     result.Project,
+		ComposeFile: result.ComposeFile,
+		ConfigHash:  result.ConfigHash,
+		Env:         result.Env,
+		Commit:      result.Commit,
+		Images:      make(map[string]string),
+		DeployedAt:  time.Now(),
+	}
+
+	if d.docker != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		containers, err := d.docker.ListContainers(ctx)
+		cancel()
+		if err == nil {
+			for _, c := range containers {
+				if c.Project == result.Project {
+					state.Images[c.Name] = c.ImageID
+				}
+			}
+		}
+	}
+
+	if err := os.MkdirAll(d.stateDir(), 0755); err != nil {
+		logger.Warn("[AGENT] failed to create state directory: %v", err)
+		return
+	}
+
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return
+	}
+	path := filepath.Join(d.stateDir(), name+".json")
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		logger.Warn("[AGENT] failed to save deploy state for %s: %v", name, err)
+		return
+	}
+	os.Chmod(path, 0600)
+}
+
+func (d *Daemon) removeDeployState(name string) {
+	err := os.Remove(filepath.Join(d.stateDir(), name+".json"))
+	if err != nil && !os.IsNotExist(err) {
+		logger.Warn("[AGENT] failed to remove deploy state for %s: %v", name, err)
+	}
+}
+
+func (d *Daemon) loadDeployStates() []deployState {
+	files, err := filepath.Glob(filepath.Join(d.stateDir(), "*.json"))
+	if err != nil {
+		return nil
+	}
+
+	var states []deployState
+	for _, f := range files {
+		data, err := os.ReadFile(f)
+		if err != nil {
+			continue
+		}
+		var st deployState
+		if err := json.Unmarshal(data, &st); err != nil {
+			logger.Warn("[AGENT] ignoring unreadable deploy state %s: %v", f, err)
+			continue
+		}
+		states = append(states, st)
+	}
+	return states
+}
+
+func (d *Daemon) checkDrift(repoName string) {
+	for _, st := range d.loadDeployStates() {
+		if repoName != "" && st.Name != repoName {
+			continue
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		findings := d.driftFindings(ctx, st)
+		cancel()
+
+		if len(findings) > 0 {
+			logger.Warn("[AGENT] drift detected in %s: %d findings", st.Name, len(findings))
+		} else {
+			logger.Debug("[AGENT] no drift in %s", st.Name)
+		}
+
+		msg, err := protocol.NewMessage(protocol.TypeDriftReport, protocol.DriftReportPayload{
+			Repository: st.Name,
+			Findings:   findings,
+			CheckedAt:  time.Now().Unix(),
+		})
+		if err != nil {
+			continue
+		}
+		d.safeWrite(msg)
+	}
+}
+
+func (d *Daemon) driftFindings(ctx context.Context, st deployState) []string {
+	var findings []string
+
+	if st.ConfigHash != "" && st.ComposeFile != "" {
+		hash, err := d.deployer.ComposeConfigHash(ctx, st.RepoDir, st.Project, st.ComposeFile, st.Env)
+		if err != nil {
+			findings = append(findings, fmt.Sprintf("compose config unreadable: %v", err))
+		} else if hash != st.ConfigHash {
+			findings = append(findings, fmt.Sprintf("compose config changed (%s → %s)", short(st.ConfigHash), short(hash)))
+		}
+	}
+
+	if d.docker == nil {
+		return findings
+	}
+
+	containers, err := d.docker.ListContainers(ctx)
+	if err != nil {
+		return findings
+	}
+
+	running := make(map[string]string)
+	for _, c := range containers {
+		if c.Project == st.Project {
+			running[c.Name] = c.ImageID
+		}
+	}
+
+	names := make([]string, 0, len(st.Images))
+	for name := range st.Images {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		expected := st.Images[name]
+		actual, ok := running[name]
+		if !ok {
+			findings = append(findings, fmt.Sprintf("container %s is missing", name))
+			continue
+		}
+		if actual != expected {
+			findings = append(findings, fmt.Sprintf("container %s image changed (%s → %s)", name, short(expected), short(actual)))
+		}
+	}
+
+	for name := range running {
+		if _, ok := st.Images[name]; !ok {
+			findings = append(findings, fmt.Sprintf("unexpected container %s", name))
+		}
+	}
+
+	return findings
+}
+
+func short(hash string) string {
+	if len(hash) > 7 && hash[:7] == "sha256:" {
+		hash = hash[7:]
+	}
+	if len(hash) > 12 {
+		return hash[:12]
+	}
+	return hash
+}
@@ -0,0 +1,142 @@
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+
+package daemon
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/urustack/uruflow/internal/models"
+	"github.com/urustack/uruflow/internal/tcp/protocol"
+	"github.com/urustack/uruflow/pkg/logger"
+)
+
+const execTimeout = 2 * time.Minute
+
+func (d *Daemon) execNames() []string {
+	names := make([]string, 0, len(d.cfg.Exec))
+	for name := range d.cfg.Exec {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (d *Daemon) handleExec(cmd protocol.CommandPayload) {
+	name, _ := cmd.Payload["name"].(string)
+	template, ok := d.cfg.Exec[name]
+	if !ok {
+		logger.Warn("[AGENT] rejected exec %q: not in the local whitelist", name)
+		d.sendCommandDone(cmd.ID, "failed", 1, fmt.Sprintf("exec command %q is not allowed on this agent", name))
+		return
+	}
+
+	params := make(map[string]string)
+	if raw, ok := cmd.Payload["params"].(map[string]interface{}); ok {
+		for k, v := range raw {
+			s, ok := v.(string)
+			if !ok {
+				d.sendCommandDone(cmd.ID, "failed", 1, fmt.Sprintf("parameter %q must be a string", k))
+				return
+			}
+			params[k] = s
+		}
+	}
+	argv, err := models.ExpandExec(template, params)
+	if err != nil {
+		d.sendCommandDone(cmd.ID, "failed", 1, err.Error())
+		return
+	}
+
+	logger.Info("[AGENT] running exec %s: %s", name, strings.Join(argv, " "))
+
+	startMsg, _ := protocol.NewMessage(protocol.TypeCommandStart, protocol.CommandStartPayload{
+		CommandID: cmd.ID,
+		StartedAt: time.Now().Unix(),
+	})
+	d.safeWrite(startMsg)
+
+	sendLog := func(stream, line string) {
+		logMsg, _ := protocol.NewMessage(protocol.TypeCommandLog, protocol.CommandLogPayload{
+			CommandID: cmd.ID,
+			Line:      line,
+			Stream:    stream,
+			Timestamp: time.Now().Unix(),
+		})
+		d.safeWrite(logMsg)
+	}
+
+	ctx, cancel := context.WithTimeout(d.abortCtx, execTimeout)
+	defer cancel()
+
+	exitCode, err := runExec(ctx, argv, sendLog)
+	switch {
+	case ctx.Err() == context.DeadlineExceeded:
+		d.sendCommandDone(cmd.ID, "failed", 1, fmt.Sprintf("timed out after %v", execTimeout))
+	case d.abortCtx.Err() != nil:
+		d.sendCommandDone(cmd.ID, "failed", 1, errShuttingDown)
+	case err != nil:
+		d.sendCommandDone(cmd.ID, "failed", 1, err.Error())
+	case exitCode != 0:
+		d.sendCommandDone(cmd.ID, "failed", exitCode, fmt.Sprintf("exited with status %d", exitCode))
+	default:
+		d.sendCommandDone(cmd.ID, "success", 0, "")
+	}
+	logger.Info("[AGENT] exec %s (%s) finished with exit code %d", name, cmd.ID, exitCode)
+}
+
+func runExec(ctx context.Context, argv []string, sendLog func(stream, line string)) (int, error) {
+	c := exec.CommandContext(ctx, argv[0], argv[1:]...)
+	stdout, _ := c.StdoutPipe()
+	stderr, _ := c.StderrPipe()
+
+	if err := c.Start(); err != nil {
+		return -1, err
+	}
+
+	done := make(chan struct{})
+	scan := func(r io.Reader, stream string) {
+		scanner := bufio.NewScanner(r)
+		for scanner.Scan() {
+			sendLog(stream, scanner.Text())
+		}
+		done <- struct{}{}
+	}
+	go scan(stdout, "stdout")
+	go scan(stderr, "stderr")
+	<-done
+	<-done
+
+	err := c.Wait()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode(), nil
+	}
+	if err != nil {
+		return -1, err
+	}
+	return 0, nil
+}
@@ -0,0 +1,159 @@
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+
+package daemon
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/urustack/uruflow/internal/agent/config"
+)
+
+const (
+	failoverMaxBackoff  = time.Minute
+	primaryProbeTimeout = 5 * time.Second
+)
+
+type endpointState struct {
+	failures int
+	retryAt  time.Time
+}
+
+type failoverState struct {
+	mu      sync.Mutex
+	states  []endpointState
+	current int
+}
+
+func (d *Daemon) endpoints() []config.Endpoint {
+	return d.cfg.Server.Targets()
+}
+
+func (f *failoverState) init(n int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(f.states) != n {
+		f.states = make([]endpointState, n)
+		f.current = -1
+	}
+}
+
+func (f *failoverState) next() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	now := time.Now()
+	best := 0
+	for i, st := range f.states {
+		if !st.retryAt.After(now) {
+			return i
+		}
+		if st.retryAt.Before(f.states[best].retryAt) {
+			best = i
+		}
+	}
+	return best
+}
+
+func (f *failoverState) wait() time.Duration {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var earliest time.Time
+	for _, st := range f.states {
+		if earliest.IsZero() || st.retryAt.Before(earliest) {
+			earliest = st.retryAt
+		}
+	}
+	return max(time.Until(earliest), 0)
+}
+
+func (f *failoverState) failed(i int, base time.Duration) time.Duration {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	st := &f.states[i]
+	st.failures++
+	delay := base << min(st.failures-1, 6)
+	if delay > failoverMaxBackoff {
+		delay = max(failoverMaxBackoff, base)
+	}
+	st.retryAt = time.Now().Add(delay)
+	return delay
+}
+
+func (f *failoverState) connected(i int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.states[i] = endpointState{}
+	f.current = i
+}
+
+func (f *failoverState) retryNow(i int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.states[i] = endpointState{}
+}
+
+func (f *failoverState) disconnected() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.current = -1
+}
+
+func (f *failoverState) onStandby() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.current > 0
+}
+
+func (d *Daemon) primaryReachable() bool {
+	targets := d.endpoints()
+	if len(targets) == 0 {
+		return false
+	}
+	conn, err := d.dial(targets[0].Addr(), primaryProbeTimeout)
+	if err != nil {
+		return false
+	}
+	conn.Close()
+	return true
+}
+
+func serverStateFile(cfg *config.Config) string {
+	return filepath.Join(cfg.DataDir, "state", "server")
+}
+
+func (d *Daemon) saveConnectedServer(addr string) {
+	path := serverStateFile(d.cfg)
+	os.MkdirAll(filepath.Dir(path), 0755)
+	os.WriteFile(path, []byte(addr), 0644)
+}
+
+func (d *Daemon) clearConnectedServer() {
+	os.Remove(serverStateFile(d.cfg))
+}
+
+func ConnectedServer(cfg *config.Config) string {
+	data, err := os.ReadFile(serverStateFile(cfg))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
@@ -0,0 +1,202 @@
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+
+package daemon
+
+import (
+	"net"
+	"path/filepath"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/urustack/uruflow/internal/agent/config"
+	"github.com/urustack/uruflow/internal/tcp/protocol"
+)
+
+type fakeSession struct {
+	writer *protocol.Writer
+	pongs  chan time.Time
+}
+
+func fakeServer(t *testing.T, ln net.Listener) <-chan *fakeSession {
+	t.Helper()
+	t.Cleanup(func() { ln.Close() })
+	sessions := make(chan *fakeSession, 4)
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				reader, writer := protocol.NewReader(conn), protocol.NewWriter(conn)
+				msg, err := reader.ReadWithTimeout(5 * time.Second)
+				if err != nil || msg.Type != protocol.TypeAuth {
+					return
+				}
+				ok, _ := protocol.NewMessage(protocol.TypeAuthOK, protocol.AuthOKPayload{AgentID: "a1", Name: "web"})
+				if writer.Write(ok) != nil {
+					return
+				}
+				s := &fakeSession{writer: writer, pongs: make(chan time.Time, 16)}
+				sessions <- s
+				for {
+					msg, err := reader.Read()
+					if err != nil {
+						return
+					}
+					if msg.Type == protocol.TypePong {
+						s.pongs <- time.Now()
+					}
+				}
+			}()
+		}
+	}()
+	return sessions
+}
+
+func listenTCP(t *testing.T, addr string) net.Listener {
+	t.Helper()
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ln
+}
+
+func endpoint(t *testing.T, addr string) (string, int) {
+	t.Helper()
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n, _ := strconv.Atoi(port)
+	return host, n
+}
+
+func runFailoverDaemon(t *testing.T, primary, standby string) (*Daemon, *config.Config) {
+	t.Helper()
+	dir := t.TempDir()
+	cfg := config.Default()
+	cfg.Token = "test-token"
+	cfg.DataDir = filepath.Join(dir, "data")
+	cfg.PidFile = filepath.Join(dir, "agent.pid")
+	cfg.LogFile = filepath.Join(dir, "agent.log")
+	cfg.Docker.Enabled = false
+	cfg.Deploy.DriftCheckSec = 0
+	cfg.Server.Compression = false
+	cfg.Server.ReconnectSec = 1
+	cfg.Server.MetricsSec = 60
+	cfg.Server.PrimaryResetSec = 1
+	cfg.Server.Host, cfg.Server.Port = endpoint(t, primary)
+	host, port := endpoint(t, standby)
+	cfg.Server.Endpoints = []config.EndpointConfig{{Host: host, Port: port}}
+
+	d, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan error, 1)
+	go func() { done <- d.Run() }()
+	t.Cleanup(func() {
+		d.shutdown()
+		select {
+		case <-done:
+		case <-time.After(10 * time.Second):
+			t.Error("agent did not stop")
+		}
+	})
+	return d, cfg
+}
+
+func waitSession(t *testing.T, sessions <-chan *fakeSession, within time.Duration, what string) *fakeSession {
+	t.Helper()
+	select {
+	case s := <-sessions:
+		return s
+	case <-time.After(within):
+		t.Fatalf("agent never connected to the %s", what)
+		return nil
+	}
+}
+
+func waitConnected(t *testing.T, cfg *config.Config, addr string) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for ConnectedServer(cfg) != addr && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got := ConnectedServer(cfg); got != addr {
+		t.Fatalf("connected server = %q, want %s", got, addr)
+	}
+}
+
+func TestFailoverReturnsToPrimary(t *testing.T) {
+	reserved := listenTCP(t, "127.0.0.1:0")
+	primary := reserved.Addr().String()
+	reserved.Close()
+	standby := listenTCP(t, "127.0.0.1:0")
+	standbySessions := fakeServer(t, standby)
+
+	_, cfg := runFailoverDaemon(t, primary, standby.Addr().String())
+	waitSession(t, standbySessions, 5*time.Second, "standby")
+	waitConnected(t, cfg, standby.Addr().String())
+
+	primarySessions := fakeServer(t, listenTCP(t, primary))
+	waitSession(t, primarySessions, 10*time.Second, "primary once it came back")
+	waitConnected(t, cfg, primary)
+}
+
+func TestPrimaryProbeDoesNotBlockRunLoop(t *testing.T) {
+	var hang atomic.Bool
+	proxy := listen(t, func(conn net.Conn) {
+		defer conn.Close()
+		if hang.Load() {
+			time.Sleep(primaryProbeTimeout + time.Second)
+		}
+	})
+	t.Setenv("ALL_PROXY", "socks5://"+proxy)
+	t.Setenv("NO_PROXY", "localhost")
+
+	standby := listenTCP(t, "127.0.0.1:0")
+	sessions := fakeServer(t, standby)
+	_, port, _ := net.SplitHostPort(standby.Addr().String())
+	runFailoverDaemon(t, "127.0.0.1:1", net.JoinHostPort("localhost", port))
+
+	s := waitSession(t, sessions, 5*time.Second, "standby")
+	hang.Store(true)
+
+	for end := time.Now().Add(3 * time.Second); time.Now().Before(end); {
+		sent := time.Now()
+		if err := s.writer.Write(protocol.Ping()); err != nil {
+			t.Fatalf("ping: %v", err)
+		}
+		select {
+		case got := <-s.pongs:
+			if got.Sub(sent) > 500*time.Millisecond {
+				t.Fatalf("pong took %s while the primary probe was hanging", got.Sub(sent))
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatal("no pong while the primary probe was hanging")
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
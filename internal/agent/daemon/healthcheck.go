@@ -0,0 +1,190 @@
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/urustack/uruflow/internal/agent/docker"
+)
+
+const (
+	defaultHealthTimeout  = 60 * time.Second
+	defaultHealthInterval = 5 * time.Second
+)
+
+type healthCheck struct {
+	Container   string `json:"container"`
+	URL         string `json:"url"`
+	TimeoutSec  int    `json:"timeout_sec"`
+	IntervalSec int    `json:"interval_sec"`
+}
+
+type healthProbe func(ctx context.Context) (healthy bool, state string)
+
+func (h *healthCheck) timeout() time.Duration {
+	if h.TimeoutSec > 0 {
+		return time.Duration(h.TimeoutSec) * time.Second
+	}
+	return defaultHealthTimeout
+}
+
+func (h *healthCheck) interval() time.Duration {
+	if h.IntervalSec > 0 {
+		return time.Duration(h.IntervalSec) * time.Second
+	}
+	return defaultHealthInterval
+}
+
+func (d *Daemon) verifyHealth(ctx context.Context, hc *healthCheck, project string, log func(stream, line string)) error {
+	timeout, interval := hc.timeout(), hc.interval()
+
+	var probe healthProbe
+	target := hc.URL
+	if hc.URL != "" {
+		probe = probeURL(hc.URL, interval)
+	} else {
+		if d.docker == nil {
+			return fmt.Errorf("health check failed: docker unavailable on agent")
+		}
+		target = project
+		if hc.Container != "" {
+			target = hc.Container
+		}
+		probe = d.probeContainers(project, hc.Container)
+	}
+
+	log("stdout", fmt.Sprintf("› Verifying health of %s (timeout %s)", target, timeout))
+
+	checkCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	for {
+		healthy, state := probe(checkCtx)
+		if healthy {
+			log("stdout", fmt.Sprintf("› Healthy after %s: %s", time.Since(start).Round(time.Second), state))
+			return nil
+		}
+		log("stdout", fmt.Sprintf("› Waiting for health (%s/%s): %s",
+			time.Since(start).Round(time.Second), timeout, state))
+
+		select {
+		case <-checkCtx.Done():
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			log("stderr", fmt.Sprintf("› Health check timed out: %s", state))
+			return fmt.Errorf("health check failed after %s: %s", timeout, state)
+		case <-time.After(interval):
+		}
+	}
+}
+
+func (d *Daemon) probeContainers(project, name string) healthProbe {
+	restarts := make(map[string]int)
+
+	return func(ctx context.Context) (bool, string) {
+		containers, err := d.healthTargets(ctx, project, name)
+		if err != nil {
+			return false, fmt.Sprintf("list containers: %v", err)
+		}
+		if len(containers) == 0 {
+			return false, "no containers found"
+		}
+
+		healthy := true
+		states := make([]string, 0, len(containers))
+		for _, c := range containers {
+			prev, seen := restarts[c.FullID]
+			restarts[c.FullID] = c.RestartCount
+
+			state := c.State
+			ok := c.State == "running"
+			switch c.Health {
+			case "healthy":
+				state += "/healthy"
+			case "none", "":
+				ok = ok && seen && prev == c.RestartCount
+			default:
+				state += "/" + c.Health
+				ok = false
+			}
+			if c.RestartCount > 0 {
+				state += fmt.Sprintf(" (%d restarts)", c.RestartCount)
+			}
+			healthy = healthy && ok
+			states = append(states, c.Name+" "+state)
+		}
+		return healthy, strings.Join(states, ", ")
+	}
+}
+
+func (d *Daemon) healthTargets(ctx context.Context, project, name string) ([]docker.Container, error) {
+	containers, err := d.docker.GetContainersByComposeProject(ctx, project)
+	if err != nil {
+		return nil, err
+	}
+
+	match := project
+	if name != "" {
+		var matched []docker.Container
+		for _, c := range containers {
+			if c.Name == name || c.Service == name {
+				matched = append(matched, c)
+			}
+		}
+		containers = matched
+		match = name
+	}
+	if len(containers) > 0 {
+		return containers, nil
+	}
+
+	all, err := d.docker.ListContainers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range all {
+		if c.Name == match {
+			containers = append(containers, c)
+		}
+	}
+	return containers, nil
+}
+
+func probeURL(url string, interval time.Duration) healthProbe {
+	client := &http.Client{Timeout: interval}
+
+	return func(ctx context.Context) (bool, string) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return false, err.Error()
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return false, err.Error()
+		}
+		resp.Body.Close()
+		return resp.StatusCode < 400, fmt.Sprintf("HTTP %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,110 @@
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+
+package daemon
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/urustack/uruflow/pkg/helper"
+)
+
+func machineIDFile(dataDir string) string {
+	return filepath.Join(dataDir, "machine-id")
+}
+
+func loadMachineID(dataDir string) (string, error) {
+	if host := hostMachineID(); host != "" {
+		sum := sha256.Sum256([]byte("uruflow-agent:" + host))
+		return hex.EncodeToString(sum[:16]), nil
+	}
+	return storedMachineID(dataDir, hostFingerprint())
+}
+
+func storedMachineID(dataDir, fingerprint string) (string, error) {
+	path := machineIDFile(dataDir)
+	if data, err := os.ReadFile(path); err == nil {
+		id, stored, _ := strings.Cut(strings.TrimSpace(string(data)), "\n")
+		id, stored = strings.TrimSpace(id), strings.TrimSpace(stored)
+		if id != "" && (stored == "" || stored == fingerprint) {
+			if stored == "" {
+				writeMachineID(path, id, fingerprint)
+			}
+			return id, nil
+		}
+	}
+
+	id := helper.GenerateID() + helper.GenerateID()
+	if err := os.MkdirAll(dataDir, 0755); err != nil {
+		return "", fmt.Errorf("create data directory: %w", err)
+	}
+	if err := writeMachineID(path, id, fingerprint); err != nil {
+		return "", err
+	}
+	return id, nil
+}
+
+func writeMachineID(path, id, fingerprint string) error {
+	if err := os.WriteFile(path, []byte(id+"\n"+fingerprint+"\n"), 0644); err != nil {
+		return fmt.Errorf("write %s: %w", path, err)
+	}
+	return nil
+}
+
+func hostFingerprint() string {
+	hostname, _ := os.Hostname()
+	var mac string
+	if ifaces, err := net.Interfaces(); err == nil {
+		for _, iface := range ifaces {
+			if iface.Flags&net.FlagLoopback == 0 && len(iface.HardwareAddr) > 0 {
+				mac = iface.HardwareAddr.String()
+				break
+			}
+		}
+	}
+	sum := sha256.Sum256([]byte(hostname + "/" + mac))
+	return hex.EncodeToString(sum[:8])
+}
+
+func parseIOPlatformUUID(out string) string {
+	for _, line := range strings.Split(out, "\n") {
+		if !strings.Contains(line, `"IOPlatformUUID"`) {
+			continue
+		}
+		if _, value, ok := strings.Cut(line, "="); ok {
+			return strings.Trim(strings.TrimSpace(value), `"`)
+		}
+	}
+	return ""
+}
+
+func parseMachineGUID(out string) string {
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 3 && fields[0] == "MachineGuid" {
+			return fields[2]
+		}
+	}
+	return ""
+}
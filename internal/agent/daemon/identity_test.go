@@ -0,0 +1,75 @@
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+
+package daemon
+
+import (
+	"os"
+	"testing"
+)
+
+func TestStoredMachineIDFollowsFingerprint(t *testing.T) {
+	dir := t.TempDir()
+	first, err := storedMachineID(dir, "host-a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again, _ := storedMachineID(dir, "host-a"); again != first {
+		t.Fatalf("machine ID changed on the same host: %s -> %s", first, again)
+	}
+	if clone, _ := storedMachineID(dir, "host-b"); clone == first {
+		t.Fatal("a clone with a different hostname or MAC kept the machine ID")
+	}
+}
+
+func TestStoredMachineIDKeepsLegacyFile(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(machineIDFile(dir), []byte("legacy-id\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if id, _ := storedMachineID(dir, "host-a"); id != "legacy-id" {
+		t.Fatalf("machine ID = %s, want the stored legacy-id", id)
+	}
+	if id, _ := storedMachineID(dir, "host-b"); id == "legacy-id" {
+		t.Fatal("legacy machine ID survived a fingerprint change")
+	}
+}
+
+func TestParseIOPlatformUUID(t *testing.T) {
+	out := `+-o MacBookPro18,3  <class IOPlatformExpertDevice, id 0x100000112, registered, matched, active, busy 0 (149 ms), retain 41>
+    {
+      "IOPlatformSystemSleepPolicy" = <534c505402000a00>
+      "IOPlatformSerialNumber" = "C02XL0AAJGH5"
+      "IOPlatformUUID" = "8A1D2B53-4E2F-5C3A-9B1E-0F6C7D8E9A0B"
+      "model" = <"MacBookPro18,3">
+    }
+`
+	if got := parseIOPlatformUUID(out); got != "8A1D2B53-4E2F-5C3A-9B1E-0F6C7D8E9A0B" {
+		t.Fatalf("parseIOPlatformUUID = %q", got)
+	}
+	if got := parseIOPlatformUUID(""); got != "" {
+		t.Fatalf("parseIOPlatformUUID of empty output = %q", got)
+	}
+}
+
+func TestParseMachineGUID(t *testing.T) {
+	out := "\r\nHKEY_LOCAL_MACHINE\\SOFTWARE\\Microsoft\\Cryptography\r\n    MachineGuid    REG_SZ    3f1c2a9e-7b4d-4e8a-9c21-5d6e7f8a9b0c\r\n\r\n"
+	if got := parseMachineGUID(out); got != "3f1c2a9e-7b4d-4e8a-9c21-5d6e7f8a9b0c" {
+		t.Fatalf("parseMachineGUID = %q", got)
+	}
+}
@@ -0,0 +1,57 @@
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+
+package daemon
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/urustack/uruflow/internal/tcp/protocol"
+	"github.com/urustack/uruflow/pkg/logger"
+)
+
+func (d *Daemon) deployedContainers(project string) []protocol.DeployedContainer {
+	if d.docker == nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	containers, err := d.docker.ListContainers(ctx)
+	if err != nil {
+		logger.Warn("[AGENT] failed to list containers of %s: %v", project, err)
+		return nil
+	}
+
+	var deployed []protocol.DeployedContainer
+	for _, c := range containers {
+		if c.Project != project && c.Name != project {
+			continue
+		}
+		deployed = append(deployed, protocol.DeployedContainer{
+			Name:    c.Name,
+			Service: c.Service,
+			Image:   c.Image,
+			ImageID: c.ImageID,
+		})
+	}
+	sort.Slice(deployed, func(i, j int) bool { return deployed[i].Name < deployed[j].Name })
+	return deployed
+}
@@ -0,0 +1,119 @@
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+
+package daemon
+
+import (
+	"sync"
+
+	"github.com/urustack/uruflow/internal/tcp/protocol"
+)
+
+const (
+	outboxMaxMessages = 1000
+	outboxMaxBytes    = 5 * 1024 * 1024
+)
+
+type outbox struct {
+	mu      sync.Mutex
+	msgs    []*protocol.Message
+	size    int
+	dropped int
+}
+
+func bufferable(t protocol.MessageType) bool {
+	switch t {
+	case protocol.TypeCommandStart, protocol.TypeCommandLog, protocol.TypeCommandStep,
+		protocol.TypeCommandDone, protocol.TypeCommandArtifact, protocol.TypeDriftReport, protocol.TypeAgentEvent:
+		return true
+	}
+	return false
+}
+
+func evictionOrder(t protocol.MessageType) int {
+	switch t {
+	case protocol.TypeCommandLog:
+		return 0
+	case protocol.TypeCommandStart:
+		return 2
+	case protocol.TypeCommandDone:
+		return -1
+	}
+	return 1
+}
+
+func (o *outbox) push(msg *protocol.Message) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.msgs = append(o.msgs, msg)
+	o.size += len(msg.Payload)
+
+	o.trim()
+}
+
+func (o *outbox) trim() {
+	for len(o.msgs) > outboxMaxMessages || o.size > outboxMaxBytes {
+		if !o.evict() {
+			return
+		}
+	}
+}
+
+func (o *outbox) evict() bool {
+	idx, order := -1, 0
+	for i, m := range o.msgs {
+		rank := evictionOrder(m.Type)
+		if rank >= 0 && (idx < 0 || rank < order) {
+			idx, order = i, rank
+		}
+	}
+	if idx < 0 {
+		return false
+	}
+	o.size -= len(o.msgs[idx].Payload)
+	o.msgs = append(o.msgs[:idx], o.msgs[idx+1:]...)
+	o.dropped++
+	return true
+}
+
+func (o *outbox) drain() ([]*protocol.Message, int) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	msgs, dropped := o.msgs, o.dropped
+	o.msgs, o.size, o.dropped = nil, 0, 0
+	return msgs, dropped
+}
+
+func (o *outbox) requeue(msgs []*protocol.Message) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	for _, m := range msgs {
+		o.size += len(m.Payload)
+	}
+	o.msgs = append(msgs, o.msgs...)
+	o.trim()
+}
+
+func (o *outbox) len() int {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return len(o.msgs)
+}
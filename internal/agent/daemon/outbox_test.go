@@ -0,0 +1,69 @@
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+
+package daemon
+
+import (
+	"testing"
+
+	"github.com/urustack/uruflow/internal/tcp/protocol"
+)
+
+func outboxMessage(t *testing.T, typ protocol.MessageType, id string) *protocol.Message {
+	t.Helper()
+	msg, err := protocol.NewMessage(typ, protocol.CommandDonePayload{CommandID: id})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return msg
+}
+
+func TestOutboxEvictsLogsBeforeCompletions(t *testing.T) {
+	var o outbox
+	o.push(outboxMessage(t, protocol.TypeCommandStart, "start"))
+	o.push(outboxMessage(t, protocol.TypeCommandDone, "done"))
+	for i := 0; i < outboxMaxMessages; i++ {
+		o.push(outboxMessage(t, protocol.TypeCommandLog, "log"))
+	}
+
+	msgs, dropped := o.drain()
+	if dropped != 2 || len(msgs) != outboxMaxMessages {
+		t.Fatalf("kept %d, dropped %d; want %d kept, 2 dropped", len(msgs), dropped, outboxMaxMessages)
+	}
+	if msgs[0].Type != protocol.TypeCommandStart || msgs[1].Type != protocol.TypeCommandDone {
+		t.Fatalf("first messages = %s, %s; want start and done kept", msgs[0].Type, msgs[1].Type)
+	}
+}
+
+func TestOutboxNeverEvictsCompletions(t *testing.T) {
+	var o outbox
+	for i := 0; i < outboxMaxMessages+10; i++ {
+		o.push(outboxMessage(t, protocol.TypeCommandDone, "done"))
+	}
+	o.push(outboxMessage(t, protocol.TypeCommandStart, "start"))
+
+	msgs, dropped := o.drain()
+	if len(msgs) != outboxMaxMessages+10 || dropped != 1 {
+		t.Fatalf("kept %d, dropped %d; want every completion kept and the start dropped", len(msgs), dropped)
+	}
+	for _, m := range msgs {
+		if m.Type != protocol.TypeCommandDone {
+			t.Fatalf("kept %s, want only completions", m.Type)
+		}
+	}
+}
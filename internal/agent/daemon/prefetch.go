@@ -0,0 +1,236 @@
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/urustack/uruflow/internal/agent/deploy"
+	"github.com/urustack/uruflow/internal/tcp/protocol"
+	"github.com/urustack/uruflow/pkg/logger"
+)
+
+const prefetchTimeout = 30 * time.Minute
+
+type checkoutState struct {
+	mu          sync.Mutex
+	items       map[string]protocol.Checkout
+	dirty       bool
+	prefetching map[string]bool
+}
+
+func (d *Daemon) checkoutsFile() string {
+	return filepath.Join(d.stateDir(), "checkouts.json")
+}
+
+func (d *Daemon) loadCheckouts() {
+	if d.checkouts.items != nil {
+		return
+	}
+	d.checkouts.items = make(map[string]protocol.Checkout)
+	data, err := os.ReadFile(d.checkoutsFile())
+	if err != nil {
+		return
+	}
+	if err := json.Unmarshal(data, &d.checkouts.items); err != nil {
+		logger.Warn("[AGENT] ignoring unreadable checkout state: %v", err)
+	}
+}
+
+func (d *Daemon) saveCheckouts() {
+	data, err := json.MarshalIndent(d.checkouts.items, "", "  ")
+	if err != nil {
+		return
+	}
+	os.MkdirAll(d.stateDir(), 0755)
+	if err := os.WriteFile(d.checkoutsFile(), data, 0644); err != nil {
+		logger.Warn("[AGENT] failed to save checkout state: %v", err)
+	}
+}
+
+func (d *Daemon) recordCheckout(c protocol.Checkout) {
+	d.checkouts.mu.Lock()
+	defer d.checkouts.mu.Unlock()
+	d.loadCheckouts()
+	d.checkouts.items[c.Repository] = c
+	d.checkouts.dirty = true
+	d.saveCheckouts()
+}
+
+func (d *Daemon) forgetCheckout(name string) {
+	d.checkouts.mu.Lock()
+	defer d.checkouts.mu.Unlock()
+	d.loadCheckouts()
+	if _, ok := d.checkouts.items[name]; !ok {
+		return
+	}
+	delete(d.checkouts.items, name)
+	d.checkouts.dirty = true
+	d.saveCheckouts()
+}
+
+func (d *Daemon) resendCheckouts() {
+	d.checkouts.mu.Lock()
+	d.checkouts.dirty = true
+	d.checkouts.mu.Unlock()
+}
+
+func (d *Daemon) checkoutReport() ([]protocol.Checkout, bool) {
+	d.checkouts.mu.Lock()
+	defer d.checkouts.mu.Unlock()
+	if !d.checkouts.dirty {
+		return nil, false
+	}
+	d.loadCheckouts()
+	d.checkouts.dirty = false
+
+	report := make([]protocol.Checkout, 0, len(d.checkouts.items))
+	for _, c := range d.checkouts.items {
+		report = append(report, c)
+	}
+	sort.Slice(report, func(i, j int) bool { return report[i].Repository < report[j].Repository })
+	return report, true
+}
+
+func (d *Daemon) startPrefetch(name string) bool {
+	d.checkouts.mu.Lock()
+	defer d.checkouts.mu.Unlock()
+	if d.checkouts.prefetching[name] {
+		return false
+	}
+	if d.checkouts.prefetching == nil {
+		d.checkouts.prefetching = make(map[string]bool)
+	}
+	d.checkouts.prefetching[name] = true
+	return true
+}
+
+func (d *Daemon) endPrefetch(name string) {
+	d.checkouts.mu.Lock()
+	delete(d.checkouts.prefetching, name)
+	d.checkouts.mu.Unlock()
+}
+
+func (d *Daemon) handlePrefetch(cmd protocol.CommandPayload) {
+	payloadBytes, _ := json.Marshal(cmd.Payload)
+	var payload struct {
+		URL        string `json:"url"`
+		Name       string `json:"name"`
+		Branch     string `json:"branch"`
+		Path       string `json:"path"`
+		Strategy   string `json:"strategy"`
+		Credential string `json:"credential"`
+	}
+	if err := json.Unmarshal(payloadBytes, &payload); err != nil || payload.Name == "" || payload.URL == "" || payload.Branch == "" {
+		d.sendCommandDone(cmd.ID, "failed", 1, "invalid prefetch payload")
+		return
+	}
+
+	if !d.startPrefetch(payload.Name) {
+		d.sendCommandDone(cmd.ID, "success", 0, fmt.Sprintf("prefetch of %s already running", payload.Name))
+		return
+	}
+	defer d.endPrefetch(payload.Name)
+
+	ctx, cancel := context.WithTimeout(d.abortCtx, prefetchTimeout)
+	defer cancel()
+
+	release, err := d.queue.acquire(ctx, payload.Name, cmd.ID, nil)
+	if err != nil {
+		d.sendCommandDone(cmd.ID, "failed", 1, d.abortReason(err))
+		return
+	}
+	defer release()
+	releaseSlot, err := d.queue.acquireSlot(ctx, nil)
+	if err != nil {
+		d.sendCommandDone(cmd.ID, "failed", 1, d.abortReason(err))
+		return
+	}
+	defer releaseSlot()
+
+	if err := d.checkDiskSpace(); err != nil {
+		logger.Warn("[AGENT] skipping prefetch of %s: %v", payload.Name, err)
+		d.sendCommandDone(cmd.ID, "failed", 1, err.Error())
+		return
+	}
+
+	cfg := deploy.Config{
+		URL:      payload.URL,
+		Name:     payload.Name,
+		Branch:   payload.Branch,
+		Path:     payload.Path,
+		Strategy: payload.Strategy,
+	}
+	if payload.Credential != "" {
+		cred, ok := d.cfg.Credentials[payload.Credential]
+		if !ok {
+			d.sendCommandDone(cmd.ID, "failed", 1, fmt.Sprintf("credential %q is not configured on this agent", payload.Credential))
+			return
+		}
+		cfg.Auth = &deploy.Auth{
+			SSHKeyFile: cred.SSHKeyFile,
+			SSHKey:     cred.SSHKey,
+			Username:   cred.Username,
+			Token:      cred.Token,
+		}
+	}
+
+	logger.Info("[AGENT] prefetching %s (%s)", payload.Name, payload.Branch)
+	startMsg, _ := protocol.NewMessage(protocol.TypeCommandStart, protocol.CommandStartPayload{
+		CommandID: cmd.ID,
+		StartedAt: time.Now().Unix(),
+	})
+	d.safeWrite(startMsg)
+
+	deployer := d.deployer.WithLog(func(stream, line string) {
+		logMsg, _ := protocol.NewMessage(protocol.TypeCommandLog, protocol.CommandLogPayload{
+			CommandID: cmd.ID,
+			Line:      line,
+			Stream:    stream,
+			Timestamp: time.Now().Unix(),
+		})
+		d.safeWrite(logMsg)
+	})
+
+	commit, err := deployer.Prefetch(ctx, cfg)
+	if err != nil {
+		logger.Warn("[AGENT] prefetch of %s failed: %v", payload.Name, err)
+		d.sendCommandDone(cmd.ID, "failed", 1, d.abortReason(err))
+		return
+	}
+
+	d.touchWorkdir(payload.Name)
+	d.recordCheckout(protocol.Checkout{
+		Repository: payload.Name,
+		URL:        payload.URL,
+		Branch:     payload.Branch,
+		Commit:     commit,
+		FetchedAt:  time.Now().Unix(),
+	})
+	logger.Info("[AGENT] prefetched %s at %s", payload.Name, commit)
+	d.sendCommandDone(cmd.ID, "success", 0, "")
+	go d.sendMetrics()
+}
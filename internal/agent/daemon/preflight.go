@@ -0,0 +1,91 @@
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/urustack/uruflow/pkg/helper"
+	"github.com/urustack/uruflow/pkg/logger"
+)
+
+type preflightState struct {
+	mu         sync.Mutex
+	dockerRoot string
+}
+
+func (d *Daemon) minFreeBytes(total uint64) uint64 {
+	var need uint64
+	if gb := d.cfg.Deploy.MinFreeGB; gb > 0 {
+		need = uint64(gb * 1024 * 1024 * 1024)
+	}
+	if pct := d.cfg.Deploy.MinFreePercent; pct > 0 {
+		byPercent := uint64(float64(total) * pct / 100)
+		if need == 0 || byPercent < need {
+			need = byPercent
+		}
+	}
+	return need
+}
+
+func (d *Daemon) dockerRootDir() string {
+	if d.docker == nil {
+		return ""
+	}
+	p := &d.preflight
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.dockerRoot != "" {
+		return p.dockerRoot
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	root, err := d.docker.RootDir(ctx)
+	if err != nil {
+		logger.Debug("[AGENT] could not read docker root directory: %v", err)
+		return ""
+	}
+	p.dockerRoot = root
+	return root
+}
+
+func (d *Daemon) checkDiskSpace() error {
+	paths := []string{d.cfg.DataDir}
+	if root := d.dockerRootDir(); root != "" && root != d.cfg.DataDir {
+		paths = append(paths, root)
+	}
+
+	for _, path := range paths {
+		free, total, err := d.metrics.DiskSpace(path)
+		if err != nil {
+			logger.Debug("[AGENT] disk space check skipped for %s: %v", path, err)
+			continue
+		}
+		need := d.minFreeBytes(total)
+		if need == 0 || free >= need {
+			continue
+		}
+		return fmt.Errorf("not enough disk space to deploy: %s has %s free of %s (%.1f%%), deploys need at least %s",
+			path, helper.FormatBytes(free), helper.FormatBytes(total), float64(free)/float64(total)*100, helper.FormatBytes(need))
+	}
+	return nil
+}
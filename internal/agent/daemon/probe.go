@@ -0,0 +1,53 @@
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+
+package daemon
+
+import (
+	"errors"
+
+	"github.com/urustack/uruflow/internal/agent/config"
+)
+
+var ErrAuthRejected = errors.New("authentication rejected")
+
+type ProbeResult struct {
+	AgentID string
+	Name    string
+	Server  string
+}
+
+func Probe(cfg *config.Config) (*ProbeResult, error) {
+	d := &Daemon{cfg: cfg, probe: true}
+	d.machineID, _ = loadMachineID(cfg.DataDir)
+	var err error
+	for _, ep := range d.endpoints() {
+		if err = d.connect(ep); err != nil {
+			if errors.Is(err, ErrAuthRejected) {
+				return nil, err
+			}
+			continue
+		}
+		d.conn.Close()
+		return &ProbeResult{AgentID: d.agentID, Name: d.name, Server: ep.Addr()}, nil
+	}
+	if err == nil {
+		err = errors.New("no server configured")
+	}
+	return nil, err
+}
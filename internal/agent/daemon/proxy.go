@@ -0,0 +1,242 @@
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+
+package daemon
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/urustack/uruflow/internal/agent/config"
+	"github.com/urustack/uruflow/pkg/logger"
+)
+
+type proxyError struct {
+	Proxy string
+	Err   error
+}
+
+func (e *proxyError) Error() string {
+	return fmt.Sprintf("proxy %s: %v", e.Proxy, e.Err)
+}
+
+func (e *proxyError) Unwrap() error {
+	return e.Err
+}
+
+var socks5Replies = map[byte]string{
+	1: "general failure",
+	2: "connection not allowed by ruleset",
+	3: "network unreachable",
+	4: "host unreachable",
+	5: "connection refused by server",
+	6: "TTL expired",
+	7: "command not supported",
+	8: "address type not supported",
+}
+
+func (d *Daemon) dial(addr string, timeout time.Duration) (net.Conn, error) {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	proxy, source, err := d.cfg.Server.ProxyFor(host)
+	if err != nil {
+		logger.Warn("[AGENT] ignoring proxy from %s, dialing %s directly: %v", source, addr, err)
+	}
+	if !proxy.Enabled() {
+		return net.DialTimeout("tcp", addr, timeout)
+	}
+	logger.Debug("[AGENT] dialing %s through %s proxy %s (from %s)", addr, proxy.Type, proxy.Address, source)
+	return dialProxy(proxy, addr, timeout)
+}
+
+func (d *Daemon) logProxy() {
+	proxy, source, err := d.cfg.Server.ProxyFor("")
+	switch {
+	case err != nil:
+		logger.Warn("[AGENT] ignoring proxy from %s, connecting directly: %v", source, err)
+	case proxy.Enabled():
+		logger.Info("[AGENT] connecting through %s proxy %s (from %s)", proxy.Type, proxy.Address, source)
+	}
+}
+
+func dialProxy(proxy config.ProxyConfig, addr string, timeout time.Duration) (net.Conn, error) {
+	conn, err := net.DialTimeout("tcp", proxy.Address, timeout)
+	if err != nil {
+		return nil, &proxyError{Proxy: proxy.Address, Err: fmt.Errorf("unreachable: %w", err)}
+	}
+	conn.SetDeadline(time.Now().Add(timeout))
+
+	var tunnel net.Conn
+	switch proxy.Type {
+	case config.ProxySOCKS5:
+		tunnel, err = socks5Connect(conn, proxy, addr)
+	default:
+		tunnel, err = httpConnect(conn, proxy, addr)
+	}
+	if err != nil {
+		conn.Close()
+		return nil, &proxyError{Proxy: proxy.Address, Err: err}
+	}
+	conn.SetDeadline(time.Time{})
+	return tunnel, nil
+}
+
+func socks5Connect(conn net.Conn, proxy config.ProxyConfig, addr string) (net.Conn, error) {
+	host, portStr, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid port %q", portStr)
+	}
+
+	methods := []byte{0x00}
+	if proxy.Username != "" {
+		methods = []byte{0x00, 0x02}
+	}
+	if _, err := conn.Write(append([]byte{0x05, byte(len(methods))}, methods...)); err != nil {
+		return nil, fmt.Errorf("socks5 greeting: %w", err)
+	}
+	reply := make([]byte, 2)
+	if _, err := io.ReadFull(conn, reply); err != nil {
+		return nil, fmt.Errorf("socks5 greeting: %w", err)
+	}
+	if reply[0] != 0x05 {
+		return nil, fmt.Errorf("not a socks5 proxy (version %d)", reply[0])
+	}
+	switch reply[1] {
+	case 0x00:
+	case 0x02:
+		if proxy.Username == "" {
+			return nil, errors.New("socks5 proxy requires a username and password")
+		}
+		auth := []byte{0x01, byte(len(proxy.Username))}
+		auth = append(auth, proxy.Username...)
+		auth = append(auth, byte(len(proxy.Password)))
+		auth = append(auth, proxy.Password...)
+		if _, err := conn.Write(auth); err != nil {
+			return nil, fmt.Errorf("socks5 auth: %w", err)
+		}
+		if _, err := io.ReadFull(conn, reply); err != nil {
+			return nil, fmt.Errorf("socks5 auth: %w", err)
+		}
+		if reply[1] != 0x00 {
+			return nil, errors.New("socks5 authentication rejected")
+		}
+	default:
+		return nil, errors.New("socks5 proxy accepted none of the offered auth methods")
+	}
+
+	req := []byte{0x05, 0x01, 0x00}
+	if ip := net.ParseIP(host); ip != nil {
+		if ip4 := ip.To4(); ip4 != nil {
+			req = append(append(req, 0x01), ip4...)
+		} else {
+			req = append(append(req, 0x04), ip.To16()...)
+		}
+	} else {
+		if len(host) > 255 {
+			return nil, fmt.Errorf("host name %s too long for socks5", host)
+		}
+		req = append(append(req, 0x03, byte(len(host))), host...)
+	}
+	req = binary.BigEndian.AppendUint16(req, uint16(port))
+	if _, err := conn.Write(req); err != nil {
+		return nil, fmt.Errorf("socks5 connect: %w", err)
+	}
+
+	head := make([]byte, 4)
+	if _, err := io.ReadFull(conn, head); err != nil {
+		return nil, fmt.Errorf("socks5 connect: %w", err)
+	}
+	if head[1] != 0x00 {
+		reason, ok := socks5Replies[head[1]]
+		if !ok {
+			reason = fmt.Sprintf("reply code %d", head[1])
+		}
+		return nil, fmt.Errorf("could not reach %s: %s", addr, reason)
+	}
+	var skip int
+	switch head[3] {
+	case 0x01:
+		skip = net.IPv4len
+	case 0x04:
+		skip = net.IPv6len
+	case 0x03:
+		n := make([]byte, 1)
+		if _, err := io.ReadFull(conn, n); err != nil {
+			return nil, fmt.Errorf("socks5 connect: %w", err)
+		}
+		skip = int(n[0])
+	default:
+		return nil, fmt.Errorf("socks5 reply with unknown address type %d", head[3])
+	}
+	if _, err := io.ReadFull(conn, make([]byte, skip+2)); err != nil {
+		return nil, fmt.Errorf("socks5 connect: %w", err)
+	}
+	return conn, nil
+}
+
+type bufferedConn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+func (c *bufferedConn) Read(p []byte) (int, error) {
+	return c.r.Read(p)
+}
+
+func httpConnect(conn net.Conn, proxy config.ProxyConfig, addr string) (net.Conn, error) {
+	req := "CONNECT " + addr + " HTTP/1.1\r\nHost: " + addr + "\r\n"
+	if proxy.Username != "" {
+		cred := base64.StdEncoding.EncodeToString([]byte(proxy.Username + ":" + proxy.Password))
+		req += "Proxy-Authorization: Basic " + cred + "\r\n"
+	}
+	req += "\r\n"
+	if _, err := io.WriteString(conn, req); err != nil {
+		return nil, fmt.Errorf("http connect: %w", err)
+	}
+
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, &http.Request{Method: http.MethodConnect})
+	if err != nil {
+		return nil, fmt.Errorf("http connect: %w", err)
+	}
+	resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusProxyAuthRequired:
+		return nil, errors.New("proxy authentication required or rejected")
+	case resp.StatusCode/100 != 2:
+		return nil, fmt.Errorf("could not reach %s: %s", addr, resp.Status)
+	}
+	if br.Buffered() > 0 {
+		return &bufferedConn{Conn: conn, r: br}, nil
+	}
+	return conn, nil
+}
@@ -0,0 +1,266 @@
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+
+package daemon
+
+import (
+	"bufio"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/urustack/uruflow/internal/agent/config"
+)
+
+func listen(t *testing.T, serve func(net.Conn)) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go serve(conn)
+		}
+	}()
+	return ln.Addr().String()
+}
+
+func pipe(a, b net.Conn) {
+	go io.Copy(a, b)
+	io.Copy(b, a)
+	a.Close()
+	b.Close()
+}
+
+func socks5Proxy(t *testing.T, username, password string, resolve map[string]string) string {
+	return listen(t, func(conn net.Conn) {
+		defer conn.Close()
+		head := make([]byte, 2)
+		if _, err := io.ReadFull(conn, head); err != nil {
+			return
+		}
+		methods := make([]byte, head[1])
+		io.ReadFull(conn, methods)
+		if username == "" {
+			conn.Write([]byte{0x05, 0x00})
+		} else {
+			conn.Write([]byte{0x05, 0x02})
+			ver := make([]byte, 2)
+			io.ReadFull(conn, ver)
+			user := make([]byte, ver[1])
+			io.ReadFull(conn, user)
+			n := make([]byte, 1)
+			io.ReadFull(conn, n)
+			pass := make([]byte, n[0])
+			io.ReadFull(conn, pass)
+			if string(user) != username || string(pass) != password {
+				conn.Write([]byte{0x01, 0x01})
+				return
+			}
+			conn.Write([]byte{0x01, 0x00})
+		}
+
+		req := make([]byte, 4)
+		if _, err := io.ReadFull(conn, req); err != nil {
+			return
+		}
+		var host string
+		switch req[3] {
+		case 0x01:
+			ip := make([]byte, net.IPv4len)
+			io.ReadFull(conn, ip)
+			host = net.IP(ip).String()
+		case 0x03:
+			n := make([]byte, 1)
+			io.ReadFull(conn, n)
+			name := make([]byte, n[0])
+			io.ReadFull(conn, name)
+			host = resolve[string(name)]
+		}
+		port := make([]byte, 2)
+		io.ReadFull(conn, port)
+		target, err := net.Dial("tcp", net.JoinHostPort(host, strconv.Itoa(int(binary.BigEndian.Uint16(port)))))
+		if err != nil {
+			conn.Write([]byte{0x05, 0x05, 0x00, 0x01, 0, 0, 0, 0, 0, 0})
+			return
+		}
+		conn.Write([]byte{0x05, 0x00, 0x00, 0x03, 9, 'l', 'o', 'c', 'a', 'l', 'h', 'o', 's', 't', 0, 0})
+		pipe(conn, target)
+	})
+}
+
+func connectProxy(t *testing.T, username, password string) string {
+	want := "Basic " + base64.StdEncoding.EncodeToString([]byte(username+":"+password))
+	return listen(t, func(conn net.Conn) {
+		defer conn.Close()
+		req, err := http.ReadRequest(bufio.NewReader(conn))
+		if err != nil || req.Method != http.MethodConnect {
+			return
+		}
+		if username != "" && req.Header.Get("Proxy-Authorization") != want {
+			io.WriteString(conn, "HTTP/1.1 407 Proxy Authentication Required\r\n\r\n")
+			return
+		}
+		target, err := net.Dial("tcp", req.Host)
+		if err != nil {
+			io.WriteString(conn, "HTTP/1.1 502 Bad Gateway\r\n\r\n")
+			return
+		}
+		io.WriteString(conn, "HTTP/1.1 200 Connection established\r\n\r\n")
+		pipe(conn, target)
+	})
+}
+
+func tlsServer(t *testing.T) (*httptest.Server, *tls.Config) {
+	t.Helper()
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "through the tunnel")
+	}))
+	t.Cleanup(srv.Close)
+	pool := x509.NewCertPool()
+	pool.AddCert(srv.Certificate())
+	return srv, &tls.Config{RootCAs: pool, ServerName: "example.com"}
+}
+
+func getOverTLS(t *testing.T, conn net.Conn, cfg *tls.Config) string {
+	t.Helper()
+	tlsConn := tls.Client(conn, cfg)
+	defer tlsConn.Close()
+	tlsConn.SetDeadline(time.Now().Add(5 * time.Second))
+	if err := tlsConn.Handshake(); err != nil {
+		t.Fatalf("TLS handshake through the proxy: %v", err)
+	}
+	io.WriteString(tlsConn, "GET / HTTP/1.1\r\nHost: example.com\r\nConnection: close\r\n\r\n")
+	resp, err := http.ReadResponse(bufio.NewReader(tlsConn), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	return string(body)
+}
+
+func TestDialProxyTLSThroughTunnel(t *testing.T) {
+	srv, tlsCfg := tlsServer(t)
+	target := strings.TrimPrefix(srv.URL, "https://")
+	_, port, _ := net.SplitHostPort(target)
+
+	for _, tc := range []struct {
+		name  string
+		proxy config.ProxyConfig
+		addr  string
+	}{
+		{"socks5", config.ProxyConfig{Type: config.ProxySOCKS5, Address: socks5Proxy(t, "", "", nil)}, target},
+		{"socks5 auth", config.ProxyConfig{Type: config.ProxySOCKS5, Address: socks5Proxy(t, "agent", "s3cret", nil), Username: "agent", Password: "s3cret"}, target},
+		{"socks5 host name", config.ProxyConfig{Type: config.ProxySOCKS5, Address: socks5Proxy(t, "", "", map[string]string{"uruflow.internal": "127.0.0.1"})}, net.JoinHostPort("uruflow.internal", port)},
+		{"http connect", config.ProxyConfig{Type: config.ProxyHTTPConnect, Address: connectProxy(t, "", "")}, target},
+		{"http connect auth", config.ProxyConfig{Type: config.ProxyHTTPConnect, Address: connectProxy(t, "agent", "s3cret"), Username: "agent", Password: "s3cret"}, target},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			conn, err := dialProxy(tc.proxy, tc.addr, 5*time.Second)
+			if err != nil {
+				t.Fatalf("dialProxy: %v", err)
+			}
+			if body := getOverTLS(t, conn, tlsCfg); body != "through the tunnel" {
+				t.Fatalf("body = %q", body)
+			}
+		})
+	}
+}
+
+func TestDialProxyErrors(t *testing.T) {
+	closed := listen(t, func(conn net.Conn) { conn.Close() })
+	unused, _ := net.Listen("tcp", "127.0.0.1:0")
+	refused := unused.Addr().String()
+	unused.Close()
+
+	for _, tc := range []struct {
+		name  string
+		proxy config.ProxyConfig
+		addr  string
+		want  string
+	}{
+		{"socks5 auth rejected", config.ProxyConfig{Type: config.ProxySOCKS5, Address: socks5Proxy(t, "agent", "s3cret", nil), Username: "agent", Password: "wrong"}, closed, "authentication rejected"},
+		{"socks5 auth missing", config.ProxyConfig{Type: config.ProxySOCKS5, Address: socks5Proxy(t, "agent", "s3cret", nil)}, closed, "requires a username"},
+		{"socks5 target refused", config.ProxyConfig{Type: config.ProxySOCKS5, Address: socks5Proxy(t, "", "", nil)}, refused, "connection refused by server"},
+		{"http connect auth rejected", config.ProxyConfig{Type: config.ProxyHTTPConnect, Address: connectProxy(t, "agent", "s3cret"), Username: "agent", Password: "wrong"}, closed, "authentication required"},
+		{"http connect target refused", config.ProxyConfig{Type: config.ProxyHTTPConnect, Address: connectProxy(t, "", "")}, refused, "502"},
+		{"proxy unreachable", config.ProxyConfig{Type: config.ProxySOCKS5, Address: refused}, closed, "unreachable"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := dialProxy(tc.proxy, tc.addr, 5*time.Second)
+			var perr *proxyError
+			if !errors.As(err, &perr) {
+				t.Fatalf("dialProxy = %v, want a proxy error", err)
+			}
+			if !strings.Contains(err.Error(), tc.want) {
+				t.Fatalf("dialProxy = %v, want %q", err, tc.want)
+			}
+		})
+	}
+}
+
+func TestHTTPConnectKeepsBufferedBytes(t *testing.T) {
+	addr := listen(t, func(conn net.Conn) {
+		defer conn.Close()
+		if _, err := http.ReadRequest(bufio.NewReader(conn)); err != nil {
+			return
+		}
+		io.WriteString(conn, "HTTP/1.1 200 Connection established\r\n\r\nhello")
+		time.Sleep(time.Second)
+	})
+	conn, err := dialProxy(config.ProxyConfig{Type: config.ProxyHTTPConnect, Address: addr}, "uruflow.internal:9000", 5*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	buf := make([]byte, 5)
+	if _, err := io.ReadFull(conn, buf); err != nil || string(buf) != "hello" {
+		t.Fatalf("read %q, %v; want the bytes that followed the CONNECT response", buf, err)
+	}
+}
+
+func TestDialIgnoresUnusableProxyVariable(t *testing.T) {
+	t.Setenv("ALL_PROXY", "")
+	t.Setenv("all_proxy", "")
+	t.Setenv("HTTPS_PROXY", "https://proxy.corp.local:3128")
+	addr := listen(t, func(conn net.Conn) { conn.Close() })
+
+	d := &Daemon{cfg: &config.Config{}}
+	conn, err := d.dial(addr, 5*time.Second)
+	if err != nil {
+		t.Fatalf("dial = %v, want a direct connection", err)
+	}
+	conn.Close()
+}
@@ -0,0 +1,164 @@
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/urustack/uruflow/internal/tcp/protocol"
+	"github.com/urustack/uruflow/pkg/logger"
+)
+
+var reclaimedPattern = regexp.MustCompile(`Total(?: reclaimed space)?:\s*([0-9.]+\s*[kKMGT]?B)`)
+
+func (d *Daemon) pruneLoop() {
+	interval := time.Duration(d.cfg.Docker.BuilderPruneHours) * time.Hour
+	if d.cfg.Docker.BuilderCacheMaxGB <= 0 || interval <= 0 {
+		return
+	}
+
+	logger.Info("[AGENT] builder cache pruning enabled: keep %dGB every %s", d.cfg.Docker.BuilderCacheMaxGB, interval)
+
+	for {
+		wait := nextPrune(readStamp(d.pruneStampFile()), interval, time.Now())
+		logger.Debug("[AGENT] next builder prune in %s", wait.Round(time.Second))
+
+		select {
+		case <-d.stopChan:
+			return
+		case <-time.After(wait):
+			d.addReclaimed(d.pruneBuilderCache())
+		}
+	}
+}
+
+func nextPrune(last time.Time, interval time.Duration, now time.Time) time.Duration {
+	if last.IsZero() {
+		return 0
+	}
+	next := last.Add(interval)
+	if !next.After(now) {
+		return 0
+	}
+	return next.Sub(now)
+}
+
+func (d *Daemon) pruneStampFile() string {
+	return filepath.Join(d.stateDir(), "builder_prune")
+}
+
+func readStamp(path string) time.Time {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return time.Time{}
+	}
+	sec, err := strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64)
+	if err != nil {
+		return time.Time{}
+	}
+	return time.Unix(sec, 0)
+}
+
+func (d *Daemon) writeStamp(path string) {
+	os.MkdirAll(d.stateDir(), 0755)
+	os.WriteFile(path, []byte(strconv.FormatInt(time.Now().Unix(), 10)), 0644)
+}
+
+func pruneArgs(maxGB int) []string {
+	return []string{"builder", "prune", "--keep-storage", fmt.Sprintf("%dGB", maxGB), "--force"}
+}
+
+func (d *Daemon) pruneBuilderCache() uint64 {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Minute)
+	defer cancel()
+
+	if d.cli.Podman() {
+		logger.Debug("[AGENT] builder cache prune skipped, podman has no --keep-storage")
+		return 0
+	}
+
+	args := pruneArgs(d.cfg.Docker.BuilderCacheMaxGB)
+	logger.Info("[AGENT] running %s %s", d.cli.Runtime, strings.Join(args, " "))
+
+	output, err := d.cli.Command(ctx, args...).CombinedOutput()
+
+	d.writeStamp(d.pruneStampFile())
+
+	if err != nil {
+		logger.Error("[AGENT] builder prune failed: %v: %s", err, strings.TrimSpace(string(output)))
+		d.sendEvent("builder_prune_failed", fmt.Sprintf("builder cache prune failed: %v", err))
+		return 0
+	}
+
+	reclaimed, bytes := reclaimedSpace(string(output))
+	logger.Info("[AGENT] builder cache pruned, reclaimed %s", reclaimed)
+	d.sendEvent("builder_prune", fmt.Sprintf("builder cache pruned to %dGB, reclaimed %s", d.cfg.Docker.BuilderCacheMaxGB, reclaimed))
+	return bytes
+}
+
+func reclaimedSpace(output string) (string, uint64) {
+	m := reclaimedPattern.FindStringSubmatch(output)
+	if m == nil {
+		return "0B", 0
+	}
+	size := strings.ReplaceAll(m[1], " ", "")
+	return size, parseSize(size)
+}
+
+func parseSize(size string) uint64 {
+	units := []struct {
+		suffix string
+		mult   float64
+	}{
+		{"TB", 1e12}, {"GB", 1e9}, {"MB", 1e6}, {"kB", 1e3}, {"KB", 1e3}, {"B", 1},
+	}
+	for _, u := range units {
+		if !strings.HasSuffix(size, u.suffix) {
+			continue
+		}
+		v, err := strconv.ParseFloat(strings.TrimSuffix(size, u.suffix), 64)
+		if err != nil || v < 0 {
+			return 0
+		}
+		return uint64(math.Round(v * u.mult))
+	}
+	return 0
+}
+
+func (d *Daemon) sendEvent(eventType, message string) {
+	msg, err := protocol.NewMessage(protocol.TypeAgentEvent, protocol.AgentEventPayload{
+		Type:      eventType,
+		Message:   message,
+		Timestamp: time.Now().Unix(),
+	})
+	if err != nil {
+		return
+	}
+	if err := d.safeWrite(msg); err != nil {
+		logger.Debug("[AGENT] event %s not delivered: %v", eventType, err)
+	}
+}
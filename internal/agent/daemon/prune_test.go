@@ -0,0 +1,75 @@
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+
+package daemon
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestNextPrune(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	interval := 24 * time.Hour
+
+	cases := []struct {
+		name string
+		last time.Time
+		want time.Duration
+	}{
+		{"never pruned", time.Time{}, 0},
+		{"pruned an hour ago", now.Add(-time.Hour), 23 * time.Hour},
+		{"pruned just now", now, interval},
+		{"due exactly now", now.Add(-interval), 0},
+		{"overdue after downtime", now.Add(-72 * time.Hour), 0},
+		{"stamp in the future", now.Add(time.Hour), 25 * time.Hour},
+	}
+	for _, tc := range cases {
+		if got := nextPrune(tc.last, interval, now); got != tc.want {
+			t.Errorf("%s: nextPrune = %s, want %s", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestPruneArgs(t *testing.T) {
+	want := []string{"builder", "prune", "--keep-storage", "20GB", "--force"}
+	if got := pruneArgs(20); !slices.Equal(got, want) {
+		t.Fatalf("pruneArgs(20) = %q, want %q", got, want)
+	}
+}
+
+func TestReclaimedSpace(t *testing.T) {
+	cases := []struct {
+		output string
+		size   string
+		bytes  uint64
+	}{
+		{"ID\tRECLAIMABLE\tSIZE\nabc\ttrue\t1.2GB\nTotal:\t1.5GB\n", "1.5GB", 1_500_000_000},
+		{"Deleted build cache objects:\nxyz\n\nTotal reclaimed space: 512.3MB\n", "512.3MB", 512_300_000},
+		{"Total:  20 kB\n", "20kB", 20_000},
+		{"Total: 0B\n", "0B", 0},
+		{"nothing to prune\n", "0B", 0},
+	}
+	for _, tc := range cases {
+		size, bytes := reclaimedSpace(tc.output)
+		if size != tc.size || bytes != tc.bytes {
+			t.Errorf("reclaimedSpace(%q) = %s %d, want %s %d", tc.output, size, bytes, tc.size, tc.bytes)
+		}
+	}
+}
@@ -0,0 +1,233 @@
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+
+package daemon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	queuePollInterval   = 5 * time.Second
+	queueReportInterval = 2 * time.Minute
+)
+
+type deployQueue struct {
+	mu       sync.Mutex
+	maxDepth int
+	repos    map[string]*repoQueue
+
+	slots   int
+	active  int
+	waiting []chan struct{}
+}
+
+type repoQueue struct {
+	sem   chan struct{}
+	depth int
+	ids   []string
+}
+
+func newDeployQueue(maxDepth, maxConcurrent int) *deployQueue {
+	return &deployQueue{
+		maxDepth: maxDepth,
+		repos:    make(map[string]*repoQueue),
+		slots:    maxConcurrent,
+	}
+}
+
+func (q *deployQueue) acquire(ctx context.Context, name, id string, onWait func(ahead int, behind string)) (func(), error) {
+	q.mu.Lock()
+	rq, ok := q.repos[name]
+	if !ok {
+		rq = &repoQueue{sem: make(chan struct{}, 1)}
+		q.repos[name] = rq
+	}
+	if rq.depth > 0 && rq.depth-1 >= q.maxDepth {
+		q.mu.Unlock()
+		return nil, fmt.Errorf("deploy queue for %s is full (%d waiting)", name, rq.depth-1)
+	}
+	rq.depth++
+	ahead := rq.depth - 1
+	var behind string
+	if len(rq.ids) > 0 {
+		behind = rq.ids[len(rq.ids)-1]
+	}
+	rq.ids = append(rq.ids, id)
+	q.mu.Unlock()
+
+	if ahead > 0 && onWait != nil {
+		onWait(ahead, behind)
+	}
+	leave := func() {
+		q.mu.Lock()
+		rq.depth--
+		for i, queued := range rq.ids {
+			if queued == id {
+				rq.ids = append(rq.ids[:i], rq.ids[i+1:]...)
+				break
+			}
+		}
+		if rq.depth == 0 {
+			delete(q.repos, name)
+		}
+		q.mu.Unlock()
+	}
+
+	select {
+	case rq.sem <- struct{}{}:
+	case <-ctx.Done():
+		leave()
+		return nil, ctx.Err()
+	}
+
+	return func() {
+		<-rq.sem
+		leave()
+	}, nil
+}
+
+func (q *deployQueue) tryAcquire(name string) (func(), bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if _, busy := q.repos[name]; busy {
+		return nil, false
+	}
+	rq := &repoQueue{sem: make(chan struct{}, 1), depth: 1}
+	rq.sem <- struct{}{}
+	q.repos[name] = rq
+
+	return func() {
+		<-rq.sem
+		q.mu.Lock()
+		rq.depth--
+		if rq.depth == 0 {
+			delete(q.repos, name)
+		}
+		q.mu.Unlock()
+	}, true
+}
+
+func (d *Daemon) queueError(err error, timeout time.Duration) string {
+	if d.isStopping() {
+		return errShuttingDown
+	}
+	if errors.Is(err, context.DeadlineExceeded) && d.abortCtx.Err() == nil {
+		return fmt.Sprintf("timed out after %s waiting in the deploy queue", timeout)
+	}
+	return d.abortReason(err)
+}
+
+func (q *deployQueue) acquireSlot(ctx context.Context, onQueued func(position int)) (func(), error) {
+	q.mu.Lock()
+	if q.slots <= 0 || (q.active < q.slots && len(q.waiting) == 0) {
+		q.active++
+		q.mu.Unlock()
+		return q.releaseSlot, nil
+	}
+	ready := make(chan struct{})
+	q.waiting = append(q.waiting, ready)
+	q.mu.Unlock()
+
+	last, lastSent := 0, time.Time{}
+	report := func() {
+		pos := q.position(ready)
+		if pos == 0 || onQueued == nil {
+			return
+		}
+		if pos != last || time.Since(lastSent) >= queueReportInterval {
+			onQueued(pos)
+			last, lastSent = pos, time.Now()
+		}
+	}
+	report()
+
+	ticker := time.NewTicker(queuePollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ready:
+			return q.releaseSlot, nil
+		case <-ctx.Done():
+			if !q.dropWaiter(ready) {
+				q.releaseSlot()
+			}
+			return nil, ctx.Err()
+		case <-ticker.C:
+			report()
+		}
+	}
+}
+
+func (q *deployQueue) releaseSlot() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.waiting) > 0 {
+		next := q.waiting[0]
+		q.waiting = q.waiting[1:]
+		close(next)
+		return
+	}
+	q.active--
+}
+
+func (q *deployQueue) position(ready chan struct{}) int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for i, ch := range q.waiting {
+		if ch == ready {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+func (q *deployQueue) dropWaiter(ready chan struct{}) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for i, ch := range q.waiting {
+		if ch == ready {
+			q.waiting = append(q.waiting[:i], q.waiting[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+func (q *deployQueue) idle() bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.active == 0 && len(q.waiting) == 0
+}
+
+func (q *deployQueue) pending() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	n := len(q.waiting)
+	for _, rq := range q.repos {
+		if rq.depth > 1 {
+			n += rq.depth - 1
+		}
+	}
+	return n
+}
@@ -0,0 +1,161 @@
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+
+package daemon
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/urustack/uruflow/internal/agent/config"
+	"github.com/urustack/uruflow/internal/tcp/protocol"
+)
+
+func TestQueueReportsDeploymentAhead(t *testing.T) {
+	q := newDeployQueue(5, 2)
+	ctx := context.Background()
+
+	waits := make(chan string, 3)
+	onWait := func(ahead int, behind string) { waits <- queuedBehind(ahead, behind) }
+
+	release1, err := q.acquire(ctx, "api", "deploy-1", onWait)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(waits) != 0 {
+		t.Fatalf("first deployment reported waiting: %s", <-waits)
+	}
+
+	acquired := make(chan func(), 2)
+	go func() {
+		release, _ := q.acquire(ctx, "api", "deploy-2", onWait)
+		acquired <- release
+	}()
+	if got := <-waits; got != "queued behind deploy-1" {
+		t.Fatalf("second deployment waits with %q", got)
+	}
+	cancelCtx, cancel := context.WithCancel(ctx)
+	done := make(chan error, 1)
+	go func() {
+		_, err := q.acquire(cancelCtx, "api", "deploy-3", onWait)
+		done <- err
+	}()
+	if got := <-waits; got != "queued behind deploy-2 (+1)" {
+		t.Fatalf("third deployment waits with %q", got)
+	}
+
+	cancel()
+	if err := <-done; err == nil {
+		t.Fatal("cancelled acquire succeeded")
+	}
+	release1()
+	release2 := <-acquired
+	release2()
+
+	q.mu.Lock()
+	_, left := q.repos["api"]
+	q.mu.Unlock()
+	if left {
+		t.Fatal("repo queue not removed after every deployment left")
+	}
+	if got := queuedBehind(3, ""); got != "queued behind 3 deployment(s)" {
+		t.Fatalf("queuedBehind without an id = %q", got)
+	}
+}
+
+func queuedDaemon(t *testing.T, maxConcurrent int) *Daemon {
+	t.Helper()
+	abortCtx, abort := context.WithCancel(context.Background())
+	t.Cleanup(abort)
+	return &Daemon{
+		cfg:      &config.Config{DataDir: t.TempDir()},
+		queue:    newDeployQueue(5, maxConcurrent),
+		abortCtx: abortCtx,
+		abort:    abort,
+	}
+}
+
+func commandDone(t *testing.T, d *Daemon, id string) protocol.CommandDonePayload {
+	t.Helper()
+	msgs, _ := d.outbox.drain()
+	for _, msg := range msgs {
+		if msg.Type != protocol.TypeCommandDone {
+			continue
+		}
+		var done protocol.CommandDonePayload
+		if err := msg.Decode(&done); err != nil {
+			t.Fatal(err)
+		}
+		if done.CommandID == id {
+			return done
+		}
+	}
+	t.Fatalf("no CommandDone for %s", id)
+	return protocol.CommandDonePayload{}
+}
+
+func TestQueuedDeployExpires(t *testing.T) {
+	old := deployTimeout
+	deployTimeout = 100 * time.Millisecond
+	t.Cleanup(func() { deployTimeout = old })
+
+	cases := []struct {
+		name  string
+		block func(d *Daemon) func()
+	}{
+		{"blocked slot", func(d *Daemon) func() {
+			release, _ := d.queue.acquireSlot(context.Background(), nil)
+			return release
+		}},
+		{"blocked repository", func(d *Daemon) func() {
+			release, _ := d.queue.acquire(context.Background(), "api", "running", nil)
+			return release
+		}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			d := queuedDaemon(t, 1)
+			release := tc.block(d)
+			defer release()
+
+			start := time.Now()
+			d.handleDeploy(protocol.CommandPayload{ID: "deploy-1", Type: "deploy", Payload: map[string]interface{}{"name": "api"}})
+			if waited := time.Since(start); waited > 5*time.Second {
+				t.Fatalf("queued deploy waited %s", waited)
+			}
+
+			done := commandDone(t, d, "deploy-1")
+			if done.Status != "failed" || done.Output != "timed out after 100ms waiting in the deploy queue" {
+				t.Fatalf("done = %s %q, want failed with a queue timeout", done.Status, done.Output)
+			}
+
+			q := d.queue
+			q.mu.Lock()
+			waiting, repo := len(q.waiting), q.repos["api"]
+			q.mu.Unlock()
+			if waiting != 0 {
+				t.Fatalf("%d waiters left on the slot queue", waiting)
+			}
+			if repo != nil && slices.Contains(repo.ids, "deploy-1") {
+				t.Fatal("expired deployment still listed in the repository queue")
+			}
+		})
+	}
+}
@@ -0,0 +1,102 @@
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+package daemon
+
+import (
+	"context"
+	"time"
+
+	"github.com/urustack/uruflow/pkg/logger"
+)
+
+const (
+	errShuttingDown = "agent shutting down"
+	abortWait       = 15 * time.Second
+)
+
+func (d *Daemon) track() bool {
+	d.inflightMu.Lock()
+	defer d.inflightMu.Unlock()
+
+	if d.stopping {
+		return false
+	}
+	d.inflight.Add(1)
+	return true
+}
+
+func (d *Daemon) isStopping() bool {
+	d.inflightMu.Lock()
+	defer d.inflightMu.Unlock()
+	return d.stopping
+}
+
+func (d *Daemon) cancelOnStop(ctx context.Context, cancel context.CancelFunc) {
+	go func() {
+		select {
+		case <-d.stopChan:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+}
+
+func (d *Daemon) shutdown() {
+	d.inflightMu.Lock()
+	if d.stopping {
+		d.inflightMu.Unlock()
+		return
+	}
+	d.stopping = true
+	d.inflightMu.Unlock()
+
+	close(d.stopChan)
+	defer close(d.doneChan)
+
+	idle := make(chan struct{})
+	go func() {
+		d.inflight.Wait()
+		close(idle)
+	}()
+
+	grace := time.Duration(d.cfg.Deploy.ShutdownGrace) * time.Second
+	logger.Info("[AGENT] waiting up to %v for in-flight deployments", grace)
+
+	select {
+	case <-idle:
+		logger.Info("[AGENT] no deployments in flight")
+		return
+	case <-time.After(grace):
+	}
+
+	logger.Warn("[AGENT] grace period expired, cancelling in-flight deployments")
+	d.abort()
+
+	select {
+	case <-idle:
+	case <-time.After(abortWait):
+		logger.Error("[AGENT] in-flight deployments did not stop within %v", abortWait)
+	}
+}
+
+func (d *Daemon) abortReason(err error) string {
+	if d.abortCtx.Err() != nil {
+		return errShuttingDown
+	}
+	return err.Error()
+}
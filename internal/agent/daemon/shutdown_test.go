@@ -0,0 +1,264 @@
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+
+package daemon
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/urustack/uruflow/internal/agent/config"
+	"github.com/urustack/uruflow/internal/agent/deploy"
+	"github.com/urustack/uruflow/internal/agent/docker"
+	"github.com/urustack/uruflow/internal/agent/metrics"
+	"github.com/urustack/uruflow/internal/tcp/protocol"
+)
+
+const slowRuntime = `#!/bin/sh
+case "$1" in
+pull) exec sleep "$FAKE_PULL_SECONDS" ;;
+esac
+exit 0
+`
+
+type shutdownFixture struct {
+	d      *Daemon
+	server *protocol.Writer
+	msgs   chan *protocol.Message
+	loop   chan struct{}
+	seen   []*protocol.Message
+}
+
+func newShutdownFixture(t *testing.T, grace int, pull string) *shutdownFixture {
+	t.Helper()
+	dir := t.TempDir()
+	script := filepath.Join(dir, "docker")
+	if err := os.WriteFile(script, []byte(slowRuntime), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := config.Default()
+	cfg.DataDir = dir
+	cfg.Deploy.ShutdownGrace = grace
+	cfg.Deploy.DriftCheckSec = 0
+	cfg.Deploy.MinFreeGB = 0
+	cfg.Deploy.MinFreePercent = 0
+
+	deployer := deploy.NewExecutor(filepath.Join(dir, "work"))
+	deployer.SetRuntime(docker.CLI{Runtime: script, Env: []string{"FAKE_PULL_SECONDS=" + pull}})
+
+	abortCtx, abort := context.WithCancel(context.Background())
+	t.Cleanup(abort)
+	client, server := net.Pipe()
+	t.Cleanup(func() { server.Close() })
+
+	d := &Daemon{
+		cfg:           cfg,
+		metrics:       metrics.NewCollector(),
+		deployer:      deployer,
+		queue:         newDeployQueue(5, 1),
+		stopChan:      make(chan struct{}),
+		doneChan:      make(chan struct{}),
+		abortCtx:      abortCtx,
+		abort:         abort,
+		streamCancels: make(map[string]context.CancelFunc),
+		conn:          client,
+		reader:        protocol.NewReader(client),
+		writer:        protocol.NewWriter(client),
+	}
+
+	f := &shutdownFixture{
+		d:      d,
+		server: protocol.NewWriter(server),
+		msgs:   make(chan *protocol.Message, 256),
+		loop:   make(chan struct{}),
+	}
+	go func() {
+		defer close(f.msgs)
+		reader := protocol.NewReader(server)
+		for {
+			msg, err := reader.Read()
+			if err != nil {
+				return
+			}
+			f.msgs <- msg
+		}
+	}()
+	go func() {
+		defer close(f.loop)
+		d.runLoop()
+	}()
+	return f
+}
+
+func (f *shutdownFixture) deploy(t *testing.T, id string) {
+	t.Helper()
+	msg, err := protocol.NewMessage(protocol.TypeCommand, protocol.CommandPayload{
+		ID:      id,
+		Type:    "deploy",
+		Payload: map[string]interface{}{"name": "api", "build_system": "image", "image": "nginx:1"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := f.server.Write(msg); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func (f *shutdownFixture) waitFor(t *testing.T, match func(*protocol.Message) bool) {
+	t.Helper()
+	timeout := time.After(10 * time.Second)
+	for {
+		select {
+		case msg, ok := <-f.msgs:
+			if !ok {
+				t.Fatal("connection closed while waiting")
+			}
+			f.seen = append(f.seen, msg)
+			if match(msg) {
+				return
+			}
+		case <-timeout:
+			t.Fatal("timed out waiting for a message from the agent")
+		}
+	}
+}
+
+func (f *shutdownFixture) drain(t *testing.T) {
+	t.Helper()
+	timeout := time.After(30 * time.Second)
+	for {
+		select {
+		case msg, ok := <-f.msgs:
+			if !ok {
+				select {
+				case <-f.loop:
+				case <-timeout:
+					t.Fatal("run loop did not return")
+				}
+				return
+			}
+			f.seen = append(f.seen, msg)
+		case <-timeout:
+			t.Fatal("agent did not close the connection")
+		}
+	}
+}
+
+func (f *shutdownFixture) done(t *testing.T, id string) (protocol.CommandDonePayload, int) {
+	t.Helper()
+	for i, msg := range f.seen {
+		if msg.Type != protocol.TypeCommandDone {
+			continue
+		}
+		var done protocol.CommandDonePayload
+		if err := msg.Decode(&done); err != nil {
+			t.Fatal(err)
+		}
+		if done.CommandID == id {
+			return done, i
+		}
+	}
+	t.Fatalf("no CommandDone for %s before the connection closed", id)
+	return protocol.CommandDonePayload{}, 0
+}
+
+func (f *shutdownFixture) disconnectedLast(t *testing.T) int {
+	t.Helper()
+	last := len(f.seen) - 1
+	if last < 0 || f.seen[last].Type != protocol.TypeDisconnect {
+		t.Fatal("connection closed without a disconnect message")
+	}
+	return last
+}
+
+func commandIs(msgType protocol.MessageType, id string, match func(*protocol.Message) bool) func(*protocol.Message) bool {
+	return func(msg *protocol.Message) bool {
+		if msg.Type != msgType {
+			return false
+		}
+		var p struct {
+			CommandID string `json:"command_id"`
+		}
+		msg.Decode(&p)
+		return p.CommandID == id && (match == nil || match(msg))
+	}
+}
+
+func queuedAck(msg *protocol.Message) bool {
+	var ack protocol.CommandAckPayload
+	msg.Decode(&ack)
+	return ack.Status == protocol.AckQueued
+}
+
+func TestShutdownWaitsForInFlightDeploy(t *testing.T) {
+	f := newShutdownFixture(t, 30, "1")
+	f.deploy(t, "deploy-1")
+	f.waitFor(t, commandIs(protocol.TypeCommandStart, "deploy-1", nil))
+	f.deploy(t, "deploy-2")
+	f.waitFor(t, commandIs(protocol.TypeCommandAck, "deploy-2", queuedAck))
+
+	go f.d.shutdown()
+	f.drain(t)
+
+	running, runningAt := f.done(t, "deploy-1")
+	if running.Status != "success" {
+		t.Fatalf("in-flight deploy = %s %q, want it to finish within the grace period", running.Status, running.Output)
+	}
+	queued, queuedAt := f.done(t, "deploy-2")
+	if queued.Status != "failed" || queued.Output != errShuttingDown {
+		t.Fatalf("queued deploy = %s %q, want failed with %q", queued.Status, queued.Output, errShuttingDown)
+	}
+	if queuedAt > runningAt {
+		t.Fatal("queued deploy waited for the in-flight deploy instead of being aborted")
+	}
+	for _, msg := range f.seen {
+		if commandIs(protocol.TypeCommandStart, "deploy-2", nil)(msg) {
+			t.Fatal("queued deploy started during shutdown")
+		}
+	}
+	if last := f.disconnectedLast(t); runningAt > last {
+		t.Fatal("CommandDone sent after the disconnect")
+	}
+}
+
+func TestShutdownAbortsAfterGrace(t *testing.T) {
+	f := newShutdownFixture(t, 1, "30")
+	f.deploy(t, "deploy-1")
+	f.waitFor(t, commandIs(protocol.TypeCommandStart, "deploy-1", nil))
+
+	start := time.Now()
+	go f.d.shutdown()
+	f.drain(t)
+	if waited := time.Since(start); waited < time.Second || waited > 10*time.Second {
+		t.Fatalf("shutdown took %s, want the 1s grace period plus the abort", waited)
+	}
+
+	done, at := f.done(t, "deploy-1")
+	if done.Status != "failed" || done.Output != errShuttingDown {
+		t.Fatalf("aborted deploy = %s %q, want failed with %q", done.Status, done.Output, errShuttingDown)
+	}
+	if last := f.disconnectedLast(t); at > last {
+		t.Fatal("CommandDone sent after the disconnect")
+	}
+}
@@ -0,0 +1,117 @@
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+
+package daemon
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/urustack/uruflow/internal/agent/docker"
+	"github.com/urustack/uruflow/internal/tcp/protocol"
+	"github.com/urustack/uruflow/pkg/logger"
+)
+
+const (
+	statsWorkers   = 5
+	statsTimeout   = 2 * time.Second
+	minStatsBudget = 2 * time.Second
+)
+
+type statsCache struct {
+	mu   sync.Mutex
+	last map[string]*docker.Container
+}
+
+func (d *Daemon) statsBudget() time.Duration {
+	budget := time.Duration(d.cfg.Server.MetricsSec) * time.Second / 2
+	if budget < minStatsBudget {
+		return minStatsBudget
+	}
+	return budget
+}
+
+func (d *Daemon) collectContainerStats(containers []protocol.Container, ids []string) {
+	ctx, cancel := context.WithTimeout(context.Background(), d.statsBudget())
+	defer cancel()
+
+	fresh := make([]*docker.Container, len(containers))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < statsWorkers && w < len(containers); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				statsCtx, statsCancel := context.WithTimeout(ctx, statsTimeout)
+				stats, err := d.docker.GetContainerStats(statsCtx, ids[i])
+				statsCancel()
+				if err == nil {
+					fresh[i] = stats
+				}
+			}
+		}()
+	}
+
+feed:
+	for i := range containers {
+		if containers[i].Status != "running" {
+			continue
+		}
+		select {
+		case jobs <- i:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	c := &d.stats
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	last := make(map[string]*docker.Container, len(containers))
+	stale := 0
+	for i := range containers {
+		cm := &containers[i]
+		if cm.Status != "running" {
+			continue
+		}
+		stats := fresh[i]
+		if stats == nil {
+			stale++
+			cm.StatsStale = true
+			stats = c.last[ids[i]]
+			if stats == nil {
+				continue
+			}
+		}
+		last[ids[i]] = stats
+		cm.CPUPercent = stats.CPUPercent
+		cm.MemoryUsage = stats.MemoryUsage
+		cm.MemoryLimit = stats.MemoryLimit
+		cm.NetworkRx = stats.NetworkRx
+		cm.NetworkTx = stats.NetworkTx
+	}
+	c.last = last
+
+	if stale > 0 {
+		logger.Warn("[AGENT] stats of %d/%d containers did not arrive within %s, reporting them as stale", stale, len(containers), d.statsBudget())
+	}
+}
@@ -0,0 +1,159 @@
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+
+package daemon
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/urustack/uruflow/internal/agent/config"
+	"github.com/urustack/uruflow/internal/agent/docker"
+	"github.com/urustack/uruflow/internal/tcp/protocol"
+)
+
+type slowDocker struct {
+	mu       sync.Mutex
+	hung     map[string]bool
+	latency  time.Duration
+	inFlight int
+	peak     int
+}
+
+func (f *slowDocker) setHung(ids ...string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.hung = make(map[string]bool)
+	for _, id := range ids {
+		f.hung[id] = true
+	}
+}
+
+func (f *slowDocker) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/version" {
+		fmt.Fprint(w, `{"Version":"27.3.1","ApiVersion":"1.47","MinAPIVersion":"1.24"}`)
+		return
+	}
+	id := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/v1.47/containers/"), "/stats")
+
+	f.mu.Lock()
+	hung := f.hung[id]
+	f.inFlight++
+	f.peak = max(f.peak, f.inFlight)
+	f.mu.Unlock()
+	defer func() {
+		f.mu.Lock()
+		f.inFlight--
+		f.mu.Unlock()
+	}()
+
+	if hung {
+		<-r.Context().Done()
+		return
+	}
+	time.Sleep(f.latency)
+	fmt.Fprint(w, `{"memory_stats":{"usage":1048576,"limit":4194304},"networks":{"eth0":{"rx_bytes":10,"tx_bytes":20}}}`)
+}
+
+func newStatsDaemon(t *testing.T, fake *slowDocker) *Daemon {
+	t.Helper()
+	srv := httptest.NewServer(fake)
+	t.Cleanup(srv.Close)
+	svc, err := docker.New(docker.Options{Endpoint: "tcp://" + srv.Listener.Addr().String()})
+	if err != nil {
+		t.Fatalf("docker.New: %v", err)
+	}
+	cfg := config.Default()
+	cfg.Server.MetricsSec = 4
+	return &Daemon{cfg: cfg, docker: svc}
+}
+
+func runningContainers(n int) ([]protocol.Container, []string) {
+	containers := make([]protocol.Container, n)
+	ids := make([]string, n)
+	for i := range containers {
+		ids[i] = fmt.Sprintf("c%02d", i)
+		containers[i] = protocol.Container{ID: ids[i], Name: ids[i], Status: "running"}
+	}
+	return containers, ids
+}
+
+func TestContainerStatsFinishWithinBudget(t *testing.T) {
+	fake := &slowDocker{latency: 100 * time.Millisecond}
+	fake.setHung("c03", "c17")
+	d := newStatsDaemon(t, fake)
+	containers, ids := runningContainers(25)
+	containers[5].Status = "exited"
+
+	start := time.Now()
+	d.collectContainerStats(containers, ids)
+	if elapsed := time.Since(start); elapsed > d.statsBudget()+time.Second {
+		t.Fatalf("stats cycle took %s, budget is %s", elapsed, d.statsBudget())
+	}
+	fake.mu.Lock()
+	peak := fake.peak
+	fake.mu.Unlock()
+	if peak > statsWorkers {
+		t.Fatalf("%d concurrent stats requests, want at most %d", peak, statsWorkers)
+	}
+
+	for i, c := range containers {
+		switch c.ID {
+		case "c03", "c17":
+			if !c.StatsStale || c.MemoryUsage != 0 {
+				t.Errorf("hung container %s = %+v, want stale without stats", c.ID, c)
+			}
+		case "c05":
+			if c.StatsStale || c.MemoryUsage != 0 {
+				t.Errorf("stopped container %s = %+v, want it skipped", c.ID, c)
+			}
+		default:
+			if c.StatsStale || c.MemoryUsage != 1048576 || c.NetworkRx != 10 || c.NetworkTx != 20 {
+				t.Errorf("container %d = %+v, want fresh stats", i, c)
+			}
+		}
+	}
+}
+
+func TestStaleContainerKeepsLastStats(t *testing.T) {
+	fake := &slowDocker{}
+	d := newStatsDaemon(t, fake)
+
+	containers, ids := runningContainers(3)
+	d.collectContainerStats(containers, ids)
+	for _, c := range containers {
+		if c.StatsStale {
+			t.Fatalf("first cycle: %s is stale", c.ID)
+		}
+	}
+
+	fake.setHung("c01")
+	containers, ids = runningContainers(3)
+	d.collectContainerStats(containers, ids)
+	if c := containers[1]; !c.StatsStale || c.MemoryUsage != 1048576 {
+		t.Fatalf("hung container = %+v, want the previous stats marked stale", c)
+	}
+	if c := containers[0]; c.StatsStale {
+		t.Fatalf("responsive container = %+v, want fresh stats", c)
+	}
+}
@@ -19,20 +19,89 @@
 package daemon
 
 import (
+	"crypto/sha256"
 	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"fmt"
 	"net"
+	"os"
 	"time"
+
+	"github.com/urustack/uruflow/internal/agent/config"
 )
 
-func (d *Daemon) connectTLS(addr string) (net.Conn, error) {
+func (d *Daemon) tlsConfig(host string) (*tls.Config, error) {
 	tlsConfig := &tls.Config{
+		ServerName:         host,
 		InsecureSkipVerify: d.cfg.Server.TLSSkipVerify,
 		MinVersion:         tls.VersionTLS12,
 	}
 
-	dialer := &net.Dialer{
-		Timeout: 10 * time.Second,
+	if d.cfg.Server.Fingerprint != "" {
+		want := config.NormalizeFingerprint(d.cfg.Server.Fingerprint)
+		tlsConfig.InsecureSkipVerify = true
+		tlsConfig.VerifyPeerCertificate = func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+			if len(rawCerts) == 0 {
+				return fmt.Errorf("server sent no certificate")
+			}
+			sum := sha256.Sum256(rawCerts[0])
+			if got := hex.EncodeToString(sum[:]); got != want {
+				return fmt.Errorf("server certificate fingerprint %s does not match server.tls_fingerprint", got)
+			}
+			return nil
+		}
+		return d.clientCert(tlsConfig)
+	}
+
+	if d.cfg.Server.CAFile != "" {
+		pem, err := os.ReadFile(d.cfg.Server.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("read ca file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("ca file %s contains no certificates", d.cfg.Server.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	return d.clientCert(tlsConfig)
+}
+
+func (d *Daemon) clientCert(tlsConfig *tls.Config) (*tls.Config, error) {
+	if d.cfg.Server.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(d.cfg.Server.CertFile, d.cfg.Server.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("load client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	return tlsConfig, nil
+}
+
+func (d *Daemon) connectTLS(addr string) (net.Conn, error) {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig, err := d.tlsConfig(host)
+	if err != nil {
+		return nil, err
+	}
+
+	raw, err := d.dial(addr, 10*time.Second)
+	if err != nil {
+		return nil, err
+	}
+
+	conn := tls.Client(raw, tlsConfig)
+	conn.SetDeadline(time.Now().Add(10 * time.Second))
+	if err := conn.Handshake(); err != nil {
+		raw.Close()
+		return nil, fmt.Errorf("tls handshake failed: %w", err)
 	}
+	conn.SetDeadline(time.Time{})
 
-	return tls.DialWithDialer(dialer, "tcp", addr, tlsConfig)
+	return conn, nil
 }
@@ -0,0 +1,75 @@
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+
+package daemon
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/urustack/uruflow/internal/agent/config"
+)
+
+func colonFingerprint(sum []byte) string {
+	s := strings.ToUpper(hex.EncodeToString(sum))
+	var parts []string
+	for i := 0; i < len(s); i += 2 {
+		parts = append(parts, s[i:i+2])
+	}
+	return strings.Join(parts, ":")
+}
+
+func TestTLSFingerprintPinning(t *testing.T) {
+	srv, _ := tlsServer(t)
+	addr := srv.Listener.Addr().String()
+	sum := sha256.Sum256(srv.Certificate().Raw)
+	other := sha256.Sum256([]byte("another certificate"))
+
+	cases := []struct {
+		name        string
+		fingerprint string
+		wantErr     string
+	}{
+		{"pinned with colons", colonFingerprint(sum[:]), ""},
+		{"pinned lowercase", hex.EncodeToString(sum[:]), ""},
+		{"wrong pin", colonFingerprint(other[:]), "does not match server.tls_fingerprint"},
+		{"no pin", "", "certificate"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			d := &Daemon{cfg: &config.Config{Server: config.ServerConfig{TLS: true, Fingerprint: tc.fingerprint}}}
+			conn, err := d.connectTLS(addr)
+			if tc.wantErr == "" {
+				if err != nil {
+					t.Fatalf("connect: %v", err)
+				}
+				conn.Close()
+				return
+			}
+			if err == nil {
+				conn.Close()
+				t.Fatal("connected to a server whose certificate should have been rejected")
+			}
+			if !strings.Contains(err.Error(), tc.wantErr) {
+				t.Fatalf("error = %v, want it to mention %q", err, tc.wantErr)
+			}
+		})
+	}
+}
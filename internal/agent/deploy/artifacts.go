@@ -0,0 +1,90 @@
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+
+package deploy
+
+import (
+	"context"
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/urustack/uruflow/internal/tcp/protocol"
+)
+
+const truncatedMarker = "\n... [truncated by uruflow-agent]\n"
+
+type Artifact struct {
+	Name      string
+	Data      string
+	Truncated bool
+}
+
+func (e *Executor) Artifacts(ctx context.Context, cfg Config, result *Result) []Artifact {
+	if result == nil || result.Project == "" || result.ComposeFile == "" {
+		return nil
+	}
+	e = e.withMasks(cfg.Env)
+
+	captures := []struct {
+		name string
+		args []string
+	}{
+		{"compose-config.yaml", []string{"config"}},
+		{"images.json", []string{"images", "--format", "json"}},
+	}
+
+	var artifacts []Artifact
+	for _, c := range captures {
+		args := append([]string{"-p", result.Project, "-f", result.ComposeFile}, c.args...)
+		output, err := e.composeCmd(ctx, result.RepoDir, result.Env, args...).Output()
+		if err != nil {
+			e.log("stderr", fmt.Sprintf("› Could not capture %s: %v", c.name, err))
+			continue
+		}
+		data, truncated := truncateArtifact(e.mask(string(output)))
+		if truncated {
+			e.log("stderr", fmt.Sprintf("› Artifact %s exceeds %d bytes, truncated", c.name, protocol.MaxArtifactSize))
+		}
+		artifacts = append(artifacts, Artifact{Name: c.name, Data: data, Truncated: truncated})
+	}
+	return artifacts
+}
+
+func truncateArtifact(data string) (string, bool) {
+	if len(data) <= protocol.MaxArtifactSize {
+		return data, false
+	}
+	cut := protocol.MaxArtifactSize - len(truncatedMarker)
+	for cut > 0 && !utf8.RuneStart(data[cut]) {
+		cut--
+	}
+	return data[:cut] + truncatedMarker, true
+}
+
+func ArtifactChunks(data string) []string {
+	var chunks []string
+	for len(data) > protocol.ArtifactChunkSize {
+		cut := protocol.ArtifactChunkSize
+		for cut > 0 && !utf8.RuneStart(data[cut]) {
+			cut--
+		}
+		chunks = append(chunks, data[:cut])
+		data = data[cut:]
+	}
+	return append(chunks, data)
+}
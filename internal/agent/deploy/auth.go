@@ -0,0 +1,143 @@
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+package deploy
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync/atomic"
+)
+
+type Auth struct {
+	SSHKeyFile string
+	SSHKey     string
+	Username   string
+	Token      string
+}
+
+const credentialHelper = `!f() { test "$1" = get && echo "username=${URUFLOW_GIT_USERNAME}" && echo "password=${URUFLOW_GIT_TOKEN}"; }; f`
+
+var authFailures = []string{
+	"permission denied (publickey",
+	"authentication failed",
+	"could not read username",
+	"could not read password",
+	"terminal prompts disabled",
+	"host key verification failed",
+	"invalid username or password",
+	"http basic: access denied",
+}
+
+func (e *Executor) withAuth(auth *Auth) (*Executor, func(), error) {
+	c := *e
+	c.gitEnv = []string{"GIT_TERMINAL_PROMPT=0"}
+	c.gitArgs = nil
+	cleanup := func() {}
+
+	if auth == nil {
+		return &c, cleanup, nil
+	}
+
+	switch {
+	case auth.Token != "":
+		user := auth.Username
+		if user == "" {
+			user = "x-access-token"
+		}
+		c.gitArgs = []string{"-c", "credential.helper=", "-c", "credential.helper=" + credentialHelper}
+		c.gitEnv = append(c.gitEnv, "URUFLOW_GIT_USERNAME="+user, "URUFLOW_GIT_TOKEN="+auth.Token)
+		c.addMask(auth.Token)
+
+	case auth.SSHKey != "" || auth.SSHKeyFile != "":
+		keyFile := auth.SSHKeyFile
+		if auth.SSHKey != "" {
+			f, err := os.CreateTemp("", "uruflow-key-*")
+			if err != nil {
+				return nil, cleanup, fmt.Errorf("write ssh key: %w", err)
+			}
+			keyFile = f.Name()
+			cleanup = func() { os.Remove(keyFile) }
+
+			key := auth.SSHKey
+			if !strings.HasSuffix(key, "\n") {
+				key += "\n"
+			}
+			_, err = f.WriteString(key)
+			if closeErr := f.Close(); err == nil {
+				err = closeErr
+			}
+			if err == nil {
+				err = os.Chmod(keyFile, 0600)
+			}
+			if err != nil {
+				cleanup()
+				return nil, func() {}, fmt.Errorf("write ssh key: %w", err)
+			}
+		} else if _, err := os.Stat(keyFile); err != nil {
+			return nil, cleanup, fmt.Errorf("ssh key: %w", err)
+		}
+		c.gitEnv = append(c.gitEnv, "GIT_SSH_COMMAND="+sshCommand(keyFile))
+	}
+
+	return &c, cleanup, nil
+}
+
+func (e *Executor) addMask(secret string) {
+	if len(secret) < minMaskLen {
+		return
+	}
+	e.masks = append(append([]string(nil), e.masks...), secret)
+	sort.Slice(e.masks, func(i, j int) bool { return len(e.masks[i]) > len(e.masks[j]) })
+}
+
+func (e *Executor) runGit(ctx context.Context, dir, repo string, args ...string) error {
+	var denied atomic.Bool
+	observe := func(line string) {
+		lower := strings.ToLower(line)
+		for _, marker := range authFailures {
+			if strings.Contains(lower, marker) {
+				denied.Store(true)
+				return
+			}
+		}
+	}
+
+	var progress *gitProgress
+	if len(args) > 0 && (args[0] == "clone" || args[0] == "fetch") {
+		args = append([]string{args[0], "--progress"}, args[1:]...)
+		progress = newGitProgress()
+	}
+	full := append(append([]string(nil), e.gitArgs...), args...)
+	err := e.runCmdProgress(ctx, dir, e.gitEnv, nil, observe, progress, "git", full...)
+	if err != nil && denied.Load() {
+		e.log("stderr", fmt.Sprintf("› authentication failed for repo %s", repo))
+		return fmt.Errorf("authentication failed for repo %s: %w", repo, err)
+	}
+	return err
+}
+
+func sshCommand(keyFile string) string {
+	return fmt.Sprintf("ssh -i %s -o IdentitiesOnly=yes -o BatchMode=yes -o StrictHostKeyChecking=accept-new", shellQuote(keyFile))
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,135 @@
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+
+package deploy
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/urustack/uruflow/internal/agent/docker"
+)
+
+func writeFiles(t *testing.T, dir string, names ...string) {
+	t.Helper()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range names {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("services: {}\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestResolveCommandPrecedence(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		files   []string
+		cfg     Config
+		want    string
+		wantErr string
+	}{
+		{"build_cmd wins", []string{"compose.yaml", "docker-compose.yml"}, Config{BuildSystem: "compose", BuildCmd: "make up", BuildFile: "ops.yml"}, "ops.yml", ""},
+		{"build_file wins over detection", []string{"compose.yaml", "docker-compose.yml"}, Config{BuildSystem: "compose", BuildFile: "docker-compose.yml"}, "docker-compose.yml", ""},
+		{"single compose file", []string{"docker-compose.yaml"}, Config{BuildSystem: "compose"}, "docker-compose.yaml", ""},
+		{"several compose files", []string{"compose.yaml", "docker-compose.yml"}, Config{BuildSystem: "compose"}, "", "several compose files"},
+		{"no compose file", nil, Config{BuildSystem: "compose"}, "", "no compose file found"},
+		{"default Dockerfile", []string{"Dockerfile"}, Config{BuildSystem: "dockerfile"}, "Dockerfile", ""},
+		{"dockerfile build_file", nil, Config{BuildSystem: "dockerfile", BuildFile: "build/Dockerfile.prod"}, "build/Dockerfile.prod", ""},
+		{"default Makefile", []string{"Makefile"}, Config{BuildSystem: "makefile"}, "Makefile", ""},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeFiles(t, dir, tc.files...)
+			tc.cfg.Name = "api"
+			_, file, err := NewExecutor(t.TempDir()).resolveCommand(dir, tc.cfg)
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("error = %v, want %q", err, tc.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if file != tc.want {
+				t.Fatalf("build file = %q, want %q", file, tc.want)
+			}
+		})
+	}
+}
+
+func TestAmbiguousComposeFileIsRefused(t *testing.T) {
+	e := NewExecutor(t.TempDir())
+	e.SetRegistries([]Registry{{Server: "ghcr.io", Username: "bot", PasswordFile: "/nonexistent"}})
+	cfg := Config{Name: "api", BuildSystem: "compose"}
+	writeFiles(t, e.repoDir(cfg), "compose.yaml", "docker-compose.yml")
+
+	if _, err := e.referencedRegistries(e.repoDir(cfg), cfg); err == nil {
+		t.Fatal("referencedRegistries picked a compose file out of several")
+	}
+	if err := e.Teardown(context.Background(), cfg, false); err == nil || !strings.Contains(err.Error(), "several compose files") {
+		t.Fatalf("Teardown = %v, want the ambiguity error", err)
+	}
+
+	cfg.BuildFile = "docker-compose.yml"
+	refs, err := e.referencedRegistries(e.repoDir(cfg), cfg)
+	if err != nil {
+		t.Fatalf("referencedRegistries with build_file: %v", err)
+	}
+	if len(refs) != 0 {
+		t.Fatalf("refs = %v, want none for a file that names no registry", refs)
+	}
+}
+
+func TestResolveCommandUsesRuntime(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		cli  docker.CLI
+		cfg  Config
+		want string
+	}{
+		{"docker compose", docker.NewCLI("", docker.Options{}), Config{BuildSystem: "compose"},
+			"docker compose -p uruflow-api -f compose.yaml up -d --build"},
+		{"podman compose", docker.NewCLI(docker.RuntimePodman, docker.Options{}), Config{BuildSystem: "compose"},
+			"podman compose -p uruflow-api -f compose.yaml up -d --build"},
+		{"docker over tls", docker.NewCLI(docker.RuntimeDocker, docker.Options{Endpoint: "tcp://10.0.0.5:2376", TLSCA: "/etc/docker/ca.pem"}),
+			Config{BuildSystem: "dockerfile"},
+			"docker '--tlsverify' '--tlscacert' '/etc/docker/ca.pem' build --label io.uruflow.managed=true -t api . && " +
+				"docker '--tlsverify' '--tlscacert' '/etc/docker/ca.pem' run -d --name uruflow-api --label io.uruflow.managed=true api"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeFiles(t, dir, "compose.yaml", "Dockerfile")
+			e := NewExecutor(t.TempDir())
+			e.SetRuntime(tc.cli)
+			tc.cfg.Name = "api"
+			cmd, _, err := e.resolveCommand(dir, tc.cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cmd != tc.want {
+				t.Fatalf("command = %q\nwant      %q", cmd, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,79 @@
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+
+package deploy
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+var (
+	buildkitStep   = regexp.MustCompile(`^#(\d+) \[[^\]]*\d+/\d+\]`)
+	buildkitCached = regexp.MustCompile(`^#(\d+) CACHED`)
+	legacyStep     = regexp.MustCompile(`^Step \d+/\d+ :`)
+)
+
+type buildStats struct {
+	mu     sync.Mutex
+	steps  map[string]bool
+	cached map[string]bool
+	legacy int
+	hits   int
+}
+
+func (s *buildStats) observe(line string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.steps == nil {
+		s.steps = make(map[string]bool)
+		s.cached = make(map[string]bool)
+	}
+
+	line = strings.TrimSpace(line)
+	if m := buildkitStep.FindStringSubmatch(line); m != nil {
+		s.steps[m[1]] = true
+		return
+	}
+	if m := buildkitCached.FindStringSubmatch(line); m != nil {
+		s.cached[m[1]] = true
+		return
+	}
+	if legacyStep.MatchString(line) {
+		s.legacy++
+		return
+	}
+	if strings.Contains(line, "---> Using cache") {
+		s.hits++
+	}
+}
+
+func (s *buildStats) summary() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	total := len(s.steps) + s.legacy
+	if total == 0 {
+		return ""
+	}
+	hits := len(s.cached) + s.hits
+	return fmt.Sprintf("Build cache: %d/%d steps cached (%.0f%%)", hits, total, float64(hits)/float64(total)*100)
+}
@@ -0,0 +1,86 @@
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+
+package deploy
+
+import "testing"
+
+func TestResolveCommandCacheOptions(t *testing.T) {
+	cases := []struct {
+		name string
+		cfg  Config
+		want string
+	}{
+		{"compose", Config{BuildSystem: "compose"},
+			"docker compose -p uruflow-api -f compose.yaml up -d --build"},
+		{"compose without cache", Config{BuildSystem: "compose", NoCache: true},
+			"docker compose -p uruflow-api -f compose.yaml build --no-cache && docker compose -p uruflow-api -f compose.yaml up -d"},
+		{"compose release without cache", Config{BuildSystem: "compose", NoCache: true, Strategy: StrategyReleases},
+			"docker compose -p uruflow-api -f compose.yaml build --no-cache"},
+		{"dockerfile without cache", Config{BuildSystem: "dockerfile", NoCache: true},
+			"docker build --no-cache --label io.uruflow.managed=true -t api . && docker run -d --name uruflow-api --label io.uruflow.managed=true api"},
+		{"build file without cache", Config{BuildSystem: "dockerfile", BuildFile: "Dockerfile.prod", NoCache: true},
+			"docker build --no-cache --label io.uruflow.managed=true -f Dockerfile.prod -t api . && docker run -d --name uruflow-api --label io.uruflow.managed=true api"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeFiles(t, dir, "compose.yaml", "Dockerfile")
+			tc.cfg.Name = "api"
+			cmd, _, err := NewExecutor(t.TempDir()).resolveCommand(dir, tc.cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cmd != tc.want {
+				t.Fatalf("command = %q\nwant      %q", cmd, tc.want)
+			}
+		})
+	}
+}
+
+func TestBuildStatsSummary(t *testing.T) {
+	cases := []struct {
+		name  string
+		lines []string
+		want  string
+	}{
+		{"no build output", []string{"Pulling api", "done"}, ""},
+		{"buildkit", []string{
+			"#5 [1/3] FROM docker.io/library/golang:1.25",
+			"#5 CACHED",
+			"#6 [2/3] COPY . .",
+			"#7 [3/3] RUN go build ./...",
+			"#7 DONE 12.3s",
+		}, "Build cache: 1/3 steps cached (33%)"},
+		{"legacy builder", []string{
+			"Step 1/2 : FROM alpine",
+			" ---> Using cache",
+			"Step 2/2 : RUN make",
+			" ---> Using cache",
+		}, "Build cache: 2/2 steps cached (100%)"},
+	}
+	for _, tc := range cases {
+		var s buildStats
+		for _, line := range tc.lines {
+			s.observe(line)
+		}
+		if got := s.summary(); got != tc.want {
+			t.Errorf("%s: summary = %q, want %q", tc.name, got, tc.want)
+		}
+	}
+}
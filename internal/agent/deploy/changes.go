@@ -0,0 +1,83 @@
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+
+package deploy
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+const maxChangeSubjects = 20
+
+func (e *Executor) changeSummary(ctx context.Context, repoDir, prev, head string) string {
+	if prev == "" {
+		return "n/a (first deploy)"
+	}
+	if prev == head {
+		return "no changes since the previous deploy"
+	}
+	if _, err := gitOutput(ctx, repoDir, "merge-base", "--is-ancestor", prev, head); err != nil {
+		return fmt.Sprintf("n/a (previous commit %s is not an ancestor of %s)", shortHash(prev), shortHash(head))
+	}
+
+	span := prev + ".." + head
+	count, err := gitOutput(ctx, repoDir, "rev-list", "--count", span)
+	if err != nil {
+		return "n/a"
+	}
+	commits, _ := strconv.Atoi(count)
+
+	headline := fmt.Sprintf("%d commit", commits)
+	if commits != 1 {
+		headline += "s"
+	}
+	if stat, err := gitOutput(ctx, repoDir, "diff", "--shortstat", prev, head); err == nil && stat != "" {
+		headline += ", " + stat
+	}
+
+	lines := []string{headline}
+	log, err := gitOutput(ctx, repoDir, "log", "--oneline", "--no-decorate", "-n", strconv.Itoa(maxChangeSubjects), span)
+	if err == nil && log != "" {
+		lines = append(lines, strings.Split(log, "\n")...)
+	}
+	if commits > maxChangeSubjects {
+		lines = append(lines, fmt.Sprintf("… and %d more", commits-maxChangeSubjects))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func gitOutput(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	output, err := cmd.Output()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+func shortHash(hash string) string {
+	if len(hash) > 7 {
+		return hash[:7]
+	}
+	return hash
+}
@@ -0,0 +1,133 @@
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+
+package deploy
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/urustack/uruflow/internal/agent/docker"
+)
+
+const fakeCompose = `#!/bin/sh
+case "$*" in
+*" config")
+	echo "services:"
+	echo "  api:"
+	echo "    image: ${URUFLOW_IMAGE:-api:local}"
+	echo "    environment:"
+	echo "      APP_TAG: ${APP_TAG:-latest}"
+	echo "      DB_PASSWORD: ${DB_PASSWORD}"
+	;;
+*" images --format json")
+	echo "[{\"Repository\":\"${URUFLOW_IMAGE:-api:local}\"}]"
+	;;
+esac
+`
+
+func newComposeExecutor(t *testing.T) (*Executor, string) {
+	t.Helper()
+	script := filepath.Join(t.TempDir(), "docker")
+	if err := os.WriteFile(script, []byte(fakeCompose), 0755); err != nil {
+		t.Fatal(err)
+	}
+	e := NewExecutor(t.TempDir())
+	e.SetRuntime(docker.CLI{Runtime: script})
+	return e, t.TempDir()
+}
+
+func TestComposeConfigHashUsesDeployEnv(t *testing.T) {
+	e, dir := newComposeExecutor(t)
+	ctx := context.Background()
+	hash := func(env map[string]string) string {
+		t.Helper()
+		h, err := e.ComposeConfigHash(ctx, dir, "uruflow-api", "compose.yaml", env)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return h
+	}
+
+	v1 := hash(map[string]string{"APP_TAG": "v1"})
+	if v1 == hash(nil) {
+		t.Fatal("hash ignores the deploy env")
+	}
+	if v1 != hash(map[string]string{"APP_TAG": "v1"}) {
+		t.Fatal("hash is not stable for the same env")
+	}
+	if v1 == hash(map[string]string{"APP_TAG": "v2"}) {
+		t.Fatal("changing an env value did not change the hash")
+	}
+}
+
+func TestImageDeployHashesWithDeployedImage(t *testing.T) {
+	e, _ := newComposeExecutor(t)
+	dir := filepath.Join(e.workDir, "api")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "compose.yaml"), []byte("services: {}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	cfg := Config{Name: "api", BuildSystem: "image", BuildFile: "compose.yaml", Image: "ghcr.io/acme/api:v2", Env: map[string]string{"APP_TAG": "v2"}}
+	result, err := e.Execute(ctx, cfg)
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if result.Env["URUFLOW_IMAGE"] != cfg.Image || result.Env["APP_TAG"] != "v2" {
+		t.Fatalf("result env = %v, want the deploy env with URUFLOW_IMAGE", result.Env)
+	}
+
+	want, err := e.ComposeConfigHash(ctx, dir, "uruflow-api", "compose.yaml", map[string]string{"APP_TAG": "v2", "URUFLOW_IMAGE": cfg.Image})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.ConfigHash != want {
+		t.Fatal("config hash was not resolved against the deployed image")
+	}
+}
+
+func TestArtifactsResolveWithDeployEnv(t *testing.T) {
+	e, dir := newComposeExecutor(t)
+	cfg := Config{Name: "api", Env: map[string]string{"APP_TAG": "v2", "DB_PASSWORD": "s3cret-password"}}
+	env := map[string]string{"APP_TAG": "v2", "DB_PASSWORD": "s3cret-password", "URUFLOW_IMAGE": "ghcr.io/acme/api:v2"}
+	result := &Result{RepoDir: dir, Project: "uruflow-api", ComposeFile: "compose.yaml", Env: env}
+
+	artifacts := e.Artifacts(context.Background(), cfg, result)
+	if len(artifacts) != 2 {
+		t.Fatalf("got %d artifacts, want 2", len(artifacts))
+	}
+	config, images := artifacts[0].Data, artifacts[1].Data
+	for _, want := range []string{"image: ghcr.io/acme/api:v2", "APP_TAG: v2", "DB_PASSWORD: ****"} {
+		if !strings.Contains(config, want) {
+			t.Errorf("compose-config.yaml missing %q:\n%s", want, config)
+		}
+	}
+	if strings.Contains(config, "s3cret-password") {
+		t.Error("compose-config.yaml leaks an env value")
+	}
+	if !strings.Contains(images, "ghcr.io/acme/api:v2") {
+		t.Errorf("images.json not resolved against the deployed image: %s", images)
+	}
+}
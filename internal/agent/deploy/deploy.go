@@ -21,92 +21,408 @@ package deploy
 import (
 	"bufio"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
+
+	"github.com/urustack/uruflow/internal/agent/docker"
+)
+
+const (
+	DirtyProceed = "proceed"
+	DirtyAbort   = "abort"
+	DirtyStash   = "stash"
+)
+
+const minMaskLen = 4
+
+var ErrDirtyWorkspace = errors.New("workspace has local modifications")
+
+const (
+	StepRunning = "running"
+	StepDone    = "done"
+	StepFailed  = "failed"
 )
 
 type Executor struct {
-	workDir string
-	onLog   func(stream, line string)
+	workDir     string
+	dirtyPolicy string
+	onLog       func(stream, line string)
+	onStep      func(Step)
+	masks       []string
+	gitEnv      []string
+	gitArgs     []string
+	registries  *registryLogins
+	limits      *limiter
+	background  *limiter
+	cli         docker.CLI
+}
+
+type Step struct {
+	Name      string
+	Status    string
+	StartedAt time.Time
+	Duration  time.Duration
 }
 
 type Config struct {
-	URL         string
-	Name        string
-	Branch      string
-	Commit      string
-	Path        string
-	BuildSystem string
-	BuildFile   string
-	BuildCmd    string
-	Env         map[string]string
+	URL          string
+	Name         string
+	Branch       string
+	Tag          string
+	Commit       string
+	VerifyBranch bool
+	Path         string
+	BuildSystem  string
+	BuildFile    string
+	BuildCmd     string
+	Image        string
+	Container    string
+	Ports        []string
+	NoCache      bool
+	Builder      string
+	PreDeploy    string
+	PostDeploy   string
+	Strategy     string
+	KeepReleases int
+	Env          map[string]string
+	Auth         *Auth
+	DryRun       bool
 }
 
 type Result struct {
-	Success  bool
-	Duration time.Duration
-	Commit   string
-	Error    string
+	Success       bool
+	Duration      time.Duration
+	Commit        string
+	Error         string
+	RepoDir       string
+	Project       string
+	ComposeFile   string
+	ConfigHash    string
+	Env           map[string]string
+	ChangeSummary string
+	BuildFile     string
+	ExitCode      int
 }
 
 func NewExecutor(workDir string) *Executor {
 	os.MkdirAll(workDir, 0755)
-	return &Executor{workDir: workDir}
+	return &Executor{workDir: workDir, dirtyPolicy: DirtyProceed, cli: docker.NewCLI("", docker.Options{})}
+}
+
+func (e *Executor) SetRuntime(cli docker.CLI) {
+	e.cli = cli
+}
+
+func (e *Executor) SetDirtyPolicy(policy string) {
+	if policy == "" {
+		policy = DirtyProceed
+	}
+	e.dirtyPolicy = policy
+}
+
+func (e *Executor) SetLimits(l Limits) (string, []string) {
+	if l.empty() {
+		e.limits = nil
+		return "", nil
+	}
+	e.limits = newLimiter(l)
+	return e.limits.applied(), e.limits.skipped
 }
 
 func (e *Executor) OnLog(handler func(stream, line string)) {
 	e.onLog = handler
 }
 
+func (e *Executor) WithLog(handler func(stream, line string)) *Executor {
+	c := *e
+	c.onLog = handler
+	return &c
+}
+
+func (e *Executor) WithStep(handler func(Step)) *Executor {
+	c := *e
+	c.onStep = handler
+	return &c
+}
+
+func (e *Executor) step(name string, fn func() error) error {
+	start := time.Now()
+	e.emitStep(Step{Name: name, Status: StepRunning, StartedAt: start})
+
+	err := fn()
+
+	status := StepDone
+	if err != nil {
+		status = StepFailed
+	}
+	e.emitStep(Step{Name: name, Status: status, StartedAt: start, Duration: time.Since(start)})
+	return err
+}
+
+func (e *Executor) withMasks(env map[string]string) *Executor {
+	if len(env) == 0 {
+		return e
+	}
+	c := *e
+	c.masks = nil
+	for _, v := range env {
+		if len(v) >= minMaskLen {
+			c.masks = append(c.masks, v)
+		}
+	}
+	sort.Slice(c.masks, func(i, j int) bool { return len(c.masks[i]) > len(c.masks[j]) })
+	return &c
+}
+
+func (e *Executor) emitStep(s Step) {
+	if e.onStep != nil {
+		e.onStep(s)
+	}
+}
+
 func (e *Executor) Execute(ctx context.Context, cfg Config) (*Result, error) {
 	start := time.Now()
+	result, err := e.execute(ctx, cfg, start)
+	if result == nil {
+		result = &Result{}
+	}
+	result.ExitCode = ExitCode(ctx, err)
+	if result.ExitCode == ExitTimeout {
+		err = fmt.Errorf("deploy timed out: %w", err)
+		result.Error = err.Error()
+	}
+	if result.Duration == 0 {
+		result.Duration = time.Since(start)
+	}
+	return result, err
+}
+
+func (e *Executor) LogSummary(result *Result, status string, exitCode int) {
+	if result == nil {
+		result = &Result{}
+	}
+	stream := "stdout"
+	if status == "failed" {
+		stream = "stderr"
+	}
+	e.log(stream, fmt.Sprintf("› Summary: status=%s duration=%s exit_code=%d commit=%s",
+		status, result.Duration.Round(time.Millisecond), exitCode, orNone(shortHash(result.Commit))))
+}
+
+func (e *Executor) execute(ctx context.Context, cfg Config, start time.Time) (*Result, error) {
 	result := &Result{}
+	e = e.withMasks(cfg.Env)
 
-	repoDir := filepath.Join(e.workDir, cfg.Name)
-	if cfg.Path != "" {
-		repoDir = cfg.Path
+	if err := e.checkPath(cfg); err != nil {
+		result.Error = err.Error()
+		e.log("stderr", "› "+result.Error)
+		return result, err
+	}
+	if cfg.BuildSystem == "image" {
+		return e.deployImage(ctx, cfg, start)
+	}
+
+	authed, cleanup, err := e.withAuth(cfg.Auth)
+	if err != nil {
+		result.Error = err.Error()
+		e.log("stderr", "› "+result.Error)
+		return result, err
 	}
+	defer cleanup()
+	e = authed
+
+	repoDir := e.repoDir(cfg)
+	sourceDir := e.sourceDir(cfg)
+	releases := cfg.releases()
 
 	e.log("stdout", fmt.Sprintf("› Deploying %s", cfg.Name))
+	e.log("stdout", fmt.Sprintf("› Checking out into %s", sourceDir))
+	if cfg.Strategy == StrategyReleases && !releases {
+		e.log("stdout", fmt.Sprintf("› Custom path %s, deploying in place", repoDir))
+	}
+	if releases {
+		if err := e.checkReleaseLayout(cfg); err != nil {
+			result.Error = err.Error()
+			e.log("stderr", "› "+result.Error)
+			return result, err
+		}
+	}
+
+	prev, _ := e.getCommitHash(ctx, repoDir)
 
 	e.log("stdout", "› Cloning/pulling repository...")
-	if err := e.cloneOrPull(ctx, cfg.URL, cfg.Branch, repoDir); err != nil {
+	pinned := cfg.Commit != "" && cfg.Commit != "HEAD"
+	err = e.step("clone", func() error {
+		return e.cloneOrPull(ctx, cfg, pinned, sourceDir)
+	})
+	if err != nil {
 		result.Error = err.Error()
 		return result, err
 	}
 
-	if cfg.Commit != "" && cfg.Commit != "HEAD" {
-		shortCommit := cfg.Commit
-		if len(shortCommit) > 7 {
-			shortCommit = shortCommit[:7]
+	if pinned && cfg.VerifyBranch && cfg.Tag == "" {
+		err := e.step("verify", func() error {
+			return e.verifyOnBranch(ctx, sourceDir, cfg.Branch, cfg.Commit)
+		})
+		if err != nil {
+			result.Error = err.Error()
+			e.log("stderr", "› "+result.Error)
+			return result, err
+		}
+	}
+
+	if cfg.DryRun {
+		return e.dryRun(ctx, cfg, sourceDir, prev, pinned, start)
+	}
+
+	buildDir := repoDir
+	if releases {
+		target := "origin/" + cfg.Branch
+		if pinned {
+			target = cfg.Commit
+		}
+		err := e.step("release", func() error {
+			dir, err := e.prepareRelease(ctx, cfg, sourceDir, target)
+			buildDir = dir
+			return err
+		})
+		if err != nil {
+			result.Error = err.Error()
+			return result, err
 		}
-		e.log("stdout", fmt.Sprintf("› Checking out %s", shortCommit))
-		if err := e.runCmd(ctx, repoDir, "git", "checkout", cfg.Commit); err != nil {
+	} else if pinned {
+		err := e.step("checkout", func() error {
+			return e.checkoutCommit(ctx, repoDir, cfg.ref(), cfg.Commit)
+		})
+		if err != nil {
 			result.Error = err.Error()
 			return result, err
 		}
 	}
+	release := filepath.Base(buildDir)
+	discard := func() {
+		if releases {
+			e.removeRelease(cfg, release)
+			e.log("stderr", fmt.Sprintf("› Discarded release %s, current release unchanged", release))
+		}
+	}
 
-	hash, _ := e.getCommitHash(ctx, repoDir)
+	hash, _ := e.getCommitHash(ctx, buildDir)
 	result.Commit = hash
+	result.ChangeSummary = e.changeSummary(ctx, sourceDir, prev, hash)
+	e.log("stdout", "› Changes: "+strings.SplitN(result.ChangeSummary, "\n", 2)[0])
 
-	cmd, err := e.resolveCommand(repoDir, cfg)
+	cmd, buildFile, err := e.resolveCommand(buildDir, cfg)
 	if err != nil {
 		result.Error = err.Error()
 		e.log("stderr", result.Error)
+		discard()
+		return result, err
+	}
+	result.BuildFile = buildFile
+	if buildFile != "" {
+		e.log("stdout", "› Build file: "+buildFile)
+	}
+
+	env := cfg.Env
+	if cfg.Builder != "" {
+		env = make(map[string]string, len(cfg.Env)+1)
+		for k, v := range cfg.Env {
+			env[k] = v
+		}
+		env["BUILDX_BUILDER"] = cfg.Builder
+		e.log("stdout", fmt.Sprintf("› Using builder %s", cfg.Builder))
+	}
+
+	if err := e.registryLogin(ctx, buildDir, cfg); err != nil {
+		result.Error = err.Error()
+		discard()
 		return result, err
 	}
 
+	if cfg.PreDeploy != "" {
+		if err := e.runHook(ctx, "pre", buildDir, cfg.PreDeploy, env); err != nil {
+			result.Error = err.Error()
+			e.log("stderr", "› "+result.Error)
+			discard()
+			return result, err
+		}
+	}
+
+	stats := &buildStats{}
 	e.log("stdout", fmt.Sprintf("› Running: %s", cmd))
-	if err := e.runScript(ctx, repoDir, cmd, cfg.Env); err != nil {
+	if e.limits != nil {
+		e.log("stdout", "› Limits: "+e.limits.String())
+	}
+	err = e.step("build", func() error {
+		return e.runScriptObserved(ctx, buildDir, cmd, env, stats.observe)
+	})
+	if summary := stats.summary(); summary != "" {
+		e.log("stdout", "› "+summary)
+	}
+	if err != nil {
 		result.Error = err.Error()
+		discard()
 		return result, err
 	}
 
+	compose := cfg.BuildSystem == "compose" && cfg.BuildCmd == ""
+	var composeFile string
+	if compose {
+		composeFile = buildFile
+	}
+
+	if releases {
+		previous := e.currentRelease(cfg)
+		err := e.step("switch", func() error {
+			e.log("stdout", fmt.Sprintf("› Switching current to release %s", release))
+			return e.switchCurrent(cfg, release)
+		})
+		if err != nil {
+			result.Error = err.Error()
+			discard()
+			return result, err
+		}
+
+		if compose {
+			err := e.step("up", func() error {
+				return e.runCmdObserved(ctx, repoDir, e.composeEnv(env), nil, e.cli.Runtime, e.cli.Argv("compose", "-p", ProjectName(cfg.Name),
+					"--project-directory", repoDir, "-f", composeFile, "up", "-d", "--remove-orphans")...)
+			})
+			if err != nil {
+				result.Error = err.Error()
+				e.restoreCurrent(cfg, previous)
+				e.removeRelease(cfg, release)
+				return result, err
+			}
+		}
+
+		for _, old := range e.pruneReleases(cfg) {
+			e.log("stdout", fmt.Sprintf("› Pruned release %s", old))
+		}
+	}
+
+	result.RepoDir = repoDir
+	if compose {
+		result.Project = ProjectName(cfg.Name)
+		result.ComposeFile = composeFile
+		result.Env = env
+		hash, err := e.ComposeConfigHash(ctx, repoDir, result.Project, result.ComposeFile, env)
+		if err != nil {
+			e.log("stderr", fmt.Sprintf("› Could not hash compose config: %v", err))
+		}
+		result.ConfigHash = hash
+	}
+
 	result.Success = true
 	result.Duration = time.Since(start)
 
@@ -115,34 +431,158 @@ func (e *Executor) Execute(ctx context.Context, cfg Config) (*Result, error) {
 	return result, nil
 }
 
-func (e *Executor) resolveCommand(repoDir string, cfg Config) (string, error) {
+func (e *Executor) Teardown(ctx context.Context, cfg Config, removeDir bool) error {
+	if err := e.checkPath(cfg); err != nil {
+		return err
+	}
+	repoDir := e.repoDir(cfg)
+	e.log("stdout", fmt.Sprintf("› Tearing down %s", cfg.Name))
+
+	var err error
+	switch cfg.BuildSystem {
+	case "compose":
+		args := []string{"compose", "-p", ProjectName(cfg.Name)}
+		dir := e.workDir
+		if _, statErr := os.Stat(repoDir); statErr == nil {
+			dir = repoDir
+			file := cfg.BuildFile
+			if file == "" {
+				found, findErr := e.findComposeFile(repoDir)
+				if findErr != nil {
+					e.log("stderr", "› "+findErr.Error())
+					return findErr
+				}
+				file = found
+			}
+			if file != "" {
+				args = append(args, "-f", file)
+			}
+		}
+		args = append(args, "down", "--remove-orphans")
+		err = e.step("down", func() error {
+			return e.runtimeCmd(ctx, dir, args...)
+		})
+	case "dockerfile":
+		err = e.step("down", func() error {
+			return e.runtimeCmd(ctx, e.workDir, "rm", "-f", ProjectName(cfg.Name))
+		})
+	case "image":
+		dir := e.imageDir(cfg)
+		if cfg.BuildFile != "" && e.fileExists(dir, cfg.BuildFile) {
+			err = e.step("down", func() error {
+				return e.runtimeCmd(ctx, dir, "compose", "-p", ProjectName(cfg.Name), "-f", cfg.BuildFile, "down", "--remove-orphans")
+			})
+		} else {
+			err = e.step("down", func() error {
+				return e.runtimeCmd(ctx, e.workDir, "rm", "-f", ContainerName(cfg))
+			})
+		}
+	default:
+		e.log("stdout", fmt.Sprintf("› Nothing to stop for build system %q", cfg.BuildSystem))
+	}
+	if err != nil {
+		return err
+	}
+
+	if removeDir {
+		if cfg.Path != "" {
+			e.log("stdout", fmt.Sprintf("› Leaving custom path %s in place", repoDir))
+		} else {
+			dir := repoDir
+			if cfg.releases() {
+				dir = e.baseDir(cfg)
+			}
+			e.log("stdout", fmt.Sprintf("› Removing %s", dir))
+			if err := os.RemoveAll(dir); err != nil {
+				return fmt.Errorf("remove working directory: %w", err)
+			}
+		}
+	}
+
+	e.log("stdout", "› Teardown complete")
+	return nil
+}
+
+func (e *Executor) repoDir(cfg Config) string {
+	if cfg.Path != "" {
+		return e.customDir(cfg)
+	}
+	if cfg.releases() {
+		return e.currentDir(cfg)
+	}
+	return filepath.Join(e.workDir, cfg.Name)
+}
+
+func (e *Executor) customDir(cfg Config) string {
+	if filepath.IsAbs(cfg.Path) {
+		return filepath.Clean(cfg.Path)
+	}
+	return filepath.Join(e.workDir, cfg.Path)
+}
+
+func (e *Executor) checkPath(cfg Config) error {
+	if cfg.Path == "" || filepath.IsAbs(cfg.Path) {
+		return nil
+	}
+	rel := filepath.Clean(cfg.Path)
+	if rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return fmt.Errorf("path %s must stay inside the work directory %s", cfg.Path, e.workDir)
+	}
+	return nil
+}
+
+func (e *Executor) resolveCommand(repoDir string, cfg Config) (string, string, error) {
 	if cfg.BuildCmd != "" {
-		return cfg.BuildCmd, nil
+		return cfg.BuildCmd, cfg.BuildFile, nil
 	}
 
 	switch cfg.BuildSystem {
 	case "compose":
 		file := cfg.BuildFile
 		if file == "" {
-			file = e.findComposeFile(repoDir)
+			found, err := e.findComposeFile(repoDir)
+			if err != nil {
+				return "", "", err
+			}
+			file = found
 		}
 		if file == "" {
-			return "", fmt.Errorf("no compose file found")
+			return "", "", fmt.Errorf("no compose file found (looked for %s)", strings.Join(composeFiles, ", "))
+		}
+		projectName := ProjectName(cfg.Name)
+		bin := e.runtimeShell()
+		if cfg.releases() {
+			if cfg.NoCache {
+				return fmt.Sprintf("%s compose -p %s -f %s build --no-cache", bin, projectName, file), file, nil
+			}
+			return fmt.Sprintf("%s compose -p %s -f %s build", bin, projectName, file), file, nil
 		}
-		projectName := fmt.Sprintf("uruflow-%s", cfg.Name)
-		return fmt.Sprintf("docker compose -p %s -f %s up -d --build", projectName, file), nil
+		if cfg.NoCache {
+			return fmt.Sprintf("%s compose -p %s -f %s build --no-cache && %s compose -p %s -f %s up -d",
+				bin, projectName, file, bin, projectName, file), file, nil
+		}
+		return fmt.Sprintf("%s compose -p %s -f %s up -d --build", bin, projectName, file), file, nil
 
 	case "dockerfile":
 		containerName := fmt.Sprintf("uruflow-%s", cfg.Name)
+		bin := e.runtimeShell()
+		buildFlags := ""
+		if cfg.NoCache {
+			buildFlags = " --no-cache"
+		}
 		if cfg.BuildFile != "" {
-			return fmt.Sprintf("docker build -f %s -t %s . && docker run -d --name %s --label io.uruflow.managed=true %s",
-				cfg.BuildFile, cfg.Name, containerName, cfg.Name), nil
+			return fmt.Sprintf("%s build%s --label io.uruflow.managed=true -f %s -t %s . && %s run -d --name %s --label io.uruflow.managed=true %s",
+				bin, buildFlags, cfg.BuildFile, cfg.Name, bin, containerName, cfg.Name), cfg.BuildFile, nil
 		}
 		if !e.fileExists(repoDir, "Dockerfile") {
-			return "", fmt.Errorf("no Dockerfile found")
+			return "", "", fmt.Errorf("no Dockerfile found")
 		}
-		return fmt.Sprintf("docker build -t %s . && docker run -d --name %s --label io.uruflow.managed=true %s",
-			cfg.Name, containerName, cfg.Name), nil
+		return fmt.Sprintf("%s build%s --label io.uruflow.managed=true -t %s . && %s run -d --name %s --label io.uruflow.managed=true %s",
+			bin, buildFlags, cfg.Name, bin, containerName, cfg.Name), "Dockerfile", nil
+
+	case "image":
+		cmd, err := e.imageCommand(repoDir, cfg)
+		return cmd, cfg.BuildFile, err
 
 	case "makefile":
 		file := cfg.BuildFile
@@ -150,26 +590,63 @@ func (e *Executor) resolveCommand(repoDir string, cfg Config) (string, error) {
 			file = "Makefile"
 		}
 		if !e.fileExists(repoDir, file) {
-			return "", fmt.Errorf("no %s found", file)
+			return "", "", fmt.Errorf("no %s found", file)
 		}
-		return fmt.Sprintf("make -f %s deploy", file), nil
+		return fmt.Sprintf("make -f %s deploy", file), file, nil
 
 	case "":
-		return "", fmt.Errorf("build_system not specified in repository config")
+		return "", "", fmt.Errorf("build_system not specified in repository config")
 
 	default:
-		return "", fmt.Errorf("unknown build_system: %s", cfg.BuildSystem)
+		return "", "", fmt.Errorf("unknown build_system: %s", cfg.BuildSystem)
 	}
 }
 
-func (e *Executor) findComposeFile(repoDir string) string {
-	if e.fileExists(repoDir, "docker-compose.yml") {
-		return "docker-compose.yml"
+func ProjectName(name string) string {
+	return fmt.Sprintf("uruflow-%s", name)
+}
+
+func (e *Executor) ComposeConfigHash(ctx context.Context, repoDir, project, file string, env map[string]string) (string, error) {
+	cmd := e.composeCmd(ctx, repoDir, env, "-p", project, "-f", file, "config")
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("%s compose config: %w", e.cli.Runtime, err)
 	}
-	if e.fileExists(repoDir, "docker-compose.yaml") {
-		return "docker-compose.yaml"
+	sum := sha256.Sum256(output)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+func (e *Executor) composeCmd(ctx context.Context, dir string, env map[string]string, args ...string) *exec.Cmd {
+	cmd := e.cli.Command(ctx, append([]string{"compose"}, args...)...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), e.composeEnv(env)...)
+	return cmd
+}
+
+func (e *Executor) composeEnv(env map[string]string) []string {
+	vars := append([]string(nil), e.cli.Env...)
+	for k, v := range env {
+		vars = append(vars, fmt.Sprintf("%s=%s", k, v))
+	}
+	return vars
+}
+
+var composeFiles = []string{"compose.yaml", "compose.yml", "docker-compose.yaml", "docker-compose.yml"}
+
+func (e *Executor) findComposeFile(repoDir string) (string, error) {
+	var found []string
+	for _, name := range composeFiles {
+		if e.fileExists(repoDir, name) {
+			found = append(found, name)
+		}
 	}
-	return ""
+	switch len(found) {
+	case 0:
+		return "", nil
+	case 1:
+		return found[0], nil
+	}
+	return "", fmt.Errorf("found several compose files (%s), set build_file on the repository to pick one", strings.Join(found, ", "))
 }
 
 func (e *Executor) fileExists(repoDir, file string) bool {
@@ -177,18 +654,119 @@ func (e *Executor) fileExists(repoDir, file string) bool {
 	return err == nil
 }
 
-func (e *Executor) cloneOrPull(ctx context.Context, repoURL, branch, repoDir string) error {
+func (cfg Config) ref() string {
+	if cfg.Tag != "" {
+		return cfg.Tag
+	}
+	return cfg.Branch
+}
+
+func (e *Executor) cloneOrPull(ctx context.Context, cfg Config, pinned bool, repoDir string) error {
+	branch := cfg.ref()
 	if _, err := os.Stat(filepath.Join(repoDir, ".git")); os.IsNotExist(err) {
 		parentDir := filepath.Dir(repoDir)
 		os.MkdirAll(parentDir, 0755)
-		return e.runCmd(ctx, parentDir, "git", "clone", "-b", branch, "--single-branch", repoURL, filepath.Base(repoDir))
+		return e.runGit(ctx, parentDir, cfg.Name, "clone", "-b", branch, "--single-branch", cfg.URL, filepath.Base(repoDir))
+	}
+
+	refspec := fmt.Sprintf("+refs/heads/%s:refs/remotes/origin/%s", branch, branch)
+	if cfg.Tag != "" {
+		refspec = fmt.Sprintf("+refs/tags/%s:refs/tags/%s", cfg.Tag, cfg.Tag)
+	}
+	if err := e.runGit(ctx, repoDir, cfg.Name, "fetch", "origin", refspec); err != nil {
+		return err
+	}
+
+	if err := e.checkWorkspace(ctx, repoDir); err != nil {
+		return err
+	}
+
+	if pinned {
+		return nil
+	}
+	return e.runCmd(ctx, repoDir, "git", "checkout", "-f", "-B", branch, "origin/"+branch)
+}
+
+func (e *Executor) verifyOnBranch(ctx context.Context, repoDir, branch, commit string) error {
+	e.log("stdout", fmt.Sprintf("› Verifying %s is on %s", shortHash(commit), branch))
+
+	if err := e.runCmd(ctx, repoDir, "git", "cat-file", "-e", commit+"^{commit}"); err != nil {
+		return fmt.Errorf("commit %s does not exist on %s, it was probably rewritten by a force-push", shortHash(commit), branch)
 	}
+	cmd := exec.CommandContext(ctx, "git", "merge-base", "--is-ancestor", commit, "origin/"+branch)
+	cmd.Dir = repoDir
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+			return fmt.Errorf("commit %s is not on branch %s, it was probably rewritten by a force-push", shortHash(commit), branch)
+		}
+		return fmt.Errorf("git merge-base: %w", err)
+	}
+	return nil
+}
 
-	if err := e.runCmd(ctx, repoDir, "git", "fetch", "origin"); err != nil {
+func (e *Executor) checkoutCommit(ctx context.Context, repoDir, branch, commit string) error {
+	shortCommit := shortHash(commit)
+	e.log("stdout", fmt.Sprintf("› Checking out %s", shortCommit))
+
+	if err := e.runCmd(ctx, repoDir, "git", "cat-file", "-e", commit+"^{commit}"); err != nil {
+		return fmt.Errorf("commit %s not found on %s: %w", shortCommit, branch, err)
+	}
+	if err := e.runCmd(ctx, repoDir, "git", "checkout", "-f", "--detach", commit); err != nil {
 		return err
 	}
 
-	return e.runCmd(ctx, repoDir, "git", "reset", "--hard", "origin/"+branch)
+	head, err := e.getCommitHash(ctx, repoDir)
+	if err != nil {
+		return err
+	}
+	if !strings.HasPrefix(head, commit) {
+		return fmt.Errorf("checked out %s but HEAD is %s", shortCommit, head)
+	}
+	return nil
+}
+
+func (e *Executor) checkWorkspace(ctx context.Context, repoDir string) error {
+	cmd := exec.CommandContext(ctx, "git", "status", "--porcelain")
+	cmd.Dir = repoDir
+	output, err := cmd.Output()
+	if err != nil {
+		return fmt.Errorf("git status: %w", err)
+	}
+
+	status := strings.TrimRight(string(output), "\n")
+	if strings.TrimSpace(status) == "" {
+		return nil
+	}
+
+	e.log("stderr", "════════════════════════════════════════")
+	e.log("stderr", "⚠ WARNING: workspace has local modifications")
+	for _, line := range strings.Split(status, "\n") {
+		e.log("stderr", "    "+line)
+	}
+	e.log("stderr", "════════════════════════════════════════")
+
+	switch e.dirtyPolicy {
+	case DirtyAbort:
+		e.log("stderr", "› Aborting deploy (dirty_workspace: abort)")
+		return ErrDirtyWorkspace
+
+	case DirtyStash:
+		msg := fmt.Sprintf("uruflow pre-deploy %s", time.Now().Format(time.RFC3339))
+		if err := e.runCmd(ctx, repoDir, "git", "stash", "push", "--include-untracked", "-m", msg); err != nil {
+			return fmt.Errorf("git stash: %w", err)
+		}
+		ref := exec.CommandContext(ctx, "git", "rev-parse", "stash@{0}")
+		ref.Dir = repoDir
+		out, _ := ref.Output()
+		e.log("stderr", fmt.Sprintf("› Local changes stashed as stash@{0} (%s), recover with: git stash apply %s",
+			strings.TrimSpace(string(out)), strings.TrimSpace(string(out))))
+
+	default:
+		e.log("stderr", "› Proceeding, local modifications will be discarded")
+	}
+
+	return nil
 }
 
 func (e *Executor) getCommitHash(ctx context.Context, repoDir string) (string, error) {
@@ -202,9 +780,22 @@ func (e *Executor) getCommitHash(ctx context.Context, repoDir string) (string, e
 }
 
 func (e *Executor) runScript(ctx context.Context, dir, script string, env map[string]string) error {
+	return e.runScriptObserved(ctx, dir, script, env, nil)
+}
+
+func (e *Executor) runScriptObserved(ctx context.Context, dir, script string, env map[string]string, observe func(string)) error {
 	cmd := exec.CommandContext(ctx, "sh", "-c", script)
+	if e.limits != nil {
+		limited, release, err := e.limits.command(ctx, "sh", "-c", script)
+		if err != nil {
+			e.log("stderr", fmt.Sprintf("› Limits not applied: %v", err))
+		} else {
+			defer release()
+			cmd = limited
+		}
+	}
 	cmd.Dir = dir
-	cmd.Env = os.Environ()
+	cmd.Env = append(os.Environ(), e.cli.Env...)
 	for k, v := range env {
 		cmd.Env = append(cmd.Env, fmt.Sprintf("%s=%s", k, v))
 	}
@@ -218,11 +809,11 @@ func (e *Executor) runScript(ctx context.Context, dir, script string, env map[st
 
 	done := make(chan struct{})
 	go func() {
-		e.scanPipe(stdout, "stdout")
+		e.scanPipeObserved(stdout, "stdout", observe)
 		done <- struct{}{}
 	}()
 	go func() {
-		e.scanPipe(stderr, "stderr")
+		e.scanPipeObserved(stderr, "stderr", observe)
 		done <- struct{}{}
 	}()
 
@@ -233,8 +824,45 @@ func (e *Executor) runScript(ctx context.Context, dir, script string, env map[st
 }
 
 func (e *Executor) runCmd(ctx context.Context, dir string, name string, args ...string) error {
+	return e.runCmdObserved(ctx, dir, nil, nil, name, args...)
+}
+
+func (e *Executor) runtimeCmd(ctx context.Context, dir string, args ...string) error {
+	return e.runCmdObserved(ctx, dir, e.cli.Env, nil, e.cli.Runtime, e.cli.Argv(args...)...)
+}
+
+func (e *Executor) runtimeShell() string {
+	parts := []string{e.cli.Runtime}
+	for _, arg := range e.cli.Args {
+		parts = append(parts, shellQuote(arg))
+	}
+	return strings.Join(parts, " ")
+}
+
+func (e *Executor) runCmdObserved(ctx context.Context, dir string, env []string, observe func(string), name string, args ...string) error {
+	return e.runCmdStdin(ctx, dir, env, nil, observe, name, args...)
+}
+
+func (e *Executor) runCmdStdin(ctx context.Context, dir string, env []string, stdin io.Reader, observe func(string), name string, args ...string) error {
+	return e.runCmdProgress(ctx, dir, env, stdin, observe, nil, name, args...)
+}
+
+func (e *Executor) runCmdProgress(ctx context.Context, dir string, env []string, stdin io.Reader, observe func(string), progress *gitProgress, name string, args ...string) error {
 	cmd := exec.CommandContext(ctx, name, args...)
+	if e.background != nil {
+		limited, release, err := e.background.command(ctx, name, args...)
+		if err != nil {
+			e.log("stderr", fmt.Sprintf("› Limits not applied: %v", err))
+		} else {
+			defer release()
+			cmd = limited
+		}
+	}
 	cmd.Dir = dir
+	cmd.Stdin = stdin
+	if len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
+	}
 
 	stdout, _ := cmd.StdoutPipe()
 	stderr, _ := cmd.StderrPipe()
@@ -245,11 +873,15 @@ func (e *Executor) runCmd(ctx context.Context, dir string, name string, args ...
 
 	done := make(chan struct{})
 	go func() {
-		e.scanPipe(stdout, "stdout")
+		e.scanPipeObserved(stdout, "stdout", observe)
 		done <- struct{}{}
 	}()
 	go func() {
-		e.scanPipe(stderr, "stderr")
+		if progress != nil {
+			e.scanProgress(stderr, observe, progress)
+		} else {
+			e.scanPipeObserved(stderr, "stderr", observe)
+		}
 		done <- struct{}{}
 	}()
 
@@ -259,15 +891,40 @@ func (e *Executor) runCmd(ctx context.Context, dir string, name string, args ...
 	return cmd.Wait()
 }
 
-func (e *Executor) scanPipe(r interface{ Read([]byte) (int, error) }, stream string) {
+func (e *Executor) scanPipeObserved(r interface{ Read([]byte) (int, error) }, stream string, observe func(string)) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if observe != nil {
+			observe(line)
+		}
+		e.log(stream, line)
+	}
+}
+
+func (e *Executor) scanProgress(r io.Reader, observe func(string), progress *gitProgress) {
 	scanner := bufio.NewScanner(r)
+	scanner.Split(scanProgressLines)
 	for scanner.Scan() {
-		e.log(stream, scanner.Text())
+		line := scanner.Text()
+		if observe != nil {
+			observe(line)
+		}
+		if line, ok := progress.filter(line); ok {
+			e.log("stderr", line)
+		}
 	}
 }
 
 func (e *Executor) log(stream, line string) {
 	if e.onLog != nil {
-		e.onLog(stream, line)
+		e.onLog(stream, e.mask(line))
+	}
+}
+
+func (e *Executor) mask(s string) string {
+	for _, secret := range e.masks {
+		s = strings.ReplaceAll(s, secret, "****")
 	}
+	return s
 }
@@ -0,0 +1,106 @@
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+
+package deploy
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDirtyWorkspacePolicies(t *testing.T) {
+	cases := []struct {
+		policy    string
+		wantErr   error
+		wantApp   string
+		wantNotes bool
+		wantStash int
+		wantLog   string
+	}{
+		{DirtyProceed, nil, "v1\n", true, 0, "local modifications will be discarded"},
+		{DirtyAbort, ErrDirtyWorkspace, "hotfix\n", true, 0, "Aborting deploy"},
+		{DirtyStash, nil, "v1\n", false, 1, "recover with: git stash apply"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.policy, func(t *testing.T) {
+			src := newSourceRepo(t)
+			if err := os.WriteFile(filepath.Join(src, "app.txt"), []byte("v1\n"), 0644); err != nil {
+				t.Fatal(err)
+			}
+			git(t, src, "add", "app.txt")
+			git(t, src, "commit", "-q", "-m", "add app")
+
+			e := NewExecutor(t.TempDir())
+			e.SetDirtyPolicy(tc.policy)
+			cfg := Config{Name: "api", URL: src, Branch: "main", BuildCmd: "true"}
+			if _, err := e.Execute(context.Background(), cfg); err != nil {
+				t.Fatalf("first deploy: %v", err)
+			}
+
+			repo := e.repoDir(cfg)
+			if err := os.WriteFile(filepath.Join(repo, "app.txt"), []byte("hotfix\n"), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(filepath.Join(repo, "notes.txt"), []byte("todo\n"), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			var lines []string
+			e.OnLog(func(stream, line string) { lines = append(lines, line) })
+			_, err := e.Execute(context.Background(), cfg)
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("deploy error = %v, want %v", err, tc.wantErr)
+			}
+
+			log := strings.Join(lines, "\n")
+			for _, want := range []string{"workspace has local modifications", " M app.txt", "?? notes.txt", tc.wantLog} {
+				if !strings.Contains(log, want) {
+					t.Fatalf("deploy log does not mention %q:\n%s", want, log)
+				}
+			}
+
+			app, err := os.ReadFile(filepath.Join(repo, "app.txt"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(app) != tc.wantApp {
+				t.Fatalf("app.txt = %q, want %q", app, tc.wantApp)
+			}
+			if _, err := os.Stat(filepath.Join(repo, "notes.txt")); (err == nil) != tc.wantNotes {
+				t.Fatalf("untracked notes.txt present = %v, want %v", err == nil, tc.wantNotes)
+			}
+
+			stashes := strings.Fields(git(t, repo, "stash", "list", "--format=%H"))
+			if len(stashes) != tc.wantStash {
+				t.Fatalf("%d stash entries, want %d", len(stashes), tc.wantStash)
+			}
+			if tc.wantStash > 0 {
+				if got := git(t, repo, "show", "stash@{0}:app.txt"); got != "hotfix\n" {
+					t.Fatalf("stashed app.txt = %q, want the local modification", got)
+				}
+				if !strings.Contains(log, stashes[0]) {
+					t.Fatalf("stash reference %s not logged:\n%s", stashes[0], log)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,104 @@
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+
+package deploy
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+func (e *Executor) dryRun(ctx context.Context, cfg Config, dir, prev string, pinned bool, start time.Time) (*Result, error) {
+	result := &Result{}
+	if pinned {
+		err := e.step("checkout", func() error {
+			return e.checkoutCommit(ctx, dir, cfg.ref(), cfg.Commit)
+		})
+		if err != nil {
+			result.Error = err.Error()
+			return result, err
+		}
+	}
+
+	hash, _ := e.getCommitHash(ctx, dir)
+	result.Commit = hash
+	result.ChangeSummary = e.changeSummary(ctx, dir, prev, hash)
+	e.log("stdout", "› Changes: "+strings.SplitN(result.ChangeSummary, "\n", 2)[0])
+
+	cmd, buildFile, err := e.resolveCommand(dir, cfg)
+	if err != nil {
+		result.Error = err.Error()
+		e.log("stderr", result.Error)
+		return result, err
+	}
+
+	target := e.repoDir(cfg)
+	if cfg.releases() {
+		target = e.releasesDir(cfg) + " (new release)"
+	}
+	env := envNames(cfg)
+	if cfg.Builder != "" {
+		env = append(env, "BUILDX_BUILDER")
+	}
+	result.BuildFile = buildFile
+	if cfg.BuildCmd != "" {
+		buildFile = "custom build_cmd"
+	}
+	e.reportDryRun(cfg, cmd, buildFile, target, env)
+	return e.finishDryRun(result, start), nil
+}
+
+func (e *Executor) reportDryRun(cfg Config, cmd, buildFile, target string, env []string) {
+	sort.Strings(env)
+	e.log("stdout", "› Dry run, nothing will be built or started")
+	e.log("stdout", "› Command: "+cmd)
+	e.log("stdout", "› Build file: "+orNone(buildFile))
+	e.log("stdout", "› Target directory: "+target)
+	e.log("stdout", "› Environment: "+orNone(strings.Join(env, ", ")))
+	if cfg.PreDeploy != "" {
+		e.log("stdout", "› Pre-deploy hook: "+cfg.PreDeploy)
+	}
+	if cfg.PostDeploy != "" {
+		e.log("stdout", "› Post-deploy hook: "+cfg.PostDeploy)
+	}
+}
+
+func (e *Executor) finishDryRun(result *Result, start time.Time) *Result {
+	result.Success = true
+	result.Duration = time.Since(start)
+	e.log("stdout", fmt.Sprintf("› Dry run completed in %s", result.Duration.Round(time.Millisecond)))
+	return result
+}
+
+func envNames(cfg Config) []string {
+	names := make([]string, 0, len(cfg.Env)+1)
+	for k := range cfg.Env {
+		names = append(names, k)
+	}
+	return names
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "none"
+	}
+	return s
+}
@@ -0,0 +1,63 @@
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+
+package deploy
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExitCodes(t *testing.T) {
+	src := newSourceRepo(t)
+	e := NewExecutor(t.TempDir())
+
+	for _, tc := range []struct {
+		name    string
+		cmd     string
+		timeout time.Duration
+		want    int
+	}{
+		{"success", "true", 0, 0},
+		{"exit status", "exit 3", 0, 3},
+		{"killed by itself", "kill -9 $$", 0, 137},
+		{"timeout", "exec sleep 30", time.Second, ExitTimeout},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tc.timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tc.timeout)
+				defer cancel()
+			}
+			cfg := Config{Name: "api", URL: src, Branch: "main", BuildCmd: tc.cmd}
+			result, err := e.Execute(ctx, cfg)
+			if (err != nil) != (tc.want != 0) {
+				t.Fatalf("Execute error = %v", err)
+			}
+			if result.ExitCode != tc.want {
+				t.Fatalf("exit code = %d, want %d", result.ExitCode, tc.want)
+			}
+			if timedOut := err != nil && strings.Contains(err.Error(), "timed out"); timedOut != (tc.want == ExitTimeout) {
+				t.Fatalf("error = %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,54 @@
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+
+package deploy
+
+import (
+	"context"
+	"fmt"
+)
+
+func (e *Executor) runHook(ctx context.Context, stage, dir, script string, env map[string]string) error {
+	prefix := "[" + stage + "] "
+	hooked := e.WithLog(func(stream, line string) {
+		e.log(stream, prefix+line)
+	})
+	e.log("stdout", fmt.Sprintf("› Running %s-deploy hook: %s", stage, script))
+	return e.step(stage+"_deploy", func() error {
+		if err := hooked.runScriptObserved(ctx, dir, script, env, nil); err != nil {
+			return fmt.Errorf("%s-deploy hook: %w", stage, err)
+		}
+		return nil
+	})
+}
+
+func (e *Executor) PostDeploy(ctx context.Context, cfg Config, result *Result) error {
+	if cfg.PostDeploy == "" || result == nil {
+		return nil
+	}
+	e = e.withMasks(cfg.Env)
+	dir := result.RepoDir
+	if dir == "" {
+		dir = e.repoDir(cfg)
+	}
+	err := e.runHook(ctx, "post", dir, cfg.PostDeploy, cfg.Env)
+	if err != nil {
+		e.log("stderr", "› "+err.Error())
+	}
+	return err
+}
@@ -0,0 +1,174 @@
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+
+package deploy
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/urustack/uruflow/internal/models"
+)
+
+func ContainerName(cfg Config) string {
+	if cfg.Container != "" {
+		return cfg.Container
+	}
+	return ProjectName(cfg.Name)
+}
+
+func (e *Executor) imageDir(cfg Config) string {
+	if cfg.Path != "" {
+		return e.customDir(cfg)
+	}
+	return filepath.Join(e.workDir, cfg.Name)
+}
+
+func (e *Executor) deployImage(ctx context.Context, cfg Config, start time.Time) (*Result, error) {
+	result := &Result{}
+	dir := e.imageDir(cfg)
+
+	e.log("stdout", fmt.Sprintf("› Deploying %s from image %s", cfg.Name, cfg.Image))
+	if cfg.Image == "" {
+		result.Error = "no image in deploy command, the server did not render the repository's image"
+		e.log("stderr", "› "+result.Error)
+		return result, fmt.Errorf("%s", result.Error)
+	}
+	if err := models.ValidateImageRef(cfg.Image); err != nil {
+		result.Error = err.Error()
+		e.log("stderr", "› "+result.Error)
+		return result, err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		result.Error = fmt.Sprintf("create %s: %v", dir, err)
+		return result, fmt.Errorf("create %s: %w", dir, err)
+	}
+
+	cmd, buildFile, err := e.resolveCommand(dir, cfg)
+	if err != nil {
+		result.Error = err.Error()
+		e.log("stderr", result.Error)
+		return result, err
+	}
+	result.BuildFile = buildFile
+
+	env := make(map[string]string, len(cfg.Env)+1)
+	for k, v := range cfg.Env {
+		env[k] = v
+	}
+	env["URUFLOW_IMAGE"] = cfg.Image
+
+	if cfg.DryRun {
+		e.reportDryRun(cfg, cmd, buildFile, dir, append(envNames(cfg), "URUFLOW_IMAGE"))
+		return e.finishDryRun(result, start), nil
+	}
+
+	if err := e.registryLogin(ctx, dir, cfg); err != nil {
+		result.Error = err.Error()
+		return result, err
+	}
+
+	if cfg.PreDeploy != "" {
+		if err := e.runHook(ctx, "pre", dir, cfg.PreDeploy, env); err != nil {
+			result.Error = err.Error()
+			e.log("stderr", "› "+result.Error)
+			return result, err
+		}
+	}
+
+	err = e.step("pull", func() error {
+		return e.runtimeCmd(ctx, dir, "pull", cfg.Image)
+	})
+	if err != nil {
+		result.Error = err.Error()
+		return result, err
+	}
+	if digest := e.imageDigest(ctx, cfg.Image); digest != "" {
+		e.log("stdout", "› Pulled "+digest)
+	}
+
+	e.log("stdout", fmt.Sprintf("› Running: %s", cmd))
+	err = e.step("up", func() error {
+		if cfg.BuildFile == "" {
+			e.cli.Command(ctx, "rm", "-f", ContainerName(cfg)).Run()
+			return e.runtimeCmd(ctx, dir, imageRunArgs(cfg)...)
+		}
+		return e.runScriptObserved(ctx, dir, cmd, env, nil)
+	})
+	if err != nil {
+		result.Error = err.Error()
+		return result, err
+	}
+
+	if cfg.Commit != "" && cfg.Commit != "HEAD" {
+		result.Commit = cfg.Commit
+	}
+	result.RepoDir = dir
+	if cfg.BuildFile != "" {
+		result.Project = ProjectName(cfg.Name)
+		result.ComposeFile = cfg.BuildFile
+		result.Env = env
+		hash, err := e.ComposeConfigHash(ctx, dir, result.Project, result.ComposeFile, env)
+		if err != nil {
+			e.log("stderr", fmt.Sprintf("› Could not hash compose config: %v", err))
+		}
+		result.ConfigHash = hash
+	}
+
+	result.Success = true
+	result.Duration = time.Since(start)
+	e.log("stdout", fmt.Sprintf("› Completed in %s", result.Duration.Round(time.Millisecond)))
+	return result, nil
+}
+
+func (e *Executor) imageCommand(dir string, cfg Config) (string, error) {
+	bin := e.runtimeShell()
+	if cfg.BuildFile != "" {
+		if !e.fileExists(dir, cfg.BuildFile) {
+			return "", fmt.Errorf("no %s found in %s, image deploys don't check out the repository so the compose file must already be there", cfg.BuildFile, dir)
+		}
+		return fmt.Sprintf("%s compose -p %s -f %s up -d --remove-orphans", bin, ProjectName(cfg.Name), cfg.BuildFile), nil
+	}
+
+	run := make([]string, 0, len(cfg.Ports)*2+7)
+	for _, arg := range imageRunArgs(cfg) {
+		run = append(run, shellQuote(arg))
+	}
+	return fmt.Sprintf("%s rm -f %s >/dev/null 2>&1; %s %s",
+		bin, shellQuote(ContainerName(cfg)), bin, strings.Join(run, " ")), nil
+}
+
+func imageRunArgs(cfg Config) []string {
+	args := []string{"run", "-d", "--name", ContainerName(cfg), "--label", "io.uruflow.managed=true"}
+	for _, p := range cfg.Ports {
+		args = append(args, "-p", p)
+	}
+	return append(args, cfg.Image)
+}
+
+func (e *Executor) imageDigest(ctx context.Context, image string) string {
+	out, err := e.cli.Command(ctx, "image", "inspect", "--format", "{{index .RepoDigests 0}}", image).Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
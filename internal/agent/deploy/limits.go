@@ -0,0 +1,189 @@
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+
+package deploy
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	IOIdle       = "idle"
+	IOBestEffort = "best-effort"
+)
+
+type Limits struct {
+	Nice      int
+	IOClass   string
+	CPUs      float64
+	MemoryMax string
+}
+
+func (l Limits) empty() bool {
+	return l.Nice == 0 && l.IOClass == "" && l.CPUs <= 0 && l.MemoryMax == ""
+}
+
+type limiter struct {
+	Limits
+	nice      string
+	ionice    string
+	systemd   bool
+	userScope bool
+	cgroup    bool
+	skipped   []string
+}
+
+func newLimiter(l Limits) *limiter {
+	lim := &limiter{Limits: l}
+
+	if l.Nice != 0 {
+		if path, err := exec.LookPath("nice"); err == nil {
+			lim.nice = path
+		} else {
+			lim.skipped = append(lim.skipped, "nice (nice not found)")
+		}
+	}
+	if l.IOClass != "" {
+		if path, err := exec.LookPath("ionice"); err == nil {
+			lim.ionice = path
+		} else {
+			lim.skipped = append(lim.skipped, "ionice (ionice not found)")
+		}
+	}
+
+	if l.CPUs > 0 || l.MemoryMax != "" {
+		systemdErr := lim.probeSystemd()
+		if systemdErr == nil {
+			lim.systemd = true
+		} else if cgroupErr := cgroupSupported(); cgroupErr == nil {
+			lim.cgroup = true
+		} else {
+			lim.skipped = append(lim.skipped, fmt.Sprintf("cpu/memory limits (systemd: %v; cgroup v2: %v)", systemdErr, cgroupErr))
+		}
+	}
+	return lim
+}
+
+func (l *limiter) probeSystemd() error {
+	if _, err := exec.LookPath("systemd-run"); err != nil {
+		return fmt.Errorf("systemd-run not found")
+	}
+	if _, err := os.Stat("/run/systemd/system"); err != nil {
+		return fmt.Errorf("systemd is not running")
+	}
+	l.userScope = os.Geteuid() != 0
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	args := append(l.systemdArgs(), "true")
+	if output, err := exec.CommandContext(ctx, args[0], args[1:]...).CombinedOutput(); err != nil {
+		return fmt.Errorf("scope test failed: %s", strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+func (l *limiter) systemdArgs() []string {
+	args := []string{"systemd-run", "--scope", "--quiet", "--collect"}
+	if l.userScope {
+		args = append(args, "--user")
+	}
+	if l.MemoryMax != "" {
+		args = append(args, "-p", "MemoryMax="+l.MemoryMax)
+	}
+	if l.CPUs > 0 {
+		args = append(args, "-p", fmt.Sprintf("CPUQuota=%d%%", int(l.CPUs*100)))
+	}
+	return append(args, "--")
+}
+
+func (l *limiter) command(ctx context.Context, name string, args ...string) (*exec.Cmd, func(), error) {
+	argv := append([]string{name}, args...)
+	if l.ionice != "" {
+		class := []string{l.ionice, "-c", "3"}
+		if l.IOClass == IOBestEffort {
+			class = []string{l.ionice, "-c", "2", "-n", "7"}
+		}
+		argv = append(class, argv...)
+	}
+	if l.nice != "" {
+		argv = append([]string{l.nice, "-n", strconv.Itoa(l.Nice)}, argv...)
+	}
+	if l.systemd {
+		argv = append(l.systemdArgs(), argv...)
+	}
+
+	cmd := exec.CommandContext(ctx, argv[0], argv[1:]...)
+	if !l.cgroup {
+		return cmd, func() {}, nil
+	}
+	release, err := joinCgroup(cmd, l.Limits)
+	if err != nil {
+		return nil, nil, fmt.Errorf("create deploy cgroup: %w", err)
+	}
+	return cmd, release, nil
+}
+
+func (l *limiter) String() string {
+	desc := l.applied()
+	if len(l.skipped) > 0 {
+		desc += "; not applied: " + strings.Join(l.skipped, ", ")
+	}
+	return desc
+}
+
+func (l *limiter) applied() string {
+	var applied []string
+	switch {
+	case l.systemd:
+		scope := "systemd scope"
+		if l.userScope {
+			scope = "systemd user scope"
+		}
+		applied = append(applied, scope+" ("+l.resources()+")")
+	case l.cgroup:
+		applied = append(applied, "cgroup v2 ("+l.resources()+")")
+	}
+	if l.nice != "" {
+		applied = append(applied, fmt.Sprintf("nice %d", l.Nice))
+	}
+	if l.ionice != "" {
+		applied = append(applied, "ionice "+l.IOClass)
+	}
+
+	if len(applied) == 0 {
+		return "none"
+	}
+	return strings.Join(applied, ", ")
+}
+
+func (l *limiter) resources() string {
+	var res []string
+	if l.MemoryMax != "" {
+		res = append(res, "memory "+l.MemoryMax)
+	}
+	if l.CPUs > 0 {
+		res = append(res, "cpus "+strconv.FormatFloat(l.CPUs, 'f', -1, 64))
+	}
+	return strings.Join(res, ", ")
+}
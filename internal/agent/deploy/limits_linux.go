@@ -0,0 +1,106 @@
+//go:build linux
+
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+
+package deploy
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"syscall"
+
+	"github.com/urustack/uruflow/pkg/helper"
+)
+
+const cgroupRoot = "/sys/fs/cgroup"
+
+func cgroupSupported() error {
+	controllers, err := os.ReadFile(filepath.Join(cgroupRoot, "cgroup.subtree_control"))
+	if err != nil {
+		return fmt.Errorf("not mounted")
+	}
+	enabled := strings.Fields(string(controllers))
+	for _, want := range []string{"cpu", "memory"} {
+		if !slices.Contains(enabled, want) {
+			return fmt.Errorf("%s controller not enabled", want)
+		}
+	}
+
+	dir, err := os.MkdirTemp(cgroupRoot, "uruflow-probe-")
+	if err != nil {
+		return fmt.Errorf("not writable")
+	}
+	return os.Remove(dir)
+}
+
+func joinCgroup(cmd *exec.Cmd, l Limits) (func(), error) {
+	dir := filepath.Join(cgroupRoot, "uruflow-deploy-"+helper.GenerateID())
+	if err := os.Mkdir(dir, 0755); err != nil {
+		return nil, err
+	}
+	release := func() { os.Remove(dir) }
+
+	if l.MemoryMax != "" {
+		if err := os.WriteFile(filepath.Join(dir, "memory.max"), []byte(l.MemoryMax), 0644); err != nil {
+			release()
+			return nil, err
+		}
+	}
+	if l.CPUs > 0 {
+		quota := fmt.Sprintf("%d 100000", int(l.CPUs*100000))
+		if err := os.WriteFile(filepath.Join(dir, "cpu.max"), []byte(quota), 0644); err != nil {
+			release()
+			return nil, err
+		}
+	}
+
+	fd, err := syscall.Open(dir, syscall.O_DIRECTORY|syscall.O_RDONLY, 0)
+	if err != nil {
+		release()
+		return nil, err
+	}
+	cmd.SysProcAttr = &syscall.SysProcAttr{UseCgroupFD: true, CgroupFD: fd}
+
+	return func() {
+		syscall.Close(fd)
+		drainCgroup(dir)
+		release()
+	}, nil
+}
+
+func drainCgroup(dir string) {
+	f, err := os.Open(filepath.Join(dir, "cgroup.procs"))
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var pid int
+		if _, err := fmt.Sscanf(scanner.Text(), "%d", &pid); err == nil {
+			syscall.Kill(pid, syscall.SIGKILL)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+
+package deploy
+
+import (
+	"context"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestBackgroundCommandFallsBackWhenLimitsFail(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("root can create the deploy cgroup, the limiter would not fail")
+	}
+	e := NewExecutor(t.TempDir())
+	e.background = &limiter{Limits: Limits{MemoryMax: "not-a-size"}, cgroup: true}
+	var lines []string
+	e.OnLog(func(stream, line string) { lines = append(lines, line) })
+
+	if err := e.runCmd(context.Background(), t.TempDir(), "sh", "-c", "echo fetched"); err != nil {
+		t.Fatalf("runCmd: %v", err)
+	}
+	if !slices.Contains(lines, "fetched") {
+		t.Fatalf("command did not run, log: %q", lines)
+	}
+	if !slices.ContainsFunc(lines, func(l string) bool { return strings.HasPrefix(l, "› Limits not applied") }) {
+		t.Fatalf("limiter failure not reported, log: %q", lines)
+	}
+}
@@ -0,0 +1,80 @@
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+
+package deploy
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+var backgroundLimits = Limits{Nice: 19, IOClass: IOIdle}
+
+func (e *Executor) Prefetch(ctx context.Context, cfg Config) (string, error) {
+	if err := e.checkPath(cfg); err != nil {
+		return "", err
+	}
+	authed, cleanup, err := e.withAuth(cfg.Auth)
+	if err != nil {
+		return "", err
+	}
+	defer cleanup()
+	e = authed
+	e.background = newLimiter(backgroundLimits)
+
+	dir := e.sourceDir(cfg)
+	e.log("stdout", fmt.Sprintf("› Prefetching %s (%s) into %s", cfg.Name, cfg.Branch, dir))
+
+	if _, err := os.Stat(filepath.Join(dir, ".git")); os.IsNotExist(err) {
+		os.MkdirAll(filepath.Dir(dir), 0755)
+		if err := e.runGit(ctx, filepath.Dir(dir), cfg.Name, "clone", "-b", cfg.Branch, "--single-branch", cfg.URL, filepath.Base(dir)); err != nil {
+			return "", err
+		}
+	} else {
+		if current := e.remoteURL(ctx, dir); current != cfg.URL {
+			e.log("stdout", fmt.Sprintf("› Remote changed from %s to %s", current, cfg.URL))
+			if err := e.runCmd(ctx, dir, "git", "remote", "set-url", "origin", cfg.URL); err != nil {
+				return "", err
+			}
+		}
+		refspec := fmt.Sprintf("+refs/heads/%s:refs/remotes/origin/%s", cfg.Branch, cfg.Branch)
+		if err := e.runGit(ctx, dir, cfg.Name, "fetch", "origin", refspec); err != nil {
+			return "", err
+		}
+	}
+
+	out, err := exec.CommandContext(ctx, "git", "-C", dir, "rev-parse", "refs/remotes/origin/"+cfg.Branch).Output()
+	if err != nil {
+		return "", fmt.Errorf("resolve origin/%s: %w", cfg.Branch, err)
+	}
+	commit := strings.TrimSpace(string(out))
+	e.log("stdout", fmt.Sprintf("› Prefetched %s at %s", cfg.Branch, commit[:min(len(commit), 7)]))
+	return commit, nil
+}
+
+func (e *Executor) remoteURL(ctx context.Context, dir string) string {
+	out, err := exec.CommandContext(ctx, "git", "-C", dir, "remote", "get-url", "origin").Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
@@ -0,0 +1,89 @@
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+
+package deploy
+
+import (
+	"bytes"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	progressStep     = 5
+	progressInterval = 2 * time.Second
+)
+
+var progressLine = regexp.MustCompile(`^(?:remote: )?([A-Za-z ]+):\s+(\d+)% \(\d+/\d+\)`)
+
+type gitProgress struct {
+	now    func() time.Time
+	phase  string
+	pct    int
+	logged time.Time
+}
+
+func newGitProgress() *gitProgress {
+	return &gitProgress{now: time.Now}
+}
+
+func (p *gitProgress) filter(line string) (string, bool) {
+	line = strings.TrimRight(strings.ReplaceAll(line, "\x1b[K", ""), " ")
+	m := progressLine.FindStringSubmatch(line)
+	if m == nil {
+		return line, true
+	}
+
+	pct, _ := strconv.Atoi(m[2])
+	now := p.now()
+	switch {
+	case strings.HasSuffix(line, ", done."):
+	case pct == 100:
+		return "", false
+	case m[1] != p.phase:
+	case pct >= p.pct+progressStep:
+	case now.Sub(p.logged) >= progressInterval && pct != p.pct:
+	default:
+		return "", false
+	}
+	p.phase, p.pct, p.logged = m[1], pct, now
+	return line, true
+}
+
+func scanProgressLines(data []byte, atEOF bool) (int, []byte, error) {
+	if atEOF && len(data) == 0 {
+		return 0, nil, nil
+	}
+	if i := bytes.IndexAny(data, "\r\n"); i >= 0 {
+		if data[i] == '\r' {
+			if i+1 == len(data) && !atEOF {
+				return 0, nil, nil
+			}
+			if i+1 < len(data) && data[i+1] == '\n' {
+				return i + 2, data[:i], nil
+			}
+		}
+		return i + 1, data[:i], nil
+	}
+	if atEOF {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
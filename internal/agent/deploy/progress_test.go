@@ -0,0 +1,95 @@
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+
+package deploy
+
+import (
+	"os"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func filterProgress(t *testing.T, out string) []string {
+	t.Helper()
+	var lines []string
+	e := NewExecutor(t.TempDir()).WithLog(func(stream, line string) {
+		lines = append(lines, line)
+	})
+	progress := newGitProgress()
+	start := time.Now()
+	progress.now = func() time.Time { return start }
+	e.scanProgress(strings.NewReader(out), nil, progress)
+	return lines
+}
+
+func TestGitProgressIsThrottled(t *testing.T) {
+	data, err := os.ReadFile("testdata/git-clone-progress.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw := strings.Count(string(data), "\r") + strings.Count(string(data), "\n")
+	lines := filterProgress(t, string(data))
+
+	if len(lines) == 0 || len(lines) > raw/4 {
+		t.Fatalf("%d of %d lines logged", len(lines), raw)
+	}
+	for _, want := range []string{
+		"Cloning into 'progdst'...",
+		"remote: Enumerating objects: 402, done.",
+		"remote: Counting objects: 100% (402/402), done.",
+		"remote: Compressing objects: 100% (402/402), done.",
+		"Receiving objects: 100% (402/402), 822.72 KiB | 6.43 MiB/s, done.",
+	} {
+		if !slices.Contains(lines, want) {
+			t.Errorf("%q was not logged", want)
+		}
+	}
+	for _, line := range lines {
+		if strings.ContainsAny(line, "\r\x1b") || strings.HasSuffix(line, " ") {
+			t.Errorf("line %q was not cleaned up", line)
+		}
+	}
+}
+
+func TestGitErrorsPassThroughUnthrottled(t *testing.T) {
+	out := "Cloning into 'api'...\n" +
+		"Receiving objects:  41% (4321/10538), 12.00 MiB | 3.10 MiB/s\r" +
+		"Receiving objects:  42% (4426/10538), 12.30 MiB | 3.10 MiB/s\r" +
+		"error: RPC failed; curl 56 GnuTLS recv error (-9): Error decoding the received TLS packet.\n" +
+		"Receiving objects:  42% (4427/10538), 12.31 MiB | 3.10 MiB/s\r" +
+		"error: 5011 bytes of body are still expected\n" +
+		"fetch-pack: unexpected disconnect while reading sideband packet\n" +
+		"fatal: early EOF\n" +
+		"fatal: fetch-pack: invalid index-pack output\n"
+	lines := filterProgress(t, out)
+
+	want := []string{
+		"Cloning into 'api'...",
+		"Receiving objects:  41% (4321/10538), 12.00 MiB | 3.10 MiB/s",
+		"error: RPC failed; curl 56 GnuTLS recv error (-9): Error decoding the received TLS packet.",
+		"error: 5011 bytes of body are still expected",
+		"fetch-pack: unexpected disconnect while reading sideband packet",
+		"fatal: early EOF",
+		"fatal: fetch-pack: invalid index-pack output",
+	}
+	if strings.Join(lines, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("logged:\n%s\nwant:\n%s", strings.Join(lines, "\n"), strings.Join(want, "\n"))
+	}
+}
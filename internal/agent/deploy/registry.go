@@ -0,0 +1,163 @@
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+
+package deploy
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const RegistryLoginTTL = 6 * time.Hour
+
+var dockerHubServers = map[string]bool{
+	"docker.io":            true,
+	"index.docker.io":      true,
+	"registry-1.docker.io": true,
+}
+
+type Registry struct {
+	Server       string
+	Username     string
+	PasswordFile string
+}
+
+type registryLogins struct {
+	mu       sync.Mutex
+	list     []Registry
+	loggedIn map[string]time.Time
+}
+
+func (e *Executor) SetRegistries(registries []Registry) {
+	e.registries = &registryLogins{
+		list:     registries,
+		loggedIn: make(map[string]time.Time),
+	}
+}
+
+func (e *Executor) registryLogin(ctx context.Context, repoDir string, cfg Config) error {
+	if e.registries == nil || len(e.registries.list) == 0 {
+		return nil
+	}
+
+	refs, err := e.referencedRegistries(repoDir, cfg)
+	if err != nil {
+		return err
+	}
+	if len(refs) == 0 {
+		return nil
+	}
+
+	return e.step("login", func() error {
+		e.registries.mu.Lock()
+		defer e.registries.mu.Unlock()
+
+		for _, reg := range refs {
+			if at, ok := e.registries.loggedIn[reg.Server]; ok && time.Since(at) < RegistryLoginTTL {
+				continue
+			}
+			if err := e.dockerLogin(ctx, repoDir, reg); err != nil {
+				delete(e.registries.loggedIn, reg.Server)
+				e.log("stderr", fmt.Sprintf("› %s login to %s failed: %v", e.cli.Runtime, reg.Server, err))
+				return fmt.Errorf("%s login to %s: %w", e.cli.Runtime, reg.Server, err)
+			}
+			e.registries.loggedIn[reg.Server] = time.Now()
+			e.log("stdout", fmt.Sprintf("› Logged in to %s as %s", reg.Server, reg.Username))
+		}
+		return nil
+	})
+}
+
+func (e *Executor) dockerLogin(ctx context.Context, dir string, reg Registry) error {
+	data, err := os.ReadFile(reg.PasswordFile)
+	if err != nil {
+		return fmt.Errorf("read password file: %w", err)
+	}
+	password := strings.TrimRight(string(data), "\r\n")
+	if password == "" {
+		return fmt.Errorf("password file %s is empty", reg.PasswordFile)
+	}
+
+	c := *e
+	c.addMask(password)
+	return c.runCmdStdin(ctx, dir, c.cli.Env, strings.NewReader(password+"\n"), nil,
+		c.cli.Runtime, c.cli.Argv("login", "--username", reg.Username, "--password-stdin", reg.Server)...)
+}
+
+func imageRegistry(image string) string {
+	host, _, ok := strings.Cut(image, "/")
+	if !ok || (!strings.ContainsAny(host, ".:") && host != "localhost") {
+		return "docker.io"
+	}
+	return host
+}
+
+func (e *Executor) referencedRegistries(repoDir string, cfg Config) ([]Registry, error) {
+	if cfg.BuildSystem == "image" {
+		server := imageRegistry(cfg.Image)
+		var refs []Registry
+		for _, reg := range e.registries.list {
+			if reg.Server == server || (dockerHubServers[reg.Server] && dockerHubServers[server]) {
+				refs = append(refs, reg)
+			}
+		}
+		return refs, nil
+	}
+
+	var file string
+	if cfg.BuildCmd == "" {
+		switch cfg.BuildSystem {
+		case "compose":
+			file = cfg.BuildFile
+			if file == "" {
+				found, err := e.findComposeFile(repoDir)
+				if err != nil {
+					return nil, err
+				}
+				file = found
+			}
+		case "dockerfile":
+			file = cfg.BuildFile
+			if file == "" {
+				file = "Dockerfile"
+			}
+		}
+	}
+
+	if file == "" {
+		return e.registries.list, nil
+	}
+	data, err := os.ReadFile(filepath.Join(repoDir, file))
+	if err != nil {
+		return e.registries.list, nil
+	}
+	content := string(data)
+
+	var refs []Registry
+	for _, reg := range e.registries.list {
+		if dockerHubServers[reg.Server] || strings.Contains(content, reg.Server+"/") {
+			refs = append(refs, reg)
+		}
+	}
+	return refs, nil
+}
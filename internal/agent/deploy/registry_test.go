@@ -0,0 +1,170 @@
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+
+package deploy
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/urustack/uruflow/internal/agent/docker"
+)
+
+const fakeDocker = `#!/bin/sh
+password=$(cat)
+echo "$* $password" >> "$FAKE_DOCKER_LOG"
+if [ "$5" = "fail.example.com" ]; then
+	echo "Error response from daemon: login attempt with $password rejected" >&2
+	exit 1
+fi
+echo "Login Succeeded"
+`
+
+func newRegistryExecutor(t *testing.T, registries ...Registry) (*Executor, string, *[]string) {
+	t.Helper()
+	dir := t.TempDir()
+	script := filepath.Join(dir, "docker")
+	if err := os.WriteFile(script, []byte(fakeDocker), 0755); err != nil {
+		t.Fatal(err)
+	}
+	calls := filepath.Join(dir, "calls")
+
+	e := NewExecutor(t.TempDir())
+	e.SetRuntime(docker.CLI{Runtime: script, Env: []string{"FAKE_DOCKER_LOG=" + calls}})
+	for i := range registries {
+		registries[i].PasswordFile = filepath.Join(dir, registries[i].Server+".pw")
+		if err := os.WriteFile(registries[i].PasswordFile, []byte("pw-"+registries[i].Server+"\n"), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	e.SetRegistries(registries)
+
+	var lines []string
+	e.OnLog(func(stream, line string) { lines = append(lines, line) })
+	return e, calls, &lines
+}
+
+func loginCalls(t *testing.T, path string) []string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return strings.Split(strings.TrimSpace(string(data)), "\n")
+}
+
+func TestImageRegistry(t *testing.T) {
+	tests := map[string]string{
+		"nginx":                            "docker.io",
+		"nginx:1.27":                       "docker.io",
+		"library/nginx":                    "docker.io",
+		"acme/api:latest":                  "docker.io",
+		"ghcr.io/acme/api:v1.4.0":          "ghcr.io",
+		"registry.example.com:5000/api":    "registry.example.com:5000",
+		"localhost/api":                    "localhost",
+		"localhost:5000/api@sha256:abc123": "localhost:5000",
+	}
+	for image, want := range tests {
+		if got := imageRegistry(image); got != want {
+			t.Errorf("imageRegistry(%q) = %q, want %q", image, got, want)
+		}
+	}
+}
+
+func TestRegistryLoginOnlyForReferencedRegistries(t *testing.T) {
+	e, calls, lines := newRegistryExecutor(t,
+		Registry{Server: "ghcr.io", Username: "acme-bot"},
+		Registry{Server: "docker.io", Username: "acme"},
+		Registry{Server: "registry.example.com", Username: "ci"},
+	)
+	repo := t.TempDir()
+	compose := "services:\n  api:\n    image: ghcr.io/acme/api:latest\n  cache:\n    image: redis:7\n"
+	if err := os.WriteFile(filepath.Join(repo, "compose.yaml"), []byte(compose), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg := Config{Name: "api", BuildSystem: "compose"}
+
+	if err := e.registryLogin(context.Background(), repo, cfg); err != nil {
+		t.Fatalf("registryLogin: %v", err)
+	}
+	got := loginCalls(t, calls)
+	want := []string{
+		"login --username acme-bot --password-stdin ghcr.io pw-ghcr.io",
+		"login --username acme --password-stdin docker.io pw-docker.io",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("logins = %q, want %q", got, want)
+	}
+	for _, line := range *lines {
+		if strings.Contains(line, "pw-") {
+			t.Fatalf("password logged: %q", line)
+		}
+	}
+
+	if err := e.registryLogin(context.Background(), repo, cfg); err != nil {
+		t.Fatalf("second registryLogin: %v", err)
+	}
+	if n := len(loginCalls(t, calls)); n != len(want) {
+		t.Fatalf("%d logins after a second deploy, want the cached logins reused", n)
+	}
+}
+
+func TestRegistryLoginForImageDeploys(t *testing.T) {
+	e, calls, _ := newRegistryExecutor(t,
+		Registry{Server: "ghcr.io", Username: "acme-bot"},
+		Registry{Server: "index.docker.io", Username: "acme"},
+	)
+
+	cfg := Config{Name: "api", BuildSystem: "image", Image: "acme/api:1.4.0"}
+	if err := e.registryLogin(context.Background(), t.TempDir(), cfg); err != nil {
+		t.Fatalf("registryLogin: %v", err)
+	}
+	if got := loginCalls(t, calls); len(got) != 1 || !strings.HasSuffix(got[0], "index.docker.io pw-index.docker.io") {
+		t.Fatalf("logins = %q, want only Docker Hub", got)
+	}
+}
+
+func TestRegistryLoginFailureIsMaskedAndRetried(t *testing.T) {
+	e, calls, lines := newRegistryExecutor(t, Registry{Server: "fail.example.com", Username: "ci"})
+	cfg := Config{Name: "api", BuildSystem: "dockerfile"}
+	repo := t.TempDir()
+	if err := os.WriteFile(filepath.Join(repo, "Dockerfile"), []byte("FROM fail.example.com/base:1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	err := e.registryLogin(context.Background(), repo, cfg)
+	if err == nil || !strings.Contains(err.Error(), "login to fail.example.com") {
+		t.Fatalf("registryLogin = %v, want a login failure", err)
+	}
+	for _, line := range append(*lines, err.Error()) {
+		if strings.Contains(line, "pw-fail.example.com") {
+			t.Fatalf("password leaked: %q", line)
+		}
+	}
+
+	e.registryLogin(context.Background(), repo, cfg)
+	if n := len(loginCalls(t, calls)); n != 2 {
+		t.Fatalf("%d login attempts, want a failed login retried on the next deploy", n)
+	}
+}
@@ -0,0 +1,160 @@
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+
+package deploy
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+const (
+	StrategyInPlace  = "inplace"
+	StrategyReleases = "releases"
+
+	DefaultKeepReleases = 5
+
+	releaseCleanupTimeout = 30 * time.Second
+)
+
+func (cfg Config) releases() bool {
+	return cfg.Strategy == StrategyReleases && cfg.Path == ""
+}
+
+func (e *Executor) baseDir(cfg Config) string {
+	return filepath.Join(e.workDir, cfg.Name)
+}
+
+func (e *Executor) sourceDir(cfg Config) string {
+	if cfg.releases() {
+		return filepath.Join(e.baseDir(cfg), "source")
+	}
+	return e.repoDir(cfg)
+}
+
+func (e *Executor) releasesDir(cfg Config) string {
+	return filepath.Join(e.baseDir(cfg), "releases")
+}
+
+func (e *Executor) currentDir(cfg Config) string {
+	return filepath.Join(e.baseDir(cfg), "current")
+}
+
+func (e *Executor) checkReleaseLayout(cfg Config) error {
+	if _, err := os.Stat(filepath.Join(e.baseDir(cfg), ".git")); err == nil {
+		return fmt.Errorf("%s holds an in-place checkout, tear it down with its working directory before switching to the releases strategy",
+			e.baseDir(cfg))
+	}
+	return nil
+}
+
+func (e *Executor) prepareRelease(ctx context.Context, cfg Config, sourceDir, target string) (string, error) {
+	hash, err := gitOutput(ctx, sourceDir, "rev-parse", "--verify", target+"^{commit}")
+	if err != nil {
+		return "", fmt.Errorf("commit %s not found on %s: %w", shortHash(target), cfg.Branch, err)
+	}
+
+	dir := filepath.Join(e.releasesDir(cfg), time.Now().UTC().Format("20060102150405")+"-"+shortHash(hash))
+	if err := os.MkdirAll(e.releasesDir(cfg), 0755); err != nil {
+		return "", fmt.Errorf("create releases directory: %w", err)
+	}
+	e.log("stdout", fmt.Sprintf("› Preparing release %s", filepath.Base(dir)))
+	if err := e.runCmd(ctx, sourceDir, "git", "worktree", "add", "--detach", dir, hash); err != nil {
+		return "", fmt.Errorf("git worktree add: %w", err)
+	}
+	return dir, nil
+}
+
+func (e *Executor) currentRelease(cfg Config) string {
+	target, err := os.Readlink(e.currentDir(cfg))
+	if err != nil {
+		return ""
+	}
+	return filepath.Base(target)
+}
+
+func (e *Executor) switchCurrent(cfg Config, release string) error {
+	current := e.currentDir(cfg)
+	tmp := current + ".next"
+	os.Remove(tmp)
+	if err := os.Symlink(filepath.Join("releases", release), tmp); err != nil {
+		return fmt.Errorf("link release %s: %w", release, err)
+	}
+	if err := os.Rename(tmp, current); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("switch to release %s: %w", release, err)
+	}
+	return nil
+}
+
+func (e *Executor) restoreCurrent(cfg Config, previous string) {
+	if previous == "" {
+		os.Remove(e.currentDir(cfg))
+		return
+	}
+	if err := e.switchCurrent(cfg, previous); err != nil {
+		e.log("stderr", fmt.Sprintf("› Could not switch back to release %s: %v", previous, err))
+		return
+	}
+	e.log("stderr", fmt.Sprintf("› Switched back to release %s", previous))
+}
+
+func (e *Executor) removeRelease(cfg Config, release string) {
+	ctx, cancel := context.WithTimeout(context.Background(), releaseCleanupTimeout)
+	defer cancel()
+	dir := filepath.Join(e.releasesDir(cfg), release)
+	sourceDir := e.sourceDir(cfg)
+	if err := e.runCmd(ctx, sourceDir, "git", "worktree", "remove", "--force", "--force", dir); err != nil {
+		os.RemoveAll(dir)
+		e.runCmd(ctx, sourceDir, "git", "worktree", "prune")
+	}
+}
+
+func (e *Executor) pruneReleases(cfg Config) []string {
+	keep := cfg.KeepReleases
+	if keep <= 0 {
+		keep = DefaultKeepReleases
+	}
+	entries, err := os.ReadDir(e.releasesDir(cfg))
+	if err != nil {
+		return nil
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+
+	current := e.currentRelease(cfg)
+	var removed []string
+	for i := 0; len(names)-len(removed) > keep && i < len(names); i++ {
+		if names[i] == current {
+			continue
+		}
+		e.removeRelease(cfg, names[i])
+		removed = append(removed, names[i])
+	}
+	return removed
+}
@@ -0,0 +1,148 @@
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+
+package deploy
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func git(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, out)
+	}
+	return string(out)
+}
+
+func newSourceRepo(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir := t.TempDir()
+	git(t, dir, "init", "-q", "-b", "main")
+	git(t, dir, "commit", "-q", "--allow-empty", "-m", "initial")
+	return dir
+}
+
+func releaseNames(t *testing.T, e *Executor, cfg Config) []string {
+	t.Helper()
+	entries, err := os.ReadDir(e.releasesDir(cfg))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	return names
+}
+
+func worktrees(t *testing.T, e *Executor, cfg Config) int {
+	t.Helper()
+	return strings.Count(git(t, e.sourceDir(cfg), "worktree", "list", "--porcelain"), "worktree ")
+}
+
+func TestReleaseDiscardedWhenBuildFails(t *testing.T) {
+	src := newSourceRepo(t)
+	e := NewExecutor(t.TempDir())
+	cfg := Config{Name: "api", URL: src, Branch: "main", Strategy: StrategyReleases, BuildCmd: "true"}
+
+	if _, err := e.Execute(context.Background(), cfg); err != nil {
+		t.Fatalf("first deploy: %v", err)
+	}
+	current := e.currentRelease(cfg)
+	if current == "" {
+		t.Fatal("no current release after the first deploy")
+	}
+
+	git(t, src, "commit", "-q", "--allow-empty", "-m", "second")
+	cfg.BuildCmd = "exit 3"
+	if _, err := e.Execute(context.Background(), cfg); err == nil {
+		t.Fatal("failing build succeeded")
+	}
+
+	if got := e.currentRelease(cfg); got != current {
+		t.Fatalf("current release = %s, want %s", got, current)
+	}
+	if names := releaseNames(t, e, cfg); len(names) != 1 || names[0] != current {
+		t.Fatalf("releases = %v, want only %s", names, current)
+	}
+	if n := worktrees(t, e, cfg); n != 2 {
+		t.Fatalf("%d worktrees registered, want the source and the current release", n)
+	}
+}
+
+func TestReleaseDiscardedAfterTimeout(t *testing.T) {
+	src := newSourceRepo(t)
+	e := NewExecutor(t.TempDir())
+	cfg := Config{Name: "api", URL: src, Branch: "main", Strategy: StrategyReleases, BuildCmd: "true"}
+
+	if _, err := e.Execute(context.Background(), cfg); err != nil {
+		t.Fatalf("first deploy: %v", err)
+	}
+	current := e.currentRelease(cfg)
+
+	git(t, src, "commit", "-q", "--allow-empty", "-m", "second")
+	cfg.BuildCmd = "exec sleep 30"
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if _, err := e.Execute(ctx, cfg); err == nil {
+		t.Fatal("build outlived the deploy timeout")
+	}
+
+	if names := releaseNames(t, e, cfg); len(names) != 1 || names[0] != current {
+		t.Fatalf("releases = %v, want only %s", names, current)
+	}
+	if n := worktrees(t, e, cfg); n != 2 {
+		t.Fatalf("%d worktrees registered, want the discarded release unregistered", n)
+	}
+}
+
+func TestPruneReleasesKeepsNewestAndCurrent(t *testing.T) {
+	e := NewExecutor(t.TempDir())
+	cfg := Config{Name: "api", Strategy: StrategyReleases, KeepReleases: 2}
+
+	names := []string{"20260101000001-aaaaaaa", "20260101000002-bbbbbbb", "20260101000003-ccccccc", "20260101000004-ddddddd"}
+	for _, name := range names {
+		if err := os.MkdirAll(filepath.Join(e.releasesDir(cfg), name), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := e.switchCurrent(cfg, names[0]); err != nil {
+		t.Fatal(err)
+	}
+
+	removed := e.pruneReleases(cfg)
+	if strings.Join(removed, ",") != names[1]+","+names[2] {
+		t.Fatalf("removed %v, want %v", removed, names[1:3])
+	}
+	if got := releaseNames(t, e, cfg); strings.Join(got, ",") != names[0]+","+names[3] {
+		t.Fatalf("kept %v, want the current and the newest release", got)
+	}
+}
@@ -0,0 +1,87 @@
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+
+package deploy
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func head(t *testing.T, dir string) string {
+	t.Helper()
+	return strings.TrimSpace(git(t, dir, "rev-parse", "HEAD"))
+}
+
+func TestVerifyBranchAcceptsCommitOnBranch(t *testing.T) {
+	src := newSourceRepo(t)
+	first := head(t, src)
+	git(t, src, "commit", "-q", "--allow-empty", "-m", "second")
+	e := NewExecutor(t.TempDir())
+	cfg := Config{Name: "api", URL: src, Branch: "main", BuildCmd: "true", Commit: first, VerifyBranch: true}
+
+	if _, err := e.Execute(context.Background(), cfg); err != nil {
+		t.Fatalf("deploy of an older commit on main: %v", err)
+	}
+	if got := head(t, e.sourceDir(cfg)); got != first {
+		t.Fatalf("HEAD = %s, want %s", got, first)
+	}
+}
+
+func TestVerifyBranchRejectsForcePushedCommit(t *testing.T) {
+	src := newSourceRepo(t)
+	git(t, src, "commit", "-q", "--allow-empty", "-m", "second")
+	rewritten := head(t, src)
+	e := NewExecutor(t.TempDir())
+	cfg := Config{Name: "api", URL: src, Branch: "main", BuildCmd: "true", Commit: rewritten, VerifyBranch: true}
+	if _, err := e.Execute(context.Background(), cfg); err != nil {
+		t.Fatalf("first deploy: %v", err)
+	}
+
+	git(t, src, "commit", "-q", "--amend", "--allow-empty", "-m", "second, reworded")
+	_, err := e.Execute(context.Background(), cfg)
+	if err == nil || !strings.Contains(err.Error(), "is not on branch main") {
+		t.Fatalf("deploy of a force-pushed commit = %v, want it refused", err)
+	}
+
+	cfg.VerifyBranch = false
+	if _, err := e.Execute(context.Background(), cfg); err != nil {
+		t.Fatalf("deploy with verify_branch off: %v", err)
+	}
+}
+
+func TestVerifyBranchRejectsUnknownCommit(t *testing.T) {
+	src := newSourceRepo(t)
+	e := NewExecutor(t.TempDir())
+	cfg := Config{Name: "api", URL: src, Branch: "main", BuildCmd: "true"}
+	if _, err := e.Execute(context.Background(), cfg); err != nil {
+		t.Fatalf("first deploy: %v", err)
+	}
+
+	git(t, src, "commit", "-q", "--allow-empty", "-m", "dropped")
+	dropped := head(t, src)
+	git(t, src, "reset", "-q", "--hard", "HEAD~1")
+
+	cfg.Commit = dropped
+	cfg.VerifyBranch = true
+	_, err := e.Execute(context.Background(), cfg)
+	if err == nil || !strings.Contains(err.Error(), "does not exist on main") {
+		t.Fatalf("deploy of an unreachable commit = %v, want it refused", err)
+	}
+}
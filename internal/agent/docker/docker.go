@@ -24,15 +24,28 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"net"
 	"net/http"
+	"net/url"
 	"strings"
+	"sync"
 	"time"
 )
 
+const inspectCacheTTL = time.Minute
+
 type Service struct {
-	client *http.Client
-	socket string
+	client    *http.Client
+	endpoint  string
+	base      string
+	engine    Engine
+	inspectMu sync.Mutex
+	inspects  map[string]inspectEntry
+}
+
+type inspectEntry struct {
+	result *inspectResult
+	key    string
+	at     time.Time
 }
 
 type Container struct {
@@ -40,6 +53,9 @@ type Container struct {
 	FullID       string
 	Name         string
 	Image        string
+	ImageID      string
+	Project      string
+	Service      string
 	Status       string
 	State        string
 	Health       string
@@ -53,32 +69,60 @@ type Container struct {
 	IsManaged    bool
 }
 
-func New(socket string) (*Service, error) {
-	transport := &http.Transport{
-		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
-			return net.Dial("unix", socket)
-		},
+func New(opts Options) (*Service, error) {
+	transport, base, err := newTransport(opts)
+	if err != nil {
+		return nil, err
 	}
 
-	client := &http.Client{
-		Transport: transport,
-		Timeout:   30 * time.Second,
+	s := &Service{
+		client: &http.Client{
+			Transport: transport,
+			Timeout:   30 * time.Second,
+		},
+		endpoint: opts.endpoint(),
+		inspects: make(map[string]inspectEntry),
 	}
 
-	resp, err := client.Get("http://localhost/version")
+	engine, err := s.negotiate(base)
 	if err != nil {
-		return nil, fmt.Errorf("docker not available: %w", err)
+		return nil, fmt.Errorf("docker not available on %s: %w", s.endpoint, err)
 	}
-	resp.Body.Close()
+	s.engine = engine
+	s.base = base + "/v" + engine.APIVersion
+	return s, nil
+}
 
-	return &Service{
-		client: client,
-		socket: socket,
-	}, nil
+func (s *Service) Endpoint() string {
+	return s.endpoint
+}
+
+func (s *Service) Engine() Engine {
+	return s.engine
 }
 
 func (s *Service) ListContainers(ctx context.Context) ([]Container, error) {
-	resp, err := s.client.Get("http://localhost/containers/json?all=true")
+	return s.listContainers(ctx, "")
+}
+
+func (s *Service) GetContainersByComposeProject(ctx context.Context, project string) ([]Container, error) {
+	filters, _ := json.Marshal(map[string][]string{
+		"label": {"com.docker.compose.project=" + project},
+	})
+	return s.listContainers(ctx, string(filters))
+}
+
+func (s *Service) listContainers(ctx context.Context, filters string) ([]Container, error) {
+	endpoint := s.base + "/containers/json?all=true"
+	if filters != "" {
+		endpoint += "&filters=" + url.QueryEscape(filters)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.client.Do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -88,6 +132,7 @@ func (s *Service) ListContainers(ctx context.Context) ([]Container, error) {
 		ID      string            `json:"Id"`
 		Names   []string          `json:"Names"`
 		Image   string            `json:"Image"`
+		ImageID string            `json:"ImageID"`
 		Status  string            `json:"Status"`
 		State   string            `json:"State"`
 		Created int64             `json:"Created"`
@@ -112,11 +157,9 @@ func (s *Service) ListContainers(ctx context.Context) ([]Container, error) {
 		restartCount := 0
 		var startedAt int64
 
-		inspect, err := s.inspectContainer(c.ID)
+		inspect, err := s.cachedInspect(ctx, c.ID, c.State+"|"+statusHealth(c.Status))
 		if err == nil {
-			if inspect.State.Health != nil {
-				health = inspect.State.Health.Status
-			}
+			health = inspect.health()
 			restartCount = inspect.RestartCount
 			if inspect.State.StartedAt != "" {
 				t, _ := time.Parse(time.RFC3339Nano, inspect.State.StartedAt)
@@ -129,6 +172,9 @@ func (s *Service) ListContainers(ctx context.Context) ([]Container, error) {
 			FullID:       c.ID,
 			Name:         name,
 			Image:        c.Image,
+			ImageID:      c.ImageID,
+			Project:      c.Labels["com.docker.compose.project"],
+			Service:      c.Labels["com.docker.compose.service"],
 			Status:       c.Status,
 			State:        c.State,
 			Health:       health,
@@ -138,6 +184,9 @@ func (s *Service) ListContainers(ctx context.Context) ([]Container, error) {
 		})
 	}
 
+	if filters == "" {
+		s.forgetInspects(result)
+	}
 	return result, nil
 }
 
@@ -176,7 +225,7 @@ func (s *Service) checkManagedFromLabels(labels map[string]string) bool {
 }
 
 func (s *Service) IsUruflowManaged(ctx context.Context, containerID string) (bool, error) {
-	resp, err := s.client.Get(fmt.Sprintf("http://localhost/containers/%s/json", containerID))
+	resp, err := s.client.Get(fmt.Sprintf("%s/containers/%s/json", s.base, containerID))
 	if err != nil {
 		return false, err
 	}
@@ -196,7 +245,11 @@ func (s *Service) IsUruflowManaged(ctx context.Context, containerID string) (boo
 }
 
 func (s *Service) GetContainerStats(ctx context.Context, containerID string) (*Container, error) {
-	resp, err := s.client.Get(fmt.Sprintf("http://localhost/containers/%s/stats?stream=false", containerID))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/containers/%s/stats?stream=false", s.base, containerID), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.client.Do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -252,18 +305,194 @@ func (s *Service) GetContainerStats(ctx context.Context, containerID string) (*C
 	}, nil
 }
 
+type DiskUsage struct {
+	Images     uint64
+	Containers uint64
+	Volumes    uint64
+	BuildCache uint64
+}
+
+func (s *Service) GetDiskUsage(ctx context.Context) (*DiskUsage, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.base+"/system/df", nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("docker system df: %s", resp.Status)
+	}
+
+	var df struct {
+		LayersSize int64 `json:"LayersSize"`
+		Containers []struct {
+			SizeRw int64 `json:"SizeRw"`
+		} `json:"Containers"`
+		Volumes []struct {
+			UsageData struct {
+				Size int64 `json:"Size"`
+			} `json:"UsageData"`
+		} `json:"Volumes"`
+		BuildCache []struct {
+			Size   int64 `json:"Size"`
+			Shared bool  `json:"Shared"`
+		} `json:"BuildCache"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&df); err != nil {
+		return nil, err
+	}
+
+	usage := &DiskUsage{Images: positive(df.LayersSize)}
+	for _, c := range df.Containers {
+		usage.Containers += positive(c.SizeRw)
+	}
+	for _, v := range df.Volumes {
+		usage.Volumes += positive(v.UsageData.Size)
+	}
+	for _, b := range df.BuildCache {
+		if !b.Shared {
+			usage.BuildCache += positive(b.Size)
+		}
+	}
+	return usage, nil
+}
+
+func (s *Service) RootDir(ctx context.Context) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.base+"/info", nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("docker info: %s", resp.Status)
+	}
+
+	var info struct {
+		DockerRootDir string `json:"DockerRootDir"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		return "", err
+	}
+	return info.DockerRootDir, nil
+}
+
+func positive(n int64) uint64 {
+	if n < 0 {
+		return 0
+	}
+	return uint64(n)
+}
+
+func (s *Service) ContainerAction(ctx context.Context, containerID, action string) error {
+	endpoint := fmt.Sprintf("%s/containers/%s/%s", s.base, url.PathEscape(containerID), action)
+	if action == "stop" || action == "restart" {
+		endpoint += "?t=10"
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusNoContent, http.StatusNotModified:
+		return nil
+	default:
+		var body struct {
+			Message string `json:"message"`
+		}
+		json.NewDecoder(resp.Body).Decode(&body)
+		if body.Message == "" {
+			body.Message = resp.Status
+		}
+		return fmt.Errorf("docker %s: %s", action, body.Message)
+	}
+}
+
 type inspectResult struct {
 	State struct {
-		Health *struct {
-			Status string `json:"Status"`
-		} `json:"Health"`
-		StartedAt string `json:"StartedAt"`
+		Health      *healthState `json:"Health"`
+		Healthcheck *healthState `json:"Healthcheck"`
+		StartedAt   string       `json:"StartedAt"`
 	} `json:"State"`
 	RestartCount int `json:"RestartCount"`
 }
 
-func (s *Service) inspectContainer(id string) (*inspectResult, error) {
-	resp, err := s.client.Get(fmt.Sprintf("http://localhost/containers/%s/json", id))
+type healthState struct {
+	Status string `json:"Status"`
+}
+
+func (r *inspectResult) health() string {
+	h := r.State.Health
+	if h == nil || h.Status == "" {
+		h = r.State.Healthcheck
+	}
+	if h == nil || h.Status == "" {
+		return "none"
+	}
+	return h.Status
+}
+
+func (s *Service) cachedInspect(ctx context.Context, id, key string) (*inspectResult, error) {
+	s.inspectMu.Lock()
+	entry, ok := s.inspects[id]
+	s.inspectMu.Unlock()
+	if ok && entry.key == key && time.Since(entry.at) < inspectCacheTTL {
+		return entry.result, nil
+	}
+
+	result, err := s.inspectContainer(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	s.inspectMu.Lock()
+	s.inspects[id] = inspectEntry{result: result, key: key, at: time.Now()}
+	s.inspectMu.Unlock()
+	return result, nil
+}
+
+func (s *Service) forgetInspects(present []Container) {
+	seen := make(map[string]bool, len(present))
+	for _, c := range present {
+		seen[c.FullID] = true
+	}
+	s.inspectMu.Lock()
+	defer s.inspectMu.Unlock()
+	for id := range s.inspects {
+		if !seen[id] {
+			delete(s.inspects, id)
+		}
+	}
+}
+
+func statusHealth(status string) string {
+	for _, h := range []string{"(healthy)", "(unhealthy)", "(health: starting)", "(starting)"} {
+		if strings.Contains(status, h) {
+			return h
+		}
+	}
+	return ""
+}
+
+func (s *Service) inspectContainer(ctx context.Context, id string) (*inspectResult, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/containers/%s/json", s.base, id), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.client.Do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -282,7 +511,7 @@ func (s *Service) StreamLogs(ctx context.Context, containerID string, onLine fun
 }
 
 func (s *Service) StreamLogsWithTail(ctx context.Context, containerID string, tail int, onLine func(string)) error {
-	url := fmt.Sprintf("http://localhost/containers/%s/logs?stdout=true&stderr=true&follow=true&timestamps=true&tail=%d", containerID, tail)
+	url := fmt.Sprintf("%s/containers/%s/logs?stdout=true&stderr=true&follow=true&timestamps=true&tail=%d", s.base, containerID, tail)
 	resp, err := s.client.Get(url)
 	if err != nil {
 		return err
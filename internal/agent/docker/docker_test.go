@@ -0,0 +1,112 @@
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+
+package docker
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type fakeEngine struct {
+	mu       sync.Mutex
+	version  string
+	status   string
+	inspect  string
+	inspects map[string]int
+}
+
+func (f *fakeEngine) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch path := r.URL.Path; {
+	case path == "/version":
+		fmt.Fprint(w, f.version)
+	case strings.HasSuffix(path, "/containers/json"):
+		fmt.Fprintf(w, `[{"Id":"4f2a9c1e8b7d6a5f4e3d2c1b","Names":["/api-web-1"],"Image":"api:latest","State":"running","Status":%q,
+			"Labels":{"com.docker.compose.project":"api","com.docker.compose.service":"web","uruflow.managed":"true"}}]`, f.status)
+	case strings.HasSuffix(path, "/json"):
+		f.inspects[strings.Split(path, "/")[3]]++
+		fmt.Fprint(w, f.inspect)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (f *fakeEngine) inspected(id string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.inspects[id]
+}
+
+func newEngine(version string) *fakeEngine {
+	return &fakeEngine{
+		version:  version,
+		status:   "Up 2 hours (healthy)",
+		inspect:  `{"State":{"Health":{"Status":"healthy"},"StartedAt":"2026-10-12T09:14:03.5Z"},"RestartCount":2}`,
+		inspects: make(map[string]int),
+	}
+}
+
+func newFakeEngine(t *testing.T) (*fakeEngine, *Service) {
+	t.Helper()
+	f := newEngine(`{"Version":"27.3.1","ApiVersion":"1.47","MinAPIVersion":"1.24"}`)
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+	s, err := New(Options{Endpoint: "tcp://" + srv.Listener.Addr().String()})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	return f, s
+}
+
+func TestListContainersCachesInspect(t *testing.T) {
+	f, s := newFakeEngine(t)
+	const id = "4f2a9c1e8b7d6a5f4e3d2c1b"
+
+	for range 3 {
+		containers, err := s.ListContainers(t.Context())
+		if err != nil {
+			t.Fatalf("ListContainers: %v", err)
+		}
+		if len(containers) != 1 {
+			t.Fatalf("containers = %+v", containers)
+		}
+		c := containers[0]
+		if c.Health != "healthy" || c.RestartCount != 2 || c.StartedAt == 0 || c.Project != "api" {
+			t.Fatalf("container = %+v, want the inspected health and restarts", c)
+		}
+	}
+	if n := f.inspected(id); n != 1 {
+		t.Fatalf("inspected %d times over three cycles, want 1", n)
+	}
+
+	f.mu.Lock()
+	f.status = "Up 2 hours (unhealthy)"
+	f.mu.Unlock()
+	if _, err := s.ListContainers(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+	if n := f.inspected(id); n != 2 {
+		t.Fatalf("inspected %d times after the health changed, want 2", n)
+	}
+}
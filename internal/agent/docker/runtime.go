@@ -0,0 +1,272 @@
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+
+package docker
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	RuntimeDocker = "docker"
+	RuntimePodman = "podman"
+
+	DefaultEndpoint = "unix:///var/run/docker.sock"
+
+	minAPIVersion = "1.24"
+	maxAPIVersion = "1.47"
+)
+
+type Options struct {
+	Endpoint string
+	TLSCert  string
+	TLSKey   string
+	TLSCA    string
+}
+
+func (o Options) endpoint() string {
+	if o.Endpoint == "" {
+		return DefaultEndpoint
+	}
+	return o.Endpoint
+}
+
+func (o Options) tls() bool {
+	return o.TLSCert != "" || o.TLSKey != "" || o.TLSCA != ""
+}
+
+type Engine struct {
+	Name       string
+	Version    string
+	APIVersion string
+}
+
+func (e Engine) Podman() bool {
+	return e.Name == RuntimePodman
+}
+
+func (e Engine) String() string {
+	return fmt.Sprintf("%s %s (api %s)", e.Name, e.Version, e.APIVersion)
+}
+
+func newTransport(opts Options) (*http.Transport, string, error) {
+	endpoint := opts.endpoint()
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid docker endpoint %q: %w", endpoint, err)
+	}
+
+	switch u.Scheme {
+	case "unix":
+		socket := u.Path
+		if socket == "" {
+			return nil, "", fmt.Errorf("invalid docker endpoint %q: missing socket path", endpoint)
+		}
+		transport := &http.Transport{
+			DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, "unix", socket)
+			},
+		}
+		return transport, "http://localhost", nil
+
+	case "tcp", "http", "https":
+		if u.Host == "" {
+			return nil, "", fmt.Errorf("invalid docker endpoint %q: missing host", endpoint)
+		}
+		tlsCfg, err := clientTLS(opts)
+		if err != nil {
+			return nil, "", err
+		}
+		scheme := "http"
+		if tlsCfg != nil || u.Scheme == "https" {
+			scheme = "https"
+		}
+		transport := &http.Transport{
+			DialContext:         (&net.Dialer{Timeout: 10 * time.Second}).DialContext,
+			TLSClientConfig:     tlsCfg,
+			TLSHandshakeTimeout: 10 * time.Second,
+		}
+		return transport, scheme + "://" + u.Host, nil
+
+	default:
+		return nil, "", fmt.Errorf("unsupported docker endpoint scheme %q", u.Scheme)
+	}
+}
+
+func clientTLS(opts Options) (*tls.Config, error) {
+	if !opts.tls() {
+		return nil, nil
+	}
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12}
+	if opts.TLSCert != "" || opts.TLSKey != "" {
+		cert, err := tls.LoadX509KeyPair(opts.TLSCert, opts.TLSKey)
+		if err != nil {
+			return nil, fmt.Errorf("load docker client certificate: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	if opts.TLSCA != "" {
+		data, err := os.ReadFile(opts.TLSCA)
+		if err != nil {
+			return nil, fmt.Errorf("read docker ca: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("no certificates found in %s", opts.TLSCA)
+		}
+		cfg.RootCAs = pool
+	}
+	return cfg, nil
+}
+
+func (s *Service) negotiate(base string) (Engine, error) {
+	resp, err := s.client.Get(base + "/version")
+	if err != nil {
+		return Engine{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return Engine{}, fmt.Errorf("version: %s", resp.Status)
+	}
+
+	var body struct {
+		Version       string `json:"Version"`
+		APIVersion    string `json:"ApiVersion"`
+		MinAPIVersion string `json:"MinAPIVersion"`
+		Components    []struct {
+			Name    string `json:"Name"`
+			Version string `json:"Version"`
+		} `json:"Components"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return Engine{}, fmt.Errorf("decode version: %w", err)
+	}
+
+	engine := Engine{Name: RuntimeDocker, Version: body.Version}
+	for _, c := range body.Components {
+		if strings.Contains(strings.ToLower(c.Name), "podman") {
+			engine.Name = RuntimePodman
+			if c.Version != "" {
+				engine.Version = c.Version
+			}
+			break
+		}
+	}
+
+	switch {
+	case body.APIVersion == "":
+		engine.APIVersion = minAPIVersion
+	case compareAPIVersion(body.APIVersion, minAPIVersion) < 0:
+		return Engine{}, fmt.Errorf("api version %s is older than the minimum %s", body.APIVersion, minAPIVersion)
+	case compareAPIVersion(body.APIVersion, maxAPIVersion) > 0 &&
+		(body.MinAPIVersion == "" || compareAPIVersion(body.MinAPIVersion, maxAPIVersion) <= 0):
+		engine.APIVersion = maxAPIVersion
+	default:
+		engine.APIVersion = body.APIVersion
+	}
+	return engine, nil
+}
+
+func compareAPIVersion(a, b string) int {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) || i < len(bs); i++ {
+		var x, y int
+		if i < len(as) {
+			x, _ = strconv.Atoi(as[i])
+		}
+		if i < len(bs) {
+			y, _ = strconv.Atoi(bs[i])
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+type CLI struct {
+	Runtime string
+	Args    []string
+	Env     []string
+}
+
+func NewCLI(runtime string, opts Options) CLI {
+	if runtime == "" {
+		runtime = RuntimeDocker
+	}
+	cli := CLI{Runtime: runtime}
+	if opts.Endpoint == "" || opts.Endpoint == DefaultEndpoint {
+		return cli
+	}
+
+	host := opts.Endpoint
+	for _, scheme := range []string{"http://", "https://"} {
+		if strings.HasPrefix(host, scheme) {
+			host = "tcp://" + strings.TrimPrefix(host, scheme)
+		}
+	}
+	if runtime == RuntimePodman {
+		cli.Env = []string{"CONTAINER_HOST=" + host}
+		return cli
+	}
+
+	cli.Env = []string{"DOCKER_HOST=" + host}
+	if opts.tls() {
+		if opts.TLSCA != "" {
+			cli.Args = append(cli.Args, "--tlsverify", "--tlscacert", opts.TLSCA)
+		} else {
+			cli.Args = append(cli.Args, "--tls")
+		}
+		if opts.TLSCert != "" {
+			cli.Args = append(cli.Args, "--tlscert", opts.TLSCert, "--tlskey", opts.TLSKey)
+		}
+	}
+	return cli
+}
+
+func (c CLI) Podman() bool {
+	return c.Runtime == RuntimePodman
+}
+
+func (c CLI) Argv(args ...string) []string {
+	return append(append([]string(nil), c.Args...), args...)
+}
+
+func (c CLI) Command(ctx context.Context, args ...string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, c.Runtime, c.Argv(args...)...)
+	if len(c.Env) > 0 {
+		cmd.Env = append(os.Environ(), c.Env...)
+	}
+	return cmd
+}
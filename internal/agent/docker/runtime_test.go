@@ -0,0 +1,184 @@
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+
+package docker
+
+import (
+	"encoding/pem"
+	"net"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+const dockerVersion = `{"Version":"27.3.1","ApiVersion":"1.47","MinAPIVersion":"1.24"}`
+
+func TestUnixSocketEndpoint(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "docker.sock")
+	ln, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Skipf("unix sockets unavailable: %v", err)
+	}
+	srv := httptest.NewUnstartedServer(newEngine(dockerVersion))
+	srv.Listener = ln
+	srv.Start()
+	t.Cleanup(srv.Close)
+
+	s, err := New(Options{Endpoint: "unix://" + socket})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if got := s.Engine(); got.Name != RuntimeDocker || got.Version != "27.3.1" || got.APIVersion != "1.47" {
+		t.Fatalf("engine = %+v", got)
+	}
+	containers, err := s.ListContainers(t.Context())
+	if err != nil || len(containers) != 1 || containers[0].Name != "api-web-1" {
+		t.Fatalf("ListContainers = %+v, %v", containers, err)
+	}
+}
+
+func TestTLSEndpoint(t *testing.T) {
+	srv := httptest.NewTLSServer(newEngine(dockerVersion))
+	t.Cleanup(srv.Close)
+	ca := filepath.Join(t.TempDir(), "ca.pem")
+	cert := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+	if err := os.WriteFile(ca, cert, 0600); err != nil {
+		t.Fatal(err)
+	}
+	endpoint := "tcp://" + srv.Listener.Addr().String()
+
+	s, err := New(Options{Endpoint: endpoint, TLSCA: ca})
+	if err != nil {
+		t.Fatalf("New with the server CA: %v", err)
+	}
+	if _, err := s.ListContainers(t.Context()); err != nil {
+		t.Fatalf("ListContainers over tls: %v", err)
+	}
+
+	if _, err := New(Options{Endpoint: endpoint}); err == nil {
+		t.Fatal("plain http to a tls endpoint succeeded")
+	}
+	if _, err := New(Options{Endpoint: endpoint, TLSCert: filepath.Join(t.TempDir(), "missing.pem"), TLSKey: "key.pem"}); err == nil ||
+		!strings.Contains(err.Error(), "client certificate") {
+		t.Fatalf("missing client certificate err = %v", err)
+	}
+}
+
+func TestPodmanEngine(t *testing.T) {
+	f := newEngine(`{"Version":"5.2.3","ApiVersion":"1.41","MinAPIVersion":"1.24",
+		"Components":[{"Name":"Podman Engine","Version":"5.2.3"},{"Name":"Conmon","Version":"2.1.12"}]}`)
+	f.status = "Up 2 hours"
+	f.inspect = `{"State":{"Healthcheck":{"Status":"starting"},"StartedAt":"2026-10-12T09:14:03.5Z"},"RestartCount":1}`
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+
+	s, err := New(Options{Endpoint: "http://" + srv.Listener.Addr().String()})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if e := s.Engine(); !e.Podman() || e.Version != "5.2.3" || e.APIVersion != "1.41" {
+		t.Fatalf("engine = %+v, want podman 5.2.3 on api 1.41", e)
+	}
+	containers, err := s.ListContainers(t.Context())
+	if err != nil || len(containers) != 1 {
+		t.Fatalf("ListContainers = %+v, %v", containers, err)
+	}
+	if c := containers[0]; c.Health != "starting" || c.RestartCount != 1 {
+		t.Fatalf("container = %+v, want podman's Healthcheck status", c)
+	}
+}
+
+func TestAPIVersionNegotiation(t *testing.T) {
+	for _, tc := range []struct {
+		version string
+		want    string
+		wantErr bool
+	}{
+		{`{"Version":"27.3.1","ApiVersion":"1.47"}`, "1.47", false},
+		{`{"Version":"24.0.7","ApiVersion":"1.43"}`, "1.43", false},
+		{`{"Version":"99.0.0","ApiVersion":"1.52","MinAPIVersion":"1.24"}`, maxAPIVersion, false},
+		{`{"Version":"99.0.0","ApiVersion":"1.52","MinAPIVersion":"1.50"}`, "1.52", false},
+		{`{"Version":"1.2.3"}`, minAPIVersion, false},
+		{`{"Version":"1.11.2","ApiVersion":"1.23"}`, "", true},
+	} {
+		srv := httptest.NewServer(newEngine(tc.version))
+		s, err := New(Options{Endpoint: "tcp://" + srv.Listener.Addr().String()})
+		srv.Close()
+		if tc.wantErr {
+			if err == nil {
+				t.Errorf("%s: negotiated %s, want an error", tc.version, s.Engine().APIVersion)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tc.version, err)
+			continue
+		}
+		if got := s.Engine().APIVersion; got != tc.want {
+			t.Errorf("%s: api version = %s, want %s", tc.version, got, tc.want)
+		}
+	}
+}
+
+func TestInvalidEndpoint(t *testing.T) {
+	for _, endpoint := range []string{"ftp://docker.internal", "unix://", "tcp://", "://"} {
+		if _, err := New(Options{Endpoint: endpoint}); err == nil {
+			t.Errorf("endpoint %q was accepted", endpoint)
+		}
+	}
+}
+
+func TestNewCLI(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		runtime string
+		opts    Options
+		args    []string
+		env     []string
+	}{
+		{"default socket", "", Options{}, nil, nil},
+		{"explicit default socket", RuntimeDocker, Options{Endpoint: DefaultEndpoint}, nil, nil},
+		{"podman socket", RuntimePodman, Options{Endpoint: "unix:///run/podman/podman.sock"}, nil,
+			[]string{"CONTAINER_HOST=unix:///run/podman/podman.sock"}},
+		{"plain tcp", RuntimeDocker, Options{Endpoint: "http://127.0.0.1:2375"}, nil,
+			[]string{"DOCKER_HOST=tcp://127.0.0.1:2375"}},
+		{"verified tls", RuntimeDocker, Options{Endpoint: "tcp://127.0.0.1:2376", TLSCA: "ca.pem", TLSCert: "cert.pem", TLSKey: "key.pem"},
+			[]string{"--tlsverify", "--tlscacert", "ca.pem", "--tlscert", "cert.pem", "--tlskey", "key.pem"},
+			[]string{"DOCKER_HOST=tcp://127.0.0.1:2376"}},
+		{"tls without ca", RuntimeDocker, Options{Endpoint: "https://127.0.0.1:2376", TLSCert: "cert.pem", TLSKey: "key.pem"},
+			[]string{"--tls", "--tlscert", "cert.pem", "--tlskey", "key.pem"},
+			[]string{"DOCKER_HOST=tcp://127.0.0.1:2376"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			cli := NewCLI(tc.runtime, tc.opts)
+			want := tc.runtime
+			if want == "" {
+				want = RuntimeDocker
+			}
+			if cli.Runtime != want || !slices.Equal(cli.Args, tc.args) || !slices.Equal(cli.Env, tc.env) {
+				t.Fatalf("NewCLI = %+v, want runtime %s args %v env %v", cli, want, tc.args, tc.env)
+			}
+			if got := cli.Argv("compose", "up"); !slices.Equal(got, append(slices.Clone(tc.args), "compose", "up")) {
+				t.Fatalf("Argv = %v", got)
+			}
+		})
+	}
+}
@@ -19,6 +19,9 @@
 package metrics
 
 import (
+	"os/exec"
+	"strconv"
+	"strings"
 	"syscall"
 )
 
@@ -35,14 +38,31 @@ func (c *Collector) getDiskInfo(path string) (uint64, uint64, error) {
 	return used, total, nil
 }
 
-func (c *Collector) getCPUPercent() (float64, error) {
-	return 0.0, nil
-}
-
 func (c *Collector) getMemoryInfo() (uint64, uint64, error) {
-	return 0, 0, nil
+	out, err := exec.Command("sysctl", "-n", "hw.memsize").Output()
+	if err != nil {
+		return 0, 0, err
+	}
+	total, err := strconv.ParseUint(strings.TrimSpace(string(out)), 10, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	out, err = exec.Command("vm_stat").Output()
+	if err != nil {
+		return 0, 0, err
+	}
+	available := parseVMStatAvailable(string(out))
+	if available > total {
+		available = total
+	}
+	return total - available, total, nil
 }
 
 func (c *Collector) getLoadAvg() []float64 {
-	return []float64{0, 0, 0}
+	out, err := exec.Command("sysctl", "-n", "vm.loadavg").Output()
+	if err != nil {
+		return []float64{0, 0, 0}
+	}
+	return parseLoadAvg(string(out))
 }
@@ -15,21 +15,115 @@
  * You should have received a copy of the MIT License
  * along with uruflow. If not, see the LICENSE file in the project root.
  */
-
 package metrics
 
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+var (
+	kernel32                 = syscall.NewLazyDLL("kernel32.dll")
+	procGlobalMemoryStatusEx = kernel32.NewProc("GlobalMemoryStatusEx")
+	procGetDiskFreeSpaceExW  = kernel32.NewProc("GetDiskFreeSpaceExW")
+	procGetSystemTimes       = kernel32.NewProc("GetSystemTimes")
+)
+
+type memoryStatusEx struct {
+	Length               uint32
+	MemoryLoad           uint32
+	TotalPhys            uint64
+	AvailPhys            uint64
+	TotalPageFile        uint64
+	AvailPageFile        uint64
+	TotalVirtual         uint64
+	AvailVirtual         uint64
+	AvailExtendedVirtual uint64
+}
+
 func (c *Collector) getDiskInfo(path string) (uint64, uint64, error) {
-	return 0, 0, nil
+	if path == "/" {
+		drive := os.Getenv("SystemDrive")
+		if drive == "" {
+			drive = "C:"
+		}
+		path = drive + `\`
+	}
+
+	p, err := syscall.UTF16PtrFromString(path)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var freeAvail, total, free uint64
+	r, _, err := procGetDiskFreeSpaceExW.Call(
+		uintptr(unsafe.Pointer(p)),
+		uintptr(unsafe.Pointer(&freeAvail)),
+		uintptr(unsafe.Pointer(&total)),
+		uintptr(unsafe.Pointer(&free)),
+	)
+	if r == 0 {
+		return 0, 0, err
+	}
+
+	return total - free, total, nil
 }
 
 func (c *Collector) getCPUPercent() (float64, error) {
-	return 0, nil
+	var idleTime, kernelTime, userTime syscall.Filetime
+	r, _, err := procGetSystemTimes.Call(
+		uintptr(unsafe.Pointer(&idleTime)),
+		uintptr(unsafe.Pointer(&kernelTime)),
+		uintptr(unsafe.Pointer(&userTime)),
+	)
+	if r == 0 {
+		return 0, err
+	}
+
+	idle := filetimeTicks(idleTime)
+	total := filetimeTicks(kernelTime) + filetimeTicks(userTime)
+
+	if c.prevCPUTotal == 0 || total <= c.prevCPUTotal || idle < c.prevCPUIdle {
+		c.prevCPUIdle = idle
+		c.prevCPUTotal = total
+		return 0, nil
+	}
+
+	idleDelta := idle - c.prevCPUIdle
+	totalDelta := total - c.prevCPUTotal
+
+	c.prevCPUIdle = idle
+	c.prevCPUTotal = total
+
+	cpuPercent := (1.0 - float64(idleDelta)/float64(totalDelta)) * 100
+
+	if cpuPercent < 0 {
+		cpuPercent = 0
+	}
+	if cpuPercent > 100 {
+		cpuPercent = 100
+	}
+
+	return cpuPercent, nil
 }
 
 func (c *Collector) getMemoryInfo() (uint64, uint64, error) {
-	return 0, 0, nil
+	var status memoryStatusEx
+	status.Length = uint32(unsafe.Sizeof(status))
+
+	r, _, err := procGlobalMemoryStatusEx.Call(uintptr(unsafe.Pointer(&status)))
+	if r == 0 {
+		return 0, 0, err
+	}
+
+	return status.TotalPhys - status.AvailPhys, status.TotalPhys, nil
 }
 
 func (c *Collector) getLoadAvg() []float64 {
 	return []float64{0, 0, 0}
 }
+
+func filetimeTicks(ft syscall.Filetime) uint64 {
+	return uint64(ft.HighDateTime)<<32 | uint64(ft.LowDateTime)
+}
@@ -0,0 +1,63 @@
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+
+package metrics
+
+import (
+	"syscall"
+	"testing"
+)
+
+func TestWindowsMemoryAndDisk(t *testing.T) {
+	c := NewCollector()
+	used, total, err := c.getMemoryInfo()
+	if err != nil || total == 0 || used > total {
+		t.Fatalf("getMemoryInfo = %d/%d, %v", used, total, err)
+	}
+	used, total, err = c.getDiskInfo("/")
+	if err != nil || total == 0 || used > total {
+		t.Fatalf("getDiskInfo(/) = %d/%d, %v", used, total, err)
+	}
+	if _, _, err := c.getDiskInfo(`Q:\does\not\exist`); err == nil {
+		t.Fatal("getDiskInfo on a missing drive succeeded")
+	}
+}
+
+func TestWindowsCPUPercent(t *testing.T) {
+	c := NewCollector()
+	if pct, err := c.getCPUPercent(); err != nil || pct != 0 {
+		t.Fatalf("first sample = %v, %v, want 0 while there is no baseline", pct, err)
+	}
+	for i := 0; i < 3; i++ {
+		spin := 0
+		for j := 0; j < 50_000_000; j++ {
+			spin += j
+		}
+		_ = spin
+		pct, err := c.getCPUPercent()
+		if err != nil || pct < 0 || pct > 100 {
+			t.Fatalf("sample %d = %v, %v, want 0-100", i, pct, err)
+		}
+	}
+}
+
+func TestFiletimeTicks(t *testing.T) {
+	if got := filetimeTicks(syscall.Filetime{HighDateTime: 1, LowDateTime: 2}); got != 1<<32|2 {
+		t.Fatalf("filetimeTicks = %d", got)
+	}
+}
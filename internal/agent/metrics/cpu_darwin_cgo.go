@@ -0,0 +1,68 @@
+//go:build darwin && cgo
+
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+
+package metrics
+
+/*
+#include <mach/mach.h>
+*/
+import "C"
+
+import (
+	"fmt"
+	"unsafe"
+)
+
+func (c *Collector) getCPUPercent() (float64, error) {
+	var info C.host_cpu_load_info_data_t
+	count := C.mach_msg_type_number_t(unsafe.Sizeof(info) / unsafe.Sizeof(C.integer_t(0)))
+	host := C.mach_host_self()
+	defer C.mach_port_deallocate(C.mach_task_self_, host)
+	if ret := C.host_statistics(host, C.HOST_CPU_LOAD_INFO, C.host_info_t(unsafe.Pointer(&info)), &count); ret != C.KERN_SUCCESS {
+		return 0, fmt.Errorf("host_statistics: kern_return_t %d", ret)
+	}
+
+	idle := uint64(info.cpu_ticks[C.CPU_STATE_IDLE])
+	total := idle + uint64(info.cpu_ticks[C.CPU_STATE_USER]) +
+		uint64(info.cpu_ticks[C.CPU_STATE_SYSTEM]) + uint64(info.cpu_ticks[C.CPU_STATE_NICE])
+
+	if c.prevCPUTotal == 0 || total <= c.prevCPUTotal || idle < c.prevCPUIdle {
+		c.prevCPUIdle = idle
+		c.prevCPUTotal = total
+		return 0, nil
+	}
+
+	idleDelta := idle - c.prevCPUIdle
+	totalDelta := total - c.prevCPUTotal
+
+	c.prevCPUIdle = idle
+	c.prevCPUTotal = total
+
+	cpuPercent := (1.0 - float64(idleDelta)/float64(totalDelta)) * 100
+
+	if cpuPercent < 0 {
+		cpuPercent = 0
+	}
+	if cpuPercent > 100 {
+		cpuPercent = 100
+	}
+
+	return cpuPercent, nil
+}
@@ -19,6 +19,7 @@
 package metrics
 
 import (
+	"sync"
 	"time"
 )
 
@@ -35,6 +36,7 @@ type System struct {
 }
 
 type Collector struct {
+	mu           sync.Mutex
 	prevCPUIdle  uint64
 	prevCPUTotal uint64
 	bootTime     time.Time
@@ -47,6 +49,9 @@ func NewCollector() *Collector {
 }
 
 func (c *Collector) Collect() (*System, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
 	m := &System{
 		LoadAvg: []float64{0, 0, 0},
 		Uptime:  int64(time.Since(c.bootTime).Seconds()),
@@ -74,3 +79,11 @@ func (c *Collector) Collect() (*System, error) {
 
 	return m, nil
 }
+
+func (c *Collector) DiskSpace(path string) (uint64, uint64, error) {
+	used, total, err := c.getDiskInfo(path)
+	if err != nil {
+		return 0, 0, err
+	}
+	return total - used, total, nil
+}
@@ -0,0 +1,44 @@
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+
+package metrics
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestCollectConcurrently(t *testing.T) {
+	c := NewCollector()
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			m, err := c.Collect()
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if m.CPUPercent < 0 || m.CPUPercent > 100 {
+				t.Errorf("CPUPercent = %v, want 0-100", m.CPUPercent)
+			}
+		}()
+	}
+	wg.Wait()
+}
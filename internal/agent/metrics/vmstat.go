@@ -0,0 +1,89 @@
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+
+package metrics
+
+import (
+	"bufio"
+	"strconv"
+	"strings"
+)
+
+func parseCPUUsage(out string, cpus int) float64 {
+	if cpus < 1 {
+		cpus = 1
+	}
+	var sum float64
+	for _, field := range strings.Fields(out) {
+		val, err := strconv.ParseFloat(strings.ReplaceAll(field, ",", "."), 64)
+		if err == nil {
+			sum += val
+		}
+	}
+
+	cpuPercent := sum / float64(cpus)
+	if cpuPercent < 0 {
+		cpuPercent = 0
+	}
+	if cpuPercent > 100 {
+		cpuPercent = 100
+	}
+	return cpuPercent
+}
+
+func parseVMStatAvailable(out string) uint64 {
+	pageSize := uint64(4096)
+	pages := make(map[string]uint64)
+
+	scanner := bufio.NewScanner(strings.NewReader(out))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if _, rest, ok := strings.Cut(line, "page size of "); ok {
+			if fields := strings.Fields(rest); len(fields) > 0 {
+				if size, err := strconv.ParseUint(fields[0], 10, 64); err == nil && size > 0 {
+					pageSize = size
+				}
+			}
+			continue
+		}
+		name, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		val, err := strconv.ParseUint(strings.TrimSuffix(strings.TrimSpace(value), "."), 10, 64)
+		if err != nil {
+			continue
+		}
+		pages[strings.TrimSpace(name)] = val
+	}
+
+	free := pages["Pages free"] + pages["Pages inactive"] + pages["Pages speculative"]
+	return free * pageSize
+}
+
+func parseLoadAvg(out string) []float64 {
+	fields := strings.Fields(strings.Trim(strings.TrimSpace(out), "{}"))
+	if len(fields) < 3 {
+		return []float64{0, 0, 0}
+	}
+	load := make([]float64, 3)
+	for i := 0; i < 3; i++ {
+		load[i], _ = strconv.ParseFloat(strings.ReplaceAll(fields[i], ",", "."), 64)
+	}
+	return load
+}
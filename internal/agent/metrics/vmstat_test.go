@@ -0,0 +1,109 @@
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+
+package metrics
+
+import (
+	"reflect"
+	"testing"
+)
+
+const vmStatAppleSilicon = `Mach Virtual Memory Statistics: (page size of 16384 bytes)
+Pages free:                                4387.
+Pages active:                            239870.
+Pages inactive:                          236745.
+Pages speculative:                         1846.
+Pages throttled:                              0.
+Pages wired down:                        123925.
+Pages purgeable:                           5319.
+"Translation faults":                 918713523.
+Pages copy-on-write:                   28147710.
+Pages zero filled:                    360916226.
+Pages reactivated:                      3560862.
+Pages purged:                           1693712.
+File-backed pages:                       169281.
+Anonymous pages:                         309180.
+Pages stored in compressor:              717422.
+Pages occupied by compressor:            151366.
+Decompressions:                         5863474.
+Compressions:                           9346713.
+Pageins:                               14553271.
+Pageouts:                                134551.
+Swapins:                                      0.
+Swapouts:                                     0.
+`
+
+const vmStatIntel = `Mach Virtual Memory Statistics: (page size of 4096 bytes)
+Pages free:                               22083.
+Pages active:                           1024357.
+Pages inactive:                          998822.
+Pages speculative:                        11254.
+Pages throttled:                              0.
+Pages wired down:                        520441.
+`
+
+func TestParseVMStatAvailable(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		out  string
+		want uint64
+	}{
+		{"apple silicon", vmStatAppleSilicon, (4387 + 236745 + 1846) * 16384},
+		{"intel", vmStatIntel, (22083 + 998822 + 11254) * 4096},
+		{"missing page size", "Mach Virtual Memory Statistics: (page size of )\nPages free: 10.\n", 10 * 4096},
+		{"empty", "", 0},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := parseVMStatAvailable(tc.out); got != tc.want {
+				t.Fatalf("parseVMStatAvailable = %d, want %d", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestParseLoadAvg(t *testing.T) {
+	for _, tc := range []struct {
+		out  string
+		want []float64
+	}{
+		{"{ 2.39 2.21 2.13 }\n", []float64{2.39, 2.21, 2.13}},
+		{"{ 2,39 2,21 2,13 }\n", []float64{2.39, 2.21, 2.13}},
+		{"", []float64{0, 0, 0}},
+	} {
+		if got := parseLoadAvg(tc.out); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("parseLoadAvg(%q) = %v, want %v", tc.out, got, tc.want)
+		}
+	}
+}
+
+func TestParseCPUUsage(t *testing.T) {
+	for _, tc := range []struct {
+		out  string
+		cpus int
+		want float64
+	}{
+		{"  0.0\n 12.5\n 37.5\n  0.0\n", 2, 25},
+		{" 12,5\n 37,5\n", 1, 50},
+		{"250.0\n 90.0\n", 2, 100},
+		{"", 0, 0},
+	} {
+		if got := parseCPUUsage(tc.out, tc.cpus); got != tc.want {
+			t.Errorf("parseCPUUsage(%q, %d) = %v, want %v", tc.out, tc.cpus, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,531 @@
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/urustack/uruflow/internal/config"
+	"github.com/urustack/uruflow/internal/models"
+	"github.com/urustack/uruflow/internal/services"
+	"github.com/urustack/uruflow/internal/storage"
+	"github.com/urustack/uruflow/internal/tcp"
+	"github.com/urustack/uruflow/pkg/helper"
+	"github.com/urustack/uruflow/pkg/logger"
+)
+
+const (
+	defaultDeploymentLimit = 20
+	maxDeploymentLimit     = 200
+)
+
+type APIHandler struct {
+	cfg           *config.Config
+	cfgPath       string
+	store         storage.Store
+	deployService *services.DeploymentService
+}
+
+type CreateRepositoryRequest struct {
+	Name        string   `json:"name"`
+	URL         string   `json:"url"`
+	Branch      string   `json:"branch"`
+	Branches    []string `json:"branches"`
+	AgentID     string   `json:"agent_id"`
+	Selector    string   `json:"agent_selector"`
+	Path        string   `json:"path"`
+	AutoDeploy  *bool    `json:"auto_deploy"`
+	BuildSystem string   `json:"build_system"`
+	BuildFile   string   `json:"build_file"`
+	BuildCmd    string   `json:"build_cmd"`
+	Image       string   `json:"image"`
+	Container   string   `json:"container"`
+	Ports       []string `json:"ports"`
+	Schedule    string   `json:"schedule"`
+	PathFilters []string `json:"path_filters"`
+}
+
+type MaintenanceRequest struct {
+	Enabled *bool `json:"enabled"`
+}
+
+type TriggerDeployRequest struct {
+	Repository  string `json:"repository"`
+	Branch      string `json:"branch"`
+	Commit      string `json:"commit"`
+	Trigger     string `json:"trigger"`
+	AgentID     string `json:"agent_id"`
+	TriggeredBy string `json:"triggered_by"`
+	DryRun      bool   `json:"dry_run"`
+}
+
+type ApprovalRequest struct {
+	Operator string `json:"operator"`
+}
+
+func NewAPIHandler(cfg *config.Config, cfgPath string, store storage.Store, deployService *services.DeploymentService) *APIHandler {
+	return &APIHandler{
+		cfg:           cfg,
+		cfgPath:       cfgPath,
+		store:         store,
+		deployService: deployService,
+	}
+}
+
+func (h *APIHandler) Register(r *mux.Router) {
+	r.HandleFunc("/agents", h.listAgents).Methods("GET")
+	r.HandleFunc("/agents/{id}", h.getAgent).Methods("GET")
+	r.HandleFunc("/agents/{id}/maintenance", h.setMaintenance).Methods("PUT")
+	r.HandleFunc("/agents/{id}/inventory", h.requestInventory).Methods("POST")
+	r.HandleFunc("/repositories", h.listRepositories).Methods("GET")
+	r.HandleFunc("/repositories", h.createRepository).Methods("POST")
+	r.HandleFunc("/repositories/{name}", h.deleteRepository).Methods("DELETE")
+	r.HandleFunc("/deployments", h.listDeployments).Methods("GET")
+	r.HandleFunc("/deployments", h.triggerDeploy).Methods("POST")
+	r.HandleFunc("/deployments/{id}/logs", h.deploymentLogs).Methods("GET")
+	r.HandleFunc("/deployments/{id}/approve", h.approveDeployment).Methods("POST")
+	r.HandleFunc("/deployments/{id}/reject", h.rejectDeployment).Methods("POST")
+	r.HandleFunc("/deployments/{id}/rollback", h.rollbackDeployment).Methods("POST")
+}
+
+func (h *APIHandler) listAgents(w http.ResponseWriter, r *http.Request) {
+	agents, err := h.store.GetAllAgents()
+	if err != nil {
+		h.internalError(w, "list agents", err)
+		return
+	}
+	if agents == nil {
+		agents = []models.Agent{}
+	}
+	helper.WriteJSON(w, http.StatusOK, agents)
+}
+
+func (h *APIHandler) getAgent(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	agent, err := h.store.GetAgent(id)
+	if err != nil {
+		h.internalError(w, "get agent", err)
+		return
+	}
+	if agent == nil {
+		helper.WriteError(w, http.StatusNotFound, "agent not found")
+		return
+	}
+
+	containers, err := h.store.GetContainersByAgent(id)
+	if err != nil {
+		h.internalError(w, "get containers", err)
+		return
+	}
+	agent.Containers = containers
+
+	helper.WriteJSON(w, http.StatusOK, agent)
+}
+
+func (h *APIHandler) setMaintenance(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	var req MaintenanceRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 64*1024)).Decode(&req); err != nil || req.Enabled == nil {
+		helper.WriteError(w, http.StatusBadRequest, "enabled is required")
+		return
+	}
+
+	agent := h.cfg.GetAgent(id)
+	if agent == nil {
+		helper.WriteError(w, http.StatusNotFound, "agent not found")
+		return
+	}
+	previous := agent.Maintenance
+	h.cfg.SetAgentMaintenance(id, *req.Enabled)
+	if err := h.cfg.Save(h.cfgPath); err != nil {
+		h.cfg.SetAgentMaintenance(id, previous)
+		h.internalError(w, "save config", err)
+		return
+	}
+	if err := h.store.SetAgentMaintenance(id, *req.Enabled); err != nil {
+		logger.Warn("[API] Failed to store maintenance flag for %s: %v", agent.Name, err)
+	}
+
+	logger.Info("[API] Maintenance mode for agent %s set to %v", agent.Name, *req.Enabled)
+	agent.Maintenance = *req.Enabled
+	reason, active := agent.InMaintenance(time.Now())
+	helper.WriteJSON(w, http.StatusOK, map[string]interface{}{
+		"id":          agent.ID,
+		"name":        agent.Name,
+		"maintenance": agent.Maintenance,
+		"frozen":      active,
+		"reason":      reason,
+	})
+}
+
+func (h *APIHandler) requestInventory(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	agent := h.cfg.GetAgent(id)
+	if agent == nil {
+		helper.WriteError(w, http.StatusNotFound, "agent not found")
+		return
+	}
+
+	err := h.deployService.RequestInventory(id)
+	switch {
+	case errors.Is(err, services.ErrAgentNotConnected):
+		helper.WriteError(w, http.StatusConflict, "agent is offline")
+		return
+	case errors.Is(err, tcp.ErrInventoryThrottled):
+		helper.WriteError(w, http.StatusTooManyRequests, err.Error())
+		return
+	case err != nil:
+		h.internalError(w, "request inventory", err)
+		return
+	}
+
+	logger.Info("[API] Inventory refresh requested from agent %s", agent.Name)
+	helper.WriteJSON(w, http.StatusAccepted, map[string]interface{}{
+		"id":   agent.ID,
+		"name": agent.Name,
+	})
+}
+
+func (h *APIHandler) listRepositories(w http.ResponseWriter, r *http.Request) {
+	repos := h.cfg.ListRepositories()
+	if repos == nil {
+		repos = []models.Repository{}
+	}
+	helper.WriteJSON(w, http.StatusOK, repos)
+}
+
+func (h *APIHandler) createRepository(w http.ResponseWriter, r *http.Request) {
+	var req CreateRepositoryRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 64*1024)).Decode(&req); err != nil {
+		helper.WriteError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	req.Name = strings.TrimSpace(req.Name)
+	req.URL = strings.TrimSpace(req.URL)
+	req.Selector = strings.TrimSpace(req.Selector)
+	if req.Name == "" || (req.URL == "" && req.BuildSystem != "image") || (req.AgentID == "") == (req.Selector == "") {
+		helper.WriteError(w, http.StatusBadRequest, "name, url and either agent_id or agent_selector are required")
+		return
+	}
+	if req.AgentID != "" && h.cfg.GetAgent(req.AgentID) == nil {
+		helper.WriteError(w, http.StatusBadRequest, "unknown agent_id")
+		return
+	}
+	if req.Selector != "" {
+		selector, err := models.ParseLabels(req.Selector)
+		if err != nil || len(selector) == 0 {
+			helper.WriteError(w, http.StatusBadRequest, "invalid agent_selector, expected key=value[,key=value]")
+			return
+		}
+		req.Selector = models.FormatLabels(selector)
+	}
+	req.Schedule = strings.TrimSpace(req.Schedule)
+	if req.Schedule != "" {
+		if _, err := models.ParseSchedule(req.Schedule); err != nil {
+			helper.WriteError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+	for _, f := range req.PathFilters {
+		if err := models.ValidatePathFilter(f); err != nil {
+			helper.WriteError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	repo := models.Repository{
+		Name:          req.Name,
+		URL:           req.URL,
+		Branch:        req.Branch,
+		Branches:      req.Branches,
+		AgentID:       req.AgentID,
+		AgentSelector: req.Selector,
+		Path:          req.Path,
+		AutoDeploy:    true,
+		BuildSystem:   models.BuildSystem(req.BuildSystem),
+		BuildFile:     req.BuildFile,
+		BuildCmd:      req.BuildCmd,
+		Image:         strings.TrimSpace(req.Image),
+		Container:     strings.TrimSpace(req.Container),
+		Ports:         req.Ports,
+		Schedule:      req.Schedule,
+		PathFilters:   req.PathFilters,
+	}
+	if repo.Branch == "" {
+		repo.Branch = "main"
+	}
+	if repo.BuildSystem == "" {
+		repo.BuildSystem = "compose"
+	}
+	if req.AutoDeploy != nil {
+		repo.AutoDeploy = *req.AutoDeploy
+	}
+	if repo.BuildSystem == "image" {
+		if err := repo.ValidateImage(); err != nil {
+			helper.WriteError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	if err := h.cfg.AddRepository(repo); err != nil {
+		helper.WriteError(w, http.StatusConflict, err.Error())
+		return
+	}
+	if err := h.cfg.Save(h.cfgPath); err != nil {
+		h.cfg.RemoveRepository(repo.Name)
+		h.internalError(w, "save config", err)
+		return
+	}
+	if err := h.store.CreateRepository(&repo); err != nil {
+		logger.Warn("[API] Failed to store repository %s: %v", repo.Name, err)
+	}
+	if err := h.deployService.Prefetch(&repo); err != nil {
+		logger.Debug("[API] Prefetch of %s not sent: %v", repo.Name, err)
+	}
+
+	logger.Info("[API] Repository %s added", repo.Name)
+	helper.WriteJSON(w, http.StatusCreated, repo)
+}
+
+func (h *APIHandler) deleteRepository(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+
+	if !h.cfg.RemoveRepository(name) {
+		helper.WriteError(w, http.StatusNotFound, "repository not found")
+		return
+	}
+	if err := h.cfg.Save(h.cfgPath); err != nil {
+		h.internalError(w, "save config", err)
+		return
+	}
+	if err := h.store.DeleteRepository(name); err != nil {
+		logger.Warn("[API] Failed to delete repository %s from store: %v", name, err)
+	}
+
+	logger.Info("[API] Repository %s removed", name)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *APIHandler) listDeployments(w http.ResponseWriter, r *http.Request) {
+	limit := defaultDeploymentLimit
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			helper.WriteError(w, http.StatusBadRequest, "limit must be a positive integer")
+			return
+		}
+		limit = n
+	}
+	if limit > maxDeploymentLimit {
+		limit = maxDeploymentLimit
+	}
+
+	deployments, err := h.deployService.GetRecent(limit)
+	if err != nil {
+		h.internalError(w, "list deployments", err)
+		return
+	}
+	if deployments == nil {
+		deployments = []models.Deployment{}
+	}
+	helper.WriteJSON(w, http.StatusOK, deployments)
+}
+
+func (h *APIHandler) deploymentLogs(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	deploy, err := h.store.GetDeployment(id)
+	if err != nil {
+		h.internalError(w, "get deployment", err)
+		return
+	}
+	if deploy == nil {
+		helper.WriteError(w, http.StatusNotFound, "deployment not found")
+		return
+	}
+
+	switch r.URL.Query().Get("format") {
+	case "", "json":
+	case "text":
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", services.LogExportName(deploy)))
+		if err := h.deployService.ExportLogs(w, deploy); err != nil {
+			logger.Warn("[API] Failed to export logs for deployment %s: %v", id, err)
+		}
+		return
+	default:
+		helper.WriteError(w, http.StatusBadRequest, "format must be json or text")
+		return
+	}
+
+	logs, err := h.deployService.GetLogs(id)
+	if err != nil {
+		h.internalError(w, "get deployment logs", err)
+		return
+	}
+	if logs == nil {
+		logs = []models.DeploymentLog{}
+	}
+	helper.WriteJSON(w, http.StatusOK, logs)
+}
+
+func (h *APIHandler) triggerDeploy(w http.ResponseWriter, r *http.Request) {
+	var req TriggerDeployRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 64*1024)).Decode(&req); err != nil {
+		helper.WriteError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if req.Repository == "" {
+		helper.WriteError(w, http.StatusBadRequest, "repository is required")
+		return
+	}
+
+	repo := h.cfg.GetRepository(req.Repository)
+	if repo == nil {
+		helper.WriteError(w, http.StatusNotFound, "repository not found")
+		return
+	}
+
+	branch := req.Branch
+	if branch == "" {
+		branch = repo.Branch
+	}
+	commit := req.Commit
+	if commit == "" {
+		commit = "HEAD"
+	}
+
+	opts := services.TriggerOptions{
+		Trigger:     "api",
+		TriggeredBy: "api",
+		Note:        req.TriggeredBy,
+		SourceIP:    remoteIP(r),
+		DryRun:      req.DryRun,
+	}
+	switch req.Trigger {
+	case "", "api":
+	case "manual":
+		opts.Trigger = "manual"
+		if req.TriggeredBy != "" {
+			opts.TriggeredBy, opts.Note = req.TriggeredBy, ""
+		}
+	default:
+		helper.WriteError(w, http.StatusBadRequest, "trigger must be api or manual")
+		return
+	}
+
+	agentID := repo.AgentID
+	if req.AgentID != "" {
+		if h.cfg.GetAgent(req.AgentID) == nil {
+			helper.WriteError(w, http.StatusBadRequest, "unknown agent_id")
+			return
+		}
+		agentID = req.AgentID
+	}
+
+	deploy, err := h.deployService.TriggerDeploy(agentID, repo.Name, branch, commit, opts)
+	switch {
+	case errors.Is(err, services.ErrRepoNotFound):
+		helper.WriteError(w, http.StatusNotFound, "repository not found")
+		return
+	case errors.Is(err, services.ErrAgentNotConnected):
+		helper.WriteError(w, http.StatusConflict, "agent is offline")
+		return
+	case errors.Is(err, services.ErrNoMatchingAgent):
+		helper.WriteError(w, http.StatusConflict, "no agent matches the repository's agent_selector")
+		return
+	case err != nil:
+		h.internalError(w, "trigger deployment", err)
+		return
+	}
+
+	helper.WriteJSON(w, http.StatusAccepted, deploy)
+}
+
+func (h *APIHandler) approveDeployment(w http.ResponseWriter, r *http.Request) {
+	operator, ok := decodeOperator(w, r)
+	if !ok {
+		return
+	}
+	deploy, err := h.deployService.Approve(mux.Vars(r)["id"], operator)
+	h.writeDeployment(w, "approve deployment", deploy, err)
+}
+
+func (h *APIHandler) rejectDeployment(w http.ResponseWriter, r *http.Request) {
+	operator, ok := decodeOperator(w, r)
+	if !ok {
+		return
+	}
+	deploy, err := h.deployService.Reject(mux.Vars(r)["id"], operator)
+	h.writeDeployment(w, "reject deployment", deploy, err)
+}
+
+func (h *APIHandler) rollbackDeployment(w http.ResponseWriter, r *http.Request) {
+	deploy, err := h.deployService.Rollback(mux.Vars(r)["id"])
+	h.writeDeployment(w, "roll back deployment", deploy, err)
+}
+
+func decodeOperator(w http.ResponseWriter, r *http.Request) (string, bool) {
+	var req ApprovalRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 64*1024)).Decode(&req); err != nil {
+			helper.WriteError(w, http.StatusBadRequest, "invalid request body")
+			return "", false
+		}
+	}
+	if req.Operator == "" {
+		return "api", true
+	}
+	return "api (" + req.Operator + ")", true
+}
+
+func (h *APIHandler) writeDeployment(w http.ResponseWriter, op string, deploy *models.Deployment, err error) {
+	switch {
+	case errors.Is(err, services.ErrDeployNotFound):
+		helper.WriteError(w, http.StatusNotFound, "deployment not found")
+	case errors.Is(err, services.ErrRepoNotFound):
+		helper.WriteError(w, http.StatusNotFound, "repository not found")
+	case errors.Is(err, services.ErrAgentNotConnected):
+		helper.WriteError(w, http.StatusConflict, "agent is offline")
+	case errors.Is(err, services.ErrNoMatchingAgent):
+		helper.WriteError(w, http.StatusConflict, "no agent matches the repository's agent_selector")
+	case errors.Is(err, services.ErrNotAwaiting), errors.Is(err, services.ErrApprovalExpired), errors.Is(err, services.ErrAgentMaintenance),
+		errors.Is(err, services.ErrNoRollbackTarget):
+		helper.WriteError(w, http.StatusConflict, err.Error())
+	case err != nil:
+		h.internalError(w, op, err)
+	default:
+		helper.WriteJSON(w, http.StatusAccepted, deploy)
+	}
+}
+
+func (h *APIHandler) internalError(w http.ResponseWriter, op string, err error) {
+	logger.Error("[API] %s: %v", op, err)
+	helper.WriteError(w, http.StatusInternalServerError, "internal error")
+}
@@ -0,0 +1,380 @@
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/urustack/uruflow/internal/api/middleware"
+	"github.com/urustack/uruflow/internal/config"
+	"github.com/urustack/uruflow/internal/models"
+	"github.com/urustack/uruflow/internal/services"
+	"github.com/urustack/uruflow/internal/storage"
+	"github.com/urustack/uruflow/internal/storage/sqlite"
+	"github.com/urustack/uruflow/internal/tcp"
+	"github.com/urustack/uruflow/internal/tcp/protocol"
+)
+
+const apiToken = "api-secret"
+
+type apiFixture struct {
+	router http.Handler
+	cfg    *config.Config
+	store  storage.Store
+	tcp    *tcp.Server
+	agents map[string]string
+	tokens map[string]string
+}
+
+func newAPIFixture(t *testing.T) *apiFixture {
+	t.Helper()
+	dir := t.TempDir()
+	store, err := sqlite.New(dir)
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	cfg := config.Default()
+	cfg.Server.APIToken = apiToken
+	cfg.Server.Host = "127.0.0.1"
+	cfg.Server.TCPPort = freePort(t)
+
+	f := &apiFixture{cfg: cfg, store: store, agents: map[string]string{}, tokens: map[string]string{}}
+	for _, name := range []string{"web", "db"} {
+		id, token, err := cfg.AddAgent(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := store.CreateAgent(&models.Agent{ID: id, Name: name, Status: models.AgentOffline}); err != nil {
+			t.Fatal(err)
+		}
+		f.agents[name], f.tokens[name] = id, token
+	}
+	repo := models.Repository{Name: "api", URL: "https://github.com/acme/api.git", Branch: "main", AgentID: f.agents["web"], BuildSystem: "compose"}
+	if err := cfg.AddRepository(repo); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.CreateRepository(&repo); err != nil {
+		t.Fatal(err)
+	}
+
+	f.tcp = tcp.NewServer(cfg, store)
+	deployService := services.NewDeploymentService(cfg, store, f.tcp)
+	r := mux.NewRouter()
+	api := r.PathPrefix("/api/v1").Subrouter()
+	api.Use(middleware.BearerAuth(apiToken))
+	NewAPIHandler(cfg, dir+"/config.yaml", store, deployService).Register(api)
+	f.router = r
+	return f
+}
+
+func freePort(t *testing.T) int {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	return ln.Addr().(*net.TCPAddr).Port
+}
+
+func (f *apiFixture) connect(t *testing.T, name string) {
+	t.Helper()
+	if err := f.tcp.Start(); err != nil {
+		t.Fatalf("start tcp server: %v", err)
+	}
+	t.Cleanup(func() { f.tcp.Stop() })
+
+	conn, err := net.Dial("tcp", net.JoinHostPort(f.cfg.Server.Host, strconv.Itoa(f.cfg.Server.TCPPort)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	auth, _ := protocol.NewMessage(protocol.TypeAuth, protocol.AuthPayload{
+		Token: f.tokens[name], Hostname: name, MachineID: "machine-" + name, Version: "1.1.0",
+	})
+	if err := protocol.NewWriter(conn).Write(auth); err != nil {
+		t.Fatal(err)
+	}
+	reader := protocol.NewReader(conn)
+	msg, err := reader.ReadWithTimeout(5 * time.Second)
+	if err != nil || msg.Type != protocol.TypeAuthOK {
+		t.Fatalf("auth = %v, %v", msg, err)
+	}
+	go func() {
+		for {
+			if _, err := reader.Read(); err != nil {
+				return
+			}
+		}
+	}()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !f.tcp.IsAgentConnected(f.agents[name]) {
+		if time.Now().After(deadline) {
+			t.Fatalf("agent %s never registered", name)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func (f *apiFixture) do(t *testing.T, method, path string, body any) *httptest.ResponseRecorder {
+	t.Helper()
+	var payload bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&payload).Encode(body); err != nil {
+			t.Fatal(err)
+		}
+	}
+	req := httptest.NewRequest(method, path, &payload)
+	req.Header.Set("Authorization", "Bearer "+apiToken)
+	req.RemoteAddr = "10.0.0.7:51200"
+	rec := httptest.NewRecorder()
+	f.router.ServeHTTP(rec, req)
+	return rec
+}
+
+func decodeJSON[T any](t *testing.T, rec *httptest.ResponseRecorder) T {
+	t.Helper()
+	var v T
+	if err := json.Unmarshal(rec.Body.Bytes(), &v); err != nil {
+		t.Fatalf("decode %q: %v", rec.Body.String(), err)
+	}
+	return v
+}
+
+func TestTriggerDeployFromAttachedTUI(t *testing.T) {
+	f := newAPIFixture(t)
+	f.connect(t, "db")
+
+	rec := f.do(t, http.MethodPost, "/api/v1/deployments", map[string]any{
+		"repository": "api", "trigger": "manual", "triggered_by": "alice", "agent_id": f.agents["db"],
+	})
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("status = %d %s", rec.Code, rec.Body.String())
+	}
+	d := decodeJSON[models.Deployment](t, rec)
+	if d.Trigger != "manual" || d.TriggeredBy != "alice" || d.Note != "" {
+		t.Fatalf("deployment attributed as trigger=%q by=%q note=%q, want a manual deploy by alice", d.Trigger, d.TriggeredBy, d.Note)
+	}
+	if d.AgentID != f.agents["db"] {
+		t.Fatalf("deployed to %s, want the requested agent %s", d.AgentID, f.agents["db"])
+	}
+}
+
+func TestTriggerDeployFromAPIKeepsNote(t *testing.T) {
+	f := newAPIFixture(t)
+	f.connect(t, "web")
+
+	rec := f.do(t, http.MethodPost, "/api/v1/deployments", map[string]any{"repository": "api", "triggered_by": "alice"})
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("status = %d %s", rec.Code, rec.Body.String())
+	}
+	d := decodeJSON[models.Deployment](t, rec)
+	if d.Trigger != "api" || d.TriggeredBy != "api" || d.Note != "alice" || d.SourceIP != "10.0.0.7" {
+		t.Fatalf("deployment = trigger=%q by=%q note=%q ip=%q", d.Trigger, d.TriggeredBy, d.Note, d.SourceIP)
+	}
+}
+
+func TestTriggerDeployRejectsBadTriggerAndAgent(t *testing.T) {
+	f := newAPIFixture(t)
+	for _, body := range []map[string]any{
+		{"repository": "api", "trigger": "webhook"},
+		{"repository": "api", "agent_id": "no-such-agent"},
+	} {
+		if rec := f.do(t, http.MethodPost, "/api/v1/deployments", body); rec.Code != http.StatusBadRequest {
+			t.Errorf("%v = %d, want 400", body, rec.Code)
+		}
+	}
+}
+
+func TestAPIRequiresBearerToken(t *testing.T) {
+	f := newAPIFixture(t)
+	for _, auth := range []string{"", "Bearer wrong", "Basic " + apiToken, apiToken} {
+		for _, route := range []struct{ method, path string }{
+			{http.MethodGet, "/api/v1/agents"},
+			{http.MethodGet, "/api/v1/repositories"},
+			{http.MethodDelete, "/api/v1/repositories/api"},
+			{http.MethodPost, "/api/v1/deployments"},
+		} {
+			req := httptest.NewRequest(route.method, route.path, nil)
+			if auth != "" {
+				req.Header.Set("Authorization", auth)
+			}
+			rec := httptest.NewRecorder()
+			f.router.ServeHTTP(rec, req)
+			if rec.Code != http.StatusUnauthorized || rec.Header().Get("WWW-Authenticate") != "Bearer" {
+				t.Errorf("%s %s with %q = %d, want 401 with a Bearer challenge", route.method, route.path, auth, rec.Code)
+			}
+		}
+	}
+	if f.cfg.GetRepository("api") == nil {
+		t.Fatal("unauthenticated delete removed the repository")
+	}
+}
+
+func TestAPIAgents(t *testing.T) {
+	f := newAPIFixture(t)
+
+	rec := f.do(t, http.MethodGet, "/api/v1/agents", nil)
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "application/json" {
+		t.Fatalf("list agents = %d %s", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	agents := decodeJSON[[]map[string]any](t, rec)
+	if len(agents) != 2 {
+		t.Fatalf("listed %d agents, want 2", len(agents))
+	}
+	for _, a := range agents {
+		if a["id"] == "" || a["name"] == "" || a["status"] != string(models.AgentOffline) {
+			t.Fatalf("agent json = %v", a)
+		}
+		if _, leaked := a["token"]; leaked {
+			t.Fatal("agent json includes the token")
+		}
+	}
+
+	rec = f.do(t, http.MethodGet, "/api/v1/agents/"+f.agents["web"], nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("get agent = %d", rec.Code)
+	}
+	if a := decodeJSON[models.Agent](t, rec); a.ID != f.agents["web"] || a.Name != "web" {
+		t.Fatalf("get agent = %+v", a)
+	}
+
+	rec = f.do(t, http.MethodGet, "/api/v1/agents/missing", nil)
+	if rec.Code != http.StatusNotFound || decodeJSON[map[string]string](t, rec)["error"] != "agent not found" {
+		t.Fatalf("unknown agent = %d %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestAPIRepositories(t *testing.T) {
+	f := newAPIFixture(t)
+
+	rec := f.do(t, http.MethodGet, "/api/v1/repositories", nil)
+	if repos := decodeJSON[[]models.Repository](t, rec); rec.Code != http.StatusOK || len(repos) != 1 || repos[0].Name != "api" {
+		t.Fatalf("list repositories = %d %v", rec.Code, repos)
+	}
+
+	create := map[string]any{"name": "worker", "url": "https://github.com/acme/worker.git", "agent_id": f.agents["db"]}
+	rec = f.do(t, http.MethodPost, "/api/v1/repositories", create)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("create repository = %d %s", rec.Code, rec.Body.String())
+	}
+	repo := decodeJSON[models.Repository](t, rec)
+	if repo.Name != "worker" || repo.Branch != "main" || repo.BuildSystem != "compose" || !repo.AutoDeploy || repo.AgentID != f.agents["db"] {
+		t.Fatalf("created repository = %+v, want defaults filled in", repo)
+	}
+	if f.cfg.GetRepository("worker") == nil {
+		t.Fatal("created repository missing from the config")
+	}
+
+	for _, tc := range []struct {
+		body any
+		code int
+	}{
+		{create, http.StatusConflict},
+		{map[string]any{"name": "x", "url": "https://github.com/acme/x.git"}, http.StatusBadRequest},
+		{map[string]any{"name": "x", "url": "https://github.com/acme/x.git", "agent_id": "missing"}, http.StatusBadRequest},
+		{map[string]any{"name": "x", "url": "https://github.com/acme/x.git", "agent_selector": "not a label"}, http.StatusBadRequest},
+		{"not an object", http.StatusBadRequest},
+	} {
+		if rec := f.do(t, http.MethodPost, "/api/v1/repositories", tc.body); rec.Code != tc.code {
+			t.Errorf("create %v = %d, want %d", tc.body, rec.Code, tc.code)
+		}
+	}
+
+	if rec := f.do(t, http.MethodDelete, "/api/v1/repositories/worker", nil); rec.Code != http.StatusNoContent || rec.Body.Len() != 0 {
+		t.Fatalf("delete repository = %d %q", rec.Code, rec.Body.String())
+	}
+	if rec := f.do(t, http.MethodDelete, "/api/v1/repositories/worker", nil); rec.Code != http.StatusNotFound {
+		t.Fatalf("delete missing repository = %d, want 404", rec.Code)
+	}
+}
+
+func TestAPIDeployments(t *testing.T) {
+	f := newAPIFixture(t)
+	start := time.Now().Add(-time.Hour)
+	for i, id := range []string{"d1", "d2", "d3"} {
+		if err := f.store.CreateDeployment(&models.Deployment{
+			ID: id, Repository: "api", Branch: "main", Commit: "abc1234", AgentID: f.agents["web"],
+			Status: models.DeploySuccess, Trigger: "webhook", StartedAt: start.Add(time.Duration(i) * time.Minute),
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := f.store.AddDeploymentLog(&models.DeploymentLog{DeploymentID: "d2", Line: "› Completed in 3s", Stream: "stdout", Timestamp: start}); err != nil {
+		t.Fatal(err)
+	}
+
+	rec := f.do(t, http.MethodGet, "/api/v1/deployments?limit=2", nil)
+	deployments := decodeJSON[[]models.Deployment](t, rec)
+	if rec.Code != http.StatusOK || len(deployments) != 2 || deployments[0].ID != "d3" {
+		t.Fatalf("list deployments = %d %v, want the 2 newest", rec.Code, deployments)
+	}
+	for _, limit := range []string{"0", "-1", "ten"} {
+		if rec := f.do(t, http.MethodGet, "/api/v1/deployments?limit="+limit, nil); rec.Code != http.StatusBadRequest {
+			t.Errorf("limit=%s = %d, want 400", limit, rec.Code)
+		}
+	}
+
+	rec = f.do(t, http.MethodGet, "/api/v1/deployments/d2/logs", nil)
+	logs := decodeJSON[[]models.DeploymentLog](t, rec)
+	if rec.Code != http.StatusOK || len(logs) != 1 || logs[0].Line != "› Completed in 3s" || logs[0].DeploymentID != "d2" {
+		t.Fatalf("logs = %d %v", rec.Code, logs)
+	}
+	rec = f.do(t, http.MethodGet, "/api/v1/deployments/d1/logs", nil)
+	if rec.Code != http.StatusOK || rec.Body.String() != "[]\n" {
+		t.Fatalf("empty logs = %d %q, want an empty json array", rec.Code, rec.Body.String())
+	}
+	if rec := f.do(t, http.MethodGet, "/api/v1/deployments/missing/logs", nil); rec.Code != http.StatusNotFound {
+		t.Fatalf("logs of unknown deployment = %d, want 404", rec.Code)
+	}
+	if rec := f.do(t, http.MethodGet, "/api/v1/deployments/d2/logs?format=xml", nil); rec.Code != http.StatusBadRequest {
+		t.Fatalf("format=xml = %d, want 400", rec.Code)
+	}
+}
+
+func TestTriggerDeployStatusCodes(t *testing.T) {
+	f := newAPIFixture(t)
+	cases := []struct {
+		body any
+		code int
+		err  string
+	}{
+		{map[string]any{}, http.StatusBadRequest, "repository is required"},
+		{"[", http.StatusBadRequest, "invalid request body"},
+		{map[string]any{"repository": "missing"}, http.StatusNotFound, "repository not found"},
+		{map[string]any{"repository": "api"}, http.StatusConflict, "agent is offline"},
+	}
+	for _, tc := range cases {
+		rec := f.do(t, http.MethodPost, "/api/v1/deployments", tc.body)
+		if rec.Code != tc.code || decodeJSON[map[string]string](t, rec)["error"] != tc.err {
+			t.Errorf("trigger %v = %d %s, want %d %q", tc.body, rec.Code, rec.Body.String(), tc.code, tc.err)
+		}
+	}
+}
@@ -0,0 +1,182 @@
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+
+package handlers
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"testing"
+
+	"github.com/urustack/uruflow/internal/models"
+)
+
+const bitbucketPush = `{
+  "push": {
+    "changes": [
+      {
+        "old": {
+          "type": "branch",
+          "name": "main",
+          "target": {"type": "commit", "hash": "1e65c05c1d5171631d92438a13901ca7dae9618c"}
+        },
+        "new": {
+          "type": "branch",
+          "name": "main",
+          "target": {
+            "type": "commit",
+            "hash": "709d658dc5b6d6afcd46049c2f332ee3f515a67d",
+            "author": {"raw": "Emma <emma@acme.dev>"},
+            "message": "fix invoice rounding\n",
+            "date": "2026-10-12T09:14:03+00:00",
+            "parents": [{"type": "commit", "hash": "1e65c05c1d5171631d92438a13901ca7dae9618c"}]
+          },
+          "links": {"html": {"href": "https://bitbucket.org/acme/billing/branch/main"}}
+        },
+        "created": false,
+        "forced": false,
+        "closed": false,
+        "truncated": false,
+        "commits": [{"type": "commit", "hash": "709d658dc5b6d6afcd46049c2f332ee3f515a67d", "message": "fix invoice rounding\n"}]
+      }
+    ]
+  },
+  "actor": {
+    "type": "user",
+    "display_name": "Emma Stone",
+    "nickname": "emma",
+    "uuid": "{b1a8e2c4-5d6f-4a7b-8c9d-0e1f2a3b4c5d}",
+    "account_id": "557058:1a2b3c4d-5e6f-7a8b-9c0d-1e2f3a4b5c6d"
+  },
+  "repository": {
+    "type": "repository",
+    "name": "billing",
+    "full_name": "acme/billing",
+    "uuid": "{2c3d4e5f-6a7b-4c8d-9e0f-1a2b3c4d5e6f}",
+    "is_private": true,
+    "scm": "git",
+    "links": {"html": {"href": "https://bitbucket.org/acme/billing"}},
+    "workspace": {"type": "workspace", "slug": "acme", "name": "Acme"},
+    "project": {"type": "project", "key": "BIL", "name": "Billing"}
+  }
+}`
+
+const bitbucketPullRequest = `{
+  "pullrequest": {
+    "id": 42,
+    "title": "fix invoice rounding",
+    "state": "OPEN",
+    "source": {"branch": {"name": "fix/rounding"}, "commit": {"hash": "709d658dc5b6"}},
+    "destination": {"branch": {"name": "main"}, "commit": {"hash": "1e65c05c1d51"}}
+  },
+  "actor": {"display_name": "Emma Stone", "nickname": "emma"},
+  "repository": {
+    "name": "billing",
+    "full_name": "acme/billing",
+    "links": {"html": {"href": "https://bitbucket.org/acme/billing"}}
+  }
+}`
+
+func newBitbucketFixture(t *testing.T) *webhookFixture {
+	t.Helper()
+	f := newWebhookFixture(t)
+	repo := models.Repository{Name: "billing", URL: "git@bitbucket.org:acme/billing.git", Branch: "main",
+		AgentID: f.cfg.Agents[0].ID, BuildSystem: "compose", AutoDeploy: true, RequireApproval: true}
+	if err := f.cfg.AddRepository(repo); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.store.CreateRepository(&repo); err != nil {
+		t.Fatal(err)
+	}
+	return f
+}
+
+func bitbucketSignature(secret, body string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(body))
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestBitbucketPushDeploys(t *testing.T) {
+	cases := []struct {
+		name     string
+		headers  map[string]string
+		wantCode int
+	}{
+		{"hmac signature", map[string]string{"X-Hub-Signature": bitbucketSignature("global-secret", bitbucketPush)}, http.StatusOK},
+		{"secret query parameter", map[string]string{"secret": "global-secret"}, http.StatusOK},
+		{"wrong signature", map[string]string{"X-Hub-Signature": bitbucketSignature("other", bitbucketPush)}, http.StatusUnauthorized},
+		{"wrong secret", map[string]string{"secret": "other"}, http.StatusUnauthorized},
+		{"unsigned", map[string]string{}, http.StatusUnauthorized},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			f := newBitbucketFixture(t)
+			headers := map[string]string{"X-Event-Key": "repo:push", "X-Request-UUID": "9f2c6c7e-1a4b-4d2e-8f3a-5b6c7d8e9f01"}
+			url := "/webhook"
+			for k, v := range tc.headers {
+				if k == "secret" {
+					url += "?secret=" + v
+				} else {
+					headers[k] = v
+				}
+			}
+
+			code, resp := f.postURL(t, url, headers, bitbucketPush)
+			if code != tc.wantCode {
+				t.Fatalf("status = %d %v, want %d", code, resp, tc.wantCode)
+			}
+			deploys, _ := f.store.GetDeploymentsByRepo("billing", 10)
+			if tc.wantCode != http.StatusOK {
+				if len(deploys) != 0 {
+					t.Fatalf("rejected push created %d deployment(s)", len(deploys))
+				}
+				return
+			}
+
+			if resp["status"] != "accepted" || resp["repository"] != "billing" || resp["branch"] != "main" {
+				t.Fatalf("push = %v", resp)
+			}
+			d, err := f.store.GetDeployment(resp["deployment_id"].(string))
+			if err != nil || d == nil {
+				t.Fatalf("GetDeployment = %v, %v", d, err)
+			}
+			if d.Commit != "709d658dc5b6d6afcd46049c2f332ee3f515a67d" || d.Branch != "main" || d.TriggeredBy != "emma" {
+				t.Fatalf("deployment = %+v", d)
+			}
+		})
+	}
+}
+
+func TestBitbucketNonPushEventIgnored(t *testing.T) {
+	f := newBitbucketFixture(t)
+	headers := map[string]string{
+		"X-Event-Key":     "pullrequest:created",
+		"X-Hub-Signature": bitbucketSignature("global-secret", bitbucketPullRequest),
+	}
+
+	code, resp := f.post(t, headers, bitbucketPullRequest)
+	if code != http.StatusOK || resp["status"] != "ignored" || resp["reason"] != "event type 'pullrequest:created' not supported" {
+		t.Fatalf("pull request event = %d %v", code, resp)
+	}
+	if deploys, _ := f.store.GetDeploymentsByRepo("billing", 10); len(deploys) != 0 {
+		t.Fatalf("non-push event created %d deployment(s)", len(deploys))
+	}
+}
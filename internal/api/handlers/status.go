@@ -0,0 +1,164 @@
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+
+package handlers
+
+import (
+	_ "embed"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/urustack/uruflow/internal/models"
+	"github.com/urustack/uruflow/internal/storage"
+	"github.com/urustack/uruflow/pkg/helper"
+	"github.com/urustack/uruflow/pkg/logger"
+)
+
+const statusDeployments = 20
+
+//go:embed status.html
+var statusPage []byte
+
+type StatusHandler struct {
+	store storage.Store
+}
+
+type StatusResponse struct {
+	Agents      []StatusAgent      `json:"agents"`
+	Deployments []StatusDeployment `json:"deployments"`
+	Alerts      []StatusAlert      `json:"alerts"`
+	GeneratedAt time.Time          `json:"generated_at"`
+}
+
+type StatusAgent struct {
+	Name          string    `json:"name"`
+	Status        string    `json:"status"`
+	Version       string    `json:"version"`
+	CPUPercent    float64   `json:"cpu_percent"`
+	MemoryPercent float64   `json:"memory_percent"`
+	DiskPercent   float64   `json:"disk_percent"`
+	LastHeartbeat time.Time `json:"last_heartbeat"`
+}
+
+type StatusDeployment struct {
+	Repository string    `json:"repository"`
+	Branch     string    `json:"branch"`
+	Commit     string    `json:"commit"`
+	Agent      string    `json:"agent"`
+	Status     string    `json:"status"`
+	Trigger    string    `json:"trigger"`
+	Duration   int64     `json:"duration"`
+	StartedAt  time.Time `json:"started_at"`
+}
+
+type StatusAlert struct {
+	Type      string    `json:"type"`
+	Agent     string    `json:"agent"`
+	Message   string    `json:"message"`
+	Severity  string    `json:"severity"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func NewStatusHandler(store storage.Store) *StatusHandler {
+	return &StatusHandler{store: store}
+}
+
+func (h *StatusHandler) Register(r *mux.Router) {
+	r.HandleFunc("", h.page).Methods("GET")
+	r.HandleFunc("/data", h.data).Methods("GET")
+}
+
+func (h *StatusHandler) page(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Write(statusPage)
+}
+
+func (h *StatusHandler) data(w http.ResponseWriter, r *http.Request) {
+	resp, err := h.snapshot()
+	if err != nil {
+		logger.Error("[HTTP] status snapshot failed: %v", err)
+		helper.WriteError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	helper.WriteJSON(w, http.StatusOK, resp)
+}
+
+func (h *StatusHandler) snapshot() (*StatusResponse, error) {
+	agents, err := h.store.GetAllAgents()
+	if err != nil {
+		return nil, err
+	}
+	deployments, err := h.store.GetRecentDeployments(statusDeployments)
+	if err != nil {
+		return nil, err
+	}
+	alerts, err := h.store.GetActiveAlerts()
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &StatusResponse{
+		Agents:      make([]StatusAgent, 0, len(agents)),
+		Deployments: make([]StatusDeployment, 0, len(deployments)),
+		Alerts:      make([]StatusAlert, 0, len(alerts)),
+		GeneratedAt: time.Now(),
+	}
+	for _, a := range agents {
+		sa := StatusAgent{
+			Name:          a.Name,
+			Status:        string(a.Status),
+			Version:       a.Version,
+			LastHeartbeat: a.LastHeartbeat,
+		}
+		if a.Metrics != nil && a.Status == models.AgentOnline {
+			sa.CPUPercent = a.Metrics.CPUPercent
+			sa.MemoryPercent = a.Metrics.MemoryPercent
+			sa.DiskPercent = a.Metrics.DiskPercent
+		}
+		resp.Agents = append(resp.Agents, sa)
+	}
+	for _, d := range deployments {
+		commit := d.Commit
+		if len(commit) > 7 {
+			commit = commit[:7]
+		}
+		resp.Deployments = append(resp.Deployments, StatusDeployment{
+			Repository: d.Repository,
+			Branch:     d.Branch,
+			Commit:     commit,
+			Agent:      d.AgentName,
+			Status:     string(d.Status),
+			Trigger:    d.Trigger,
+			Duration:   d.Duration,
+			StartedAt:  d.StartedAt,
+		})
+	}
+	for _, a := range alerts {
+		resp.Alerts = append(resp.Alerts, StatusAlert{
+			Type:      a.Type,
+			Agent:     a.AgentName,
+			Message:   a.Message,
+			Severity:  string(a.Severity),
+			CreatedAt: a.CreatedAt,
+		})
+	}
+	return resp, nil
+}
@@ -0,0 +1,179 @@
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/urustack/uruflow/internal/api/middleware"
+	"github.com/urustack/uruflow/internal/models"
+	"github.com/urustack/uruflow/internal/storage"
+	"github.com/urustack/uruflow/internal/storage/sqlite"
+)
+
+func newStatusRouter(t *testing.T, user, password, token string) (http.Handler, storage.Store) {
+	t.Helper()
+	store, err := sqlite.New(t.TempDir())
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	r := mux.NewRouter()
+	status := r.PathPrefix("/status").Subrouter()
+	status.Use(middleware.StatusAuth(user, password, token))
+	NewStatusHandler(store).Register(status)
+	return r, store
+}
+
+func statusRequest(h http.Handler, method, path string, auth func(*http.Request)) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	if auth != nil {
+		auth(req)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestStatusPageRequiresCredentials(t *testing.T) {
+	h, _ := newStatusRouter(t, "ops", "hunter2", "api-token")
+
+	tests := []struct {
+		name string
+		auth func(*http.Request)
+		want int
+	}{
+		{"none", nil, http.StatusUnauthorized},
+		{"wrong password", func(r *http.Request) { r.SetBasicAuth("ops", "wrong") }, http.StatusUnauthorized},
+		{"api token as password", func(r *http.Request) { r.SetBasicAuth("ops", "api-token") }, http.StatusUnauthorized},
+		{"basic auth", func(r *http.Request) { r.SetBasicAuth("ops", "hunter2") }, http.StatusOK},
+		{"bearer token", func(r *http.Request) { r.Header.Set("Authorization", "Bearer api-token") }, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := statusRequest(h, http.MethodGet, "/status/data", tt.auth)
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.want)
+			}
+			if tt.want == http.StatusUnauthorized && rec.Header().Get("WWW-Authenticate") == "" {
+				t.Fatal("401 without a WWW-Authenticate challenge")
+			}
+		})
+	}
+}
+
+func TestStatusPageTokenOnly(t *testing.T) {
+	h, _ := newStatusRouter(t, "", "", "api-token")
+
+	if rec := statusRequest(h, http.MethodGet, "/status", func(r *http.Request) { r.SetBasicAuth("anyone", "api-token") }); rec.Code != http.StatusOK {
+		t.Fatalf("basic auth with the API token = %d", rec.Code)
+	}
+	if rec := statusRequest(h, http.MethodGet, "/status", func(r *http.Request) { r.SetBasicAuth("", "") }); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("empty credentials = %d", rec.Code)
+	}
+}
+
+func TestStatusPageIsReadOnly(t *testing.T) {
+	h, _ := newStatusRouter(t, "ops", "hunter2", "")
+	auth := func(r *http.Request) { r.SetBasicAuth("ops", "hunter2") }
+
+	rec := statusRequest(h, http.MethodGet, "/status", auth)
+	if rec.Code != http.StatusOK || !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/html") {
+		t.Fatalf("page = %d %s", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	if rec.Header().Get("Cache-Control") != "no-store" {
+		t.Fatalf("Cache-Control = %q", rec.Header().Get("Cache-Control"))
+	}
+	for _, method := range []string{http.MethodPost, http.MethodPut, http.MethodDelete} {
+		if rec := statusRequest(h, method, "/status/data", auth); rec.Code != http.StatusMethodNotAllowed {
+			t.Errorf("%s /status/data = %d, want 405", method, rec.Code)
+		}
+	}
+}
+
+func TestStatusDataOmitsSecrets(t *testing.T) {
+	h, store := newStatusRouter(t, "ops", "hunter2", "")
+	agents := []models.Agent{
+		{ID: "a1", Name: "web", TokenHash: "sha256:3f1c9e0b2a", Status: models.AgentOnline, Version: "1.4.0", Host: "10.0.0.5"},
+		{ID: "a2", Name: "db", TokenHash: "sha256:8d2e7f4c1b", Status: models.AgentOffline},
+	}
+	for i := range agents {
+		if err := store.CreateAgent(&agents[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, id := range []string{"a1", "a2"} {
+		if err := store.UpdateAgentMetrics(id, &models.AgentMetrics{CPUPercent: 42, MemoryPercent: 61, DiskPercent: 77}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := store.UpdateAgentStatus("a2", models.AgentOffline); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.CreateDeployment(&models.Deployment{
+		ID: "d1", Repository: "api", Branch: "main", Commit: "9f2c1e4b7a0d3c5e8f1a2b3c4d5e6f7a8b9c0d1e",
+		AgentID: "a1", AgentName: "web", Status: models.DeployFailed, Trigger: "webhook",
+		Output: "DATABASE_PASSWORD=s3cr3t", SourceIP: "140.82.112.3", StartedAt: time.Now(),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.CreateAlert(&models.Alert{
+		ID: "al1", AgentID: "a1", AgentName: "web", Type: "deploy_failed", Message: "api failed on web",
+		Severity: models.SeverityCritical, CreatedAt: time.Now(),
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	rec := statusRequest(h, http.MethodGet, "/status/data", func(r *http.Request) { r.SetBasicAuth("ops", "hunter2") })
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d", rec.Code)
+	}
+	body := rec.Body.String()
+	for _, secret := range []string{"sha256:3f1c9e0b2a", "s3cr3t", "140.82.112.3", "10.0.0.5", "\"a1\""} {
+		if strings.Contains(body, secret) {
+			t.Errorf("status data leaks %q: %s", secret, body)
+		}
+	}
+
+	var resp StatusResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Agents) != 2 || len(resp.Deployments) != 1 || len(resp.Alerts) != 1 {
+		t.Fatalf("response = %+v", resp)
+	}
+	for _, a := range resp.Agents {
+		if a.Name == "db" && a.CPUPercent != 0 {
+			t.Errorf("offline agent reports %.0f%% CPU", a.CPUPercent)
+		}
+		if a.Name == "web" && a.CPUPercent != 42 {
+			t.Errorf("online agent CPU = %.0f", a.CPUPercent)
+		}
+	}
+	if d := resp.Deployments[0]; d.Commit != "9f2c1e4" || d.Status != string(models.DeployFailed) {
+		t.Fatalf("deployment = %+v", d)
+	}
+}
@@ -21,6 +21,7 @@ package handlers
 import (
 	"fmt"
 	"io"
+	"net"
 	"net/http"
 
 	"github.com/urustack/uruflow/internal/services"
@@ -60,6 +61,11 @@ func (h *WebhookHandler) Handle(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if isBitbucket(r) {
+		h.handleBitbucket(w, r, body)
+		return
+	}
+
 	logger.Warn("[WEBHOOK] Unsupported webhook source from %s", r.RemoteAddr)
 	helper.WriteError(w, http.StatusBadRequest, "unsupported webhook source")
 }
@@ -71,6 +77,7 @@ func (h *WebhookHandler) handleGitHub(w http.ResponseWriter, r *http.Request, bo
 
 	if !h.webhookService.ValidateGitHubSignature(body, signature) {
 		logger.Warn("[WEBHOOK] GitHub signature validation failed from %s", r.RemoteAddr)
+		h.webhookService.RecordEvent("github", services.WebhookUnauthorized, nil, "invalid signature or token")
 		helper.WriteError(w, http.StatusUnauthorized, "invalid signature")
 		return
 	}
@@ -78,6 +85,7 @@ func (h *WebhookHandler) handleGitHub(w http.ResponseWriter, r *http.Request, bo
 	event := r.Header.Get("X-GitHub-Event")
 	if event != "push" {
 		logger.Debug("[WEBHOOK] GitHub event '%s' ignored (not a push event)", event)
+		h.webhookService.RecordEvent("github", services.WebhookIgnored, nil, fmt.Sprintf("event type '%s' not supported", event))
 		helper.WriteJSON(w, http.StatusOK, map[string]string{
 			"status": "ignored",
 			"reason": fmt.Sprintf("event type '%s' not supported", event),
@@ -85,9 +93,17 @@ func (h *WebhookHandler) handleGitHub(w http.ResponseWriter, r *http.Request, bo
 		return
 	}
 
-	result, err := h.webhookService.ProcessGitHubPush(body)
+	delivery := r.Header.Get("X-GitHub-Delivery")
+	if !h.webhookService.ClaimDelivery("github", delivery) {
+		h.writeDuplicate(w, "github", delivery)
+		return
+	}
+
+	result, err := h.webhookService.ProcessGitHubPush(body, remoteIP(r))
 	if err != nil {
 		logger.Error("[WEBHOOK] GitHub deployment failed: %v", err)
+		h.webhookService.ReleaseDelivery("github", delivery)
+		h.webhookService.RecordEvent("github", services.WebhookRejected, result, err.Error())
 
 		helper.WriteJSON(w, http.StatusOK, map[string]string{
 			"status": "failed",
@@ -96,15 +112,27 @@ func (h *WebhookHandler) handleGitHub(w http.ResponseWriter, r *http.Request, bo
 		return
 	}
 
+	if result.Coalesced {
+		h.writeCoalesced(w, "github", result)
+		return
+	}
+	if result.Ignored != "" {
+		h.writeIgnored(w, "github", result)
+		return
+	}
+
 	logger.Info("[WEBHOOK] GitHub deployment triggered: repo=%s branch=%s commit=%s deployment_id=%s",
 		result.Repository, result.Branch, result.Commit, result.Deployment.ID)
+	h.webhookService.RecordEvent("github", services.WebhookAccepted, result, "")
 
 	helper.WriteJSON(w, http.StatusOK, map[string]interface{}{
-		"status":        "accepted",
-		"deployment_id": result.Deployment.ID,
-		"repository":    result.Repository,
-		"branch":        result.Branch,
-		"commit":        result.Commit,
+		"status":            "accepted",
+		"deployment_id":     result.Deployment.ID,
+		"deployment_status": result.Deployment.Status,
+		"repository":        result.Repository,
+		"branch":            result.Branch,
+		"tag":               result.Tag,
+		"commit":            result.Commit,
 	})
 }
 
@@ -113,15 +141,17 @@ func (h *WebhookHandler) handleGitLab(w http.ResponseWriter, r *http.Request, bo
 
 	logger.Debug("[WEBHOOK] GitLab webhook received, validating token")
 
-	if !h.webhookService.ValidateGitLabToken(token) {
+	if !h.webhookService.ValidateGitLabToken(body, token) {
 		logger.Warn("[WEBHOOK] GitLab token validation failed from %s", r.RemoteAddr)
+		h.webhookService.RecordEvent("gitlab", services.WebhookUnauthorized, nil, "invalid signature or token")
 		helper.WriteError(w, http.StatusUnauthorized, "invalid token")
 		return
 	}
 
 	event := r.Header.Get("X-Gitlab-Event")
-	if event != "Push Hook" {
+	if event != "Push Hook" && event != "Tag Push Hook" {
 		logger.Debug("[WEBHOOK] GitLab event '%s' ignored (not a push event)", event)
+		h.webhookService.RecordEvent("gitlab", services.WebhookIgnored, nil, fmt.Sprintf("event type '%s' not supported", event))
 		helper.WriteJSON(w, http.StatusOK, map[string]string{
 			"status": "ignored",
 			"reason": fmt.Sprintf("event type '%s' not supported", event),
@@ -129,9 +159,17 @@ func (h *WebhookHandler) handleGitLab(w http.ResponseWriter, r *http.Request, bo
 		return
 	}
 
-	result, err := h.webhookService.ProcessGitLabPush(body)
+	delivery := r.Header.Get("X-Gitlab-Event-UUID")
+	if !h.webhookService.ClaimDelivery("gitlab", delivery) {
+		h.writeDuplicate(w, "gitlab", delivery)
+		return
+	}
+
+	result, err := h.webhookService.ProcessGitLabPush(body, remoteIP(r))
 	if err != nil {
 		logger.Error("[WEBHOOK] GitLab deployment failed: %v", err)
+		h.webhookService.ReleaseDelivery("gitlab", delivery)
+		h.webhookService.RecordEvent("gitlab", services.WebhookRejected, result, err.Error())
 
 		helper.WriteJSON(w, http.StatusOK, map[string]string{
 			"status": "failed",
@@ -140,18 +178,127 @@ func (h *WebhookHandler) handleGitLab(w http.ResponseWriter, r *http.Request, bo
 		return
 	}
 
+	if result.Coalesced {
+		h.writeCoalesced(w, "gitlab", result)
+		return
+	}
+	if result.Ignored != "" {
+		h.writeIgnored(w, "gitlab", result)
+		return
+	}
+
 	logger.Info("[WEBHOOK] GitLab deployment triggered: repo=%s branch=%s commit=%s deployment_id=%s",
 		result.Repository, result.Branch, result.Commit, result.Deployment.ID)
+	h.webhookService.RecordEvent("gitlab", services.WebhookAccepted, result, "")
+
+	helper.WriteJSON(w, http.StatusOK, map[string]interface{}{
+		"status":            "accepted",
+		"deployment_id":     result.Deployment.ID,
+		"deployment_status": result.Deployment.Status,
+		"repository":        result.Repository,
+		"branch":            result.Branch,
+		"tag":               result.Tag,
+		"commit":            result.Commit,
+	})
+}
+
+func (h *WebhookHandler) handleBitbucket(w http.ResponseWriter, r *http.Request, body []byte) {
+	signature := r.Header.Get("X-Hub-Signature")
+	token := r.URL.Query().Get("secret")
+
+	logger.Debug("[WEBHOOK] Bitbucket webhook received, validating signature")
+
+	if !h.webhookService.ValidateBitbucketRequest(body, signature, token) {
+		logger.Warn("[WEBHOOK] Bitbucket validation failed from %s", r.RemoteAddr)
+		h.webhookService.RecordEvent("bitbucket", services.WebhookUnauthorized, nil, "invalid signature or token")
+		helper.WriteError(w, http.StatusUnauthorized, "invalid signature")
+		return
+	}
+
+	event := r.Header.Get("X-Event-Key")
+	if event != "repo:push" {
+		logger.Debug("[WEBHOOK] Bitbucket event '%s' ignored (not a push event)", event)
+		h.webhookService.RecordEvent("bitbucket", services.WebhookIgnored, nil, fmt.Sprintf("event type '%s' not supported", event))
+		helper.WriteJSON(w, http.StatusOK, map[string]string{
+			"status": "ignored",
+			"reason": fmt.Sprintf("event type '%s' not supported", event),
+		})
+		return
+	}
+
+	result, err := h.webhookService.ProcessBitbucketPush(body, remoteIP(r))
+	if err != nil {
+		logger.Error("[WEBHOOK] Bitbucket deployment failed: %v", err)
+		h.webhookService.RecordEvent("bitbucket", services.WebhookRejected, result, err.Error())
+
+		helper.WriteJSON(w, http.StatusOK, map[string]string{
+			"status": "failed",
+			"error":  err.Error(),
+		})
+		return
+	}
+
+	if result.Coalesced {
+		h.writeCoalesced(w, "bitbucket", result)
+		return
+	}
+	if result.Ignored != "" {
+		h.writeIgnored(w, "bitbucket", result)
+		return
+	}
+
+	logger.Info("[WEBHOOK] Bitbucket deployment triggered: repo=%s branch=%s commit=%s deployment_id=%s",
+		result.Repository, result.Branch, result.Commit, result.Deployment.ID)
+	h.webhookService.RecordEvent("bitbucket", services.WebhookAccepted, result, "")
 
 	helper.WriteJSON(w, http.StatusOK, map[string]interface{}{
-		"status":        "accepted",
-		"deployment_id": result.Deployment.ID,
-		"repository":    result.Repository,
-		"branch":        result.Branch,
-		"commit":        result.Commit,
+		"status":            "accepted",
+		"deployment_id":     result.Deployment.ID,
+		"deployment_status": result.Deployment.Status,
+		"repository":        result.Repository,
+		"branch":            result.Branch,
+		"commit":            result.Commit,
 	})
 }
 
+func (h *WebhookHandler) writeDuplicate(w http.ResponseWriter, source, delivery string) {
+	logger.Info("[WEBHOOK] Ignoring duplicate %s delivery %s", source, delivery)
+	h.webhookService.RecordEvent(source, services.WebhookIgnored, nil, "duplicate delivery "+delivery)
+	helper.WriteJSON(w, http.StatusOK, map[string]string{
+		"status":   "duplicate",
+		"delivery": delivery,
+	})
+}
+
+func (h *WebhookHandler) writeCoalesced(w http.ResponseWriter, source string, result *services.WebhookResult) {
+	h.webhookService.RecordEvent(source, services.WebhookCoalesced, result,
+		"deploy cooldown, latest push deploys at "+result.DeployAt.Format("15:04:05"))
+	helper.WriteJSON(w, http.StatusOK, map[string]interface{}{
+		"status":     "coalesced",
+		"repository": result.Repository,
+		"branch":     result.Branch,
+		"commit":     result.Commit,
+		"deploy_at":  result.DeployAt,
+	})
+}
+
+func (h *WebhookHandler) writeIgnored(w http.ResponseWriter, source string, result *services.WebhookResult) {
+	logger.Info("[WEBHOOK] Ignoring %s push to %s: %s", source, result.Repository, result.Ignored)
+	h.webhookService.RecordEvent(source, services.WebhookIgnored, result, result.Ignored)
+	helper.WriteJSON(w, http.StatusOK, map[string]string{
+		"status": "ignored",
+		"reason": result.Ignored,
+	})
+}
+
+func remoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
 func isGitHub(r *http.Request) bool {
 	return r.Header.Get("X-GitHub-Event") != ""
 }
@@ -159,3 +306,7 @@ func isGitHub(r *http.Request) bool {
 func isGitLab(r *http.Request) bool {
 	return r.Header.Get("X-Gitlab-Event") != ""
 }
+
+func isBitbucket(r *http.Request) bool {
+	return r.Header.Get("X-Event-Key") != ""
+}
@@ -0,0 +1,339 @@
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+
+package handlers
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/urustack/uruflow/internal/config"
+	"github.com/urustack/uruflow/internal/models"
+	"github.com/urustack/uruflow/internal/services"
+	"github.com/urustack/uruflow/internal/storage"
+	"github.com/urustack/uruflow/internal/storage/sqlite"
+	"github.com/urustack/uruflow/internal/tcp"
+)
+
+const githubTagPush = `{
+  "ref": "refs/tags/v1.4.0",
+  "before": "0000000000000000000000000000000000000000",
+  "after": "5d1f0e7c3a9b2e4f6a8c0d2e4f6a8b0c2d4e6f8a",
+  "created": true,
+  "deleted": false,
+  "forced": false,
+  "base_ref": "refs/heads/main",
+  "compare": "https://github.com/acme/api/compare/v1.4.0",
+  "commits": [],
+  "head_commit": {
+    "id": "c3e1a7b95f2d4e6a8b0c1d3e5f7a9b2c4d6e8f01",
+    "tree_id": "8a1b2c3d4e5f60718293a4b5c6d7e8f901234567",
+    "distinct": true,
+    "message": "release 1.4.0",
+    "timestamp": "2026-10-12T09:14:03+02:00",
+    "author": {"name": "Mona Lisa", "email": "mona@acme.dev", "username": "mona"},
+    "committer": {"name": "GitHub", "email": "noreply@github.com", "username": "web-flow"},
+    "added": [], "removed": [], "modified": []
+  },
+  "repository": {
+    "id": 1296269,
+    "name": "api",
+    "full_name": "acme/api",
+    "private": true,
+    "clone_url": "https://github.com/acme/api.git",
+    "ssh_url": "git@github.com:acme/api.git",
+    "default_branch": "main"
+  },
+  "pusher": {"name": "mona", "email": "mona@acme.dev"},
+  "sender": {"login": "mona", "id": 583231, "type": "User"}
+}`
+
+const githubTagDelete = `{
+  "ref": "refs/tags/v1.4.0",
+  "before": "5d1f0e7c3a9b2e4f6a8c0d2e4f6a8b0c2d4e6f8a",
+  "after": "0000000000000000000000000000000000000000",
+  "created": false,
+  "deleted": true,
+  "base_ref": null,
+  "commits": [],
+  "head_commit": null,
+  "repository": {
+    "name": "api",
+    "full_name": "acme/api",
+    "clone_url": "https://github.com/acme/api.git",
+    "ssh_url": "git@github.com:acme/api.git"
+  },
+  "pusher": {"name": "mona", "email": "mona@acme.dev"},
+  "sender": {"login": "mona"}
+}`
+
+const gitlabTagPush = `{
+  "object_kind": "tag_push",
+  "event_name": "tag_push",
+  "before": "0000000000000000000000000000000000000000",
+  "after": "82b3d5ae55f7080f1e6022629cdb57bfae7cccc7",
+  "ref": "refs/tags/v1.5.0",
+  "ref_protected": true,
+  "checkout_sha": "5937ac0a7beb003549fc5fd26fc247adbce4a52e",
+  "message": "Tag message",
+  "user_id": 1,
+  "user_name": "John Smith",
+  "user_username": "jsmith",
+  "project_id": 1,
+  "project": {
+    "id": 1,
+    "name": "api",
+    "web_url": "https://github.com/acme/api",
+    "git_ssh_url": "git@github.com:acme/api.git",
+    "git_http_url": "https://github.com/acme/api.git",
+    "path_with_namespace": "acme/api",
+    "default_branch": "main"
+  },
+  "commits": [],
+  "total_commits_count": 0
+}`
+
+const gitlabTagDelete = `{
+  "object_kind": "tag_push",
+  "event_name": "tag_push",
+  "before": "82b3d5ae55f7080f1e6022629cdb57bfae7cccc7",
+  "after": "0000000000000000000000000000000000000000",
+  "ref": "refs/tags/v1.5.0",
+  "checkout_sha": null,
+  "user_name": "John Smith",
+  "user_username": "jsmith",
+  "project": {
+    "name": "api",
+    "git_ssh_url": "git@github.com:acme/api.git",
+    "git_http_url": "https://github.com/acme/api.git"
+  },
+  "commits": [],
+  "total_commits_count": 0
+}`
+
+type webhookFixture struct {
+	handler *WebhookHandler
+	cfg     *config.Config
+	store   storage.Store
+}
+
+func newWebhookFixture(t *testing.T) *webhookFixture {
+	t.Helper()
+	store, err := sqlite.New(t.TempDir())
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	cfg := config.Default()
+	cfg.Webhook.Secret = "global-secret"
+	id, _, err := cfg.AddAgent("web")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.CreateAgent(&models.Agent{ID: id, Name: "web", Status: models.AgentOffline}); err != nil {
+		t.Fatal(err)
+	}
+	for _, repo := range []models.Repository{
+		{Name: "api", URL: "https://github.com/acme/api.git", Branch: "main", AgentID: id, BuildSystem: "compose", AutoDeploy: true},
+		{Name: "api-release", URL: "git@github.com:acme/api.git", Branch: "main", AgentID: id, BuildSystem: "compose",
+			AutoDeploy: true, RequireApproval: true, TagPattern: "v*", Secret: "release-secret"},
+	} {
+		if err := cfg.AddRepository(repo); err != nil {
+			t.Fatal(err)
+		}
+		if err := store.CreateRepository(&repo); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	deployService := services.NewDeploymentService(cfg, store, tcp.NewServer(cfg, store))
+	webhookService := services.NewWebhookService(cfg, deployService, store)
+	t.Cleanup(webhookService.Stop)
+	return &webhookFixture{handler: NewWebhookHandler(webhookService), cfg: cfg, store: store}
+}
+
+func (f *webhookFixture) post(t *testing.T, headers map[string]string, body string) (int, map[string]any) {
+	t.Helper()
+	return f.postURL(t, "/webhook", headers, body)
+}
+
+func (f *webhookFixture) postURL(t *testing.T, url string, headers map[string]string, body string) (int, map[string]any) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, url, bytes.NewBufferString(body))
+	req.RemoteAddr = "140.82.112.3:41522"
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	rec := httptest.NewRecorder()
+	f.handler.Handle(rec, req)
+
+	var resp map[string]any
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode response %q: %v", rec.Body.String(), err)
+	}
+	return rec.Code, resp
+}
+
+func githubHeaders(secret, delivery, body string) map[string]string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(body))
+	return map[string]string{
+		"X-GitHub-Event":      "push",
+		"X-GitHub-Delivery":   delivery,
+		"X-Hub-Signature-256": "sha256=" + hex.EncodeToString(mac.Sum(nil)),
+	}
+}
+
+func TestGitHubTagPushDeploysMatchingRepository(t *testing.T) {
+	f := newWebhookFixture(t)
+
+	code, resp := f.post(t, githubHeaders("release-secret", "72d3162e-cc78-11e3-81ab-4c9367dc0958", githubTagPush), githubTagPush)
+	if code != http.StatusOK || resp["status"] != "accepted" {
+		t.Fatalf("tag push = %d %v", code, resp)
+	}
+	if resp["repository"] != "api-release" || resp["tag"] != "v1.4.0" || resp["deployment_status"] != string(models.DeployAwaitingApproval) {
+		t.Fatalf("tag push response = %v", resp)
+	}
+
+	d, err := f.store.GetDeployment(resp["deployment_id"].(string))
+	if err != nil || d == nil {
+		t.Fatalf("GetDeployment = %v, %v", d, err)
+	}
+	if d.Commit != "c3e1a7b95f2d4e6a8b0c1d3e5f7a9b2c4d6e8f01" {
+		t.Fatalf("deployed commit = %s, want the tagged commit rather than the tag object", d.Commit)
+	}
+	if d.Tag != "v1.4.0" || d.Trigger != "tag" || d.Branch != "" || d.TriggeredBy != "mona" {
+		t.Fatalf("deployment = %+v", d)
+	}
+}
+
+func TestGitHubTagPushIgnored(t *testing.T) {
+	f := newWebhookFixture(t)
+
+	code, resp := f.post(t, githubHeaders("release-secret", "delete-1", githubTagDelete), githubTagDelete)
+	if code != http.StatusOK || resp["status"] != "ignored" || resp["reason"] != "tag 'v1.4.0' was deleted" {
+		t.Fatalf("tag delete = %d %v", code, resp)
+	}
+
+	var nightly map[string]any
+	json.Unmarshal([]byte(githubTagPush), &nightly)
+	nightly["ref"] = "refs/tags/nightly"
+	data, _ := json.Marshal(nightly)
+	code, resp = f.post(t, githubHeaders("global-secret", "nightly-1", string(data)), string(data))
+	if code != http.StatusOK || resp["status"] != "ignored" ||
+		resp["reason"] != "repository 'api' has no tag_pattern, tag 'nightly' not deployed" {
+		t.Fatalf("unmatched tag = %d %v", code, resp)
+	}
+}
+
+func TestGitHubTagPushUsesRepositorySecret(t *testing.T) {
+	f := newWebhookFixture(t)
+
+	code, _ := f.post(t, githubHeaders("global-secret", "wrong-secret", githubTagPush), githubTagPush)
+	if code != http.StatusUnauthorized {
+		t.Fatalf("tag push signed with the global secret = %d, want 401", code)
+	}
+}
+
+func TestGitLabTagPushDeploysCheckoutSHA(t *testing.T) {
+	f := newWebhookFixture(t)
+	headers := map[string]string{
+		"X-Gitlab-Event":      "Tag Push Hook",
+		"X-Gitlab-Token":      "release-secret",
+		"X-Gitlab-Event-UUID": "13792a34-cac6-4fda-95a8-c58e00a3954e",
+	}
+
+	code, resp := f.post(t, headers, gitlabTagPush)
+	if code != http.StatusOK || resp["status"] != "accepted" || resp["repository"] != "api-release" {
+		t.Fatalf("tag push = %d %v", code, resp)
+	}
+	d, err := f.store.GetDeployment(resp["deployment_id"].(string))
+	if err != nil || d == nil {
+		t.Fatalf("GetDeployment = %v, %v", d, err)
+	}
+	if d.Commit != "5937ac0a7beb003549fc5fd26fc247adbce4a52e" || d.Tag != "v1.5.0" || d.TriggeredBy != "jsmith" {
+		t.Fatalf("deployment = %+v", d)
+	}
+
+	headers["X-Gitlab-Event-UUID"] = "7d0c6a5e-1b4f-4b6e-9f3c-2a8d5e1f0b9c"
+	code, resp = f.post(t, headers, gitlabTagDelete)
+	if code != http.StatusOK || resp["status"] != "ignored" || resp["reason"] != "tag 'v1.5.0' was deleted" {
+		t.Fatalf("tag delete = %d %v", code, resp)
+	}
+}
+
+func TestReplayedDeliveryIsIgnored(t *testing.T) {
+	f := newWebhookFixture(t)
+	headers := githubHeaders("release-secret", "72d3162e-cc78-11e3-81ab-4c9367dc0958", githubTagPush)
+
+	if code, resp := f.post(t, headers, githubTagPush); code != http.StatusOK || resp["status"] != "accepted" {
+		t.Fatalf("first delivery = %d %v", code, resp)
+	}
+	code, resp := f.post(t, headers, githubTagPush)
+	if code != http.StatusOK || resp["status"] != "duplicate" || resp["delivery"] != "72d3162e-cc78-11e3-81ab-4c9367dc0958" {
+		t.Fatalf("replayed delivery = %d %v", code, resp)
+	}
+	if deploys, _ := f.store.GetDeploymentsByRepo("api-release", 10); len(deploys) != 1 {
+		t.Fatalf("got %d deployments, want the replay dropped", len(deploys))
+	}
+
+	var unknown map[string]any
+	json.Unmarshal([]byte(githubTagPush), &unknown)
+	unknown["repository"] = map[string]any{"name": "billing", "clone_url": "https://github.com/acme/billing.git"}
+	data, _ := json.Marshal(unknown)
+	headers = githubHeaders("global-secret", "a1b2c3d4-cc78-11e3-81ab-4c9367dc0958", string(data))
+	for i := range 2 {
+		if code, resp := f.post(t, headers, string(data)); code != http.StatusOK || resp["status"] != "failed" {
+			t.Fatalf("attempt %d of a failed delivery = %d %v, want it processed again", i+1, code, resp)
+		}
+	}
+}
+
+func TestPushInsideCooldownIsCoalesced(t *testing.T) {
+	f := newWebhookFixture(t)
+	f.cfg.Webhook.CooldownSec = 60
+	for i := range f.cfg.Repositories {
+		f.cfg.Repositories[i].RequireApproval = true
+	}
+
+	var push map[string]any
+	json.Unmarshal([]byte(githubTagPush), &push)
+	push["ref"] = "refs/heads/main"
+	first, _ := json.Marshal(push)
+	push["head_commit"].(map[string]any)["id"] = "d4e6f8a0b2c4d6e8f0a1b3c5d7e9f1a3b5c7d9e1"
+	second, _ := json.Marshal(push)
+
+	code, resp := f.post(t, githubHeaders("global-secret", "push-1", string(first)), string(first))
+	if code != http.StatusOK || resp["status"] != "accepted" {
+		t.Fatalf("first push = %d %v", code, resp)
+	}
+	code, resp = f.post(t, githubHeaders("global-secret", "push-2", string(second)), string(second))
+	if code != http.StatusOK || resp["status"] != "coalesced" || resp["commit"] != "d4e6f8a" || resp["deploy_at"] == nil {
+		t.Fatalf("push inside the cooldown = %d %v", code, resp)
+	}
+	if deploys, _ := f.store.GetDeploymentsByRepo("api", 10); len(deploys) != 1 {
+		t.Fatalf("got %d deployments, want the second push held back", len(deploys))
+	}
+}
@@ -19,8 +19,11 @@
 package middleware
 
 import (
+	"crypto/subtle"
+	"github.com/urustack/uruflow/pkg/helper"
 	"github.com/urustack/uruflow/pkg/logger"
 	"net/http"
+	"strings"
 	"time"
 )
 
@@ -52,6 +55,47 @@ func Recovery(next http.Handler) http.Handler {
 	})
 }
 
+func BearerAuth(token string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			presented, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok || subtle.ConstantTimeCompare([]byte(presented), []byte(token)) != 1 {
+				w.Header().Set("WWW-Authenticate", "Bearer")
+				helper.WriteError(w, http.StatusUnauthorized, "unauthorized")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func StatusAuth(user, password, token string) func(http.Handler) http.Handler {
+	matches := func(presented, want string) bool {
+		return want != "" && subtle.ConstantTimeCompare([]byte(presented), []byte(want)) == 1
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if bearer, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok && matches(bearer, token) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if u, p, ok := r.BasicAuth(); ok {
+				if password != "" && matches(u, user) && matches(p, password) {
+					next.ServeHTTP(w, r)
+					return
+				}
+				if password == "" && matches(p, token) {
+					next.ServeHTTP(w, r)
+					return
+				}
+			}
+			w.Header().Set("WWW-Authenticate", `Basic realm="uruflow"`)
+			helper.WriteError(w, http.StatusUnauthorized, "unauthorized")
+		})
+	}
+}
+
 type responseWriter struct {
 	http.ResponseWriter
 	statusCode int
@@ -0,0 +1,126 @@
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+
+package middleware
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/urustack/uruflow/pkg/helper"
+	"github.com/urustack/uruflow/pkg/logger"
+)
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+func (b *bucket) take(now time.Time, perSec, capacity float64) (time.Duration, bool) {
+	b.tokens = math.Min(capacity, b.tokens+now.Sub(b.last).Seconds()*perSec)
+	b.last = now
+	if b.tokens >= 1 {
+		b.tokens--
+		return 0, true
+	}
+	return time.Duration((1 - b.tokens) / perSec * float64(time.Second)), false
+}
+
+type RateLimiter struct {
+	mu      sync.Mutex
+	perIP   float64
+	burst   float64
+	global  float64
+	buckets map[string]*bucket
+	all     bucket
+	swept   time.Time
+	now     func() time.Time
+}
+
+func NewRateLimiter(perIPMin, burst, globalMin int) *RateLimiter {
+	l := &RateLimiter{
+		perIP:   float64(perIPMin) / 60,
+		burst:   float64(max(burst, 1)),
+		global:  float64(globalMin) / 60,
+		buckets: make(map[string]*bucket),
+		now:     time.Now,
+	}
+	start := l.now()
+	l.all = bucket{tokens: float64(globalMin), last: start}
+	l.swept = start
+	return l
+}
+
+func (l *RateLimiter) Allow(ip string) (time.Duration, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+
+	if l.perIP > 0 {
+		b, ok := l.buckets[ip]
+		if !ok {
+			b = &bucket{tokens: l.burst, last: now}
+			l.buckets[ip] = b
+		}
+		if wait, ok := b.take(now, l.perIP, l.burst); !ok {
+			return wait, false
+		}
+	}
+	if l.global > 0 {
+		if wait, ok := l.all.take(now, l.global, l.global*60); !ok {
+			return wait, false
+		}
+	}
+	return 0, true
+}
+
+func (l *RateLimiter) sweep(now time.Time) {
+	if l.perIP <= 0 || now.Sub(l.swept) < time.Minute {
+		return
+	}
+	l.swept = now
+	refill := time.Duration(l.burst / l.perIP * float64(time.Second))
+	for ip, b := range l.buckets {
+		if now.Sub(b.last) > refill {
+			delete(l.buckets, ip)
+		}
+	}
+}
+
+func (l *RateLimiter) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ip := r.RemoteAddr
+		if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+			ip = host
+		}
+
+		if wait, ok := l.Allow(ip); !ok {
+			logger.Warn("[HTTP] rate limit exceeded for %s on %s", ip, r.URL.Path)
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			helper.WriteError(w, http.StatusTooManyRequests, "rate limit exceeded")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,123 @@
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func newTestLimiter(perIPMin, burst, globalMin int) (*RateLimiter, *time.Time) {
+	now := time.Date(2026, 10, 12, 9, 0, 0, 0, time.UTC)
+	l := NewRateLimiter(perIPMin, burst, globalMin)
+	l.now = func() time.Time { return now }
+	l.all.last = now
+	l.swept = now
+	return l, &now
+}
+
+func TestRateLimiterPerIP(t *testing.T) {
+	l, now := newTestLimiter(60, 3, 0)
+
+	for i := range 3 {
+		if _, ok := l.Allow("203.0.113.7"); !ok {
+			t.Fatalf("request %d inside the burst was limited", i+1)
+		}
+	}
+	wait, ok := l.Allow("203.0.113.7")
+	if ok {
+		t.Fatal("request over the burst was allowed")
+	}
+	if wait <= 0 || wait > time.Second {
+		t.Fatalf("wait = %s, want up to one second at 60/min", wait)
+	}
+	if _, ok := l.Allow("198.51.100.4"); !ok {
+		t.Fatal("another address was limited by the first one's bucket")
+	}
+
+	*now = now.Add(time.Second)
+	if _, ok := l.Allow("203.0.113.7"); !ok {
+		t.Fatal("bucket did not refill after a second")
+	}
+	if _, ok := l.Allow("203.0.113.7"); ok {
+		t.Fatal("bucket refilled more than one token in a second")
+	}
+}
+
+func TestRateLimiterGlobalCap(t *testing.T) {
+	l, now := newTestLimiter(0, 1, 2)
+
+	for i, ip := range []string{"203.0.113.1", "203.0.113.2"} {
+		if _, ok := l.Allow(ip); !ok {
+			t.Fatalf("request %d under the global cap was limited", i+1)
+		}
+	}
+	if _, ok := l.Allow("203.0.113.3"); ok {
+		t.Fatal("request over the global cap was allowed")
+	}
+	*now = now.Add(30 * time.Second)
+	if _, ok := l.Allow("203.0.113.3"); !ok {
+		t.Fatal("global bucket did not refill")
+	}
+}
+
+func TestRateLimiterSweepsIdleBuckets(t *testing.T) {
+	l, now := newTestLimiter(60, 5, 0)
+	l.Allow("203.0.113.7")
+
+	*now = now.Add(2 * time.Minute)
+	l.Allow("198.51.100.4")
+	if _, ok := l.buckets["203.0.113.7"]; ok {
+		t.Fatal("idle bucket was not swept")
+	}
+	if _, ok := l.buckets["198.51.100.4"]; !ok {
+		t.Fatal("active bucket was swept")
+	}
+}
+
+func TestRateLimiterMiddleware(t *testing.T) {
+	l, _ := newTestLimiter(60, 1, 0)
+	handler := l.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	send := func(addr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/webhook", nil)
+		req.RemoteAddr = addr
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := send("203.0.113.7:50000"); rec.Code != http.StatusNoContent {
+		t.Fatalf("first request = %d", rec.Code)
+	}
+	rec := send("203.0.113.7:50001")
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("second request from the same host = %d, want 429", rec.Code)
+	}
+	if got := rec.Header().Get("Retry-After"); got != "1" {
+		t.Fatalf("Retry-After = %q, want 1", got)
+	}
+	if rec := send("198.51.100.4:50000"); rec.Code != http.StatusNoContent {
+		t.Fatalf("request from another host = %d", rec.Code)
+	}
+}
@@ -20,8 +20,10 @@ package api
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"github.com/urustack/uruflow/internal/api/middleware"
+	"net"
 	"net/http"
 	"time"
 
@@ -36,24 +38,43 @@ import (
 
 type Server struct {
 	cfg            *config.Config
+	cfgPath        string
 	store          storage.Store
 	httpServer     *http.Server
 	tcpServer      *tcp.Server
 	deployService  *services.DeploymentService
 	webhookService *services.WebhookService
+	notifier       *services.Notifier
+	statuses       *services.CommitStatusReporter
+	retention      *services.RetentionService
+	scheduler      *services.Scheduler
 }
 
-func NewServer(cfg *config.Config, store storage.Store) *Server {
+func NewServer(cfg *config.Config, cfgPath string, store storage.Store) *Server {
 	tcpServer := tcp.NewServer(cfg, store)
 	deployService := services.NewDeploymentService(cfg, store, tcpServer)
-	webhookService := services.NewWebhookService(cfg, deployService)
+	webhookService := services.NewWebhookService(cfg, deployService, store)
+
+	notifier := services.NewNotifier(cfg.Notifications)
+	tcpServer.SetAlertHandler(notifier.NotifyAlert)
+	tcpServer.SetDeployFailedHandler(notifier.NotifyDeployFailed)
+
+	statuses := services.NewCommitStatusReporter(cfg)
+	deployService.SetStatusReporter(statuses)
+	tcpServer.SetDeployDoneHandler(statuses.DeploymentFinished)
+	tcpServer.SetCheckoutsHandler(deployService.CheckoutsReported)
 
 	return &Server{
 		cfg:            cfg,
+		cfgPath:        cfgPath,
 		store:          store,
 		tcpServer:      tcpServer,
 		deployService:  deployService,
 		webhookService: webhookService,
+		notifier:       notifier,
+		statuses:       statuses,
+		retention:      services.NewRetentionService(cfg, store),
+		scheduler:      services.NewScheduler(cfg, store, deployService),
 	}
 }
 
@@ -61,29 +82,56 @@ func (s *Server) Start() error {
 	if err := s.tcpServer.Start(); err != nil {
 		return fmt.Errorf("tcp server: %w", err)
 	}
+	s.retention.Start()
+	s.scheduler.Start()
+
+	addrs, err := s.cfg.HTTPListenAddrs()
+	if err != nil {
+		return fmt.Errorf("http listen: %w", err)
+	}
 
 	router := s.setupRoutes()
 	s.httpServer = &http.Server{
-		Addr:         fmt.Sprintf("%s:%d", s.cfg.Server.Host, s.cfg.Server.HTTPPort),
 		Handler:      router,
 		ReadTimeout:  5 * time.Second,
 		WriteTimeout: 5 * time.Second,
 		IdleTimeout:  60 * time.Second,
 	}
 
-	logger.Info("[HTTP] Webhook listener on %s:%d", s.cfg.Server.Host, s.cfg.Server.HTTPPort)
-
-	go func() {
-		if err := s.httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			logger.Error("[HTTP] Error: %v", err)
+	var errs []error
+	listening := 0
+	for _, addr := range addrs {
+		listener, err := net.Listen("tcp", addr)
+		if err != nil {
+			logger.Warn("[HTTP] listen on %s failed: %v", addr, err)
+			errs = append(errs, fmt.Errorf("%s: %w", addr, err))
+			continue
 		}
-	}()
+
+		logger.Info("[HTTP] Webhook listener on %s", addr)
+		listening++
+
+		go func() {
+			if err := s.httpServer.Serve(listener); err != nil && err != http.ErrServerClosed {
+				logger.Error("[HTTP] Error: %v", err)
+			}
+		}()
+	}
+
+	if listening == 0 {
+		return fmt.Errorf("http listen: %w", errors.Join(errs...))
+	}
 
 	return nil
 }
 
 func (s *Server) Shutdown(ctx context.Context) error {
 	s.tcpServer.Stop()
+	s.notifier.Stop()
+	s.retention.Stop()
+	s.scheduler.Stop()
+	s.webhookService.Stop()
+	s.statuses.Stop()
 
 	if s.httpServer != nil {
 		return s.httpServer.Shutdown(ctx)
@@ -94,7 +142,22 @@ func (s *Server) Shutdown(ctx context.Context) error {
 func (s *Server) setupRoutes() http.Handler {
 	r := mux.NewRouter()
 	webhookHandler := handlers.NewWebhookHandler(s.webhookService)
-	r.HandleFunc(s.cfg.Webhook.Path, webhookHandler.Handle).Methods("POST")
+	limiter := middleware.NewRateLimiter(s.cfg.Webhook.RateLimit, s.cfg.Webhook.RateBurst, s.cfg.Webhook.GlobalRateLimit)
+	r.Handle(s.cfg.Webhook.Path, limiter.Middleware(http.HandlerFunc(webhookHandler.Handle))).Methods("POST")
+
+	if s.cfg.Server.APIToken != "" {
+		apiRouter := r.PathPrefix("/api/v1").Subrouter()
+		apiRouter.Use(middleware.BearerAuth(s.cfg.Server.APIToken))
+		handlers.NewAPIHandler(s.cfg, s.cfgPath, s.store, s.deployService).Register(apiRouter)
+	} else {
+		logger.Info("[HTTP] REST API disabled (server.api_token not set)")
+	}
+
+	if s.cfg.Server.StatusPage {
+		statusRouter := r.PathPrefix("/status").Subrouter()
+		statusRouter.Use(middleware.StatusAuth(s.cfg.Server.StatusUser, s.cfg.Server.StatusPassword, s.cfg.Server.APIToken))
+		handlers.NewStatusHandler(s.store).Register(statusRouter)
+	}
 	return middleware.Recovery(middleware.Logging(r))
 }
 
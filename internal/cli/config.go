@@ -0,0 +1,198 @@
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+
+package cli
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/urustack/uruflow/internal/config"
+	"github.com/urustack/uruflow/internal/models"
+	"github.com/urustack/uruflow/internal/storage"
+	"github.com/urustack/uruflow/internal/storage/sqlite"
+)
+
+var (
+	exportOutput      string
+	exportWithSecrets bool
+	importOverwrite   bool
+	importSkip        bool
+	importDryRun      bool
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Export or import agent and repository definitions",
+}
+
+var configExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Write agents and repositories as YAML",
+	Args:  cobra.NoArgs,
+	Run:   runConfigExport,
+}
+
+var configImportCmd = &cobra.Command{
+	Use:   "import FILE",
+	Short: "Merge agents and repositories from an exported YAML file",
+	Args:  cobra.ExactArgs(1),
+	Run:   runConfigImport,
+}
+
+func init() {
+	configExportCmd.Flags().StringVarP(&exportOutput, "output", "o", "", "write to file instead of stdout")
+	configExportCmd.Flags().BoolVar(&exportWithSecrets, "with-secrets", false, "include agent token hashes, webhook secrets and env")
+	configImportCmd.Flags().BoolVar(&importOverwrite, "overwrite", false, "replace existing definitions that differ")
+	configImportCmd.Flags().BoolVar(&importSkip, "skip-existing", false, "keep existing definitions that differ")
+	configImportCmd.Flags().BoolVar(&importDryRun, "dry-run", false, "print the planned changes without applying them")
+	configCmd.AddCommand(configExportCmd, configImportCmd)
+	rootCmd.AddCommand(configCmd)
+}
+
+func runConfigExport(cmd *cobra.Command, args []string) {
+	if cfg == nil {
+		fmt.Printf("Error: no config found at %s\n", cfgPath)
+		os.Exit(1)
+	}
+
+	data, err := cfg.Export(exportWithSecrets).Marshal()
+	if err != nil {
+		fmt.Printf("Error encoding export: %v\n", err)
+		os.Exit(1)
+	}
+
+	if exportOutput == "" {
+		os.Stdout.Write(data)
+		return
+	}
+	if err := os.WriteFile(exportOutput, data, 0600); err != nil {
+		fmt.Printf("Error writing %s: %v\n", exportOutput, err)
+		os.Exit(1)
+	}
+	fmt.Printf("Exported %d agent(s) and %d repository(ies) to %s\n", len(cfg.ListAgents()), len(cfg.ListRepositories()), exportOutput)
+}
+
+func runConfigImport(cmd *cobra.Command, args []string) {
+	if cfg == nil {
+		fmt.Printf("Error: no config found at %s\n", cfgPath)
+		os.Exit(1)
+	}
+	if importOverwrite && importSkip {
+		fmt.Println("Error: --overwrite and --skip-existing cannot be combined")
+		os.Exit(1)
+	}
+
+	doc, err := config.LoadExport(args[0])
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	policy := config.ConflictFail
+	if importOverwrite {
+		policy = config.ConflictOverwrite
+	} else if importSkip {
+		policy = config.ConflictSkip
+	}
+
+	changes, err := cfg.PlanImport(doc, policy)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	pending := 0
+	for _, ch := range changes {
+		fmt.Printf("  %-10s %-10s %s\n", ch.Action, ch.Kind, ch.Name)
+		if ch.Action == config.ImportCreate || ch.Action == config.ImportUpdate {
+			pending++
+		}
+	}
+	if importDryRun {
+		fmt.Printf("Dry run: %d change(s) planned, nothing written\n", pending)
+		return
+	}
+	if pending == 0 {
+		fmt.Println("Nothing to import")
+		return
+	}
+
+	cfg.ApplyImport(changes)
+	if err := cfg.Save(cfgPath); err != nil {
+		fmt.Printf("Error saving config: %v\n", err)
+		os.Exit(1)
+	}
+
+	store, err := sqlite.New(cfg.Server.DataDir)
+	if err != nil {
+		fmt.Printf("Error initializing database: %v\n", err)
+		os.Exit(1)
+	}
+	defer store.Close()
+
+	for _, ch := range changes {
+		if err := storeImportChange(store, ch); err != nil {
+			fmt.Printf("Warning: %s %s saved to config but not to the database: %v\n", ch.Kind, ch.Name, err)
+		}
+	}
+
+	fmt.Printf("Imported %d change(s) into %s\n", pending, cfgPath)
+	for _, ch := range changes {
+		if ch.Token != "" {
+			fmt.Printf("New token for agent %s: %s\n", ch.Name, ch.Token)
+		}
+	}
+}
+
+func storeImportChange(store storage.Store, ch config.ImportChange) error {
+	if ch.Action != config.ImportCreate && ch.Action != config.ImportUpdate {
+		return nil
+	}
+
+	if a := ch.Agent; a != nil {
+		existing, err := store.GetAgent(a.ID)
+		if err != nil {
+			return err
+		}
+		if existing == nil {
+			err = store.CreateAgent(&models.Agent{
+				ID: a.ID, Name: a.Name, TokenHash: a.TokenHash, Labels: a.Labels,
+				Status: models.AgentOffline, RegisteredAt: time.Now(),
+			})
+		} else {
+			err = store.SetAgentLabels(a.ID, a.Labels)
+		}
+		if err != nil {
+			return err
+		}
+		return store.SetAgentMaintenance(a.ID, a.Maintenance)
+	}
+
+	repo := *ch.Repo
+	existing, err := store.GetRepository(repo.Name)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		return store.CreateRepository(&repo)
+	}
+	return store.UpdateRepository(&repo)
+}
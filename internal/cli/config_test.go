@@ -0,0 +1,82 @@
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+
+package cli
+
+import (
+	"testing"
+
+	"github.com/urustack/uruflow/internal/config"
+	"github.com/urustack/uruflow/internal/models"
+	"github.com/urustack/uruflow/internal/storage/sqlite"
+)
+
+func TestStoreImportChange(t *testing.T) {
+	store, err := sqlite.New(t.TempDir())
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	defer store.Close()
+
+	cfg := config.Default()
+	doc := &config.Export{
+		Agents: []config.AgentConfig{{ID: "agent-1", Name: "web", Labels: map[string]string{"env": "prod"}}},
+		Repositories: []models.Repository{{
+			Name: "api", URL: "https://github.com/acme/api.git", Branch: "main", AgentID: "agent-1", Path: "/srv/api",
+		}},
+	}
+	changes, err := cfg.PlanImport(doc, config.ConflictFail)
+	if err != nil {
+		t.Fatalf("plan import: %v", err)
+	}
+	cfg.ApplyImport(changes)
+	for _, ch := range changes {
+		if err := storeImportChange(store, ch); err != nil {
+			t.Fatalf("store %s %s: %v", ch.Kind, ch.Name, err)
+		}
+	}
+
+	agent, err := store.GetAgent("agent-1")
+	if err != nil || agent == nil {
+		t.Fatalf("agent row = %+v, %v", agent, err)
+	}
+	if agent.TokenHash != cfg.GetAgent("agent-1").TokenHash || agent.Labels["env"] != "prod" {
+		t.Errorf("agent row = %+v, want the imported hash and labels", agent)
+	}
+	if repo, err := store.GetRepository("api"); err != nil || repo == nil || repo.Path != "/srv/api" {
+		t.Fatalf("repository row = %+v, %v", repo, err)
+	}
+
+	doc.Agents[0].Labels = map[string]string{"env": "staging"}
+	doc.Repositories[0].Path = "/srv/api-v2"
+	changes, err = cfg.PlanImport(doc, config.ConflictOverwrite)
+	if err != nil {
+		t.Fatalf("plan overwrite: %v", err)
+	}
+	for _, ch := range changes {
+		if err := storeImportChange(store, ch); err != nil {
+			t.Fatalf("store %s %s: %v", ch.Kind, ch.Name, err)
+		}
+	}
+	if agent, _ := store.GetAgent("agent-1"); agent.Labels["env"] != "staging" {
+		t.Errorf("agent labels = %v, want the overwritten labels", agent.Labels)
+	}
+	if repo, _ := store.GetRepository("api"); repo.Path != "/srv/api-v2" {
+		t.Errorf("repository path = %s, want the overwritten path", repo.Path)
+	}
+}
@@ -0,0 +1,57 @@
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+
+package cli
+
+import (
+	"errors"
+	"os"
+	"testing"
+)
+
+func TestServerLock(t *testing.T) {
+	dir := t.TempDir()
+	if _, running := serverOwner(dir); running {
+		t.Fatal("server reported running before the lock was taken")
+	}
+
+	lock, err := acquireServerLock(dir)
+	if err != nil {
+		t.Fatalf("acquireServerLock: %v", err)
+	}
+	pid, running := serverOwner(dir)
+	if !running || pid != os.Getpid() {
+		t.Fatalf("serverOwner = %d, %v; want %d, true", pid, running, os.Getpid())
+	}
+
+	_, err = acquireServerLock(dir)
+	var runningErr *serverRunningError
+	if !errors.As(err, &runningErr) || runningErr.pid != os.Getpid() {
+		t.Fatalf("second acquireServerLock = %v, want a server running as pid %d", err, os.Getpid())
+	}
+
+	lock.Release()
+	if _, running := serverOwner(dir); running {
+		t.Fatal("server reported running after the lock was released")
+	}
+	lock, err = acquireServerLock(dir)
+	if err != nil {
+		t.Fatalf("acquireServerLock after release: %v", err)
+	}
+	lock.Release()
+}
@@ -0,0 +1,105 @@
+//go:build windows
+
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"golang.org/x/sys/windows"
+)
+
+type serverLock struct {
+	f       *os.File
+	dataDir string
+}
+
+func lockPath(dataDir string) string {
+	return filepath.Join(dataDir, "server.lock")
+}
+
+func pidPath(dataDir string) string {
+	return filepath.Join(dataDir, "server.pid")
+}
+
+func lockFile(f *os.File, flags uint32) error {
+	var ol windows.Overlapped
+	return windows.LockFileEx(windows.Handle(f.Fd()), flags|windows.LOCKFILE_FAIL_IMMEDIATELY, 0, 1, 0, &ol)
+}
+
+func unlockFile(f *os.File) {
+	var ol windows.Overlapped
+	windows.UnlockFileEx(windows.Handle(f.Fd()), 0, 1, 0, &ol)
+}
+
+func acquireServerLock(dataDir string) (*serverLock, error) {
+	if err := os.MkdirAll(dataDir, 0755); err != nil {
+		return nil, fmt.Errorf("create data dir: %w", err)
+	}
+	f, err := os.OpenFile(lockPath(dataDir), os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("open lock file: %w", err)
+	}
+	if err := lockFile(f, windows.LOCKFILE_EXCLUSIVE_LOCK); err != nil {
+		defer f.Close()
+		if errors.Is(err, windows.ERROR_LOCK_VIOLATION) {
+			return nil, &serverRunningError{pid: readPidFile(dataDir)}
+		}
+		return nil, fmt.Errorf("lock %s: %w", f.Name(), err)
+	}
+	os.WriteFile(pidPath(dataDir), []byte(strconv.Itoa(os.Getpid())+"\n"), 0644)
+	return &serverLock{f: f, dataDir: dataDir}, nil
+}
+
+func (l *serverLock) Release() {
+	if l == nil || l.f == nil {
+		return
+	}
+	os.Remove(pidPath(l.dataDir))
+	unlockFile(l.f)
+	l.f.Close()
+}
+
+func serverOwner(dataDir string) (int, bool) {
+	f, err := os.Open(lockPath(dataDir))
+	if err != nil {
+		return 0, false
+	}
+	defer f.Close()
+	if err := lockFile(f, 0); err == nil {
+		unlockFile(f)
+		return 0, false
+	}
+	return readPidFile(dataDir), true
+}
+
+func readPidFile(dataDir string) int {
+	data, err := os.ReadFile(pidPath(dataDir))
+	if err != nil {
+		return 0
+	}
+	pid, _ := strconv.Atoi(strings.TrimSpace(string(data)))
+	return pid
+}
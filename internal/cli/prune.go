@@ -0,0 +1,69 @@
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/urustack/uruflow/internal/services"
+	"github.com/urustack/uruflow/internal/storage/sqlite"
+)
+
+var pruneVacuum bool
+
+var pruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Remove old deployments and logs according to the retention settings",
+	Run:   runPrune,
+}
+
+func init() {
+	pruneCmd.Flags().BoolVar(&pruneVacuum, "vacuum", true, "compact the database after pruning")
+	rootCmd.AddCommand(pruneCmd)
+}
+
+func runPrune(cmd *cobra.Command, args []string) {
+	if cfg == nil {
+		fmt.Printf("Error: no config found at %s\n", cfgPath)
+		os.Exit(1)
+	}
+
+	store, err := sqlite.New(cfg.Server.DataDir)
+	if err != nil {
+		fmt.Printf("Error initializing database: %v\n", err)
+		os.Exit(1)
+	}
+	defer store.Close()
+
+	result, err := services.NewRetentionService(cfg, store).Prune(pruneVacuum)
+	if err != nil {
+		fmt.Printf("Error pruning: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Removed %d log line(s) older than %d day(s)\n", result.Logs, cfg.Server.KeepLogsDays)
+	fmt.Printf("Removed %d deployment(s) beyond the newest %d\n", result.Deployments, cfg.Server.KeepDeployments)
+	fmt.Printf("Removed %d metrics sample(s) older than %d hour(s)\n", result.Metrics, cfg.Server.KeepMetricsHours)
+	fmt.Printf("Removed %d webhook event(s) beyond the newest %d\n", result.Webhooks, cfg.Server.KeepDeployments)
+	fmt.Printf("Removed %d agent event(s) beyond the newest %d per agent\n", result.AgentEvents, cfg.Server.KeepDeployments)
+	if result.Vacuumed {
+		fmt.Println("Database compacted")
+	}
+}
@@ -0,0 +1,380 @@
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+
+package cli
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/charmbracelet/x/term"
+	"github.com/spf13/cobra"
+	"github.com/urustack/uruflow/internal/models"
+	"github.com/urustack/uruflow/internal/services/scm"
+	"github.com/urustack/uruflow/internal/storage/sqlite"
+)
+
+var (
+	importProvider    string
+	importOwner       string
+	importAPIURL      string
+	importTokenEnv    string
+	importAgent       string
+	importSelector    string
+	importBuildSystem string
+	importBuildFile   string
+	importCredential  string
+	importSSH         bool
+	importArchived    bool
+	importAll         bool
+	importSelect      []string
+	importManual      bool
+	importReposDryRun bool
+)
+
+var reposCmd = &cobra.Command{
+	Use:   "repos",
+	Short: "Manage repositories",
+}
+
+var reposImportCmd = &cobra.Command{
+	Use:   "import",
+	Short: "Add repositories from a GitHub organization or GitLab group",
+	Args:  cobra.NoArgs,
+	Run:   runReposImport,
+}
+
+func init() {
+	f := reposImportCmd.Flags()
+	f.StringVar(&importProvider, "provider", models.ProviderGitHub, "github or gitlab")
+	f.StringVar(&importOwner, "org", "", "GitHub organization or user, or GitLab group path")
+	f.StringVar(&importAPIURL, "api-url", "", "API base URL for GitHub Enterprise or self-hosted GitLab")
+	f.StringVar(&importTokenEnv, "token-env", "", "environment variable holding the API token (default GITHUB_TOKEN or GITLAB_TOKEN)")
+	f.StringVar(&importAgent, "agent", "", "target agent name or ID")
+	f.StringVar(&importSelector, "agent-selector", "", "target agents by label, key=value[,key=value]")
+	f.StringVar(&importBuildSystem, "build-system", "compose", "build system for every imported repository")
+	f.StringVar(&importBuildFile, "build-file", "", "build file for every imported repository")
+	f.StringVar(&importCredential, "credential", "", "agent credential used to clone the repositories")
+	f.BoolVar(&importSSH, "ssh", false, "use SSH clone URLs instead of HTTPS")
+	f.BoolVar(&importArchived, "include-archived", false, "list archived repositories too")
+	f.BoolVar(&importAll, "all", false, "import every listed repository without prompting")
+	f.StringSliceVar(&importSelect, "select", nil, "import these repository names without prompting")
+	f.BoolVar(&importManual, "no-auto-deploy", false, "do not deploy the imported repositories on push")
+	f.BoolVar(&importReposDryRun, "dry-run", false, "print what would be imported without writing anything")
+	reposImportCmd.MarkFlagRequired("org")
+	reposCmd.AddCommand(reposImportCmd)
+	rootCmd.AddCommand(reposCmd)
+}
+
+func runReposImport(cmd *cobra.Command, args []string) {
+	if cfg == nil {
+		fmt.Printf("Error: no config found at %s\n", cfgPath)
+		os.Exit(1)
+	}
+
+	template, err := importTemplate()
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	token, err := importToken()
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	client, err := scm.NewClient(importProvider, importAPIURL, token)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Minute)
+	defer cancel()
+
+	fmt.Printf("Fetching repositories of %s from %s...\n", importOwner, importProvider)
+	listed, err := client.ListRepositories(ctx, importOwner)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	var candidates []scm.Repository
+	archived := 0
+	for _, r := range listed {
+		if r.Archived && !importArchived {
+			archived++
+			continue
+		}
+		candidates = append(candidates, r)
+	}
+	if len(candidates) == 0 {
+		fmt.Printf("No repositories found (%d archived skipped)\n", archived)
+		return
+	}
+
+	for i, r := range candidates {
+		note := ""
+		if cfg.GetRepository(r.Name) != nil {
+			note = "  (exists)"
+		}
+		visibility := "public"
+		if r.Private {
+			visibility = "private"
+		}
+		fmt.Printf("  %3d  %-30s %-15s %-8s%s\n", i+1, r.FullName, r.DefaultBranch, visibility, note)
+	}
+	if archived > 0 {
+		fmt.Printf("  (%d archived repositories hidden, use --include-archived)\n", archived)
+	}
+
+	selected, err := importSelection(candidates)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	var added []models.Repository
+	var skipped []string
+	seen := make(map[string]bool)
+	for _, r := range selected {
+		if cfg.GetRepository(r.Name) != nil || seen[r.Name] {
+			skipped = append(skipped, r.FullName)
+			continue
+		}
+		seen[r.Name] = true
+		added = append(added, importedRepository(template, r))
+	}
+
+	for _, r := range added {
+		fmt.Printf("  %-10s %s (%s)\n", "create", r.Name, r.URL)
+	}
+	for _, name := range skipped {
+		fmt.Printf("  %-10s %s (name already in use)\n", "skip", name)
+	}
+	if importReposDryRun {
+		fmt.Printf("Dry run: %d repository(ies) would be added, %d skipped, nothing written\n", len(added), len(skipped))
+		return
+	}
+	if len(added) == 0 {
+		fmt.Println("Nothing to import")
+		return
+	}
+
+	for _, r := range added {
+		if err := cfg.AddRepository(r); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	if err := cfg.Save(cfgPath); err != nil {
+		fmt.Printf("Error saving config: %v\n", err)
+		os.Exit(1)
+	}
+
+	store, err := sqlite.New(cfg.Server.DataDir)
+	if err != nil {
+		fmt.Printf("Error initializing database: %v\n", err)
+		os.Exit(1)
+	}
+	defer store.Close()
+	for i := range added {
+		if err := store.CreateRepository(&added[i]); err != nil {
+			fmt.Printf("Warning: repository %s saved to config but not to the database: %v\n", added[i].Name, err)
+		}
+	}
+
+	fmt.Printf("Imported %d repository(ies) into %s, skipped %d\n", len(added), cfgPath, len(skipped))
+	if importCredential == "" && !importSSH {
+		for _, r := range added {
+			if strings.HasPrefix(r.URL, "https://") && isPrivate(candidates, r.Name) {
+				fmt.Println("Note: private repositories were imported without --credential, agents may fail to clone them")
+				break
+			}
+		}
+	}
+}
+
+func importTemplate() (models.Repository, error) {
+	template := models.Repository{
+		AutoDeploy:  !importManual,
+		BuildSystem: models.BuildSystem(importBuildSystem),
+		BuildFile:   importBuildFile,
+		Credential:  importCredential,
+	}
+	switch importBuildSystem {
+	case "compose", "dockerfile", "makefile":
+	default:
+		return template, fmt.Errorf("--build-system must be compose, dockerfile or makefile")
+	}
+
+	if (importAgent == "") == (importSelector == "") {
+		return template, fmt.Errorf("set exactly one of --agent or --agent-selector")
+	}
+	if importSelector != "" {
+		selector, err := models.ParseLabels(importSelector)
+		if err != nil || len(selector) == 0 {
+			return template, fmt.Errorf("invalid --agent-selector, expected key=value[,key=value]")
+		}
+		template.AgentSelector = models.FormatLabels(selector)
+		return template, nil
+	}
+	agent := cfg.GetAgentByName(importAgent)
+	if agent == nil {
+		agent = cfg.GetAgent(importAgent)
+	}
+	if agent == nil {
+		return template, fmt.Errorf("agent %s not found", importAgent)
+	}
+	template.AgentID = agent.ID
+	return template, nil
+}
+
+func importToken() (string, error) {
+	env := importTokenEnv
+	if env == "" {
+		env = "GITHUB_TOKEN"
+		if importProvider == models.ProviderGitLab {
+			env = "GITLAB_TOKEN"
+		}
+	}
+	if token := strings.TrimSpace(os.Getenv(env)); token != "" {
+		return token, nil
+	}
+	if !term.IsTerminal(os.Stdin.Fd()) {
+		return "", fmt.Errorf("environment variable %s is empty", env)
+	}
+
+	fmt.Printf("%s API token: ", importProvider)
+	token, err := term.ReadPassword(os.Stdin.Fd())
+	fmt.Println()
+	if err != nil {
+		return "", fmt.Errorf("read token: %w", err)
+	}
+	return strings.TrimSpace(string(token)), nil
+}
+
+func importSelection(candidates []scm.Repository) ([]scm.Repository, error) {
+	if importAll {
+		return candidates, nil
+	}
+	if len(importSelect) > 0 {
+		byName := make(map[string]scm.Repository, len(candidates))
+		for _, r := range candidates {
+			byName[r.Name] = r
+			byName[r.FullName] = r
+		}
+		var selected []scm.Repository
+		for _, name := range importSelect {
+			r, ok := byName[strings.TrimSpace(name)]
+			if !ok {
+				return nil, fmt.Errorf("repository %s is not in the list", name)
+			}
+			selected = append(selected, r)
+		}
+		return selected, nil
+	}
+	if !term.IsTerminal(os.Stdin.Fd()) {
+		return nil, fmt.Errorf("stdin is not a terminal, pass --all or --select")
+	}
+
+	fmt.Print("Select repositories (e.g. 1,3,5-8 or all): ")
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return nil, fmt.Errorf("read selection: %w", err)
+	}
+	indexes, err := parseSelection(strings.TrimSpace(line), len(candidates))
+	if err != nil {
+		return nil, err
+	}
+	selected := make([]scm.Repository, 0, len(indexes))
+	for _, i := range indexes {
+		selected = append(selected, candidates[i])
+	}
+	return selected, nil
+}
+
+func parseSelection(input string, count int) ([]int, error) {
+	if input == "" {
+		return nil, nil
+	}
+	if strings.EqualFold(input, "all") {
+		indexes := make([]int, count)
+		for i := range indexes {
+			indexes[i] = i
+		}
+		return indexes, nil
+	}
+
+	picked := make(map[int]bool)
+	var indexes []int
+	for _, part := range strings.Split(input, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		lo, hi, isRange := strings.Cut(part, "-")
+		first, err := strconv.Atoi(strings.TrimSpace(lo))
+		last := first
+		if err == nil && isRange {
+			last, err = strconv.Atoi(strings.TrimSpace(hi))
+		}
+		if err != nil || first < 1 || last > count || first > last {
+			return nil, fmt.Errorf("invalid selection %q, use numbers between 1 and %d", part, count)
+		}
+		for n := first; n <= last; n++ {
+			if !picked[n-1] {
+				picked[n-1] = true
+				indexes = append(indexes, n-1)
+			}
+		}
+	}
+	return indexes, nil
+}
+
+func importedRepository(template models.Repository, r scm.Repository) models.Repository {
+	repo := template
+	repo.Name = r.Name
+	repo.URL = r.CloneURL
+	if importSSH && r.SSHURL != "" {
+		repo.URL = r.SSHURL
+	}
+	repo.Branch = r.DefaultBranch
+	if repo.Branch == "" {
+		repo.Branch = "main"
+	}
+	return repo
+}
+
+func isPrivate(candidates []scm.Repository, name string) bool {
+	for _, r := range candidates {
+		if r.Name == name {
+			return r.Private
+		}
+	}
+	return false
+}
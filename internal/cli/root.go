@@ -30,14 +30,15 @@ import (
 	"github.com/spf13/cobra"
 	"github.com/urustack/uruflow/internal/api"
 	"github.com/urustack/uruflow/internal/config"
-	"github.com/urustack/uruflow/internal/storage/sqlite"
+	"github.com/urustack/uruflow/internal/storage"
 	"github.com/urustack/uruflow/internal/tui"
 	"github.com/urustack/uruflow/pkg/helper"
 )
 
 var (
-	cfgPath string
-	cfg     *config.Config
+	cfgPath  string
+	cfg      *config.Config
+	headless bool
 )
 
 var rootCmd = &cobra.Command{
@@ -54,6 +55,7 @@ func Execute() error {
 func init() {
 	cobra.OnInitialize(initConfig)
 	rootCmd.PersistentFlags().StringVar(&cfgPath, "config", "", "config file path")
+	rootCmd.Flags().BoolVar(&headless, "headless", false, "run the servers without the TUI (same as serve)")
 }
 
 func initConfig() {
@@ -70,12 +72,23 @@ func initConfig() {
 		if err != nil {
 			logger.Warn("failed to load config from %s: %v", cfgPath, err)
 		} else {
+			configureLogging()
 			logger.Info("config loaded from %s", cfgPath)
 		}
 	}
 }
 
+func configureLogging() {
+	if err := logger.Configure(cfg.Log.Options()); err != nil {
+		logger.Warn("failed to apply log settings: %v", err)
+	}
+}
+
 func runApplication(cmd *cobra.Command, args []string) {
+	if headless {
+		runHeadless(cmd, args)
+		return
+	}
 	if cfg == nil {
 		logger.Info("No config found, running initialization")
 		if err := tui.RunInit(); err != nil {
@@ -91,19 +104,18 @@ func runApplication(cmd *cobra.Command, args []string) {
 			fmt.Printf("Error loading config after init: %v\n", err)
 			os.Exit(1)
 		}
+		configureLogging()
 	}
 
-	logger.Info("Initializing database at %s", cfg.Server.DataDir)
-	store, err := sqlite.New(cfg.Server.DataDir)
-	if err != nil {
-		logger.Error("Database initialization failed: %v", err)
-		fmt.Printf("Error initializing database: %v\n", err)
-		os.Exit(1)
-	}
+	lock := lockServer()
+	defer lock.Release()
+
+	store := openStore()
 	defer store.Close()
+	backfillBuildSettings(store)
 
 	logger.Info("Starting API server")
-	server := api.NewServer(cfg, store)
+	server := api.NewServer(cfg, cfgPath, store)
 
 	_, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -145,3 +157,16 @@ func runApplication(cmd *cobra.Command, args []string) {
 	defer shutdownCancel()
 	server.Shutdown(shutdownCtx)
 }
+
+func backfillBuildSettings(store storage.Store) {
+	for _, r := range cfg.ListRepositories() {
+		stored, err := store.GetRepository(r.Name)
+		if err != nil || stored == nil || stored.BuildSystem != "" || r.BuildSystem == "" {
+			continue
+		}
+		stored.BuildSystem, stored.BuildFile, stored.BuildCmd = r.BuildSystem, r.BuildFile, r.BuildCmd
+		if err := store.UpdateRepository(stored); err != nil {
+			logger.Warn("Failed to store build settings of %s: %v", r.Name, err)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/urustack/uruflow/internal/api"
+	"github.com/urustack/uruflow/internal/services"
+	"github.com/urustack/uruflow/internal/storage"
+	"github.com/urustack/uruflow/internal/storage/sqlite"
+	"github.com/urustack/uruflow/internal/tui"
+	"github.com/urustack/uruflow/pkg/logger"
+)
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Run the webhook and agent servers without the TUI",
+	Run:   runHeadless,
+}
+
+var tuiCmd = &cobra.Command{
+	Use:   "tui",
+	Short: "Open the TUI against a server started with serve",
+	Run:   runAttached,
+}
+
+func init() {
+	rootCmd.AddCommand(serveCmd, tuiCmd)
+}
+
+func runHeadless(cmd *cobra.Command, args []string) {
+	if cfg == nil {
+		fmt.Printf("Error: no config found at %s, run uruflow once to create it\n", cfgPath)
+		os.Exit(1)
+	}
+
+	opts := cfg.Log.Options()
+	opts.Stdout = true
+	if err := logger.Configure(opts); err != nil {
+		logger.Warn("failed to apply log settings: %v", err)
+	}
+
+	lock := lockServer()
+	defer lock.Release()
+
+	store := openStore()
+	defer store.Close()
+	backfillBuildSettings(store)
+
+	logger.Info("Starting API server (headless, pid %d)", os.Getpid())
+	server := api.NewServer(cfg, cfgPath, store)
+	if err := server.Start(); err != nil {
+		logger.Error("Server error: %v", err)
+		server.Shutdown(context.Background())
+		store.Close()
+		lock.Release()
+		os.Exit(1)
+	}
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	sig := <-sigChan
+	logger.Info("Received %s, shutting down", sig)
+
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer shutdownCancel()
+	server.Shutdown(shutdownCtx)
+}
+
+func runAttached(cmd *cobra.Command, args []string) {
+	if cfg == nil {
+		fmt.Printf("Error: no config found at %s, run uruflow once to create it\n", cfgPath)
+		os.Exit(1)
+	}
+
+	pid, running := serverOwner(cfg.Server.DataDir)
+	if !running {
+		fmt.Println("Error: no server is running, start one with uruflow serve or run uruflow to start it with the TUI")
+		os.Exit(1)
+	}
+	cfg.SetOwner(pid)
+
+	opts := cfg.Log.Options()
+	opts.MaxSizeMB = 0
+	if err := logger.Configure(opts); err != nil {
+		logger.Warn("failed to apply log settings: %v", err)
+	}
+
+	store := openStore()
+	defer store.Close()
+
+	server := api.NewServer(cfg, cfgPath, store)
+	if client, err := services.NewServerClient(cfg); err != nil {
+		logger.Warn("Deploys from the attached TUI are disabled: %v", err)
+	} else {
+		server.GetDeployService().Attach(client)
+	}
+
+	logger.Info("Starting TUI attached to server pid %d", pid)
+	if err := tui.Run(store, cfg, server); err != nil {
+		logger.Error("TUI error: %v", err)
+		fmt.Printf("TUI Error: %v\n", err)
+		store.Close()
+		os.Exit(1)
+	}
+}
+
+func lockServer() *serverLock {
+	lock, err := acquireServerLock(cfg.Server.DataDir)
+	if err != nil {
+		var running *serverRunningError
+		if errors.As(err, &running) {
+			logger.Error("Refusing to start: %v", err)
+			fmt.Printf("Error: %v, use uruflow tui to attach to it\n", err)
+		} else {
+			fmt.Printf("Error: %v\n", err)
+		}
+		os.Exit(1)
+	}
+	return lock
+}
+
+func openStore() storage.Store {
+	logger.Info("Initializing database at %s", cfg.Server.DataDir)
+	store, err := sqlite.New(cfg.Server.DataDir)
+	if err != nil {
+		logger.Error("Database initialization failed: %v", err)
+		fmt.Printf("Error initializing database: %v\n", err)
+		os.Exit(1)
+	}
+	return store
+}
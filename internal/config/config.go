@@ -19,53 +19,147 @@
 package config
 
 import (
+	"crypto/subtle"
+	"errors"
 	"fmt"
+	"net"
 	"os"
+	"os/user"
 	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/urustack/uruflow/internal/models"
 	"github.com/urustack/uruflow/pkg/helper"
+	"github.com/urustack/uruflow/pkg/logger"
 	"gopkg.in/yaml.v3"
 )
 
 type Config struct {
-	Server       ServerConfig        `yaml:"server"`
-	Webhook      WebhookConfig       `yaml:"webhook"`
-	TLS          TLSConfig           `yaml:"tls"`
-	Agents       []AgentConfig       `yaml:"agents"`
-	Repositories []models.Repository `yaml:"repositories"`
+	Server        ServerConfig        `yaml:"server"`
+	Webhook       WebhookConfig       `yaml:"webhook"`
+	TLS           TLSConfig           `yaml:"tls"`
+	Notifications NotificationsConfig `yaml:"notifications,omitempty"`
+	Log           LogConfig           `yaml:"log"`
+	Agents        []AgentConfig       `yaml:"agents"`
+	Repositories  []models.Repository `yaml:"repositories"`
+	Exec          map[string][]string `yaml:"exec,omitempty"`
+
+	mu    sync.RWMutex
+	owner int
+}
+
+type Snapshot struct {
+	agents       []AgentConfig
+	repositories []models.Repository
 }
 
 type ServerConfig struct {
-	HTTPPort int    `yaml:"http_port"`
-	TCPPort  int    `yaml:"tcp_port"`
-	Host     string `yaml:"host"`
-	DataDir  string `yaml:"data_dir"`
+	HTTPPort         int      `yaml:"http_port"`
+	TCPPort          int      `yaml:"tcp_port"`
+	Host             string   `yaml:"host"`
+	DataDir          string   `yaml:"data_dir"`
+	TCPListen        []string `yaml:"tcp_listen,omitempty"`
+	HTTPListen       []string `yaml:"http_listen,omitempty"`
+	APIToken         string   `yaml:"api_token,omitempty"`
+	DeployTimeoutMin int      `yaml:"deploy_timeout_min"`
+	ApprovalHours    int      `yaml:"approval_hours"`
+	AckTimeoutSec    int      `yaml:"ack_timeout_sec"`
+	KeepDeployments  int      `yaml:"keep_deployments"`
+	KeepLogsDays     int      `yaml:"keep_logs_days"`
+	KeepMetricsHours int      `yaml:"keep_metrics_hours"`
+	LogStreamIdleSec int      `yaml:"log_stream_idle_sec"`
+	OfflineGraceSec  int      `yaml:"offline_grace_sec"`
+	TokenGraceHours  *int     `yaml:"token_grace_hours,omitempty"`
+	StatusPage       bool     `yaml:"status_page,omitempty"`
+	StatusUser       string   `yaml:"status_user,omitempty"`
+	StatusPassword   string   `yaml:"status_password,omitempty"`
+	Operator         string   `yaml:"operator,omitempty"`
+	GitProxy         string   `yaml:"git_proxy,omitempty"`
+	PublicURL        string   `yaml:"public_url,omitempty"`
+	Prefetch         bool     `yaml:"prefetch,omitempty"`
 }
 
 type WebhookConfig struct {
 	Path   string `yaml:"path"`
 	Secret string `yaml:"secret"`
+
+	RateLimit       int `yaml:"rate_limit"`
+	RateBurst       int `yaml:"rate_burst"`
+	GlobalRateLimit int `yaml:"global_rate_limit"`
+	ReplayWindowMin int `yaml:"replay_window_min"`
+	CooldownSec     int `yaml:"cooldown_sec,omitempty"`
 }
 
 type TLSConfig struct {
-	Enabled  bool   `yaml:"enabled"`
-	CertFile string `yaml:"cert_file"`
-	KeyFile  string `yaml:"key_file"`
-	AutoCert bool   `yaml:"auto_cert"`
+	Enabled           bool     `yaml:"enabled"`
+	CertFile          string   `yaml:"cert_file"`
+	KeyFile           string   `yaml:"key_file"`
+	AutoCert          bool     `yaml:"auto_cert"`
+	AutoCertDays      int      `yaml:"auto_cert_days,omitempty"`
+	AutoCertHosts     []string `yaml:"auto_cert_hosts,omitempty"`
+	ClientCAFile      string   `yaml:"client_ca_file,omitempty"`
+	RequireClientCert bool     `yaml:"require_client_cert,omitempty"`
+	VerifyClientCN    bool     `yaml:"verify_client_cn,omitempty"`
+}
+
+type NotificationsConfig struct {
+	Sinks          []NotifySink `yaml:"sinks,omitempty"`
+	DeployFailures bool         `yaml:"deploy_failures,omitempty"`
+}
+
+type NotifySink struct {
+	Type        string `yaml:"type"`
+	URL         string `yaml:"url"`
+	MinSeverity string `yaml:"min_severity,omitempty"`
+}
+
+type LogConfig struct {
+	File       string `yaml:"file"`
+	Level      string `yaml:"level"`
+	Format     string `yaml:"format"`
+	MaxSizeMB  int    `yaml:"max_size_mb"`
+	MaxBackups int    `yaml:"max_backups"`
 }
 
 type AgentConfig struct {
-	ID    string `yaml:"id"`
-	Name  string `yaml:"name"`
-	Token string `yaml:"token"`
+	ID        string `yaml:"id"`
+	Name      string `yaml:"name"`
+	Token     string `yaml:"token,omitempty"`
+	TokenHash string `yaml:"token_hash,omitempty"`
+
+	PreviousTokenHash    string    `yaml:"previous_token_hash,omitempty"`
+	PreviousTokenExpires time.Time `yaml:"previous_token_expires,omitempty"`
+
+	Labels map[string]string `yaml:"labels,omitempty"`
+
+	Maintenance        bool     `yaml:"maintenance,omitempty"`
+	MaintenanceWindows []string `yaml:"maintenance_windows,omitempty"`
+}
+
+func (a *AgentConfig) InMaintenance(now time.Time) (string, bool) {
+	if a.Maintenance {
+		return "maintenance mode is on", true
+	}
+	if window, ok := models.InMaintenanceWindow(a.MaintenanceWindows, now); ok {
+		return "inside maintenance window " + window, true
+	}
+	return "", false
 }
 
 var (
 	DefaultConfigPath = "/etc/uruflow/config.yaml"
 	DefaultDataDir    = "/var/lib/uruflow"
+	DefaultLogFile    = "/var/log/uruflow-server.log"
 )
 
+var ErrConfigOwned = errors.New("config is owned by a running server")
+
+var saveMu sync.Mutex
+
 func Load(path string) (*Config, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
@@ -78,9 +172,103 @@ func Load(path string) (*Config, error) {
 	}
 
 	cfg.setDefaults()
+	if err := cfg.Log.Validate(); err != nil {
+		return nil, err
+	}
+	if (cfg.Server.StatusUser == "") != (cfg.Server.StatusPassword == "") {
+		return nil, fmt.Errorf("server.status_user and server.status_password must be set together")
+	}
+	if cfg.Server.StatusPage && cfg.Server.StatusPassword == "" && cfg.Server.APIToken == "" {
+		return nil, fmt.Errorf("server.status_page needs server.status_password or server.api_token")
+	}
+	if cfg.TLS.AutoCertDays < 0 {
+		return nil, fmt.Errorf("tls.auto_cert_days must not be negative")
+	}
+	if cfg.Server.OfflineGraceSec < 0 {
+		return nil, fmt.Errorf("server.offline_grace_sec must not be negative")
+	}
+	if cfg.TLS.VerifyClientCN && cfg.TLS.ClientCAFile == "" {
+		return nil, fmt.Errorf("tls.verify_client_cn needs tls.client_ca_file")
+	}
+	for name, argv := range cfg.Exec {
+		if err := models.ValidateExec(name, argv); err != nil {
+			return nil, err
+		}
+	}
+	for _, a := range cfg.Agents {
+		for _, w := range a.MaintenanceWindows {
+			if _, err := models.ParseMaintenanceWindow(w); err != nil {
+				return nil, fmt.Errorf("agent %s: maintenance_windows: %w", a.Name, err)
+			}
+		}
+	}
+	for _, r := range cfg.Repositories {
+		if r.CommitStatus != nil {
+			if err := r.CommitStatus.Validate(); err != nil {
+				return nil, fmt.Errorf("repository %s: commit_status: %w", r.Name, err)
+			}
+		}
+		switch r.Strategy {
+		case "", models.StrategyInPlace, models.StrategyReleases:
+		default:
+			return nil, fmt.Errorf("repository %s: strategy must be %q or %q", r.Name, models.StrategyInPlace, models.StrategyReleases)
+		}
+		if r.BuildSystem == "image" {
+			if err := r.ValidateImage(); err != nil {
+				return nil, fmt.Errorf("repository %s: %w", r.Name, err)
+			}
+			if r.Strategy == models.StrategyReleases {
+				return nil, fmt.Errorf("repository %s: strategy %q needs a git checkout and does not apply to build_system image", r.Name, models.StrategyReleases)
+			}
+		}
+		if r.KeepReleases < 0 {
+			return nil, fmt.Errorf("repository %s: keep_releases must not be negative", r.Name)
+		}
+		for _, filter := range r.PathFilters {
+			if err := models.ValidatePathFilter(filter); err != nil {
+				return nil, fmt.Errorf("repository %s: path_filters: %w", r.Name, err)
+			}
+		}
+		if r.Schedule != "" {
+			if _, err := models.ParseSchedule(r.Schedule); err != nil {
+				return nil, fmt.Errorf("repository %s: schedule: %w", r.Name, err)
+			}
+		}
+		if r.AgentSelector == "" {
+			continue
+		}
+		if _, err := models.ParseLabels(r.AgentSelector); err != nil {
+			return nil, fmt.Errorf("repository %s: agent_selector: %w", r.Name, err)
+		}
+	}
+	if cfg.hashTokens() {
+		if err := cfg.Save(path); err != nil {
+			return nil, fmt.Errorf("migrate agent tokens: %w", err)
+		}
+	}
 	return &cfg, nil
 }
 
+func (c *Config) hashTokens() bool {
+	changed := false
+	for i := range c.Agents {
+		a := &c.Agents[i]
+		if a.Token == "" {
+			continue
+		}
+		if a.TokenHash == "" {
+			if helper.IsTokenHash(a.Token) {
+				a.TokenHash = a.Token
+			} else {
+				a.TokenHash = helper.HashToken(a.Token)
+			}
+		}
+		a.Token = ""
+		changed = true
+	}
+	return changed
+}
+
 func (c *Config) setDefaults() {
 	if c.Server.HTTPPort == 0 {
 		c.Server.HTTPPort = 9000
@@ -97,20 +285,163 @@ func (c *Config) setDefaults() {
 	if c.Webhook.Path == "" {
 		c.Webhook.Path = "/webhook"
 	}
+	if c.Webhook.RateLimit == 0 {
+		c.Webhook.RateLimit = 60
+	}
+	if c.Webhook.RateBurst == 0 {
+		c.Webhook.RateBurst = 20
+	}
+	if c.Webhook.GlobalRateLimit == 0 {
+		c.Webhook.GlobalRateLimit = 600
+	}
+	if c.Webhook.ReplayWindowMin == 0 {
+		c.Webhook.ReplayWindowMin = 60
+	}
+	if c.Server.DeployTimeoutMin == 0 {
+		c.Server.DeployTimeoutMin = 30
+	}
+	if c.Server.ApprovalHours == 0 {
+		c.Server.ApprovalHours = 24
+	}
+	if c.Server.AckTimeoutSec == 0 {
+		c.Server.AckTimeoutSec = 120
+	}
+	if c.TLS.AutoCertDays == 0 {
+		c.TLS.AutoCertDays = 730
+	}
+	if c.Server.TokenGraceHours == nil {
+		grace := 24
+		c.Server.TokenGraceHours = &grace
+	}
+	if c.Server.KeepDeployments == 0 {
+		c.Server.KeepDeployments = 500
+	}
+	if c.Server.KeepLogsDays == 0 {
+		c.Server.KeepLogsDays = 30
+	}
+	if c.Server.KeepMetricsHours == 0 {
+		c.Server.KeepMetricsHours = 24
+	}
+	if c.Server.LogStreamIdleSec == 0 {
+		c.Server.LogStreamIdleSec = 120
+	}
+	if c.Server.OfflineGraceSec == 0 {
+		c.Server.OfflineGraceSec = 60
+	}
+	if c.Log.File == "" {
+		c.Log.File = DefaultLogFile
+	}
+	if c.Log.Level == "" {
+		c.Log.Level = "info"
+	}
+	if c.Log.Format == "" {
+		c.Log.Format = logger.FormatText
+	}
+	if c.Log.MaxSizeMB == 0 {
+		c.Log.MaxSizeMB = 100
+	}
+	if c.Log.MaxBackups == 0 {
+		c.Log.MaxBackups = 5
+	}
+}
+
+func (l LogConfig) Validate() error {
+	if !logger.ValidLevel(l.Level) {
+		return fmt.Errorf("log.level must be debug, info, warn or error")
+	}
+	if !logger.ValidFormat(l.Format) {
+		return fmt.Errorf("log.format must be text or json")
+	}
+	if l.MaxSizeMB < 0 || l.MaxBackups < 0 {
+		return fmt.Errorf("log.max_size_mb and log.max_backups must not be negative")
+	}
+	return nil
+}
+
+func (l LogConfig) Options() logger.Options {
+	return logger.Options{
+		Path:       l.File,
+		Level:      l.Level,
+		Format:     l.Format,
+		MaxSizeMB:  l.MaxSizeMB,
+		MaxBackups: l.MaxBackups,
+	}
+}
+
+func (c *Config) TCPListenAddrs() ([]string, error) {
+	return ListenAddrs(c.Server.TCPListen, c.Server.Host, c.Server.TCPPort)
+}
+
+func (c *Config) HTTPListenAddrs() ([]string, error) {
+	return ListenAddrs(c.Server.HTTPListen, c.Server.Host, c.Server.HTTPPort)
+}
+
+func ListenAddrs(list []string, host string, port int) ([]string, error) {
+	if len(list) == 0 {
+		return []string{net.JoinHostPort(strings.Trim(host, "[]"), strconv.Itoa(port))}, nil
+	}
+
+	addrs := make([]string, 0, len(list))
+	for _, entry := range list {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		h, p, err := net.SplitHostPort(entry)
+		if err != nil {
+			h, p = strings.Trim(entry, "[]"), strconv.Itoa(port)
+		}
+		if n, err := strconv.Atoi(p); err != nil || n < 0 || n > 65535 {
+			return nil, fmt.Errorf("invalid listen address %q: bad port", entry)
+		}
+		addrs = append(addrs, net.JoinHostPort(h, p))
+	}
+
+	if len(addrs) == 0 {
+		return nil, fmt.Errorf("no listen addresses configured")
+	}
+	return addrs, nil
+}
+
+func (c *Config) SetOwner(pid int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.owner = pid
+}
+
+func (c *Config) Owner() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.owner
 }
 
 func (c *Config) Save(path string) error {
+	if owner := c.Owner(); owner != 0 {
+		return fmt.Errorf("%w (pid %d), stop it or make the change through the server", ErrConfigOwned, owner)
+	}
+	saveMu.Lock()
+	defer saveMu.Unlock()
+
 	dir := filepath.Dir(path)
 	if err := os.MkdirAll(dir, 0755); err != nil {
 		return fmt.Errorf("create config dir: %w", err)
 	}
 
+	c.mu.Lock()
+	c.hashTokens()
 	data, err := yaml.Marshal(c)
+	c.mu.Unlock()
 	if err != nil {
 		return fmt.Errorf("marshal config: %w", err)
 	}
 
-	if err := os.WriteFile(path, data, 0600); err != nil {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("write config: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
 		return fmt.Errorf("write config: %w", err)
 	}
 
@@ -118,20 +449,42 @@ func (c *Config) Save(path string) error {
 }
 
 func Default() *Config {
+	grace := 24
 	return &Config{
 		Server: ServerConfig{
-			HTTPPort: 9000,
-			TCPPort:  9001,
-			Host:     "0.0.0.0",
-			DataDir:  DefaultDataDir,
+			HTTPPort:         9000,
+			TCPPort:          9001,
+			Host:             "0.0.0.0",
+			DataDir:          DefaultDataDir,
+			DeployTimeoutMin: 30,
+			ApprovalHours:    24,
+			AckTimeoutSec:    120,
+			KeepDeployments:  500,
+			KeepLogsDays:     30,
+			KeepMetricsHours: 24,
+			LogStreamIdleSec: 120,
+			OfflineGraceSec:  60,
+			TokenGraceHours:  &grace,
+		},
+		Log: LogConfig{
+			File:       DefaultLogFile,
+			Level:      "info",
+			Format:     logger.FormatText,
+			MaxSizeMB:  100,
+			MaxBackups: 5,
 		},
 		Webhook: WebhookConfig{
-			Path:   "/webhook",
-			Secret: helper.GenerateSecret(),
+			Path:            "/webhook",
+			Secret:          helper.GenerateSecret(),
+			RateLimit:       60,
+			RateBurst:       20,
+			GlobalRateLimit: 600,
+			ReplayWindowMin: 60,
 		},
 		TLS: TLSConfig{
-			Enabled:  false,
-			AutoCert: false,
+			Enabled:      false,
+			AutoCert:     false,
+			AutoCertDays: 730,
 		},
 		Agents:       []AgentConfig{},
 		Repositories: []models.Repository{},
@@ -139,6 +492,8 @@ func Default() *Config {
 }
 
 func (c *Config) AddAgent(name string) (string, string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 	for _, a := range c.Agents {
 		if a.Name == name {
 			return "", "", fmt.Errorf("agent %s already exists", name)
@@ -149,15 +504,48 @@ func (c *Config) AddAgent(name string) (string, string, error) {
 	token := helper.GenerateToken()
 
 	c.Agents = append(c.Agents, AgentConfig{
-		ID:    id,
-		Name:  name,
-		Token: token,
+		ID:        id,
+		Name:      name,
+		TokenHash: helper.HashToken(token),
 	})
 
 	return id, token, nil
 }
 
+func (c *Config) Snapshot() Snapshot {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return Snapshot{
+		agents:       append([]AgentConfig(nil), c.Agents...),
+		repositories: append([]models.Repository(nil), c.Repositories...),
+	}
+}
+
+func (c *Config) Restore(snap Snapshot) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.Agents, c.Repositories = snap.agents, snap.repositories
+}
+
+func (c *Config) ListAgents() []AgentConfig {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return append([]AgentConfig(nil), c.Agents...)
+}
+
+func (c *Config) ListRepositories() []models.Repository {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return append([]models.Repository(nil), c.Repositories...)
+}
+
 func (c *Config) GetAgent(id string) *AgentConfig {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return copyAgent(c.agent(id))
+}
+
+func (c *Config) agent(id string) *AgentConfig {
 	for i := range c.Agents {
 		if c.Agents[i].ID == id {
 			return &c.Agents[i]
@@ -166,16 +554,76 @@ func (c *Config) GetAgent(id string) *AgentConfig {
 	return nil
 }
 
+func copyAgent(a *AgentConfig) *AgentConfig {
+	if a == nil {
+		return nil
+	}
+	cp := *a
+	return &cp
+}
+
 func (c *Config) GetAgentByToken(token string) *AgentConfig {
+	if token == "" {
+		return nil
+	}
+	hash := []byte(helper.HashToken(token))
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	var found *AgentConfig
 	for i := range c.Agents {
-		if c.Agents[i].Token == token {
-			return &c.Agents[i]
+		if subtle.ConstantTimeCompare([]byte(c.Agents[i].TokenHash), hash) == 1 {
+			found = &c.Agents[i]
 		}
 	}
-	return nil
+	return copyAgent(found)
+}
+
+func (c *Config) GetAgentByPreviousToken(token string, now time.Time) (*AgentConfig, bool) {
+	if token == "" {
+		return nil, false
+	}
+	hash := []byte(helper.HashToken(token))
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	var found *AgentConfig
+	for i := range c.Agents {
+		if c.Agents[i].PreviousTokenHash == "" {
+			continue
+		}
+		if subtle.ConstantTimeCompare([]byte(c.Agents[i].PreviousTokenHash), hash) == 1 {
+			found = &c.Agents[i]
+		}
+	}
+	if found == nil {
+		return nil, false
+	}
+	return copyAgent(found), now.Before(found.PreviousTokenExpires)
+}
+
+func (c *Config) RotateAgentToken(id string) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	agent := c.agent(id)
+	if agent == nil {
+		return "", fmt.Errorf("agent %s not found", id)
+	}
+
+	token := helper.GenerateToken()
+	agent.PreviousTokenHash = agent.TokenHash
+	agent.PreviousTokenExpires = time.Now().Add(c.TokenGrace())
+	agent.TokenHash = helper.HashToken(token)
+	return token, nil
 }
 
 func (c *Config) GetAgentByName(name string) *AgentConfig {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return copyAgent(c.agentByName(name))
+}
+
+func (c *Config) agentByName(name string) *AgentConfig {
 	for i := range c.Agents {
 		if c.Agents[i].Name == name {
 			return &c.Agents[i]
@@ -184,7 +632,96 @@ func (c *Config) GetAgentByName(name string) *AgentConfig {
 	return nil
 }
 
+func (c *Config) SetAgentLabels(id string, labels map[string]string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	agent := c.agent(id)
+	if agent == nil {
+		return false
+	}
+	if len(labels) == 0 {
+		labels = nil
+	}
+	agent.Labels = labels
+	return true
+}
+
+func (c *Config) SetAgentMaintenance(id string, enabled bool) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	agent := c.agent(id)
+	if agent == nil {
+		return false
+	}
+	agent.Maintenance = enabled
+	return true
+}
+
+func (c *Config) AgentMaintenance(id string, now time.Time) (string, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	agent := c.agent(id)
+	if agent == nil {
+		return "", false
+	}
+	return agent.InMaintenance(now)
+}
+
+func (c *Config) AgentsMatching(selector map[string]string) []AgentConfig {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	var matches []AgentConfig
+	for _, a := range c.Agents {
+		if models.MatchLabels(a.Labels, selector) {
+			matches = append(matches, a)
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool { return matches[i].Name < matches[j].Name })
+	return matches
+}
+
+func (c *Config) RenameAgent(id, name string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	agent := c.agent(id)
+	if agent == nil {
+		return fmt.Errorf("agent %s not found", id)
+	}
+	if other := c.agentByName(name); other != nil && other.ID != id {
+		return fmt.Errorf("agent %s already exists", name)
+	}
+	agent.Name = name
+	return nil
+}
+
+func (c *Config) AgentRepositories(id string) []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	var names []string
+	for _, r := range c.Repositories {
+		if r.AgentID == id {
+			names = append(names, r.Name)
+		}
+	}
+	return names
+}
+
+func (c *Config) ReassignRepositories(from, to string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	n := 0
+	for i := range c.Repositories {
+		if c.Repositories[i].AgentID == from {
+			c.Repositories[i].AgentID = to
+			n++
+		}
+	}
+	return n
+}
+
 func (c *Config) RemoveAgent(id string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 	for i := range c.Agents {
 		if c.Agents[i].ID == id {
 			c.Agents = append(c.Agents[:i], c.Agents[i+1:]...)
@@ -194,7 +731,52 @@ func (c *Config) RemoveAgent(id string) bool {
 	return false
 }
 
+func (c *Config) OperatorName() string {
+	if c.Server.Operator != "" {
+		return c.Server.Operator
+	}
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		return u.Username
+	}
+	if name := os.Getenv("USER"); name != "" {
+		return name
+	}
+	return "unknown"
+}
+
+func (c *Config) ApprovalWindow() time.Duration {
+	if c.Server.ApprovalHours <= 0 {
+		return 0
+	}
+	return time.Duration(c.Server.ApprovalHours) * time.Hour
+}
+
+func (c *Config) TokenGrace() time.Duration {
+	if c.Server.TokenGraceHours == nil || *c.Server.TokenGraceHours <= 0 {
+		return 0
+	}
+	return time.Duration(*c.Server.TokenGraceHours) * time.Hour
+}
+
+func (c *Config) AckTimeout() time.Duration {
+	if c.Server.AckTimeoutSec <= 0 {
+		return 0
+	}
+	return time.Duration(c.Server.AckTimeoutSec) * time.Second
+}
+
+func (c *Config) ExecNames() []string {
+	names := make([]string, 0, len(c.Exec))
+	for name := range c.Exec {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
 func (c *Config) AddRepository(repo models.Repository) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 	for _, r := range c.Repositories {
 		if r.Name == repo.Name {
 			return fmt.Errorf("repository %s already exists", repo.Name)
@@ -205,6 +787,12 @@ func (c *Config) AddRepository(repo models.Repository) error {
 }
 
 func (c *Config) GetRepository(name string) *models.Repository {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return copyRepository(c.repository(name))
+}
+
+func (c *Config) repository(name string) *models.Repository {
 	for i := range c.Repositories {
 		if c.Repositories[i].Name == name {
 			return &c.Repositories[i]
@@ -213,7 +801,102 @@ func (c *Config) GetRepository(name string) *models.Repository {
 	return nil
 }
 
+func copyRepository(r *models.Repository) *models.Repository {
+	if r == nil {
+		return nil
+	}
+	cp := *r
+	return &cp
+}
+
+func (c *Config) GetRepositoryByURL(url, branch string) *models.Repository {
+	return c.repositoryByURL(url, func(r *models.Repository) bool { return r.MatchesBranch(branch) })
+}
+
+func (c *Config) GetRepositoriesByURL(url, branch string) []*models.Repository {
+	target := NormalizeGitURL(url)
+	if target == "" {
+		return nil
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	var matches []*models.Repository
+	for i := range c.Repositories {
+		r := &c.Repositories[i]
+		if NormalizeGitURL(r.URL) == target && r.MatchesBranch(branch) {
+			matches = append(matches, copyRepository(r))
+		}
+	}
+	return matches
+}
+
+func (c *Config) GetRepositoryByTag(url, tag string) *models.Repository {
+	return c.repositoryByURL(url, func(r *models.Repository) bool { return r.MatchesTag(tag) })
+}
+
+func (c *Config) repositoryByURL(url string, preferred func(r *models.Repository) bool) *models.Repository {
+	target := NormalizeGitURL(url)
+	if target == "" {
+		return nil
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	var matches []*models.Repository
+	for i := range c.Repositories {
+		if NormalizeGitURL(c.Repositories[i].URL) == target {
+			matches = append(matches, &c.Repositories[i])
+		}
+	}
+
+	if len(matches) == 0 {
+		return nil
+	}
+	for _, r := range matches {
+		if preferred(r) {
+			return copyRepository(r)
+		}
+	}
+	return copyRepository(matches[0])
+}
+
+func NormalizeGitURL(raw string) string {
+	u := strings.TrimSpace(raw)
+	if u == "" {
+		return ""
+	}
+
+	if i := strings.Index(u, "://"); i >= 0 {
+		u = u[i+3:]
+	} else if colon := strings.Index(u, ":"); colon >= 0 {
+		if slash := strings.Index(u, "/"); slash < 0 || colon < slash {
+			u = u[:colon] + "/" + u[colon+1:]
+		}
+	}
+
+	if at := strings.Index(u, "@"); at >= 0 {
+		if slash := strings.Index(u, "/"); slash < 0 || at < slash {
+			u = u[at+1:]
+		}
+	}
+
+	u = strings.TrimSuffix(u, "/")
+	u = strings.TrimSuffix(u, ".git")
+
+	host, path, found := strings.Cut(u, "/")
+	if colon := strings.Index(host, ":"); colon >= 0 {
+		host = host[:colon]
+	}
+	host = strings.ToLower(host)
+	if !found {
+		return host
+	}
+	return host + "/" + path
+}
+
 func (c *Config) RemoveRepository(name string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 	for i := range c.Repositories {
 		if c.Repositories[i].Name == name {
 			c.Repositories = append(c.Repositories[:i], c.Repositories[i+1:]...)
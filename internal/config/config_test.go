@@ -0,0 +1,284 @@
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/urustack/uruflow/internal/models"
+)
+
+func TestTokenGraceHours(t *testing.T) {
+	tests := []struct {
+		name string
+		yaml string
+		want time.Duration
+	}{
+		{"unset", "server:\n  http_port: 9000\n", 24 * time.Hour},
+		{"zero", "server:\n  token_grace_hours: 0\n", 0},
+		{"disabled", "server:\n  token_grace_hours: -1\n", 0},
+		{"custom", "server:\n  token_grace_hours: 2\n", 2 * time.Hour},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.yaml")
+			if err := os.WriteFile(path, []byte(tt.yaml), 0600); err != nil {
+				t.Fatal(err)
+			}
+			cfg, err := Load(path)
+			if err != nil {
+				t.Fatalf("load: %v", err)
+			}
+			if got := cfg.TokenGrace(); got != tt.want {
+				t.Fatalf("TokenGrace() = %v, want %v", got, tt.want)
+			}
+
+			if err := cfg.Save(path); err != nil {
+				t.Fatalf("save: %v", err)
+			}
+			reloaded, err := Load(path)
+			if err != nil {
+				t.Fatalf("reload: %v", err)
+			}
+			if got := reloaded.TokenGrace(); got != tt.want {
+				t.Fatalf("TokenGrace() after save = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRotateWithoutGraceRetiresTokenAtOnce(t *testing.T) {
+	cfg := Default()
+	grace := 0
+	cfg.Server.TokenGraceHours = &grace
+	id, token, err := cfg.AddAgent("web")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cfg.RotateAgentToken(id); err != nil {
+		t.Fatal(err)
+	}
+	if agent, valid := cfg.GetAgentByPreviousToken(token, time.Now()); agent == nil || valid {
+		t.Fatalf("previous token: agent=%v valid=%v, want a match that is no longer valid", agent, valid)
+	}
+}
+
+func TestConcurrentAccess(t *testing.T) {
+	cfg := Default()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	id, _, err := cfg.AddAgent("web")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				name := fmt.Sprintf("repo-%d-%d", i, j)
+				cfg.AddRepository(models.Repository{Name: name, URL: "https://example.com/" + name, AgentID: id})
+				cfg.RotateAgentToken(id)
+				cfg.SetAgentMaintenance(id, j%2 == 0)
+				if err := cfg.Save(path); err != nil {
+					t.Error(err)
+					return
+				}
+				cfg.RemoveRepository(name)
+			}
+		}()
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				cfg.GetAgent(id)
+				cfg.AgentRepositories(id)
+				cfg.GetRepositoriesByURL("https://example.com/repo-0-0", "main")
+				cfg.AgentMaintenance(id, time.Now())
+				cfg.ListRepositories()
+				cfg.Export(false)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if n := len(cfg.ListRepositories()); n != 0 {
+		t.Fatalf("%d repositories left, want 0", n)
+	}
+}
+
+func TestSnapshotRestore(t *testing.T) {
+	cfg := Default()
+	id, _, _ := cfg.AddAgent("web")
+	cfg.AddRepository(models.Repository{Name: "api", AgentID: id})
+
+	snap := cfg.Snapshot()
+	cfg.ReassignRepositories(id, "other")
+	cfg.RemoveAgent(id)
+	cfg.Restore(snap)
+
+	if cfg.GetAgent(id) == nil {
+		t.Fatal("agent not restored")
+	}
+	if repo := cfg.GetRepository("api"); repo == nil || repo.AgentID != id {
+		t.Fatalf("repository = %+v, want it assigned to %s", repo, id)
+	}
+}
+
+func loadYAML(t *testing.T, doc string) (*Config, error) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(doc), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return Load(path)
+}
+
+func TestVerifyClientCNNeedsClientCA(t *testing.T) {
+	if _, err := loadYAML(t, "tls:\n  enabled: true\n  verify_client_cn: true\n"); err == nil {
+		t.Fatal("verify_client_cn without client_ca_file loaded without error")
+	}
+	if _, err := loadYAML(t, "tls:\n  enabled: true\n  client_ca_file: /etc/uruflow/ca.pem\n  verify_client_cn: true\n"); err != nil {
+		t.Fatalf("verify_client_cn with client_ca_file: %v", err)
+	}
+}
+
+func TestOfflineGraceSec(t *testing.T) {
+	if _, err := loadYAML(t, "server:\n  offline_grace_sec: -5\n"); err == nil {
+		t.Fatal("negative offline_grace_sec loaded without error")
+	}
+	cfg, err := loadYAML(t, "server:\n  offline_grace_sec: 15\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Server.OfflineGraceSec != 15 {
+		t.Fatalf("offline_grace_sec = %d, want 15", cfg.Server.OfflineGraceSec)
+	}
+	if cfg, err = loadYAML(t, "server: {}\n"); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Server.OfflineGraceSec != 60 {
+		t.Fatalf("default offline_grace_sec = %d, want 60", cfg.Server.OfflineGraceSec)
+	}
+}
+
+func TestListenAddrs(t *testing.T) {
+	cases := []struct {
+		name    string
+		list    []string
+		host    string
+		want    []string
+		wantErr string
+	}{
+		{"host and port", nil, "0.0.0.0", []string{"0.0.0.0:9001"}, ""},
+		{"ipv6 host", nil, "::", []string{"[::]:9001"}, ""},
+		{"bracketed ipv6 host", nil, "[::1]", []string{"[::1]:9001"}, ""},
+		{"dual stack list", []string{"0.0.0.0:9001", "[::]:9001"}, "", []string{"0.0.0.0:9001", "[::]:9001"}, ""},
+		{"ipv4 without port", []string{"127.0.0.1"}, "", []string{"127.0.0.1:9001"}, ""},
+		{"hostname without port", []string{"localhost"}, "", []string{"localhost:9001"}, ""},
+		{"ipv6 without port", []string{"::1"}, "", []string{"[::1]:9001"}, ""},
+		{"bracketed ipv6 without port", []string{"[fe80::1]"}, "", []string{"[fe80::1]:9001"}, ""},
+		{"bracketed ipv6 with port", []string{"[2001:db8::1]:9443"}, "", []string{"[2001:db8::1]:9443"}, ""},
+		{"port only", []string{":9002"}, "", []string{":9002"}, ""},
+		{"blank entries skipped", []string{" ", " 10.0.0.1:9001 ", ""}, "", []string{"10.0.0.1:9001"}, ""},
+		{"named port", []string{"0.0.0.0:http"}, "", nil, "bad port"},
+		{"port out of range", []string{"0.0.0.0:70000"}, "", nil, "bad port"},
+		{"only blank entries", []string{"", "  "}, "", nil, "no listen addresses configured"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := ListenAddrs(tc.list, tc.host, 9001)
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("ListenAddrs(%q) error = %v, want %q", tc.list, err, tc.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ListenAddrs(%q): %v", tc.list, err)
+			}
+			if !slices.Equal(got, tc.want) {
+				t.Fatalf("ListenAddrs(%q, %q) = %q, want %q", tc.list, tc.host, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeGitURL(t *testing.T) {
+	cases := []struct {
+		url  string
+		want string
+	}{
+		{"https://github.com/acme/api.git", "github.com/acme/api"},
+		{"https://github.com/acme/api", "github.com/acme/api"},
+		{"https://GitHub.com/acme/api/", "github.com/acme/api"},
+		{"https://token@github.com/acme/api.git", "github.com/acme/api"},
+		{"http://gitlab.internal:8080/group/sub/api.git", "gitlab.internal/group/sub/api"},
+		{"git@github.com:acme/api.git", "github.com/acme/api"},
+		{"git@GITHUB.COM:acme/api", "github.com/acme/api"},
+		{"github.com:acme/api.git", "github.com/acme/api"},
+		{"ssh://git@github.com/acme/api.git", "github.com/acme/api"},
+		{"ssh://git@gitlab.internal:2222/group/api.git", "gitlab.internal/group/api"},
+		{"git://github.com/acme/api.git", "github.com/acme/api"},
+		{"https://github.com/acme/API.git", "github.com/acme/API"},
+		{"  ", ""},
+	}
+	for _, tc := range cases {
+		if got := NormalizeGitURL(tc.url); got != tc.want {
+			t.Errorf("NormalizeGitURL(%q) = %q, want %q", tc.url, got, tc.want)
+		}
+	}
+}
+
+func TestGetRepositoryByURL(t *testing.T) {
+	cfg := Default()
+	cfg.AddRepository(models.Repository{Name: "prod-api", URL: "https://github.com/acme/api.git", Branch: "main"})
+	cfg.AddRepository(models.Repository{Name: "staging-api", URL: "git@github.com:acme/api.git", Branch: "develop"})
+	cfg.AddRepository(models.Repository{Name: "web", URL: "git@github.com:acme/web.git", Branch: "main"})
+
+	cases := []struct {
+		url    string
+		branch string
+		want   string
+	}{
+		{"git@github.com:acme/api.git", "main", "prod-api"},
+		{"https://github.com/acme/api", "develop", "staging-api"},
+		{"ssh://git@github.com/acme/api.git", "feature/x", "prod-api"},
+		{"https://github.com/acme/web.git", "main", "web"},
+		{"https://github.com/acme/worker.git", "main", ""},
+		{"https://gitlab.com/acme/api.git", "main", ""},
+	}
+	for _, tc := range cases {
+		got := ""
+		if r := cfg.GetRepositoryByURL(tc.url, tc.branch); r != nil {
+			got = r.Name
+		}
+		if got != tc.want {
+			t.Errorf("GetRepositoryByURL(%q, %q) = %q, want %q", tc.url, tc.branch, got, tc.want)
+		}
+	}
+}
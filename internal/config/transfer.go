@@ -0,0 +1,259 @@
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+
+package config
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"time"
+
+	"github.com/urustack/uruflow/internal/models"
+	"github.com/urustack/uruflow/pkg/helper"
+	"gopkg.in/yaml.v3"
+)
+
+type ConflictPolicy int
+
+const (
+	ConflictFail ConflictPolicy = iota
+	ConflictOverwrite
+	ConflictSkip
+)
+
+const (
+	ImportCreate    = "create"
+	ImportUpdate    = "update"
+	ImportSkip      = "skip"
+	ImportUnchanged = "unchanged"
+)
+
+type Export struct {
+	Agents       []AgentConfig       `yaml:"agents"`
+	Repositories []models.Repository `yaml:"repositories"`
+}
+
+type ImportChange struct {
+	Kind   string
+	Name   string
+	Action string
+	Agent  *AgentConfig
+	Repo   *models.Repository
+	Token  string
+}
+
+func (c *Config) Export(withSecrets bool) *Export {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	doc := &Export{
+		Agents:       make([]AgentConfig, 0, len(c.Agents)),
+		Repositories: make([]models.Repository, 0, len(c.Repositories)),
+	}
+	for _, a := range c.Agents {
+		a.Token = ""
+		if !withSecrets {
+			a.TokenHash = ""
+		}
+		doc.Agents = append(doc.Agents, a)
+	}
+	for _, r := range c.Repositories {
+		r = exportRepository(r)
+		if !withSecrets {
+			r.Secret = ""
+			r.Env = nil
+		}
+		doc.Repositories = append(doc.Repositories, r)
+	}
+	return doc
+}
+
+func exportRepository(r models.Repository) models.Repository {
+	r.ID = 0
+	r.CreatedAt = time.Time{}
+	r.Drift = nil
+	if len(r.Env) == 0 {
+		r.Env = nil
+	}
+	if len(r.Branches) == 0 {
+		r.Branches = nil
+	}
+	return r
+}
+
+func (e *Export) Marshal() ([]byte, error) {
+	return yaml.Marshal(e)
+}
+
+func LoadExport(path string) (*Export, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read export: %w", err)
+	}
+	var doc Export
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("parse export: %w", err)
+	}
+	return &doc, nil
+}
+
+func (c *Config) PlanImport(doc *Export, policy ConflictPolicy) ([]ImportChange, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	var changes []ImportChange
+	agentIDs := make(map[string]bool)
+	for _, a := range c.Agents {
+		agentIDs[a.ID] = true
+	}
+
+	seen, names := make(map[string]bool), make(map[string]bool)
+	for _, in := range doc.Agents {
+		in := in
+		if in.ID == "" || in.Name == "" {
+			return nil, fmt.Errorf("agent %q: id and name are required", in.Name)
+		}
+		if seen[in.ID] {
+			return nil, fmt.Errorf("agent %s is listed more than once", in.ID)
+		}
+		if names[in.Name] {
+			return nil, fmt.Errorf("agent name %s is listed more than once", in.Name)
+		}
+		seen[in.ID], names[in.Name] = true, true
+		for _, w := range in.MaintenanceWindows {
+			if _, err := models.ParseMaintenanceWindow(w); err != nil {
+				return nil, fmt.Errorf("agent %s: maintenance_windows: %w", in.Name, err)
+			}
+		}
+		if in.Token != "" {
+			in.TokenHash = helper.HashToken(in.Token)
+			in.Token = ""
+		}
+		if len(in.Labels) == 0 {
+			in.Labels = nil
+		}
+
+		if other := c.agentByName(in.Name); other != nil && other.ID != in.ID {
+			return nil, fmt.Errorf("agent %s: name is already used by agent %s", in.Name, other.ID)
+		}
+
+		existing := c.agent(in.ID)
+		if existing == nil {
+			change := ImportChange{Kind: "agent", Name: in.Name, Action: ImportCreate, Agent: &in}
+			if in.TokenHash == "" {
+				change.Token = helper.GenerateToken()
+				in.TokenHash = helper.HashToken(change.Token)
+			}
+			changes = append(changes, change)
+			agentIDs[in.ID] = true
+			continue
+		}
+
+		if in.TokenHash == "" {
+			in.TokenHash = existing.TokenHash
+		}
+		current := *existing
+		current.Token = ""
+		if len(current.Labels) == 0 {
+			current.Labels = nil
+		}
+		action, err := resolveConflict("agent", in.Name, reflect.DeepEqual(current, in), policy)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, ImportChange{Kind: "agent", Name: in.Name, Action: action, Agent: &in})
+	}
+
+	seen = make(map[string]bool)
+	for _, in := range doc.Repositories {
+		in := exportRepository(in)
+		if in.Name == "" || in.URL == "" {
+			return nil, fmt.Errorf("repository %q: name and url are required", in.Name)
+		}
+		if seen[in.Name] {
+			return nil, fmt.Errorf("repository %s is listed more than once", in.Name)
+		}
+		seen[in.Name] = true
+
+		switch {
+		case in.AgentSelector != "":
+			if _, err := models.ParseLabels(in.AgentSelector); err != nil {
+				return nil, fmt.Errorf("repository %s: agent_selector: %w", in.Name, err)
+			}
+		case in.AgentID == "":
+			return nil, fmt.Errorf("repository %s: agent_id or agent_selector is required", in.Name)
+		case !agentIDs[in.AgentID]:
+			return nil, fmt.Errorf("repository %s: agent %s does not exist", in.Name, in.AgentID)
+		}
+
+		existing := c.repository(in.Name)
+		if existing == nil {
+			changes = append(changes, ImportChange{Kind: "repository", Name: in.Name, Action: ImportCreate, Repo: &in})
+			continue
+		}
+
+		if in.Secret == "" {
+			in.Secret = existing.Secret
+		}
+		if in.Env == nil {
+			in.Env = existing.Env
+		}
+		current := exportRepository(*existing)
+		in = exportRepository(in)
+		action, err := resolveConflict("repository", in.Name, reflect.DeepEqual(current, in), policy)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, ImportChange{Kind: "repository", Name: in.Name, Action: action, Repo: &in})
+	}
+
+	return changes, nil
+}
+
+func resolveConflict(kind, name string, equal bool, policy ConflictPolicy) (string, error) {
+	switch {
+	case equal:
+		return ImportUnchanged, nil
+	case policy == ConflictOverwrite:
+		return ImportUpdate, nil
+	case policy == ConflictSkip:
+		return ImportSkip, nil
+	}
+	return "", fmt.Errorf("%s %s already exists with different settings (use --overwrite or --skip-existing)", kind, name)
+}
+
+func (c *Config) ApplyImport(changes []ImportChange) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, ch := range changes {
+		switch {
+		case ch.Agent != nil && ch.Action == ImportCreate:
+			c.Agents = append(c.Agents, *ch.Agent)
+		case ch.Agent != nil && ch.Action == ImportUpdate:
+			*c.agent(ch.Agent.ID) = *ch.Agent
+		case ch.Repo != nil && ch.Action == ImportCreate:
+			repo := *ch.Repo
+			repo.CreatedAt = time.Now()
+			c.Repositories = append(c.Repositories, repo)
+		case ch.Repo != nil && ch.Action == ImportUpdate:
+			existing := c.repository(ch.Repo.Name)
+			repo := *ch.Repo
+			repo.ID, repo.CreatedAt = existing.ID, existing.CreatedAt
+			*existing = repo
+		}
+	}
+}
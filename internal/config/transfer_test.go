@@ -0,0 +1,238 @@
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+
+package config
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/urustack/uruflow/internal/models"
+	"github.com/urustack/uruflow/pkg/helper"
+)
+
+func newTransferConfig(t *testing.T) (*Config, string) {
+	t.Helper()
+	cfg := Default()
+	id, _, err := cfg.AddAgent("web")
+	if err != nil {
+		t.Fatalf("add agent: %v", err)
+	}
+	cfg.SetAgentLabels(id, map[string]string{"env": "prod"})
+	repos := []models.Repository{
+		{
+			Name:        "api",
+			URL:         "https://github.com/acme/api.git",
+			Branch:      "main",
+			AgentID:     id,
+			Path:        "/srv/api",
+			AutoDeploy:  true,
+			BuildSystem: "compose",
+			Secret:      "api-secret",
+			Env:         map[string]string{"TOKEN": "s3cret"},
+		},
+		{
+			Name:          "worker",
+			URL:           "https://github.com/acme/worker.git",
+			Branch:        "main",
+			AgentSelector: "env=prod",
+			Path:          "/srv/worker",
+			BuildSystem:   "make",
+		},
+	}
+	for _, r := range repos {
+		if err := cfg.AddRepository(r); err != nil {
+			t.Fatalf("add repository %s: %v", r.Name, err)
+		}
+	}
+	return cfg, id
+}
+
+func exportYAML(t *testing.T, cfg *Config, withSecrets bool) []byte {
+	t.Helper()
+	data, err := cfg.Export(withSecrets).Marshal()
+	if err != nil {
+		t.Fatalf("marshal export: %v", err)
+	}
+	return data
+}
+
+func loadExportYAML(t *testing.T, data []byte) *Export {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "export.yaml")
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	doc, err := LoadExport(path)
+	if err != nil {
+		t.Fatalf("load export: %v", err)
+	}
+	return doc
+}
+
+func importYAML(t *testing.T, cfg *Config, data []byte, policy ConflictPolicy) []ImportChange {
+	t.Helper()
+	changes, err := cfg.PlanImport(loadExportYAML(t, data), policy)
+	if err != nil {
+		t.Fatalf("plan import: %v", err)
+	}
+	cfg.ApplyImport(changes)
+	return changes
+}
+
+func actions(changes []ImportChange) map[string]string {
+	out := make(map[string]string, len(changes))
+	for _, ch := range changes {
+		out[ch.Kind+" "+ch.Name] = ch.Action
+	}
+	return out
+}
+
+func TestExportImportRoundTrip(t *testing.T) {
+	src, _ := newTransferConfig(t)
+	first := exportYAML(t, src, true)
+
+	dst := Default()
+	changes := importYAML(t, dst, first, ConflictFail)
+	for key, action := range actions(changes) {
+		if action != ImportCreate {
+			t.Errorf("%s: action = %s, want %s", key, action, ImportCreate)
+		}
+	}
+
+	second := exportYAML(t, dst, true)
+	if !bytes.Equal(first, second) {
+		t.Fatalf("export after import differs:\n--- first\n%s\n--- second\n%s", first, second)
+	}
+
+	again := importYAML(t, dst, second, ConflictFail)
+	for key, action := range actions(again) {
+		if action != ImportUnchanged {
+			t.Errorf("re-import %s: action = %s, want %s", key, action, ImportUnchanged)
+		}
+	}
+	if third := exportYAML(t, dst, true); !bytes.Equal(second, third) {
+		t.Fatalf("export changed after re-import:\n%s", third)
+	}
+}
+
+func TestExportRedactsSecrets(t *testing.T) {
+	cfg, id := newTransferConfig(t)
+	data := string(exportYAML(t, cfg, false))
+	for _, secret := range []string{cfg.GetAgent(id).TokenHash, "api-secret", "s3cret"} {
+		if strings.Contains(data, secret) {
+			t.Errorf("sanitized export contains %q", secret)
+		}
+	}
+	if !strings.Contains(string(exportYAML(t, cfg, true)), "api-secret") {
+		t.Error("export --with-secrets is missing the webhook secret")
+	}
+
+	hash := cfg.GetAgent(id).TokenHash
+	changes := importYAML(t, cfg, []byte(data), ConflictFail)
+	for key, action := range actions(changes) {
+		if action != ImportUnchanged {
+			t.Errorf("%s: action = %s, want %s", key, action, ImportUnchanged)
+		}
+	}
+	if cfg.GetAgent(id).TokenHash != hash {
+		t.Error("importing a sanitized export replaced the token hash")
+	}
+	if repo := cfg.GetRepository("api"); repo.Secret != "api-secret" || repo.Env["TOKEN"] != "s3cret" {
+		t.Errorf("importing a sanitized export dropped secrets: %+v", repo)
+	}
+}
+
+func TestImportGeneratesTokenForNewAgent(t *testing.T) {
+	src, _ := newTransferConfig(t)
+	dst := Default()
+	changes := importYAML(t, dst, exportYAML(t, src, false), ConflictFail)
+
+	var agent *ImportChange
+	for i := range changes {
+		if changes[i].Kind == "agent" {
+			agent = &changes[i]
+		}
+	}
+	if agent == nil || agent.Token == "" {
+		t.Fatalf("new agent without a token hash got no token: %+v", agent)
+	}
+	if got := dst.GetAgent(agent.Agent.ID); got == nil || got.TokenHash != helper.HashToken(agent.Token) {
+		t.Fatalf("stored agent %+v does not match the printed token", got)
+	}
+}
+
+func TestImportConflicts(t *testing.T) {
+	cfg, _ := newTransferConfig(t)
+	doc := exportYAML(t, cfg, true)
+	changed := []byte(strings.Replace(string(doc), "path: /srv/api", "path: /srv/api-v2", 1))
+
+	if _, err := cfg.PlanImport(loadExportYAML(t, changed), ConflictFail); err == nil ||
+		!strings.Contains(err.Error(), "repository api already exists") {
+		t.Fatalf("conflicting import err = %v, want an already-exists error", err)
+	}
+
+	skipped := importYAML(t, cfg, changed, ConflictSkip)
+	if got := actions(skipped)["repository api"]; got != ImportSkip {
+		t.Errorf("skip-existing action = %s, want %s", got, ImportSkip)
+	}
+	if cfg.GetRepository("api").Path != "/srv/api" {
+		t.Error("skip-existing changed the repository")
+	}
+
+	updated := importYAML(t, cfg, changed, ConflictOverwrite)
+	if got := actions(updated)["repository api"]; got != ImportUpdate {
+		t.Errorf("overwrite action = %s, want %s", got, ImportUpdate)
+	}
+	if cfg.GetRepository("api").Path != "/srv/api-v2" {
+		t.Error("overwrite did not change the repository")
+	}
+	if got := actions(updated)["repository worker"]; got != ImportUnchanged {
+		t.Errorf("untouched repository action = %s, want %s", got, ImportUnchanged)
+	}
+}
+
+func TestImportValidatesAgentReferences(t *testing.T) {
+	cfg := Default()
+	doc := &Export{Repositories: []models.Repository{{
+		Name: "api", URL: "https://github.com/acme/api.git", Branch: "main", AgentID: "missing",
+	}}}
+	if _, err := cfg.PlanImport(doc, ConflictFail); err == nil || !strings.Contains(err.Error(), "agent missing does not exist") {
+		t.Fatalf("unknown agent err = %v", err)
+	}
+
+	doc.Agents = []AgentConfig{{ID: "missing", Name: "web"}}
+	changes, err := cfg.PlanImport(doc, ConflictFail)
+	if err != nil {
+		t.Fatalf("agent in the same import: %v", err)
+	}
+	if len(changes) != 2 {
+		t.Fatalf("changes = %+v, want the agent and the repository", changes)
+	}
+	if cfg.GetRepository("api") != nil {
+		t.Error("planning an import changed the config")
+	}
+
+	doc.Agents = append(doc.Agents, AgentConfig{ID: "other", Name: "web"})
+	if _, err := cfg.PlanImport(doc, ConflictFail); err == nil {
+		t.Error("duplicate agent names were accepted")
+	}
+}
@@ -19,6 +19,9 @@
 package logic
 
 import (
+	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/urustack/uruflow/internal/models"
@@ -55,16 +58,35 @@ func CheckDisk(agentID, agentName string, diskPercent float64) *models.Alert {
 	return nil
 }
 
-func CheckContainerDown(agentID, agentName, containerName string) *models.Alert {
+func CPURecovered(cpuPercent float64) bool {
+	return cpuPercent < 70
+}
+
+func MemoryRecovered(memPercent float64) bool {
+	return memPercent < 85
+}
+
+func DiskRecovered(diskPercent float64) bool {
+	return diskPercent < 80
+}
+
+func CheckContainerDown(agentID, agentName, containerName, project, service string) *models.Alert {
 	return newAlert(
 		agentID,
 		agentName,
 		"container_down",
-		"Container "+containerName+" is not running",
+		ContainerDownMessage(containerName, project, service),
 		models.SeverityCritical,
 	)
 }
 
+func ContainerDownMessage(containerName, project, service string) string {
+	if project != "" && service != "" {
+		return fmt.Sprintf("Service %s of %s is not running (container %s)", service, project, containerName)
+	}
+	return "Container " + containerName + " is not running"
+}
+
 func CheckOffline(agentID, agentName string) *models.Alert {
 	return newAlert(
 		agentID,
@@ -75,6 +97,202 @@ func CheckOffline(agentID, agentName string) *models.Alert {
 	)
 }
 
+func CheckDrift(agentID, agentName, repoName string) *models.Alert {
+	return newAlert(
+		agentID,
+		agentName,
+		"drift",
+		"Repository "+repoName+" has drifted from its last deployment",
+		models.SeverityInfo,
+	)
+}
+
+const DeployFailCriticalAfter = 3
+
+var deployLogNoise = []string{
+	"Cloning into",
+	"Reset branch",
+	"Switched to",
+	"Already on",
+	"From ",
+	" * ",
+	"HEAD is now at",
+	"Your branch",
+	"done.",
+}
+
+func DeployFailed(d *models.Deployment, reason string, failures int) *models.Alert {
+	target := d.Branch
+	if commit := d.Commit; commit != "" && commit != "HEAD" {
+		if len(commit) > 7 {
+			commit = commit[:7]
+		}
+		target += "@" + commit
+	}
+	msg := "Deployment of " + d.Repository
+	if target != "" {
+		msg += " (" + target + ")"
+	}
+	msg += " failed"
+	if failures > 1 {
+		msg += fmt.Sprintf(" %d times in a row", failures)
+	}
+	if reason != "" {
+		if len(reason) > 200 {
+			reason = reason[:200] + "..."
+		}
+		msg += ": " + reason
+	}
+
+	severity := models.SeverityWarning
+	if failures >= DeployFailCriticalAfter {
+		severity = models.SeverityCritical
+	}
+	return newAlert(d.AgentID, d.AgentName, "deploy_failed", msg, severity)
+}
+
+func IsDeployFailedAlert(a *models.Alert, repoName string) bool {
+	return a.Type == "deploy_failed" && strings.HasPrefix(a.Message, "Deployment of "+repoName+" ")
+}
+
+func ConsecutiveFailures(history []models.Deployment, agentID string) int {
+	count := 0
+	for _, d := range history {
+		if d.AgentID != agentID || d.DryRun {
+			continue
+		}
+		switch d.Status {
+		case models.DeployFailed:
+			count++
+		case models.DeploySuccess, models.DeployWarning:
+			return count
+		}
+	}
+	return count
+}
+
+func DeployFailureReason(logs []models.DeploymentLog, fallback string) string {
+	for _, l := range logs {
+		if l.Stream != "stderr" {
+			continue
+		}
+		line := strings.TrimSpace(strings.TrimPrefix(l.Line, "›"))
+		if line == "" || isDeployLogNoise(l.Line) {
+			continue
+		}
+		return line
+	}
+	return strings.TrimSpace(fallback)
+}
+
+func isDeployLogNoise(line string) bool {
+	for _, prefix := range deployLogNoise {
+		if strings.HasPrefix(line, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+const (
+	MinAgentVersion    = "1.0.0"
+	ClockSkewThreshold = 30 * time.Second
+)
+
+func ClockSkew(agentTime int64, now time.Time) time.Duration {
+	if agentTime <= 0 {
+		return 0
+	}
+	return time.Unix(agentTime, 0).Sub(now.Truncate(time.Second))
+}
+
+func CheckClockSkew(agentID, agentName string, skew time.Duration) *models.Alert {
+	if skew.Abs() <= ClockSkewThreshold {
+		return nil
+	}
+	direction := "ahead of"
+	if skew < 0 {
+		direction = "behind"
+	}
+	return newAlert(
+		agentID,
+		agentName,
+		"clock_skew",
+		fmt.Sprintf("Agent %s clock is %s %s the server", agentName, skew.Abs().Round(time.Second), direction),
+		models.SeverityWarning,
+	)
+}
+
+func CheckDuplicateToken(agentID, agentName, holder, intruder string) *models.Alert {
+	return newAlert(
+		agentID,
+		agentName,
+		"duplicate_token",
+		fmt.Sprintf("Agent %s token is used by two machines: rejected %s while %s is connected", agentName, intruder, holder),
+		models.SeverityWarning,
+	)
+}
+
+func CheckRetiredToken(agentID, agentName, host string) *models.Alert {
+	return newAlert(
+		agentID,
+		agentName,
+		"retired_token",
+		fmt.Sprintf("Agent %s tried to connect from %s with a token that was rotated out, update it with the new token", agentName, host),
+		models.SeverityInfo,
+	)
+}
+
+func CheckAuthFlood(agentID, agentName, host string, failures int, window time.Duration) *models.Alert {
+	return newAlert(
+		agentID,
+		agentName,
+		"auth_flood",
+		fmt.Sprintf("%d failed agent logins from %s in the last %s", failures, host, window),
+		models.SeverityWarning,
+	)
+}
+
+func VersionSupported(version string) bool {
+	return compareVersions(version, MinAgentVersion) >= 0
+}
+
+func compareVersions(a, b string) int {
+	pa, pb := versionParts(a), versionParts(b)
+	for i := 0; i < len(pa) || i < len(pb); i++ {
+		var x, y int
+		if i < len(pa) {
+			x = pa[i]
+		}
+		if i < len(pb) {
+			y = pb[i]
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+func versionParts(v string) []int {
+	v = strings.TrimPrefix(strings.TrimSpace(v), "v")
+	if i := strings.IndexAny(v, "-+ "); i >= 0 {
+		v = v[:i]
+	}
+	if v == "" {
+		return nil
+	}
+	fields := strings.Split(v, ".")
+	parts := make([]int, len(fields))
+	for i, f := range fields {
+		parts[i], _ = strconv.Atoi(f)
+	}
+	return parts
+}
+
 func newAlert(agentID, agentName, alertType, msg string, severity models.AlertSeverity) *models.Alert {
 	return &models.Alert{
 		ID:        helper.GenerateID(),
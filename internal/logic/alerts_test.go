@@ -0,0 +1,143 @@
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+
+package logic
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/urustack/uruflow/internal/agent/daemon"
+	"github.com/urustack/uruflow/internal/models"
+)
+
+func TestVersionSupported(t *testing.T) {
+	tests := []struct {
+		version string
+		want    bool
+	}{
+		{daemon.Version, true},
+		{MinAgentVersion, true},
+		{"v1.0.0", true},
+		{"1.1.0-rc1", true},
+		{"1.10.0", true},
+		{"0.9.9", false},
+		{"0.10", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := VersionSupported(tt.version); got != tt.want {
+			t.Errorf("VersionSupported(%q) = %v, want %v", tt.version, got, tt.want)
+		}
+	}
+	if compareVersions(MinAgentVersion, daemon.Version) >= 0 {
+		t.Errorf("MinAgentVersion %s is not older than the agent version %s", MinAgentVersion, daemon.Version)
+	}
+}
+
+func TestConsecutiveFailures(t *testing.T) {
+	history := []models.Deployment{
+		{AgentID: "agent-1", Status: models.DeployFailed},
+		{AgentID: "agent-2", Status: models.DeploySuccess},
+		{AgentID: "agent-1", Status: models.DeployFailed, DryRun: true},
+		{AgentID: "agent-1", Status: models.DeployRejected},
+		{AgentID: "agent-1", Status: models.DeployFailed},
+		{AgentID: "agent-1", Status: models.DeploySuccess},
+		{AgentID: "agent-1", Status: models.DeployFailed},
+	}
+	if got := ConsecutiveFailures(history, "agent-1"); got != 2 {
+		t.Errorf("agent-1 failures = %d, want 2", got)
+	}
+	if got := ConsecutiveFailures(history, "agent-2"); got != 0 {
+		t.Errorf("agent-2 failures = %d, want 0", got)
+	}
+	if got := ConsecutiveFailures(history[:1], "agent-1"); got != 1 {
+		t.Errorf("single failure = %d, want 1", got)
+	}
+}
+
+func TestDeployFailedEscalates(t *testing.T) {
+	d := &models.Deployment{
+		AgentID:    "agent-1",
+		AgentName:  "web",
+		Repository: "api",
+		Branch:     "main",
+		Commit:     "4f2a9c1e8b7d6a5f4e3d2c1b0a9f8e7d6c5b4a39",
+	}
+
+	first := DeployFailed(d, "exit status 1", 1)
+	if first.Type != "deploy_failed" || first.Severity != models.SeverityWarning {
+		t.Fatalf("first failure = %s/%s, want deploy_failed/warning", first.Type, first.Severity)
+	}
+	if want := "Deployment of api (main@4f2a9c1) failed: exit status 1"; first.Message != want {
+		t.Errorf("message = %q, want %q", first.Message, want)
+	}
+
+	second := DeployFailed(d, "", DeployFailCriticalAfter-1)
+	if second.Severity != models.SeverityWarning {
+		t.Errorf("severity after %d failures = %s, want warning", DeployFailCriticalAfter-1, second.Severity)
+	}
+	if !strings.Contains(second.Message, " times in a row") {
+		t.Errorf("message %q does not count the failures", second.Message)
+	}
+
+	critical := DeployFailed(d, "", DeployFailCriticalAfter)
+	if critical.Severity != models.SeverityCritical {
+		t.Errorf("severity after %d failures = %s, want critical", DeployFailCriticalAfter, critical.Severity)
+	}
+
+	d.Commit = "HEAD"
+	if msg := DeployFailed(d, "", 1).Message; msg != "Deployment of api (main) failed" {
+		t.Errorf("HEAD deploy message = %q", msg)
+	}
+}
+
+func TestIsDeployFailedAlert(t *testing.T) {
+	alert := DeployFailed(&models.Deployment{Repository: "api", Branch: "main"}, "", 1)
+	if !IsDeployFailedAlert(alert, "api") {
+		t.Error("alert not matched for its own repository")
+	}
+	if IsDeployFailedAlert(alert, "ap") || IsDeployFailedAlert(alert, "api-v2") {
+		t.Error("alert matched for another repository")
+	}
+	other := DeployFailed(&models.Deployment{Repository: "api-v2", Branch: "main"}, "", 1)
+	if IsDeployFailedAlert(other, "api") {
+		t.Error("api-v2 alert matched for api")
+	}
+	other.Type = "drift"
+	if IsDeployFailedAlert(other, "api-v2") {
+		t.Error("drift alert matched as a deploy failure")
+	}
+}
+
+func TestDeployFailureReason(t *testing.T) {
+	logs := []models.DeploymentLog{
+		{Stream: "stdout", Line: "Building api"},
+		{Stream: "stderr", Line: "Cloning into '/srv/api'..."},
+		{Stream: "stderr", Line: "HEAD is now at 4f2a9c1 release"},
+		{Stream: "stderr", Line: "  "},
+		{Stream: "stderr", Line: "› npm ERR! missing script: build"},
+		{Stream: "stderr", Line: "exit status 1"},
+	}
+	if got := DeployFailureReason(logs, "fallback"); got != "npm ERR! missing script: build" {
+		t.Errorf("reason = %q", got)
+	}
+	if got := DeployFailureReason(logs[:4], " exit status 2\n"); got != "exit status 2" {
+		t.Errorf("fallback reason = %q", got)
+	}
+}
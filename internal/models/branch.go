@@ -0,0 +1,69 @@
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+
+package models
+
+import (
+	"path"
+	"strings"
+)
+
+func (r *Repository) BranchPatterns() []string {
+	if len(r.Branches) > 0 {
+		return r.Branches
+	}
+	if r.Branch == "" {
+		return nil
+	}
+	return []string{r.Branch}
+}
+
+func (r *Repository) MatchesBranch(branch string) bool {
+	for _, pattern := range r.BranchPatterns() {
+		if MatchBranch(pattern, branch) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Repository) MatchesTag(tag string) bool {
+	return r.TagPattern != "" && MatchBranch(r.TagPattern, tag)
+}
+
+func MatchBranch(pattern, branch string) bool {
+	if pattern == branch {
+		return true
+	}
+	ok, err := path.Match(pattern, branch)
+	return err == nil && ok
+}
+
+func IsBranchPattern(s string) bool {
+	return strings.ContainsAny(s, "*?[")
+}
+
+func SplitBranches(s string) []string {
+	var out []string
+	for _, b := range strings.Split(s, ",") {
+		if b = strings.TrimSpace(b); b != "" {
+			out = append(out, b)
+		}
+	}
+	return out
+}
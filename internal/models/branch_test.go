@@ -0,0 +1,77 @@
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+
+package models
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestMatchesBranch(t *testing.T) {
+	cases := []struct {
+		name   string
+		repo   Repository
+		branch string
+		want   bool
+	}{
+		{"exact", Repository{Branch: "main"}, "main", true},
+		{"exact no match", Repository{Branch: "main"}, "master", false},
+		{"exact is not a prefix", Repository{Branch: "main"}, "main-old", false},
+		{"prefix glob", Repository{Branches: []string{"release/*"}}, "release/1.4", true},
+		{"prefix glob needs the separator", Repository{Branches: []string{"release/*"}}, "release", false},
+		{"glob stays within one segment", Repository{Branches: []string{"release/*"}}, "release/1.4/hotfix", false},
+		{"single character glob", Repository{Branches: []string{"v?"}}, "v2", true},
+		{"character class", Repository{Branches: []string{"env-[ab]"}}, "env-c", false},
+		{"list", Repository{Branches: []string{"main", "release/*"}}, "release/2.0", true},
+		{"list no match", Repository{Branches: []string{"main", "release/*"}}, "feature/login", false},
+		{"branches override branch", Repository{Branch: "main", Branches: []string{"develop"}}, "main", false},
+		{"malformed pattern matches only itself", Repository{Branches: []string{"fix[1"}}, "fix[1", true},
+		{"no branch configured", Repository{}, "main", false},
+	}
+	for _, tc := range cases {
+		if got := tc.repo.MatchesBranch(tc.branch); got != tc.want {
+			t.Errorf("%s: MatchesBranch(%q) = %v, want %v", tc.name, tc.branch, got, tc.want)
+		}
+	}
+}
+
+func TestBranchPatterns(t *testing.T) {
+	cases := []struct {
+		repo Repository
+		want []string
+	}{
+		{Repository{}, nil},
+		{Repository{Branch: "main"}, []string{"main"}},
+		{Repository{Branch: "main", Branches: []string{"main", "release/*"}}, []string{"main", "release/*"}},
+	}
+	for _, tc := range cases {
+		if got := tc.repo.BranchPatterns(); !slices.Equal(got, tc.want) {
+			t.Errorf("BranchPatterns(%+v) = %q, want %q", tc.repo, got, tc.want)
+		}
+	}
+
+	for s, want := range map[string]bool{"main": false, "release/*": true, "v?": true, "env-[ab]": true, "feature/login": false} {
+		if got := IsBranchPattern(s); got != want {
+			t.Errorf("IsBranchPattern(%q) = %v, want %v", s, got, want)
+		}
+	}
+	if got := SplitBranches(" main, release/* ,,develop "); !slices.Equal(got, []string{"main", "release/*", "develop"}) {
+		t.Errorf("SplitBranches = %q", got)
+	}
+}
@@ -0,0 +1,97 @@
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+
+package models
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+const (
+	ProviderGitHub = "github"
+	ProviderGitLab = "gitlab"
+)
+
+type CommitStatus struct {
+	Provider   string `json:"provider" yaml:"provider"`
+	APIBaseURL string `json:"api_base_url,omitempty" yaml:"api_base_url,omitempty"`
+	Project    string `json:"project,omitempty" yaml:"project,omitempty"`
+	TokenEnv   string `json:"token_env,omitempty" yaml:"token_env,omitempty"`
+	TokenFile  string `json:"token_file,omitempty" yaml:"token_file,omitempty"`
+}
+
+func (c *CommitStatus) Validate() error {
+	switch c.Provider {
+	case ProviderGitHub, ProviderGitLab:
+	default:
+		return fmt.Errorf("provider must be github or gitlab")
+	}
+	if (c.TokenEnv == "") == (c.TokenFile == "") {
+		return fmt.Errorf("set exactly one of token_env or token_file")
+	}
+	return nil
+}
+
+func (c *CommitStatus) BaseURL() string {
+	if c.APIBaseURL != "" {
+		return strings.TrimRight(c.APIBaseURL, "/")
+	}
+	if c.Provider == ProviderGitLab {
+		return "https://gitlab.com/api/v4"
+	}
+	return "https://api.github.com"
+}
+
+func (c *CommitStatus) Token() (string, error) {
+	if c.TokenEnv != "" {
+		token := os.Getenv(c.TokenEnv)
+		if token == "" {
+			return "", fmt.Errorf("environment variable %s is empty", c.TokenEnv)
+		}
+		return token, nil
+	}
+	data, err := os.ReadFile(c.TokenFile)
+	if err != nil {
+		return "", fmt.Errorf("read token file: %w", err)
+	}
+	token := strings.TrimSpace(string(data))
+	if token == "" {
+		return "", fmt.Errorf("token file %s is empty", c.TokenFile)
+	}
+	return token, nil
+}
+
+func (c *CommitStatus) ProjectPath(repoURL string) string {
+	if c.Project != "" {
+		return c.Project
+	}
+	path := strings.TrimSuffix(strings.TrimRight(repoURL, "/"), ".git")
+	if i := strings.Index(path, "://"); i >= 0 {
+		path = path[i+3:]
+		if slash := strings.Index(path, "/"); slash >= 0 {
+			return path[slash+1:]
+		}
+		return ""
+	}
+	if _, rest, ok := strings.Cut(path, ":"); ok {
+		return rest
+	}
+	return path
+}
@@ -0,0 +1,91 @@
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+
+package models
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var execParam = regexp.MustCompile(`\{([a-z0-9_]+)\}`)
+
+func ValidateExec(name string, argv []string) error {
+	if name == "" || strings.ContainsAny(name, " \t") {
+		return fmt.Errorf("exec command %q: names must not be empty or contain spaces", name)
+	}
+	if len(argv) == 0 || argv[0] == "" {
+		return fmt.Errorf("exec command %s: argv must not be empty", name)
+	}
+	if execParam.MatchString(argv[0]) {
+		return fmt.Errorf("exec command %s: the program %q must not contain parameters", name, argv[0])
+	}
+	return nil
+}
+
+func ExecParams(argv []string) []string {
+	var params []string
+	seen := make(map[string]bool)
+	for _, arg := range argv {
+		for _, m := range execParam.FindAllStringSubmatch(arg, -1) {
+			if !seen[m[1]] {
+				seen[m[1]] = true
+				params = append(params, m[1])
+			}
+		}
+	}
+	return params
+}
+
+func ExpandExec(argv []string, params map[string]string) ([]string, error) {
+	for _, name := range ExecParams(argv) {
+		value, ok := params[name]
+		if !ok || value == "" {
+			return nil, fmt.Errorf("missing parameter %q", name)
+		}
+		if strings.HasPrefix(value, "-") {
+			return nil, fmt.Errorf("parameter %q must not start with '-'", name)
+		}
+		if strings.ContainsAny(value, "\x00\r\n") {
+			return nil, fmt.Errorf("parameter %q must not contain control characters", name)
+		}
+	}
+	for name := range params {
+		if !execParamUsed(argv, name) {
+			return nil, fmt.Errorf("unknown parameter %q", name)
+		}
+	}
+
+	out := make([]string, len(argv))
+	for i, arg := range argv {
+		out[i] = execParam.ReplaceAllStringFunc(arg, func(m string) string {
+			return params[m[1:len(m)-1]]
+		})
+	}
+	return out, nil
+}
+
+func execParamUsed(argv []string, name string) bool {
+	for _, p := range ExecParams(argv) {
+		if p == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,110 @@
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+
+package models
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	imagePlaceholder = regexp.MustCompile(`\{[^}]*\}`)
+	imageRef         = regexp.MustCompile(`^` +
+		`(?:(?:[a-zA-Z0-9](?:[a-zA-Z0-9-]*[a-zA-Z0-9])?)(?:\.[a-zA-Z0-9](?:[a-zA-Z0-9-]*[a-zA-Z0-9])?)*(?::[0-9]+)?/)?` +
+		`[a-z0-9]+(?:(?:[._]|__|-+)[a-z0-9]+)*(?:/[a-z0-9]+(?:(?:[._]|__|-+)[a-z0-9]+)*)*` +
+		`(?::[a-zA-Z0-9_][a-zA-Z0-9_.-]{0,127})?` +
+		`(?:@[a-zA-Z][a-zA-Z0-9]*(?:[-_+.][a-zA-Z][a-zA-Z0-9]*)*:[0-9a-fA-F]{32,})?$`)
+	imagePort      = regexp.MustCompile(`^([0-9.]+:)?[0-9]+(:[0-9]+)?(/(tcp|udp))?$`)
+	containerName  = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]*$`)
+	imageTagUnsafe = regexp.MustCompile(`[^a-zA-Z0-9_.-]`)
+)
+
+func (r *Repository) ValidateImage() error {
+	if err := validateImageTemplate(r.Image); err != nil {
+		return err
+	}
+	if r.Container != "" && !containerName.MatchString(r.Container) {
+		return fmt.Errorf("container %q is not a valid container name", r.Container)
+	}
+	for _, p := range r.Ports {
+		if !imagePort.MatchString(p) {
+			return fmt.Errorf("port %q must look like 8080, 8080:80 or 127.0.0.1:8080:80/tcp", p)
+		}
+	}
+	return nil
+}
+
+func validateImageTemplate(template string) error {
+	if template == "" {
+		return fmt.Errorf("image is required for build_system image")
+	}
+	for _, p := range imagePlaceholder.FindAllString(template, -1) {
+		switch p {
+		case "{commit}", "{short_commit}", "{tag}", "{branch}":
+		default:
+			return fmt.Errorf("image %s: unknown placeholder %s (use {commit}, {short_commit}, {tag} or {branch})", template, p)
+		}
+	}
+	if !imageRef.MatchString(imagePlaceholder.ReplaceAllString(template, "x")) {
+		return fmt.Errorf("image %s is not a valid image reference", template)
+	}
+	return nil
+}
+
+func ValidateImageRef(ref string) error {
+	if len(ref) > 255 || !imageRef.MatchString(ref) {
+		return fmt.Errorf("image %q is not a valid image reference", ref)
+	}
+	return nil
+}
+
+func (r *Repository) ImageRef(branch, tag, commit string) (string, error) {
+	if err := validateImageTemplate(r.Image); err != nil {
+		return "", fmt.Errorf("repository %s: %w", r.Name, err)
+	}
+
+	pinned := commit != "" && commit != "HEAD"
+	short := commit
+	if len(short) > 7 {
+		short = short[:7]
+	}
+	values := map[string]string{
+		"{commit}":       commit,
+		"{short_commit}": short,
+		"{tag}":          imageTagUnsafe.ReplaceAllString(tag, "-"),
+		"{branch}":       imageTagUnsafe.ReplaceAllString(branch, "-"),
+	}
+
+	var missing []string
+	ref := imagePlaceholder.ReplaceAllStringFunc(r.Image, func(p string) string {
+		v := values[p]
+		if v == "" || ((p == "{commit}" || p == "{short_commit}") && !pinned) {
+			missing = append(missing, p)
+		}
+		return v
+	})
+	if len(missing) > 0 {
+		return "", fmt.Errorf("image %s needs %s, deploy a specific commit or tag", r.Image, strings.Join(missing, " and "))
+	}
+	if err := ValidateImageRef(ref); err != nil {
+		return "", fmt.Errorf("repository %s: %w", r.Name, err)
+	}
+	return ref, nil
+}
@@ -0,0 +1,76 @@
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+
+package models
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestImageRefSanitizesTag(t *testing.T) {
+	repo := &Repository{Name: "api", Image: "ghcr.io/acme/api:{tag}"}
+
+	ref, err := repo.ImageRef("main", "v1.2.0;touch /tmp/x", "")
+	if err != nil {
+		t.Fatalf("ImageRef: %v", err)
+	}
+	if ref != "ghcr.io/acme/api:v1.2.0-touch--tmp-x" {
+		t.Fatalf("ref = %q", ref)
+	}
+}
+
+func TestImageRefRejectsInvalidReference(t *testing.T) {
+	repo := &Repository{Name: "api", Image: "ghcr.io/acme/api:{tag}"}
+
+	if _, err := repo.ImageRef("main", strings.Repeat("a", 200), ""); err == nil {
+		t.Fatal("expected an error for a tag longer than 128 characters")
+	}
+}
+
+func TestValidateImageRef(t *testing.T) {
+	valid := []string{
+		"nginx",
+		"nginx:1.27",
+		"library/nginx:latest",
+		"localhost:5000/acme/api:abc1234",
+		"ghcr.io/acme/api-server@sha256:" + strings.Repeat("a", 64),
+		"registry.example.com/team/app:v1.0.0-rc.1",
+	}
+	for _, ref := range valid {
+		if err := ValidateImageRef(ref); err != nil {
+			t.Errorf("ValidateImageRef(%q): %v", ref, err)
+		}
+	}
+
+	invalid := []string{
+		"",
+		"nginx:1.27; rm -rf /",
+		"nginx $(id)",
+		"acme/API",
+		"acme/api:",
+		"-rm",
+		"acme/api:tag`id`",
+		"acme/api@sha256:short",
+	}
+	for _, ref := range invalid {
+		if err := ValidateImageRef(ref); err == nil {
+			t.Errorf("ValidateImageRef(%q) accepted an invalid reference", ref)
+		}
+	}
+}
@@ -36,29 +36,60 @@ const (
 	DeployRunning DeployStatus = "running"
 	DeploySuccess DeployStatus = "success"
 	DeployFailed  DeployStatus = "failed"
+	DeployWarning DeployStatus = "success_with_warnings"
+	DeployDryRun  DeployStatus = "dry_run"
+
+	DeployAwaitingApproval DeployStatus = "awaiting_approval"
+	DeployRejected         DeployStatus = "rejected"
+)
+
+const (
+	DetailSent             = "sent to agent"
+	DetailAcknowledged     = "acknowledged"
+	DetailAwaitingApproval = "awaiting approval"
+	DetailSendFailed       = "send failed"
+	DetailNoAck            = "agent did not acknowledge"
 )
 
+func (s DeployStatus) Succeeded() bool {
+	return s == DeploySuccess || s == DeployWarning
+}
+
 type AlertSeverity string
 
 const (
+	SeverityInfo     AlertSeverity = "info"
 	SeverityWarning  AlertSeverity = "warning"
 	SeverityCritical AlertSeverity = "critical"
 )
 
 type BuildSystem string
 
+const (
+	StrategyInPlace  = "inplace"
+	StrategyReleases = "releases"
+)
+
 type Agent struct {
-	ID            string        `json:"id" yaml:"id"`
-	Name          string        `json:"name" yaml:"name"`
-	Token         string        `json:"-" yaml:"token"`
-	Host          string        `json:"host" yaml:"host"`
-	Hostname      string        `json:"hostname" yaml:"hostname"`
-	Version       string        `json:"version" yaml:"version"`
-	Status        AgentStatus   `json:"status" yaml:"status"`
-	LastHeartbeat time.Time     `json:"last_heartbeat" yaml:"last_heartbeat"`
-	Metrics       *AgentMetrics `json:"metrics,omitempty" yaml:"metrics,omitempty"`
-	Containers    []Container   `json:"containers,omitempty" yaml:"containers,omitempty"`
-	RegisteredAt  time.Time     `json:"registered_at" yaml:"registered_at"`
+	ID            string            `json:"id" yaml:"id"`
+	Name          string            `json:"name" yaml:"name"`
+	TokenHash     string            `json:"-" yaml:"token_hash"`
+	Host          string            `json:"host" yaml:"host"`
+	Hostname      string            `json:"hostname" yaml:"hostname"`
+	MachineID     string            `json:"machine_id,omitempty" yaml:"machine_id,omitempty"`
+	Version       string            `json:"version" yaml:"version"`
+	Status        AgentStatus       `json:"status" yaml:"status"`
+	ClockSkewMs   int64             `json:"clock_skew_ms" yaml:"clock_skew_ms"`
+	Degraded      bool              `json:"degraded" yaml:"degraded"`
+	Maintenance   bool              `json:"maintenance" yaml:"maintenance"`
+	Labels        map[string]string `json:"labels,omitempty" yaml:"labels,omitempty"`
+	LastHeartbeat time.Time         `json:"last_heartbeat" yaml:"last_heartbeat"`
+	Metrics       *AgentMetrics     `json:"metrics,omitempty" yaml:"metrics,omitempty"`
+	Containers    []Container       `json:"containers,omitempty" yaml:"containers,omitempty"`
+	RegisteredAt  time.Time         `json:"registered_at" yaml:"registered_at"`
+
+	ReclaimedBytes uint64    `json:"reclaimed_bytes,omitempty" yaml:"reclaimed_bytes,omitempty"`
+	LastCleanup    time.Time `json:"last_cleanup,omitempty" yaml:"last_cleanup,omitempty"`
 }
 
 type AgentMetrics struct {
@@ -71,6 +102,29 @@ type AgentMetrics struct {
 	DiskTotal     uint64    `json:"disk_total" yaml:"disk_total"`
 	LoadAvg       []float64 `json:"load_avg" yaml:"load_avg"`
 	Uptime        int64     `json:"uptime" yaml:"uptime"`
+	QueuedDeploys int       `json:"queued_deploys" yaml:"queued_deploys"`
+	LowDisk       bool      `json:"low_disk_for_deploys,omitempty" yaml:"low_disk_for_deploys,omitempty"`
+
+	DockerDisk *DockerDiskUsage `json:"docker_disk,omitempty" yaml:"docker_disk,omitempty"`
+}
+
+type DockerDiskUsage struct {
+	Images     uint64 `json:"images" yaml:"images"`
+	Containers uint64 `json:"containers" yaml:"containers"`
+	Volumes    uint64 `json:"volumes" yaml:"volumes"`
+	BuildCache uint64 `json:"build_cache" yaml:"build_cache"`
+}
+
+func (u *DockerDiskUsage) Total() uint64 {
+	return u.Images + u.Containers + u.Volumes + u.BuildCache
+}
+
+type MetricsSample struct {
+	AgentID   string    `json:"agent_id"`
+	Timestamp time.Time `json:"timestamp"`
+	CPU       float64   `json:"cpu"`
+	Memory    float64   `json:"memory"`
+	Disk      float64   `json:"disk"`
 }
 
 type Container struct {
@@ -78,6 +132,8 @@ type Container struct {
 	AgentID      string          `json:"agent_id" yaml:"agent_id"`
 	Name         string          `json:"name" yaml:"name"`
 	Image        string          `json:"image" yaml:"image"`
+	Project      string          `json:"project,omitempty" yaml:"project,omitempty"`
+	Service      string          `json:"service,omitempty" yaml:"service,omitempty"`
 	Status       string          `json:"status" yaml:"status"`
 	Health       ContainerHealth `json:"health" yaml:"health"`
 	CPUPercent   float64         `json:"cpu_percent" yaml:"cpu_percent"`
@@ -87,20 +143,63 @@ type Container struct {
 	NetworkTx    uint64          `json:"network_tx" yaml:"network_tx"`
 	RestartCount int             `json:"restart_count" yaml:"restart_count"`
 	StartedAt    time.Time       `json:"started_at" yaml:"started_at"`
+	StatsStale   bool            `json:"stats_stale,omitempty" yaml:"stats_stale,omitempty"`
+}
+
+type Checkout struct {
+	AgentID    string    `json:"agent_id" yaml:"agent_id"`
+	Repository string    `json:"repository" yaml:"repository"`
+	URL        string    `json:"url" yaml:"url"`
+	Branch     string    `json:"branch" yaml:"branch"`
+	Commit     string    `json:"commit" yaml:"commit"`
+	FetchedAt  time.Time `json:"fetched_at" yaml:"fetched_at"`
+}
+
+func (c Checkout) Matches(r *Repository) bool {
+	return c.URL == r.URL && c.Branch == r.Branch
 }
 
 type Repository struct {
-	ID          int64       `json:"id" yaml:"id"`
-	Name        string      `json:"name" yaml:"name"`
-	URL         string      `json:"url" yaml:"url"`
-	Branch      string      `json:"branch" yaml:"branch"`
-	AgentID     string      `json:"agent_id" yaml:"agent_id"`
-	Path        string      `json:"path" yaml:"path"`
-	AutoDeploy  bool        `json:"auto_deploy" yaml:"auto_deploy"`
-	BuildSystem BuildSystem `json:"build_system" yaml:"build_system"`
-	BuildFile   string      `json:"build_file" yaml:"build_file"`
-	BuildCmd    string      `json:"build_cmd" yaml:"build_cmd"`
-	CreatedAt   time.Time   `json:"created_at" yaml:"created_at"`
+	ID              int64             `json:"id" yaml:"id"`
+	Name            string            `json:"name" yaml:"name"`
+	URL             string            `json:"url" yaml:"url"`
+	Branch          string            `json:"branch" yaml:"branch"`
+	Branches        []string          `json:"branches,omitempty" yaml:"branches,omitempty"`
+	TagPattern      string            `json:"tag_pattern,omitempty" yaml:"tag_pattern,omitempty"`
+	PathFilters     []string          `json:"path_filters,omitempty" yaml:"path_filters,omitempty"`
+	AgentID         string            `json:"agent_id" yaml:"agent_id"`
+	AgentSelector   string            `json:"agent_selector,omitempty" yaml:"agent_selector,omitempty"`
+	Path            string            `json:"path" yaml:"path"`
+	AutoDeploy      bool              `json:"auto_deploy" yaml:"auto_deploy"`
+	VerifyBranch    bool              `json:"verify_branch,omitempty" yaml:"verify_branch,omitempty"`
+	RequireApproval bool              `json:"require_approval,omitempty" yaml:"require_approval,omitempty"`
+	Schedule        string            `json:"schedule,omitempty" yaml:"schedule,omitempty"`
+	BuildSystem     BuildSystem       `json:"build_system" yaml:"build_system"`
+	BuildFile       string            `json:"build_file" yaml:"build_file"`
+	BuildCmd        string            `json:"build_cmd" yaml:"build_cmd"`
+	Image           string            `json:"image,omitempty" yaml:"image,omitempty"`
+	Container       string            `json:"container,omitempty" yaml:"container,omitempty"`
+	Ports           []string          `json:"ports,omitempty" yaml:"ports,omitempty"`
+	NoCache         bool              `json:"no_cache,omitempty" yaml:"no_cache,omitempty"`
+	Builder         string            `json:"builder,omitempty" yaml:"builder,omitempty"`
+	PreDeploy       string            `json:"pre_deploy,omitempty" yaml:"pre_deploy,omitempty"`
+	PostDeploy      string            `json:"post_deploy,omitempty" yaml:"post_deploy,omitempty"`
+	Strategy        string            `json:"strategy,omitempty" yaml:"strategy,omitempty"`
+	KeepReleases    int               `json:"keep_releases,omitempty" yaml:"keep_releases,omitempty"`
+	Secret          string            `json:"-" yaml:"secret,omitempty"`
+	Credential      string            `json:"credential,omitempty" yaml:"credential,omitempty"`
+	HealthCheck     *HealthCheck      `json:"health_check,omitempty" yaml:"health_check,omitempty"`
+	CommitStatus    *CommitStatus     `json:"commit_status,omitempty" yaml:"commit_status,omitempty"`
+	Env             map[string]string `json:"-" yaml:"env,omitempty"`
+	Drift           []string          `json:"drift,omitempty" yaml:"-"`
+	CreatedAt       time.Time         `json:"created_at" yaml:"created_at"`
+}
+
+type HealthCheck struct {
+	Container   string `json:"container,omitempty" yaml:"container,omitempty"`
+	URL         string `json:"url,omitempty" yaml:"url,omitempty"`
+	TimeoutSec  int    `json:"timeout_sec,omitempty" yaml:"timeout_sec,omitempty"`
+	IntervalSec int    `json:"interval_sec,omitempty" yaml:"interval_sec,omitempty"`
 }
 
 type Command struct {
@@ -116,30 +215,111 @@ type Command struct {
 }
 
 type Alert struct {
-	ID         string        `json:"id" yaml:"id"`
-	AgentID    string        `json:"agent_id" yaml:"agent_id"`
-	AgentName  string        `json:"agent_name" yaml:"agent_name"`
-	Type       string        `json:"type" yaml:"type"`
-	Message    string        `json:"message" yaml:"message"`
-	Severity   AlertSeverity `json:"severity" yaml:"severity"`
-	Resolved   bool          `json:"resolved" yaml:"resolved"`
-	CreatedAt  time.Time     `json:"created_at" yaml:"created_at"`
-	ResolvedAt *time.Time    `json:"resolved_at,omitempty" yaml:"resolved_at,omitempty"`
+	ID           string        `json:"id" yaml:"id"`
+	AgentID      string        `json:"agent_id" yaml:"agent_id"`
+	AgentName    string        `json:"agent_name" yaml:"agent_name"`
+	Type         string        `json:"type" yaml:"type"`
+	Message      string        `json:"message" yaml:"message"`
+	Severity     AlertSeverity `json:"severity" yaml:"severity"`
+	Resolved     bool          `json:"resolved" yaml:"resolved"`
+	AutoResolved bool          `json:"auto_resolved,omitempty" yaml:"auto_resolved,omitempty"`
+	CreatedAt    time.Time     `json:"created_at" yaml:"created_at"`
+	ResolvedAt   *time.Time    `json:"resolved_at,omitempty" yaml:"resolved_at,omitempty"`
 }
 
 type Deployment struct {
-	ID         string       `json:"id" yaml:"id"`
-	Repository string       `json:"repository" yaml:"repository"`
-	Branch     string       `json:"branch" yaml:"branch"`
-	Commit     string       `json:"commit" yaml:"commit"`
-	AgentID    string       `json:"agent_id" yaml:"agent_id"`
-	AgentName  string       `json:"agent_name" yaml:"agent_name"`
-	Status     DeployStatus `json:"status" yaml:"status"`
-	Output     string       `json:"output,omitempty" yaml:"output,omitempty"`
-	Duration   int64        `json:"duration" yaml:"duration"`
-	StartedAt  time.Time    `json:"started_at" yaml:"started_at"`
-	EndedAt    *time.Time   `json:"ended_at,omitempty" yaml:"ended_at,omitempty"`
-	Trigger    string       `json:"trigger" yaml:"trigger"`
+	ID            string       `json:"id" yaml:"id"`
+	Repository    string       `json:"repository" yaml:"repository"`
+	Branch        string       `json:"branch" yaml:"branch"`
+	Commit        string       `json:"commit" yaml:"commit"`
+	AgentID       string       `json:"agent_id" yaml:"agent_id"`
+	AgentName     string       `json:"agent_name" yaml:"agent_name"`
+	Status        DeployStatus `json:"status" yaml:"status"`
+	StatusDetail  string       `json:"status_detail,omitempty" yaml:"status_detail,omitempty"`
+	Output        string       `json:"output,omitempty" yaml:"output,omitempty"`
+	Duration      int64        `json:"duration" yaml:"duration"`
+	StartedAt     time.Time    `json:"started_at" yaml:"started_at"`
+	EndedAt       *time.Time   `json:"ended_at,omitempty" yaml:"ended_at,omitempty"`
+	Trigger       string       `json:"trigger" yaml:"trigger"`
+	ConfigHash    string       `json:"config_hash,omitempty" yaml:"config_hash,omitempty"`
+	RollbackOf    string       `json:"rollback_of,omitempty" yaml:"rollback_of,omitempty"`
+	ChangeSummary string       `json:"change_summary,omitempty" yaml:"change_summary,omitempty"`
+	TriggeredBy   string       `json:"triggered_by,omitempty" yaml:"triggered_by,omitempty"`
+	Note          string       `json:"note,omitempty" yaml:"note,omitempty"`
+	SourceIP      string       `json:"source_ip,omitempty" yaml:"source_ip,omitempty"`
+	Tag           string       `json:"tag,omitempty" yaml:"tag,omitempty"`
+	DryRun        bool         `json:"dry_run,omitempty" yaml:"dry_run,omitempty"`
+	ExitCode      *int         `json:"exit_code,omitempty" yaml:"exit_code,omitempty"`
+	BuildFile     string       `json:"build_file,omitempty" yaml:"build_file,omitempty"`
+
+	ImageUnchanged bool `json:"image_unchanged,omitempty" yaml:"image_unchanged,omitempty"`
+}
+
+type DeployedContainer struct {
+	DeploymentID string `json:"deployment_id"`
+	Name         string `json:"name"`
+	Service      string `json:"service,omitempty"`
+	Image        string `json:"image"`
+	ImageID      string `json:"image_id"`
+}
+
+type DeploymentArtifact struct {
+	DeploymentID string    `json:"deployment_id"`
+	Name         string    `json:"name"`
+	Content      string    `json:"content,omitempty"`
+	Size         int       `json:"size"`
+	Truncated    bool      `json:"truncated"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+func SameImages(a, b []DeployedContainer) bool {
+	if len(a) == 0 || len(a) != len(b) {
+		return false
+	}
+	ids := make(map[string]string, len(a))
+	for _, c := range a {
+		ids[c.Name] = c.ImageID
+	}
+	for _, c := range b {
+		if id, ok := ids[c.Name]; !ok || id != c.ImageID {
+			return false
+		}
+	}
+	return true
+}
+
+type WebhookEvent struct {
+	ID        int64     `json:"id"`
+	Source    string    `json:"source"`
+	Repo      string    `json:"repo"`
+	Branch    string    `json:"branch"`
+	Commit    string    `json:"commit"`
+	Outcome   string    `json:"outcome"`
+	Reason    string    `json:"reason,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+const (
+	AgentEventConnect    = "connect"
+	AgentEventDisconnect = "disconnect"
+	AgentEventAuthFailed = "auth_failed"
+)
+
+type AgentEvent struct {
+	ID        int64     `json:"id"`
+	AgentID   string    `json:"agent_id"`
+	Type      string    `json:"type"`
+	Detail    string    `json:"detail,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type DeploymentStep struct {
+	ID           int64     `json:"id"`
+	DeploymentID string    `json:"deployment_id"`
+	Name         string    `json:"name"`
+	Status       string    `json:"status"`
+	StartedAt    time.Time `json:"started_at"`
+	Duration     int64     `json:"duration"`
 }
 
 type DeploymentLog struct {
@@ -0,0 +1,63 @@
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+
+package models
+
+import "testing"
+
+func TestMatchPath(t *testing.T) {
+	for _, tc := range []struct {
+		pattern string
+		name    string
+		want    bool
+	}{
+		{"services/api", "services/api/main.go", true},
+		{"services/api", "services/api-gateway/main.go", false},
+		{"services/api/", "services/api/internal/db/db.go", true},
+		{"services/api/", "services/api", false},
+		{"/docker-compose.yml", "docker-compose.yml", true},
+		{"*.go", "main.go", true},
+		{"*.go", "cmd/main.go", false},
+		{"**/*.go", "cmd/server/main.go", true},
+		{"**/*.go", "main.go", true},
+		{"docs/**", "docs/guide/install.md", true},
+		{"docs/**", "README.md", false},
+		{"services/**/Dockerfile", "services/Dockerfile", true},
+		{"services/**/Dockerfile", "services/api/build/Dockerfile", true},
+		{"services/**/Dockerfile", "services/api/Dockerfile.dev", false},
+		{"**", "anything/at/all", true},
+	} {
+		if got := MatchPath(tc.pattern, tc.name); got != tc.want {
+			t.Errorf("MatchPath(%q, %q) = %v, want %v", tc.pattern, tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestMatchesPaths(t *testing.T) {
+	repo := &Repository{}
+	if !repo.MatchesPaths([]string{"README.md"}) {
+		t.Fatal("a repository without path filters skipped a push")
+	}
+	repo.PathFilters = []string{"services/api/", "go.mod"}
+	if repo.MatchesPaths([]string{"README.md", "docs/index.md"}) {
+		t.Fatal("a push touching no filtered path matched")
+	}
+	if !repo.MatchesPaths([]string{"README.md", "go.mod"}) {
+		t.Fatal("a push touching go.mod did not match")
+	}
+}
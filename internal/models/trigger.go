@@ -0,0 +1,37 @@
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+
+package models
+
+func (d *Deployment) Initiator() string {
+	if d.TriggeredBy == "" {
+		return "unknown"
+	}
+	return d.TriggeredBy
+}
+
+func (d *Deployment) TriggeredByLabel() string {
+	label := d.Initiator()
+	if d.Note != "" {
+		label += " (" + d.Note + ")"
+	}
+	if d.SourceIP != "" {
+		label += " from " + d.SourceIP
+	}
+	return label
+}
@@ -0,0 +1,36 @@
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+
+package models
+
+import "testing"
+
+func TestTriggeredByLabel(t *testing.T) {
+	for _, tc := range []struct {
+		d    Deployment
+		want string
+	}{
+		{Deployment{}, "unknown"},
+		{Deployment{TriggeredBy: "octocat", SourceIP: "140.82.112.1"}, "octocat from 140.82.112.1"},
+		{Deployment{TriggeredBy: "api", Note: "alice", SourceIP: "10.0.0.7"}, "api (alice) from 10.0.0.7"},
+	} {
+		if got := tc.d.TriggeredByLabel(); got != tc.want {
+			t.Errorf("TriggeredByLabel() = %q, want %q", got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+
+package services
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/urustack/uruflow/internal/config"
+	"github.com/urustack/uruflow/internal/storage"
+	"github.com/urustack/uruflow/pkg/logger"
+)
+
+const maxAgentName = 30
+
+type AgentInUseError struct {
+	Agent        string
+	Repositories []string
+}
+
+func (e *AgentInUseError) Error() string {
+	return fmt.Sprintf("agent %s still deploys %s, reassign them first", e.Agent, strings.Join(e.Repositories, ", "))
+}
+
+type AgentService struct {
+	cfg     *config.Config
+	cfgPath string
+	store   storage.Store
+}
+
+func NewAgentService(cfg *config.Config, cfgPath string, store storage.Store) *AgentService {
+	return &AgentService{cfg: cfg, cfgPath: cfgPath, store: store}
+}
+
+func (s *AgentService) RenameAgent(id, name string) error {
+	name = strings.TrimSpace(name)
+	if name == "" || len(name) > maxAgentName {
+		return fmt.Errorf("agent name must be 1-%d characters", maxAgentName)
+	}
+	agent := s.cfg.GetAgent(id)
+	if agent == nil {
+		return ErrAgentNotFound
+	}
+	previous := agent.Name
+	if previous == name {
+		return nil
+	}
+	if err := s.cfg.RenameAgent(id, name); err != nil {
+		return err
+	}
+	if err := s.cfg.Save(s.cfgPath); err != nil {
+		s.cfg.RenameAgent(id, previous)
+		return err
+	}
+	if err := s.store.RenameAgent(id, name); err != nil {
+		return fmt.Errorf("rename agent in store: %w", err)
+	}
+	logger.Info("[AGENT] Renamed %s from %s to %s", id, previous, name)
+	return nil
+}
+
+func (s *AgentService) DeleteAgent(id, reassignTo string) error {
+	agent := s.cfg.GetAgent(id)
+	if agent == nil {
+		return ErrAgentNotFound
+	}
+	name := agent.Name
+	repos := s.cfg.AgentRepositories(id)
+	if len(repos) > 0 {
+		if reassignTo == "" {
+			return &AgentInUseError{Agent: name, Repositories: repos}
+		}
+		if reassignTo == id || s.cfg.GetAgent(reassignTo) == nil {
+			return fmt.Errorf("reassign to %s: %w", reassignTo, ErrAgentNotFound)
+		}
+	}
+
+	snap := s.cfg.Snapshot()
+	if len(repos) > 0 {
+		s.cfg.ReassignRepositories(id, reassignTo)
+	}
+	s.cfg.RemoveAgent(id)
+	if err := s.cfg.Save(s.cfgPath); err != nil {
+		s.cfg.Restore(snap)
+		return err
+	}
+	if err := s.store.DeleteAgent(id, reassignTo); err != nil {
+		s.cfg.Restore(snap)
+		if serr := s.cfg.Save(s.cfgPath); serr != nil {
+			logger.Error("[AGENT] Failed to restore config after store error: %v", serr)
+		}
+		return fmt.Errorf("delete agent from store: %w", err)
+	}
+	if len(repos) > 0 {
+		logger.Info("[AGENT] Reassigned %s from %s to %s", strings.Join(repos, ", "), name, reassignTo)
+	}
+	logger.Info("[AGENT] Removed %s (%s)", name, id)
+	return nil
+}
@@ -0,0 +1,168 @@
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+
+package services
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/urustack/uruflow/internal/config"
+	"github.com/urustack/uruflow/internal/models"
+	"github.com/urustack/uruflow/internal/storage"
+	"github.com/urustack/uruflow/internal/storage/sqlite"
+)
+
+type agentFixture struct {
+	cfg     *config.Config
+	cfgPath string
+	store   storage.Store
+	web     string
+	db      string
+}
+
+func newAgentFixture(t *testing.T) *agentFixture {
+	t.Helper()
+	dir := t.TempDir()
+	store, err := sqlite.New(dir)
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	f := &agentFixture{cfg: config.Default(), cfgPath: filepath.Join(dir, "config.yaml"), store: store}
+	for _, name := range []string{"web", "db"} {
+		id, _, err := f.cfg.AddAgent(name)
+		if err != nil {
+			t.Fatalf("add agent %s: %v", name, err)
+		}
+		if err := store.CreateAgent(&models.Agent{ID: id, Name: name, Status: models.AgentOffline}); err != nil {
+			t.Fatalf("create agent %s: %v", name, err)
+		}
+		if name == "web" {
+			f.web = id
+		} else {
+			f.db = id
+		}
+	}
+
+	repo := models.Repository{Name: "api", URL: "https://github.com/acme/api.git", Branch: "main", AgentID: f.web, BuildSystem: "compose"}
+	if err := f.cfg.AddRepository(repo); err != nil {
+		t.Fatalf("add repository: %v", err)
+	}
+	if err := store.CreateRepository(&repo); err != nil {
+		t.Fatalf("create repository: %v", err)
+	}
+	if err := store.CreateDeployment(&models.Deployment{
+		ID: "d1", Repository: "api", Branch: "main", AgentID: f.web, Status: models.DeploySuccess, StartedAt: time.Now(),
+	}); err != nil {
+		t.Fatalf("create deployment: %v", err)
+	}
+	if err := f.cfg.Save(f.cfgPath); err != nil {
+		t.Fatalf("save config: %v", err)
+	}
+	return f
+}
+
+func TestDeleteAgentBlockedByRepositories(t *testing.T) {
+	f := newAgentFixture(t)
+	svc := NewAgentService(f.cfg, f.cfgPath, f.store)
+
+	err := svc.DeleteAgent(f.web, "")
+	var inUse *AgentInUseError
+	if !errors.As(err, &inUse) {
+		t.Fatalf("DeleteAgent = %v, want AgentInUseError", err)
+	}
+	if len(inUse.Repositories) != 1 || inUse.Repositories[0] != "api" {
+		t.Fatalf("repositories = %v, want [api]", inUse.Repositories)
+	}
+	if f.cfg.GetAgent(f.web) == nil {
+		t.Fatal("agent removed from config")
+	}
+	if a, _ := f.store.GetAgent(f.web); a == nil {
+		t.Fatal("agent removed from store")
+	}
+
+	if err := svc.DeleteAgent(f.web, f.web); !errors.Is(err, ErrAgentNotFound) {
+		t.Fatalf("reassign to itself = %v, want ErrAgentNotFound", err)
+	}
+}
+
+func TestDeleteAgentReassignsRepositories(t *testing.T) {
+	f := newAgentFixture(t)
+	svc := NewAgentService(f.cfg, f.cfgPath, f.store)
+
+	if err := svc.DeleteAgent(f.web, f.db); err != nil {
+		t.Fatalf("DeleteAgent: %v", err)
+	}
+
+	if f.cfg.GetAgent(f.web) != nil {
+		t.Fatal("agent still in config")
+	}
+	if got := f.cfg.GetRepository("api").AgentID; got != f.db {
+		t.Fatalf("config repository agent = %s, want %s", got, f.db)
+	}
+	repo, err := f.store.GetRepository("api")
+	if err != nil || repo == nil {
+		t.Fatalf("GetRepository: %v", err)
+	}
+	if repo.AgentID != f.db {
+		t.Fatalf("stored repository agent = %s, want %s", repo.AgentID, f.db)
+	}
+	if a, _ := f.store.GetAgent(f.web); a != nil {
+		t.Fatal("agent still in store")
+	}
+
+	saved, err := config.Load(f.cfgPath)
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+	if saved.GetAgent(f.web) != nil || saved.GetRepository("api").AgentID != f.db {
+		t.Fatal("saved config does not reflect the reassignment")
+	}
+}
+
+type failingDeleteStore struct {
+	storage.Store
+}
+
+func (failingDeleteStore) DeleteAgent(id, reassignTo string) error {
+	return errors.New("database is locked")
+}
+
+func TestDeleteAgentRestoresConfigOnStoreFailure(t *testing.T) {
+	f := newAgentFixture(t)
+	svc := NewAgentService(f.cfg, f.cfgPath, failingDeleteStore{f.store})
+
+	if err := svc.DeleteAgent(f.web, f.db); err == nil {
+		t.Fatal("DeleteAgent succeeded with a failing store")
+	}
+
+	if f.cfg.GetAgent(f.web) == nil || f.cfg.GetRepository("api").AgentID != f.web {
+		t.Fatal("in-memory config not restored")
+	}
+	saved, err := config.Load(f.cfgPath)
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+	if saved.GetAgent(f.web) == nil || saved.GetRepository("api").AgentID != f.web {
+		t.Fatal("saved config not restored")
+	}
+}
@@ -0,0 +1,140 @@
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/urustack/uruflow/internal/config"
+	"github.com/urustack/uruflow/internal/models"
+	"github.com/urustack/uruflow/pkg/helper"
+)
+
+var ErrNoAPIToken = errors.New("set server.api_token to deploy from an attached TUI")
+
+type ServerClient struct {
+	base   string
+	token  string
+	client *http.Client
+}
+
+func NewServerClient(cfg *config.Config) (*ServerClient, error) {
+	if cfg.Server.APIToken == "" {
+		return nil, ErrNoAPIToken
+	}
+	addrs, err := cfg.HTTPListenAddrs()
+	if err != nil {
+		return nil, err
+	}
+	host, port, err := net.SplitHostPort(addrs[0])
+	if err != nil {
+		return nil, err
+	}
+	if ip := net.ParseIP(host); host == "" || (ip != nil && ip.IsUnspecified()) {
+		host = "127.0.0.1"
+		if ip != nil && ip.To4() == nil {
+			host = "::1"
+		}
+	}
+	return &ServerClient{
+		base:   "http://" + net.JoinHostPort(host, port) + "/api/v1",
+		token:  cfg.Server.APIToken,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}, nil
+}
+
+func (c *ServerClient) TriggerDeploy(agentID, repoName, branch, commit string, opts TriggerOptions) (*models.Deployment, error) {
+	body := map[string]interface{}{
+		"repository":   repoName,
+		"branch":       branch,
+		"commit":       commit,
+		"trigger":      opts.Trigger,
+		"triggered_by": opts.TriggeredBy,
+		"dry_run":      opts.DryRun,
+	}
+	if agentID != "" {
+		body["agent_id"] = agentID
+	}
+	return c.deployment("/deployments", body)
+}
+
+func (c *ServerClient) Rollback(deploymentID string) (*models.Deployment, error) {
+	return c.deployment("/deployments/"+url.PathEscape(deploymentID)+"/rollback", nil)
+}
+
+func (c *ServerClient) Approve(deploymentID, operator string) (*models.Deployment, error) {
+	return c.deployment("/deployments/"+url.PathEscape(deploymentID)+"/approve", map[string]string{"operator": operator})
+}
+
+func (c *ServerClient) Reject(deploymentID, operator string) (*models.Deployment, error) {
+	return c.deployment("/deployments/"+url.PathEscape(deploymentID)+"/reject", map[string]string{"operator": operator})
+}
+
+func (c *ServerClient) RequestInventory(agentID string) error {
+	return c.post("/agents/"+url.PathEscape(agentID)+"/inventory", nil, nil)
+}
+
+func (c *ServerClient) deployment(path string, body interface{}) (*models.Deployment, error) {
+	deploy := &models.Deployment{}
+	if err := c.post(path, body, deploy); err != nil {
+		return nil, err
+	}
+	return deploy, nil
+}
+
+func (c *ServerClient) post(path string, body, out interface{}) error {
+	var payload bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&payload).Encode(body); err != nil {
+			return err
+		}
+	}
+	req, err := http.NewRequest(http.MethodPost, c.base+path, &payload)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("reach the running server: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		var apiErr helper.ErrorResponse
+		json.NewDecoder(resp.Body).Decode(&apiErr)
+		if apiErr.Error == "" {
+			apiErr.Error = resp.Status
+		}
+		return fmt.Errorf("server refused: %s", apiErr.Error)
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
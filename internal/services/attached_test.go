@@ -0,0 +1,90 @@
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+
+package services
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/urustack/uruflow/internal/config"
+	"github.com/urustack/uruflow/internal/models"
+)
+
+func TestServerClientTriggerDeploy(t *testing.T) {
+	var body map[string]interface{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/api/v1/deployments" {
+			t.Errorf("request = %s %s", r.Method, r.URL.Path)
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer secret" {
+			t.Errorf("authorization = %q", got)
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(models.Deployment{ID: "d1", Repository: "api", Status: models.DeployPending})
+	}))
+	defer ts.Close()
+
+	c := &ServerClient{base: ts.URL + "/api/v1", token: "secret", client: ts.Client()}
+	deploy, err := c.TriggerDeploy("agent-2", "api", "main", "HEAD", TriggerOptions{Trigger: "manual", TriggeredBy: "alice", DryRun: true})
+	if err != nil {
+		t.Fatalf("TriggerDeploy: %v", err)
+	}
+	if deploy.ID != "d1" {
+		t.Fatalf("deployment id = %q, want d1", deploy.ID)
+	}
+	if body["repository"] != "api" || body["trigger"] != "manual" || body["agent_id"] != "agent-2" ||
+		body["triggered_by"] != "alice" || body["dry_run"] != true {
+		t.Fatalf("body = %v", body)
+	}
+}
+
+func TestServerClientReportsServerErrors(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusConflict)
+		w.Write([]byte(`{"error":"agent is offline"}`))
+	}))
+	defer ts.Close()
+
+	c := &ServerClient{base: ts.URL + "/api/v1", token: "secret", client: ts.Client()}
+	_, err := c.Approve("d1", "alice")
+	if err == nil || !strings.Contains(err.Error(), "agent is offline") {
+		t.Fatalf("Approve = %v, want the server's error", err)
+	}
+}
+
+func TestNewServerClientUsesLoopback(t *testing.T) {
+	cfg := config.Default()
+	if _, err := NewServerClient(cfg); err != ErrNoAPIToken {
+		t.Fatalf("NewServerClient without token = %v, want ErrNoAPIToken", err)
+	}
+
+	cfg.Server.APIToken = "secret"
+	c, err := NewServerClient(cfg)
+	if err != nil {
+		t.Fatalf("NewServerClient: %v", err)
+	}
+	if c.base != "http://127.0.0.1:9000/api/v1" {
+		t.Fatalf("base = %q", c.base)
+	}
+}
@@ -0,0 +1,230 @@
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/urustack/uruflow/internal/config"
+	"github.com/urustack/uruflow/internal/models"
+	"github.com/urustack/uruflow/pkg/helper"
+	"github.com/urustack/uruflow/pkg/logger"
+)
+
+const (
+	StatusContext = "uruflow/deploy"
+
+	statusQueueSize = 100
+	statusTimeout   = 10 * time.Second
+)
+
+const (
+	statusPending  = "pending"
+	statusRunning  = "running"
+	statusSuccess  = "success"
+	statusFailure  = "failure"
+	statusCanceled = "canceled"
+)
+
+type commitStatusUpdate struct {
+	repo        models.Repository
+	commit      string
+	state       string
+	description string
+}
+
+type CommitStatusReporter struct {
+	cfg    *config.Config
+	client *http.Client
+	queue  chan commitStatusUpdate
+	done   chan struct{}
+}
+
+func NewCommitStatusReporter(cfg *config.Config) *CommitStatusReporter {
+	r := &CommitStatusReporter{
+		cfg:    cfg,
+		client: &http.Client{Timeout: statusTimeout},
+		queue:  make(chan commitStatusUpdate, statusQueueSize),
+		done:   make(chan struct{}),
+	}
+	go r.run()
+	return r
+}
+
+func (r *CommitStatusReporter) Stop() {
+	select {
+	case <-r.done:
+	default:
+		close(r.done)
+	}
+}
+
+func (r *CommitStatusReporter) DeploymentCreated(d *models.Deployment) {
+	if d.Status == models.DeployAwaitingApproval {
+		r.report(d, statusPending, "Awaiting approval")
+		return
+	}
+	r.report(d, statusRunning, "Deploying to "+d.AgentName)
+}
+
+func (r *CommitStatusReporter) DeploymentFinished(d *models.Deployment) {
+	switch d.Status {
+	case models.DeploySuccess:
+		desc := "Deployed to " + d.AgentName
+		if d.Duration > 0 {
+			desc += " in " + (time.Duration(d.Duration) * time.Millisecond).Round(time.Second).String()
+		}
+		r.report(d, statusSuccess, desc)
+	case models.DeployWarning:
+		r.report(d, statusSuccess, "Deployed to "+d.AgentName+" with warnings: "+d.Output)
+	case models.DeployRejected:
+		r.report(d, statusCanceled, "Rejected: "+d.Output)
+	default:
+		r.report(d, statusFailure, "Deployment failed on "+d.AgentName)
+	}
+}
+
+func (r *CommitStatusReporter) report(d *models.Deployment, state, description string) {
+	if r == nil || d.DryRun || (d.Trigger != "webhook" && d.Trigger != "tag") || d.Commit == "" || d.Commit == "HEAD" {
+		return
+	}
+	repo := r.cfg.GetRepository(d.Repository)
+	if repo == nil || repo.CommitStatus == nil {
+		return
+	}
+
+	select {
+	case r.queue <- commitStatusUpdate{repo: *repo, commit: d.Commit, state: state, description: description}:
+	default:
+		logger.Warn("[STATUS] queue full, dropping %s status for %s@%s", state, d.Repository, shortCommit(d.Commit))
+	}
+}
+
+func (r *CommitStatusReporter) run() {
+	for {
+		select {
+		case <-r.done:
+			return
+		case update := <-r.queue:
+			if err := r.post(update); err != nil {
+				logger.Error("[STATUS] failed to post %s status for %s@%s: %v",
+					update.state, update.repo.Name, shortCommit(update.commit), err)
+				continue
+			}
+			logger.Debug("[STATUS] posted %s status for %s@%s", update.state, update.repo.Name, shortCommit(update.commit))
+		}
+	}
+}
+
+func (r *CommitStatusReporter) post(update commitStatusUpdate) error {
+	cs := update.repo.CommitStatus
+	token, err := cs.Token()
+	if err != nil {
+		return err
+	}
+	project := cs.ProjectPath(update.repo.URL)
+	if project == "" {
+		return fmt.Errorf("cannot derive project from %s, set commit_status.project", update.repo.URL)
+	}
+
+	endpoint, body := githubStatus(cs.BaseURL(), project, update, r.targetURL())
+	if cs.Provider == models.ProviderGitLab {
+		endpoint, body = gitlabStatus(cs.BaseURL(), project, update, r.targetURL())
+	}
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "uruflow")
+	if cs.Provider == models.ProviderGitLab {
+		req.Header.Set("PRIVATE-TOKEN", token)
+	} else {
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("Accept", "application/vnd.github+json")
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("unexpected status %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+func (r *CommitStatusReporter) targetURL() string {
+	if r.cfg.Server.PublicURL == "" || !r.cfg.Server.StatusPage {
+		return ""
+	}
+	return strings.TrimRight(r.cfg.Server.PublicURL, "/") + "/status"
+}
+
+func githubStatus(base, project string, update commitStatusUpdate, target string) (string, map[string]string) {
+	state := update.state
+	switch state {
+	case statusRunning:
+		state = "pending"
+	case statusCanceled:
+		state = "error"
+	}
+
+	body := map[string]string{
+		"state":       state,
+		"description": helper.TruncateString(update.description, 140),
+		"context":     StatusContext,
+	}
+	if target != "" {
+		body["target_url"] = target
+	}
+	return fmt.Sprintf("%s/repos/%s/statuses/%s", base, project, update.commit), body
+}
+
+func gitlabStatus(base, project string, update commitStatusUpdate, target string) (string, map[string]string) {
+	state := update.state
+	if state == statusFailure {
+		state = "failed"
+	}
+
+	body := map[string]string{
+		"state":       state,
+		"description": helper.TruncateString(update.description, 255),
+		"name":        StatusContext,
+	}
+	if target != "" {
+		body["target_url"] = target
+	}
+	return fmt.Sprintf("%s/projects/%s/statuses/%s", base, url.PathEscape(project), update.commit), body
+}
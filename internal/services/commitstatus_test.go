@@ -0,0 +1,179 @@
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+
+package services
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/urustack/uruflow/internal/config"
+	"github.com/urustack/uruflow/internal/models"
+)
+
+type postedStatus struct {
+	path   string
+	header http.Header
+	body   map[string]string
+}
+
+func newStatusServer(t *testing.T, code int) (*httptest.Server, chan postedStatus) {
+	t.Helper()
+	posted := make(chan postedStatus, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]string
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("decode status body: %v", err)
+		}
+		posted <- postedStatus{path: r.URL.EscapedPath(), header: r.Header.Clone(), body: body}
+		w.WriteHeader(code)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, posted
+}
+
+func newStatusReporter(t *testing.T, provider, baseURL string) *CommitStatusReporter {
+	t.Helper()
+	t.Setenv("URUFLOW_TEST_STATUS_TOKEN", "s3cret")
+	cfg := config.Default()
+	cfg.Server.PublicURL = "https://deploy.example.com/"
+	cfg.Server.StatusPage = true
+	repo := models.Repository{
+		Name: "api", URL: "git@github.com:acme/api.git", Branch: "main", AgentID: "agent-1", BuildSystem: "compose",
+		CommitStatus: &models.CommitStatus{Provider: provider, APIBaseURL: baseURL, TokenEnv: "URUFLOW_TEST_STATUS_TOKEN"},
+	}
+	if err := cfg.AddRepository(repo); err != nil {
+		t.Fatal(err)
+	}
+	r := NewCommitStatusReporter(cfg)
+	t.Cleanup(r.Stop)
+	return r
+}
+
+func statusDeployment(status models.DeployStatus) *models.Deployment {
+	return &models.Deployment{
+		ID: "d1", Repository: "api", Branch: "main", Commit: "9f2c1e4b7a0d3c5e8f1a2b3c4d5e6f7a8b9c0d1e",
+		AgentName: "web", Status: status, Trigger: "webhook", Duration: 42_300,
+	}
+}
+
+func waitStatus(t *testing.T, posted chan postedStatus) postedStatus {
+	t.Helper()
+	select {
+	case p := <-posted:
+		return p
+	case <-time.After(5 * time.Second):
+		t.Fatal("no commit status posted")
+		return postedStatus{}
+	}
+}
+
+func TestGitHubCommitStatus(t *testing.T) {
+	srv, posted := newStatusServer(t, http.StatusCreated)
+	r := newStatusReporter(t, models.ProviderGitHub, srv.URL)
+
+	r.DeploymentCreated(statusDeployment(models.DeployPending))
+	p := waitStatus(t, posted)
+	if p.path != "/repos/acme/api/statuses/9f2c1e4b7a0d3c5e8f1a2b3c4d5e6f7a8b9c0d1e" {
+		t.Fatalf("path = %s", p.path)
+	}
+	if got := p.header.Get("Authorization"); got != "Bearer s3cret" {
+		t.Fatalf("Authorization = %q", got)
+	}
+	want := map[string]string{
+		"state": "pending", "description": "Deploying to web", "context": StatusContext,
+		"target_url": "https://deploy.example.com/status",
+	}
+	for k, v := range want {
+		if p.body[k] != v {
+			t.Fatalf("%s = %q, want %q (body %v)", k, p.body[k], v, p.body)
+		}
+	}
+
+	r.DeploymentFinished(statusDeployment(models.DeploySuccess))
+	if p := waitStatus(t, posted); p.body["state"] != "success" || p.body["description"] != "Deployed to web in 42s" {
+		t.Fatalf("success body = %v", p.body)
+	}
+	r.DeploymentFinished(statusDeployment(models.DeployRejected))
+	if p := waitStatus(t, posted); p.body["state"] != "error" {
+		t.Fatalf("rejected state = %q, want error", p.body["state"])
+	}
+}
+
+func TestGitLabCommitStatus(t *testing.T) {
+	srv, posted := newStatusServer(t, http.StatusCreated)
+	r := newStatusReporter(t, models.ProviderGitLab, srv.URL)
+
+	r.DeploymentFinished(statusDeployment(models.DeployFailed))
+	p := waitStatus(t, posted)
+	if p.path != "/projects/acme%2Fapi/statuses/9f2c1e4b7a0d3c5e8f1a2b3c4d5e6f7a8b9c0d1e" {
+		t.Fatalf("path = %s", p.path)
+	}
+	if got := p.header.Get("PRIVATE-TOKEN"); got != "s3cret" {
+		t.Fatalf("PRIVATE-TOKEN = %q", got)
+	}
+	if p.body["state"] != "failed" || p.body["name"] != StatusContext {
+		t.Fatalf("body = %v", p.body)
+	}
+}
+
+func TestCommitStatusSkipsUntrackedDeployments(t *testing.T) {
+	srv, posted := newStatusServer(t, http.StatusCreated)
+	r := newStatusReporter(t, models.ProviderGitHub, srv.URL)
+
+	manual := statusDeployment(models.DeployPending)
+	manual.Trigger = "manual"
+	dry := statusDeployment(models.DeployPending)
+	dry.DryRun = true
+	head := statusDeployment(models.DeployPending)
+	head.Commit = "HEAD"
+	for _, d := range []*models.Deployment{manual, dry, head} {
+		r.DeploymentCreated(d)
+	}
+
+	tag := statusDeployment(models.DeployPending)
+	tag.Trigger = "tag"
+	tag.Commit = "1a2b3c4d5e6f7a8b9c0d1e2f3a4b5c6d7e8f9a0b"
+	r.DeploymentCreated(tag)
+	if p := waitStatus(t, posted); p.path != "/repos/acme/api/statuses/"+tag.Commit {
+		t.Fatalf("first posted status = %s, want only the tag deployment", p.path)
+	}
+}
+
+func TestCommitStatusReportsProviderErrors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		w.Write([]byte(`{"message":"No commit found for SHA: 9f2c1e4"}`))
+	}))
+	defer srv.Close()
+	r := newStatusReporter(t, models.ProviderGitHub, srv.URL)
+	repo := r.cfg.GetRepository("api")
+
+	err := r.post(commitStatusUpdate{repo: *repo, commit: "9f2c1e4", state: statusSuccess})
+	if err == nil || err.Error() != `unexpected status 422: {"message":"No commit found for SHA: 9f2c1e4"}` {
+		t.Fatalf("post = %v", err)
+	}
+
+	t.Setenv("URUFLOW_TEST_STATUS_TOKEN", "")
+	if err := r.post(commitStatusUpdate{repo: *repo, commit: "9f2c1e4", state: statusSuccess}); err == nil {
+		t.Fatal("post with an empty token succeeded")
+	}
+}
@@ -0,0 +1,147 @@
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+
+package services
+
+import (
+	"time"
+
+	"github.com/urustack/uruflow/pkg/logger"
+)
+
+type pendingPush struct {
+	source   string
+	repo     string
+	branch   string
+	commit   string
+	pusher   string
+	sourceIP string
+}
+
+type cooldown struct {
+	last    time.Time
+	pending *pendingPush
+	timer   *time.Timer
+}
+
+func (s *WebhookService) ClaimDelivery(source, id string) bool {
+	if id == "" || s.cfg.Webhook.ReplayWindowMin < 0 {
+		return true
+	}
+	window := time.Duration(s.cfg.Webhook.ReplayWindowMin) * time.Minute
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if now.Sub(s.swept) > time.Minute {
+		s.swept = now
+		for key, seen := range s.deliveries {
+			if now.Sub(seen) > window {
+				delete(s.deliveries, key)
+			}
+		}
+	}
+
+	key := source + ":" + id
+	if seen, ok := s.deliveries[key]; ok && now.Sub(seen) <= window {
+		return false
+	}
+	s.deliveries[key] = now
+	return true
+}
+
+func (s *WebhookService) ReleaseDelivery(source, id string) {
+	s.mu.Lock()
+	delete(s.deliveries, source+":"+id)
+	s.mu.Unlock()
+}
+
+func (s *WebhookService) coalesce(push pendingPush) (time.Time, bool) {
+	if s.cfg.Webhook.CooldownSec <= 0 {
+		return time.Time{}, false
+	}
+	window := time.Duration(s.cfg.Webhook.CooldownSec) * time.Second
+	key := push.repo + "@" + push.branch
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.cooldowns[key]
+	if !ok {
+		c = &cooldown{}
+		s.cooldowns[key] = c
+	}
+	if c.timer == nil && now.Sub(c.last) >= window {
+		c.last = now
+		return time.Time{}, false
+	}
+
+	c.pending = &push
+	deployAt := c.last.Add(window)
+	if c.timer == nil {
+		c.timer = time.AfterFunc(deployAt.Sub(now), func() { s.deployCoalesced(key) })
+	}
+	return deployAt, true
+}
+
+func (s *WebhookService) deployCoalesced(key string) {
+	s.mu.Lock()
+	c := s.cooldowns[key]
+	push := c.pending
+	c.pending, c.timer = nil, nil
+	c.last = time.Now()
+	s.mu.Unlock()
+
+	if push == nil {
+		return
+	}
+
+	result := &WebhookResult{Repository: push.repo, Branch: push.branch, Commit: shortCommit(push.commit)}
+	repo := s.cfg.GetRepository(push.repo)
+	if repo == nil {
+		s.RecordEvent(push.source, WebhookRejected, result, "repository removed during cooldown")
+		return
+	}
+
+	err := checkPush(repo, push.branch)
+	if err == nil {
+		err = s.deployPush(result, repo, push.branch, push.commit, push.pusher, push.sourceIP)
+	}
+	if err != nil {
+		logger.Error("[WEBHOOK] Coalesced deployment of %s failed: %v", push.repo, err)
+		s.RecordEvent(push.source, WebhookRejected, result, err.Error())
+		return
+	}
+
+	logger.Info("[WEBHOOK] Coalesced deployment triggered: repo=%s branch=%s commit=%s deployment_id=%s",
+		result.Repository, result.Branch, result.Commit, result.Deployment.ID)
+	s.RecordEvent(push.source, WebhookAccepted, result, "")
+}
+
+func (s *WebhookService) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, c := range s.cooldowns {
+		if c.timer != nil {
+			c.timer.Stop()
+			c.timer = nil
+		}
+	}
+}
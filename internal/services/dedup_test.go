@@ -0,0 +1,124 @@
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+
+package services
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/urustack/uruflow/internal/models"
+)
+
+func TestClaimDelivery(t *testing.T) {
+	s := newMonorepoWebhook(t)
+
+	if !s.ClaimDelivery("github", "72d3162e") {
+		t.Fatal("first delivery was rejected")
+	}
+	if s.ClaimDelivery("github", "72d3162e") {
+		t.Fatal("replayed delivery was accepted")
+	}
+	if !s.ClaimDelivery("gitlab", "72d3162e") {
+		t.Fatal("the same id from another source was rejected")
+	}
+	if !s.ClaimDelivery("github", "") || !s.ClaimDelivery("github", "") {
+		t.Fatal("deliveries without an id were rejected")
+	}
+
+	s.ReleaseDelivery("github", "72d3162e")
+	if !s.ClaimDelivery("github", "72d3162e") {
+		t.Fatal("released delivery was rejected on redelivery")
+	}
+
+	s.cfg.Webhook.ReplayWindowMin = -1
+	if !s.ClaimDelivery("github", "72d3162e") {
+		t.Fatal("replay protection is not disabled by a negative window")
+	}
+}
+
+func githubPushAt(t *testing.T, commit string) []byte {
+	t.Helper()
+	var payload map[string]any
+	if err := json.Unmarshal(githubPush(t, "services/api/handler.go"), &payload); err != nil {
+		t.Fatal(err)
+	}
+	payload["after"] = commit
+	payload["head_commit"].(map[string]any)["id"] = commit
+	data, err := json.Marshal(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestCooldownCoalescesPushes(t *testing.T) {
+	s := newMonorepoWebhook(t)
+	t.Cleanup(s.Stop)
+	s.cfg.Webhook.CooldownSec = 1
+	for i := range s.cfg.Repositories {
+		s.cfg.Repositories[i].RequireApproval = true
+	}
+
+	commits := []string{
+		"1111111111111111111111111111111111111111",
+		"2222222222222222222222222222222222222222",
+		"3333333333333333333333333333333333333333",
+	}
+	start := time.Now()
+	first, err := s.ProcessGitHubPush(githubPushAt(t, commits[0]), "140.82.112.1")
+	if err != nil || first.Coalesced || first.Deployment == nil {
+		t.Fatalf("first push = %+v, %v; want a deployment", first, err)
+	}
+	for _, commit := range commits[1:] {
+		result, err := s.ProcessGitHubPush(githubPushAt(t, commit), "140.82.112.1")
+		if err != nil {
+			t.Fatalf("push %s: %v", commit, err)
+		}
+		if !result.Coalesced || result.Deployment != nil {
+			t.Fatalf("push %s inside the cooldown = %+v, want it coalesced", commit, result)
+		}
+		if result.DeployAt.Before(start.Add(time.Second)) || result.DeployAt.After(time.Now().Add(time.Second)) {
+			t.Fatalf("DeployAt = %s, want about a second after the first push", result.DeployAt)
+		}
+	}
+
+	var deploys []models.Deployment
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		deploys, _ = s.store.GetDeploymentsByRepo("monorepo-api", 10)
+		if len(deploys) >= 2 {
+			break
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	if len(deploys) != 2 {
+		t.Fatalf("got %d deployments, want the first push and one coalesced deploy", len(deploys))
+	}
+	commitsDeployed := map[string]bool{deploys[0].Commit: true, deploys[1].Commit: true}
+	if !commitsDeployed[commits[0]] || !commitsDeployed[commits[2]] {
+		t.Fatalf("deployed commits = %v, want the first and the latest push", commitsDeployed)
+	}
+
+	s.cfg.Webhook.CooldownSec = 0
+	result, err := s.ProcessGitHubPush(githubPushAt(t, commits[1]), "140.82.112.1")
+	if err != nil || result.Coalesced {
+		t.Fatalf("push with the cooldown disabled = %+v, %v", result, err)
+	}
+}
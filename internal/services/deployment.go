@@ -34,6 +34,8 @@ type DeploymentService struct {
 	cfg       *config.Config
 	store     storage.Store
 	tcpServer *tcp.Server
+	statuses  *CommitStatusReporter
+	server    *ServerClient
 }
 
 func NewDeploymentService(cfg *config.Config, store storage.Store, tcpServer *tcp.Server) *DeploymentService {
@@ -44,19 +46,147 @@ func NewDeploymentService(cfg *config.Config, store storage.Store, tcpServer *tc
 	}
 }
 
-func (s *DeploymentService) TriggerDeploy(agentID, repoName, branch, commit, trigger string) (*models.Deployment, error) {
-	logger.Debug("[DEPLOY] Checking agent %s connection status", agentID)
+func (s *DeploymentService) SetStatusReporter(r *CommitStatusReporter) {
+	s.statuses = r
+}
 
-	if !s.tcpServer.IsAgentConnected(agentID) {
-		logger.Warn("[DEPLOY] Agent %s is offline, cannot deploy", agentID)
-		return nil, fmt.Errorf("agent %s is not connected: %w", agentID, ErrAgentNotConnected)
+func (s *DeploymentService) Attach(c *ServerClient) {
+	s.server = c
+}
+
+type TriggerOptions struct {
+	Trigger     string
+	TriggeredBy string
+	Note        string
+	SourceIP    string
+	Tag         string
+	DryRun      bool
+}
+
+func (s *DeploymentService) TriggerDeploy(agentID, repoName, branch, commit string, opts TriggerOptions) (*models.Deployment, error) {
+	if s.server != nil {
+		return s.server.TriggerDeploy(agentID, repoName, branch, commit, opts)
 	}
+	return s.triggerDeploy(agentID, repoName, branch, commit, opts, "")
+}
 
+func (s *DeploymentService) Rollback(deploymentID string) (*models.Deployment, error) {
+	if s.server != nil {
+		return s.server.Rollback(deploymentID)
+	}
+	source, err := s.store.GetDeployment(deploymentID)
+	if err != nil {
+		return nil, fmt.Errorf("load deployment %s: %w", deploymentID, err)
+	}
+	if source == nil {
+		return nil, fmt.Errorf("deployment %s: %w", deploymentID, ErrDeployNotFound)
+	}
+	if !source.Status.Succeeded() {
+		return nil, fmt.Errorf("deployment %s did not succeed: %w", deploymentID, ErrNoRollbackTarget)
+	}
+	if source.Commit == "" || source.Commit == "HEAD" {
+		return nil, fmt.Errorf("deployment %s has no recorded commit: %w", deploymentID, ErrNoRollbackTarget)
+	}
+
+	repo := s.cfg.GetRepository(source.Repository)
+	if repo == nil {
+		return nil, fmt.Errorf("repository %s: %w", source.Repository, ErrRepoNotFound)
+	}
+
+	logger.Info("[DEPLOY] Rolling back %s to %s (from deployment %s)",
+		repo.Name, shortCommit(source.Commit), source.ID)
+
+	agentID := repo.AgentID
+	if repo.AgentSelector != "" {
+		agentID = source.AgentID
+	}
+	opts := TriggerOptions{Trigger: "rollback", TriggeredBy: s.cfg.OperatorName(), Tag: source.Tag}
+	return s.triggerDeploy(agentID, repo.Name, source.Branch, source.Commit, opts, source.ID)
+}
+
+func (s *DeploymentService) ResolveAgent(repo *models.Repository) (string, error) {
+	if repo.AgentSelector == "" {
+		return repo.AgentID, nil
+	}
+
+	selector, err := models.ParseLabels(repo.AgentSelector)
+	if err != nil {
+		return "", fmt.Errorf("repository %s: %w", repo.Name, err)
+	}
+	if len(selector) == 0 {
+		return "", fmt.Errorf("repository %s has an empty agent selector", repo.Name)
+	}
+
+	matches := s.cfg.AgentsMatching(selector)
+	if len(matches) == 0 {
+		return "", fmt.Errorf("repository %s: %s: %w", repo.Name, repo.AgentSelector, ErrNoMatchingAgent)
+	}
+	for _, a := range matches {
+		if s.tcpServer.IsAgentConnected(a.ID) {
+			logger.Debug("[DEPLOY] Selector %s resolved to agent %s", repo.AgentSelector, a.Name)
+			return a.ID, nil
+		}
+	}
+	return "", fmt.Errorf("no agent matching %s is connected: %w", repo.AgentSelector, ErrAgentNotConnected)
+}
+
+func (s *DeploymentService) deployedAgent(repo *models.Repository) (string, error) {
+	if repo.AgentSelector == "" {
+		return repo.AgentID, nil
+	}
+	if last, err := s.store.GetLastDeployment(repo.Name); err == nil && last != nil {
+		return last.AgentID, nil
+	}
+	return s.ResolveAgent(repo)
+}
+
+func (s *DeploymentService) deployedBuildFile(repo *models.Repository) string {
+	if repo.BuildFile != "" {
+		return repo.BuildFile
+	}
+	recent, _ := s.store.GetDeploymentsByRepo(repo.Name, 20)
+	for _, d := range recent {
+		if d.BuildFile != "" && !d.DryRun {
+			return d.BuildFile
+		}
+	}
+	return ""
+}
+
+func (s *DeploymentService) triggerDeploy(agentID, repoName, branch, commit string, opts TriggerOptions, rollbackOf string) (*models.Deployment, error) {
 	repo := s.cfg.GetRepository(repoName)
 	if repo == nil {
 		logger.Error("[DEPLOY] Repository %s not found in config", repoName)
 		return nil, fmt.Errorf("repository %s: %w", repoName, ErrRepoNotFound)
 	}
+	if repo.BuildSystem == "image" {
+		if _, err := repo.ImageRef(branch, opts.Tag, commit); err != nil {
+			return nil, err
+		}
+	}
+
+	if repo.RequireApproval && !opts.DryRun && (opts.Trigger == "webhook" || opts.Trigger == "tag") {
+		return s.awaitApproval(agentID, repo, branch, commit, opts)
+	}
+
+	if agentID == "" {
+		resolved, err := s.ResolveAgent(repo)
+		if err != nil {
+			logger.Warn("[DEPLOY] Cannot resolve target agent for %s: %v", repoName, err)
+			return nil, err
+		}
+		agentID = resolved
+	}
+	if err := s.refuseMaintenance(agentID, opts); err != nil {
+		return nil, err
+	}
+
+	logger.Debug("[DEPLOY] Checking agent %s connection status", agentID)
+
+	if !s.tcpServer.IsAgentConnected(agentID) {
+		logger.Warn("[DEPLOY] Agent %s is offline, cannot deploy", agentID)
+		return nil, s.notConnected(agentID)
+	}
 
 	agent, err := s.store.GetAgent(agentID)
 	agentName := "unknown"
@@ -65,38 +195,91 @@ func (s *DeploymentService) TriggerDeploy(agentID, repoName, branch, commit, tri
 	}
 
 	deploy := &models.Deployment{
-		ID:         helper.GenerateID(),
-		Repository: repoName,
-		Branch:     branch,
-		Commit:     commit,
-		AgentID:    agentID,
-		AgentName:  agentName,
-		Status:     models.DeployPending,
-		StartedAt:  time.Now(),
-		Trigger:    trigger,
+		ID:           helper.GenerateID(),
+		Repository:   repoName,
+		Branch:       branch,
+		Commit:       commit,
+		AgentID:      agentID,
+		AgentName:    agentName,
+		Status:       models.DeployPending,
+		StatusDetail: models.DetailSent,
+		StartedAt:    time.Now(),
+		Trigger:      opts.Trigger,
+		TriggeredBy:  opts.TriggeredBy,
+		Note:         opts.Note,
+		SourceIP:     opts.SourceIP,
+		Tag:          opts.Tag,
+		RollbackOf:   rollbackOf,
+		DryRun:       opts.DryRun,
 	}
 
-	logger.Info("[DEPLOY] Creating deployment: id=%s repo=%s branch=%s agent=%s trigger=%s",
-		deploy.ID, repoName, branch, agentName, trigger)
+	logger.Info("[DEPLOY] Creating deployment: id=%s repo=%s branch=%s agent=%s trigger=%s by=%s dry_run=%v",
+		deploy.ID, repoName, branch, agentName, opts.Trigger, deploy.TriggeredByLabel(), opts.DryRun)
 
 	if err := s.store.CreateDeployment(deploy); err != nil {
 		logger.Error("[DEPLOY] Failed to create deployment record: %v", err)
 		return nil, fmt.Errorf("create deployment: %w", err)
 	}
 
+	return s.dispatch(deploy, repo)
+}
+
+func (s *DeploymentService) refuseMaintenance(agentID string, opts TriggerOptions) error {
+	if (opts.Trigger != "webhook" && opts.Trigger != "tag" && opts.Trigger != "schedule") || agentID == "" {
+		return nil
+	}
+	reason, ok := s.cfg.AgentMaintenance(agentID, time.Now())
+	if !ok {
+		return nil
+	}
+	name := agentID
+	if agent := s.cfg.GetAgent(agentID); agent != nil {
+		name = agent.Name
+	}
+	logger.Warn("[DEPLOY] Refusing %s deploy to agent %s: %s", opts.Trigger, name, reason)
+	return fmt.Errorf("deploys to agent %s are frozen (%s): %w", name, reason, ErrAgentMaintenance)
+}
+
+func (s *DeploymentService) dispatch(deploy *models.Deployment, repo *models.Repository) (*models.Deployment, error) {
+	agentID := deploy.AgentID
+	var image string
+	if repo.BuildSystem == "image" {
+		ref, err := repo.ImageRef(deploy.Branch, deploy.Tag, deploy.Commit)
+		if err != nil {
+			deploy.StatusDetail = ""
+			s.fail(deploy, err.Error())
+			return nil, err
+		}
+		image = ref
+	}
 	cmd := &models.Command{
 		ID:      deploy.ID,
 		Type:    "deploy",
 		AgentID: agentID,
 		Payload: map[string]interface{}{
-			"url":          repo.URL,
-			"name":         repo.Name,
-			"branch":       branch,
-			"commit":       commit,
-			"path":         repo.Path,
-			"build_system": string(repo.BuildSystem),
-			"build_file":   repo.BuildFile,
-			"build_cmd":    repo.BuildCmd,
+			"url":           repo.URL,
+			"name":          repo.Name,
+			"branch":        deploy.Branch,
+			"tag":           deploy.Tag,
+			"commit":        deploy.Commit,
+			"verify_branch": repo.VerifyBranch,
+			"path":          repo.Path,
+			"build_system":  string(repo.BuildSystem),
+			"build_file":    repo.BuildFile,
+			"build_cmd":     repo.BuildCmd,
+			"image":         image,
+			"container":     repo.Container,
+			"ports":         repo.Ports,
+			"no_cache":      repo.NoCache,
+			"builder":       repo.Builder,
+			"pre_deploy":    repo.PreDeploy,
+			"post_deploy":   repo.PostDeploy,
+			"strategy":      repo.Strategy,
+			"keep_releases": repo.KeepReleases,
+			"env":           repo.Env,
+			"credential":    repo.Credential,
+			"health_check":  repo.HealthCheck,
+			"dry_run":       deploy.DryRun,
 		},
 	}
 
@@ -106,21 +289,306 @@ func (s *DeploymentService) TriggerDeploy(agentID, repoName, branch, commit, tri
 	if err := s.tcpServer.SendCommand(agentID, cmd); err != nil {
 		logger.Error("[DEPLOY] Failed to send command to agent %s: %v", agentID, err)
 
-		deploy.Status = models.DeployFailed
-		deploy.Output = fmt.Sprintf("Failed to send command: %v", err)
-		deploy.EndedAt = &deploy.StartedAt
+		deploy.StatusDetail = models.DetailSendFailed
+		s.fail(deploy, fmt.Sprintf("Failed to send command: %v", err))
+		return nil, fmt.Errorf("send command to agent %s: %w", agentID, err)
+	}
+
+	logger.Info("[DEPLOY] Command sent successfully: deployment_id=%s", deploy.ID)
+	s.statuses.DeploymentCreated(deploy)
+	s.tcpServer.DeploymentChanged(deploy)
+	return deploy, nil
+}
 
-		if updateErr := s.store.UpdateDeployment(deploy); updateErr != nil {
-			logger.Error("[DEPLOY] Failed to update deployment status: %v", updateErr)
+func (s *DeploymentService) fail(deploy *models.Deployment, output string) {
+	deploy.Status = models.DeployFailed
+	deploy.Output = output
+	deploy.EndedAt = &deploy.StartedAt
+
+	if err := s.store.UpdateDeployment(deploy); err != nil {
+		logger.Error("[DEPLOY] Failed to update deployment status: %v", err)
+	}
+	s.statuses.DeploymentFinished(deploy)
+	s.tcpServer.DeploymentChanged(deploy)
+}
+
+func (s *DeploymentService) awaitApproval(agentID string, repo *models.Repository, branch, commit string, opts TriggerOptions) (*models.Deployment, error) {
+	if agentID == "" {
+		if resolved, err := s.ResolveAgent(repo); err == nil {
+			agentID = resolved
 		}
+	}
+	if err := s.refuseMaintenance(agentID, opts); err != nil {
+		return nil, err
+	}
+	agentName := ""
+	if agent, err := s.store.GetAgent(agentID); err == nil && agent != nil {
+		agentName = agent.Name
+	}
 
-		return nil, fmt.Errorf("send command to agent %s: %w", agentID, err)
+	deploy := &models.Deployment{
+		ID:           helper.GenerateID(),
+		Repository:   repo.Name,
+		Branch:       branch,
+		Commit:       commit,
+		AgentID:      agentID,
+		AgentName:    agentName,
+		Status:       models.DeployAwaitingApproval,
+		StatusDetail: models.DetailAwaitingApproval,
+		StartedAt:    time.Now(),
+		Trigger:      opts.Trigger,
+		TriggeredBy:  opts.TriggeredBy,
+		Note:         opts.Note,
+		SourceIP:     opts.SourceIP,
+		Tag:          opts.Tag,
 	}
 
-	logger.Info("[DEPLOY] Command sent successfully: deployment_id=%s", deploy.ID)
+	if err := s.store.CreateDeployment(deploy); err != nil {
+		logger.Error("[DEPLOY] Failed to create deployment record: %v", err)
+		return nil, fmt.Errorf("create deployment: %w", err)
+	}
+
+	logger.Info("[DEPLOY] Deployment %s of %s@%s awaits approval (by %s)",
+		deploy.ID, repo.Name, shortCommit(commit), deploy.TriggeredByLabel())
+	s.statuses.DeploymentCreated(deploy)
+	s.tcpServer.DeploymentChanged(deploy)
 	return deploy, nil
 }
 
+func (s *DeploymentService) Approve(deploymentID, operator string) (*models.Deployment, error) {
+	if s.server != nil {
+		return s.server.Approve(deploymentID, operator)
+	}
+	deploy, err := s.awaiting(deploymentID)
+	if err != nil {
+		return nil, err
+	}
+
+	if window := s.cfg.ApprovalWindow(); window > 0 && time.Since(deploy.StartedAt) > window {
+		s.reject(deploy, fmt.Sprintf("approval expired after %dh", s.cfg.Server.ApprovalHours))
+		return nil, fmt.Errorf("deployment %s: %w", deploymentID, ErrApprovalExpired)
+	}
+
+	repo := s.cfg.GetRepository(deploy.Repository)
+	if repo == nil {
+		return nil, fmt.Errorf("repository %s: %w", deploy.Repository, ErrRepoNotFound)
+	}
+
+	agentID := deploy.AgentID
+	if agentID == "" || (repo.AgentSelector != "" && !s.tcpServer.IsAgentConnected(agentID)) {
+		if agentID, err = s.ResolveAgent(repo); err != nil {
+			return nil, err
+		}
+	}
+	if !s.tcpServer.IsAgentConnected(agentID) {
+		return nil, s.notConnected(agentID)
+	}
+
+	deploy.AgentID = agentID
+	if agent, err := s.store.GetAgent(agentID); err == nil && agent != nil {
+		deploy.AgentName = agent.Name
+	}
+	deploy.Status = models.DeployPending
+	deploy.StatusDetail = models.DetailSent
+	deploy.StartedAt = time.Now()
+
+	ok, err := s.store.ApproveDeployment(deploy)
+	if err != nil {
+		return nil, fmt.Errorf("approve deployment: %w", err)
+	}
+	if !ok {
+		return nil, fmt.Errorf("deployment %s: %w", deploymentID, ErrNotAwaiting)
+	}
+	s.addLog(deploy.ID, "stdout", "approved by "+operator)
+
+	logger.Info("[DEPLOY] Deployment %s of %s approved by %s", deploy.ID, deploy.Repository, operator)
+	return s.dispatch(deploy, repo)
+}
+
+func (s *DeploymentService) Reject(deploymentID, operator string) (*models.Deployment, error) {
+	if s.server != nil {
+		return s.server.Reject(deploymentID, operator)
+	}
+	deploy, err := s.awaiting(deploymentID)
+	if err != nil {
+		return nil, err
+	}
+	if !s.reject(deploy, "rejected by "+operator) {
+		return nil, fmt.Errorf("deployment %s: %w", deploymentID, ErrNotAwaiting)
+	}
+	logger.Info("[DEPLOY] Deployment %s of %s rejected by %s", deploy.ID, deploy.Repository, operator)
+	return deploy, nil
+}
+
+func (s *DeploymentService) awaiting(deploymentID string) (*models.Deployment, error) {
+	deploy, err := s.store.GetDeployment(deploymentID)
+	if err != nil {
+		return nil, fmt.Errorf("load deployment %s: %w", deploymentID, err)
+	}
+	if deploy == nil {
+		return nil, fmt.Errorf("deployment %s: %w", deploymentID, ErrDeployNotFound)
+	}
+	if deploy.Status != models.DeployAwaitingApproval {
+		return nil, fmt.Errorf("deployment %s is %s: %w", deploymentID, deploy.Status, ErrNotAwaiting)
+	}
+	return deploy, nil
+}
+
+func (s *DeploymentService) reject(deploy *models.Deployment, reason string) bool {
+	now := time.Now()
+	deploy.Status = models.DeployRejected
+	deploy.StatusDetail = ""
+	deploy.Output = reason
+	deploy.EndedAt = &now
+	ok, err := s.store.RejectDeployment(deploy)
+	if err != nil {
+		logger.Error("[DEPLOY] Failed to update deployment %s: %v", deploy.ID, err)
+		return false
+	}
+	if !ok {
+		return false
+	}
+	s.addLog(deploy.ID, "stderr", reason)
+	s.statuses.DeploymentFinished(deploy)
+	s.tcpServer.DeploymentChanged(deploy)
+	return true
+}
+
+func (s *DeploymentService) addLog(deploymentID, stream, line string) {
+	s.store.AddDeploymentLog(&models.DeploymentLog{
+		DeploymentID: deploymentID,
+		Line:         line,
+		Stream:       stream,
+		Timestamp:    time.Now(),
+	})
+}
+
+func (s *DeploymentService) RequestInventory(agentID string) error {
+	if s.server != nil {
+		return s.server.RequestInventory(agentID)
+	}
+	if !s.tcpServer.IsAgentConnected(agentID) {
+		return s.notConnected(agentID)
+	}
+	return s.tcpServer.RequestInventory(agentID)
+}
+
+func (s *DeploymentService) notConnected(agentID string) error {
+	if pid := s.cfg.Owner(); pid != 0 {
+		return fmt.Errorf("agent %s is connected to the server running as pid %d: %w", agentID, pid, ErrAttached)
+	}
+	return fmt.Errorf("agent %s is not connected: %w", agentID, ErrAgentNotConnected)
+}
+
+func (s *DeploymentService) Teardown(repo models.Repository, removeDir bool) (*models.Deployment, error) {
+	agentID, err := s.deployedAgent(&repo)
+	if err != nil {
+		return nil, fmt.Errorf("teardown of %s: %w", repo.Name, err)
+	}
+	repo.AgentID = agentID
+	repo.BuildFile = s.deployedBuildFile(&repo)
+	if s.cfg.Owner() != 0 {
+		return nil, fmt.Errorf("teardown of %s: %w", repo.Name, s.notConnected(agentID))
+	}
+
+	agentName := "unknown"
+	if agent, err := s.store.GetAgent(repo.AgentID); err == nil && agent != nil {
+		agentName = agent.Name
+	}
+
+	now := time.Now()
+	record := &models.Deployment{
+		ID:           helper.GenerateID(),
+		Repository:   repo.Name,
+		Branch:       repo.Branch,
+		AgentID:      repo.AgentID,
+		AgentName:    agentName,
+		Status:       models.DeployPending,
+		StatusDetail: models.DetailSent,
+		StartedAt:    now,
+		Trigger:      "teardown",
+		TriggeredBy:  s.cfg.OperatorName(),
+	}
+
+	connected := s.tcpServer.IsAgentConnected(repo.AgentID)
+	if !connected {
+		record.Status = models.DeployFailed
+		record.StatusDetail = ""
+		record.Output = "teardown skipped: agent offline"
+		record.EndedAt = &now
+	}
+
+	if err := s.store.CreateDeployment(record); err != nil {
+		logger.Error("[DEPLOY] Failed to create teardown record: %v", err)
+		return nil, fmt.Errorf("create teardown record: %w", err)
+	}
+
+	s.tcpServer.DeploymentChanged(record)
+
+	if !connected {
+		logger.Warn("[DEPLOY] Agent %s is offline, skipping teardown of %s", repo.AgentID, repo.Name)
+		return record, fmt.Errorf("teardown of %s skipped, agent %s is not connected: %w", repo.Name, agentName, ErrAgentNotConnected)
+	}
+
+	cmd := &models.Command{
+		ID:      record.ID,
+		Type:    "teardown",
+		AgentID: repo.AgentID,
+		Payload: map[string]interface{}{
+			"name":         repo.Name,
+			"path":         repo.Path,
+			"build_system": string(repo.BuildSystem),
+			"build_file":   repo.BuildFile,
+			"container":    repo.Container,
+			"strategy":     repo.Strategy,
+			"remove_dir":   removeDir,
+		},
+	}
+
+	logger.Info("[DEPLOY] Requesting teardown of %s on agent %s", repo.Name, agentName)
+	if err := s.tcpServer.SendCommand(repo.AgentID, cmd); err != nil {
+		record.Status = models.DeployFailed
+		record.StatusDetail = models.DetailSendFailed
+		record.Output = fmt.Sprintf("Failed to send command: %v", err)
+		record.EndedAt = &now
+		s.store.UpdateDeployment(record)
+		s.tcpServer.DeploymentChanged(record)
+		return record, fmt.Errorf("send teardown to agent %s: %w", agentName, err)
+	}
+
+	return record, nil
+}
+
+func (s *DeploymentService) CheckDrift(agentID, repoName string) error {
+	if agentID == "" {
+		repo := s.cfg.GetRepository(repoName)
+		if repo == nil {
+			return fmt.Errorf("repository %s: %w", repoName, ErrRepoNotFound)
+		}
+		resolved, err := s.deployedAgent(repo)
+		if err != nil {
+			return err
+		}
+		agentID = resolved
+	}
+
+	if !s.tcpServer.IsAgentConnected(agentID) {
+		return s.notConnected(agentID)
+	}
+
+	cmd := &models.Command{
+		ID:      helper.GenerateID(),
+		Type:    "drift_check",
+		AgentID: agentID,
+		Payload: map[string]interface{}{
+			"name": repoName,
+		},
+	}
+
+	logger.Debug("[DEPLOY] Requesting drift check from agent %s for %s", agentID, repoName)
+	return s.tcpServer.SendCommand(agentID, cmd)
+}
+
 func (s *DeploymentService) GetRecent(limit int) ([]models.Deployment, error) {
 	return s.store.GetRecentDeployments(limit)
 }
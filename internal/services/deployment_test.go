@@ -0,0 +1,193 @@
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/urustack/uruflow/internal/models"
+	"github.com/urustack/uruflow/internal/tcp"
+)
+
+func newAwaitingDeployment(t *testing.T, f *agentFixture) *models.Deployment {
+	t.Helper()
+	d := &models.Deployment{
+		ID:         "deploy-1",
+		Repository: "api",
+		Branch:     "main",
+		Commit:     "abc123",
+		AgentID:    f.web,
+		AgentName:  "web",
+		Status:     models.DeployAwaitingApproval,
+		Trigger:    "webhook",
+		StartedAt:  time.Now(),
+	}
+	if err := f.store.CreateDeployment(d); err != nil {
+		t.Fatalf("create deployment: %v", err)
+	}
+	return d
+}
+
+func TestRejectDoesNotOverrideApproval(t *testing.T) {
+	f := newAgentFixture(t)
+	svc := NewDeploymentService(f.cfg, f.store, tcp.NewServer(f.cfg, f.store))
+	stale := newAwaitingDeployment(t, f)
+
+	approved := *stale
+	approved.Status = models.DeployPending
+	approved.StatusDetail = models.DetailSent
+	if ok, err := f.store.ApproveDeployment(&approved); err != nil || !ok {
+		t.Fatalf("approve: ok=%v err=%v", ok, err)
+	}
+
+	if svc.reject(stale, "rejected by bob") {
+		t.Fatal("reject applied to a deployment that was already approved")
+	}
+	got, err := f.store.GetDeployment(stale.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Status != models.DeployPending {
+		t.Fatalf("status = %s, want %s", got.Status, models.DeployPending)
+	}
+	if _, err := svc.Reject(stale.ID, "bob"); !errors.Is(err, ErrNotAwaiting) {
+		t.Fatalf("Reject = %v, want ErrNotAwaiting", err)
+	}
+}
+
+func TestRejectAwaitingDeployment(t *testing.T) {
+	f := newAgentFixture(t)
+	svc := NewDeploymentService(f.cfg, f.store, tcp.NewServer(f.cfg, f.store))
+	d := newAwaitingDeployment(t, f)
+
+	if _, err := svc.Reject(d.ID, "bob"); err != nil {
+		t.Fatalf("Reject: %v", err)
+	}
+	got, err := f.store.GetDeployment(d.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Status != models.DeployRejected || got.Output != "rejected by bob" || got.EndedAt == nil {
+		t.Fatalf("deployment = %+v, want rejected by bob", got)
+	}
+}
+
+func TestDeployedAgentIgnoresDryRuns(t *testing.T) {
+	f := newAgentFixture(t)
+	svc := NewDeploymentService(f.cfg, f.store, tcp.NewServer(f.cfg, f.store))
+	started := time.Now().Add(-time.Minute)
+	for _, d := range []models.Deployment{
+		{ID: "real", Repository: "worker", Branch: "main", Commit: "abc123", AgentID: f.web, Status: models.DeploySuccess, StartedAt: started},
+		{ID: "dry", Repository: "worker", Branch: "main", Commit: "def456", AgentID: f.db, Status: models.DeployDryRun, DryRun: true, StartedAt: started.Add(30 * time.Second)},
+	} {
+		if err := f.store.CreateDeployment(&d); err != nil {
+			t.Fatalf("create deployment %s: %v", d.ID, err)
+		}
+	}
+
+	last, err := f.store.GetLastDeployment("worker")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if last == nil || last.ID != "real" {
+		t.Fatalf("GetLastDeployment = %+v, want real", last)
+	}
+	agentID, err := svc.deployedAgent(&models.Repository{Name: "worker", AgentSelector: "env=prod"})
+	if err != nil {
+		t.Fatalf("deployedAgent: %v", err)
+	}
+	if agentID != f.web {
+		t.Fatalf("deployedAgent = %s, want %s", agentID, f.web)
+	}
+}
+
+func TestTeardownUsesRecordedBuildFile(t *testing.T) {
+	f := newAgentFixture(t)
+	svc := NewDeploymentService(f.cfg, f.store, tcp.NewServer(f.cfg, f.store))
+	started := time.Now().Add(-time.Minute)
+	for _, d := range []models.Deployment{
+		{ID: "real", Repository: "worker", AgentID: f.web, Status: models.DeploySuccess, BuildFile: "docker-compose.yml", StartedAt: started},
+		{ID: "dry", Repository: "worker", AgentID: f.web, Status: models.DeployDryRun, DryRun: true, BuildFile: "compose.yaml", StartedAt: started.Add(time.Second)},
+		{ID: "teardown", Repository: "worker", AgentID: f.web, Status: models.DeploySuccess, Trigger: "teardown", StartedAt: started.Add(2 * time.Second)},
+	} {
+		if err := f.store.CreateDeployment(&d); err != nil {
+			t.Fatalf("create deployment %s: %v", d.ID, err)
+		}
+		if err := f.store.UpdateDeployment(&d); err != nil {
+			t.Fatalf("update deployment %s: %v", d.ID, err)
+		}
+	}
+
+	if got := svc.deployedBuildFile(&models.Repository{Name: "worker"}); got != "docker-compose.yml" {
+		t.Fatalf("deployedBuildFile = %q, want docker-compose.yml", got)
+	}
+	if got := svc.deployedBuildFile(&models.Repository{Name: "worker", BuildFile: "ops.yml"}); got != "ops.yml" {
+		t.Fatalf("deployedBuildFile = %q, want the configured ops.yml", got)
+	}
+}
+
+func TestDeploymentNoteIsStored(t *testing.T) {
+	f := newAgentFixture(t)
+	d := &models.Deployment{ID: "noted", Repository: "api", AgentID: f.web, Status: models.DeployPending, Trigger: "api", TriggeredBy: "api", Note: "alice", StartedAt: time.Now()}
+	if err := f.store.CreateDeployment(d); err != nil {
+		t.Fatal(err)
+	}
+	got, err := f.store.GetDeployment(d.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.TriggeredBy != "api" || got.Note != "alice" {
+		t.Fatalf("triggered_by = %q, note = %q; want api and alice", got.TriggeredBy, got.Note)
+	}
+}
+
+func TestDeploymentStatusDetail(t *testing.T) {
+	f := newAgentFixture(t)
+	svc := NewDeploymentService(f.cfg, f.store, tcp.NewServer(f.cfg, f.store))
+	for i := range f.cfg.Repositories {
+		f.cfg.Repositories[i].RequireApproval = true
+	}
+
+	d, err := svc.TriggerDeploy(f.web, "api", "main", "abc123", TriggerOptions{Trigger: "webhook"})
+	if err != nil {
+		t.Fatalf("TriggerDeploy: %v", err)
+	}
+	got, _ := f.store.GetDeployment(d.ID)
+	if got.Status != models.DeployAwaitingApproval || got.StatusDetail != models.DetailAwaitingApproval {
+		t.Fatalf("deployment = %s/%q, want awaiting approval", got.Status, got.StatusDetail)
+	}
+
+	if _, err := svc.Approve(d.ID, "bob"); !errors.Is(err, ErrAgentNotConnected) {
+		t.Fatalf("Approve with the agent offline = %v, want ErrAgentNotConnected", err)
+	}
+	got, _ = f.store.GetDeployment(d.ID)
+	if got.Status != models.DeployAwaitingApproval || got.StatusDetail != models.DetailAwaitingApproval {
+		t.Fatalf("deployment after a refused approval = %s/%q, want it still awaiting approval", got.Status, got.StatusDetail)
+	}
+
+	if _, err := svc.Reject(d.ID, "bob"); err != nil {
+		t.Fatalf("Reject: %v", err)
+	}
+	got, _ = f.store.GetDeployment(d.ID)
+	if got.Status != models.DeployRejected || got.StatusDetail != "" {
+		t.Fatalf("rejected deployment = %s/%q, want the detail cleared", got.Status, got.StatusDetail)
+	}
+}
@@ -22,5 +22,13 @@ import "errors"
 
 var (
 	ErrAgentNotConnected = errors.New("agent not connected")
+	ErrAgentNotFound     = errors.New("agent not found")
 	ErrRepoNotFound      = errors.New("repository not found")
+	ErrDeployNotFound    = errors.New("deployment not found")
+	ErrNoMatchingAgent   = errors.New("no agent matches the selector")
+	ErrNotAwaiting       = errors.New("deployment is not awaiting approval")
+	ErrApprovalExpired   = errors.New("approval window expired")
+	ErrAgentMaintenance  = errors.New("agent is in maintenance")
+	ErrAttached          = errors.New("not available while attached to a running server")
+	ErrNoRollbackTarget  = errors.New("deployment cannot be rolled back to")
 )
@@ -0,0 +1,134 @@
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+
+package services
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/urustack/uruflow/internal/models"
+)
+
+const exportPageSize = 1000
+
+var unsafeFileChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+func LogExportName(deploy *models.Deployment) string {
+	return fmt.Sprintf("%s-%s.log", unsafeFileChars.ReplaceAllString(deploy.Repository, "_"), deploy.ID)
+}
+
+func (s *DeploymentService) ExportLogs(w io.Writer, deploy *models.Deployment) error {
+	bw := bufio.NewWriter(w)
+	writeExportHeader(bw, deploy)
+
+	var after int64
+	for {
+		logs, err := s.store.GetDeploymentLogsPage(deploy.ID, after, exportPageSize)
+		if err != nil {
+			return fmt.Errorf("read logs: %w", err)
+		}
+		for _, l := range logs {
+			fmt.Fprintf(bw, "%s [%s] %s\n", l.Timestamp.UTC().Format(time.RFC3339Nano), l.Stream, l.Line)
+		}
+		if err := bw.Flush(); err != nil {
+			return err
+		}
+		if len(logs) < exportPageSize {
+			return nil
+		}
+		after = logs[len(logs)-1].ID
+	}
+}
+
+func (s *DeploymentService) ExportLogsFile(deploymentID string) (string, error) {
+	deploy, err := s.store.GetDeployment(deploymentID)
+	if err != nil {
+		return "", err
+	}
+	if deploy == nil {
+		return "", fmt.Errorf("deployment %s not found", deploymentID)
+	}
+
+	dir := filepath.Join(s.cfg.Server.DataDir, "exports")
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		return "", fmt.Errorf("create exports directory: %w", err)
+	}
+	path := filepath.Join(dir, LogExportName(deploy))
+
+	tmp, err := os.CreateTemp(dir, ".export-*")
+	if err != nil {
+		return "", fmt.Errorf("create export file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := s.ExportLogs(tmp, deploy); err != nil {
+		tmp.Close()
+		return "", err
+	}
+	if err := tmp.Close(); err != nil {
+		return "", fmt.Errorf("write export file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return "", fmt.Errorf("write export file: %w", err)
+	}
+	return path, nil
+}
+
+func writeExportHeader(w io.Writer, deploy *models.Deployment) {
+	field := func(name, value string) {
+		if value != "" {
+			fmt.Fprintf(w, "# %-14s %s\n", name+":", value)
+		}
+	}
+
+	agent := deploy.AgentName
+	if agent == "" {
+		agent = deploy.AgentID
+	}
+	var duration, ended string
+	if deploy.EndedAt != nil {
+		ended = deploy.EndedAt.UTC().Format(time.RFC3339)
+		duration = (time.Duration(deploy.Duration) * time.Millisecond).String()
+	}
+
+	field("deployment", deploy.ID)
+	field("repository", deploy.Repository)
+	field("branch", deploy.Branch)
+	field("tag", deploy.Tag)
+	field("commit", deploy.Commit)
+	field("agent", agent)
+	field("build file", deploy.BuildFile)
+	field("status", string(deploy.Status))
+	field("trigger", deploy.Trigger)
+	field("triggered by", deploy.TriggeredBy)
+	field("note", deploy.Note)
+	field("started", deploy.StartedAt.UTC().Format(time.RFC3339))
+	field("ended", ended)
+	field("duration", duration)
+	if deploy.ExitCode != nil {
+		field("exit code", strconv.Itoa(*deploy.ExitCode))
+	}
+	fmt.Fprintln(w)
+}
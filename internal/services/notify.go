@@ -0,0 +1,194 @@
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/urustack/uruflow/internal/config"
+	"github.com/urustack/uruflow/internal/models"
+	"github.com/urustack/uruflow/pkg/logger"
+)
+
+const (
+	notifyQueueSize = 100
+	notifyAttempts  = 4
+	notifyBackoff   = 2 * time.Second
+	notifyTimeout   = 10 * time.Second
+)
+
+type Notifier struct {
+	sinks          []*notifySink
+	deployFailures bool
+	done           chan struct{}
+}
+
+type notifySink struct {
+	kind   string
+	url    string
+	min    int
+	queue  chan *models.Alert
+	client *http.Client
+}
+
+func NewNotifier(cfg config.NotificationsConfig) *Notifier {
+	n := &Notifier{
+		deployFailures: cfg.DeployFailures,
+		done:           make(chan struct{}),
+	}
+
+	for _, sc := range cfg.Sinks {
+		kind := strings.ToLower(strings.TrimSpace(sc.Type))
+		if kind != "slack" && kind != "webhook" {
+			logger.Warn("[NOTIFY] ignoring sink with unknown type %q", sc.Type)
+			continue
+		}
+		if sc.URL == "" {
+			logger.Warn("[NOTIFY] ignoring %s sink without url", kind)
+			continue
+		}
+		sink := &notifySink{
+			kind:   kind,
+			url:    sc.URL,
+			min:    severityRank(models.AlertSeverity(strings.ToLower(sc.MinSeverity))),
+			queue:  make(chan *models.Alert, notifyQueueSize),
+			client: &http.Client{Timeout: notifyTimeout},
+		}
+		n.sinks = append(n.sinks, sink)
+		go n.run(sink)
+	}
+
+	if len(n.sinks) > 0 {
+		logger.Info("[NOTIFY] %d notification sink(s) configured", len(n.sinks))
+	}
+	return n
+}
+
+func (n *Notifier) Stop() {
+	select {
+	case <-n.done:
+	default:
+		close(n.done)
+	}
+}
+
+func (n *Notifier) NotifyAlert(alert *models.Alert) {
+	for _, sink := range n.sinks {
+		if severityRank(alert.Severity) < sink.min {
+			continue
+		}
+		select {
+		case sink.queue <- alert:
+		default:
+			logger.Warn("[NOTIFY] %s queue full, dropping %s alert for %s", sink.kind, alert.Type, alert.AgentName)
+		}
+	}
+}
+
+func (n *Notifier) NotifyDeployFailed(alert *models.Alert) {
+	if !n.deployFailures {
+		return
+	}
+	n.NotifyAlert(alert)
+}
+
+func (n *Notifier) run(sink *notifySink) {
+	for {
+		select {
+		case <-n.done:
+			return
+		case alert := <-sink.queue:
+			n.deliver(sink, alert)
+		}
+	}
+}
+
+func (n *Notifier) deliver(sink *notifySink, alert *models.Alert) {
+	body, err := sink.payload(alert)
+	if err != nil {
+		logger.Error("[NOTIFY] failed to encode %s alert: %v", alert.Type, err)
+		return
+	}
+
+	backoff := notifyBackoff
+	for attempt := 1; attempt <= notifyAttempts; attempt++ {
+		retry, err := sink.post(body)
+		if err == nil {
+			logger.Debug("[NOTIFY] delivered %s alert to %s sink", alert.Type, sink.kind)
+			return
+		}
+		if !retry || attempt == notifyAttempts {
+			logger.Error("[NOTIFY] %s sink gave up on %s alert after %d attempt(s): %v", sink.kind, alert.Type, attempt, err)
+			return
+		}
+		logger.Warn("[NOTIFY] %s sink attempt %d failed: %v, retrying in %v", sink.kind, attempt, err, backoff)
+
+		select {
+		case <-n.done:
+			return
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+func (s *notifySink) payload(alert *models.Alert) ([]byte, error) {
+	if s.kind == "webhook" {
+		return json.Marshal(alert)
+	}
+	return json.Marshal(map[string]string{
+		"text": fmt.Sprintf("*[%s] %s* on agent `%s`\n%s",
+			strings.ToUpper(string(alert.Severity)), alert.Type, alert.AgentName, alert.Message),
+	})
+}
+
+func (s *notifySink) post(body []byte) (bool, error) {
+	req, err := http.NewRequest(http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "uruflow")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+	retry := resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests
+	return retry, fmt.Errorf("unexpected status %d", resp.StatusCode)
+}
+
+func severityRank(s models.AlertSeverity) int {
+	switch s {
+	case models.SeverityCritical:
+		return 2
+	case models.SeverityWarning:
+		return 1
+	}
+	return 0
+}
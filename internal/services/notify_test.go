@@ -0,0 +1,136 @@
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+
+package services
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/urustack/uruflow/internal/config"
+	"github.com/urustack/uruflow/internal/models"
+)
+
+type capturedRequest struct {
+	method      string
+	contentType string
+	userAgent   string
+	body        []byte
+}
+
+func notifySinkServer(t *testing.T) (string, <-chan capturedRequest) {
+	t.Helper()
+	requests := make(chan capturedRequest, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests <- capturedRequest{
+			method:      r.Method,
+			contentType: r.Header.Get("Content-Type"),
+			userAgent:   r.Header.Get("User-Agent"),
+			body:        body,
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv.URL, requests
+}
+
+func nextRequest(t *testing.T, requests <-chan capturedRequest) capturedRequest {
+	t.Helper()
+	select {
+	case req := <-requests:
+		if req.method != http.MethodPost || req.contentType != "application/json" || req.userAgent != "uruflow" {
+			t.Fatalf("request = %s %q %q, want a JSON POST from uruflow", req.method, req.contentType, req.userAgent)
+		}
+		return req
+	case <-time.After(5 * time.Second):
+		t.Fatal("no notification delivered")
+		return capturedRequest{}
+	}
+}
+
+func diskAlert(severity models.AlertSeverity) *models.Alert {
+	return &models.Alert{
+		ID:        "alert-1",
+		AgentID:   "agent-1",
+		AgentName: "web",
+		Type:      "disk_full",
+		Message:   "disk usage at 97%",
+		Severity:  severity,
+		CreatedAt: time.Date(2026, 3, 10, 2, 14, 0, 0, time.UTC),
+	}
+}
+
+func TestWebhookSinkPostsAlertJSON(t *testing.T) {
+	url, requests := notifySinkServer(t)
+	n := NewNotifier(config.NotificationsConfig{Sinks: []config.NotifySink{{Type: "webhook", URL: url}}})
+	defer n.Stop()
+
+	n.NotifyAlert(diskAlert(models.SeverityCritical))
+	req := nextRequest(t, requests)
+
+	var got models.Alert
+	if err := json.Unmarshal(req.body, &got); err != nil {
+		t.Fatalf("webhook body is not an alert: %v\n%s", err, req.body)
+	}
+	want := diskAlert(models.SeverityCritical)
+	if got.ID != want.ID || got.AgentID != want.AgentID || got.AgentName != want.AgentName ||
+		got.Type != want.Type || got.Message != want.Message || got.Severity != want.Severity ||
+		!got.CreatedAt.Equal(want.CreatedAt) || got.Resolved {
+		t.Fatalf("webhook alert = %+v, want %+v", got, *want)
+	}
+}
+
+func TestSlackSinkPostsText(t *testing.T) {
+	url, requests := notifySinkServer(t)
+	n := NewNotifier(config.NotificationsConfig{Sinks: []config.NotifySink{{Type: "Slack", URL: url, MinSeverity: "warning"}}})
+	defer n.Stop()
+
+	n.NotifyAlert(diskAlert(models.SeverityInfo))
+	n.NotifyAlert(diskAlert(models.SeverityWarning))
+	req := nextRequest(t, requests)
+
+	var got map[string]string
+	if err := json.Unmarshal(req.body, &got); err != nil {
+		t.Fatalf("slack body: %v\n%s", err, req.body)
+	}
+	if want := "*[WARNING] disk_full* on agent `web`\ndisk usage at 97%"; got["text"] != want || len(got) != 1 {
+		t.Fatalf("slack payload = %q, want only text %q", got, want)
+	}
+	select {
+	case req := <-requests:
+		t.Fatalf("alert below min_severity delivered: %s", req.body)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestDeployFailuresNeedOptIn(t *testing.T) {
+	url, requests := notifySinkServer(t)
+	n := NewNotifier(config.NotificationsConfig{Sinks: []config.NotifySink{{Type: "webhook", URL: url}}})
+	defer n.Stop()
+
+	n.NotifyDeployFailed(diskAlert(models.SeverityCritical))
+	select {
+	case req := <-requests:
+		t.Fatalf("deploy failure delivered without deploy_failures: %s", req.body)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
@@ -0,0 +1,82 @@
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+
+package services
+
+import (
+	"fmt"
+
+	"github.com/urustack/uruflow/internal/models"
+	"github.com/urustack/uruflow/pkg/logger"
+)
+
+func (s *DeploymentService) Prefetch(repo *models.Repository) error {
+	if !s.cfg.Server.Prefetch {
+		return nil
+	}
+	agentID, err := s.deployedAgent(repo)
+	if err != nil {
+		return err
+	}
+	return s.prefetch(agentID, repo)
+}
+
+func (s *DeploymentService) CheckoutsReported(agentID string, checkouts []models.Checkout) {
+	if !s.cfg.Server.Prefetch {
+		return
+	}
+
+	warm := make(map[string]models.Checkout, len(checkouts))
+	for _, c := range checkouts {
+		warm[c.Repository] = c
+	}
+	for _, repo := range s.cfg.ListRepositories() {
+		if c, ok := warm[repo.Name]; ok && c.Matches(&repo) {
+			continue
+		}
+		if target, err := s.deployedAgent(&repo); err != nil || target != agentID {
+			continue
+		}
+		if err := s.prefetch(agentID, &repo); err != nil {
+			logger.Warn("[DEPLOY] Failed to prefetch %s on agent %s: %v", repo.Name, agentID, err)
+		}
+	}
+}
+
+func (s *DeploymentService) prefetch(agentID string, repo *models.Repository) error {
+	if repo.BuildSystem == "image" {
+		return nil
+	}
+	if !s.tcpServer.IsAgentConnected(agentID) {
+		return s.notConnected(agentID)
+	}
+
+	id, err := s.tcpServer.SendPrefetch(agentID, map[string]interface{}{
+		"url":        repo.URL,
+		"name":       repo.Name,
+		"branch":     repo.Branch,
+		"path":       repo.Path,
+		"strategy":   repo.Strategy,
+		"credential": repo.Credential,
+	})
+	if err != nil {
+		return fmt.Errorf("send prefetch to agent %s: %w", agentID, err)
+	}
+	logger.Info("[DEPLOY] Prefetching %s (%s) on agent %s: command_id=%s", repo.Name, repo.Branch, agentID, id)
+	return nil
+}
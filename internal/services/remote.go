@@ -0,0 +1,118 @@
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+
+package services
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/urustack/uruflow/internal/models"
+)
+
+const remoteCheckTimeout = 15 * time.Second
+
+type RemoteCheck struct {
+	URL       string
+	Reachable bool
+	Error     string
+	Branches  []string
+	Missing   []string
+}
+
+func (c RemoteCheck) OK() bool {
+	return c.Reachable && len(c.Missing) == 0
+}
+
+func (s *DeploymentService) CheckRemote(ctx context.Context, url, branches string) RemoteCheck {
+	check := RemoteCheck{URL: url}
+
+	ctx, cancel := context.WithTimeout(ctx, remoteCheckTimeout)
+	defer cancel()
+
+	var args []string
+	if s.cfg.Server.GitProxy != "" {
+		args = append(args, "-c", "http.proxy="+s.cfg.Server.GitProxy)
+	}
+	args = append(args, "ls-remote", "--heads", "--", url)
+
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Env = append(os.Environ(),
+		"GIT_TERMINAL_PROMPT=0",
+		"GIT_SSH_COMMAND=ssh -o BatchMode=yes -o ConnectTimeout=10",
+	)
+	output, err := cmd.Output()
+	if err != nil {
+		check.Error = lsRemoteError(ctx, err)
+		return check
+	}
+
+	check.Reachable = true
+	check.Branches = ParseLsRemoteHeads(string(output))
+	for _, pattern := range models.SplitBranches(branches) {
+		if !anyBranchMatches(pattern, check.Branches) {
+			check.Missing = append(check.Missing, pattern)
+		}
+	}
+	return check
+}
+
+func ParseLsRemoteHeads(output string) []string {
+	var branches []string
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 {
+			continue
+		}
+		if name, ok := strings.CutPrefix(fields[1], "refs/heads/"); ok && name != "" {
+			branches = append(branches, name)
+		}
+	}
+	sort.Strings(branches)
+	return branches
+}
+
+func anyBranchMatches(pattern string, branches []string) bool {
+	for _, b := range branches {
+		if models.MatchBranch(pattern, b) {
+			return true
+		}
+	}
+	return false
+}
+
+func lsRemoteError(ctx context.Context, err error) string {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Sprintf("timed out after %s", remoteCheckTimeout)
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		if stderr := strings.TrimSpace(string(exitErr.Stderr)); stderr != "" {
+			return strings.TrimPrefix(strings.SplitN(stderr, "\n", 2)[0], "fatal: ")
+		}
+	}
+	return err.Error()
+}
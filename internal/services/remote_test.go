@@ -0,0 +1,98 @@
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+
+package services
+
+import (
+	"context"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/urustack/uruflow/internal/tcp"
+)
+
+func TestParseLsRemoteHeads(t *testing.T) {
+	output := "5d1f0e7c3a9b2e4f6a8c0d2e4f6a8b0c2d4e6f8a\trefs/heads/main\n" +
+		"c3e1a7b95f2d4e6a8b0c1d3e5f7a9b2c4d6e8f01\trefs/heads/release/1.4\n" +
+		"warning: redirecting to https://github.com/acme/api.git/\n" +
+		"82b3d5ae55f7080f1e6022629cdb57bfae7cccc7\trefs/heads/develop\r\n" +
+		"\n" +
+		"9f2c1e4b7a0d3c5e8f1a2b3c4d5e6f7a8b9c0d1e\trefs/tags/v1.4.0\n" +
+		"1a2b3c4d5e6f7a8b9c0d1e2f3a4b5c6d7e8f9a0b\trefs/heads/\n"
+
+	want := []string{"develop", "main", "release/1.4"}
+	if got := ParseLsRemoteHeads(output); !reflect.DeepEqual(got, want) {
+		t.Fatalf("ParseLsRemoteHeads = %v, want %v", got, want)
+	}
+	if got := ParseLsRemoteHeads(""); got != nil {
+		t.Fatalf("empty output = %v, want no branches", got)
+	}
+}
+
+func newRemote(t *testing.T, branches ...string) string {
+	t.Helper()
+	dir := t.TempDir()
+	run := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(cmd.Environ(), "GIT_AUTHOR_NAME=t", "GIT_AUTHOR_EMAIL=t@t", "GIT_COMMITTER_NAME=t", "GIT_COMMITTER_EMAIL=t@t")
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, out)
+		}
+	}
+	run("init", "-q", "-b", branches[0])
+	run("commit", "-q", "--allow-empty", "-m", "initial")
+	for _, b := range branches[1:] {
+		run("branch", b)
+	}
+	return dir
+}
+
+func TestCheckRemote(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	f := newAgentFixture(t)
+	s := NewDeploymentService(f.cfg, f.store, tcp.NewServer(f.cfg, f.store))
+	url := newRemote(t, "main", "release/1.4")
+
+	check := s.CheckRemote(context.Background(), url, "main, release/*")
+	if !check.OK() || check.Error != "" {
+		t.Fatalf("check = %+v, want reachable with every branch present", check)
+	}
+	if want := []string{"main", "release/1.4"}; !reflect.DeepEqual(check.Branches, want) {
+		t.Fatalf("branches = %v, want %v", check.Branches, want)
+	}
+
+	check = s.CheckRemote(context.Background(), url, "main,develop,hotfix/*")
+	if check.OK() || !check.Reachable {
+		t.Fatalf("check = %+v, want reachable with missing branches", check)
+	}
+	if want := []string{"develop", "hotfix/*"}; !reflect.DeepEqual(check.Missing, want) {
+		t.Fatalf("missing = %v, want %v", check.Missing, want)
+	}
+
+	check = s.CheckRemote(context.Background(), filepath.Join(t.TempDir(), "missing.git"), "main")
+	if check.Reachable || check.OK() || check.Error == "" || strings.HasPrefix(check.Error, "fatal:") {
+		t.Fatalf("check of a missing remote = %+v, want an unreachable error without the fatal prefix", check)
+	}
+}
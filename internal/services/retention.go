@@ -0,0 +1,146 @@
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+package services
+
+import (
+	"sync"
+	"time"
+
+	"github.com/urustack/uruflow/internal/config"
+	"github.com/urustack/uruflow/internal/storage"
+	"github.com/urustack/uruflow/pkg/logger"
+)
+
+const (
+	PruneInterval  = time.Hour
+	VacuumInterval = 24 * time.Hour
+)
+
+type PruneResult struct {
+	Logs        int64
+	Deployments int64
+	Metrics     int64
+	Webhooks    int64
+	AgentEvents int64
+	Vacuumed    bool
+}
+
+type RetentionService struct {
+	cfg        *config.Config
+	store      storage.Store
+	done       chan struct{}
+	stopOnce   sync.Once
+	mu         sync.Mutex
+	lastVacuum time.Time
+}
+
+func NewRetentionService(cfg *config.Config, store storage.Store) *RetentionService {
+	return &RetentionService{
+		cfg:        cfg,
+		store:      store,
+		done:       make(chan struct{}),
+		lastVacuum: time.Now(),
+	}
+}
+
+func (s *RetentionService) Start() {
+	go func() {
+		ticker := time.NewTicker(PruneInterval)
+		defer ticker.Stop()
+
+		s.run()
+		for {
+			select {
+			case <-s.done:
+				return
+			case <-ticker.C:
+				s.run()
+			}
+		}
+	}()
+}
+
+func (s *RetentionService) Stop() {
+	s.stopOnce.Do(func() { close(s.done) })
+}
+
+func (s *RetentionService) run() {
+	if _, err := s.Prune(false); err != nil {
+		logger.Error("[RETENTION] prune failed: %v", err)
+	}
+}
+
+func (s *RetentionService) Prune(forceVacuum bool) (*PruneResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result := &PruneResult{}
+
+	if days := s.cfg.Server.KeepLogsDays; days > 0 {
+		n, err := s.store.PruneDeploymentLogs(time.Now().AddDate(0, 0, -days))
+		if err != nil {
+			return nil, err
+		}
+		result.Logs = n
+	}
+
+	if keep := s.cfg.Server.KeepDeployments; keep > 0 {
+		n, err := s.store.PruneDeployments(keep)
+		if err != nil {
+			return nil, err
+		}
+		result.Deployments = n
+
+		n, err = s.store.PruneWebhookEvents(keep)
+		if err != nil {
+			return nil, err
+		}
+		result.Webhooks = n
+
+		n, err = s.store.PruneAgentEvents(keep)
+		if err != nil {
+			return nil, err
+		}
+		result.AgentEvents = n
+	}
+
+	if hours := s.cfg.Server.KeepMetricsHours; hours > 0 {
+		n, err := s.store.PruneMetricsHistory(time.Now().Add(-time.Duration(hours) * time.Hour))
+		if err != nil {
+			return nil, err
+		}
+		result.Metrics = n
+	}
+
+	pruned := result.Logs > 0 || result.Deployments > 0 || result.Metrics > 0 || result.Webhooks > 0 || result.AgentEvents > 0
+	if pruned {
+		logger.Info("[RETENTION] removed %d log line(s), %d deployment(s), %d metrics sample(s), %d webhook event(s) and %d agent event(s)",
+			result.Logs, result.Deployments, result.Metrics, result.Webhooks, result.AgentEvents)
+	}
+
+	if forceVacuum || (pruned && time.Since(s.lastVacuum) >= VacuumInterval) {
+		if err := s.store.Vacuum(); err != nil {
+			return result, err
+		}
+		s.lastVacuum = time.Now()
+		result.Vacuumed = true
+		logger.Info("[RETENTION] database vacuumed")
+	}
+
+	return result, nil
+}
@@ -0,0 +1,184 @@
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+
+package services
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"github.com/urustack/uruflow/internal/config"
+	"github.com/urustack/uruflow/internal/models"
+	"github.com/urustack/uruflow/internal/storage"
+	"github.com/urustack/uruflow/pkg/logger"
+)
+
+const ScheduleInterval = time.Minute
+
+type scheduledRepo struct {
+	spec  string
+	sched cron.Schedule
+	next  time.Time
+}
+
+type Scheduler struct {
+	cfg      *config.Config
+	store    storage.Store
+	deploy   *DeploymentService
+	repos    map[string]*scheduledRepo
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+func NewScheduler(cfg *config.Config, store storage.Store, deploy *DeploymentService) *Scheduler {
+	return &Scheduler{
+		cfg:    cfg,
+		store:  store,
+		deploy: deploy,
+		repos:  make(map[string]*scheduledRepo),
+		done:   make(chan struct{}),
+	}
+}
+
+func (s *Scheduler) Start() {
+	go func() {
+		ticker := time.NewTicker(ScheduleInterval)
+		defer ticker.Stop()
+
+		s.run(time.Now())
+		for {
+			select {
+			case <-s.done:
+				return
+			case now := <-ticker.C:
+				s.run(now)
+			}
+		}
+	}()
+}
+
+func (s *Scheduler) Stop() {
+	s.stopOnce.Do(func() { close(s.done) })
+}
+
+func (s *Scheduler) run(now time.Time) {
+	seen := make(map[string]bool)
+	for _, repo := range s.cfg.ListRepositories() {
+		if repo.Schedule == "" {
+			continue
+		}
+		seen[repo.Name] = true
+
+		entry := s.repos[repo.Name]
+		if entry == nil || entry.spec != repo.Schedule {
+			sched, err := models.ParseSchedule(repo.Schedule)
+			if err != nil {
+				logger.Warn("[SCHEDULE] %s: %v", repo.Name, err)
+				delete(s.repos, repo.Name)
+				continue
+			}
+			entry = &scheduledRepo{spec: repo.Schedule, sched: sched, next: s.firstRun(repo.Name, sched, now)}
+			s.repos[repo.Name] = entry
+			logger.Info("[SCHEDULE] %s scheduled %q, next run %s", repo.Name, repo.Schedule, entry.next.Format("2006-01-02 15:04"))
+		}
+
+		if now.Before(entry.next) {
+			continue
+		}
+		entry.next = entry.sched.Next(now)
+		s.fire(repo)
+	}
+	for name := range s.repos {
+		if !seen[name] {
+			delete(s.repos, name)
+		}
+	}
+}
+
+func (s *Scheduler) firstRun(repoName string, sched cron.Schedule, now time.Time) time.Time {
+	last, _, err := s.store.GetDeploymentsPage(0, 1, storage.DeploymentFilter{Repo: repoName, Trigger: "schedule"})
+	if err != nil {
+		logger.Warn("[SCHEDULE] %s: load last scheduled deployment: %v", repoName, err)
+	}
+	if len(last) == 0 {
+		return sched.Next(now)
+	}
+	return sched.Next(last[0].StartedAt)
+}
+
+func (s *Scheduler) fire(repo models.Repository) {
+	if running := s.activeDeployment(repo.Name); running != nil {
+		logger.Info("[SCHEDULE] Skipping %s: deployment %s is still %s", repo.Name, running.ID, running.Status)
+		return
+	}
+
+	branch := scheduleBranch(&repo)
+	if branch == "" {
+		logger.Warn("[SCHEDULE] Skipping %s: no plain branch to deploy, only patterns", repo.Name)
+		return
+	}
+
+	agentID, err := s.deploy.ResolveAgent(&repo)
+	if err == nil && !s.deploy.tcpServer.IsAgentConnected(agentID) {
+		err = ErrAgentNotConnected
+	}
+	if err != nil {
+		logger.Warn("[SCHEDULE] Skipping %s: %v", repo.Name, err)
+		return
+	}
+
+	deploy, err := s.deploy.TriggerDeploy(agentID, repo.Name, branch, "HEAD", TriggerOptions{
+		Trigger:     "schedule",
+		TriggeredBy: "schedule",
+	})
+	switch {
+	case errors.Is(err, ErrAgentNotConnected), errors.Is(err, ErrAgentMaintenance):
+		logger.Warn("[SCHEDULE] Skipping %s: %v", repo.Name, err)
+	case err != nil:
+		logger.Error("[SCHEDULE] Deploy of %s failed: %v", repo.Name, err)
+	default:
+		logger.Info("[SCHEDULE] Started deployment %s for %s (%s)", deploy.ID, repo.Name, repo.Schedule)
+	}
+}
+
+func (s *Scheduler) activeDeployment(repoName string) *models.Deployment {
+	recent, err := s.store.GetDeploymentsByRepo(repoName, 5)
+	if err != nil {
+		return nil
+	}
+	for i := range recent {
+		if recent[i].Status == models.DeployPending || recent[i].Status == models.DeployRunning {
+			return &recent[i]
+		}
+	}
+	return nil
+}
+
+func scheduleBranch(repo *models.Repository) string {
+	if repo.Branch != "" && !models.IsBranchPattern(repo.Branch) {
+		return repo.Branch
+	}
+	for _, b := range repo.BranchPatterns() {
+		if !models.IsBranchPattern(b) {
+			return b
+		}
+	}
+	return ""
+}
@@ -0,0 +1,216 @@
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+
+package services
+
+import (
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/urustack/uruflow/internal/models"
+	"github.com/urustack/uruflow/internal/storage"
+	"github.com/urustack/uruflow/internal/tcp"
+	"github.com/urustack/uruflow/internal/tcp/protocol"
+)
+
+const nightly = "0 3 * * *"
+
+type scheduleFixture struct {
+	*agentFixture
+	scheduler *Scheduler
+	agent     string
+}
+
+func newScheduleFixture(t *testing.T) *scheduleFixture {
+	t.Helper()
+	f := &scheduleFixture{agentFixture: newAgentFixture(t)}
+
+	id, token, err := f.cfg.AddAgent("cron")
+	if err != nil {
+		t.Fatalf("add agent: %v", err)
+	}
+	if err := f.store.CreateAgent(&models.Agent{ID: id, Name: "cron", Status: models.AgentOffline}); err != nil {
+		t.Fatalf("create agent: %v", err)
+	}
+	f.agent = id
+	repo := models.Repository{Name: "nightly", URL: "https://github.com/acme/nightly.git", Branch: "main", AgentID: id, BuildSystem: "compose", Schedule: nightly}
+	if err := f.cfg.AddRepository(repo); err != nil {
+		t.Fatalf("add repository: %v", err)
+	}
+	if err := f.store.CreateRepository(&repo); err != nil {
+		t.Fatalf("create repository: %v", err)
+	}
+
+	f.cfg.Server.Host = "127.0.0.1"
+	f.cfg.Server.TCPPort = freePort(t)
+	server := tcp.NewServer(f.cfg, f.store)
+	if err := server.Start(); err != nil {
+		t.Fatalf("start tcp server: %v", err)
+	}
+	t.Cleanup(func() { server.Stop() })
+	connectAgent(t, server, net.JoinHostPort(f.cfg.Server.Host, strconv.Itoa(f.cfg.Server.TCPPort)), id, token)
+
+	f.scheduler = NewScheduler(f.cfg, f.store, NewDeploymentService(f.cfg, f.store, server))
+	return f
+}
+
+func freePort(t *testing.T) int {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port
+}
+
+func connectAgent(t *testing.T, server *tcp.Server, addr, id, token string) {
+	t.Helper()
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	auth, _ := protocol.NewMessage(protocol.TypeAuth, protocol.AuthPayload{
+		Token: token, Hostname: "cron-1", MachineID: "machine-cron-1", Version: "1.1.0",
+	})
+	if err := protocol.NewWriter(conn).Write(auth); err != nil {
+		t.Fatal(err)
+	}
+	reader := protocol.NewReader(conn)
+	if msg, err := reader.ReadWithTimeout(5 * time.Second); err != nil || msg.Type != protocol.TypeAuthOK {
+		t.Fatalf("auth = %v, %v", msg, err)
+	}
+	go func() {
+		for {
+			if _, err := reader.Read(); err != nil {
+				return
+			}
+		}
+	}()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !server.IsAgentConnected(id) {
+		if time.Now().After(deadline) {
+			t.Fatal("agent never connected")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func (f *scheduleFixture) addDeployment(t *testing.T, id, trigger string, status models.DeployStatus, started time.Time) {
+	t.Helper()
+	if err := f.store.CreateDeployment(&models.Deployment{
+		ID: id, Repository: "nightly", Branch: "main", Commit: "abc123", AgentID: f.agent, AgentName: "cron",
+		Status: status, Trigger: trigger, StartedAt: started,
+	}); err != nil {
+		t.Fatalf("create deployment %s: %v", id, err)
+	}
+}
+
+func (f *scheduleFixture) scheduled(t *testing.T) []models.Deployment {
+	t.Helper()
+	deploys, _, err := f.store.GetDeploymentsPage(0, 20, storage.DeploymentFilter{Repo: "nightly", Trigger: "schedule"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return deploys
+}
+
+func (f *scheduleFixture) next(t *testing.T) time.Time {
+	t.Helper()
+	entry := f.scheduler.repos["nightly"]
+	if entry == nil {
+		t.Fatal("nightly is not scheduled")
+	}
+	return entry.next
+}
+
+func today(hour, min int) time.Time {
+	y, m, d := time.Now().UTC().Date()
+	return time.Date(y, m, d, hour, min, 0, 0, time.UTC)
+}
+
+func TestSchedulerRestartAfterRecentRunDoesNotRefire(t *testing.T) {
+	f := newScheduleFixture(t)
+	f.addDeployment(t, "sched-1", "schedule", models.DeploySuccess, today(3, 0).Add(5*time.Second))
+
+	f.scheduler.run(today(3, 30))
+	if got := len(f.scheduled(t)); got != 1 {
+		t.Fatalf("%d scheduled deployments after restart, want the original 1", got)
+	}
+	if next, want := f.next(t), today(3, 0).AddDate(0, 0, 1); !next.Equal(want) {
+		t.Fatalf("next run = %s, want %s", next, want)
+	}
+
+	f.scheduler.run(today(3, 0).AddDate(0, 0, 1))
+	if got := len(f.scheduled(t)); got != 2 {
+		t.Fatalf("%d scheduled deployments at the next slot, want 2", got)
+	}
+}
+
+func TestSchedulerCatchesUpOnceAfterLongGap(t *testing.T) {
+	f := newScheduleFixture(t)
+	f.addDeployment(t, "sched-1", "schedule", models.DeploySuccess, today(3, 0).AddDate(0, 0, -5))
+
+	now := today(10, 0)
+	f.scheduler.run(now)
+	deploys := f.scheduled(t)
+	if len(deploys) != 2 {
+		t.Fatalf("%d scheduled deployments after catch-up, want 2", len(deploys))
+	}
+	if next, want := f.next(t), today(3, 0).AddDate(0, 0, 1); !next.Equal(want) {
+		t.Fatalf("next run = %s, want %s", next, want)
+	}
+
+	for _, d := range deploys {
+		if d.ID != "sched-1" {
+			d.Status = models.DeploySuccess
+			if err := f.store.UpdateDeployment(&d); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	for i := 1; i <= 3; i++ {
+		f.scheduler.run(now.Add(time.Duration(i) * ScheduleInterval))
+	}
+	if got := len(f.scheduled(t)); got != 2 {
+		t.Fatalf("%d scheduled deployments after later ticks, want 2", got)
+	}
+}
+
+func TestSchedulerSkipsWhileDeployRunning(t *testing.T) {
+	f := newScheduleFixture(t)
+	f.addDeployment(t, "push-1", "webhook", models.DeployRunning, today(2, 55))
+
+	f.scheduler.run(today(2, 50))
+	slot := f.next(t)
+	if want := today(3, 0); !slot.Equal(want) {
+		t.Fatalf("next run = %s, want %s", slot, want)
+	}
+
+	f.scheduler.run(slot)
+	if got := len(f.scheduled(t)); got != 0 {
+		t.Fatalf("%d scheduled deployments while push-1 is running, want 0", got)
+	}
+	if next, want := f.next(t), slot.AddDate(0, 0, 1); !next.Equal(want) {
+		t.Fatalf("next run after the skip = %s, want %s", next, want)
+	}
+}
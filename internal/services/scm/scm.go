@@ -0,0 +1,324 @@
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+
+package scm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/urustack/uruflow/internal/models"
+)
+
+const (
+	pageSize    = 100
+	maxPages    = 100
+	maxRetries  = 3
+	httpTimeout = 30 * time.Second
+
+	DefaultMaxWait = time.Minute
+)
+
+var (
+	ErrUnauthorized = errors.New("token rejected by provider")
+	ErrNotFound     = errors.New("organization or group not found")
+	ErrRateLimited  = errors.New("provider rate limit exceeded")
+)
+
+var nextLink = regexp.MustCompile(`<([^>]+)>;\s*rel="next"`)
+
+type Repository struct {
+	Name          string
+	FullName      string
+	CloneURL      string
+	SSHURL        string
+	DefaultBranch string
+	Private       bool
+	Archived      bool
+}
+
+type Client struct {
+	provider string
+	baseURL  string
+	token    string
+	client   *http.Client
+	maxWait  time.Duration
+}
+
+func NewClient(provider, baseURL, token string) (*Client, error) {
+	switch provider {
+	case models.ProviderGitHub, models.ProviderGitLab:
+	default:
+		return nil, fmt.Errorf("provider must be github or gitlab")
+	}
+	if token == "" {
+		return nil, fmt.Errorf("%s token is required", provider)
+	}
+	if baseURL == "" {
+		baseURL = DefaultBaseURL(provider)
+	}
+	return &Client{
+		provider: provider,
+		baseURL:  strings.TrimRight(baseURL, "/"),
+		token:    token,
+		client:   &http.Client{Timeout: httpTimeout},
+		maxWait:  DefaultMaxWait,
+	}, nil
+}
+
+func DefaultBaseURL(provider string) string {
+	if provider == models.ProviderGitLab {
+		return "https://gitlab.com/api/v4"
+	}
+	return "https://api.github.com"
+}
+
+func (c *Client) SetMaxWait(d time.Duration) {
+	c.maxWait = d
+}
+
+func (c *Client) ListRepositories(ctx context.Context, owner string) ([]Repository, error) {
+	owner = strings.Trim(owner, "/ ")
+	if owner == "" {
+		return nil, fmt.Errorf("organization or group is required")
+	}
+	if c.provider == models.ProviderGitLab {
+		return c.listGitLab(ctx, owner)
+	}
+	return c.listGitHub(ctx, owner)
+}
+
+func (c *Client) listGitHub(ctx context.Context, owner string) ([]Repository, error) {
+	first := fmt.Sprintf("%s/orgs/%s/repos?type=all&per_page=%d", c.baseURL, url.PathEscape(owner), pageSize)
+	repos, err := c.collectGitHub(ctx, first)
+	if errors.Is(err, ErrNotFound) {
+		first = fmt.Sprintf("%s/users/%s/repos?type=owner&per_page=%d", c.baseURL, url.PathEscape(owner), pageSize)
+		repos, err = c.collectGitHub(ctx, first)
+	}
+	return repos, err
+}
+
+func (c *Client) collectGitHub(ctx context.Context, endpoint string) ([]Repository, error) {
+	var repos []Repository
+	err := c.paginate(ctx, endpoint, func(body io.Reader) error {
+		var page []struct {
+			Name          string `json:"name"`
+			FullName      string `json:"full_name"`
+			CloneURL      string `json:"clone_url"`
+			SSHURL        string `json:"ssh_url"`
+			DefaultBranch string `json:"default_branch"`
+			Private       bool   `json:"private"`
+			Archived      bool   `json:"archived"`
+		}
+		if err := json.NewDecoder(body).Decode(&page); err != nil {
+			return fmt.Errorf("decode repositories: %w", err)
+		}
+		for _, r := range page {
+			repos = append(repos, Repository{
+				Name: r.Name, FullName: r.FullName, CloneURL: r.CloneURL, SSHURL: r.SSHURL,
+				DefaultBranch: r.DefaultBranch, Private: r.Private, Archived: r.Archived,
+			})
+		}
+		return nil
+	})
+	return repos, err
+}
+
+func (c *Client) listGitLab(ctx context.Context, group string) ([]Repository, error) {
+	endpoint := fmt.Sprintf("%s/groups/%s/projects?include_subgroups=true&order_by=path&sort=asc&per_page=%d",
+		c.baseURL, url.PathEscape(group), pageSize)
+
+	var repos []Repository
+	err := c.paginate(ctx, endpoint, func(body io.Reader) error {
+		var page []struct {
+			Path              string `json:"path"`
+			PathWithNamespace string `json:"path_with_namespace"`
+			HTTPURL           string `json:"http_url_to_repo"`
+			SSHURL            string `json:"ssh_url_to_repo"`
+			DefaultBranch     string `json:"default_branch"`
+			Visibility        string `json:"visibility"`
+			Archived          bool   `json:"archived"`
+		}
+		if err := json.NewDecoder(body).Decode(&page); err != nil {
+			return fmt.Errorf("decode projects: %w", err)
+		}
+		for _, p := range page {
+			repos = append(repos, Repository{
+				Name: p.Path, FullName: p.PathWithNamespace, CloneURL: p.HTTPURL, SSHURL: p.SSHURL,
+				DefaultBranch: p.DefaultBranch, Private: p.Visibility != "public", Archived: p.Archived,
+			})
+		}
+		return nil
+	})
+	return repos, err
+}
+
+func (c *Client) paginate(ctx context.Context, endpoint string, decode func(io.Reader) error) error {
+	for page := 0; endpoint != ""; page++ {
+		if page >= maxPages {
+			return fmt.Errorf("more than %d pages of repositories, narrow the organization or group", maxPages)
+		}
+		resp, err := c.get(ctx, endpoint)
+		if err != nil {
+			return err
+		}
+		err = decode(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return err
+		}
+		endpoint = c.nextPage(resp, endpoint)
+		if endpoint != "" {
+			if err := c.throttle(ctx, resp); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (c *Client) get(ctx context.Context, endpoint string) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("User-Agent", "uruflow")
+		if c.provider == models.ProviderGitLab {
+			req.Header.Set("PRIVATE-TOKEN", c.token)
+		} else {
+			req.Header.Set("Authorization", "Bearer "+c.token)
+			req.Header.Set("Accept", "application/vnd.github+json")
+		}
+
+		resp, err := c.client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode == http.StatusOK {
+			return resp, nil
+		}
+
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		resp.Body.Close()
+
+		switch {
+		case rateLimited(resp):
+			wait := retryAfter(resp, time.Now())
+			if attempt >= maxRetries || wait > c.maxWait {
+				return nil, fmt.Errorf("%w, retry after %s", ErrRateLimited, wait.Round(time.Second))
+			}
+			if err := sleep(ctx, wait); err != nil {
+				return nil, err
+			}
+			continue
+		case resp.StatusCode == http.StatusUnauthorized:
+			return nil, ErrUnauthorized
+		case resp.StatusCode == http.StatusNotFound:
+			return nil, ErrNotFound
+		case resp.StatusCode >= 500 && attempt < maxRetries:
+			if err := sleep(ctx, time.Duration(attempt+1)*time.Second); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		return nil, fmt.Errorf("unexpected status %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+}
+
+func (c *Client) nextPage(resp *http.Response, current string) string {
+	if m := nextLink.FindStringSubmatch(resp.Header.Get("Link")); m != nil {
+		return m[1]
+	}
+	if next := resp.Header.Get("X-Next-Page"); next != "" {
+		u, err := url.Parse(current)
+		if err != nil {
+			return ""
+		}
+		q := u.Query()
+		q.Set("page", next)
+		u.RawQuery = q.Encode()
+		return u.String()
+	}
+	return ""
+}
+
+func (c *Client) throttle(ctx context.Context, resp *http.Response) error {
+	remaining := header(resp, "X-RateLimit-Remaining", "RateLimit-Remaining")
+	if remaining != "0" {
+		return nil
+	}
+	wait := retryAfter(resp, time.Now())
+	if wait > c.maxWait {
+		return fmt.Errorf("%w, retry after %s", ErrRateLimited, wait.Round(time.Second))
+	}
+	return sleep(ctx, wait)
+}
+
+func rateLimited(resp *http.Response) bool {
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return true
+	}
+	return resp.StatusCode == http.StatusForbidden &&
+		(header(resp, "X-RateLimit-Remaining", "RateLimit-Remaining") == "0" || resp.Header.Get("Retry-After") != "")
+}
+
+func retryAfter(resp *http.Response, now time.Time) time.Duration {
+	if secs, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second
+	}
+	if reset, err := strconv.ParseInt(header(resp, "X-RateLimit-Reset", "RateLimit-Reset"), 10, 64); err == nil {
+		if wait := time.Unix(reset, 0).Sub(now); wait > 0 {
+			return wait + time.Second
+		}
+		return 0
+	}
+	return time.Minute
+}
+
+func header(resp *http.Response, names ...string) string {
+	for _, name := range names {
+		if v := resp.Header.Get(name); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
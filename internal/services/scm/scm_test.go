@@ -0,0 +1,221 @@
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+
+package scm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/urustack/uruflow/internal/models"
+)
+
+const githubPage1 = `[
+  {"id": 1296269, "name": "api", "full_name": "acme/api", "private": false, "archived": false,
+   "clone_url": "https://github.com/acme/api.git", "ssh_url": "git@github.com:acme/api.git", "default_branch": "main"}
+]`
+
+const githubPage2 = `[
+  {"id": 1296270, "name": "legacy", "full_name": "acme/legacy", "private": true, "archived": true,
+   "clone_url": "https://github.com/acme/legacy.git", "ssh_url": "git@github.com:acme/legacy.git", "default_branch": "master"}
+]`
+
+const gitlabPage1 = `[
+  {"id": 4, "path": "web", "path_with_namespace": "acme/platform/web", "visibility": "internal", "archived": false,
+   "http_url_to_repo": "https://gitlab.com/acme/platform/web.git", "ssh_url_to_repo": "git@gitlab.com:acme/platform/web.git", "default_branch": "main"}
+]`
+
+const gitlabPage2 = `[
+  {"id": 9, "path": "docs", "path_with_namespace": "acme/docs", "visibility": "public", "archived": false,
+   "http_url_to_repo": "https://gitlab.com/acme/docs.git", "ssh_url_to_repo": "git@gitlab.com:acme/docs.git", "default_branch": "main"}
+]`
+
+func newTestClient(t *testing.T, provider string, handler http.HandlerFunc) *Client {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	c, err := NewClient(provider, srv.URL+"/", "s3cret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestListGitHubFollowsLinkHeader(t *testing.T) {
+	var base string
+	c := newTestClient(t, models.ProviderGitHub, func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "Bearer s3cret" {
+			t.Errorf("Authorization = %q", got)
+		}
+		switch r.URL.RequestURI() {
+		case "/orgs/acme/repos?type=all&per_page=100":
+			w.Header().Set("Link", fmt.Sprintf(`<%s/organizations/42/repos?type=all&per_page=100&page=2>; rel="next", <%s/organizations/42/repos?type=all&per_page=100&page=2>; rel="last"`, base, base))
+			w.Write([]byte(githubPage1))
+		case "/organizations/42/repos?type=all&per_page=100&page=2":
+			w.Write([]byte(githubPage2))
+		default:
+			t.Errorf("unexpected request %s", r.URL.RequestURI())
+			http.NotFound(w, r)
+		}
+	})
+	base = c.baseURL
+
+	repos, err := c.ListRepositories(context.Background(), "/acme/")
+	if err != nil {
+		t.Fatalf("ListRepositories: %v", err)
+	}
+	want := []Repository{
+		{Name: "api", FullName: "acme/api", CloneURL: "https://github.com/acme/api.git", SSHURL: "git@github.com:acme/api.git", DefaultBranch: "main"},
+		{Name: "legacy", FullName: "acme/legacy", CloneURL: "https://github.com/acme/legacy.git", SSHURL: "git@github.com:acme/legacy.git", DefaultBranch: "master", Private: true, Archived: true},
+	}
+	if len(repos) != len(want) {
+		t.Fatalf("repos = %+v", repos)
+	}
+	for i := range want {
+		if repos[i] != want[i] {
+			t.Fatalf("repos[%d] = %+v, want %+v", i, repos[i], want[i])
+		}
+	}
+}
+
+func TestListGitHubFallsBackToUser(t *testing.T) {
+	c := newTestClient(t, models.ProviderGitHub, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/orgs/octocat/repos":
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"message":"Not Found","documentation_url":"https://docs.github.com/rest/repos/repos#list-organization-repositories"}`))
+		case "/users/octocat/repos":
+			if r.URL.Query().Get("type") != "owner" {
+				t.Errorf("user listing type = %q", r.URL.Query().Get("type"))
+			}
+			w.Write([]byte(githubPage1))
+		}
+	})
+
+	repos, err := c.ListRepositories(context.Background(), "octocat")
+	if err != nil || len(repos) != 1 || repos[0].Name != "api" {
+		t.Fatalf("ListRepositories = %+v, %v", repos, err)
+	}
+}
+
+func TestListGitLabFollowsNextPage(t *testing.T) {
+	c := newTestClient(t, models.ProviderGitLab, func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("PRIVATE-TOKEN") != "s3cret" {
+			t.Errorf("PRIVATE-TOKEN = %q", r.Header.Get("PRIVATE-TOKEN"))
+		}
+		if r.URL.EscapedPath() != "/groups/acme%2Fplatform/projects" || r.URL.Query().Get("include_subgroups") != "true" {
+			t.Errorf("unexpected request %s", r.URL.RequestURI())
+		}
+		switch r.URL.Query().Get("page") {
+		case "":
+			w.Header().Set("X-Next-Page", "2")
+			w.Header().Set("X-Total-Pages", "2")
+			w.Write([]byte(gitlabPage1))
+		case "2":
+			w.Header().Set("X-Next-Page", "")
+			w.Write([]byte(gitlabPage2))
+		}
+	})
+
+	repos, err := c.ListRepositories(context.Background(), "acme/platform")
+	if err != nil {
+		t.Fatalf("ListRepositories: %v", err)
+	}
+	if len(repos) != 2 || repos[0].FullName != "acme/platform/web" || !repos[0].Private || repos[1].Private {
+		t.Fatalf("repos = %+v", repos)
+	}
+}
+
+func TestListErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		headers map[string]string
+		want    error
+	}{
+		{"unauthorized", http.StatusUnauthorized, nil, ErrUnauthorized},
+		{"not found", http.StatusNotFound, nil, ErrNotFound},
+		{"secondary rate limit", http.StatusForbidden, map[string]string{"Retry-After": "3600"}, ErrRateLimited},
+		{"primary rate limit", http.StatusForbidden, map[string]string{
+			"X-RateLimit-Remaining": "0",
+			"X-RateLimit-Reset":     fmt.Sprint(time.Now().Add(time.Hour).Unix()),
+		}, ErrRateLimited},
+		{"too many requests", http.StatusTooManyRequests, map[string]string{"Retry-After": "120"}, ErrRateLimited},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestClient(t, models.ProviderGitLab, func(w http.ResponseWriter, r *http.Request) {
+				for k, v := range tt.headers {
+					w.Header().Set(k, v)
+				}
+				w.WriteHeader(tt.status)
+			})
+			if _, err := c.ListRepositories(context.Background(), "acme"); !errors.Is(err, tt.want) {
+				t.Fatalf("err = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestRateLimitIsRetried(t *testing.T) {
+	calls := 0
+	c := newTestClient(t, models.ProviderGitHub, func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte(githubPage1))
+	})
+
+	repos, err := c.ListRepositories(context.Background(), "acme")
+	if err != nil || len(repos) != 1 || calls != 2 {
+		t.Fatalf("ListRepositories = %+v, %v after %d calls", repos, err, calls)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	resp := func(headers map[string]string) *http.Response {
+		r := &http.Response{Header: http.Header{}}
+		for k, v := range headers {
+			r.Header.Set(k, v)
+		}
+		return r
+	}
+	tests := []struct {
+		headers map[string]string
+		want    time.Duration
+	}{
+		{map[string]string{"Retry-After": "30"}, 30 * time.Second},
+		{map[string]string{"X-RateLimit-Reset": "1700000060"}, 61 * time.Second},
+		{map[string]string{"RateLimit-Reset": "1699999990"}, 0},
+		{nil, time.Minute},
+	}
+	for _, tt := range tests {
+		if got := retryAfter(resp(tt.headers), now); got != tt.want {
+			t.Errorf("retryAfter(%v) = %s, want %s", tt.headers, got, tt.want)
+		}
+	}
+}
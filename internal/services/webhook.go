@@ -20,57 +20,204 @@ package services
 import (
 	"crypto/hmac"
 	"crypto/sha256"
+	"crypto/subtle"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/urustack/uruflow/internal/config"
 	"github.com/urustack/uruflow/internal/models"
+	"github.com/urustack/uruflow/internal/storage"
 	"github.com/urustack/uruflow/pkg/logger"
 )
 
+const (
+	WebhookAccepted     = "accepted"
+	WebhookRejected     = "rejected"
+	WebhookIgnored      = "ignored"
+	WebhookUnauthorized = "unauthorized"
+	WebhookCoalesced    = "coalesced"
+)
+
 type WebhookService struct {
 	cfg           *config.Config
 	deployService *DeploymentService
+	store         storage.Store
+
+	mu         sync.Mutex
+	deliveries map[string]time.Time
+	swept      time.Time
+	cooldowns  map[string]*cooldown
 }
 
-func NewWebhookService(cfg *config.Config, ds *DeploymentService) *WebhookService {
+func NewWebhookService(cfg *config.Config, ds *DeploymentService, store storage.Store) *WebhookService {
 	return &WebhookService{
 		cfg:           cfg,
 		deployService: ds,
+		store:         store,
+		deliveries:    make(map[string]time.Time),
+		cooldowns:     make(map[string]*cooldown),
+	}
+}
+
+func (s *WebhookService) RecordEvent(source, outcome string, result *WebhookResult, reason string) {
+	event := &models.WebhookEvent{
+		Source:  source,
+		Outcome: outcome,
+		Reason:  reason,
+	}
+	if result != nil {
+		event.Repo = result.Repository
+		event.Branch = firstNonEmpty(result.Branch, result.Tag)
+		event.Commit = result.Commit
+	}
+	if err := s.store.AddWebhookEvent(event); err != nil {
+		logger.Error("[WEBHOOK] Failed to record webhook event: %v", err)
 	}
 }
 
 type WebhookResult struct {
 	Repository string
 	Branch     string
+	Tag        string
 	Commit     string
 	Deployment *models.Deployment
+	Coalesced  bool
+	DeployAt   time.Time
+	Ignored    string
 }
 
 type GitHubPushPayload struct {
 	Ref        string `json:"ref"`
+	After      string `json:"after"`
+	Deleted    bool   `json:"deleted"`
 	Repository struct {
-		Name string `json:"name"`
+		Name     string `json:"name"`
+		CloneURL string `json:"clone_url"`
+		SSHURL   string `json:"ssh_url"`
 	} `json:"repository"`
 	HeadCommit struct {
 		ID string `json:"id"`
 	} `json:"head_commit"`
+	Commits []pushCommit `json:"commits"`
+	Pusher  struct {
+		Name string `json:"name"`
+	} `json:"pusher"`
+	Sender struct {
+		Login string `json:"login"`
+	} `json:"sender"`
 }
 
 type GitLabPushPayload struct {
-	Ref     string `json:"ref"`
-	Project struct {
-		Name string `json:"name"`
+	Ref         string `json:"ref"`
+	After       string `json:"after"`
+	CheckoutSHA string `json:"checkout_sha"`
+	Project     struct {
+		Name       string `json:"name"`
+		GitHTTPURL string `json:"git_http_url"`
+		GitSSHURL  string `json:"git_ssh_url"`
 	} `json:"project"`
-	Commits []struct {
-		ID string `json:"id"`
-	} `json:"commits"`
+	Commits           []pushCommit `json:"commits"`
+	TotalCommitsCount int          `json:"total_commits_count"`
+	UserUsername      string       `json:"user_username"`
+	UserName          string       `json:"user_name"`
+}
+
+type pushCommit struct {
+	ID       string   `json:"id"`
+	Added    []string `json:"added"`
+	Modified []string `json:"modified"`
+	Removed  []string `json:"removed"`
+}
+
+func changedPaths(commits []pushCommit) []string {
+	if len(commits) == 0 {
+		return nil
+	}
+	seen := make(map[string]bool)
+	var paths []string
+	for _, c := range commits {
+		for _, list := range [][]string{c.Added, c.Modified, c.Removed} {
+			for _, p := range list {
+				if !seen[p] {
+					seen[p] = true
+					paths = append(paths, p)
+				}
+			}
+		}
+	}
+	if len(paths) == 0 || len(paths) > models.MaxChangedPaths {
+		return nil
+	}
+	return paths
+}
+
+func (p *GitLabPushPayload) changedPaths() []string {
+	if p.TotalCommitsCount > len(p.Commits) {
+		return nil
+	}
+	return changedPaths(p.Commits)
+}
+
+type BitbucketPushPayload struct {
+	Push struct {
+		Changes []struct {
+			New *struct {
+				Type   string `json:"type"`
+				Name   string `json:"name"`
+				Target struct {
+					Hash string `json:"hash"`
+				} `json:"target"`
+			} `json:"new"`
+		} `json:"changes"`
+	} `json:"push"`
+	Actor struct {
+		Nickname    string `json:"nickname"`
+		DisplayName string `json:"display_name"`
+	} `json:"actor"`
+	Repository struct {
+		Name     string `json:"name"`
+		FullName string `json:"full_name"`
+		Links    struct {
+			HTML struct {
+				Href string `json:"href"`
+			} `json:"html"`
+		} `json:"links"`
+	} `json:"repository"`
+}
+
+func (p *BitbucketPushPayload) branchChange() (string, string) {
+	for _, c := range p.Push.Changes {
+		if c.New != nil && c.New.Type == "branch" && c.New.Name != "" {
+			return c.New.Name, c.New.Target.Hash
+		}
+	}
+	return "", ""
+}
+
+func (p *BitbucketPushPayload) urls() []string {
+	var urls []string
+	if p.Repository.Links.HTML.Href != "" {
+		urls = append(urls, p.Repository.Links.HTML.Href)
+	}
+	if p.Repository.FullName != "" {
+		urls = append(urls, "https://bitbucket.org/"+p.Repository.FullName)
+	}
+	return urls
 }
 
 func (s *WebhookService) ValidateGitHubSignature(payload []byte, signature string) bool {
-	if s.cfg.Webhook.Secret == "" {
+	var data GitHubPushPayload
+	var repo *models.Repository
+	if err := json.Unmarshal(payload, &data); err == nil {
+		repo = s.findRefRepository(data.Repository.Name, data.Ref, data.Repository.CloneURL, data.Repository.SSHURL)
+	}
+
+	secret := s.secretFor(repo)
+	if secret == "" {
 		logger.Warn("[WEBHOOK] No webhook secret configured, accepting unsigned requests")
 		return true
 	}
@@ -78,113 +225,369 @@ func (s *WebhookService) ValidateGitHubSignature(payload []byte, signature strin
 		return false
 	}
 	sig := strings.TrimPrefix(signature, "sha256=")
-	mac := hmac.New(sha256.New, []byte(s.cfg.Webhook.Secret))
+	mac := hmac.New(sha256.New, []byte(secret))
 	mac.Write(payload)
 	expected := hex.EncodeToString(mac.Sum(nil))
 	return hmac.Equal([]byte(sig), []byte(expected))
 }
 
-func (s *WebhookService) ValidateGitLabToken(token string) bool {
-	if s.cfg.Webhook.Secret == "" {
+func (s *WebhookService) ValidateGitLabToken(payload []byte, token string) bool {
+	var data GitLabPushPayload
+	var repo *models.Repository
+	if err := json.Unmarshal(payload, &data); err == nil {
+		repo = s.findRefRepository(data.Project.Name, data.Ref, data.Project.GitHTTPURL, data.Project.GitSSHURL)
+	}
+
+	secret := s.secretFor(repo)
+	if secret == "" {
+		logger.Warn("[WEBHOOK] No webhook secret configured, accepting unsigned requests")
+		return true
+	}
+	return subtle.ConstantTimeCompare([]byte(token), []byte(secret)) == 1
+}
+
+func (s *WebhookService) ValidateBitbucketRequest(payload []byte, signature, token string) bool {
+	var data BitbucketPushPayload
+	var repo *models.Repository
+	if err := json.Unmarshal(payload, &data); err == nil {
+		branch, _ := data.branchChange()
+		repo = s.findRepository(data.Repository.Name, branch, data.urls()...)
+	}
+
+	secret := s.secretFor(repo)
+	if secret == "" {
 		logger.Warn("[WEBHOOK] No webhook secret configured, accepting unsigned requests")
 		return true
 	}
-	return token == s.cfg.Webhook.Secret
+	if strings.HasPrefix(signature, "sha256=") {
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write(payload)
+		expected := hex.EncodeToString(mac.Sum(nil))
+		return hmac.Equal([]byte(strings.TrimPrefix(signature, "sha256=")), []byte(expected))
+	}
+	return token != "" && subtle.ConstantTimeCompare([]byte(token), []byte(secret)) == 1
+}
+
+func (s *WebhookService) secretFor(repo *models.Repository) string {
+	if repo != nil && repo.Secret != "" {
+		return repo.Secret
+	}
+	return s.cfg.Webhook.Secret
 }
 
-func (s *WebhookService) ProcessGitHubPush(payload []byte) (*WebhookResult, error) {
+func (s *WebhookService) ProcessGitHubPush(payload []byte, sourceIP string) (*WebhookResult, error) {
 	var data GitHubPushPayload
 	if err := json.Unmarshal(payload, &data); err != nil {
 		return nil, fmt.Errorf("failed to parse GitHub payload: %w", err)
 	}
 
+	if tag := extractTag(data.Ref); tag != "" {
+		logger.Debug("[WEBHOOK] GitHub tag push: repo=%s tag=%s commit=%s",
+			data.Repository.Name, tag, shortCommit(data.HeadCommit.ID))
+
+		repo := s.findRefRepository(data.Repository.Name, data.Ref, data.Repository.CloneURL, data.Repository.SSHURL)
+		pusher := firstNonEmpty(data.Pusher.Name, data.Sender.Login)
+		return s.triggerTag("github", repo, data.Repository.Name, tag, data.HeadCommit.ID, data.Deleted || isNullCommit(data.After), pusher, sourceIP)
+	}
+
 	branch := extractBranch(data.Ref)
 	if branch == "" {
 		return nil, fmt.Errorf("invalid git ref format: %s", data.Ref)
 	}
 
-	repoName := data.Repository.Name
-	logger.Debug("[WEBHOOK] GitHub push: repo=%s branch=%s commit=%s",
-		repoName, branch, data.HeadCommit.ID[:7])
-
-	repo := s.cfg.GetRepository(repoName)
-	if repo == nil {
-		return nil, fmt.Errorf("repository '%s' not configured in uruflow - add it first", repoName)
+	commitID := data.HeadCommit.ID
+	if commitID == "" && !isNullCommit(data.After) {
+		commitID = data.After
 	}
+	deleted := data.Deleted || isNullCommit(data.After)
+
+	logger.Debug("[WEBHOOK] GitHub push: repo=%s branch=%s commit=%s deleted=%t",
+		data.Repository.Name, branch, shortCommit(commitID), deleted)
 
-	if repo.Branch != branch {
-		return nil, fmt.Errorf("branch '%s' not configured for auto-deploy (configured branch: '%s')",
-			branch, repo.Branch)
+	repos := s.findRepositories(data.Repository.Name, branch, data.Repository.CloneURL, data.Repository.SSHURL)
+	pusher := firstNonEmpty(data.Pusher.Name, data.Sender.Login)
+	return s.triggerPushes("github", repos, data.Repository.Name, branch, commitID, deleted, pusher, sourceIP, changedPaths(data.Commits))
+}
+
+func (s *WebhookService) ProcessGitLabPush(payload []byte, sourceIP string) (*WebhookResult, error) {
+	var data GitLabPushPayload
+	if err := json.Unmarshal(payload, &data); err != nil {
+		return nil, fmt.Errorf("failed to parse GitLab payload: %w", err)
 	}
 
-	if !repo.AutoDeploy {
-		return nil, fmt.Errorf("auto-deploy is disabled for repository '%s'", repoName)
+	if tag := extractTag(data.Ref); tag != "" {
+		logger.Debug("[WEBHOOK] GitLab tag push: repo=%s tag=%s commit=%s",
+			data.Project.Name, tag, shortCommit(data.CheckoutSHA))
+
+		repo := s.findRefRepository(data.Project.Name, data.Ref, data.Project.GitHTTPURL, data.Project.GitSSHURL)
+		pusher := firstNonEmpty(data.UserUsername, data.UserName)
+		return s.triggerTag("gitlab", repo, data.Project.Name, tag, data.CheckoutSHA, data.CheckoutSHA == "", pusher, sourceIP)
 	}
 
-	logger.Info("[WEBHOOK] Triggering deployment: repo=%s branch=%s agent=%s",
-		repoName, branch, repo.AgentID)
+	branch := extractBranch(data.Ref)
+	if branch == "" {
+		return nil, fmt.Errorf("invalid git ref format: %s", data.Ref)
+	}
 
-	deploy, err := s.deployService.TriggerDeploy(repo.AgentID, repoName, branch, data.HeadCommit.ID, "webhook")
-	if err != nil {
-		return nil, fmt.Errorf("trigger deployment failed: %w", err)
+	commitID := data.CheckoutSHA
+	if commitID == "" && !isNullCommit(data.After) {
+		commitID = data.After
+	}
+	if commitID == "" && len(data.Commits) > 0 {
+		commitID = data.Commits[len(data.Commits)-1].ID
 	}
+	deleted := isNullCommit(data.After)
 
-	return &WebhookResult{
-		Repository: repoName,
-		Branch:     branch,
-		Commit:     data.HeadCommit.ID[:7],
-		Deployment: deploy,
-	}, nil
+	logger.Debug("[WEBHOOK] GitLab push: repo=%s branch=%s commit=%s deleted=%t",
+		data.Project.Name, branch, shortCommit(commitID), deleted)
+
+	repos := s.findRepositories(data.Project.Name, branch, data.Project.GitHTTPURL, data.Project.GitSSHURL)
+	pusher := firstNonEmpty(data.UserUsername, data.UserName)
+	return s.triggerPushes("gitlab", repos, data.Project.Name, branch, commitID, deleted, pusher, sourceIP, data.changedPaths())
 }
 
-func (s *WebhookService) ProcessGitLabPush(payload []byte) (*WebhookResult, error) {
-	var data GitLabPushPayload
+func (s *WebhookService) ProcessBitbucketPush(payload []byte, sourceIP string) (*WebhookResult, error) {
+	var data BitbucketPushPayload
 	if err := json.Unmarshal(payload, &data); err != nil {
-		return nil, fmt.Errorf("failed to parse GitLab payload: %w", err)
+		return nil, fmt.Errorf("failed to parse Bitbucket payload: %w", err)
 	}
 
-	branch := extractBranch(data.Ref)
+	branch, commitID := data.branchChange()
 	if branch == "" {
-		return nil, fmt.Errorf("invalid git ref format: %s", data.Ref)
+		return nil, fmt.Errorf("push contains no branch updates")
+	}
+
+	logger.Debug("[WEBHOOK] Bitbucket push: repo=%s branch=%s commit=%s",
+		data.Repository.FullName, branch, shortCommit(commitID))
+
+	repos := s.findRepositories(data.Repository.Name, branch, data.urls()...)
+	pusher := firstNonEmpty(data.Actor.Nickname, data.Actor.DisplayName)
+	return s.triggerPushes("bitbucket", repos, data.Repository.Name, branch, commitID, false, pusher, sourceIP, nil)
+}
+
+func (s *WebhookService) findRepository(name, branch string, urls ...string) *models.Repository {
+	for _, u := range urls {
+		if repo := s.cfg.GetRepositoryByURL(u, branch); repo != nil {
+			return repo
+		}
+	}
+	return s.cfg.GetRepository(name)
+}
+
+func (s *WebhookService) findRepositories(name, branch string, urls ...string) []*models.Repository {
+	for _, u := range urls {
+		if repos := s.cfg.GetRepositoriesByURL(u, branch); len(repos) > 0 {
+			return repos
+		}
+	}
+	if repo := s.findRepository(name, branch, urls...); repo != nil {
+		return []*models.Repository{repo}
+	}
+	return nil
+}
+
+func (s *WebhookService) findRefRepository(name, ref string, urls ...string) *models.Repository {
+	tag := extractTag(ref)
+	if tag == "" {
+		return s.findRepository(name, extractBranch(ref), urls...)
+	}
+	for _, u := range urls {
+		if repo := s.cfg.GetRepositoryByTag(u, tag); repo != nil {
+			return repo
+		}
+	}
+	return s.cfg.GetRepository(name)
+}
+
+func (s *WebhookService) triggerPushes(source string, repos []*models.Repository, pushedName, branch, commit string, deleted bool, pusher, sourceIP string, paths []string) (*WebhookResult, error) {
+	if len(repos) <= 1 {
+		var repo *models.Repository
+		if len(repos) == 1 {
+			repo = repos[0]
+		}
+		return s.triggerPush(source, repo, pushedName, branch, commit, deleted, pusher, sourceIP, paths)
+	}
+
+	results := make([]*WebhookResult, len(repos))
+	errs := make([]error, len(repos))
+	best := 0
+	for i, repo := range repos {
+		results[i], errs[i] = s.triggerPush(source, repo, pushedName, branch, commit, deleted, pusher, sourceIP, paths)
+		if pushRank(results[i], errs[i]) > pushRank(results[best], errs[best]) {
+			best = i
+		}
+	}
+	for i := range repos {
+		if i != best {
+			s.recordPush(source, results[i], errs[i])
+		}
 	}
+	return results[best], errs[best]
+}
+
+func pushRank(result *WebhookResult, err error) int {
+	switch {
+	case err != nil:
+		return 0
+	case result.Deployment != nil:
+		return 3
+	case result.Coalesced:
+		return 2
+	default:
+		return 1
+	}
+}
 
-	repoName := data.Project.Name
-	commitID := ""
-	if len(data.Commits) > 0 {
-		commitID = data.Commits[0].ID
+func (s *WebhookService) recordPush(source string, result *WebhookResult, err error) {
+	switch {
+	case err != nil:
+		logger.Error("[WEBHOOK] %s push to %s failed: %v", source, result.Repository, err)
+		s.RecordEvent(source, WebhookRejected, result, err.Error())
+	case result.Coalesced:
+		s.RecordEvent(source, WebhookCoalesced, result, "deploy cooldown, latest push deploys at "+result.DeployAt.Format("15:04:05"))
+	case result.Ignored != "":
+		logger.Info("[WEBHOOK] Ignoring %s push to %s: %s", source, result.Repository, result.Ignored)
+		s.RecordEvent(source, WebhookIgnored, result, result.Ignored)
+	default:
+		logger.Info("[WEBHOOK] %s deployment triggered: repo=%s branch=%s commit=%s deployment_id=%s",
+			source, result.Repository, result.Branch, result.Commit, result.Deployment.ID)
+		s.RecordEvent(source, WebhookAccepted, result, "")
 	}
+}
 
-	logger.Debug("[WEBHOOK] GitLab push: repo=%s branch=%s commit=%s",
-		repoName, branch, commitID[:7])
+func (s *WebhookService) triggerPush(source string, repo *models.Repository, pushedName, branch, commit string, deleted bool, pusher, sourceIP string, paths []string) (*WebhookResult, error) {
+	result := &WebhookResult{
+		Repository: pushedName,
+		Branch:     branch,
+		Commit:     shortCommit(commit),
+	}
 
-	repo := s.cfg.GetRepository(repoName)
 	if repo == nil {
-		return nil, fmt.Errorf("repository '%s' not configured in uruflow - add it first", repoName)
+		return result, fmt.Errorf("repository '%s' not configured in uruflow - add it first", pushedName)
 	}
+	result.Repository = repo.Name
 
-	if repo.Branch != branch {
-		return nil, fmt.Errorf("branch '%s' not configured for auto-deploy (configured branch: '%s')",
-			branch, repo.Branch)
+	if deleted {
+		result.Ignored = fmt.Sprintf("branch '%s' was deleted", branch)
+		return result, nil
+	}
+	if err := checkPush(repo, branch); err != nil {
+		return result, err
+	}
+	if paths != nil && !repo.MatchesPaths(paths) {
+		logger.Debug("[WEBHOOK] Push to %s touches none of %s", repo.Name, strings.Join(repo.PathFilters, ", "))
+		result.Ignored = "skipped: no matching paths"
+		return result, nil
+	}
+
+	if deployAt, ok := s.coalesce(pendingPush{
+		source: source, repo: repo.Name, branch: branch, commit: commit, pusher: pusher, sourceIP: sourceIP,
+	}); ok {
+		logger.Info("[WEBHOOK] Coalescing push: repo=%s branch=%s commit=%s, latest push deploys at %s",
+			repo.Name, branch, shortCommit(commit), deployAt.Format("15:04:05"))
+		result.Coalesced = true
+		result.DeployAt = deployAt
+		return result, nil
+	}
+
+	return result, s.deployPush(result, repo, branch, commit, pusher, sourceIP)
+}
+
+func checkPush(repo *models.Repository, branch string) error {
+	if !repo.MatchesBranch(branch) {
+		return fmt.Errorf("branch '%s' not configured for auto-deploy (configured branches: '%s')",
+			branch, strings.Join(repo.BranchPatterns(), "', '"))
 	}
 
 	if !repo.AutoDeploy {
-		return nil, fmt.Errorf("auto-deploy is disabled for repository '%s'", repoName)
+		return fmt.Errorf("auto-deploy is disabled for repository '%s'", repo.Name)
 	}
+	return nil
+}
 
+func (s *WebhookService) deployPush(result *WebhookResult, repo *models.Repository, branch, commit, pusher, sourceIP string) error {
 	logger.Info("[WEBHOOK] Triggering deployment: repo=%s branch=%s agent=%s",
-		repoName, branch, repo.AgentID)
+		repo.Name, branch, repo.Target())
 
-	deploy, err := s.deployService.TriggerDeploy(repo.AgentID, repoName, branch, commitID, "webhook")
+	deploy, err := s.deployService.TriggerDeploy(repo.AgentID, repo.Name, branch, commit, TriggerOptions{
+		Trigger:     "webhook",
+		TriggeredBy: pusher,
+		SourceIP:    sourceIP,
+	})
 	if err != nil {
-		return nil, fmt.Errorf("trigger deployment failed: %w", err)
+		return fmt.Errorf("trigger deployment failed: %w", err)
 	}
 
-	return &WebhookResult{
-		Repository: repoName,
-		Branch:     branch,
-		Commit:     commitID,
-		Deployment: deploy,
-	}, nil
+	result.Deployment = deploy
+	return nil
+}
+
+func (s *WebhookService) triggerTag(source string, repo *models.Repository, pushedName, tag, commit string, deleted bool, pusher, sourceIP string) (*WebhookResult, error) {
+	result := &WebhookResult{
+		Repository: pushedName,
+		Tag:        tag,
+		Commit:     shortCommit(commit),
+	}
+
+	if repo == nil {
+		return result, fmt.Errorf("repository '%s' not configured in uruflow - add it first", pushedName)
+	}
+	result.Repository = repo.Name
+
+	if deleted {
+		result.Ignored = fmt.Sprintf("tag '%s' was deleted", tag)
+		return result, nil
+	}
+	if repo.TagPattern == "" {
+		result.Ignored = fmt.Sprintf("repository '%s' has no tag_pattern, tag '%s' not deployed", repo.Name, tag)
+		return result, nil
+	}
+	if !repo.MatchesTag(tag) {
+		result.Ignored = fmt.Sprintf("tag '%s' does not match tag_pattern '%s'", tag, repo.TagPattern)
+		return result, nil
+	}
+	if !repo.AutoDeploy {
+		return result, fmt.Errorf("auto-deploy is disabled for repository '%s'", repo.Name)
+	}
+	if commit == "" {
+		return result, fmt.Errorf("tag push for '%s' carries no commit", tag)
+	}
+
+	logger.Info("[WEBHOOK] Triggering tag deployment: repo=%s tag=%s commit=%s agent=%s",
+		repo.Name, tag, shortCommit(commit), repo.Target())
+
+	deploy, err := s.deployService.TriggerDeploy(repo.AgentID, repo.Name, "", commit, TriggerOptions{
+		Trigger:     "tag",
+		TriggeredBy: pusher,
+		SourceIP:    sourceIP,
+		Tag:         tag,
+	})
+	if err != nil {
+		return result, fmt.Errorf("trigger deployment failed: %w", err)
+	}
+
+	result.Deployment = deploy
+	return result, nil
+}
+
+func shortCommit(commit string) string {
+	if len(commit) > 7 {
+		return commit[:7]
+	}
+	return commit
+}
+
+func isNullCommit(sha string) bool {
+	return sha != "" && strings.Trim(sha, "0") == ""
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
 }
 
 func extractBranch(ref string) string {
@@ -193,3 +596,10 @@ func extractBranch(ref string) string {
 	}
 	return ""
 }
+
+func extractTag(ref string) string {
+	if strings.HasPrefix(ref, "refs/tags/") {
+		return strings.TrimPrefix(ref, "refs/tags/")
+	}
+	return ""
+}
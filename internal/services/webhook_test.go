@@ -0,0 +1,190 @@
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/urustack/uruflow/internal/models"
+	"github.com/urustack/uruflow/internal/tcp"
+)
+
+func newMonorepoWebhook(t *testing.T) *WebhookService {
+	t.Helper()
+	f := newAgentFixture(t)
+	repo := models.Repository{
+		Name: "monorepo-api", URL: "https://github.com/acme/monorepo.git", Branch: "main", AgentID: f.web,
+		BuildSystem: "compose", AutoDeploy: true, PathFilters: []string{"services/api/", "go.mod"},
+	}
+	if err := f.cfg.AddRepository(repo); err != nil {
+		t.Fatal(err)
+	}
+	deployService := NewDeploymentService(f.cfg, f.store, tcp.NewServer(f.cfg, f.store))
+	return NewWebhookService(f.cfg, deployService, f.store)
+}
+
+func githubPush(t *testing.T, files ...string) []byte {
+	t.Helper()
+	payload := map[string]any{
+		"ref":    "refs/heads/main",
+		"after":  "9f2c1e4b7a0d3c5e8f1a2b3c4d5e6f7a8b9c0d1e",
+		"before": "1a2b3c4d5e6f7a8b9c0d1e2f3a4b5c6d7e8f9a0b",
+		"repository": map[string]any{
+			"name":      "monorepo",
+			"full_name": "acme/monorepo",
+			"clone_url": "https://github.com/acme/monorepo.git",
+			"ssh_url":   "git@github.com:acme/monorepo.git",
+		},
+		"pusher":      map[string]any{"name": "octocat", "email": "octocat@github.com"},
+		"sender":      map[string]any{"login": "octocat"},
+		"head_commit": map[string]any{"id": "9f2c1e4b7a0d3c5e8f1a2b3c4d5e6f7a8b9c0d1e", "message": "update"},
+		"commits": []map[string]any{
+			{"id": "9f2c1e4b7a0d3c5e8f1a2b3c4d5e6f7a8b9c0d1e", "added": []string{}, "removed": []string{}, "modified": files},
+		},
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestPathFiltersSkipUnrelatedPush(t *testing.T) {
+	s := newMonorepoWebhook(t)
+
+	result, err := s.ProcessGitHubPush(githubPush(t, "docs/index.md", "README.md"), "140.82.112.1")
+	if err != nil {
+		t.Fatalf("ProcessGitHubPush: %v", err)
+	}
+	if result.Ignored != "skipped: no matching paths" {
+		t.Fatalf("Ignored = %q, want the push skipped", result.Ignored)
+	}
+
+	_, err = s.ProcessGitHubPush(githubPush(t, "docs/index.md", "services/api/handler.go"), "140.82.112.1")
+	if !errors.Is(err, ErrAgentNotConnected) {
+		t.Fatalf("push touching services/api = %v, want a deploy attempt", err)
+	}
+}
+
+func TestPathFiltersDeployWhenTooManyFiles(t *testing.T) {
+	s := newMonorepoWebhook(t)
+	files := make([]string, models.MaxChangedPaths+1)
+	for i := range files {
+		files[i] = fmt.Sprintf("docs/page-%03d.md", i)
+	}
+
+	if _, err := s.ProcessGitHubPush(githubPush(t, files...), "140.82.112.1"); !errors.Is(err, ErrAgentNotConnected) {
+		t.Fatalf("push with %d files = %v, want a deploy attempt", len(files), err)
+	}
+	result, err := s.ProcessGitHubPush(githubPush(t, files[:models.MaxChangedPaths]...), "140.82.112.1")
+	if err != nil || result.Ignored != "skipped: no matching paths" {
+		t.Fatalf("push with %d files = %+v, %v; want it skipped", models.MaxChangedPaths, result, err)
+	}
+}
+
+const gitlabTruncatedPush = `{
+  "object_kind": "push",
+  "event_name": "push",
+  "before": "95790bf891e76fee5e1747ab589903a6a1f80f22",
+  "after": "da1560886d4f094c3e6c9ef40349f7d38b5d27d7",
+  "ref": "refs/heads/main",
+  "checkout_sha": "da1560886d4f094c3e6c9ef40349f7d38b5d27d7",
+  "user_name": "John Smith",
+  "user_username": "jsmith",
+  "project": {
+    "name": "monorepo",
+    "git_http_url": "https://github.com/acme/monorepo.git",
+    "git_ssh_url": "git@github.com:acme/monorepo.git"
+  },
+  "commits": [
+    {
+      "id": "da1560886d4f094c3e6c9ef40349f7d38b5d27d7",
+      "message": "fixed readme",
+      "added": [],
+      "modified": ["README.md"],
+      "removed": []
+    }
+  ],
+  "total_commits_count": 24
+}`
+
+func TestGitLabTruncatedPushAlwaysDeploys(t *testing.T) {
+	s := newMonorepoWebhook(t)
+
+	if _, err := s.ProcessGitLabPush([]byte(gitlabTruncatedPush), "10.0.0.9"); !errors.Is(err, ErrAgentNotConnected) {
+		t.Fatalf("truncated GitLab push = %v, want a deploy attempt", err)
+	}
+
+	var complete map[string]any
+	if err := json.Unmarshal([]byte(gitlabTruncatedPush), &complete); err != nil {
+		t.Fatal(err)
+	}
+	complete["total_commits_count"] = 1
+	data, _ := json.Marshal(complete)
+	result, err := s.ProcessGitLabPush(data, "10.0.0.9")
+	if err != nil || result.Ignored != "skipped: no matching paths" {
+		t.Fatalf("complete GitLab push = %+v, %v; want it skipped", result, err)
+	}
+}
+
+const githubBranchDelete = `{
+  "ref": "refs/heads/main",
+  "before": "9f2c1e4b7a0d3c5e8f1a2b3c4d5e6f7a8b9c0d1e",
+  "after": "0000000000000000000000000000000000000000",
+  "created": false,
+  "deleted": true,
+  "forced": false,
+  "base_ref": null,
+  "commits": [],
+  "head_commit": null,
+  "repository": {
+    "name": "monorepo",
+    "full_name": "acme/monorepo",
+    "clone_url": "https://github.com/acme/monorepo.git",
+    "ssh_url": "git@github.com:acme/monorepo.git"
+  },
+  "pusher": {"name": "octocat", "email": "octocat@github.com"},
+  "sender": {"login": "octocat"}
+}`
+
+func TestBranchDeletionIsIgnored(t *testing.T) {
+	s := newMonorepoWebhook(t)
+
+	result, err := s.ProcessGitHubPush([]byte(githubBranchDelete), "140.82.112.1")
+	if err != nil || result.Ignored != "branch 'main' was deleted" {
+		t.Fatalf("GitHub branch deletion = %+v, %v; want it ignored", result, err)
+	}
+
+	var gitlab map[string]any
+	if err := json.Unmarshal([]byte(gitlabTruncatedPush), &gitlab); err != nil {
+		t.Fatal(err)
+	}
+	gitlab["after"] = "0000000000000000000000000000000000000000"
+	gitlab["checkout_sha"] = nil
+	gitlab["commits"] = []any{}
+	gitlab["total_commits_count"] = 0
+	data, _ := json.Marshal(gitlab)
+	result, err = s.ProcessGitLabPush(data, "10.0.0.9")
+	if err != nil || result.Ignored != "branch 'main' was deleted" {
+		t.Fatalf("GitLab branch deletion = %+v, %v; want it ignored", result, err)
+	}
+}
@@ -18,48 +18,112 @@
 
 package storage
 
-import "github.com/urustack/uruflow/internal/models"
+import (
+	"time"
+
+	"github.com/urustack/uruflow/internal/models"
+)
 
 type Store interface {
 	CreateAgent(agent *models.Agent) error
 	UpdateAgent(agent *models.Agent) error
 	UpdateAgentMetrics(id string, metrics *models.AgentMetrics) error
+	AddAgentReclaimed(id string, bytes uint64) error
+	RecordMetrics(sample *models.MetricsSample) error
+	GetMetricsHistory(agentID string, since time.Time) ([]models.MetricsSample, error)
+	PruneMetricsHistory(olderThan time.Time) (int64, error)
 	UpdateAgentStatus(id string, status models.AgentStatus) error
+	SetAgentLabels(id string, labels map[string]string) error
+	RenameAgent(id, name string) error
+	SetAgentMaintenance(id string, enabled bool) error
 	GetAgent(id string) (*models.Agent, error)
 	GetAgentByToken(token string) (*models.Agent, error)
 	GetAllAgents() ([]models.Agent, error)
-	DeleteAgent(id string) error
+	DeleteAgent(id, reassignTo string) error
 
 	UpsertContainer(c *models.Container) error
 	GetContainersByAgent(agentID string) ([]models.Container, error)
 	DeleteContainersByAgent(agentID string) error
+	SyncContainers(agentID string, present []models.Container) error
 
 	CreateRepository(repo *models.Repository) error
 	UpdateRepository(repo *models.Repository) error
 	GetRepository(name string) (*models.Repository, error)
 	GetAllRepositories() ([]models.Repository, error)
 	DeleteRepository(name string) error
+	SetRepositoryDrift(name string, findings []string) error
 
 	CreateDeployment(d *models.Deployment) error
 	UpdateDeployment(d *models.Deployment) error
+	ApproveDeployment(d *models.Deployment) (bool, error)
+	RejectDeployment(d *models.Deployment) (bool, error)
 	GetDeployment(id string) (*models.Deployment, error)
+	GetDeploymentsByStatus(status models.DeployStatus) ([]models.Deployment, error)
 	GetRecentDeployments(limit int) ([]models.Deployment, error)
 	GetDeploymentsByAgent(agentID string, limit int) ([]models.Deployment, error)
 	GetDeploymentsByRepo(repoName string, limit int) ([]models.Deployment, error)
+	GetLastDeployment(repoName string) (*models.Deployment, error)
+	GetDeploymentsPage(offset, limit int, filter DeploymentFilter) ([]models.Deployment, int, error)
+	GetStaleDeployments(startedBefore, quietSince time.Time) ([]models.Deployment, error)
 
 	AddDeploymentLog(log *models.DeploymentLog) error
+	AddDeploymentLogsBatch(logs []models.DeploymentLog) error
 	GetDeploymentLogs(deploymentID string) ([]models.DeploymentLog, error)
+	GetDeploymentLogsPage(deploymentID string, afterID int64, limit int) ([]models.DeploymentLog, error)
+	GetDeploymentLogsBefore(deploymentID string, beforeID int64, limit int) ([]models.DeploymentLog, error)
+	AddDeploymentStep(step *models.DeploymentStep) error
+	GetDeploymentSteps(deploymentID string) ([]models.DeploymentStep, error)
+	AddDeploymentContainers(deploymentID string, containers []models.DeployedContainer) error
+	GetDeploymentContainers(deploymentID string) ([]models.DeployedContainer, error)
+	SaveDeploymentArtifact(artifact *models.DeploymentArtifact) error
+	GetDeploymentArtifacts(deploymentID string) ([]models.DeploymentArtifact, error)
+	GetDeploymentArtifact(deploymentID, name string) (*models.DeploymentArtifact, error)
+	PruneDeploymentLogs(olderThan time.Time) (int64, error)
+	PruneDeployments(keep int) (int64, error)
+	Vacuum() error
+
+	RecordAgentEvent(e *models.AgentEvent) error
+	GetAgentEvents(agentID string, limit int) ([]models.AgentEvent, error)
+	PruneAgentEvents(keep int) (int64, error)
+
+	AddWebhookEvent(e *models.WebhookEvent) error
+	GetWebhookEvents(limit int) ([]models.WebhookEvent, error)
+	PruneWebhookEvents(keep int) (int64, error)
 
 	CreateAlert(a *models.Alert) error
+	UpdateAlert(a *models.Alert) error
 	ResolveAlert(id string) error
+	ResolveAlerts(ids []string) (int64, error)
+	AutoResolveAlert(id string) error
+	ResolveAlertsByTypeAndAgent(agentID, alertType string) (int64, error)
 	GetActiveAlerts() ([]models.Alert, error)
+	GetActiveAlertsFiltered(alertType, severity string) ([]models.Alert, error)
 	GetRecentAlerts(hours int) ([]models.Alert, error)
 	GetAlertsByAgent(agentID string) ([]models.Alert, error)
 
+	SyncCheckouts(agentID string, checkouts []models.Checkout) error
+	GetCheckoutsByRepo(repository string) ([]models.Checkout, error)
+
+	CreateCommand(c *models.Command) error
+	UpdateCommand(c *models.Command) error
+	GetCommandsByAgent(agentID string, limit int) ([]models.Command, error)
+
 	GetStats() (*Stats, error)
+	GetRepoStats() ([]RepoStats, error)
 	Close() error
 }
 
+type DeploymentFilter struct {
+	Repo    string
+	AgentID string
+	Status  models.DeployStatus
+	Trigger string
+}
+
+func (f DeploymentFilter) IsEmpty() bool {
+	return f.Repo == "" && f.AgentID == "" && f.Status == "" && f.Trigger == ""
+}
+
 type Stats struct {
 	AgentsTotal       int
 	AgentsOnline      int
@@ -71,3 +135,14 @@ type Stats struct {
 	ContainersStopped int
 	AlertsActive      int
 }
+
+const RepoStatsWindow = 30 * 24 * time.Hour
+
+type RepoStats struct {
+	Repo        string
+	LastDeploy  *time.Time
+	Deploys     int
+	SuccessRate float64
+	AvgDuration time.Duration
+	FailStreak  int
+}
@@ -20,16 +20,19 @@ package sqlite
 
 import (
 	"database/sql"
+	"fmt"
 	"time"
 
 	"github.com/urustack/uruflow/internal/models"
+	"github.com/urustack/uruflow/pkg/helper"
 )
 
 func (s *Store) CreateAgent(agent *models.Agent) error {
 	_, err := s.db.Exec(`
-		INSERT INTO agents (id, name, token, host, hostname, version, status, last_heartbeat, created_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
-	`, agent.ID, agent.Name, agent.Token, agent.Host, agent.Hostname, agent.Version, agent.Status, agent.LastHeartbeat, time.Now())
+		INSERT INTO agents (id, name, token_hash, host, hostname, machine_id, version, status, labels, clock_skew_ms, degraded, last_heartbeat, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, agent.ID, agent.Name, agent.TokenHash, agent.Host, agent.Hostname, agent.MachineID, agent.Version, agent.Status,
+		models.FormatLabels(agent.Labels), agent.ClockSkewMs, agent.Degraded, agent.LastHeartbeat, time.Now())
 	return err
 }
 
@@ -38,15 +41,26 @@ func (s *Store) UpdateAgent(agent *models.Agent) error {
 		UPDATE agents SET
 			host = COALESCE(NULLIF(?, ''), host),
 			hostname = COALESCE(NULLIF(?, ''), hostname),
+			machine_id = COALESCE(NULLIF(?, ''), machine_id),
 			version = COALESCE(NULLIF(?, ''), version),
 			status = ?,
+			clock_skew_ms = ?,
+			degraded = ?,
 			last_heartbeat = ?
 		WHERE id = ?
-	`, agent.Host, agent.Hostname, agent.Version, agent.Status, agent.LastHeartbeat, agent.ID)
+	`, agent.Host, agent.Hostname, agent.MachineID, agent.Version, agent.Status, agent.ClockSkewMs, agent.Degraded, agent.LastHeartbeat, agent.ID)
 	return err
 }
 
 func (s *Store) UpdateAgentMetrics(id string, metrics *models.AgentMetrics) error {
+	var images, containers, volumes, buildCache sql.NullInt64
+	if u := metrics.DockerDisk; u != nil {
+		images = sql.NullInt64{Int64: int64(u.Images), Valid: true}
+		containers = sql.NullInt64{Int64: int64(u.Containers), Valid: true}
+		volumes = sql.NullInt64{Int64: int64(u.Volumes), Valid: true}
+		buildCache = sql.NullInt64{Int64: int64(u.BuildCache), Valid: true}
+	}
+
 	_, err := s.db.Exec(`
 		UPDATE agents SET
 			cpu_percent = ?,
@@ -57,12 +71,52 @@ func (s *Store) UpdateAgentMetrics(id string, metrics *models.AgentMetrics) erro
 			disk_used = ?,
 			disk_total = ?,
 			uptime = ?,
+			queued_deploys = ?,
+			low_disk_for_deploys = ?,
+			docker_images_bytes = ?,
+			docker_containers_bytes = ?,
+			docker_volumes_bytes = ?,
+			docker_build_cache_bytes = ?,
 			status = 'online',
 			last_heartbeat = ?
 		WHERE id = ?
 	`, metrics.CPUPercent, metrics.MemoryPercent, metrics.DiskPercent,
 		metrics.MemoryUsed, metrics.MemoryTotal, metrics.DiskUsed, metrics.DiskTotal,
-		metrics.Uptime, time.Now(), id)
+		metrics.Uptime, metrics.QueuedDeploys, metrics.LowDisk, images, containers, volumes, buildCache, time.Now(), id)
+	return err
+}
+
+func (s *Store) AddAgentReclaimed(id string, bytes uint64) error {
+	_, err := s.db.Exec(`
+		UPDATE agents SET reclaimed_bytes = reclaimed_bytes + ?, last_cleanup_at = ? WHERE id = ?
+	`, bytes, time.Now(), id)
+	return err
+}
+
+func scanDockerDisk(images, containers, volumes, buildCache sql.NullInt64) *models.DockerDiskUsage {
+	if !images.Valid {
+		return nil
+	}
+	return &models.DockerDiskUsage{
+		Images:     uint64(images.Int64),
+		Containers: uint64(containers.Int64),
+		Volumes:    uint64(volumes.Int64),
+		BuildCache: uint64(buildCache.Int64),
+	}
+}
+
+func (s *Store) SetAgentMaintenance(id string, enabled bool) error {
+	_, err := s.db.Exec(`UPDATE agents SET maintenance = ? WHERE id = ?`, enabled, id)
+	return err
+}
+
+func (s *Store) RenameAgent(id, name string) error {
+	_, err := s.db.Exec(`UPDATE agents SET name = ? WHERE id = ?`, name, id)
+	return err
+}
+
+func (s *Store) SetAgentLabels(id string, labels map[string]string) error {
+	_, err := s.db.Exec(`UPDATE agents SET labels = ? WHERE id = ?`, models.FormatLabels(labels), id)
 	return err
 }
 
@@ -73,22 +127,29 @@ func (s *Store) UpdateAgentStatus(id string, status models.AgentStatus) error {
 
 func (s *Store) GetAgent(id string) (*models.Agent, error) {
 	agent := &models.Agent{}
-	var lastHeartbeat, createdAt sql.NullTime
+	var lastHeartbeat, createdAt, cleanedAt sql.NullTime
 	var cpu, mem, disk float64
 	var memUsed, memTotal, diskUsed, diskTotal uint64
 	var uptime int64
+	var queued int
+	var lowDisk bool
+	var labels sql.NullString
+	var dockerImages, dockerContainers, dockerVolumes, dockerBuildCache sql.NullInt64
 
 	err := s.db.QueryRow(`
-		SELECT id, name, token, host, hostname, version, status,
+		SELECT id, name, token_hash, host, hostname, machine_id, version, status, labels, clock_skew_ms, degraded, maintenance,
 			cpu_percent, memory_percent, disk_percent,
-			memory_used, memory_total, disk_used, disk_total, uptime,
-			last_heartbeat, created_at
+			memory_used, memory_total, disk_used, disk_total, uptime, queued_deploys, low_disk_for_deploys,
+			docker_images_bytes, docker_containers_bytes, docker_volumes_bytes, docker_build_cache_bytes,
+			reclaimed_bytes, last_cleanup_at, last_heartbeat, created_at
 		FROM agents WHERE id = ?
 	`, id).Scan(
-		&agent.ID, &agent.Name, &agent.Token, &agent.Host, &agent.Hostname, &agent.Version, &agent.Status,
+		&agent.ID, &agent.Name, &agent.TokenHash, &agent.Host, &agent.Hostname, &agent.MachineID, &agent.Version, &agent.Status,
+		&labels, &agent.ClockSkewMs, &agent.Degraded, &agent.Maintenance,
 		&cpu, &mem, &disk,
-		&memUsed, &memTotal, &diskUsed, &diskTotal, &uptime,
-		&lastHeartbeat, &createdAt,
+		&memUsed, &memTotal, &diskUsed, &diskTotal, &uptime, &queued, &lowDisk,
+		&dockerImages, &dockerContainers, &dockerVolumes, &dockerBuildCache,
+		&agent.ReclaimedBytes, &cleanedAt, &lastHeartbeat, &createdAt,
 	)
 
 	if err == sql.ErrNoRows {
@@ -104,6 +165,10 @@ func (s *Store) GetAgent(id string) (*models.Agent, error) {
 	if createdAt.Valid {
 		agent.RegisteredAt = createdAt.Time
 	}
+	if cleanedAt.Valid {
+		agent.LastCleanup = cleanedAt.Time
+	}
+	agent.Labels, _ = models.ParseLabels(labels.String)
 
 	agent.Metrics = &models.AgentMetrics{
 		CPUPercent:    cpu,
@@ -114,6 +179,9 @@ func (s *Store) GetAgent(id string) (*models.Agent, error) {
 		DiskUsed:      diskUsed,
 		DiskTotal:     diskTotal,
 		Uptime:        uptime,
+		QueuedDeploys: queued,
+		LowDisk:       lowDisk,
+		DockerDisk:    scanDockerDisk(dockerImages, dockerContainers, dockerVolumes, dockerBuildCache),
 	}
 
 	return agent, nil
@@ -122,9 +190,9 @@ func (s *Store) GetAgent(id string) (*models.Agent, error) {
 func (s *Store) GetAgentByToken(token string) (*models.Agent, error) {
 	agent := &models.Agent{}
 	err := s.db.QueryRow(`
-		SELECT id, name, token, host, hostname, version, status
-		FROM agents WHERE token = ?
-	`, token).Scan(&agent.ID, &agent.Name, &agent.Token, &agent.Host, &agent.Hostname, &agent.Version, &agent.Status)
+		SELECT id, name, token_hash, host, hostname, version, status
+		FROM agents WHERE token_hash = ?
+	`, helper.HashToken(token)).Scan(&agent.ID, &agent.Name, &agent.TokenHash, &agent.Host, &agent.Hostname, &agent.Version, &agent.Status)
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
@@ -133,10 +201,11 @@ func (s *Store) GetAgentByToken(token string) (*models.Agent, error) {
 
 func (s *Store) GetAllAgents() ([]models.Agent, error) {
 	rows, err := s.db.Query(`
-		SELECT id, name, token, host, hostname, version, status,
+		SELECT id, name, token_hash, host, hostname, machine_id, version, status, labels, clock_skew_ms, degraded, maintenance,
 			cpu_percent, memory_percent, disk_percent,
-			memory_used, memory_total, disk_used, disk_total, uptime,
-			last_heartbeat, created_at
+			memory_used, memory_total, disk_used, disk_total, uptime, queued_deploys, low_disk_for_deploys,
+			docker_images_bytes, docker_containers_bytes, docker_volumes_bytes, docker_build_cache_bytes,
+			reclaimed_bytes, last_cleanup_at, last_heartbeat, created_at
 		FROM agents ORDER BY name
 	`)
 	if err != nil {
@@ -147,16 +216,22 @@ func (s *Store) GetAllAgents() ([]models.Agent, error) {
 	var agents []models.Agent
 	for rows.Next() {
 		var a models.Agent
-		var lastHeartbeat, createdAt sql.NullTime
+		var lastHeartbeat, createdAt, cleanedAt sql.NullTime
 		var cpu, mem, disk float64
 		var memUsed, memTotal, diskUsed, diskTotal uint64
 		var uptime int64
+		var queued int
+		var lowDisk bool
+		var labels sql.NullString
+		var dockerImages, dockerContainers, dockerVolumes, dockerBuildCache sql.NullInt64
 
 		err := rows.Scan(
-			&a.ID, &a.Name, &a.Token, &a.Host, &a.Hostname, &a.Version, &a.Status,
+			&a.ID, &a.Name, &a.TokenHash, &a.Host, &a.Hostname, &a.MachineID, &a.Version, &a.Status,
+			&labels, &a.ClockSkewMs, &a.Degraded, &a.Maintenance,
 			&cpu, &mem, &disk,
-			&memUsed, &memTotal, &diskUsed, &diskTotal, &uptime,
-			&lastHeartbeat, &createdAt,
+			&memUsed, &memTotal, &diskUsed, &diskTotal, &uptime, &queued, &lowDisk,
+			&dockerImages, &dockerContainers, &dockerVolumes, &dockerBuildCache,
+			&a.ReclaimedBytes, &cleanedAt, &lastHeartbeat, &createdAt,
 		)
 		if err != nil {
 			return nil, err
@@ -168,6 +243,10 @@ func (s *Store) GetAllAgents() ([]models.Agent, error) {
 		if createdAt.Valid {
 			a.RegisteredAt = createdAt.Time
 		}
+		if cleanedAt.Valid {
+			a.LastCleanup = cleanedAt.Time
+		}
+		a.Labels, _ = models.ParseLabels(labels.String)
 
 		a.Metrics = &models.AgentMetrics{
 			CPUPercent:    cpu,
@@ -178,6 +257,9 @@ func (s *Store) GetAllAgents() ([]models.Agent, error) {
 			DiskUsed:      diskUsed,
 			DiskTotal:     diskTotal,
 			Uptime:        uptime,
+			QueuedDeploys: queued,
+			LowDisk:       lowDisk,
+			DockerDisk:    scanDockerDisk(dockerImages, dockerContainers, dockerVolumes, dockerBuildCache),
 		}
 
 		agents = append(agents, a)
@@ -186,7 +268,83 @@ func (s *Store) GetAllAgents() ([]models.Agent, error) {
 	return agents, nil
 }
 
-func (s *Store) DeleteAgent(id string) error {
-	_, err := s.db.Exec(`DELETE FROM agents WHERE id = ?`, id)
-	return err
+func (s *Store) DeleteAgent(id, reassignTo string) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`UPDATE repositories SET agent_id = NULLIF(?, ''), updated_at = ? WHERE agent_id = ?`,
+		reassignTo, time.Now(), id); err != nil {
+		return fmt.Errorf("reassign repositories: %w", err)
+	}
+
+	const owned = `SELECT id FROM deployments WHERE agent_id = ?`
+	for _, stmt := range []string{
+		`DELETE FROM deployment_logs WHERE deployment_id IN (` + owned + `)`,
+		`DELETE FROM deployment_steps WHERE deployment_id IN (` + owned + `)`,
+		`DELETE FROM deployment_containers WHERE deployment_id IN (` + owned + `)`,
+		`DELETE FROM deployment_artifacts WHERE deployment_id IN (` + owned + `)`,
+		`DELETE FROM deployments WHERE agent_id = ?`,
+		`DELETE FROM alerts WHERE agent_id = ?`,
+		`DELETE FROM commands WHERE agent_id = ?`,
+		`DELETE FROM agent_events WHERE agent_id = ?`,
+		`DELETE FROM agents WHERE id = ?`,
+	} {
+		if _, err := tx.Exec(stmt, id); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (s *Store) RecordAgentEvent(e *models.AgentEvent) error {
+	if e.CreatedAt.IsZero() {
+		e.CreatedAt = time.Now()
+	}
+	res, err := s.db.Exec(`
+		INSERT INTO agent_events (agent_id, event_type, detail, created_at)
+		VALUES (?, ?, ?, ?)
+	`, e.AgentID, e.Type, e.Detail, e.CreatedAt)
+	if err != nil {
+		return err
+	}
+	e.ID, _ = res.LastInsertId()
+	return nil
+}
+
+func (s *Store) GetAgentEvents(agentID string, limit int) ([]models.AgentEvent, error) {
+	rows, err := s.db.Query(`
+		SELECT id, agent_id, event_type, detail, created_at
+		FROM agent_events WHERE agent_id = ? ORDER BY id DESC LIMIT ?
+	`, agentID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []models.AgentEvent
+	for rows.Next() {
+		var e models.AgentEvent
+		if err := rows.Scan(&e.ID, &e.AgentID, &e.Type, &e.Detail, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
+
+func (s *Store) PruneAgentEvents(keep int) (int64, error) {
+	res, err := s.db.Exec(`
+		DELETE FROM agent_events WHERE id IN (
+			SELECT id FROM (
+				SELECT id, ROW_NUMBER() OVER (PARTITION BY agent_id ORDER BY id DESC) AS n FROM agent_events
+			) WHERE n > ?
+		)
+	`, keep)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
 }
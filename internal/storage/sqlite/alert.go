@@ -25,14 +25,23 @@ import (
 	"github.com/urustack/uruflow/internal/models"
 )
 
+const alertColumns = `id, type, severity, COALESCE(agent_id, ''), agent_name, message, resolved, auto_resolved, created_at, resolved_at`
+
 func (s *Store) CreateAlert(a *models.Alert) error {
 	_, err := s.db.Exec(`
 		INSERT INTO alerts (id, type, severity, agent_id, agent_name, message, resolved, created_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		VALUES (?, ?, ?, NULLIF(?, ''), ?, ?, ?, ?)
 	`, a.ID, a.Type, a.Severity, a.AgentID, a.AgentName, a.Message, a.Resolved, a.CreatedAt)
 	return err
 }
 
+func (s *Store) UpdateAlert(a *models.Alert) error {
+	_, err := s.db.Exec(`
+		UPDATE alerts SET severity = ?, message = ? WHERE id = ?
+	`, a.Severity, a.Message, a.ID)
+	return err
+}
+
 func (s *Store) ResolveAlert(id string) error {
 	_, err := s.db.Exec(`
 		UPDATE alerts SET resolved = 1, resolved_at = ? WHERE id = ?
@@ -40,9 +49,55 @@ func (s *Store) ResolveAlert(id string) error {
 	return err
 }
 
+func (s *Store) ResolveAlerts(ids []string) (int64, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	var resolved int64
+	for _, id := range ids {
+		res, err := tx.Exec(`
+			UPDATE alerts SET resolved = 1, resolved_at = ? WHERE id = ? AND resolved = 0
+		`, now, id)
+		if err != nil {
+			return 0, err
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return 0, err
+		}
+		resolved += n
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return resolved, nil
+}
+
+func (s *Store) AutoResolveAlert(id string) error {
+	_, err := s.db.Exec(`
+		UPDATE alerts SET resolved = 1, auto_resolved = 1, resolved_at = ? WHERE id = ? AND resolved = 0
+	`, time.Now(), id)
+	return err
+}
+
+func (s *Store) ResolveAlertsByTypeAndAgent(agentID, alertType string) (int64, error) {
+	res, err := s.db.Exec(`
+		UPDATE alerts SET resolved = 1, auto_resolved = 1, resolved_at = ?
+		WHERE agent_id = ? AND type = ? AND resolved = 0
+	`, time.Now(), agentID, alertType)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
 func (s *Store) GetActiveAlerts() ([]models.Alert, error) {
 	rows, err := s.db.Query(`
-		SELECT id, type, severity, agent_id, agent_name, message, resolved, created_at, resolved_at
+		SELECT ` + alertColumns + `
 		FROM alerts WHERE resolved = 0 ORDER BY created_at DESC
 	`)
 	if err != nil {
@@ -53,10 +108,32 @@ func (s *Store) GetActiveAlerts() ([]models.Alert, error) {
 	return scanAlerts(rows)
 }
 
+func (s *Store) GetActiveAlertsFiltered(alertType, severity string) ([]models.Alert, error) {
+	query := `
+		SELECT ` + alertColumns + `
+		FROM alerts WHERE resolved = 0`
+	var args []interface{}
+	if alertType != "" {
+		query += " AND type = ?"
+		args = append(args, alertType)
+	}
+	if severity != "" {
+		query += " AND severity = ?"
+		args = append(args, severity)
+	}
+	rows, err := s.db.Query(query+" ORDER BY created_at DESC", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanAlerts(rows)
+}
+
 func (s *Store) GetRecentAlerts(hours int) ([]models.Alert, error) {
 	since := time.Now().Add(-time.Duration(hours) * time.Hour)
 	rows, err := s.db.Query(`
-		SELECT id, type, severity, agent_id, agent_name, message, resolved, created_at, resolved_at
+		SELECT `+alertColumns+`
 		FROM alerts WHERE created_at > ? ORDER BY created_at DESC
 	`, since)
 	if err != nil {
@@ -69,7 +146,7 @@ func (s *Store) GetRecentAlerts(hours int) ([]models.Alert, error) {
 
 func (s *Store) GetAlertsByAgent(agentID string) ([]models.Alert, error) {
 	rows, err := s.db.Query(`
-		SELECT id, type, severity, agent_id, agent_name, message, resolved, created_at, resolved_at
+		SELECT `+alertColumns+`
 		FROM alerts WHERE agent_id = ? ORDER BY created_at DESC
 	`, agentID)
 	if err != nil {
@@ -85,7 +162,7 @@ func scanAlerts(rows *sql.Rows) ([]models.Alert, error) {
 	for rows.Next() {
 		var a models.Alert
 		var resolvedAt sql.NullTime
-		err := rows.Scan(&a.ID, &a.Type, &a.Severity, &a.AgentID, &a.AgentName, &a.Message, &a.Resolved, &a.CreatedAt, &resolvedAt)
+		err := rows.Scan(&a.ID, &a.Type, &a.Severity, &a.AgentID, &a.AgentName, &a.Message, &a.Resolved, &a.AutoResolved, &a.CreatedAt, &resolvedAt)
 		if err != nil {
 			return nil, err
 		}
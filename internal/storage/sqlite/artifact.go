@@ -0,0 +1,76 @@
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+
+package sqlite
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/urustack/uruflow/internal/models"
+)
+
+func (s *Store) SaveDeploymentArtifact(a *models.DeploymentArtifact) error {
+	if a.CreatedAt.IsZero() {
+		a.CreatedAt = time.Now()
+	}
+	_, err := s.db.Exec(`
+		INSERT INTO deployment_artifacts (deployment_id, name, content, size, truncated, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT(deployment_id, name) DO UPDATE SET
+			content = excluded.content, size = excluded.size,
+			truncated = excluded.truncated, created_at = excluded.created_at
+	`, a.DeploymentID, a.Name, a.Content, a.Size, a.Truncated, a.CreatedAt)
+	return err
+}
+
+func (s *Store) GetDeploymentArtifacts(deploymentID string) ([]models.DeploymentArtifact, error) {
+	rows, err := s.db.Query(`
+		SELECT deployment_id, name, size, truncated, created_at
+		FROM deployment_artifacts WHERE deployment_id = ? ORDER BY name
+	`, deploymentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var artifacts []models.DeploymentArtifact
+	for rows.Next() {
+		var a models.DeploymentArtifact
+		if err := rows.Scan(&a.DeploymentID, &a.Name, &a.Size, &a.Truncated, &a.CreatedAt); err != nil {
+			return nil, err
+		}
+		artifacts = append(artifacts, a)
+	}
+	return artifacts, rows.Err()
+}
+
+func (s *Store) GetDeploymentArtifact(deploymentID, name string) (*models.DeploymentArtifact, error) {
+	var a models.DeploymentArtifact
+	err := s.db.QueryRow(`
+		SELECT deployment_id, name, content, size, truncated, created_at
+		FROM deployment_artifacts WHERE deployment_id = ? AND name = ?
+	`, deploymentID, name).Scan(&a.DeploymentID, &a.Name, &a.Content, &a.Size, &a.Truncated, &a.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
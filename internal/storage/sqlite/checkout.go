@@ -0,0 +1,72 @@
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+
+package sqlite
+
+import (
+	"database/sql"
+
+	"github.com/urustack/uruflow/internal/models"
+)
+
+func (s *Store) SyncCheckouts(agentID string, checkouts []models.Checkout) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM checkouts WHERE agent_id = ?`, agentID); err != nil {
+		return err
+	}
+	for _, c := range checkouts {
+		_, err := tx.Exec(`
+			INSERT INTO checkouts (agent_id, repository, url, branch, commit_hash, fetched_at)
+			VALUES (?, ?, ?, ?, ?, ?)
+		`, agentID, c.Repository, c.URL, c.Branch, c.Commit, c.FetchedAt)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (s *Store) GetCheckoutsByRepo(repository string) ([]models.Checkout, error) {
+	rows, err := s.db.Query(`
+		SELECT agent_id, repository, url, branch, commit_hash, fetched_at
+		FROM checkouts WHERE repository = ? ORDER BY fetched_at DESC
+	`, repository)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var checkouts []models.Checkout
+	for rows.Next() {
+		var c models.Checkout
+		var fetchedAt sql.NullTime
+		if err := rows.Scan(&c.AgentID, &c.Repository, &c.URL, &c.Branch, &c.Commit, &fetchedAt); err != nil {
+			return nil, err
+		}
+		if fetchedAt.Valid {
+			c.FetchedAt = fetchedAt.Time
+		}
+		checkouts = append(checkouts, c)
+	}
+	return checkouts, rows.Err()
+}
@@ -0,0 +1,79 @@
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+
+package sqlite
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/urustack/uruflow/internal/models"
+)
+
+func (s *Store) CreateCommand(c *models.Command) error {
+	payload, err := json.Marshal(c.Payload)
+	if err != nil {
+		return fmt.Errorf("encode command payload: %w", err)
+	}
+	_, err = s.db.Exec(`
+		INSERT INTO commands (id, type, agent_id, payload, status, output, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, c.ID, c.Type, c.AgentID, string(payload), c.Status, c.Output, c.CreatedAt)
+	return err
+}
+
+func (s *Store) UpdateCommand(c *models.Command) error {
+	_, err := s.db.Exec(`
+		UPDATE commands SET status = ?, output = ?, started_at = ?, ended_at = ? WHERE id = ?
+	`, c.Status, c.Output, c.StartedAt, c.EndedAt, c.ID)
+	return err
+}
+
+func (s *Store) GetCommandsByAgent(agentID string, limit int) ([]models.Command, error) {
+	rows, err := s.db.Query(`
+		SELECT id, type, agent_id, payload, status, output, created_at, started_at, ended_at
+		FROM commands WHERE agent_id = ? ORDER BY created_at DESC LIMIT ?
+	`, agentID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var commands []models.Command
+	for rows.Next() {
+		var c models.Command
+		var payload string
+		var startedAt, endedAt sql.NullTime
+		if err := rows.Scan(&c.ID, &c.Type, &c.AgentID, &payload, &c.Status, &c.Output,
+			&c.CreatedAt, &startedAt, &endedAt); err != nil {
+			return nil, err
+		}
+		if payload != "" {
+			json.Unmarshal([]byte(payload), &c.Payload)
+		}
+		if startedAt.Valid {
+			c.StartedAt = &startedAt.Time
+		}
+		if endedAt.Valid {
+			c.EndedAt = &endedAt.Time
+		}
+		commands = append(commands, c)
+	}
+	return commands, rows.Err()
+}
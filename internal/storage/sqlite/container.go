@@ -20,14 +20,15 @@ package sqlite
 
 import (
 	"database/sql"
+	"strings"
 
 	"github.com/urustack/uruflow/internal/models"
 )
 
 func (s *Store) UpsertContainer(c *models.Container) error {
 	_, err := s.db.Exec(`
-		INSERT INTO containers (id, agent_id, name, image, status, health, cpu_percent, memory_usage, memory_limit, network_rx, network_tx, restart_count, started_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		INSERT INTO containers (id, agent_id, name, image, status, health, cpu_percent, memory_usage, memory_limit, network_rx, network_tx, restart_count, started_at, stats_stale, project, service)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 		ON CONFLICT(id) DO UPDATE SET
 			status = excluded.status,
 			health = excluded.health,
@@ -36,14 +37,19 @@ func (s *Store) UpsertContainer(c *models.Container) error {
 			memory_limit = excluded.memory_limit,
 			network_rx = excluded.network_rx,
 			network_tx = excluded.network_tx,
-			restart_count = excluded.restart_count
-	`, c.ID, c.AgentID, c.Name, c.Image, c.Status, c.Health, c.CPUPercent, c.MemoryUsage, c.MemoryLimit, c.NetworkRx, c.NetworkTx, c.RestartCount, c.StartedAt)
+			restart_count = excluded.restart_count,
+			stats_stale = excluded.stats_stale,
+			project = excluded.project,
+			service = excluded.service
+	`, c.ID, c.AgentID, c.Name, c.Image, c.Status, c.Health, c.CPUPercent, c.MemoryUsage, c.MemoryLimit, c.NetworkRx, c.NetworkTx, c.RestartCount, c.StartedAt, c.StatsStale,
+		c.Project, c.Service)
 	return err
 }
 
 func (s *Store) GetContainersByAgent(agentID string) ([]models.Container, error) {
 	rows, err := s.db.Query(`
-		SELECT id, agent_id, name, image, status, health, cpu_percent, memory_usage, memory_limit, network_rx, network_tx, restart_count, started_at
+		SELECT id, agent_id, name, image, status, health, cpu_percent, memory_usage, memory_limit, network_rx, network_tx, restart_count, started_at, stats_stale,
+			project, service
 		FROM containers WHERE agent_id = ? ORDER BY name
 	`, agentID)
 	if err != nil {
@@ -55,7 +61,8 @@ func (s *Store) GetContainersByAgent(agentID string) ([]models.Container, error)
 	for rows.Next() {
 		var c models.Container
 		var startedAt sql.NullTime
-		err := rows.Scan(&c.ID, &c.AgentID, &c.Name, &c.Image, &c.Status, &c.Health, &c.CPUPercent, &c.MemoryUsage, &c.MemoryLimit, &c.NetworkRx, &c.NetworkTx, &c.RestartCount, &startedAt)
+		err := rows.Scan(&c.ID, &c.AgentID, &c.Name, &c.Image, &c.Status, &c.Health, &c.CPUPercent, &c.MemoryUsage, &c.MemoryLimit, &c.NetworkRx, &c.NetworkTx, &c.RestartCount, &startedAt, &c.StatsStale,
+			&c.Project, &c.Service)
 		if err != nil {
 			return nil, err
 		}
@@ -71,3 +78,19 @@ func (s *Store) DeleteContainersByAgent(agentID string) error {
 	_, err := s.db.Exec(`DELETE FROM containers WHERE agent_id = ?`, agentID)
 	return err
 }
+
+func (s *Store) SyncContainers(agentID string, present []models.Container) error {
+	if len(present) == 0 {
+		return s.DeleteContainersByAgent(agentID)
+	}
+
+	args := []interface{}{agentID}
+	placeholders := make([]string, len(present))
+	for i, c := range present {
+		placeholders[i] = "?"
+		args = append(args, c.ID)
+	}
+
+	_, err := s.db.Exec(`DELETE FROM containers WHERE agent_id = ? AND id NOT IN (`+strings.Join(placeholders, ", ")+`)`, args...)
+	return err
+}
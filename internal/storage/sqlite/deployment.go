@@ -20,60 +20,90 @@ package sqlite
 
 import (
 	"database/sql"
+	"strings"
+	"time"
 
 	"github.com/urustack/uruflow/internal/models"
+	"github.com/urustack/uruflow/internal/storage"
 )
 
+const deploymentColumns = `id, repo_name, branch, commit_hash, agent_id, agent_name, status, trigger_type,
+	started_at, finished_at, duration_ms, output, config_hash, rollback_of, change_summary,
+	triggered_by, source_ip, image_unchanged, tag, status_detail, dry_run, exit_code, build_file, note`
+
 func (s *Store) CreateDeployment(d *models.Deployment) error {
 	_, err := s.db.Exec(`
-		INSERT INTO deployments (id, repo_name, branch, commit_hash, agent_id, agent_name, status, trigger_type, started_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
-	`, d.ID, d.Repository, d.Branch, d.Commit, d.AgentID, d.AgentName, d.Status, d.Trigger, d.StartedAt)
+		INSERT INTO deployments (id, repo_name, branch, commit_hash, agent_id, agent_name, status, trigger_type, started_at, rollback_of,
+			triggered_by, source_ip, tag, status_detail, dry_run, note)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, d.ID, d.Repository, d.Branch, d.Commit, d.AgentID, d.AgentName, d.Status, d.Trigger, d.StartedAt, d.RollbackOf,
+		d.TriggeredBy, d.SourceIP, d.Tag, d.StatusDetail, d.DryRun, d.Note)
 	return err
 }
 
 func (s *Store) UpdateDeployment(d *models.Deployment) error {
 	_, err := s.db.Exec(`
-		UPDATE deployments SET status = ?, finished_at = ?, duration_ms = ?, output = ?
+		UPDATE deployments SET status = ?, commit_hash = ?, finished_at = ?, duration_ms = ?, output = ?, config_hash = ?, change_summary = ?,
+			image_unchanged = ?, status_detail = ?, exit_code = ?, build_file = ?
 		WHERE id = ?
-	`, d.Status, d.EndedAt, d.Duration, d.Output, d.ID)
+	`, d.Status, d.Commit, d.EndedAt, d.Duration, d.Output, d.ConfigHash, d.ChangeSummary, d.ImageUnchanged, d.StatusDetail, d.ExitCode, d.BuildFile, d.ID)
 	return err
 }
 
-func (s *Store) GetDeployment(id string) (*models.Deployment, error) {
-	d := &models.Deployment{}
-	var finishedAt sql.NullTime
-	var duration sql.NullInt64
-	var output sql.NullString
-
-	err := s.db.QueryRow(`
-		SELECT id, repo_name, branch, commit_hash, agent_id, agent_name, status, trigger_type, started_at, finished_at, duration_ms, output
-		FROM deployments WHERE id = ?
-	`, id).Scan(&d.ID, &d.Repository, &d.Branch, &d.Commit, &d.AgentID, &d.AgentName, &d.Status, &d.Trigger, &d.StartedAt, &finishedAt, &duration, &output)
+func (s *Store) ApproveDeployment(d *models.Deployment) (bool, error) {
+	res, err := s.db.Exec(`
+		UPDATE deployments SET status = ?, agent_id = ?, agent_name = ?, started_at = ?, status_detail = ?
+		WHERE id = ? AND status = ?
+	`, d.Status, d.AgentID, d.AgentName, d.StartedAt, d.StatusDetail, d.ID, models.DeployAwaitingApproval)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
 
-	if err == sql.ErrNoRows {
-		return nil, nil
+func (s *Store) RejectDeployment(d *models.Deployment) (bool, error) {
+	res, err := s.db.Exec(`
+		UPDATE deployments SET status = ?, finished_at = ?, output = ?, status_detail = ?
+		WHERE id = ? AND status = ?
+	`, d.Status, d.EndedAt, d.Output, d.StatusDetail, d.ID, models.DeployAwaitingApproval)
+	if err != nil {
+		return false, err
 	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+func (s *Store) GetDeploymentsByStatus(status models.DeployStatus) ([]models.Deployment, error) {
+	rows, err := s.db.Query(`
+		SELECT `+deploymentColumns+`
+		FROM deployments WHERE status = ? ORDER BY started_at
+	`, status)
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
 
-	if finishedAt.Valid {
-		d.EndedAt = &finishedAt.Time
-	}
-	if duration.Valid {
-		d.Duration = duration.Int64
+	return scanDeployments(rows)
+}
+
+func (s *Store) GetDeployment(id string) (*models.Deployment, error) {
+	d, err := scanDeployment(s.db.QueryRow(`
+		SELECT `+deploymentColumns+`
+		FROM deployments WHERE id = ?
+	`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
 	}
-	if output.Valid {
-		d.Output = output.String
+	if err != nil {
+		return nil, err
 	}
-
 	return d, nil
 }
 
 func (s *Store) GetRecentDeployments(limit int) ([]models.Deployment, error) {
 	rows, err := s.db.Query(`
-		SELECT id, repo_name, branch, commit_hash, agent_id, agent_name, status, trigger_type, started_at, finished_at, duration_ms, output
+		SELECT `+deploymentColumns+`
 		FROM deployments ORDER BY started_at DESC LIMIT ?
 	`, limit)
 	if err != nil {
@@ -86,7 +116,7 @@ func (s *Store) GetRecentDeployments(limit int) ([]models.Deployment, error) {
 
 func (s *Store) GetDeploymentsByAgent(agentID string, limit int) ([]models.Deployment, error) {
 	rows, err := s.db.Query(`
-		SELECT id, repo_name, branch, commit_hash, agent_id, agent_name, status, trigger_type, started_at, finished_at, duration_ms, output
+		SELECT `+deploymentColumns+`
 		FROM deployments WHERE agent_id = ? ORDER BY started_at DESC LIMIT ?
 	`, agentID, limit)
 	if err != nil {
@@ -99,7 +129,7 @@ func (s *Store) GetDeploymentsByAgent(agentID string, limit int) ([]models.Deplo
 
 func (s *Store) GetDeploymentsByRepo(repoName string, limit int) ([]models.Deployment, error) {
 	rows, err := s.db.Query(`
-		SELECT id, repo_name, branch, commit_hash, agent_id, agent_name, status, trigger_type, started_at, finished_at, duration_ms, output
+		SELECT `+deploymentColumns+`
 		FROM deployments WHERE repo_name = ? ORDER BY started_at DESC LIMIT ?
 	`, repoName, limit)
 	if err != nil {
@@ -110,30 +140,144 @@ func (s *Store) GetDeploymentsByRepo(repoName string, limit int) ([]models.Deplo
 	return scanDeployments(rows)
 }
 
+func (s *Store) GetLastDeployment(repoName string) (*models.Deployment, error) {
+	d, err := scanDeployment(s.db.QueryRow(`
+		SELECT `+deploymentColumns+`
+		FROM deployments WHERE repo_name = ? AND dry_run = 0 ORDER BY started_at DESC LIMIT 1
+	`, repoName))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+func (s *Store) GetDeploymentsPage(offset, limit int, filter storage.DeploymentFilter) ([]models.Deployment, int, error) {
+	var conds []string
+	var args []interface{}
+	if filter.Repo != "" {
+		conds = append(conds, "repo_name = ?")
+		args = append(args, filter.Repo)
+	}
+	if filter.AgentID != "" {
+		conds = append(conds, "agent_id = ?")
+		args = append(args, filter.AgentID)
+	}
+	if filter.Status != "" {
+		conds = append(conds, "status = ?")
+		args = append(args, filter.Status)
+	}
+	if filter.Trigger != "" {
+		conds = append(conds, "trigger_type = ?")
+		args = append(args, filter.Trigger)
+	}
+
+	where := ""
+	if len(conds) > 0 {
+		where = "WHERE " + strings.Join(conds, " AND ")
+	}
+
+	var total int
+	if err := s.db.QueryRow(`SELECT COUNT(*) FROM deployments `+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := s.db.Query(`
+		SELECT `+deploymentColumns+`
+		FROM deployments `+where+` ORDER BY started_at DESC LIMIT ? OFFSET ?
+	`, append(args, limit, offset)...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	deployments, err := scanDeployments(rows)
+	if err != nil {
+		return nil, 0, err
+	}
+	return deployments, total, nil
+}
+
+func (s *Store) GetStaleDeployments(startedBefore, quietSince time.Time) ([]models.Deployment, error) {
+	rows, err := s.db.Query(`
+		SELECT `+deploymentColumns+`
+		FROM deployments d
+		WHERE status IN ('pending', 'running') AND started_at < ?
+			AND NOT EXISTS (
+				SELECT 1 FROM deployment_logs l
+				WHERE l.deployment_id = d.id AND l.timestamp >= ?
+			)
+	`, startedBefore, quietSince)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanDeployments(rows)
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanDeployment(row rowScanner) (*models.Deployment, error) {
+	d := &models.Deployment{}
+	var finishedAt sql.NullTime
+	var duration, exitCode sql.NullInt64
+	var output, configHash, rollbackOf, changeSummary, triggeredBy, sourceIP, tag sql.NullString
+
+	err := row.Scan(&d.ID, &d.Repository, &d.Branch, &d.Commit, &d.AgentID, &d.AgentName, &d.Status, &d.Trigger,
+		&d.StartedAt, &finishedAt, &duration, &output, &configHash, &rollbackOf, &changeSummary,
+		&triggeredBy, &sourceIP, &d.ImageUnchanged, &tag, &d.StatusDetail, &d.DryRun, &exitCode, &d.BuildFile, &d.Note)
+	if err != nil {
+		return nil, err
+	}
+
+	if finishedAt.Valid {
+		d.EndedAt = &finishedAt.Time
+	}
+	if duration.Valid {
+		d.Duration = duration.Int64
+	}
+	if output.Valid {
+		d.Output = output.String
+	}
+	if configHash.Valid {
+		d.ConfigHash = configHash.String
+	}
+	if rollbackOf.Valid {
+		d.RollbackOf = rollbackOf.String
+	}
+	if changeSummary.Valid {
+		d.ChangeSummary = changeSummary.String
+	}
+	if triggeredBy.Valid {
+		d.TriggeredBy = triggeredBy.String
+	}
+	if sourceIP.Valid {
+		d.SourceIP = sourceIP.String
+	}
+	if tag.Valid {
+		d.Tag = tag.String
+	}
+	if exitCode.Valid {
+		code := int(exitCode.Int64)
+		d.ExitCode = &code
+	}
+
+	return d, nil
+}
+
 func scanDeployments(rows *sql.Rows) ([]models.Deployment, error) {
 	var deployments []models.Deployment
 	for rows.Next() {
-		var d models.Deployment
-		var finishedAt sql.NullTime
-		var duration sql.NullInt64
-		var output sql.NullString
-
-		err := rows.Scan(&d.ID, &d.Repository, &d.Branch, &d.Commit, &d.AgentID, &d.AgentName, &d.Status, &d.Trigger, &d.StartedAt, &finishedAt, &duration, &output)
+		d, err := scanDeployment(rows)
 		if err != nil {
 			return nil, err
 		}
-
-		if finishedAt.Valid {
-			d.EndedAt = &finishedAt.Time
-		}
-		if duration.Valid {
-			d.Duration = duration.Int64
-		}
-		if output.Valid {
-			d.Output = output.String
-		}
-
-		deployments = append(deployments, d)
+		deployments = append(deployments, *d)
 	}
 	return deployments, nil
 }
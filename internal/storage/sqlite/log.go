@@ -19,6 +19,10 @@
 package sqlite
 
 import (
+	"database/sql"
+	"math"
+	"slices"
+
 	"github.com/urustack/uruflow/internal/models"
 )
 
@@ -40,6 +44,44 @@ func (s *Store) GetDeploymentLogs(deploymentID string) ([]models.DeploymentLog,
 	}
 	defer rows.Close()
 
+	return scanDeploymentLogs(rows)
+}
+
+func (s *Store) GetDeploymentLogsPage(deploymentID string, afterID int64, limit int) ([]models.DeploymentLog, error) {
+	rows, err := s.db.Query(`
+		SELECT id, deployment_id, timestamp, stream, content
+		FROM deployment_logs WHERE deployment_id = ? AND id > ? ORDER BY id LIMIT ?
+	`, deploymentID, afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanDeploymentLogs(rows)
+}
+
+func (s *Store) GetDeploymentLogsBefore(deploymentID string, beforeID int64, limit int) ([]models.DeploymentLog, error) {
+	if beforeID <= 0 {
+		beforeID = math.MaxInt64
+	}
+	rows, err := s.db.Query(`
+		SELECT id, deployment_id, timestamp, stream, content
+		FROM deployment_logs WHERE deployment_id = ? AND id < ? ORDER BY id DESC LIMIT ?
+	`, deploymentID, beforeID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	logs, err := scanDeploymentLogs(rows)
+	if err != nil {
+		return nil, err
+	}
+	slices.Reverse(logs)
+	return logs, nil
+}
+
+func scanDeploymentLogs(rows *sql.Rows) ([]models.DeploymentLog, error) {
 	var logs []models.DeploymentLog
 	for rows.Next() {
 		var l models.DeploymentLog
@@ -49,10 +91,109 @@ func (s *Store) GetDeploymentLogs(deploymentID string) ([]models.DeploymentLog,
 		}
 		logs = append(logs, l)
 	}
-	return logs, nil
+	return logs, rows.Err()
+}
+
+func (s *Store) AddDeploymentStep(step *models.DeploymentStep) error {
+	_, err := s.db.Exec(`
+		INSERT INTO deployment_steps (deployment_id, name, status, started_at, duration_ms)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT (deployment_id, name) DO UPDATE SET
+			status = excluded.status,
+			duration_ms = excluded.duration_ms
+	`, step.DeploymentID, step.Name, step.Status, step.StartedAt, step.Duration)
+	return err
+}
+
+func (s *Store) GetDeploymentSteps(deploymentID string) ([]models.DeploymentStep, error) {
+	rows, err := s.db.Query(`
+		SELECT id, deployment_id, name, status, started_at, duration_ms
+		FROM deployment_steps WHERE deployment_id = ? ORDER BY id
+	`, deploymentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var steps []models.DeploymentStep
+	for rows.Next() {
+		var st models.DeploymentStep
+		if err := rows.Scan(&st.ID, &st.DeploymentID, &st.Name, &st.Status, &st.StartedAt, &st.Duration); err != nil {
+			return nil, err
+		}
+		steps = append(steps, st)
+	}
+	return steps, rows.Err()
+}
+
+func (s *Store) AddDeploymentContainers(deploymentID string, containers []models.DeployedContainer) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, c := range containers {
+		if _, err := tx.Exec(`
+			INSERT INTO deployment_containers (deployment_id, name, service, image, image_id)
+			VALUES (?, ?, ?, ?, ?)
+		`, deploymentID, c.Name, c.Service, c.Image, c.ImageID); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (s *Store) GetDeploymentContainers(deploymentID string) ([]models.DeployedContainer, error) {
+	rows, err := s.db.Query(`
+		SELECT deployment_id, name, service, image, image_id
+		FROM deployment_containers WHERE deployment_id = ? ORDER BY name
+	`, deploymentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var containers []models.DeployedContainer
+	for rows.Next() {
+		var c models.DeployedContainer
+		if err := rows.Scan(&c.DeploymentID, &c.Name, &c.Service, &c.Image, &c.ImageID); err != nil {
+			return nil, err
+		}
+		containers = append(containers, c)
+	}
+	return containers, rows.Err()
 }
 
 func (s *Store) DeleteDeploymentLogs(deploymentID string) error {
 	_, err := s.db.Exec(`DELETE FROM deployment_logs WHERE deployment_id = ?`, deploymentID)
 	return err
 }
+
+func (s *Store) AddDeploymentLogsBatch(logs []models.DeploymentLog) error {
+	if len(logs) == 0 {
+		return nil
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	stmt, err := tx.Prepare(`
+		INSERT INTO deployment_logs (deployment_id, timestamp, stream, content)
+		VALUES (?, ?, ?, ?)
+	`)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	defer stmt.Close()
+
+	for _, log := range logs {
+		if _, err := stmt.Exec(log.DeploymentID, log.Timestamp, log.Stream, log.Line); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,58 @@
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+package sqlite
+
+import (
+	"time"
+
+	"github.com/urustack/uruflow/internal/models"
+)
+
+func (s *Store) RecordMetrics(sample *models.MetricsSample) error {
+	_, err := s.insertMetrics.Exec(sample.AgentID, sample.Timestamp, sample.CPU, sample.Memory, sample.Disk)
+	return err
+}
+
+func (s *Store) GetMetricsHistory(agentID string, since time.Time) ([]models.MetricsSample, error) {
+	rows, err := s.db.Query(`
+		SELECT agent_id, ts, cpu, mem, disk
+		FROM metrics_history WHERE agent_id = ? AND ts >= ? ORDER BY ts
+	`, agentID, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var samples []models.MetricsSample
+	for rows.Next() {
+		var m models.MetricsSample
+		if err := rows.Scan(&m.AgentID, &m.Timestamp, &m.CPU, &m.Memory, &m.Disk); err != nil {
+			return nil, err
+		}
+		samples = append(samples, m)
+	}
+	return samples, rows.Err()
+}
+
+func (s *Store) PruneMetricsHistory(olderThan time.Time) (int64, error) {
+	res, err := s.db.Exec(`DELETE FROM metrics_history WHERE ts < ?`, olderThan)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,282 @@
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+
+package sqlite
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/urustack/uruflow/pkg/helper"
+	"github.com/urustack/uruflow/pkg/logger"
+)
+
+var ErrSchemaTooNew = errors.New("database schema is newer than this build")
+
+type migration struct {
+	version int
+	name    string
+	sql     string
+	fn      func(tx *sql.Tx) error
+}
+
+var migrations = []migration{
+	{version: 1, name: "initial schema", fn: baseline},
+	{version: 2, name: "drop plaintext agent tokens", fn: dropPlaintextTokens},
+	{version: 3, name: "deployment status detail", sql: addStatusDetail},
+	{version: 4, name: "agent checkouts", sql: addCheckouts},
+	{version: 5, name: "deployment dry runs", sql: addDryRun},
+	{version: 6, name: "container compose labels", sql: addContainerCompose},
+	{version: 7, name: "deployment exit codes", sql: addExitCode},
+	{version: 8, name: "deployment build files", sql: addBuildFile},
+	{version: 9, name: "deployment notes", sql: addDeploymentNote},
+	{version: 10, name: "alerts without an agent", sql: relaxAlertAgent},
+}
+
+const dropAgentToken = `
+DROP INDEX IF EXISTS idx_agents_token;
+ALTER TABLE agents DROP COLUMN token;
+`
+
+const addStatusDetail = `
+ALTER TABLE deployments ADD COLUMN status_detail TEXT NOT NULL DEFAULT '';
+`
+
+const addCheckouts = `
+CREATE TABLE IF NOT EXISTS checkouts (
+	agent_id TEXT NOT NULL,
+	repository TEXT NOT NULL,
+	url TEXT NOT NULL DEFAULT '',
+	branch TEXT NOT NULL DEFAULT '',
+	commit_hash TEXT NOT NULL DEFAULT '',
+	fetched_at DATETIME,
+	PRIMARY KEY (agent_id, repository),
+	FOREIGN KEY (agent_id) REFERENCES agents(id) ON DELETE CASCADE
+);
+`
+
+const addDryRun = `
+ALTER TABLE deployments ADD COLUMN dry_run INTEGER NOT NULL DEFAULT 0;
+`
+
+const addContainerCompose = `
+ALTER TABLE containers ADD COLUMN project TEXT NOT NULL DEFAULT '';
+ALTER TABLE containers ADD COLUMN service TEXT NOT NULL DEFAULT '';
+`
+
+const addExitCode = `
+ALTER TABLE deployments ADD COLUMN exit_code INTEGER;
+`
+
+const addBuildFile = `
+ALTER TABLE deployments ADD COLUMN build_file TEXT NOT NULL DEFAULT '';
+`
+
+const addDeploymentNote = `
+ALTER TABLE deployments ADD COLUMN note TEXT NOT NULL DEFAULT '';
+`
+
+const relaxAlertAgent = `
+CREATE TABLE alerts_new (
+	id TEXT PRIMARY KEY,
+	type TEXT NOT NULL,
+	severity TEXT DEFAULT 'warning',
+	agent_id TEXT,
+	agent_name TEXT NOT NULL,
+	message TEXT NOT NULL,
+	resolved INTEGER DEFAULT 0,
+	auto_resolved INTEGER DEFAULT 0,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	resolved_at DATETIME,
+	FOREIGN KEY (agent_id) REFERENCES agents(id)
+);
+INSERT INTO alerts_new (id, type, severity, agent_id, agent_name, message, resolved, auto_resolved, created_at, resolved_at)
+	SELECT id, type, severity, agent_id, agent_name, message, resolved, auto_resolved, created_at, resolved_at FROM alerts;
+DROP TABLE alerts;
+ALTER TABLE alerts_new RENAME TO alerts;
+CREATE INDEX IF NOT EXISTS idx_alerts_resolved ON alerts(resolved);
+CREATE INDEX IF NOT EXISTS idx_alerts_agent ON alerts(agent_id);
+`
+
+const schemaMigrations = `
+CREATE TABLE IF NOT EXISTS schema_migrations (
+	version INTEGER PRIMARY KEY,
+	name TEXT NOT NULL,
+	applied_at DATETIME NOT NULL
+);
+`
+
+type querier interface {
+	Exec(query string, args ...any) (sql.Result, error)
+	Query(query string, args ...any) (*sql.Rows, error)
+}
+
+func (s *Store) migrate() error {
+	if _, err := s.db.Exec(schemaMigrations); err != nil {
+		return err
+	}
+
+	current, err := s.schemaVersion()
+	if err != nil {
+		return err
+	}
+	latest := migrations[len(migrations)-1].version
+	if current > latest {
+		return fmt.Errorf("%w: database is at version %d, this build supports up to %d, upgrade uruflow", ErrSchemaTooNew, current, latest)
+	}
+
+	for _, m := range migrations {
+		if m.version <= current {
+			continue
+		}
+		if err := s.apply(m); err != nil {
+			return fmt.Errorf("migration %d (%s): %w", m.version, m.name, err)
+		}
+		logger.Info("[STORE] applied schema migration %d (%s)", m.version, m.name)
+	}
+	return nil
+}
+
+func (s *Store) schemaVersion() (int, error) {
+	var version sql.NullInt64
+	if err := s.db.QueryRow(`SELECT MAX(version) FROM schema_migrations`).Scan(&version); err != nil {
+		return 0, err
+	}
+	return int(version.Int64), nil
+}
+
+func (s *Store) apply(m migration) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if m.sql != "" {
+		if _, err := tx.Exec(m.sql); err != nil {
+			return err
+		}
+	}
+	if m.fn != nil {
+		if err := m.fn(tx); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.Exec(`INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, ?, ?)`,
+		m.version, m.name, time.Now()); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func baseline(tx *sql.Tx) error {
+	if _, err := tx.Exec(schema); err != nil {
+		return err
+	}
+
+	for _, c := range columns {
+		exists, _, err := column(tx, c.table, c.name)
+		if err != nil {
+			return err
+		}
+		if exists {
+			continue
+		}
+		if _, err := tx.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", c.table, c.name, c.def)); err != nil {
+			return fmt.Errorf("add column %s.%s: %w", c.table, c.name, err)
+		}
+	}
+
+	if _, notNull, err := column(tx, "repositories", "agent_id"); err != nil {
+		return err
+	} else if notNull {
+		if _, err := tx.Exec(relaxRepositoryAgent); err != nil {
+			return fmt.Errorf("allow repositories without a fixed agent: %w", err)
+		}
+	}
+
+	_, err := tx.Exec(postMigrate)
+	return err
+}
+
+func dropPlaintextTokens(tx *sql.Tx) error {
+	legacy, _, err := column(tx, "agents", "token")
+	if err != nil || !legacy {
+		return err
+	}
+	if err := hashAgentTokens(tx); err != nil {
+		return fmt.Errorf("hash agent tokens: %w", err)
+	}
+	_, err = tx.Exec(dropAgentToken)
+	return err
+}
+
+func hashAgentTokens(q querier) error {
+	rows, err := q.Query(`SELECT id, token FROM agents WHERE token != ''`)
+	if err != nil {
+		return err
+	}
+
+	legacy := make(map[string]string)
+	for rows.Next() {
+		var id, token string
+		if err := rows.Scan(&id, &token); err != nil {
+			rows.Close()
+			return err
+		}
+		legacy[id] = token
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for id, token := range legacy {
+		hash := token
+		if !helper.IsTokenHash(token) {
+			hash = helper.HashToken(token)
+		}
+		if _, err := q.Exec(`UPDATE agents SET token_hash = ?, token = '' WHERE id = ?`, hash, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func column(q querier, table, name string) (exists, notNull bool, err error) {
+	rows, err := q.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return false, false, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var cid, nn, pk int
+		var col, typ string
+		var dflt sql.NullString
+		if err := rows.Scan(&cid, &col, &typ, &nn, &dflt, &pk); err != nil {
+			return false, false, err
+		}
+		if col == name {
+			return true, nn == 1, nil
+		}
+	}
+	return false, false, rows.Err()
+}
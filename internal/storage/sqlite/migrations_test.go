@@ -0,0 +1,171 @@
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+
+package sqlite
+
+import (
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/urustack/uruflow/internal/models"
+)
+
+func newLegacyDatabase(t *testing.T) string {
+	t.Helper()
+	schema, err := os.ReadFile("testdata/schema-initial.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	db, err := sql.Open("sqlite3", filepath.Join(dir, "uruflow-server.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	for _, stmt := range []string{
+		string(schema),
+		`INSERT INTO agents (id, name, token, status) VALUES ('a1', 'web', 'plain-token', 'online')`,
+		`INSERT INTO repositories (name, url, branch, agent_id) VALUES ('api', 'https://github.com/acme/api.git', 'main', 'a1')`,
+		`INSERT INTO deployments (id, repo_name, branch, commit_hash, agent_id, agent_name, status, trigger_type, output, duration_ms)
+			VALUES ('d1', 'api', 'main', 'abc1234', 'a1', 'web', 'success', 'webhook', 'ok', 1500)`,
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatalf("seed legacy database: %v", err)
+		}
+	}
+	return dir
+}
+
+func TestMigrateLegacyDatabase(t *testing.T) {
+	dir := newLegacyDatabase(t)
+
+	store, err := New(dir)
+	if err != nil {
+		t.Fatalf("open legacy database: %v", err)
+	}
+	s := store.(*Store)
+	defer s.Close()
+
+	if v, err := s.schemaVersion(); err != nil || v != migrations[len(migrations)-1].version {
+		t.Fatalf("schema version = %d, %v", v, err)
+	}
+	if exists, _, err := column(s.db, "agents", "token"); err != nil || exists {
+		t.Fatalf("plaintext token column still present: %v", err)
+	}
+
+	agent, err := s.GetAgentByToken("plain-token")
+	if err != nil || agent == nil || agent.ID != "a1" {
+		t.Fatalf("GetAgentByToken after migration = %+v, %v", agent, err)
+	}
+
+	d, err := s.GetDeployment("d1")
+	if err != nil || d == nil {
+		t.Fatalf("GetDeployment = %v, %v", d, err)
+	}
+	if d.Commit != "abc1234" || d.Status != models.DeploySuccess || d.DryRun || d.ExitCode != nil || d.BuildFile != "" || d.Note != "" {
+		t.Fatalf("legacy deployment = %+v", d)
+	}
+
+	repo, err := s.GetRepository("api")
+	if err != nil || repo == nil || repo.AgentID != "a1" {
+		t.Fatalf("GetRepository = %+v, %v", repo, err)
+	}
+	repo.BuildSystem, repo.BuildFile, repo.BuildCmd = "compose", "compose.prod.yaml", ""
+	if err := s.UpdateRepository(repo); err != nil {
+		t.Fatalf("UpdateRepository: %v", err)
+	}
+	if got, _ := s.GetRepository("api"); got.BuildSystem != "compose" || got.BuildFile != "compose.prod.yaml" {
+		t.Fatalf("build settings = %q %q", got.BuildSystem, got.BuildFile)
+	}
+	if err := s.CreateRepository(&models.Repository{Name: "worker", URL: "https://github.com/acme/worker.git", Branch: "main", AgentSelector: "role=worker"}); err != nil {
+		t.Fatalf("repository without a fixed agent: %v", err)
+	}
+
+	if err := s.CreateAgent(&models.Agent{ID: "a2", Name: "db", Status: models.AgentOffline}); err != nil {
+		t.Fatalf("CreateAgent after migration: %v", err)
+	}
+}
+
+func TestMigrateIsIdempotent(t *testing.T) {
+	dir := newLegacyDatabase(t)
+	store, err := New(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.Close()
+
+	store, err = New(dir)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	s := store.(*Store)
+	defer s.Close()
+
+	var applied int
+	if err := s.db.QueryRow(`SELECT COUNT(*) FROM schema_migrations`).Scan(&applied); err != nil {
+		t.Fatal(err)
+	}
+	if applied != len(migrations) {
+		t.Fatalf("%d migrations recorded, want %d", applied, len(migrations))
+	}
+}
+
+func TestMigrateRefusesNewerSchema(t *testing.T) {
+	dir := t.TempDir()
+	store, err := New(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := store.(*Store)
+	if _, err := s.db.Exec(`INSERT INTO schema_migrations (version, name, applied_at) VALUES (999, 'from the future', CURRENT_TIMESTAMP)`); err != nil {
+		t.Fatal(err)
+	}
+	s.Close()
+
+	if _, err := New(dir); !errors.Is(err, ErrSchemaTooNew) {
+		t.Fatalf("open newer database = %v, want ErrSchemaTooNew", err)
+	}
+}
+
+func TestFailedMigrationRollsBack(t *testing.T) {
+	dir := t.TempDir()
+	store, err := New(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := store.(*Store)
+	defer s.Close()
+
+	broken := migration{version: len(migrations) + 1, name: "broken", sql: `
+		ALTER TABLE deployments ADD COLUMN half_applied TEXT;
+		ALTER TABLE no_such_table ADD COLUMN x TEXT;
+	`}
+	if err := s.apply(broken); err == nil {
+		t.Fatal("broken migration applied")
+	}
+	if exists, _, _ := column(s.db, "deployments", "half_applied"); exists {
+		t.Fatal("failed migration left a column behind")
+	}
+	if v, _ := s.schemaVersion(); v != migrations[len(migrations)-1].version {
+		t.Fatalf("schema version = %d after a failed migration", v)
+	}
+}
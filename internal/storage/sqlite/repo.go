@@ -20,16 +20,20 @@ package sqlite
 
 import (
 	"database/sql"
+	"strings"
 	"time"
 
 	"github.com/urustack/uruflow/internal/models"
 )
 
+const repositoryColumns = `id, name, url, branch, agent_id, agent_selector, path, auto_deploy, drift, build_system, build_file, build_cmd, created_at`
+
 func (s *Store) CreateRepository(repo *models.Repository) error {
 	result, err := s.db.Exec(`
-		INSERT INTO repositories (name, url, branch, agent_id, path, auto_deploy)
-		VALUES (?, ?, ?, ?, ?, ?)
-	`, repo.Name, repo.URL, repo.Branch, repo.AgentID, repo.Path, repo.AutoDeploy)
+		INSERT INTO repositories (name, url, branch, agent_id, agent_selector, path, auto_deploy, build_system, build_file, build_cmd)
+		VALUES (?, ?, ?, NULLIF(?, ''), ?, ?, ?, ?, ?, ?)
+	`, repo.Name, repo.URL, repo.Branch, repo.AgentID, repo.AgentSelector, repo.Path, repo.AutoDeploy,
+		repo.BuildSystem, repo.BuildFile, repo.BuildCmd)
 	if err != nil {
 		return err
 	}
@@ -41,31 +45,28 @@ func (s *Store) CreateRepository(repo *models.Repository) error {
 func (s *Store) UpdateRepository(repo *models.Repository) error {
 	_, err := s.db.Exec(`
 		UPDATE repositories SET
-			url = ?, branch = ?, agent_id = ?, path = ?, auto_deploy = ?, updated_at = ?
+			url = ?, branch = ?, agent_id = NULLIF(?, ''), agent_selector = ?, path = ?, auto_deploy = ?,
+			build_system = ?, build_file = ?, build_cmd = ?, updated_at = ?
 		WHERE name = ?
-	`, repo.URL, repo.Branch, repo.AgentID, repo.Path, repo.AutoDeploy, time.Now(), repo.Name)
+	`, repo.URL, repo.Branch, repo.AgentID, repo.AgentSelector, repo.Path, repo.AutoDeploy,
+		repo.BuildSystem, repo.BuildFile, repo.BuildCmd, time.Now(), repo.Name)
 	return err
 }
 
 func (s *Store) GetRepository(name string) (*models.Repository, error) {
-	repo := &models.Repository{}
-	var createdAt sql.NullTime
-	err := s.db.QueryRow(`
-		SELECT id, name, url, branch, agent_id, path, auto_deploy, created_at
+	repo, err := scanRepository(s.db.QueryRow(`
+		SELECT `+repositoryColumns+`
 		FROM repositories WHERE name = ?
-	`, name).Scan(&repo.ID, &repo.Name, &repo.URL, &repo.Branch, &repo.AgentID, &repo.Path, &repo.AutoDeploy, &createdAt)
+	`, name))
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
-	if createdAt.Valid {
-		repo.CreatedAt = createdAt.Time
-	}
 	return repo, err
 }
 
 func (s *Store) GetAllRepositories() ([]models.Repository, error) {
 	rows, err := s.db.Query(`
-		SELECT id, name, url, branch, agent_id, path, auto_deploy, created_at
+		SELECT ` + repositoryColumns + `
 		FROM repositories ORDER BY name
 	`)
 	if err != nil {
@@ -75,20 +76,43 @@ func (s *Store) GetAllRepositories() ([]models.Repository, error) {
 
 	var repos []models.Repository
 	for rows.Next() {
-		var r models.Repository
-		var createdAt sql.NullTime
-		err := rows.Scan(&r.ID, &r.Name, &r.URL, &r.Branch, &r.AgentID, &r.Path, &r.AutoDeploy, &createdAt)
+		r, err := scanRepository(rows)
 		if err != nil {
 			return nil, err
 		}
-		if createdAt.Valid {
-			r.CreatedAt = createdAt.Time
-		}
-		repos = append(repos, r)
+		repos = append(repos, *r)
 	}
 	return repos, nil
 }
 
+func (s *Store) SetRepositoryDrift(name string, findings []string) error {
+	_, err := s.db.Exec(`UPDATE repositories SET drift = ? WHERE name = ?`, strings.Join(findings, "\n"), name)
+	return err
+}
+
+func scanRepository(row rowScanner) (*models.Repository, error) {
+	r := &models.Repository{}
+	var createdAt sql.NullTime
+	var agentID, selector, drift, buildSystem, buildFile, buildCmd sql.NullString
+	err := row.Scan(&r.ID, &r.Name, &r.URL, &r.Branch, &agentID, &selector, &r.Path, &r.AutoDeploy, &drift,
+		&buildSystem, &buildFile, &buildCmd, &createdAt)
+	if err != nil {
+		return nil, err
+	}
+	r.AgentID = agentID.String
+	r.AgentSelector = selector.String
+	r.BuildSystem = models.BuildSystem(buildSystem.String)
+	r.BuildFile = buildFile.String
+	r.BuildCmd = buildCmd.String
+	if createdAt.Valid {
+		r.CreatedAt = createdAt.Time
+	}
+	if drift.Valid && drift.String != "" {
+		r.Drift = strings.Split(drift.String, "\n")
+	}
+	return r, nil
+}
+
 func (s *Store) DeleteRepository(name string) error {
 	_, err := s.db.Exec(`DELETE FROM repositories WHERE name = ?`, name)
 	return err
@@ -0,0 +1,78 @@
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+package sqlite
+
+import (
+	"fmt"
+	"time"
+)
+
+func (s *Store) PruneDeploymentLogs(olderThan time.Time) (int64, error) {
+	res, err := s.db.Exec(`
+		DELETE FROM deployment_logs
+		WHERE timestamp < ? AND deployment_id IN (
+			SELECT id FROM deployments WHERE status NOT IN ('pending', 'running', 'awaiting_approval')
+		)
+	`, olderThan)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+func (s *Store) PruneDeployments(keep int) (int64, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	const expired = `
+		SELECT id FROM deployments
+		WHERE status NOT IN ('pending', 'running', 'awaiting_approval') AND id NOT IN (
+			SELECT id FROM deployments ORDER BY started_at DESC LIMIT ?
+		)`
+
+	if _, err := tx.Exec(`DELETE FROM deployment_logs WHERE deployment_id IN (`+expired+`)`, keep); err != nil {
+		return 0, fmt.Errorf("delete logs: %w", err)
+	}
+	if _, err := tx.Exec(`DELETE FROM deployment_steps WHERE deployment_id IN (`+expired+`)`, keep); err != nil {
+		return 0, fmt.Errorf("delete steps: %w", err)
+	}
+	if _, err := tx.Exec(`DELETE FROM deployment_containers WHERE deployment_id IN (`+expired+`)`, keep); err != nil {
+		return 0, fmt.Errorf("delete containers: %w", err)
+	}
+	if _, err := tx.Exec(`DELETE FROM deployment_artifacts WHERE deployment_id IN (`+expired+`)`, keep); err != nil {
+		return 0, fmt.Errorf("delete artifacts: %w", err)
+	}
+	res, err := tx.Exec(`DELETE FROM deployments WHERE id IN (`+expired+`)`, keep)
+	if err != nil {
+		return 0, fmt.Errorf("delete deployments: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return n, tx.Commit()
+}
+
+func (s *Store) Vacuum() error {
+	_, err := s.db.Exec(`VACUUM`)
+	return err
+}
@@ -22,11 +22,16 @@ const schema = `
 CREATE TABLE IF NOT EXISTS agents (
 	id TEXT PRIMARY KEY,
 	name TEXT NOT NULL UNIQUE,
-	token TEXT NOT NULL,
+	token TEXT NOT NULL DEFAULT '',
+	token_hash TEXT DEFAULT '',
 	host TEXT DEFAULT '',
 	hostname TEXT DEFAULT '',
+	machine_id TEXT DEFAULT '',
 	version TEXT DEFAULT '',
 	status TEXT DEFAULT 'offline',
+	labels TEXT DEFAULT '',
+	clock_skew_ms INTEGER DEFAULT 0,
+	degraded INTEGER DEFAULT 0,
 	cpu_percent REAL DEFAULT 0,
 	memory_percent REAL DEFAULT 0,
 	disk_percent REAL DEFAULT 0,
@@ -35,6 +40,14 @@ CREATE TABLE IF NOT EXISTS agents (
 	disk_used INTEGER DEFAULT 0,
 	disk_total INTEGER DEFAULT 0,
 	uptime INTEGER DEFAULT 0,
+	queued_deploys INTEGER DEFAULT 0,
+	docker_images_bytes INTEGER,
+	docker_containers_bytes INTEGER,
+	docker_volumes_bytes INTEGER,
+	docker_build_cache_bytes INTEGER,
+	reclaimed_bytes INTEGER DEFAULT 0,
+	maintenance INTEGER DEFAULT 0,
+	last_cleanup_at DATETIME,
 	last_heartbeat DATETIME,
 	created_at DATETIME DEFAULT CURRENT_TIMESTAMP
 );
@@ -61,9 +74,14 @@ CREATE TABLE IF NOT EXISTS repositories (
 	name TEXT NOT NULL UNIQUE,
 	url TEXT NOT NULL,
 	branch TEXT DEFAULT 'main',
-	agent_id TEXT NOT NULL,
+	agent_id TEXT,
+	agent_selector TEXT DEFAULT '',
 	path TEXT DEFAULT '',
 	auto_deploy INTEGER DEFAULT 1,
+	drift TEXT DEFAULT '',
+	build_system TEXT DEFAULT '',
+	build_file TEXT DEFAULT '',
+	build_cmd TEXT DEFAULT '',
 	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
 	updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
 	FOREIGN KEY (agent_id) REFERENCES agents(id)
@@ -82,6 +100,13 @@ CREATE TABLE IF NOT EXISTS deployments (
 	started_at DATETIME DEFAULT CURRENT_TIMESTAMP,
 	finished_at DATETIME,
 	duration_ms INTEGER DEFAULT 0,
+	config_hash TEXT DEFAULT '',
+	rollback_of TEXT DEFAULT '',
+	change_summary TEXT DEFAULT '',
+	triggered_by TEXT,
+	source_ip TEXT,
+	image_unchanged INTEGER DEFAULT 0,
+	tag TEXT DEFAULT '',
 	FOREIGN KEY (agent_id) REFERENCES agents(id)
 );
 
@@ -94,6 +119,38 @@ CREATE TABLE IF NOT EXISTS deployment_logs (
 	FOREIGN KEY (deployment_id) REFERENCES deployments(id) ON DELETE CASCADE
 );
 
+CREATE TABLE IF NOT EXISTS deployment_steps (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	deployment_id TEXT NOT NULL,
+	name TEXT NOT NULL,
+	status TEXT DEFAULT 'running',
+	started_at DATETIME,
+	duration_ms INTEGER DEFAULT 0,
+	UNIQUE (deployment_id, name),
+	FOREIGN KEY (deployment_id) REFERENCES deployments(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS deployment_containers (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	deployment_id TEXT NOT NULL,
+	name TEXT NOT NULL,
+	service TEXT DEFAULT '',
+	image TEXT DEFAULT '',
+	image_id TEXT DEFAULT '',
+	FOREIGN KEY (deployment_id) REFERENCES deployments(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS deployment_artifacts (
+	deployment_id TEXT NOT NULL,
+	name TEXT NOT NULL,
+	content TEXT NOT NULL DEFAULT '',
+	size INTEGER DEFAULT 0,
+	truncated INTEGER DEFAULT 0,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	PRIMARY KEY (deployment_id, name),
+	FOREIGN KEY (deployment_id) REFERENCES deployments(id) ON DELETE CASCADE
+);
+
 CREATE TABLE IF NOT EXISTS alerts (
 	id TEXT PRIMARY KEY,
 	type TEXT NOT NULL,
@@ -102,13 +159,54 @@ CREATE TABLE IF NOT EXISTS alerts (
 	agent_name TEXT NOT NULL,
 	message TEXT NOT NULL,
 	resolved INTEGER DEFAULT 0,
+	auto_resolved INTEGER DEFAULT 0,
 	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
 	resolved_at DATETIME,
 	FOREIGN KEY (agent_id) REFERENCES agents(id)
 );
 
+CREATE TABLE IF NOT EXISTS commands (
+	id TEXT PRIMARY KEY,
+	type TEXT NOT NULL,
+	agent_id TEXT NOT NULL,
+	payload TEXT DEFAULT '',
+	status TEXT DEFAULT 'pending',
+	output TEXT DEFAULT '',
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	started_at DATETIME,
+	ended_at DATETIME,
+	FOREIGN KEY (agent_id) REFERENCES agents(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS metrics_history (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	agent_id TEXT NOT NULL,
+	ts DATETIME NOT NULL,
+	cpu REAL DEFAULT 0,
+	mem REAL DEFAULT 0,
+	disk REAL DEFAULT 0
+);
+
+CREATE TABLE IF NOT EXISTS webhook_events (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	source TEXT NOT NULL,
+	repo TEXT DEFAULT '',
+	branch TEXT DEFAULT '',
+	commit_hash TEXT DEFAULT '',
+	outcome TEXT NOT NULL,
+	reason TEXT DEFAULT '',
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS agent_events (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	agent_id TEXT NOT NULL DEFAULT '',
+	event_type TEXT NOT NULL,
+	detail TEXT DEFAULT '',
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
 CREATE INDEX IF NOT EXISTS idx_agents_status ON agents(status);
-CREATE INDEX IF NOT EXISTS idx_agents_token ON agents(token);
 CREATE INDEX IF NOT EXISTS idx_containers_agent ON containers(agent_id);
 CREATE INDEX IF NOT EXISTS idx_deployments_repo ON deployments(repo_name);
 CREATE INDEX IF NOT EXISTS idx_deployments_agent ON deployments(agent_id);
@@ -116,4 +214,71 @@ CREATE INDEX IF NOT EXISTS idx_deployments_started ON deployments(started_at DES
 CREATE INDEX IF NOT EXISTS idx_alerts_resolved ON alerts(resolved);
 CREATE INDEX IF NOT EXISTS idx_alerts_agent ON alerts(agent_id);
 CREATE INDEX IF NOT EXISTS idx_deployment_logs_deployment ON deployment_logs(deployment_id);
+CREATE INDEX IF NOT EXISTS idx_commands_agent ON commands(agent_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_metrics_history_agent_ts ON metrics_history(agent_id, ts);
+CREATE INDEX IF NOT EXISTS idx_webhook_events_created ON webhook_events(created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_agent_events_agent ON agent_events(agent_id, id DESC);
+`
+
+var columns = []struct {
+	table string
+	name  string
+	def   string
+}{
+	{"repositories", "drift", "TEXT DEFAULT ''"},
+	{"deployments", "config_hash", "TEXT DEFAULT ''"},
+	{"agents", "token_hash", "TEXT DEFAULT ''"},
+	{"deployments", "rollback_of", "TEXT DEFAULT ''"},
+	{"alerts", "auto_resolved", "INTEGER DEFAULT 0"},
+	{"agents", "clock_skew_ms", "INTEGER DEFAULT 0"},
+	{"agents", "degraded", "INTEGER DEFAULT 0"},
+	{"agents", "queued_deploys", "INTEGER DEFAULT 0"},
+	{"agents", "labels", "TEXT DEFAULT ''"},
+	{"agents", "docker_images_bytes", "INTEGER"},
+	{"agents", "docker_containers_bytes", "INTEGER"},
+	{"agents", "docker_volumes_bytes", "INTEGER"},
+	{"agents", "docker_build_cache_bytes", "INTEGER"},
+	{"agents", "reclaimed_bytes", "INTEGER DEFAULT 0"},
+	{"agents", "last_cleanup_at", "DATETIME"},
+	{"agents", "maintenance", "INTEGER DEFAULT 0"},
+	{"repositories", "agent_selector", "TEXT DEFAULT ''"},
+	{"deployments", "change_summary", "TEXT DEFAULT ''"},
+	{"deployments", "image_unchanged", "INTEGER DEFAULT 0"},
+	{"deployments", "triggered_by", "TEXT"},
+	{"deployments", "source_ip", "TEXT"},
+	{"containers", "stats_stale", "INTEGER DEFAULT 0"},
+	{"agents", "low_disk_for_deploys", "INTEGER DEFAULT 0"},
+	{"repositories", "build_system", "TEXT DEFAULT ''"},
+	{"repositories", "build_file", "TEXT DEFAULT ''"},
+	{"repositories", "build_cmd", "TEXT DEFAULT ''"},
+	{"deployments", "tag", "TEXT DEFAULT ''"},
+	{"agents", "machine_id", "TEXT DEFAULT ''"},
+}
+
+const relaxRepositoryAgent = `
+CREATE TABLE repositories_new (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	name TEXT NOT NULL UNIQUE,
+	url TEXT NOT NULL,
+	branch TEXT DEFAULT 'main',
+	agent_id TEXT,
+	agent_selector TEXT DEFAULT '',
+	path TEXT DEFAULT '',
+	auto_deploy INTEGER DEFAULT 1,
+	drift TEXT DEFAULT '',
+	build_system TEXT DEFAULT '',
+	build_file TEXT DEFAULT '',
+	build_cmd TEXT DEFAULT '',
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	FOREIGN KEY (agent_id) REFERENCES agents(id)
+);
+INSERT INTO repositories_new (id, name, url, branch, agent_id, agent_selector, path, auto_deploy, drift, build_system, build_file, build_cmd, created_at, updated_at)
+	SELECT id, name, url, branch, agent_id, agent_selector, path, auto_deploy, drift, build_system, build_file, build_cmd, created_at, updated_at FROM repositories;
+DROP TABLE repositories;
+ALTER TABLE repositories_new RENAME TO repositories;
+`
+
+const postMigrate = `
+CREATE INDEX IF NOT EXISTS idx_agents_token_hash ON agents(token_hash);
 `
@@ -30,7 +30,8 @@ import (
 )
 
 type Store struct {
-	db *sql.DB
+	db            *sql.DB
+	insertMetrics *sql.Stmt
 }
 
 func New(dataDir string) (storage.Store, error) {
@@ -53,18 +54,21 @@ func New(dataDir string) (storage.Store, error) {
 		return nil, fmt.Errorf("migrate: %w", err)
 	}
 
+	store.insertMetrics, err = conn.Prepare(`INSERT INTO metrics_history (agent_id, ts, cpu, mem, disk) VALUES (?, ?, ?, ?, ?)`)
+	if err != nil {
+		return nil, fmt.Errorf("prepare statements: %w", err)
+	}
+
 	return store, nil
 }
 
 func (s *Store) Close() error {
+	if s.insertMetrics != nil {
+		s.insertMetrics.Close()
+	}
 	return s.db.Close()
 }
 
-func (s *Store) migrate() error {
-	_, err := s.db.Exec(schema)
-	return err
-}
-
 func (s *Store) GetStats() (*storage.Stats, error) {
 	stats := &storage.Stats{}
 
@@ -77,8 +81,8 @@ func (s *Store) GetStats() (*storage.Stats, error) {
 	s.db.QueryRow(`SELECT COUNT(*) FROM deployments WHERE started_at >= ?`, today).Scan(&stats.DeploymentsToday)
 
 	var success, total int
-	s.db.QueryRow(`SELECT COUNT(*) FROM deployments WHERE status = 'success'`).Scan(&success)
-	s.db.QueryRow(`SELECT COUNT(*) FROM deployments WHERE status IN ('success', 'failed')`).Scan(&total)
+	s.db.QueryRow(`SELECT COUNT(*) FROM deployments WHERE status IN ('success', 'success_with_warnings') AND dry_run = 0`).Scan(&success)
+	s.db.QueryRow(`SELECT COUNT(*) FROM deployments WHERE status IN ('success', 'success_with_warnings', 'failed') AND dry_run = 0`).Scan(&total)
 	if total > 0 {
 		stats.SuccessRate = float64(success) / float64(total) * 100
 	}
@@ -89,3 +93,52 @@ func (s *Store) GetStats() (*storage.Stats, error) {
 
 	return stats, nil
 }
+
+func (s *Store) GetRepoStats() ([]storage.RepoStats, error) {
+	rows, err := s.db.Query(`
+		SELECT r.name, l.started_at,
+			COUNT(d.id),
+			COALESCE(SUM(d.status IN ('success', 'success_with_warnings')), 0),
+			COALESCE(SUM(d.status IN ('success', 'success_with_warnings', 'failed')), 0),
+			COALESCE(AVG(CASE WHEN d.status IN ('success', 'success_with_warnings', 'failed') THEN d.duration_ms END), 0),
+			(
+				SELECT COUNT(*) FROM deployments f
+				WHERE f.repo_name = r.name AND f.status = 'failed' AND f.dry_run = 0
+					AND f.started_at > COALESCE((
+						SELECT MAX(started_at) FROM deployments ok
+						WHERE ok.repo_name = r.name AND ok.status IN ('success', 'success_with_warnings')
+					), '')
+			)
+		FROM repositories r
+		LEFT JOIN deployments l ON l.id = (
+			SELECT id FROM deployments WHERE repo_name = r.name ORDER BY started_at DESC LIMIT 1
+		)
+		LEFT JOIN deployments d ON d.repo_name = r.name AND d.dry_run = 0 AND d.started_at >= ?
+		GROUP BY r.name
+		ORDER BY r.name
+	`, time.Now().Add(-storage.RepoStatsWindow))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var stats []storage.RepoStats
+	for rows.Next() {
+		var st storage.RepoStats
+		var lastDeploy sql.NullTime
+		var success, finished int
+		var avgMs float64
+		if err := rows.Scan(&st.Repo, &lastDeploy, &st.Deploys, &success, &finished, &avgMs, &st.FailStreak); err != nil {
+			return nil, err
+		}
+		if lastDeploy.Valid {
+			st.LastDeploy = &lastDeploy.Time
+		}
+		if finished > 0 {
+			st.SuccessRate = float64(success) / float64(finished) * 100
+		}
+		st.AvgDuration = time.Duration(avgMs) * time.Millisecond
+		stats = append(stats, st)
+	}
+	return stats, rows.Err()
+}
@@ -0,0 +1,73 @@
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+
+package sqlite
+
+import (
+	"time"
+
+	"github.com/urustack/uruflow/internal/models"
+)
+
+func (s *Store) AddWebhookEvent(e *models.WebhookEvent) error {
+	if e.CreatedAt.IsZero() {
+		e.CreatedAt = time.Now()
+	}
+	res, err := s.db.Exec(`
+		INSERT INTO webhook_events (source, repo, branch, commit_hash, outcome, reason, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, e.Source, e.Repo, e.Branch, e.Commit, e.Outcome, e.Reason, e.CreatedAt)
+	if err != nil {
+		return err
+	}
+	e.ID, _ = res.LastInsertId()
+	return nil
+}
+
+func (s *Store) GetWebhookEvents(limit int) ([]models.WebhookEvent, error) {
+	rows, err := s.db.Query(`
+		SELECT id, source, repo, branch, commit_hash, outcome, reason, created_at
+		FROM webhook_events ORDER BY created_at DESC, id DESC LIMIT ?
+	`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []models.WebhookEvent
+	for rows.Next() {
+		var e models.WebhookEvent
+		if err := rows.Scan(&e.ID, &e.Source, &e.Repo, &e.Branch, &e.Commit, &e.Outcome, &e.Reason, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
+
+func (s *Store) PruneWebhookEvents(keep int) (int64, error) {
+	res, err := s.db.Exec(`
+		DELETE FROM webhook_events WHERE id NOT IN (
+			SELECT id FROM webhook_events ORDER BY created_at DESC, id DESC LIMIT ?
+		)
+	`, keep)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
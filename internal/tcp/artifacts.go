@@ -0,0 +1,101 @@
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+
+package tcp
+
+import (
+	"strings"
+
+	"github.com/urustack/uruflow/internal/models"
+	"github.com/urustack/uruflow/internal/tcp/protocol"
+	"github.com/urustack/uruflow/pkg/logger"
+)
+
+type artifactKey struct {
+	commandID string
+	name      string
+}
+
+type artifactUpload struct {
+	data      strings.Builder
+	next      int
+	truncated bool
+}
+
+func (s *Server) handleCommandArtifact(conn *Connection, msg *protocol.Message) {
+	var chunk protocol.CommandArtifactPayload
+	if err := msg.Decode(&chunk); err != nil || chunk.CommandID == "" || chunk.Name == "" {
+		return
+	}
+
+	key := artifactKey{chunk.CommandID, chunk.Name}
+	s.artifactMu.Lock()
+	upload, ok := s.artifacts[key]
+	if chunk.Seq == 0 {
+		if deploy, _ := s.store.GetDeployment(chunk.CommandID); deploy == nil {
+			s.artifactMu.Unlock()
+			return
+		}
+		upload = &artifactUpload{}
+		s.artifacts[key] = upload
+	} else if !ok || chunk.Seq != upload.next {
+		delete(s.artifacts, key)
+		s.artifactMu.Unlock()
+		logger.Warn("[TCP] agent %s sent artifact %s for %s out of order, discarding", conn.AgentName, chunk.Name, chunk.CommandID)
+		return
+	}
+
+	upload.next++
+	upload.truncated = upload.truncated || chunk.Truncated
+	if room := protocol.MaxArtifactSize - upload.data.Len(); len(chunk.Data) > room {
+		upload.data.WriteString(chunk.Data[:room])
+		upload.truncated = true
+	} else {
+		upload.data.WriteString(chunk.Data)
+	}
+	if !chunk.Final {
+		s.artifactMu.Unlock()
+		return
+	}
+	delete(s.artifacts, key)
+	s.artifactMu.Unlock()
+
+	content := strings.ToValidUTF8(upload.data.String(), "")
+	artifact := &models.DeploymentArtifact{
+		DeploymentID: chunk.CommandID,
+		Name:         chunk.Name,
+		Content:      content,
+		Size:         len(content),
+		Truncated:    upload.truncated,
+	}
+	if err := s.store.SaveDeploymentArtifact(artifact); err != nil {
+		logger.Warn("[TCP] failed to store artifact %s for %s: %v", chunk.Name, chunk.CommandID, err)
+		return
+	}
+	logger.Info("[TCP] stored artifact %s for deployment %s (%d bytes)", chunk.Name, chunk.CommandID, artifact.Size)
+}
+
+func (s *Server) dropArtifacts(commandID string) {
+	s.artifactMu.Lock()
+	defer s.artifactMu.Unlock()
+	for key := range s.artifacts {
+		if key.commandID == commandID {
+			delete(s.artifacts, key)
+		}
+	}
+}
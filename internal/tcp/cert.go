@@ -22,47 +22,160 @@ import (
 	"crypto/ecdsa"
 	"crypto/elliptic"
 	"crypto/rand"
+	"crypto/sha256"
 	"crypto/tls"
 	"crypto/x509"
 	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
 	"math/big"
 	"net"
+	"os"
+	"path/filepath"
+	"strings"
 	"time"
 )
 
-func generateSelfSignedCert() (tls.Certificate, error) {
+const certRenewBefore = 30 * 24 * time.Hour
+
+func loadOrCreateAutoCert(dir string, hosts []string, validity time.Duration, strictHosts bool) (tls.Certificate, bool, error) {
+	certPath := filepath.Join(dir, "server.crt")
+	keyPath := filepath.Join(dir, "server.key")
+
+	cert, err := tls.LoadX509KeyPair(certPath, keyPath)
+	if err == nil {
+		leaf, perr := x509.ParseCertificate(cert.Certificate[0])
+		if perr == nil && time.Until(leaf.NotAfter) > renewWindow(leaf) && (!strictHosts || certCovers(leaf, hosts)) {
+			cert.Leaf = leaf
+			return cert, false, nil
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return tls.Certificate{}, false, fmt.Errorf("load %s: %w", certPath, err)
+	}
+
+	cert, certPEM, keyPEM, err := generateSelfSignedCert(hosts, validity)
+	if err != nil {
+		return tls.Certificate{}, false, err
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return tls.Certificate{}, false, fmt.Errorf("create %s: %w", dir, err)
+	}
+	if err := os.WriteFile(keyPath, keyPEM, 0600); err != nil {
+		return tls.Certificate{}, false, fmt.Errorf("write key: %w", err)
+	}
+	if err := os.WriteFile(certPath, certPEM, 0644); err != nil {
+		return tls.Certificate{}, false, fmt.Errorf("write cert: %w", err)
+	}
+	return cert, true, nil
+}
+
+func renewWindow(leaf *x509.Certificate) time.Duration {
+	return min(certRenewBefore, leaf.NotAfter.Sub(leaf.NotBefore)/4)
+}
+
+func generateSelfSignedCert(hosts []string, validity time.Duration) (tls.Certificate, []byte, []byte, error) {
 	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
 	if err != nil {
-		return tls.Certificate{}, err
+		return tls.Certificate{}, nil, nil, err
 	}
 
 	serialNumber, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
 	if err != nil {
-		return tls.Certificate{}, err
+		return tls.Certificate{}, nil, nil, err
 	}
 
 	template := x509.Certificate{
 		SerialNumber: serialNumber,
 		Subject: pkix.Name{
 			Organization: []string{"UruFlow"},
-			CommonName:   "uruflow-server-server",
+			CommonName:   "uruflow-server",
 		},
-		NotBefore:             time.Now(),
-		NotAfter:              time.Now().Add(365 * 24 * time.Hour),
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(validity),
 		KeyUsage:              x509.KeyUsageKeyEncipherment | x509.KeyUsageDigitalSignature,
 		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
 		BasicConstraintsValid: true,
-		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1"), net.ParseIP("0.0.0.0")},
-		DNSNames:              []string{"localhost", "uruflow-server"},
+	}
+	for _, h := range hosts {
+		if ip := net.ParseIP(h); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else if h != "" {
+			template.DNSNames = append(template.DNSNames, h)
+		}
 	}
 
 	certDER, err := x509.CreateCertificate(rand.Reader, &template, &template, &priv.PublicKey, priv)
 	if err != nil {
-		return tls.Certificate{}, err
+		return tls.Certificate{}, nil, nil, err
+	}
+	keyDER, err := x509.MarshalECPrivateKey(priv)
+	if err != nil {
+		return tls.Certificate{}, nil, nil, err
+	}
+	leaf, err := x509.ParseCertificate(certDER)
+	if err != nil {
+		return tls.Certificate{}, nil, nil, err
 	}
 
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDER})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
 	return tls.Certificate{
 		Certificate: [][]byte{certDER},
 		PrivateKey:  priv,
-	}, nil
+		Leaf:        leaf,
+	}, certPEM, keyPEM, nil
+}
+
+func certCovers(leaf *x509.Certificate, hosts []string) bool {
+	for _, h := range hosts {
+		if ip := net.ParseIP(h); ip != nil {
+			found := false
+			for _, have := range leaf.IPAddresses {
+				if have.Equal(ip) {
+					found = true
+					break
+				}
+			}
+			if !found {
+				return false
+			}
+		} else if h != "" && leaf.VerifyHostname(h) != nil {
+			return false
+		}
+	}
+	return true
+}
+
+func defaultCertHosts() []string {
+	hosts := []string{"localhost", "127.0.0.1"}
+	if name, err := os.Hostname(); err == nil && name != "" && name != "localhost" {
+		hosts = append(hosts, name)
+	}
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		return hosts
+	}
+	for _, addr := range addrs {
+		ipnet, ok := addr.(*net.IPNet)
+		if !ok || ipnet.IP.IsLoopback() || ipnet.IP.IsLinkLocalUnicast() {
+			continue
+		}
+		hosts = append(hosts, ipnet.IP.String())
+	}
+	return hosts
+}
+
+func CertFingerprint(der []byte) string {
+	sum := sha256.Sum256(der)
+	s := strings.ToUpper(hex.EncodeToString(sum[:]))
+	var b strings.Builder
+	for i := 0; i < len(s); i += 2 {
+		if i > 0 {
+			b.WriteByte(':')
+		}
+		b.WriteString(s[i : i+2])
+	}
+	return b.String()
 }
@@ -0,0 +1,152 @@
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+
+package tcp
+
+import (
+	"crypto/x509"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"testing"
+	"time"
+)
+
+const year = 365 * 24 * time.Hour
+
+func TestAutoCertPersistsAcrossRestarts(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "tls")
+	hosts := []string{"uruflow.example.com", "10.0.0.5"}
+
+	first, created, err := loadOrCreateAutoCert(dir, hosts, year, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !created {
+		t.Fatal("first start did not generate a certificate")
+	}
+
+	second, created, err := loadOrCreateAutoCert(dir, hosts, year, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if created {
+		t.Fatal("restart generated a new certificate instead of reusing the stored one")
+	}
+	if CertFingerprint(first.Certificate[0]) != CertFingerprint(second.Certificate[0]) {
+		t.Fatal("fingerprint changed across restart")
+	}
+	if second.Leaf == nil {
+		t.Fatal("reused certificate has no parsed leaf")
+	}
+
+	info, err := os.Stat(filepath.Join(dir, "server.key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mode := info.Mode().Perm(); mode != 0600 {
+		t.Fatalf("server.key mode = %o, want 600", mode)
+	}
+}
+
+func TestAutoCertSANs(t *testing.T) {
+	hosts := []string{"uruflow.example.com", "deploy.internal", "10.0.0.5", "::1", ""}
+	cert, _, err := loadOrCreateAutoCert(t.TempDir(), hosts, year, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf := cert.Leaf
+
+	if want := []string{"uruflow.example.com", "deploy.internal"}; !slices.Equal(leaf.DNSNames, want) {
+		t.Fatalf("DNSNames = %v, want %v", leaf.DNSNames, want)
+	}
+	if len(leaf.IPAddresses) != 2 || !leaf.IPAddresses[0].Equal(net.ParseIP("10.0.0.5")) || !leaf.IPAddresses[1].Equal(net.ParseIP("::1")) {
+		t.Fatalf("IPAddresses = %v, want [10.0.0.5 ::1]", leaf.IPAddresses)
+	}
+	if !slices.Contains(leaf.ExtKeyUsage, x509.ExtKeyUsageServerAuth) {
+		t.Fatal("certificate is not valid for server auth")
+	}
+	for _, h := range []string{"uruflow.example.com", "deploy.internal", "10.0.0.5"} {
+		if err := leaf.VerifyHostname(h); err != nil {
+			t.Errorf("VerifyHostname(%s): %v", h, err)
+		}
+	}
+	if err := leaf.VerifyHostname("other.example.com"); err == nil {
+		t.Error("certificate verified for a host it was not issued for")
+	}
+}
+
+func TestAutoCertRenewsNearExpiry(t *testing.T) {
+	dir := t.TempDir()
+	hosts := []string{"localhost"}
+
+	first, _, err := loadOrCreateAutoCert(dir, hosts, 10*time.Minute, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	second, created, err := loadOrCreateAutoCert(dir, hosts, year, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !created {
+		t.Fatal("certificate inside its renew window was reused")
+	}
+	if CertFingerprint(first.Certificate[0]) == CertFingerprint(second.Certificate[0]) {
+		t.Fatal("renewed certificate kept the old fingerprint")
+	}
+}
+
+func TestAutoCertHosts(t *testing.T) {
+	dir := t.TempDir()
+	first, _, err := loadOrCreateAutoCert(dir, []string{"a.example.com"}, year, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, created, err := loadOrCreateAutoCert(dir, []string{"b.example.com"}, year, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if created {
+		t.Fatal("default hosts forced a new certificate")
+	}
+
+	second, created, err := loadOrCreateAutoCert(dir, []string{"a.example.com", "b.example.com"}, year, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !created {
+		t.Fatal("configured host missing from the stored certificate did not regenerate it")
+	}
+	if CertFingerprint(first.Certificate[0]) == CertFingerprint(second.Certificate[0]) {
+		t.Fatal("regenerated certificate kept the old fingerprint")
+	}
+	if !certCovers(second.Leaf, []string{"a.example.com", "b.example.com"}) {
+		t.Fatalf("regenerated certificate SANs = %v", second.Leaf.DNSNames)
+	}
+}
+
+func TestCertFingerprint(t *testing.T) {
+	fp := CertFingerprint([]byte("uruflow"))
+	if !regexp.MustCompile(`^([0-9A-F]{2}:){31}[0-9A-F]{2}$`).MatchString(fp) {
+		t.Fatalf("fingerprint %q is not colon-separated uppercase sha256", fp)
+	}
+}
@@ -19,13 +19,28 @@
 package tcp
 
 import (
+	"errors"
 	"net"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/urustack/uruflow/internal/tcp/protocol"
 )
 
+const (
+	SendQueueSize = 256
+	writeTimeout  = 10 * time.Second
+	drainTimeout  = 2 * time.Second
+)
+
+var ErrSendQueueFull = errors.New("send queue full")
+
+type outbound struct {
+	msg         *protocol.Message
+	compression string
+}
+
 type Connection struct {
 	ID        string
 	AgentID   string
@@ -33,24 +48,93 @@ type Connection struct {
 	Conn      net.Conn
 	Reader    *protocol.Reader
 	Writer    *protocol.Writer
+	Exec      []string
+	Version   string
+	Hostname  string
+	MachineID string
+	TokenHash string
+	Probe     bool
 	Connected time.Time
 	LastPing  time.Time
+	sampledAt time.Time
 	mu        sync.Mutex
 	closed    bool
+	reason    string
+	done      chan struct{}
+	queue     chan outbound
+	written   chan struct{}
+	dropped   int
+	counted   *countingConn
+	statsMu   sync.Mutex
+	stats     ConnectionStats
+}
+
+type ConnectionStats struct {
+	MessagesIn  int
+	MessagesOut int
+	BytesIn     int64
+	BytesOut    int64
+	InByType    map[string]int
+	OutByType   map[string]int
+	LastIn      time.Time
+	LastOut     time.Time
+	WriteErrors int
+	QueueDepth  int
+	Dropped     int
+}
+
+func (s ConnectionStats) LastActivity() time.Time {
+	if s.LastOut.After(s.LastIn) {
+		return s.LastOut
+	}
+	return s.LastIn
+}
+
+type countingConn struct {
+	net.Conn
+	in  atomic.Int64
+	out atomic.Int64
+}
+
+func (c *countingConn) Read(p []byte) (int, error) {
+	n, err := c.Conn.Read(p)
+	c.in.Add(int64(n))
+	return n, err
+}
+
+func (c *countingConn) Write(p []byte) (int, error) {
+	n, err := c.Conn.Write(p)
+	c.out.Add(int64(n))
+	return n, err
 }
 
 func NewConnection(id string, conn net.Conn) *Connection {
-	return &Connection{
+	counted := &countingConn{Conn: conn}
+	c := &Connection{
 		ID:        id,
 		Conn:      conn,
-		Reader:    protocol.NewReader(conn),
-		Writer:    protocol.NewWriter(conn),
+		Reader:    protocol.NewReader(counted),
+		Writer:    protocol.NewWriter(counted),
+		counted:   counted,
 		Connected: time.Now(),
 		LastPing:  time.Now(),
+		done:      make(chan struct{}),
+		queue:     make(chan outbound, SendQueueSize),
+		written:   make(chan struct{}),
 	}
+	go c.writeLoop()
+	return c
 }
 
 func (c *Connection) Send(msg *protocol.Message) error {
+	return c.enqueue(outbound{msg: msg})
+}
+
+func (c *Connection) SetCompression(alg string) error {
+	return c.enqueue(outbound{compression: alg})
+}
+
+func (c *Connection) enqueue(out outbound) error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
@@ -58,29 +142,178 @@ func (c *Connection) Send(msg *protocol.Message) error {
 		return net.ErrClosed
 	}
 
-	return c.Writer.WriteWithTimeout(msg, 10*time.Second)
+	select {
+	case c.queue <- out:
+		return nil
+	default:
+	}
+
+	c.dropped++
+	if out.msg != nil && droppable(out.msg.Type) {
+		return nil
+	}
+	return ErrSendQueueFull
+}
+
+func droppable(t protocol.MessageType) bool {
+	return t == protocol.TypePing || t == protocol.TypePong || t == protocol.TypeMetricsAck
+}
+
+func (c *Connection) QueueStats() (depth, dropped int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.queue), c.dropped
+}
+
+func (c *Connection) Stats() ConnectionStats {
+	c.statsMu.Lock()
+	stats := c.stats
+	stats.InByType = make(map[string]int, len(c.stats.InByType))
+	for t, n := range c.stats.InByType {
+		stats.InByType[t] = n
+	}
+	stats.OutByType = make(map[string]int, len(c.stats.OutByType))
+	for t, n := range c.stats.OutByType {
+		stats.OutByType[t] = n
+	}
+	c.statsMu.Unlock()
+
+	stats.BytesIn = c.counted.in.Load()
+	stats.BytesOut = c.counted.out.Load()
+	stats.QueueDepth, stats.Dropped = c.QueueStats()
+	return stats
+}
+
+func (c *Connection) countIn(msg *protocol.Message) {
+	c.statsMu.Lock()
+	defer c.statsMu.Unlock()
+	if c.stats.InByType == nil {
+		c.stats.InByType = make(map[string]int)
+	}
+	c.stats.MessagesIn++
+	c.stats.InByType[msg.Type.String()]++
+	c.stats.LastIn = time.Now()
+}
+
+func (c *Connection) countOut(msg *protocol.Message, err error) {
+	c.statsMu.Lock()
+	defer c.statsMu.Unlock()
+	if err != nil {
+		c.stats.WriteErrors++
+		return
+	}
+	if c.stats.OutByType == nil {
+		c.stats.OutByType = make(map[string]int)
+	}
+	c.stats.MessagesOut++
+	c.stats.OutByType[msg.Type.String()]++
+	c.stats.LastOut = time.Now()
+}
+
+func (c *Connection) writeLoop() {
+	defer close(c.written)
+
+	for {
+		select {
+		case out := <-c.queue:
+			if err := c.write(out, writeTimeout); err != nil {
+				c.fail("write error: " + err.Error())
+				return
+			}
+		case <-c.done:
+			c.drain()
+			return
+		}
+	}
+}
+
+func (c *Connection) drain() {
+	deadline := time.Now().Add(drainTimeout)
+	for {
+		select {
+		case out := <-c.queue:
+			remaining := time.Until(deadline)
+			if remaining <= 0 || c.write(out, remaining) != nil {
+				return
+			}
+		default:
+			return
+		}
+	}
+}
+
+func (c *Connection) write(out outbound, timeout time.Duration) error {
+	if out.msg == nil {
+		c.Writer.SetCompression(out.compression)
+		return nil
+	}
+	err := c.Writer.WriteWithTimeout(out.msg, timeout)
+	c.countOut(out.msg, err)
+	return err
+}
+
+func (c *Connection) fail(reason string) {
+	c.mu.Lock()
+	if !c.closed {
+		c.closed = true
+		c.reason = reason
+		close(c.done)
+	}
+	c.mu.Unlock()
+	c.Conn.Close()
 }
 
 func (c *Connection) Receive() (*protocol.Message, error) {
-	return c.Reader.Read()
+	msg, err := c.Reader.Read()
+	if err == nil {
+		c.countIn(msg)
+	}
+	return msg, err
 }
 
 func (c *Connection) ReceiveWithTimeout(timeout time.Duration) (*protocol.Message, error) {
-	return c.Reader.ReadWithTimeout(timeout)
+	msg, err := c.Reader.ReadWithTimeout(timeout)
+	if err == nil {
+		c.countIn(msg)
+	}
+	return msg, err
 }
 
 func (c *Connection) Close() error {
-	c.mu.Lock()
-	defer c.mu.Unlock()
+	return c.CloseWithReason("")
+}
 
+func (c *Connection) CloseWithReason(reason string) error {
+	c.mu.Lock()
 	if c.closed {
+		c.mu.Unlock()
 		return nil
 	}
-
 	c.closed = true
+	c.reason = reason
+	close(c.done)
+	c.mu.Unlock()
+
+	select {
+	case <-c.written:
+	case <-time.After(drainTimeout):
+	}
 	return c.Conn.Close()
 }
 
+func (c *Connection) Done() <-chan struct{} {
+	return c.done
+}
+
+func (c *Connection) CloseReason() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.reason == "" {
+		return "connection closed"
+	}
+	return c.reason
+}
+
 func (c *Connection) IsClosed() bool {
 	c.mu.Lock()
 	defer c.mu.Unlock()
@@ -0,0 +1,108 @@
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+
+package tcp
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/urustack/uruflow/internal/tcp/protocol"
+)
+
+func TestConnectionStats(t *testing.T) {
+	local, remote := net.Pipe()
+	defer remote.Close()
+	conn := NewConnection("conn-1", local)
+	defer conn.Close()
+
+	agent := &countingConn{Conn: remote}
+	agentReader := protocol.NewReader(agent)
+	agentWriter := protocol.NewWriter(agent)
+
+	for _, typ := range []protocol.MessageType{protocol.TypePing, protocol.TypePing, protocol.TypeCommand} {
+		msg, err := protocol.NewMessage(typ, map[string]string{"id": "cmd-1"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := conn.Send(msg); err != nil {
+			t.Fatalf("Send: %v", err)
+		}
+		if _, err := agentReader.ReadWithTimeout(time.Second); err != nil {
+			t.Fatalf("agent read: %v", err)
+		}
+	}
+
+	for _, typ := range []protocol.MessageType{protocol.TypePong, protocol.TypeMetrics} {
+		msg, _ := protocol.NewMessage(typ, protocol.MetricsPayload{})
+		done := make(chan error, 1)
+		go func() { done <- agentWriter.Write(msg) }()
+		if _, err := conn.ReceiveWithTimeout(time.Second); err != nil {
+			t.Fatalf("Receive: %v", err)
+		}
+		if err := <-done; err != nil {
+			t.Fatalf("agent write: %v", err)
+		}
+	}
+
+	stats := conn.Stats()
+	for deadline := time.Now().Add(time.Second); stats.MessagesOut < 3 && time.Now().Before(deadline); stats = conn.Stats() {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if stats.MessagesOut != 3 || stats.OutByType["PING"] != 2 || stats.OutByType["COMMAND"] != 1 {
+		t.Fatalf("out = %d %v, want 2 PING and 1 COMMAND", stats.MessagesOut, stats.OutByType)
+	}
+	if stats.MessagesIn != 2 || stats.InByType["PONG"] != 1 || stats.InByType["METRICS"] != 1 {
+		t.Fatalf("in = %d %v, want 1 PONG and 1 METRICS", stats.MessagesIn, stats.InByType)
+	}
+	if stats.BytesOut != agent.in.Load() || stats.BytesIn != agent.out.Load() {
+		t.Fatalf("bytes out/in = %d/%d, agent read/wrote %d/%d", stats.BytesOut, stats.BytesIn, agent.in.Load(), agent.out.Load())
+	}
+	if stats.LastIn.IsZero() || stats.LastOut.IsZero() || stats.LastActivity().IsZero() {
+		t.Fatalf("activity times not set: %+v", stats)
+	}
+	if stats.WriteErrors != 0 || stats.QueueDepth != 0 || stats.Dropped != 0 {
+		t.Fatalf("write errors %d, queue %d, dropped %d; want none", stats.WriteErrors, stats.QueueDepth, stats.Dropped)
+	}
+
+	stats.OutByType["PING"] = 99
+	if conn.Stats().OutByType["PING"] != 2 {
+		t.Fatal("Stats returned the connection's own map")
+	}
+}
+
+func TestConnectionStatsCountsWriteErrors(t *testing.T) {
+	local, remote := net.Pipe()
+	conn := NewConnection("conn-1", local)
+	defer conn.Close()
+	remote.Close()
+
+	msg, _ := protocol.NewMessage(protocol.TypeCommand, map[string]string{"id": "cmd-1"})
+	if err := conn.Send(msg); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	select {
+	case <-conn.Done():
+	case <-time.After(time.Second):
+		t.Fatal("connection stayed open after a failed write")
+	}
+	if stats := conn.Stats(); stats.WriteErrors != 1 || stats.MessagesOut != 0 {
+		t.Fatalf("write errors %d, messages out %d; want 1 and 0", stats.WriteErrors, stats.MessagesOut)
+	}
+}
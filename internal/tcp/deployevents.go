@@ -0,0 +1,158 @@
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+
+package tcp
+
+import (
+	"math"
+	"time"
+
+	"github.com/urustack/uruflow/internal/models"
+	"github.com/urustack/uruflow/pkg/logger"
+)
+
+const watchedDeployments = 50
+
+const (
+	DeploymentAck    = "ack"
+	DeploymentStart  = "start"
+	DeploymentStep   = "step"
+	DeploymentLog    = "log"
+	DeploymentDone   = "done"
+	DeploymentStatus = "status"
+)
+
+type DeploymentEvent struct {
+	Kind         string
+	DeploymentID string
+	Repository   string
+	Status       models.DeployStatus
+	Line         string
+}
+
+func (s *Server) SetDeploymentHandler(handler func(event DeploymentEvent)) {
+	s.onDeployment = handler
+}
+
+func (s *Server) deploymentEvent(event DeploymentEvent) {
+	if s.onDeployment != nil {
+		s.onDeployment(event)
+	}
+}
+
+func (s *Server) DeploymentChanged(d *models.Deployment) {
+	kind := DeploymentStatus
+	if d.EndedAt != nil {
+		kind = DeploymentDone
+	}
+	s.deploymentEvent(DeploymentEvent{Kind: kind, DeploymentID: d.ID, Repository: d.Repository, Status: d.Status, Line: d.Output})
+}
+
+func (s *Server) logsFlushed(batch []models.DeploymentLog) {
+	if s.onDeployment == nil {
+		return
+	}
+	last := make(map[string]string)
+	var order []string
+	for _, l := range batch {
+		if _, ok := last[l.DeploymentID]; !ok {
+			order = append(order, l.DeploymentID)
+		}
+		last[l.DeploymentID] = l.Line
+	}
+	for _, id := range order {
+		s.onDeployment(DeploymentEvent{Kind: DeploymentLog, DeploymentID: id, Line: last[id]})
+	}
+}
+
+type watchedDeployment struct {
+	status  models.DeployStatus
+	detail  string
+	lastLog int64
+}
+
+func (s *Server) WatchDeployments(interval time.Duration) func() {
+	stop := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		seen := make(map[string]*watchedDeployment)
+		s.pollDeployments(seen, false)
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				s.pollDeployments(seen, true)
+			}
+		}
+	}()
+	return func() { close(stop) }
+}
+
+func (s *Server) pollDeployments(seen map[string]*watchedDeployment, emit bool) {
+	recent, err := s.store.GetRecentDeployments(watchedDeployments)
+	if err != nil {
+		logger.Debug("[TCP] failed to poll deployments: %v", err)
+		return
+	}
+
+	current := make(map[string]*watchedDeployment, len(recent))
+	for i := range recent {
+		d := &recent[i]
+		w, known := seen[d.ID]
+		if !known {
+			w = &watchedDeployment{lastLog: s.lastLogID(d.ID)}
+		}
+		current[d.ID] = w
+
+		if d.Status == models.DeployRunning || d.Status == models.DeployPending {
+			logs, err := s.store.GetDeploymentLogsPage(d.ID, w.lastLog, 500)
+			if err == nil && len(logs) > 0 {
+				w.lastLog = logs[len(logs)-1].ID
+				if emit {
+					s.deploymentEvent(DeploymentEvent{Kind: DeploymentLog, DeploymentID: d.ID, Line: logs[len(logs)-1].Line})
+				}
+			}
+		}
+
+		if known && w.status == d.Status && w.detail == d.StatusDetail {
+			continue
+		}
+		w.status, w.detail = d.Status, d.StatusDetail
+		if emit {
+			s.DeploymentChanged(d)
+		}
+	}
+
+	for id := range seen {
+		delete(seen, id)
+	}
+	for id, w := range current {
+		seen[id] = w
+	}
+}
+
+func (s *Server) lastLogID(deploymentID string) int64 {
+	logs, err := s.store.GetDeploymentLogsBefore(deploymentID, math.MaxInt64, 1)
+	if err != nil || len(logs) == 0 {
+		return 0
+	}
+	return logs[len(logs)-1].ID
+}
@@ -0,0 +1,169 @@
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+
+package tcp
+
+import (
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/urustack/uruflow/internal/logic"
+	"github.com/urustack/uruflow/internal/models"
+	"github.com/urustack/uruflow/pkg/logger"
+)
+
+const (
+	AuthFloodThreshold = 10
+	AuthFloodWindow    = 5 * time.Minute
+)
+
+const (
+	DisconnectPingTimeout  = "ping timeout"
+	DisconnectExplicit     = "agent disconnected"
+	DisconnectSuperseded   = "superseded by new connection"
+	DisconnectShutdown     = "server shutdown"
+	DisconnectTokenRetired = "token retired"
+)
+
+type authFailures struct {
+	times   []time.Time
+	alerted time.Time
+}
+
+func (s *Server) recordEvent(agentID, eventType, detail string) {
+	err := s.store.RecordAgentEvent(&models.AgentEvent{AgentID: agentID, Type: eventType, Detail: detail})
+	if err != nil {
+		logger.Error("[TCP] failed to record %s event for agent %s: %v", eventType, agentID, err)
+	}
+}
+
+func (s *Server) authFailed(conn *Connection, agentID, token, reason string) {
+	detail := fmt.Sprintf("%s from %s", reason, conn.RemoteAddr())
+	if token != "" {
+		detail += ", token " + tokenPrefix(token)
+	}
+	s.recordEvent(agentID, models.AgentEventAuthFailed, detail)
+
+	host, _, err := net.SplitHostPort(conn.RemoteAddr())
+	if err != nil {
+		host = conn.RemoteAddr()
+	}
+	if n := s.countAuthFailure(host, time.Now()); n > 0 {
+		s.authFlood(host, agentID, n)
+	}
+}
+
+func (s *Server) countAuthFailure(host string, now time.Time) int {
+	s.authMu.Lock()
+	defer s.authMu.Unlock()
+
+	for h, f := range s.authFails {
+		if len(f.times) > 0 && now.Sub(f.times[len(f.times)-1]) > AuthFloodWindow {
+			delete(s.authFails, h)
+		}
+	}
+
+	f := s.authFails[host]
+	if f == nil {
+		f = &authFailures{}
+		s.authFails[host] = f
+	}
+	kept := f.times[:0]
+	for _, t := range f.times {
+		if now.Sub(t) <= AuthFloodWindow {
+			kept = append(kept, t)
+		}
+	}
+	f.times = append(kept, now)
+
+	if len(f.times) < AuthFloodThreshold || now.Sub(f.alerted) <= AuthFloodWindow {
+		return 0
+	}
+	f.alerted = now
+	return len(f.times)
+}
+
+func (s *Server) authFlood(host, agentID string, failures int) {
+	agentName := "unknown"
+	if agentID == "" {
+		if agents, err := s.store.GetAllAgents(); err == nil {
+			for _, a := range agents {
+				if a.Host == host {
+					agentID, agentName = a.ID, a.Name
+					break
+				}
+			}
+		}
+	} else if agent := s.cfg.GetAgent(agentID); agent != nil {
+		agentName = agent.Name
+	}
+
+	alert := logic.CheckAuthFlood(agentID, agentName, host, failures, AuthFloodWindow)
+	logger.Warn("[TCP] %s", alert.Message)
+	s.raiseAlert(alert)
+}
+
+func (s *Server) tokenHolder(agentID, machineID string) string {
+	s.mu.RLock()
+	live, ok := s.connections[agentID]
+	s.mu.RUnlock()
+	if !ok || live.MachineID == "" || live.MachineID == machineID {
+		return ""
+	}
+	if live.Hostname != "" {
+		return live.Hostname
+	}
+	return live.RemoteAddr()
+}
+
+func (s *Server) duplicateToken(conn *Connection, agentID, agentName, holder, hostname string) {
+	intruder := fmt.Sprintf("%s (%s)", hostname, conn.RemoteAddr())
+	s.recordEvent(agentID, models.AgentEventAuthFailed,
+		fmt.Sprintf("token already in use by host %s, rejected %s", holder, intruder))
+
+	alert := logic.CheckDuplicateToken(agentID, agentName, holder, intruder)
+	logger.Warn("[TCP] %s", alert.Message)
+	active, _ := s.store.GetActiveAlerts()
+	for _, a := range active {
+		if a.AgentID == agentID && a.Type == alert.Type {
+			return
+		}
+	}
+	s.raiseAlert(alert)
+}
+
+func (s *Server) retiredToken(conn *Connection, agentID, agentName, hostname string) {
+	host := conn.RemoteAddr()
+	if hostname != "" {
+		host = fmt.Sprintf("%s (%s)", hostname, host)
+	}
+	alert := logic.CheckRetiredToken(agentID, agentName, host)
+	logger.Warn("[TCP] %s", alert.Message)
+	active, _ := s.store.GetActiveAlerts()
+	for _, a := range active {
+		if a.AgentID == agentID && a.Type == alert.Type {
+			return
+		}
+	}
+	s.raiseAlert(alert)
+}
+
+func tokenPrefix(token string) string {
+	return token[:min(6, len(token)/2)] + "…"
+}
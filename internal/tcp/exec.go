@@ -0,0 +1,211 @@
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+
+package tcp
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/urustack/uruflow/internal/models"
+	"github.com/urustack/uruflow/pkg/helper"
+	"github.com/urustack/uruflow/pkg/logger"
+)
+
+const (
+	ExecTimeout     = 2 * time.Minute
+	ExecOutputLimit = 64 * 1024
+)
+
+type ExecResult struct {
+	Status   string
+	ExitCode int
+	Output   []string
+}
+
+type execRun struct {
+	cmd       *models.Command
+	lines     []string
+	size      int
+	truncated bool
+	result    chan ExecResult
+}
+
+func (r *execRun) add(line string) {
+	if r.truncated {
+		return
+	}
+	if r.size+len(line) > ExecOutputLimit {
+		r.truncated = true
+		r.lines = append(r.lines, fmt.Sprintf("… output truncated at %d KB", ExecOutputLimit/1024))
+		return
+	}
+	r.size += len(line) + 1
+	r.lines = append(r.lines, line)
+}
+
+func (s *Server) ExecCommands(agentID string) []string {
+	s.mu.RLock()
+	conn, exists := s.connections[agentID]
+	s.mu.RUnlock()
+	if !exists {
+		return nil
+	}
+
+	var names []string
+	for _, name := range conn.Exec {
+		if _, ok := s.cfg.Exec[name]; ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (s *Server) ExecParams(name string) []string {
+	return models.ExecParams(s.cfg.Exec[name])
+}
+
+func (s *Server) SendExec(agentID, name string, params map[string]string) (string, <-chan ExecResult, error) {
+	argv, ok := s.cfg.Exec[name]
+	if !ok {
+		return "", nil, fmt.Errorf("exec command %q is not in the server whitelist", name)
+	}
+	if _, err := models.ExpandExec(argv, params); err != nil {
+		return "", nil, fmt.Errorf("exec %s: %w", name, err)
+	}
+
+	payload := map[string]interface{}{"name": name}
+	if len(params) > 0 {
+		values := make(map[string]interface{}, len(params))
+		for k, v := range params {
+			values[k] = v
+		}
+		payload["params"] = values
+	}
+	cmd := &models.Command{
+		ID:        helper.GenerateID(),
+		Type:      "exec",
+		AgentID:   agentID,
+		Payload:   payload,
+		Status:    models.DeployPending,
+		CreatedAt: time.Now(),
+	}
+	if err := s.store.CreateCommand(cmd); err != nil {
+		return "", nil, fmt.Errorf("create command record: %w", err)
+	}
+
+	run := &execRun{cmd: cmd, result: make(chan ExecResult, 1)}
+	s.execMu.Lock()
+	s.execs[cmd.ID] = run
+	s.execMu.Unlock()
+
+	if err := s.SendCommand(agentID, cmd); err != nil {
+		s.finishExec(cmd.ID, "failed", 1, err.Error())
+		return "", nil, err
+	}
+
+	logger.Info("[TCP] sent exec %s (%s) to agent %s", name, cmd.ID, agentID)
+	return cmd.ID, run.result, nil
+}
+
+func (s *Server) ExecOutput(id string) []string {
+	s.execMu.Lock()
+	defer s.execMu.Unlock()
+	run, ok := s.execs[id]
+	if !ok {
+		return nil
+	}
+	return append([]string(nil), run.lines...)
+}
+
+func (s *Server) execLog(id, line string) bool {
+	s.execMu.Lock()
+	defer s.execMu.Unlock()
+	run, ok := s.execs[id]
+	if ok {
+		run.add(line)
+	}
+	return ok
+}
+
+func (s *Server) execStarted(id string) bool {
+	s.execMu.Lock()
+	run, ok := s.execs[id]
+	var cmd models.Command
+	if ok {
+		now := time.Now()
+		run.cmd.Status = models.DeployRunning
+		run.cmd.StartedAt = &now
+		cmd = *run.cmd
+	}
+	s.execMu.Unlock()
+
+	if ok {
+		if err := s.store.UpdateCommand(&cmd); err != nil {
+			logger.Warn("[TCP] failed to update command %s: %v", id, err)
+		}
+	}
+	return ok
+}
+
+func (s *Server) finishExec(id, status string, exitCode int, output string) bool {
+	s.execMu.Lock()
+	run, ok := s.execs[id]
+	if !ok {
+		s.execMu.Unlock()
+		return false
+	}
+	delete(s.execs, id)
+	s.execMu.Unlock()
+
+	if output != "" {
+		run.add(output)
+	}
+
+	now := time.Now()
+	run.cmd.Status = models.DeploySuccess
+	if status != "success" {
+		run.cmd.Status = models.DeployFailed
+	}
+	run.cmd.Output = strings.Join(run.lines, "\n")
+	run.cmd.EndedAt = &now
+	if err := s.store.UpdateCommand(run.cmd); err != nil {
+		logger.Warn("[TCP] failed to store result of command %s: %v", id, err)
+	}
+
+	run.result <- ExecResult{Status: status, ExitCode: exitCode, Output: run.lines}
+	return true
+}
+
+func (s *Server) abandonExecs(agentID string) {
+	s.execMu.Lock()
+	var ids []string
+	for id, run := range s.execs {
+		if run.cmd.AgentID == agentID {
+			ids = append(ids, id)
+		}
+	}
+	s.execMu.Unlock()
+
+	for _, id := range ids {
+		s.finishExec(id, "failed", 1, "agent disconnected before the command finished")
+	}
+}
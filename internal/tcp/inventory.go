@@ -0,0 +1,57 @@
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+
+package tcp
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/urustack/uruflow/internal/tcp/protocol"
+	"github.com/urustack/uruflow/pkg/logger"
+)
+
+const InventoryInterval = 5 * time.Second
+
+var ErrInventoryThrottled = errors.New("inventory was refreshed moments ago")
+
+func (s *Server) RequestInventory(agentID string) error {
+	s.mu.RLock()
+	conn, exists := s.connections[agentID]
+	s.mu.RUnlock()
+	if !exists {
+		return s.notConnected()
+	}
+
+	now := time.Now()
+	s.inventoryMu.Lock()
+	if last, ok := s.inventory[agentID]; ok && now.Sub(last) < InventoryInterval {
+		s.inventoryMu.Unlock()
+		wait := (InventoryInterval - now.Sub(last)).Round(time.Second)
+		return fmt.Errorf("%w, try again in %s", ErrInventoryThrottled, max(wait, time.Second))
+	}
+	s.inventory[agentID] = now
+	s.inventoryMu.Unlock()
+
+	if err := conn.Send(&protocol.Message{Type: protocol.TypeInventoryRequest}); err != nil {
+		return fmt.Errorf("request inventory: %w", err)
+	}
+	logger.Debug("[TCP] requested inventory from agent %s", conn.AgentName)
+	return nil
+}
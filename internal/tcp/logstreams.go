@@ -0,0 +1,269 @@
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+package tcp
+
+import (
+	"slices"
+	"time"
+
+	"github.com/urustack/uruflow/internal/tcp/protocol"
+	"github.com/urustack/uruflow/pkg/logger"
+)
+
+type logStreamKey struct {
+	agentID     string
+	containerID string
+}
+
+const (
+	resumeTail    = 20
+	boundaryLines = 20
+)
+
+type logStream struct {
+	refs         int
+	follow       bool
+	pendingSince time.Time
+	interrupted  bool
+	resuming     bool
+	recent       []string
+}
+
+func (s *Server) StreamContainerLogs(agentID, containerID string, tail int, follow bool) error {
+	s.mu.RLock()
+	conn, exists := s.connections[agentID]
+	s.mu.RUnlock()
+
+	if !exists {
+		return s.notConnected()
+	}
+
+	key := logStreamKey{agentID, containerID}
+	s.logMu.Lock()
+	st, streaming := s.logStreams[key]
+	if !streaming {
+		st = &logStream{follow: follow}
+		s.logStreams[key] = st
+	}
+	st.refs++
+	s.logMu.Unlock()
+
+	if streaming {
+		return nil
+	}
+
+	msg, _ := protocol.NewMessage(protocol.TypeContainerLogsRequest, protocol.ContainerLogsRequestPayload{
+		ContainerID: containerID,
+		Tail:        tail,
+		Follow:      follow,
+	})
+	if err := conn.Send(msg); err != nil {
+		s.dropLogStream(key)
+		return err
+	}
+	return nil
+}
+
+func (s *Server) StopContainerLogs(agentID, containerID string) error {
+	key := logStreamKey{agentID, containerID}
+
+	s.logMu.Lock()
+	st, ok := s.logStreams[key]
+	if !ok {
+		s.logMu.Unlock()
+		return nil
+	}
+	st.refs--
+	last := st.refs <= 0
+	if last {
+		delete(s.logStreams, key)
+	}
+	s.logMu.Unlock()
+
+	if !last {
+		return nil
+	}
+	return s.sendLogsStop(key)
+}
+
+func (s *Server) sendLogsStop(key logStreamKey) error {
+	s.mu.RLock()
+	conn, exists := s.connections[key.agentID]
+	s.mu.RUnlock()
+
+	if !exists {
+		return nil
+	}
+
+	msg, _ := protocol.NewMessage(protocol.TypeContainerLogsStop, protocol.ContainerLogsStopPayload{
+		ContainerID: key.containerID,
+	})
+	return conn.Send(msg)
+}
+
+func (s *Server) dropLogStream(key logStreamKey) {
+	s.logMu.Lock()
+	delete(s.logStreams, key)
+	s.logMu.Unlock()
+}
+
+func (s *Server) interruptAgentLogStreams(agentID string) {
+	var interrupted []logStreamKey
+	s.logMu.Lock()
+	for key, st := range s.logStreams {
+		if key.agentID != agentID || st.interrupted {
+			continue
+		}
+		if !st.follow {
+			delete(s.logStreams, key)
+			continue
+		}
+		st.interrupted = true
+		st.resuming = false
+		st.pendingSince = time.Time{}
+		interrupted = append(interrupted, key)
+	}
+	s.logMu.Unlock()
+
+	for _, key := range interrupted {
+		logger.Info("[TCP] log stream for %s on agent %s interrupted", key.containerID, key.agentID)
+		s.notifyLogStream(key, protocol.StreamInterrupted)
+	}
+}
+
+func (s *Server) resumeAgentLogStreams(agentID string, conn *Connection) {
+	var resumed []logStreamKey
+	s.logMu.Lock()
+	for key, st := range s.logStreams {
+		if key.agentID == agentID && st.interrupted {
+			st.interrupted = false
+			st.resuming = len(st.recent) > 0
+			resumed = append(resumed, key)
+		}
+	}
+	s.logMu.Unlock()
+
+	for _, key := range resumed {
+		msg, _ := protocol.NewMessage(protocol.TypeContainerLogsRequest, protocol.ContainerLogsRequestPayload{
+			ContainerID: key.containerID,
+			Tail:        resumeTail,
+			Follow:      true,
+		})
+		if err := conn.Send(msg); err != nil {
+			logger.Warn("[TCP] failed to resume log stream for %s on agent %s: %v", key.containerID, conn.AgentName, err)
+			continue
+		}
+		logger.Info("[TCP] resumed log stream for %s on agent %s", key.containerID, conn.AgentName)
+		s.notifyLogStream(key, protocol.StreamResumed)
+	}
+}
+
+func (s *Server) notifyLogStream(key logStreamKey, state string) {
+	if s.onContainerLog == nil {
+		return
+	}
+	s.onContainerLog(key.agentID, protocol.ContainerLogsDataPayload{
+		ContainerID: key.containerID,
+		Stream:      state,
+		Timestamp:   time.Now().Unix(),
+	})
+}
+
+func (s *Server) acceptLogLine(key logStreamKey, data protocol.ContainerLogsDataPayload) bool {
+	s.logMu.Lock()
+	defer s.logMu.Unlock()
+
+	st, ok := s.logStreams[key]
+	if !ok {
+		return true
+	}
+	line := data.Stream + "\x00" + data.Line
+	if st.resuming {
+		if slices.Contains(st.recent, line) {
+			return false
+		}
+		st.resuming = false
+	}
+	st.recent = append(st.recent, line)
+	if len(st.recent) > boundaryLines {
+		st.recent = slices.Delete(st.recent, 0, len(st.recent)-boundaryLines)
+	}
+	return true
+}
+
+func (s *Server) deliverContainerLog(agentID string, data protocol.ContainerLogsDataPayload) {
+	key := logStreamKey{agentID, data.ContainerID}
+
+	if data.Error != "" {
+		logger.Warn("[TCP] agent refused log stream for %s: %s", data.ContainerID, data.Error)
+		s.dropLogStream(key)
+	} else if !s.acceptLogLine(key, data) {
+		return
+	}
+
+	delivered := false
+	if s.onContainerLog != nil {
+		delivered = s.onContainerLog(agentID, data)
+	}
+
+	s.logMu.Lock()
+	if st, ok := s.logStreams[key]; ok {
+		if delivered {
+			st.pendingSince = time.Time{}
+		} else if st.pendingSince.IsZero() {
+			st.pendingSince = time.Now()
+		}
+	}
+	s.logMu.Unlock()
+}
+
+func (s *Server) expireLogStreams() {
+	idle := time.Duration(s.cfg.Server.LogStreamIdleSec) * time.Second
+	if idle <= 0 {
+		return
+	}
+
+	var expired []logStreamKey
+	s.logMu.Lock()
+	for key, st := range s.logStreams {
+		if !st.pendingSince.IsZero() && time.Since(st.pendingSince) >= idle {
+			expired = append(expired, key)
+			delete(s.logStreams, key)
+		}
+	}
+	s.logMu.Unlock()
+
+	for _, key := range expired {
+		logger.Info("[TCP] log stream for %s on agent %s has no reader, stopping", key.containerID, key.agentID)
+		s.sendLogsStop(key)
+	}
+}
+
+func (s *Server) stopAllLogStreams() {
+	s.logMu.Lock()
+	keys := make([]logStreamKey, 0, len(s.logStreams))
+	for key := range s.logStreams {
+		keys = append(keys, key)
+	}
+	s.logStreams = make(map[logStreamKey]*logStream)
+	s.logMu.Unlock()
+
+	for _, key := range keys {
+		s.sendLogsStop(key)
+	}
+}
@@ -0,0 +1,169 @@
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+
+package tcp
+
+import (
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/urustack/uruflow/internal/models"
+	"github.com/urustack/uruflow/internal/tcp/protocol"
+)
+
+type logRecorder struct {
+	mu     sync.Mutex
+	lines  []string
+	states []string
+}
+
+func (r *logRecorder) handle(agentID string, data protocol.ContainerLogsDataPayload) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if data.Line == "" {
+		r.states = append(r.states, data.ContainerID+" "+data.Stream)
+	} else {
+		r.lines = append(r.lines, data.Line)
+	}
+	return true
+}
+
+func (r *logRecorder) take() (lines, states []string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	lines, states = r.lines, r.states
+	r.lines, r.states = nil, nil
+	return lines, states
+}
+
+func expectMessage(t *testing.T, received <-chan protocol.MessageType, want protocol.MessageType) {
+	t.Helper()
+	select {
+	case typ := <-received:
+		if typ != want {
+			t.Fatalf("agent received %s, want %s", typ, want)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("agent did not receive %s", want)
+	}
+}
+
+func sendLines(s *Server, agentID, containerID string, lines ...string) {
+	for _, line := range lines {
+		s.deliverContainerLog(agentID, protocol.ContainerLogsDataPayload{ContainerID: containerID, Line: line, Stream: "stdout"})
+	}
+}
+
+func TestLogStreamResumesAfterReconnect(t *testing.T) {
+	s, store := newTestServer(t)
+	if err := store.CreateAgent(&models.Agent{ID: "agent-1", Name: "web", Status: models.AgentOnline}); err != nil {
+		t.Fatalf("create agent: %v", err)
+	}
+	rec := &logRecorder{}
+	s.SetContainerLogHandler(rec.handle)
+
+	first, firstReceived := recordingConnection(t, "agent-1", "web")
+	s.addConnection("agent-1", first)
+	if err := s.StreamContainerLogs("agent-1", "api", 100, true); err != nil {
+		t.Fatalf("stream api: %v", err)
+	}
+	expectMessage(t, firstReceived, protocol.TypeContainerLogsRequest)
+	if err := s.StreamContainerLogs("agent-1", "migrate", 100, false); err != nil {
+		t.Fatalf("stream migrate: %v", err)
+	}
+	expectMessage(t, firstReceived, protocol.TypeContainerLogsRequest)
+	sendLines(s, "agent-1", "api", "one", "two", "three")
+	rec.take()
+
+	s.removeConnection(first)
+	if _, states := rec.take(); !slices.Equal(states, []string{"api " + protocol.StreamInterrupted}) {
+		t.Fatalf("states after disconnect = %v, want the followed stream interrupted", states)
+	}
+	if _, ok := s.logStreams[logStreamKey{"agent-1", "migrate"}]; ok {
+		t.Fatal("a stream without follow survived the disconnect")
+	}
+	s.interruptAgentLogStreams("agent-1")
+	if _, states := rec.take(); len(states) != 0 {
+		t.Fatalf("interrupted stream was reported again: %v", states)
+	}
+
+	second, secondReceived := recordingConnection(t, "agent-1", "web")
+	s.addConnection("agent-1", second)
+	expectMessage(t, secondReceived, protocol.TypeContainerLogsRequest)
+	if _, states := rec.take(); !slices.Equal(states, []string{"api " + protocol.StreamResumed}) {
+		t.Fatalf("states after reconnect = %v, want the stream resumed", states)
+	}
+
+	sendLines(s, "agent-1", "api", "two", "three", "four", "two")
+	if lines, _ := rec.take(); !slices.Equal(lines, []string{"four", "two"}) {
+		t.Fatalf("lines after resume = %v, want the replayed boundary dropped", lines)
+	}
+}
+
+func TestLogStreamResumesOnSupersedingSession(t *testing.T) {
+	s, _ := newTestServer(t)
+	rec := &logRecorder{}
+	s.SetContainerLogHandler(rec.handle)
+
+	first, firstReceived := recordingConnection(t, "agent-1", "web")
+	s.addConnection("agent-1", first)
+	if err := s.StreamContainerLogs("agent-1", "api", 100, true); err != nil {
+		t.Fatalf("stream api: %v", err)
+	}
+	expectMessage(t, firstReceived, protocol.TypeContainerLogsRequest)
+
+	second, secondReceived := recordingConnection(t, "agent-1", "web")
+	s.addConnection("agent-1", second)
+	expectMessage(t, secondReceived, protocol.TypeContainerLogsRequest)
+	want := []string{"api " + protocol.StreamInterrupted, "api " + protocol.StreamResumed}
+	if _, states := rec.take(); !slices.Equal(states, want) {
+		t.Fatalf("states = %v, want %v", states, want)
+	}
+	if first.CloseReason() != DisconnectSuperseded {
+		t.Fatalf("old session close reason = %q", first.CloseReason())
+	}
+}
+
+func TestLogStreamStopsWithLastSubscriber(t *testing.T) {
+	s, _ := newTestServer(t)
+	conn, received := recordingConnection(t, "agent-1", "web")
+	s.connections["agent-1"] = conn
+
+	for range 2 {
+		if err := s.StreamContainerLogs("agent-1", "api", 100, true); err != nil {
+			t.Fatalf("stream api: %v", err)
+		}
+	}
+	expectMessage(t, received, protocol.TypeContainerLogsRequest)
+
+	if err := s.StopContainerLogs("agent-1", "api"); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case typ := <-received:
+		t.Fatalf("agent received %s while a subscriber remained", typ)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	if err := s.StopContainerLogs("agent-1", "api"); err != nil {
+		t.Fatal(err)
+	}
+	expectMessage(t, received, protocol.TypeContainerLogsStop)
+}
@@ -0,0 +1,141 @@
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+
+package tcp
+
+import (
+	"sync"
+	"time"
+
+	"github.com/urustack/uruflow/internal/models"
+	"github.com/urustack/uruflow/internal/storage"
+	"github.com/urustack/uruflow/pkg/logger"
+)
+
+const (
+	LogFlushInterval = 250 * time.Millisecond
+	LogBatchSize     = 100
+)
+
+type logWriter struct {
+	store   storage.Store
+	flushed func(batch []models.DeploymentLog)
+	entries chan models.DeploymentLog
+	flushes chan chan struct{}
+	stop    chan struct{}
+	done    chan struct{}
+	mu      sync.RWMutex
+	closed  bool
+}
+
+func newLogWriter(store storage.Store, flushed func(batch []models.DeploymentLog)) *logWriter {
+	w := &logWriter{
+		store:   store,
+		flushed: flushed,
+		entries: make(chan models.DeploymentLog, 4*LogBatchSize),
+		flushes: make(chan chan struct{}),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	go w.run()
+	return w
+}
+
+func (w *logWriter) Add(log models.DeploymentLog) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	if !w.closed {
+		w.entries <- log
+		return
+	}
+	if err := w.store.AddDeploymentLog(&log); err != nil {
+		logger.Error("[TCP] failed to store log line for %s: %v", log.DeploymentID, err)
+	}
+}
+
+func (w *logWriter) Flush() {
+	ack := make(chan struct{})
+	select {
+	case w.flushes <- ack:
+		<-ack
+	case <-w.done:
+	}
+}
+
+func (w *logWriter) Close() {
+	w.mu.Lock()
+	if !w.closed {
+		w.closed = true
+		close(w.stop)
+	}
+	w.mu.Unlock()
+	<-w.done
+}
+
+func (w *logWriter) run() {
+	defer close(w.done)
+
+	ticker := time.NewTicker(LogFlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]models.DeploymentLog, 0, LogBatchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		if err := w.store.AddDeploymentLogsBatch(batch); err != nil {
+			logger.Error("[TCP] failed to store %d log line(s): %v", len(batch), err)
+		} else if w.flushed != nil {
+			w.flushed(batch)
+		}
+		batch = batch[:0]
+	}
+	drain := func() {
+		for {
+			select {
+			case log := <-w.entries:
+				batch = append(batch, log)
+				if len(batch) >= LogBatchSize {
+					flush()
+				}
+			default:
+				flush()
+				return
+			}
+		}
+	}
+
+	for {
+		select {
+		case log := <-w.entries:
+			batch = append(batch, log)
+			if len(batch) >= LogBatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		case ack := <-w.flushes:
+			drain()
+			close(ack)
+		case <-w.stop:
+			drain()
+			return
+		}
+	}
+}
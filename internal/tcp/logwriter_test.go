@@ -0,0 +1,96 @@
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+
+package tcp
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/urustack/uruflow/internal/models"
+	"github.com/urustack/uruflow/internal/storage"
+	"github.com/urustack/uruflow/internal/storage/sqlite"
+)
+
+const benchDeployLines = 10000
+
+func newBenchLogStore(b *testing.B) storage.Store {
+	b.Helper()
+	store, err := sqlite.New(b.TempDir())
+	if err != nil {
+		b.Fatalf("open store: %v", err)
+	}
+	b.Cleanup(func() { store.Close() })
+	if err := store.CreateAgent(&models.Agent{ID: "agent-1", Name: "web", Status: models.AgentOnline}); err != nil {
+		b.Fatalf("create agent: %v", err)
+	}
+	if err := store.CreateDeployment(&models.Deployment{
+		ID:         "deploy-1",
+		Repository: "api",
+		Branch:     "main",
+		Commit:     "HEAD",
+		AgentID:    "agent-1",
+		AgentName:  "web",
+		Status:     models.DeployRunning,
+		Trigger:    "manual",
+		StartedAt:  time.Now(),
+	}); err != nil {
+		b.Fatalf("create deployment: %v", err)
+	}
+	return store
+}
+
+func benchDeployLog(i int) models.DeploymentLog {
+	return models.DeploymentLog{
+		DeploymentID: "deploy-1",
+		Timestamp:    time.Now(),
+		Stream:       "stdout",
+		Line:         fmt.Sprintf("#%d [build 3/7] RUN npm ci --omit=dev", i),
+	}
+}
+
+func BenchmarkDeployLogsPerRow(b *testing.B) {
+	store := newBenchLogStore(b)
+
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		for i := 0; i < benchDeployLines; i++ {
+			log := benchDeployLog(i)
+			if err := store.AddDeploymentLog(&log); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+	b.ReportMetric(float64(benchDeployLines), "lines/op")
+}
+
+func BenchmarkDeployLogsBatched(b *testing.B) {
+	store := newBenchLogStore(b)
+	w := newLogWriter(store, nil)
+	b.Cleanup(w.Close)
+
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		for i := 0; i < benchDeployLines; i++ {
+			w.Add(benchDeployLog(i))
+		}
+		w.Flush()
+	}
+	b.ReportMetric(float64(benchDeployLines), "lines/op")
+}
@@ -0,0 +1,80 @@
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+
+package tcp
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/urustack/uruflow/internal/models"
+	"github.com/urustack/uruflow/internal/tcp/protocol"
+	"github.com/urustack/uruflow/pkg/helper"
+	"github.com/urustack/uruflow/pkg/logger"
+)
+
+func (s *Server) SetCheckoutsHandler(handler func(agentID string, checkouts []models.Checkout)) {
+	s.onCheckouts = handler
+}
+
+func (s *Server) SendPrefetch(agentID string, payload map[string]interface{}) (string, error) {
+	cmd := &models.Command{
+		ID:        helper.GenerateID(),
+		Type:      "prefetch",
+		AgentID:   agentID,
+		Payload:   payload,
+		Status:    models.DeployPending,
+		CreatedAt: time.Now(),
+	}
+	if err := s.store.CreateCommand(cmd); err != nil {
+		return "", fmt.Errorf("create command record: %w", err)
+	}
+
+	s.execMu.Lock()
+	s.execs[cmd.ID] = &execRun{cmd: cmd, result: make(chan ExecResult, 1)}
+	s.execMu.Unlock()
+
+	if err := s.SendCommand(agentID, cmd); err != nil {
+		s.finishExec(cmd.ID, "failed", 1, err.Error())
+		return "", err
+	}
+	return cmd.ID, nil
+}
+
+func (s *Server) syncCheckouts(conn *Connection, reported []protocol.Checkout) {
+	checkouts := make([]models.Checkout, len(reported))
+	for i, c := range reported {
+		checkouts[i] = models.Checkout{
+			AgentID:    conn.AgentID,
+			Repository: c.Repository,
+			URL:        c.URL,
+			Branch:     c.Branch,
+			Commit:     c.Commit,
+			FetchedAt:  time.Unix(c.FetchedAt, 0),
+		}
+	}
+	if err := s.store.SyncCheckouts(conn.AgentID, checkouts); err != nil {
+		logger.Warn("[TCP] failed to store checkouts of %s: %v", conn.AgentName, err)
+		return
+	}
+	logger.Debug("[TCP] agent %s reported %d checkout(s)", conn.AgentName, len(checkouts))
+
+	if s.onCheckouts != nil {
+		s.onCheckouts(conn.AgentID, checkouts)
+	}
+}
@@ -0,0 +1,79 @@
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+
+package protocol
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+)
+
+const (
+	CompressionGzip   = "gzip"
+	CompressThreshold = 1024
+)
+
+func SupportedCompression() []string {
+	return []string{CompressionGzip}
+}
+
+func NegotiateCompression(offered []string) string {
+	for _, alg := range offered {
+		if alg == CompressionGzip {
+			return alg
+		}
+	}
+	return ""
+}
+
+func compress(alg string, payload []byte) ([]byte, bool) {
+	if alg != CompressionGzip || len(payload) <= CompressThreshold {
+		return payload, false
+	}
+
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(payload); err != nil {
+		return payload, false
+	}
+	if err := zw.Close(); err != nil {
+		return payload, false
+	}
+	if buf.Len() >= len(payload) {
+		return payload, false
+	}
+	return buf.Bytes(), true
+}
+
+func decompress(payload []byte) ([]byte, error) {
+	zr, err := gzip.NewReader(bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+
+	data, err := io.ReadAll(io.LimitReader(zr, MaxPayloadSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > MaxPayloadSize {
+		return nil, ErrPayloadTooLarge
+	}
+	return data, nil
+}
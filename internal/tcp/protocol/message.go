@@ -59,17 +59,37 @@ func (m *Message) Encode() []byte {
 	return result
 }
 
+func (m *Message) EncodeCompressed(alg string) []byte {
+	payload, compressed := compress(alg, m.Payload)
+	var flags byte
+	if compressed {
+		flags |= FlagCompressed
+	}
+
+	header := EncodeHeaderFlags(m.Type, flags, uint32(len(payload)))
+	result := make([]byte, HeaderSizeFlags+len(payload))
+	copy(result[:HeaderSizeFlags], header)
+	copy(result[HeaderSizeFlags:], payload)
+	return result
+}
+
 type AuthPayload struct {
-	Token    string `json:"token"`
-	Hostname string `json:"hostname"`
-	IP       string `json:"ip"`
-	Version  string `json:"version"`
+	Token       string   `json:"token"`
+	Hostname    string   `json:"hostname"`
+	MachineID   string   `json:"machine_id,omitempty"`
+	IP          string   `json:"ip"`
+	Version     string   `json:"version"`
+	Time        int64    `json:"time,omitempty"`
+	Compression []string `json:"compression,omitempty"`
+	Exec        []string `json:"exec,omitempty"`
+	Probe       bool     `json:"probe,omitempty"`
 }
 
 type AuthOKPayload struct {
 	AgentID       string `json:"agent_id"`
 	Name          string `json:"name"`
 	ServerVersion string `json:"server_version"`
+	Compression   string `json:"compression,omitempty"`
 }
 
 type AuthFailPayload struct {
@@ -77,9 +97,34 @@ type AuthFailPayload struct {
 }
 
 type MetricsPayload struct {
-	Timestamp  int64         `json:"timestamp"`
-	System     SystemMetrics `json:"system"`
-	Containers []Container   `json:"containers"`
+	Timestamp     int64         `json:"timestamp"`
+	System        SystemMetrics `json:"system"`
+	Containers    []Container   `json:"containers,omitempty"`
+	Inventory     bool          `json:"inventory,omitempty"`
+	QueuedDeploys int           `json:"queued_deploys,omitempty"`
+
+	DockerDiskUsage *DockerDiskUsage `json:"docker_disk_usage,omitempty"`
+	ReclaimedBytes  uint64           `json:"reclaimed_bytes,omitempty"`
+
+	LowDiskForDeploys bool `json:"low_disk_for_deploys,omitempty"`
+
+	Checkouts         []Checkout `json:"checkouts,omitempty"`
+	CheckoutInventory bool       `json:"checkout_inventory,omitempty"`
+}
+
+type Checkout struct {
+	Repository string `json:"repository"`
+	URL        string `json:"url"`
+	Branch     string `json:"branch"`
+	Commit     string `json:"commit"`
+	FetchedAt  int64  `json:"fetched_at"`
+}
+
+type DockerDiskUsage struct {
+	Images     uint64 `json:"images"`
+	Containers uint64 `json:"containers"`
+	Volumes    uint64 `json:"volumes"`
+	BuildCache uint64 `json:"build_cache"`
 }
 
 type SystemMetrics struct {
@@ -98,6 +143,8 @@ type Container struct {
 	ID           string  `json:"id"`
 	Name         string  `json:"name"`
 	Image        string  `json:"image"`
+	Project      string  `json:"project,omitempty"`
+	Service      string  `json:"service,omitempty"`
 	Status       string  `json:"status"`
 	Health       string  `json:"health"`
 	CPUPercent   float64 `json:"cpu_percent"`
@@ -107,6 +154,7 @@ type Container struct {
 	NetworkTx    uint64  `json:"network_tx"`
 	RestartCount int     `json:"restart_count"`
 	StartedAt    int64   `json:"started_at"`
+	StatsStale   bool    `json:"stats_stale,omitempty"`
 }
 
 type CommandPayload struct {
@@ -118,8 +166,14 @@ type CommandPayload struct {
 type CommandAckPayload struct {
 	CommandID string `json:"command_id"`
 	Status    string `json:"status"`
+	Detail    string `json:"detail,omitempty"`
 }
 
+const (
+	AckReceived = "received"
+	AckQueued   = "queued"
+)
+
 type CommandStartPayload struct {
 	CommandID string `json:"command_id"`
 	StartedAt int64  `json:"started_at"`
@@ -132,12 +186,47 @@ type CommandLogPayload struct {
 	Timestamp int64  `json:"timestamp"`
 }
 
+type CommandStepPayload struct {
+	CommandID  string `json:"command_id"`
+	Step       string `json:"step"`
+	Status     string `json:"status"`
+	StartedAt  int64  `json:"started_at"`
+	DurationMs int64  `json:"duration_ms"`
+}
+
 type CommandDonePayload struct {
+	CommandID     string `json:"command_id"`
+	Status        string `json:"status"`
+	ExitCode      int    `json:"exit_code"`
+	Duration      int64  `json:"duration"`
+	Output        string `json:"output"`
+	Commit        string `json:"commit,omitempty"`
+	ConfigHash    string `json:"config_hash,omitempty"`
+	ChangeSummary string `json:"change_summary,omitempty"`
+	BuildFile     string `json:"build_file,omitempty"`
+
+	Containers []DeployedContainer `json:"containers,omitempty"`
+}
+
+type DeployedContainer struct {
+	Name    string `json:"name"`
+	Service string `json:"service,omitempty"`
+	Image   string `json:"image"`
+	ImageID string `json:"image_id"`
+}
+
+const (
+	MaxArtifactSize   = 4 * 1024 * 1024
+	ArtifactChunkSize = 256 * 1024
+)
+
+type CommandArtifactPayload struct {
 	CommandID string `json:"command_id"`
-	Status    string `json:"status"`
-	ExitCode  int    `json:"exit_code"`
-	Duration  int64  `json:"duration"`
-	Output    string `json:"output"`
+	Name      string `json:"name"`
+	Seq       int    `json:"seq"`
+	Final     bool   `json:"final"`
+	Truncated bool   `json:"truncated,omitempty"`
+	Data      string `json:"data"`
 }
 
 type ErrorPayload struct {
@@ -156,12 +245,30 @@ type ContainerLogsDataPayload struct {
 	Line        string `json:"line"`
 	Stream      string `json:"stream"`
 	Timestamp   int64  `json:"timestamp"`
+	Error       string `json:"error,omitempty"`
 }
 
+const (
+	StreamInterrupted = "interrupted"
+	StreamResumed     = "resumed"
+)
+
 type ContainerLogsStopPayload struct {
 	ContainerID string `json:"container_id"`
 }
 
+type DriftReportPayload struct {
+	Repository string   `json:"repository"`
+	Findings   []string `json:"findings"`
+	CheckedAt  int64    `json:"checked_at"`
+}
+
+type AgentEventPayload struct {
+	Type      string `json:"type"`
+	Message   string `json:"message"`
+	Timestamp int64  `json:"timestamp"`
+}
+
 func Ping() *Message {
 	return &Message{Type: TypePing}
 }
@@ -26,10 +26,16 @@ import (
 const (
 	MagicByte1 byte = 0x55
 	MagicByte2 byte = 0x46
-	Version    byte = 0x01
+	Version    byte = 0x02
 
-	HeaderSize     = 8
-	MaxPayloadSize = 16 * 1024 * 1024
+	VersionLegacy byte = 0x01
+
+	HeaderSize       = 8
+	HeaderSizeFlags  = 9
+	headerPrefixSize = 3
+	MaxPayloadSize   = 16 * 1024 * 1024
+
+	FlagCompressed byte = 0x01
 )
 
 type MessageType byte
@@ -42,11 +48,16 @@ const (
 	TypeMetrics    MessageType = 0x10
 	TypeMetricsAck MessageType = 0x11
 
+	TypeInventoryRequest MessageType = 0x12
+
 	TypeCommand      MessageType = 0x20
 	TypeCommandAck   MessageType = 0x21
 	TypeCommandStart MessageType = 0x22
 	TypeCommandLog   MessageType = 0x23
 	TypeCommandDone  MessageType = 0x24
+	TypeCommandStep  MessageType = 0x25
+
+	TypeCommandArtifact MessageType = 0x26
 
 	TypePing MessageType = 0x30
 	TypePong MessageType = 0x31
@@ -57,6 +68,9 @@ const (
 	TypeContainerLogsRequest MessageType = 0x50
 	TypeContainerLogsData    MessageType = 0x51
 	TypeContainerLogsStop    MessageType = 0x52
+
+	TypeDriftReport MessageType = 0x60
+	TypeAgentEvent  MessageType = 0x61
 )
 
 var (
@@ -78,6 +92,8 @@ func (t MessageType) String() string {
 		return "METRICS"
 	case TypeMetricsAck:
 		return "METRICS_ACK"
+	case TypeInventoryRequest:
+		return "INVENTORY_REQUEST"
 	case TypeCommand:
 		return "COMMAND"
 	case TypeCommandAck:
@@ -88,6 +104,10 @@ func (t MessageType) String() string {
 		return "COMMAND_LOG"
 	case TypeCommandDone:
 		return "COMMAND_DONE"
+	case TypeCommandStep:
+		return "COMMAND_STEP"
+	case TypeCommandArtifact:
+		return "COMMAND_ARTIFACT"
 	case TypePing:
 		return "PING"
 	case TypePong:
@@ -102,6 +122,10 @@ func (t MessageType) String() string {
 		return "CONTAINER_LOGS_DATA"
 	case TypeContainerLogsStop:
 		return "CONTAINER_LOGS_STOP"
+	case TypeDriftReport:
+		return "DRIFT_REPORT"
+	case TypeAgentEvent:
+		return "AGENT_EVENT"
 	default:
 		return "UNKNOWN"
 	}
@@ -111,31 +135,63 @@ func EncodeHeader(msgType MessageType, payloadLen uint32) []byte {
 	header := make([]byte, HeaderSize)
 	header[0] = MagicByte1
 	header[1] = MagicByte2
-	header[2] = Version
+	header[2] = VersionLegacy
 	header[3] = byte(msgType)
 	binary.BigEndian.PutUint32(header[4:], payloadLen)
 	return header
 }
 
-func DecodeHeader(header []byte) (MessageType, uint32, error) {
-	if len(header) < HeaderSize {
-		return 0, 0, ErrInvalidHeader
+func EncodeHeaderFlags(msgType MessageType, flags byte, payloadLen uint32) []byte {
+	header := make([]byte, HeaderSizeFlags)
+	header[0] = MagicByte1
+	header[1] = MagicByte2
+	header[2] = Version
+	header[3] = byte(msgType)
+	header[4] = flags
+	binary.BigEndian.PutUint32(header[5:], payloadLen)
+	return header
+}
+
+func headerSize(prefix []byte) (int, error) {
+	if len(prefix) < headerPrefixSize {
+		return 0, ErrInvalidHeader
 	}
 
-	if header[0] != MagicByte1 || header[1] != MagicByte2 {
-		return 0, 0, ErrInvalidMagic
+	if prefix[0] != MagicByte1 || prefix[1] != MagicByte2 {
+		return 0, ErrInvalidMagic
 	}
 
-	if header[2] != Version {
-		return 0, 0, ErrInvalidVersion
+	switch prefix[2] {
+	case VersionLegacy:
+		return HeaderSize, nil
+	case Version:
+		return HeaderSizeFlags, nil
+	default:
+		return 0, ErrInvalidVersion
+	}
+}
+
+func DecodeHeader(header []byte) (MessageType, byte, uint32, error) {
+	size, err := headerSize(header)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	if len(header) < size {
+		return 0, 0, 0, ErrInvalidHeader
 	}
 
 	msgType := MessageType(header[3])
-	payloadLen := binary.BigEndian.Uint32(header[4:])
+	var flags byte
+	lenAt := 4
+	if size == HeaderSizeFlags {
+		flags = header[4]
+		lenAt = 5
+	}
+	payloadLen := binary.BigEndian.Uint32(header[lenAt:])
 
 	if payloadLen > MaxPayloadSize {
-		return 0, 0, ErrPayloadTooLarge
+		return 0, 0, 0, ErrPayloadTooLarge
 	}
 
-	return msgType, payloadLen, nil
+	return msgType, flags, payloadLen, nil
 }
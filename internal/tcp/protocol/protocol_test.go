@@ -0,0 +1,247 @@
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+
+package protocol
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func readEncoded(t *testing.T, data []byte) (*Message, error) {
+	t.Helper()
+	local, remote := net.Pipe()
+	t.Cleanup(func() {
+		local.Close()
+		remote.Close()
+	})
+	go func() {
+		remote.Write(data)
+		remote.Close()
+	}()
+	return NewReader(local).ReadWithTimeout(5 * time.Second)
+}
+
+func TestEncodeDecodeRoundTrip(t *testing.T) {
+	small := strings.Repeat("x", 100)
+	large := strings.Repeat("deploy log line\n", 1000)
+
+	cases := []struct {
+		name       string
+		encode     func(*Message) []byte
+		payload    string
+		version    byte
+		compressed bool
+	}{
+		{"v1", (*Message).Encode, large, VersionLegacy, false},
+		{"v2 without compression", func(m *Message) []byte { return m.EncodeCompressed("") }, large, Version, false},
+		{"v2 below threshold", func(m *Message) []byte { return m.EncodeCompressed(CompressionGzip) }, small, Version, false},
+		{"v2 gzip", func(m *Message) []byte { return m.EncodeCompressed(CompressionGzip) }, large, Version, true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			msg, err := NewMessage(TypeCommandLog, CommandLogPayload{CommandID: "d1", Line: tc.payload})
+			if err != nil {
+				t.Fatal(err)
+			}
+			data := tc.encode(msg)
+
+			if data[2] != tc.version {
+				t.Fatalf("version byte = %#x, want %#x", data[2], tc.version)
+			}
+			typ, flags, n, err := DecodeHeader(data)
+			if err != nil {
+				t.Fatalf("DecodeHeader: %v", err)
+			}
+			if typ != TypeCommandLog {
+				t.Fatalf("type = %s", typ)
+			}
+			if got := flags&FlagCompressed != 0; got != tc.compressed {
+				t.Fatalf("compressed flag = %v, want %v", got, tc.compressed)
+			}
+			if tc.compressed && int(n) >= len(msg.Payload) {
+				t.Fatalf("compressed payload is %d bytes, original %d", n, len(msg.Payload))
+			}
+
+			got, err := readEncoded(t, data)
+			if err != nil {
+				t.Fatalf("Read: %v", err)
+			}
+			var log CommandLogPayload
+			if err := got.Decode(&log); err != nil {
+				t.Fatal(err)
+			}
+			if got.Type != TypeCommandLog || log.CommandID != "d1" || log.Line != tc.payload {
+				t.Fatalf("round trip changed the message: %s %q", got.Type, log.CommandID)
+			}
+		})
+	}
+}
+
+func TestDecodeHeaderRejectsBadFrames(t *testing.T) {
+	good := EncodeHeaderFlags(TypePing, 0, 0)
+	cases := []struct {
+		name   string
+		header []byte
+		want   error
+	}{
+		{"short", good[:2], ErrInvalidHeader},
+		{"bad magic", append([]byte{0x00}, good[1:]...), ErrInvalidMagic},
+		{"unknown version", append([]byte{MagicByte1, MagicByte2, 0x07}, good[3:]...), ErrInvalidVersion},
+		{"v2 header cut short", good[:HeaderSize], ErrInvalidHeader},
+		{"oversized", EncodeHeader(TypeCommandLog, MaxPayloadSize+1), ErrPayloadTooLarge},
+	}
+	for _, tc := range cases {
+		if _, _, _, err := DecodeHeader(tc.header); !errors.Is(err, tc.want) {
+			t.Errorf("%s: DecodeHeader = %v, want %v", tc.name, err, tc.want)
+		}
+	}
+}
+
+func TestUnknownFlagsAreIgnored(t *testing.T) {
+	payload := []byte(`{"command_id":"d1"}`)
+	data := append(EncodeHeaderFlags(TypeCommandDone, 0x80, uint32(len(payload))), payload...)
+	msg, err := readEncoded(t, data)
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if !bytes.Equal(msg.Payload, payload) {
+		t.Fatalf("payload = %q", msg.Payload)
+	}
+}
+
+func TestGzipBombIsRejected(t *testing.T) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	zero := make([]byte, 1024*1024)
+	for i := 0; i < MaxPayloadSize/len(zero)+1; i++ {
+		zw.Write(zero)
+	}
+	zw.Close()
+	if buf.Len() > MaxPayloadSize {
+		t.Fatalf("bomb is %d bytes compressed, it should fit in one frame", buf.Len())
+	}
+
+	data := append(EncodeHeaderFlags(TypeCommandLog, FlagCompressed, uint32(buf.Len())), buf.Bytes()...)
+	_, err := readEncoded(t, data)
+	if !errors.Is(err, ErrPayloadTooLarge) {
+		t.Fatalf("Read = %v, want ErrPayloadTooLarge", err)
+	}
+}
+
+func TestCorruptGzipIsRejected(t *testing.T) {
+	payload := []byte("not gzip at all")
+	data := append(EncodeHeaderFlags(TypeCommandLog, FlagCompressed, uint32(len(payload))), payload...)
+	if _, err := readEncoded(t, data); err == nil {
+		t.Fatal("corrupt compressed payload was accepted")
+	}
+}
+
+func TestNegotiateCompression(t *testing.T) {
+	cases := []struct {
+		offered []string
+		want    string
+	}{
+		{nil, ""},
+		{[]string{"zstd"}, ""},
+		{[]string{"zstd", CompressionGzip}, CompressionGzip},
+		{SupportedCompression(), CompressionGzip},
+	}
+	for _, tc := range cases {
+		if got := NegotiateCompression(tc.offered); got != tc.want {
+			t.Errorf("NegotiateCompression(%v) = %q, want %q", tc.offered, got, tc.want)
+		}
+	}
+}
+
+func TestWriterCompressesAfterNegotiation(t *testing.T) {
+	local, remote := net.Pipe()
+	defer local.Close()
+	defer remote.Close()
+
+	w := NewWriter(local)
+	r := NewReader(remote)
+	msg, _ := NewMessage(TypeCommandLog, CommandLogPayload{CommandID: "d1", Line: strings.Repeat("a", 4096)})
+
+	for _, alg := range []string{"", CompressionGzip} {
+		w.SetCompression(alg)
+		go w.Write(msg)
+		got, err := r.ReadWithTimeout(5 * time.Second)
+		if err != nil {
+			t.Fatalf("compression %q: %v", alg, err)
+		}
+		if !bytes.Equal(got.Payload, msg.Payload) {
+			t.Fatalf("compression %q changed the payload", alg)
+		}
+	}
+}
+
+func TestMetricsContainerInventoryRoundTrip(t *testing.T) {
+	cases := []struct {
+		name          string
+		payload       MetricsPayload
+		present       []string
+		absent        []string
+		wantInventory bool
+		wantCount     int
+	}{
+		{"docker unavailable", MetricsPayload{Timestamp: 1},
+			nil, []string{`"inventory"`, `"containers"`}, false, 0},
+		{"no containers", MetricsPayload{Timestamp: 1, Inventory: true, Containers: []Container{}},
+			[]string{`"inventory":true`}, []string{`"containers"`}, true, 0},
+		{"containers", MetricsPayload{Timestamp: 1, Inventory: true, Containers: []Container{{ID: "c1"}, {ID: "c2"}}},
+			[]string{`"inventory":true`, `"containers":[{`}, nil, true, 2},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			msg, err := NewMessage(TypeMetrics, tc.payload)
+			if err != nil {
+				t.Fatal(err)
+			}
+			raw := string(msg.Payload)
+			for _, key := range tc.present {
+				if !strings.Contains(raw, key) {
+					t.Fatalf("payload %s does not contain %s", raw, key)
+				}
+			}
+			for _, key := range tc.absent {
+				if strings.Contains(raw, key) {
+					t.Fatalf("payload %s contains %s", raw, key)
+				}
+			}
+
+			got, err := readEncoded(t, msg.Encode())
+			if err != nil {
+				t.Fatal(err)
+			}
+			var metrics MetricsPayload
+			if err := got.Decode(&metrics); err != nil {
+				t.Fatal(err)
+			}
+			if metrics.Inventory != tc.wantInventory || len(metrics.Containers) != tc.wantCount {
+				t.Fatalf("decoded inventory = %v with %d containers, want %v with %d",
+					metrics.Inventory, len(metrics.Containers), tc.wantInventory, tc.wantCount)
+			}
+		})
+	}
+}
@@ -20,6 +20,7 @@ package protocol
 
 import (
 	"bufio"
+	"fmt"
 	"io"
 	"net"
 	"time"
@@ -38,13 +39,20 @@ func NewReader(conn net.Conn) *Reader {
 }
 
 func (r *Reader) Read() (*Message, error) {
-	header := make([]byte, HeaderSize)
-	_, err := io.ReadFull(r.reader, header)
+	header := make([]byte, HeaderSizeFlags)
+	if _, err := io.ReadFull(r.reader, header[:headerPrefixSize]); err != nil {
+		return nil, err
+	}
+
+	size, err := headerSize(header)
 	if err != nil {
 		return nil, err
 	}
+	if _, err := io.ReadFull(r.reader, header[headerPrefixSize:size]); err != nil {
+		return nil, err
+	}
 
-	msgType, payloadLen, err := DecodeHeader(header)
+	msgType, flags, payloadLen, err := DecodeHeader(header[:size])
 	if err != nil {
 		return nil, err
 	}
@@ -58,6 +66,13 @@ func (r *Reader) Read() (*Message, error) {
 		}
 	}
 
+	if flags&FlagCompressed != 0 {
+		payload, err = decompress(payload)
+		if err != nil {
+			return nil, fmt.Errorf("decompress %s payload: %w", msgType, err)
+		}
+	}
+
 	return &Message{
 		Type:    msgType,
 		Payload: payload,
@@ -26,9 +26,10 @@ import (
 )
 
 type Writer struct {
-	conn   net.Conn
-	writer *bufio.Writer
-	mu     sync.Mutex
+	conn        net.Conn
+	writer      *bufio.Writer
+	mu          sync.Mutex
+	compression string
 }
 
 func NewWriter(conn net.Conn) *Writer {
@@ -43,6 +44,9 @@ func (w *Writer) Write(msg *Message) error {
 	defer w.mu.Unlock()
 
 	data := msg.Encode()
+	if w.compression != "" {
+		data = msg.EncodeCompressed(w.compression)
+	}
 	_, err := w.writer.Write(data)
 	if err != nil {
 		return err
@@ -51,6 +55,12 @@ func (w *Writer) Write(msg *Message) error {
 	return w.writer.Flush()
 }
 
+func (w *Writer) SetCompression(alg string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.compression = alg
+}
+
 func (w *Writer) WriteWithTimeout(msg *Message, timeout time.Duration) error {
 	w.conn.SetWriteDeadline(time.Now().Add(timeout))
 	defer w.conn.SetWriteDeadline(time.Time{})
@@ -19,9 +19,16 @@
 package tcp
 
 import (
+	"context"
 	"crypto/tls"
+	"crypto/x509"
+	"errors"
 	"fmt"
+	"io"
 	"net"
+	"os"
+	"path/filepath"
+	"strings"
 	"sync"
 	"time"
 
@@ -38,27 +45,60 @@ const (
 	AuthTimeout  = 10 * time.Second
 	PingInterval = 30 * time.Second
 	PongTimeout  = 45 * time.Second
+
+	ReapInterval = time.Minute
+	ReapQuiet    = 5 * time.Minute
+
+	MetricsSampleInterval = 30 * time.Second
 )
 
 type Server struct {
 	cfg            *config.Config
 	store          storage.Store
-	listener       net.Listener
+	listeners      []net.Listener
 	connections    map[string]*Connection
 	mu             sync.RWMutex
 	done           chan struct{}
 	onLog          func(agentID string, log *models.CommandLog)
 	onMetrics      func(agentID string, metrics *models.AgentMetrics)
-	onContainerLog func(agentID string, data protocol.ContainerLogsDataPayload)
+	onContainerLog func(agentID string, data protocol.ContainerLogsDataPayload) bool
+	onAlert        func(alert *models.Alert)
+	onDeployFailed func(alert *models.Alert)
+	onDeployDone   func(d *models.Deployment)
+	onDeployment   func(event DeploymentEvent)
+	onCheckouts    func(agentID string, checkouts []models.Checkout)
+	pending        map[string]chan protocol.CommandDonePayload
+	pendingMu      sync.Mutex
+	logStreams     map[logStreamKey]*logStream
+	logMu          sync.Mutex
+	offlineTimers  map[string]*time.Timer
+	logs           *logWriter
+	execs          map[string]*execRun
+	execMu         sync.Mutex
+	authFails      map[string]*authFailures
+	authMu         sync.Mutex
+	artifacts      map[artifactKey]*artifactUpload
+	artifactMu     sync.Mutex
+	inventory      map[string]time.Time
+	inventoryMu    sync.Mutex
 }
 
 func NewServer(cfg *config.Config, store storage.Store) *Server {
-	return &Server{
-		cfg:         cfg,
-		store:       store,
-		connections: make(map[string]*Connection),
-		done:        make(chan struct{}),
+	s := &Server{
+		cfg:           cfg,
+		store:         store,
+		connections:   make(map[string]*Connection),
+		done:          make(chan struct{}),
+		pending:       make(map[string]chan protocol.CommandDonePayload),
+		logStreams:    make(map[logStreamKey]*logStream),
+		offlineTimers: make(map[string]*time.Timer),
+		execs:         make(map[string]*execRun),
+		authFails:     make(map[string]*authFailures),
+		artifacts:     make(map[artifactKey]*artifactUpload),
+		inventory:     make(map[string]time.Time),
 	}
+	s.logs = newLogWriter(store, s.logsFlushed)
+	return s
 }
 
 func (s *Server) SetLogHandler(handler func(agentID string, log *models.CommandLog)) {
@@ -69,92 +109,231 @@ func (s *Server) SetMetricsHandler(handler func(agentID string, metrics *models.
 	s.onMetrics = handler
 }
 
-func (s *Server) SetContainerLogHandler(handler func(agentID string, data protocol.ContainerLogsDataPayload)) {
+func (s *Server) SetContainerLogHandler(handler func(agentID string, data protocol.ContainerLogsDataPayload) bool) {
 	s.onContainerLog = handler
 }
 
-func (s *Server) Start() error {
-	addr := fmt.Sprintf("%s:%d", s.cfg.Server.Host, s.cfg.Server.TCPPort)
+func (s *Server) SetAlertHandler(handler func(alert *models.Alert)) {
+	s.onAlert = handler
+}
 
-	var listener net.Listener
-	var err error
+func (s *Server) SetDeployFailedHandler(handler func(alert *models.Alert)) {
+	s.onDeployFailed = handler
+}
 
+func (s *Server) SetDeployDoneHandler(handler func(d *models.Deployment)) {
+	s.onDeployDone = handler
+}
+
+func (s *Server) deployDone(d *models.Deployment) {
+	s.deploymentEvent(DeploymentEvent{Kind: DeploymentDone, DeploymentID: d.ID, Repository: d.Repository, Status: d.Status, Line: d.Output})
+	if s.onDeployDone != nil {
+		s.onDeployDone(d)
+	}
+}
+
+func (s *Server) raiseAlert(alert *models.Alert) {
+	if err := s.store.CreateAlert(alert); err != nil {
+		logger.Error("[TCP] failed to store %s alert for %s: %v", alert.Type, alert.AgentName, err)
+		return
+	}
+	if s.onAlert != nil {
+		s.onAlert(alert)
+	}
+}
+
+func (s *Server) deployFailed(d *models.Deployment) {
+	if d.DryRun {
+		return
+	}
+	history, _ := s.store.GetDeploymentsByRepo(d.Repository, 50)
+	failures := logic.ConsecutiveFailures(history, d.AgentID)
+	if failures == 0 {
+		failures = 1
+	}
+	logs, _ := s.store.GetDeploymentLogsPage(d.ID, 0, 200)
+	alert := logic.DeployFailed(d, logic.DeployFailureReason(logs, d.Output), failures)
+
+	activeAlerts, _ := s.store.GetAlertsByAgent(d.AgentID)
+	for _, a := range activeAlerts {
+		if a.Resolved || !logic.IsDeployFailedAlert(&a, d.Repository) {
+			continue
+		}
+		alert.ID = a.ID
+		alert.CreatedAt = a.CreatedAt
+		if err := s.store.UpdateAlert(alert); err != nil {
+			logger.Error("[TCP] failed to update deploy alert for %s: %v", d.Repository, err)
+		}
+		s.notifyDeployFailed(alert)
+		return
+	}
+
+	if err := s.store.CreateAlert(alert); err != nil {
+		logger.Error("[TCP] failed to store deploy alert for %s: %v", d.Repository, err)
+	}
+	s.notifyDeployFailed(alert)
+}
+
+func (s *Server) notifyDeployFailed(alert *models.Alert) {
+	if s.onDeployFailed != nil {
+		s.onDeployFailed(alert)
+	}
+}
+
+func (s *Server) resolveDeployAlert(agentID, repoName string) {
+	activeAlerts, _ := s.store.GetAlertsByAgent(agentID)
+	for _, a := range activeAlerts {
+		if !a.Resolved && logic.IsDeployFailedAlert(&a, repoName) {
+			if err := s.store.AutoResolveAlert(a.ID); err == nil {
+				logger.Info("[TCP] %s deployed successfully, failure alert resolved", repoName)
+			}
+		}
+	}
+}
+
+func (s *Server) Start() error {
+	addrs, err := s.cfg.TCPListenAddrs()
+	if err != nil {
+		return fmt.Errorf("tcp listen: %w", err)
+	}
+
+	var tlsConfig *tls.Config
 	if s.cfg.TLS.Enabled {
-		listener, err = s.listenTLS(addr)
+		tlsConfig, err = s.tlsConfig()
 		if err != nil {
-			return fmt.Errorf("tls listen: %w", err)
+			return fmt.Errorf("tls config: %w", err)
 		}
-		logger.Info("[TCP] server listening on %s (TLS)", addr)
-	} else {
-		listener, err = net.Listen("tcp", addr)
+	}
+
+	var errs []error
+	for _, addr := range addrs {
+		listener, err := net.Listen("tcp", addr)
 		if err != nil {
-			return fmt.Errorf("tcp listen: %w", err)
+			logger.Warn("[TCP] listen on %s failed: %v", addr, err)
+			errs = append(errs, fmt.Errorf("%s: %w", addr, err))
+			continue
+		}
+
+		if tlsConfig != nil {
+			listener = tls.NewListener(listener, tlsConfig)
+			logger.Info("[TCP] server listening on %s (TLS)", addr)
+		} else {
+			logger.Info("[TCP] server listening on %s", addr)
 		}
-		logger.Info("[TCP] server listening on %s", addr)
+
+		s.listeners = append(s.listeners, listener)
+		go s.acceptRequests(listener)
 	}
 
-	s.listener = listener
+	if len(s.listeners) == 0 {
+		return fmt.Errorf("tcp listen: %w", errors.Join(errs...))
+	}
 
-	go s.acceptRequests()
 	go s.pingService()
+	go s.reapService()
 
 	return nil
 }
 
-func (s *Server) listenTLS(addr string) (net.Listener, error) {
-	if s.cfg.TLS.AutoCert {
-		return s.listenAutoTLS(addr)
-	}
+func (s *Server) tlsConfig() (*tls.Config, error) {
+	var cert tls.Certificate
+	var err error
 
-	cert, err := tls.LoadX509KeyPair(s.cfg.TLS.CertFile, s.cfg.TLS.KeyFile)
-	if err != nil {
-		return nil, fmt.Errorf("load cert: %w", err)
+	if s.cfg.TLS.AutoCert {
+		hosts := s.cfg.TLS.AutoCertHosts
+		if len(hosts) == 0 {
+			hosts = defaultCertHosts()
+		}
+		validity := time.Duration(s.cfg.TLS.AutoCertDays) * 24 * time.Hour
+		dir := filepath.Join(s.cfg.Server.DataDir, "tls")
+		var created bool
+		cert, created, err = loadOrCreateAutoCert(dir, hosts, validity, len(s.cfg.TLS.AutoCertHosts) > 0)
+		if err != nil {
+			return nil, fmt.Errorf("auto cert: %w", err)
+		}
+		if created {
+			logger.Info("[TCP] generated self-signed certificate in %s for %s, valid until %s",
+				dir, strings.Join(hosts, ", "), cert.Leaf.NotAfter.Format("2006-01-02"))
+		} else {
+			logger.Info("[TCP] using self-signed certificate from %s, valid until %s", dir, cert.Leaf.NotAfter.Format("2006-01-02"))
+		}
+	} else {
+		cert, err = tls.LoadX509KeyPair(s.cfg.TLS.CertFile, s.cfg.TLS.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("load cert: %w", err)
+		}
 	}
+	logger.Info("[TCP] certificate fingerprint (sha256) %s", CertFingerprint(cert.Certificate[0]))
 
 	tlsConfig := &tls.Config{
 		Certificates: []tls.Certificate{cert},
 		MinVersion:   tls.VersionTLS12,
 	}
 
-	return tls.Listen("tcp", addr, tlsConfig)
-}
+	if s.cfg.TLS.ClientCAFile == "" {
+		if s.cfg.TLS.RequireClientCert {
+			return nil, fmt.Errorf("tls.require_client_cert needs tls.client_ca_file")
+		}
+		return tlsConfig, nil
+	}
 
-func (s *Server) listenAutoTLS(addr string) (net.Listener, error) {
-	cert, err := generateSelfSignedCert()
+	pem, err := os.ReadFile(s.cfg.TLS.ClientCAFile)
 	if err != nil {
-		return nil, fmt.Errorf("generate cert: %w", err)
+		return nil, fmt.Errorf("read client ca: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("client ca %s contains no certificates", s.cfg.TLS.ClientCAFile)
 	}
 
-	tlsConfig := &tls.Config{
-		Certificates: []tls.Certificate{cert},
-		MinVersion:   tls.VersionTLS12,
+	tlsConfig.ClientCAs = pool
+	tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+	if s.cfg.TLS.RequireClientCert {
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
 	}
+	logger.Info("[TCP] client certificates verified against %s (required: %v)", s.cfg.TLS.ClientCAFile, s.cfg.TLS.RequireClientCert)
 
-	logger.Info("[TCP] using auto-generated self-signed certificate")
-	return tls.Listen("tcp", addr, tlsConfig)
+	return tlsConfig, nil
 }
 
 func (s *Server) Stop() error {
+	s.stopAllLogStreams()
 	close(s.done)
-	if s.listener != nil {
-		s.listener.Close()
+	for _, listener := range s.listeners {
+		listener.Close()
 	}
 	s.mu.Lock()
+	conns := make([]*Connection, 0, len(s.connections))
 	for _, conn := range s.connections {
-		conn.Send(protocol.Disconnect())
-		conn.Close()
+		conns = append(conns, conn)
+	}
+	for agentID, timer := range s.offlineTimers {
+		timer.Stop()
+		delete(s.offlineTimers, agentID)
 	}
 	s.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for _, conn := range conns {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			conn.Send(protocol.Disconnect())
+			conn.CloseWithReason(DisconnectShutdown)
+		}()
+	}
+	wg.Wait()
+	s.logs.Close()
 	return nil
 }
 
-func (s *Server) acceptRequests() {
+func (s *Server) acceptRequests(listener net.Listener) {
 	for {
 		select {
 		case <-s.done:
 			return
 		default:
-			conn, err := s.listener.Accept()
+			conn, err := listener.Accept()
 			if err != nil {
 				select {
 				case <-s.done:
@@ -173,6 +352,17 @@ func (s *Server) handleConnection(netConn net.Conn) {
 	connID := helper.GenerateID()
 	conn := NewConnection(connID, netConn)
 
+	if tlsConn, ok := netConn.(*tls.Conn); ok {
+		ctx, cancel := context.WithTimeout(context.Background(), AuthTimeout)
+		err := tlsConn.HandshakeContext(ctx)
+		cancel()
+		if err != nil {
+			logger.Warn("[TCP] TLS handshake with %s failed: %v", conn.RemoteAddr(), err)
+			conn.Close()
+			return
+		}
+	}
+
 	agentID, err := s.authenticate(conn)
 	if err != nil {
 		logger.Warn("[TCP] auth failed for %s: %v", conn.RemoteAddr(), err)
@@ -180,11 +370,19 @@ func (s *Server) handleConnection(netConn net.Conn) {
 		return
 	}
 
+	if conn.Probe {
+		logger.Info("[TCP] agent %s verified its connection settings from %s", conn.AgentName, conn.RemoteAddr())
+		conn.Close()
+		return
+	}
+
 	s.addConnection(agentID, conn)
-	defer s.removeConnection(agentID)
+	defer s.removeConnection(conn)
 
 	logger.Info("[TCP] agent %s connected", conn.AgentName)
+	s.recordEvent(agentID, models.AgentEventConnect, fmt.Sprintf("from %s, version %s", conn.RemoteAddr(), conn.Version))
 	s.handleMessages(conn)
+	s.recordEvent(agentID, models.AgentEventDisconnect, conn.CloseReason())
 }
 
 func (s *Server) authenticate(conn *Connection) (string, error) {
@@ -207,26 +405,91 @@ func (s *Server) authenticate(conn *Connection) (string, error) {
 	}
 
 	agentCfg := s.cfg.GetAgentByToken(auth.Token)
+	tokenHash := ""
+	if agentCfg != nil {
+		tokenHash = agentCfg.TokenHash
+	}
+	if agentCfg == nil {
+		if prev, valid := s.cfg.GetAgentByPreviousToken(auth.Token, time.Now()); prev != nil {
+			if !valid {
+				reason := "token was rotated, update the agent with its new token"
+				failMsg, _ := protocol.NewMessage(protocol.TypeAuthFail, protocol.AuthFailPayload{
+					Reason: reason,
+				})
+				conn.Send(failMsg)
+				s.authFailed(conn, prev.ID, auth.Token, "rotated token")
+				s.retiredToken(conn, prev.ID, prev.Name, auth.Hostname)
+				return "", errors.New(reason)
+			}
+			logger.Warn("[TCP] agent %s authenticated with its previous token, which stops working at %s",
+				prev.Name, prev.PreviousTokenExpires.Format(time.RFC3339))
+			agentCfg = prev
+			tokenHash = prev.PreviousTokenHash
+		}
+	}
 	if agentCfg == nil {
 		failMsg, _ := protocol.NewMessage(protocol.TypeAuthFail, protocol.AuthFailPayload{
 			Reason: "invalid token",
 		})
 		conn.Send(failMsg)
+		s.authFailed(conn, "", auth.Token, "invalid token")
 		return "", fmt.Errorf("invalid token")
 	}
 
+	if err := s.verifyClientCert(conn, agentCfg.Name); err != nil {
+		failMsg, _ := protocol.NewMessage(protocol.TypeAuthFail, protocol.AuthFailPayload{
+			Reason: err.Error(),
+		})
+		conn.Send(failMsg)
+		s.authFailed(conn, agentCfg.ID, auth.Token, err.Error())
+		return "", err
+	}
+
+	if holder := s.tokenHolder(agentCfg.ID, auth.MachineID); holder != "" {
+		reason := "token already in use by host " + holder
+		failMsg, _ := protocol.NewMessage(protocol.TypeAuthFail, protocol.AuthFailPayload{
+			Reason: reason,
+		})
+		conn.Send(failMsg)
+		s.duplicateToken(conn, agentCfg.ID, agentCfg.Name, holder, auth.Hostname)
+		return "", errors.New(reason)
+	}
+
+	if auth.Probe {
+		conn.SetAgent(agentCfg.ID, agentCfg.Name)
+		conn.Probe = true
+		okMsg, _ := protocol.NewMessage(protocol.TypeAuthOK, protocol.AuthOKPayload{
+			AgentID:       agentCfg.ID,
+			Name:          agentCfg.Name,
+			ServerVersion: "1.1.0",
+		})
+		conn.Send(okMsg)
+		return agentCfg.ID, nil
+	}
+
 	host, _, _ := net.SplitHostPort(conn.RemoteAddr())
 
+	skew := logic.ClockSkew(auth.Time, time.Now())
+	degraded := !logic.VersionSupported(auth.Version)
+	if degraded {
+		logger.Warn("[TCP] agent %s runs version %q, older than the minimum supported %s",
+			agentCfg.Name, auth.Version, logic.MinAgentVersion)
+	}
+
 	existingAgent, _ := s.store.GetAgent(agentCfg.ID)
 	if existingAgent == nil {
 		agent := &models.Agent{
 			ID:            agentCfg.ID,
 			Name:          agentCfg.Name,
-			Token:         agentCfg.Token,
+			TokenHash:     agentCfg.TokenHash,
 			Host:          host,
 			Hostname:      auth.Hostname,
+			MachineID:     auth.MachineID,
 			Version:       auth.Version,
 			Status:        models.AgentOnline,
+			Labels:        agentCfg.Labels,
+			ClockSkewMs:   skew.Milliseconds(),
+			Degraded:      degraded,
 			LastHeartbeat: time.Now(),
 			RegisteredAt:  time.Now(),
 		}
@@ -234,28 +497,89 @@ func (s *Server) authenticate(conn *Connection) (string, error) {
 	} else {
 		existingAgent.Host = host
 		existingAgent.Hostname = auth.Hostname
+		existingAgent.MachineID = auth.MachineID
 		existingAgent.Version = auth.Version
 		existingAgent.Status = models.AgentOnline
+		existingAgent.ClockSkewMs = skew.Milliseconds()
+		existingAgent.Degraded = degraded
 		existingAgent.LastHeartbeat = time.Now()
 		s.store.UpdateAgent(existingAgent)
+		s.store.SetAgentLabels(agentCfg.ID, agentCfg.Labels)
 	}
+	s.store.SetAgentMaintenance(agentCfg.ID, agentCfg.Maintenance)
 
 	conn.SetAgent(agentCfg.ID, agentCfg.Name)
-
+	conn.Exec = auth.Exec
+	conn.Version = auth.Version
+	conn.Hostname = auth.Hostname
+	conn.MachineID = auth.MachineID
+	conn.TokenHash = tokenHash
+	s.checkClockSkew(agentCfg.ID, agentCfg.Name, skew)
+
+	compression := protocol.NegotiateCompression(auth.Compression)
 	okMsg, _ := protocol.NewMessage(protocol.TypeAuthOK, protocol.AuthOKPayload{
 		AgentID:       agentCfg.ID,
 		Name:          agentCfg.Name,
 		ServerVersion: "1.1.0",
+		Compression:   compression,
 	})
 	conn.Send(okMsg)
+	if compression != "" {
+		conn.SetCompression(compression)
+		logger.Debug("[TCP] agent %s negotiated %s compression", agentCfg.Name, compression)
+	}
 
 	return agentCfg.ID, nil
 }
 
+func (s *Server) checkClockSkew(agentID, agentName string, skew time.Duration) {
+	alert := logic.CheckClockSkew(agentID, agentName, skew)
+	if alert == nil {
+		if n, err := s.store.ResolveAlertsByTypeAndAgent(agentID, "clock_skew"); err == nil && n > 0 {
+			logger.Info("[TCP] agent %s clock is back in sync", agentName)
+		}
+		return
+	}
+
+	logger.Warn("[TCP] %s", alert.Message)
+	active, _ := s.store.GetActiveAlerts()
+	for _, a := range active {
+		if a.AgentID == agentID && a.Type == alert.Type {
+			return
+		}
+	}
+	s.raiseAlert(alert)
+}
+
+func (s *Server) verifyClientCert(conn *Connection, agentName string) error {
+	tlsConn, ok := conn.Conn.(*tls.Conn)
+	if !ok {
+		return nil
+	}
+
+	certs := tlsConn.ConnectionState().PeerCertificates
+	if len(certs) == 0 {
+		if s.cfg.TLS.VerifyClientCN {
+			return fmt.Errorf("client certificate required")
+		}
+		return nil
+	}
+
+	cn := certs[0].Subject.CommonName
+	logger.Info("[TCP] agent %s presented client certificate CN=%s", agentName, cn)
+	if s.cfg.TLS.VerifyClientCN && cn != agentName {
+		return fmt.Errorf("client certificate CN %q does not match agent %q", cn, agentName)
+	}
+	return nil
+}
+
 func (s *Server) handleMessages(conn *Connection) {
 	for {
 		select {
 		case <-s.done:
+			conn.CloseWithReason(DisconnectShutdown)
+			return
+		case <-conn.Done():
 			return
 		default:
 			conn.Conn.SetReadDeadline(time.Now().Add(60 * time.Second))
@@ -264,6 +588,11 @@ func (s *Server) handleMessages(conn *Connection) {
 				if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
 					continue
 				}
+				if errors.Is(err, io.EOF) {
+					conn.CloseWithReason("connection closed by agent")
+				} else {
+					conn.CloseWithReason(fmt.Sprintf("read error: %v", err))
+				}
 				return
 			}
 			s.processMessage(conn, msg)
@@ -283,14 +612,25 @@ func (s *Server) processMessage(conn *Connection, msg *protocol.Message) {
 		s.handleCommandLog(conn, msg)
 	case protocol.TypeCommandDone:
 		s.handleCommandDone(conn, msg)
+	case protocol.TypeCommandStep:
+		s.handleCommandStep(conn, msg)
+	case protocol.TypeCommandArtifact:
+		s.handleCommandArtifact(conn, msg)
 	case protocol.TypePong:
 		conn.UpdatePing()
 	case protocol.TypeDisconnect:
-		conn.Close()
+		conn.CloseWithReason(DisconnectExplicit)
+	case protocol.TypeDriftReport:
+		s.handleDriftReport(conn, msg)
+	case protocol.TypeAgentEvent:
+		var event protocol.AgentEventPayload
+		if err := msg.Decode(&event); err == nil {
+			logger.Info("[TCP] agent %s event %s: %s", conn.AgentName, event.Type, event.Message)
+		}
 	case protocol.TypeContainerLogsData:
 		var data protocol.ContainerLogsDataPayload
-		if err := msg.Decode(&data); err == nil && s.onContainerLog != nil {
-			s.onContainerLog(conn.AgentID, data)
+		if err := msg.Decode(&data); err == nil {
+			s.deliverContainerLog(conn.AgentID, data)
 		}
 	}
 }
@@ -311,8 +651,41 @@ func (s *Server) handleMetrics(conn *Connection, msg *protocol.Message) {
 		DiskTotal:     metrics.System.DiskTotal,
 		LoadAvg:       metrics.System.LoadAvg,
 		Uptime:        metrics.System.Uptime,
+		QueuedDeploys: metrics.QueuedDeploys,
+		LowDisk:       metrics.LowDiskForDeploys,
+	}
+	if du := metrics.DockerDiskUsage; du != nil {
+		agentMetrics.DockerDisk = &models.DockerDiskUsage{
+			Images:     du.Images,
+			Containers: du.Containers,
+			Volumes:    du.Volumes,
+			BuildCache: du.BuildCache,
+		}
 	}
 	s.store.UpdateAgentMetrics(conn.AgentID, agentMetrics)
+	if metrics.CheckoutInventory {
+		s.syncCheckouts(conn, metrics.Checkouts)
+	}
+	if metrics.ReclaimedBytes > 0 {
+		logger.Info("[TCP] agent %s reclaimed %s during cleanup", conn.AgentName, helper.FormatBytes(metrics.ReclaimedBytes))
+		if err := s.store.AddAgentReclaimed(conn.AgentID, metrics.ReclaimedBytes); err != nil {
+			logger.Warn("[TCP] failed to record reclaimed space for %s: %v", conn.AgentName, err)
+		}
+	}
+
+	if now := time.Now(); now.Sub(conn.sampledAt) >= MetricsSampleInterval {
+		conn.sampledAt = now
+		err := s.store.RecordMetrics(&models.MetricsSample{
+			AgentID:   conn.AgentID,
+			Timestamp: now,
+			CPU:       metrics.System.CPUPercent,
+			Memory:    metrics.System.MemoryPercent,
+			Disk:      metrics.System.DiskPercent,
+		})
+		if err != nil {
+			logger.Error("[TCP] failed to record metrics for %s: %v", conn.AgentName, err)
+		}
+	}
 
 	if s.onMetrics != nil {
 		s.onMetrics(conn.AgentID, agentMetrics)
@@ -327,12 +700,15 @@ func (s *Server) handleMetrics(conn *Connection, msg *protocol.Message) {
 		}
 	}
 
+	present := make([]models.Container, 0, len(metrics.Containers))
 	for _, c := range metrics.Containers {
 		container := &models.Container{
 			ID:           c.ID,
 			AgentID:      conn.AgentID,
 			Name:         c.Name,
 			Image:        c.Image,
+			Project:      c.Project,
+			Service:      c.Service,
 			Status:       c.Status,
 			Health:       models.ContainerHealth(c.Health),
 			CPUPercent:   c.CPUPercent,
@@ -342,35 +718,58 @@ func (s *Server) handleMetrics(conn *Connection, msg *protocol.Message) {
 			NetworkTx:    c.NetworkTx,
 			RestartCount: c.RestartCount,
 			StartedAt:    time.Unix(c.StartedAt, 0),
+			StatsStale:   c.StatsStale,
 		}
 		s.store.UpsertContainer(container)
+		present = append(present, *container)
 
-		alertMsg := fmt.Sprintf("Container %s is not running", c.Name)
+		alertMsg := logic.ContainerDownMessage(c.Name, c.Project, c.Service)
 
 		if c.Status == "running" {
 			if alert, exists := activeAlertMap[alertMsg]; exists {
-				s.store.ResolveAlert(alert.ID)
+				if err := s.store.AutoResolveAlert(alert.ID); err == nil {
+					logger.Info("[TCP] container %s on %s is running again, alert resolved", c.Name, conn.AgentName)
+				}
 				delete(activeAlertMap, alertMsg)
 			}
 		} else if c.Status != "created" && c.Status != "starting" && c.Status != "restarting" {
 			if _, exists := activeAlertMap[alertMsg]; !exists {
-				if alert := logic.CheckContainerDown(conn.AgentID, conn.AgentName, c.Name); alert != nil {
-					s.store.CreateAlert(alert)
+				if alert := logic.CheckContainerDown(conn.AgentID, conn.AgentName, c.Name, c.Project, c.Service); alert != nil {
+					s.raiseAlert(alert)
 					activeAlertMap[alert.Message] = alert
 				}
 			}
 		}
 	}
 
+	if metrics.Inventory {
+		if err := s.store.SyncContainers(conn.AgentID, present); err != nil {
+			logger.Error("[TCP] failed to sync containers for %s: %v", conn.AgentName, err)
+		}
+	}
+
 	createIfNotExists := func(alert *models.Alert) {
 		if alert != nil {
 			if _, exists := activeAlertMap[alert.Message]; !exists {
-				s.store.CreateAlert(alert)
+				s.raiseAlert(alert)
 				activeAlertMap[alert.Message] = alert
 			}
 		}
 	}
 
+	resolveIfRecovered := func(alertType string, recovered bool) {
+		if !recovered {
+			return
+		}
+		if n, err := s.store.ResolveAlertsByTypeAndAgent(conn.AgentID, alertType); err == nil && n > 0 {
+			logger.Info("[TCP] %s cleared on %s, resolved %d alert(s)", alertType, conn.AgentName, n)
+		}
+	}
+
+	resolveIfRecovered("high_cpu", logic.CPURecovered(metrics.System.CPUPercent))
+	resolveIfRecovered("high_memory", logic.MemoryRecovered(metrics.System.MemoryPercent))
+	resolveIfRecovered("high_disk", logic.DiskRecovered(metrics.System.DiskPercent))
+
 	createIfNotExists(logic.CheckCPU(conn.AgentID, conn.AgentName, metrics.System.CPUPercent))
 	createIfNotExists(logic.CheckMemory(conn.AgentID, conn.AgentName, metrics.System.MemoryPercent))
 	createIfNotExists(logic.CheckDisk(conn.AgentID, conn.AgentName, metrics.System.DiskPercent))
@@ -384,12 +783,22 @@ func (s *Server) handleCommandAck(conn *Connection, msg *protocol.Message) {
 		return
 	}
 
+	detail := models.DetailAcknowledged
+	if ack.Status == protocol.AckQueued && ack.Detail != "" {
+		detail = ack.Detail
+	}
+
 	deploy, _ := s.store.GetDeployment(ack.CommandID)
-	if deploy != nil && deploy.Status == models.DeployPending {
-		deploy.Status = models.DeployRunning
+	if deploy != nil && deploy.Status == models.DeployPending && deploy.StatusDetail != detail {
+		deploy.StatusDetail = detail
 		s.store.UpdateDeployment(deploy)
+		s.deploymentEvent(DeploymentEvent{Kind: DeploymentAck, DeploymentID: deploy.ID, Repository: deploy.Repository, Status: deploy.Status})
 	}
 
+	if ack.Status == protocol.AckQueued {
+		logger.Info("[TCP] agent %s queued command %s: %s", conn.AgentName, ack.CommandID, ack.Detail)
+		return
+	}
 	logger.Info("[TCP] agent %s acknowledged command %s", conn.AgentName, ack.CommandID)
 }
 
@@ -398,11 +807,16 @@ func (s *Server) handleCommandStart(conn *Connection, msg *protocol.Message) {
 	if err := msg.Decode(&start); err != nil {
 		return
 	}
+	if s.execStarted(start.CommandID) {
+		return
+	}
 
 	deploy, _ := s.store.GetDeployment(start.CommandID)
 	if deploy != nil {
 		deploy.Status = models.DeployRunning
+		deploy.StatusDetail = ""
 		s.store.UpdateDeployment(deploy)
+		s.deploymentEvent(DeploymentEvent{Kind: DeploymentStart, DeploymentID: deploy.ID, Repository: deploy.Repository, Status: deploy.Status})
 	}
 	logger.Info("[TCP] agent %s started deployment %s", conn.AgentName, start.CommandID)
 }
@@ -412,6 +826,9 @@ func (s *Server) handleCommandLog(conn *Connection, msg *protocol.Message) {
 	if err := msg.Decode(&logPayload); err != nil {
 		return
 	}
+	if s.execLog(logPayload.CommandID, logPayload.Line) {
+		return
+	}
 
 	cmdLog := &models.DeploymentLog{
 		DeploymentID: logPayload.CommandID,
@@ -420,7 +837,7 @@ func (s *Server) handleCommandLog(conn *Connection, msg *protocol.Message) {
 		Timestamp:    time.Unix(logPayload.Timestamp, 0),
 	}
 
-	s.store.AddDeploymentLog(cmdLog)
+	s.logs.Add(*cmdLog)
 
 	if s.onLog != nil {
 		legacyLog := &models.CommandLog{
@@ -433,30 +850,116 @@ func (s *Server) handleCommandLog(conn *Connection, msg *protocol.Message) {
 	}
 }
 
+func (s *Server) handleCommandStep(conn *Connection, msg *protocol.Message) {
+	var step protocol.CommandStepPayload
+	if err := msg.Decode(&step); err != nil {
+		return
+	}
+
+	err := s.store.AddDeploymentStep(&models.DeploymentStep{
+		DeploymentID: step.CommandID,
+		Name:         step.Step,
+		Status:       step.Status,
+		StartedAt:    time.Unix(step.StartedAt, 0),
+		Duration:     step.DurationMs,
+	})
+	if err != nil {
+		logger.Warn("[TCP] failed to store step %s for %s: %v", step.Step, step.CommandID, err)
+		return
+	}
+	s.deploymentEvent(DeploymentEvent{Kind: DeploymentStep, DeploymentID: step.CommandID})
+}
+
+func (s *Server) recordContainers(deploy *models.Deployment, deployed []protocol.DeployedContainer) bool {
+	containers := make([]models.DeployedContainer, 0, len(deployed))
+	for _, c := range deployed {
+		containers = append(containers, models.DeployedContainer{
+			DeploymentID: deploy.ID,
+			Name:         c.Name,
+			Service:      c.Service,
+			Image:        c.Image,
+			ImageID:      c.ImageID,
+		})
+	}
+	if err := s.store.AddDeploymentContainers(deploy.ID, containers); err != nil {
+		logger.Error("[TCP] failed to record containers of deployment %s: %v", deploy.ID, err)
+		return false
+	}
+
+	history, err := s.store.GetDeploymentsByRepo(deploy.Repository, 20)
+	if err != nil {
+		return false
+	}
+	for _, prev := range history {
+		if prev.ID == deploy.ID || !prev.Status.Succeeded() {
+			continue
+		}
+		previous, err := s.store.GetDeploymentContainers(prev.ID)
+		if err != nil || !models.SameImages(previous, containers) {
+			return false
+		}
+		logger.Info("[TCP] deployment %s of %s runs the same images as %s", deploy.ID, deploy.Repository, prev.ID)
+		s.store.AddDeploymentLog(&models.DeploymentLog{
+			DeploymentID: deploy.ID,
+			Line:         "no image change: containers run the same images as deployment " + prev.ID,
+			Stream:       "stdout",
+			Timestamp:    time.Now(),
+		})
+		return true
+	}
+	return false
+}
+
 func (s *Server) handleCommandDone(conn *Connection, msg *protocol.Message) {
 	var done protocol.CommandDonePayload
 	if err := msg.Decode(&done); err != nil {
 		return
 	}
 
+	s.pendingMu.Lock()
+	if ch, ok := s.pending[done.CommandID]; ok {
+		ch <- done
+		delete(s.pending, done.CommandID)
+	}
+	s.pendingMu.Unlock()
+
+	if s.finishExec(done.CommandID, done.Status, done.ExitCode, done.Output) {
+		logger.Info("[TCP] agent %s completed command %s: %s (exit %d)", conn.AgentName, done.CommandID, done.Status, done.ExitCode)
+		return
+	}
+
+	s.logs.Flush()
+	s.dropArtifacts(done.CommandID)
+
 	deploy, _ := s.store.GetDeployment(done.CommandID)
 	if deploy != nil {
-		status := models.DeploySuccess
-		if done.Status != "success" {
+		status := models.DeployStatus(done.Status)
+		if !status.Succeeded() && (status != models.DeployDryRun || !deploy.DryRun) {
 			status = models.DeployFailed
 		}
 
 		now := time.Now()
 		deploy.Status = status
+		deploy.StatusDetail = ""
 		deploy.Output = done.Output
 		deploy.EndedAt = &now
 		deploy.Duration = int64(now.Sub(deploy.StartedAt) / time.Millisecond)
-
-		s.store.UpdateDeployment(deploy)
+		if done.Commit != "" && (deploy.Commit == "" || deploy.Commit == "HEAD") {
+			deploy.Commit = done.Commit
+		}
+		deploy.ConfigHash = done.ConfigHash
+		deploy.ChangeSummary = done.ChangeSummary
+		deploy.ExitCode = &done.ExitCode
+		if done.BuildFile != "" {
+			deploy.BuildFile = done.BuildFile
+		}
+		if status.Succeeded() && len(done.Containers) > 0 {
+			deploy.ImageUnchanged = s.recordContainers(deploy, done.Containers)
+		}
 
 		if done.Output != "" {
 			streamType := "stdout"
-			if status == models.DeployFailed {
+			if status != models.DeploySuccess && status != models.DeployDryRun {
 				streamType = "stderr"
 			}
 			cmdLog := &models.DeploymentLog{
@@ -467,9 +970,63 @@ func (s *Server) handleCommandDone(conn *Connection, msg *protocol.Message) {
 			}
 			s.store.AddDeploymentLog(cmdLog)
 		}
+
+		s.store.UpdateDeployment(deploy)
+		s.deployDone(deploy)
+
+		if status.Succeeded() {
+			s.store.SetRepositoryDrift(deploy.Repository, nil)
+			s.resolveDriftAlert(conn, deploy.Repository)
+			s.resolveDeployAlert(deploy.AgentID, deploy.Repository)
+		} else {
+			s.deployFailed(deploy)
+		}
+
+		if !deploy.DryRun {
+			if err := s.RequestInventory(conn.AgentID); err != nil {
+				logger.Debug("[TCP] inventory refresh after deployment %s skipped: %v", deploy.ID, err)
+			}
+		}
+	}
+
+	logger.Info("[TCP] agent %s completed deployment %s: %s (exit %d)", conn.AgentName, done.CommandID, done.Status, done.ExitCode)
+}
+
+func (s *Server) handleDriftReport(conn *Connection, msg *protocol.Message) {
+	var report protocol.DriftReportPayload
+	if err := msg.Decode(&report); err != nil {
+		return
+	}
+
+	if err := s.store.SetRepositoryDrift(report.Repository, report.Findings); err != nil {
+		logger.Error("[TCP] failed to store drift for %s: %v", report.Repository, err)
+	}
+
+	if len(report.Findings) == 0 {
+		s.resolveDriftAlert(conn, report.Repository)
+		return
+	}
+
+	logger.Warn("[TCP] agent %s reported drift in %s: %d findings", conn.AgentName, report.Repository, len(report.Findings))
+
+	alert := logic.CheckDrift(conn.AgentID, conn.AgentName, report.Repository)
+	activeAlerts, _ := s.store.GetAlertsByAgent(conn.AgentID)
+	for _, a := range activeAlerts {
+		if !a.Resolved && a.Message == alert.Message {
+			return
+		}
 	}
+	s.raiseAlert(alert)
+}
 
-	logger.Info("[TCP] agent %s completed deployment %s: %s", conn.AgentName, done.CommandID, done.Status)
+func (s *Server) resolveDriftAlert(conn *Connection, repoName string) {
+	alert := logic.CheckDrift(conn.AgentID, conn.AgentName, repoName)
+	activeAlerts, _ := s.store.GetAlertsByAgent(conn.AgentID)
+	for _, a := range activeAlerts {
+		if !a.Resolved && a.Message == alert.Message {
+			s.store.AutoResolveAlert(a.ID)
+		}
+	}
 }
 
 func (s *Server) pingService() {
@@ -485,6 +1042,138 @@ func (s *Server) pingService() {
 	}
 }
 
+func (s *Server) reapService() {
+	ticker := time.NewTicker(ReapInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-ticker.C:
+			s.reapDeployments()
+			s.failUnacknowledged()
+			s.expireApprovals()
+			s.expireLogStreams()
+			s.CloseRetiredSessions()
+		}
+	}
+}
+
+func (s *Server) reapDeployments() {
+	if s.cfg.Server.DeployTimeoutMin <= 0 {
+		return
+	}
+
+	now := time.Now()
+	limit := time.Duration(s.cfg.Server.DeployTimeoutMin) * time.Minute
+	stale, err := s.store.GetStaleDeployments(now.Add(-limit), now.Add(-ReapQuiet))
+	if err != nil {
+		logger.Error("[TCP] failed to look up stale deployments: %v", err)
+		return
+	}
+
+	for i := range stale {
+		d := &stale[i]
+		d.Status = models.DeployFailed
+		d.Output = "failed (agent lost)"
+		d.EndedAt = &now
+		d.Duration = int64(now.Sub(d.StartedAt) / time.Millisecond)
+		if err := s.store.UpdateDeployment(d); err != nil {
+			logger.Error("[TCP] failed to mark deployment %s as lost: %v", d.ID, err)
+			continue
+		}
+		s.store.AddDeploymentLog(&models.DeploymentLog{
+			DeploymentID: d.ID,
+			Line:         fmt.Sprintf("no progress from agent for %s, marking deployment as failed", ReapQuiet),
+			Stream:       "stderr",
+			Timestamp:    now,
+		})
+		logger.Warn("[TCP] deployment %s (%s) marked failed: agent lost", d.ID, d.Repository)
+		s.deployDone(d)
+		s.deployFailed(d)
+	}
+}
+
+func (s *Server) failUnacknowledged() {
+	timeout := s.cfg.AckTimeout()
+	if timeout <= 0 {
+		return
+	}
+
+	pending, err := s.store.GetDeploymentsByStatus(models.DeployPending)
+	if err != nil {
+		logger.Error("[TCP] failed to look up pending deployments: %v", err)
+		return
+	}
+
+	now := time.Now()
+	for i := range pending {
+		d := &pending[i]
+		if d.StatusDetail != models.DetailSent || now.Sub(d.StartedAt) < timeout {
+			continue
+		}
+		d.Status = models.DeployFailed
+		d.StatusDetail = models.DetailNoAck
+		d.Output = fmt.Sprintf("%s within %s", models.DetailNoAck, timeout)
+		d.EndedAt = &now
+		d.Duration = int64(now.Sub(d.StartedAt) / time.Millisecond)
+		if err := s.store.UpdateDeployment(d); err != nil {
+			logger.Error("[TCP] failed to mark deployment %s as unacknowledged: %v", d.ID, err)
+			continue
+		}
+		s.store.AddDeploymentLog(&models.DeploymentLog{
+			DeploymentID: d.ID,
+			Line:         d.Output + ", marking deployment as failed",
+			Stream:       "stderr",
+			Timestamp:    now,
+		})
+		logger.Warn("[TCP] deployment %s (%s) marked failed: %s", d.ID, d.Repository, models.DetailNoAck)
+		s.deployDone(d)
+		s.deployFailed(d)
+	}
+}
+
+func (s *Server) expireApprovals() {
+	window := s.cfg.ApprovalWindow()
+	if window <= 0 {
+		return
+	}
+
+	waiting, err := s.store.GetDeploymentsByStatus(models.DeployAwaitingApproval)
+	if err != nil {
+		logger.Error("[TCP] failed to look up deployments awaiting approval: %v", err)
+		return
+	}
+
+	now := time.Now()
+	for i := range waiting {
+		d := &waiting[i]
+		if now.Sub(d.StartedAt) < window {
+			continue
+		}
+		d.Status = models.DeployRejected
+		d.StatusDetail = ""
+		d.Output = fmt.Sprintf("approval expired after %dh", s.cfg.Server.ApprovalHours)
+		d.EndedAt = &now
+		ok, err := s.store.RejectDeployment(d)
+		if err != nil {
+			logger.Error("[TCP] failed to expire deployment %s: %v", d.ID, err)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		s.store.AddDeploymentLog(&models.DeploymentLog{
+			DeploymentID: d.ID,
+			Line:         d.Output,
+			Stream:       "stderr",
+			Timestamp:    now,
+		})
+		logger.Warn("[TCP] deployment %s (%s) rejected: approval expired", d.ID, d.Repository)
+		s.deployDone(d)
+	}
+}
+
 func (s *Server) pingAll() {
 	s.mu.RLock()
 	conns := make([]*Connection, 0, len(s.connections))
@@ -493,42 +1182,161 @@ func (s *Server) pingAll() {
 	}
 	s.mu.RUnlock()
 
+	var timedOut []*Connection
 	for _, conn := range conns {
 		if time.Since(conn.LastPing) > PongTimeout {
 			logger.Warn("[TCP] agent %s ping timeout, disconnecting", conn.AgentName)
-			s.removeConnection(conn.AgentID)
+			timedOut = append(timedOut, conn)
 			continue
 		}
+		if stats := conn.Stats(); stats.QueueDepth >= SendQueueSize/2 {
+			lastWrite := "never"
+			if !stats.LastOut.IsZero() {
+				lastWrite = time.Since(stats.LastOut).Round(time.Second).String() + " ago"
+			}
+			logger.Warn("[TCP] agent %s is not keeping up: %d/%d messages queued, %d dropped, %d write errors, last write %s",
+				conn.AgentName, stats.QueueDepth, SendQueueSize, stats.Dropped, stats.WriteErrors, lastWrite)
+		}
 		conn.Send(protocol.Ping())
 	}
+	s.closeConnections(timedOut, DisconnectPingTimeout)
+}
+
+func (s *Server) CloseRetiredSessions() {
+	now := time.Now()
+	s.mu.RLock()
+	var retired []*Connection
+	for agentID, conn := range s.connections {
+		agent := s.cfg.GetAgent(agentID)
+		if agent == nil || conn.TokenHash == "" || conn.TokenHash == agent.TokenHash {
+			continue
+		}
+		if conn.TokenHash == agent.PreviousTokenHash && now.Before(agent.PreviousTokenExpires) {
+			continue
+		}
+		retired = append(retired, conn)
+	}
+	s.mu.RUnlock()
+
+	for _, conn := range retired {
+		logger.Warn("[TCP] agent %s is connected with a retired token, disconnecting", conn.AgentName)
+	}
+	s.closeConnections(retired, DisconnectTokenRetired)
+}
+
+func (s *Server) closeConnections(conns []*Connection, reason string) {
+	var wg sync.WaitGroup
+	for _, conn := range conns {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			conn.CloseWithReason(reason)
+			s.removeConnection(conn)
+		}()
+	}
+	wg.Wait()
+}
+
+func (s *Server) SendQueueStats(agentID string) (depth, dropped int, ok bool) {
+	s.mu.RLock()
+	conn, exists := s.connections[agentID]
+	s.mu.RUnlock()
+	if !exists {
+		return 0, 0, false
+	}
+	depth, dropped = conn.QueueStats()
+	return depth, dropped, true
+}
+
+func (s *Server) GetConnectionStats(agentID string) (ConnectionStats, bool) {
+	s.mu.RLock()
+	conn, exists := s.connections[agentID]
+	s.mu.RUnlock()
+	if !exists {
+		return ConnectionStats{}, false
+	}
+	return conn.Stats(), true
 }
 
 func (s *Server) addConnection(agentID string, conn *Connection) {
 	s.mu.Lock()
-	defer s.mu.Unlock()
-	if old, exists := s.connections[agentID]; exists {
-		old.Close()
-	}
+	old, exists := s.connections[agentID]
 	s.connections[agentID] = conn
+	timer, pending := s.offlineTimers[agentID]
+	if pending {
+		timer.Stop()
+		delete(s.offlineTimers, agentID)
+	}
+	s.mu.Unlock()
+
+	if pending {
+		logger.Info("[TCP] agent %s reconnected within the offline grace period", conn.AgentName)
+	}
+
+	if exists && old != conn {
+		logger.Info("[TCP] agent %s reconnected, closing previous session %s", conn.AgentName, old.ID)
+		old.CloseWithReason(DisconnectSuperseded)
+		s.interruptAgentLogStreams(agentID)
+	}
+	s.resumeAgentLogStreams(agentID, conn)
+
+	s.store.UpdateAgentStatus(agentID, models.AgentOnline)
+	if n, err := s.store.ResolveAlertsByTypeAndAgent(agentID, "agent_offline"); err == nil && n > 0 {
+		logger.Info("[TCP] agent %s is back online, resolved %d offline alert(s)", conn.AgentName, n)
+	}
 }
 
-func (s *Server) removeConnection(agentID string) {
+func (s *Server) removeConnection(conn *Connection) {
+	conn.Close()
+
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	if conn, exists := s.connections[agentID]; exists {
-		conn.Close()
+	agentID := conn.AgentID
+	if current, exists := s.connections[agentID]; exists && current == conn {
 		delete(s.connections, agentID)
+		s.interruptAgentLogStreams(agentID)
+		s.abandonExecs(agentID)
 
 		s.store.UpdateAgentStatus(agentID, models.AgentOffline)
-
-		if alert := logic.CheckOffline(agentID, conn.AgentName); alert != nil {
-			s.store.CreateAlert(alert)
-		}
+		s.scheduleOfflineAlert(agentID, conn.AgentName)
 
 		logger.Warn("[TCP] agent %s disconnected", conn.AgentName)
 	}
 }
 
+func (s *Server) scheduleOfflineAlert(agentID, agentName string) {
+	select {
+	case <-s.done:
+		return
+	default:
+	}
+
+	if timer, exists := s.offlineTimers[agentID]; exists {
+		timer.Stop()
+	}
+
+	var timer *time.Timer
+	timer = time.AfterFunc(time.Duration(s.cfg.Server.OfflineGraceSec)*time.Second, func() {
+		s.mu.Lock()
+		current, exists := s.offlineTimers[agentID]
+		if !exists || current != timer {
+			s.mu.Unlock()
+			return
+		}
+		delete(s.offlineTimers, agentID)
+		_, connected := s.connections[agentID]
+		s.mu.Unlock()
+
+		if connected {
+			return
+		}
+		if alert := logic.CheckOffline(agentID, agentName); alert != nil {
+			s.raiseAlert(alert)
+		}
+	})
+	s.offlineTimers[agentID] = timer
+}
+
 func (s *Server) IsAgentConnected(agentID string) bool {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
@@ -542,7 +1350,7 @@ func (s *Server) SendCommand(agentID string, cmd *models.Command) error {
 	s.mu.RUnlock()
 
 	if !exists {
-		return fmt.Errorf("agent not connected")
+		return s.notConnected()
 	}
 
 	cmdMsg, err := protocol.NewMessage(protocol.TypeCommand, protocol.CommandPayload{
@@ -557,36 +1365,44 @@ func (s *Server) SendCommand(agentID string, cmd *models.Command) error {
 	return conn.Send(cmdMsg)
 }
 
-func (s *Server) StreamContainerLogs(agentID, containerID string, tail int, follow bool) error {
-	s.mu.RLock()
-	conn, exists := s.connections[agentID]
-	s.mu.RUnlock()
+func (s *Server) notConnected() error {
+	if pid := s.cfg.Owner(); pid != 0 {
+		return fmt.Errorf("agent is connected to the server running as pid %d", pid)
+	}
+	return fmt.Errorf("agent not connected")
+}
 
-	if !exists {
-		return fmt.Errorf("agent not connected")
+func (s *Server) SendContainerAction(agentID, containerID, action string) (<-chan protocol.CommandDonePayload, error) {
+	switch action {
+	case "start", "stop", "restart":
+	default:
+		return nil, fmt.Errorf("unsupported container action: %s", action)
 	}
 
-	msg, _ := protocol.NewMessage(protocol.TypeContainerLogsRequest, protocol.ContainerLogsRequestPayload{
-		ContainerID: containerID,
-		Tail:        tail,
-		Follow:      follow,
-	})
-	return conn.Send(msg)
-}
+	cmd := &models.Command{
+		ID:      helper.GenerateID(),
+		Type:    "container_action",
+		AgentID: agentID,
+		Payload: map[string]interface{}{
+			"container_id": containerID,
+			"action":       action,
+		},
+	}
 
-func (s *Server) StopContainerLogs(agentID, containerID string) error {
-	s.mu.RLock()
-	conn, exists := s.connections[agentID]
-	s.mu.RUnlock()
+	result := make(chan protocol.CommandDonePayload, 1)
+	s.pendingMu.Lock()
+	s.pending[cmd.ID] = result
+	s.pendingMu.Unlock()
 
-	if !exists {
-		return nil
+	if err := s.SendCommand(agentID, cmd); err != nil {
+		s.pendingMu.Lock()
+		delete(s.pending, cmd.ID)
+		s.pendingMu.Unlock()
+		return nil, err
 	}
 
-	msg, _ := protocol.NewMessage(protocol.TypeContainerLogsStop, protocol.ContainerLogsStopPayload{
-		ContainerID: containerID,
-	})
-	return conn.Send(msg)
+	logger.Info("[TCP] sent %s for container %s to agent %s", action, containerID, agentID)
+	return result, nil
 }
 
 func (s *Server) GetConnectedAgents() []string {
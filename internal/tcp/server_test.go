@@ -0,0 +1,836 @@
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+
+package tcp
+
+import (
+	"fmt"
+	"net"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/urustack/uruflow/internal/config"
+	"github.com/urustack/uruflow/internal/logic"
+	"github.com/urustack/uruflow/internal/models"
+	"github.com/urustack/uruflow/internal/storage"
+	"github.com/urustack/uruflow/internal/storage/sqlite"
+	"github.com/urustack/uruflow/internal/tcp/protocol"
+)
+
+func newTestServer(t *testing.T) (*Server, storage.Store) {
+	t.Helper()
+	store, err := sqlite.New(t.TempDir())
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return NewServer(config.Default(), store), store
+}
+
+func newTestConnection(t *testing.T, agentID, agentName string) *Connection {
+	t.Helper()
+	local, remote := net.Pipe()
+	t.Cleanup(func() {
+		local.Close()
+		remote.Close()
+	})
+	go func() {
+		buf := make([]byte, 4096)
+		for {
+			if _, err := remote.Read(buf); err != nil {
+				return
+			}
+		}
+	}()
+	conn := NewConnection("conn-"+agentID, local)
+	conn.AgentID = agentID
+	conn.AgentName = agentName
+	return conn
+}
+
+func TestCommandDoneRecordsResolvedCommitForManualDeploy(t *testing.T) {
+	s, store := newTestServer(t)
+	if err := store.CreateAgent(&models.Agent{ID: "agent-1", Name: "web", Status: models.AgentOnline}); err != nil {
+		t.Fatalf("create agent: %v", err)
+	}
+	deploy := &models.Deployment{
+		ID:         "deploy-1",
+		Repository: "api",
+		Branch:     "main",
+		Commit:     "HEAD",
+		AgentID:    "agent-1",
+		AgentName:  "web",
+		Status:     models.DeployRunning,
+		Trigger:    "manual",
+		StartedAt:  time.Now(),
+	}
+	if err := store.CreateDeployment(deploy); err != nil {
+		t.Fatalf("create deployment: %v", err)
+	}
+
+	const resolved = "4f2a9c1e8b7d6a5f4e3d2c1b0a9f8e7d6c5b4a39"
+	msg, err := protocol.NewMessage(protocol.TypeCommandDone, protocol.CommandDonePayload{
+		CommandID: deploy.ID,
+		Status:    string(models.DeploySuccess),
+		Commit:    resolved,
+	})
+	if err != nil {
+		t.Fatalf("new message: %v", err)
+	}
+	s.handleCommandDone(newTestConnection(t, "agent-1", "web"), msg)
+
+	stored, err := store.GetDeployment(deploy.ID)
+	if err != nil {
+		t.Fatalf("get deployment: %v", err)
+	}
+	if stored.Status != models.DeploySuccess {
+		t.Fatalf("status = %s, want %s", stored.Status, models.DeploySuccess)
+	}
+	if stored.Commit != resolved {
+		t.Fatalf("commit = %q, want %q", stored.Commit, resolved)
+	}
+}
+
+func TestUnacknowledgedDeployEmitsDoneEvent(t *testing.T) {
+	s, store := newTestServer(t)
+	if err := store.CreateAgent(&models.Agent{ID: "agent-1", Name: "web", Status: models.AgentOnline}); err != nil {
+		t.Fatalf("create agent: %v", err)
+	}
+	if err := store.CreateDeployment(&models.Deployment{
+		ID:           "deploy-1",
+		Repository:   "api",
+		Branch:       "main",
+		AgentID:      "agent-1",
+		AgentName:    "web",
+		Status:       models.DeployPending,
+		StatusDetail: models.DetailSent,
+		StartedAt:    time.Now().Add(-time.Hour),
+	}); err != nil {
+		t.Fatalf("create deployment: %v", err)
+	}
+
+	var events []DeploymentEvent
+	s.SetDeploymentHandler(func(e DeploymentEvent) { events = append(events, e) })
+	s.failUnacknowledged()
+
+	if len(events) != 1 {
+		t.Fatalf("got %d events, want 1", len(events))
+	}
+	if e := events[0]; e.Kind != DeploymentDone || e.DeploymentID != "deploy-1" || e.Status != models.DeployFailed {
+		t.Fatalf("event = %+v, want a failed done event for deploy-1", e)
+	}
+}
+
+func TestPollDeploymentsEmitsChanges(t *testing.T) {
+	s, store := newTestServer(t)
+	if err := store.CreateAgent(&models.Agent{ID: "agent-1", Name: "web", Status: models.AgentOnline}); err != nil {
+		t.Fatalf("create agent: %v", err)
+	}
+	deploy := &models.Deployment{
+		ID:         "deploy-1",
+		Repository: "api",
+		Branch:     "main",
+		AgentID:    "agent-1",
+		Status:     models.DeployRunning,
+		StartedAt:  time.Now(),
+	}
+	if err := store.CreateDeployment(deploy); err != nil {
+		t.Fatalf("create deployment: %v", err)
+	}
+
+	var events []DeploymentEvent
+	s.SetDeploymentHandler(func(e DeploymentEvent) { events = append(events, e) })
+	seen := make(map[string]*watchedDeployment)
+	s.pollDeployments(seen, false)
+	if len(events) != 0 {
+		t.Fatalf("initial poll emitted %d events", len(events))
+	}
+
+	store.AddDeploymentLog(&models.DeploymentLog{DeploymentID: deploy.ID, Line: "building", Stream: "stdout", Timestamp: time.Now()})
+	now := time.Now()
+	deploy.Status = models.DeploySuccess
+	deploy.EndedAt = &now
+	if err := store.UpdateDeployment(deploy); err != nil {
+		t.Fatalf("update deployment: %v", err)
+	}
+	s.pollDeployments(seen, true)
+
+	if len(events) != 1 {
+		t.Fatalf("got %d events, want 1: %+v", len(events), events)
+	}
+	if e := events[0]; e.Kind != DeploymentDone || e.Status != models.DeploySuccess {
+		t.Fatalf("event = %+v, want a success done event", e)
+	}
+}
+
+func TestProbeDoesNotReplaceLiveSession(t *testing.T) {
+	s, store := newTestServer(t)
+	agentID, token, err := s.cfg.AddAgent("web")
+	if err != nil {
+		t.Fatalf("add agent: %v", err)
+	}
+	live := newTestConnection(t, agentID, "web")
+	s.connections[agentID] = live
+
+	client, server := net.Pipe()
+	defer client.Close()
+	finished := make(chan struct{})
+	go func() {
+		s.handleConnection(server)
+		close(finished)
+	}()
+
+	auth, _ := protocol.NewMessage(protocol.TypeAuth, protocol.AuthPayload{Token: token, Hostname: "web", Probe: true})
+	if err := protocol.NewWriter(client).WriteWithTimeout(auth, time.Second); err != nil {
+		t.Fatalf("send auth: %v", err)
+	}
+	resp, err := protocol.NewReader(client).ReadWithTimeout(time.Second)
+	if err != nil {
+		t.Fatalf("read auth response: %v", err)
+	}
+	if resp.Type != protocol.TypeAuthOK {
+		t.Fatalf("response = %s, want %s", resp.Type, protocol.TypeAuthOK)
+	}
+
+	select {
+	case <-finished:
+	case <-time.After(time.Second):
+		t.Fatal("probe session was kept open")
+	}
+	if s.connections[agentID] != live {
+		t.Fatal("probe replaced the live session")
+	}
+	select {
+	case <-live.Done():
+		t.Fatalf("live session closed: %s", live.CloseReason())
+	default:
+	}
+	if agent, _ := store.GetAgent(agentID); agent != nil {
+		t.Fatalf("probe registered agent %+v", agent)
+	}
+}
+
+func TestCloseRetiredSessions(t *testing.T) {
+	s, _ := newTestServer(t)
+	grace := 1
+	s.cfg.Server.TokenGraceHours = &grace
+
+	current, _, _ := s.cfg.AddAgent("current")
+	previous, _, _ := s.cfg.AddAgent("previous")
+	expired, _, _ := s.cfg.AddAgent("expired")
+
+	sessions := make(map[string]*Connection)
+	for _, id := range []string{current, previous, expired} {
+		agent := s.cfg.GetAgent(id)
+		conn := newTestConnection(t, id, agent.Name)
+		conn.TokenHash = agent.TokenHash
+		s.connections[id] = conn
+		sessions[id] = conn
+	}
+	for _, id := range []string{previous, expired} {
+		if _, err := s.cfg.RotateAgentToken(id); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i := range s.cfg.Agents {
+		if s.cfg.Agents[i].ID == expired {
+			s.cfg.Agents[i].PreviousTokenExpires = time.Now().Add(-time.Minute)
+		}
+	}
+
+	s.CloseRetiredSessions()
+
+	for id, wantOpen := range map[string]bool{current: true, previous: true, expired: false} {
+		conn := sessions[id]
+		_, registered := s.connections[id]
+		if registered != wantOpen {
+			t.Errorf("%s registered = %v, want %v", conn.AgentName, registered, wantOpen)
+		}
+		if !wantOpen && conn.CloseReason() != DisconnectTokenRetired {
+			t.Errorf("%s close reason = %q, want %q", conn.AgentName, conn.CloseReason(), DisconnectTokenRetired)
+		}
+	}
+
+	if _, err := s.cfg.RotateAgentToken(previous); err != nil {
+		t.Fatal(err)
+	}
+	s.CloseRetiredSessions()
+	if _, registered := s.connections[previous]; registered {
+		t.Error("session with a token retired by a second rotation is still registered")
+	}
+}
+
+func stalledConnection(t *testing.T, agentID, agentName string) *Connection {
+	t.Helper()
+	local, remote := net.Pipe()
+	t.Cleanup(func() {
+		local.Close()
+		remote.Close()
+	})
+	conn := NewConnection("conn-"+agentID, local)
+	conn.AgentID = agentID
+	conn.AgentName = agentName
+	conn.Send(protocol.Ping())
+	return conn
+}
+
+func recordingConnection(t *testing.T, agentID, agentName string) (*Connection, <-chan protocol.MessageType) {
+	t.Helper()
+	local, remote := net.Pipe()
+	t.Cleanup(func() {
+		local.Close()
+		remote.Close()
+	})
+	received := make(chan protocol.MessageType, 16)
+	go func() {
+		defer close(received)
+		reader := protocol.NewReader(remote)
+		for {
+			msg, err := reader.Read()
+			if err != nil {
+				return
+			}
+			received <- msg.Type
+		}
+	}()
+	conn := NewConnection("conn-"+agentID, local)
+	conn.AgentID = agentID
+	conn.AgentName = agentName
+	return conn, received
+}
+
+func TestPingAllClosesTimedOutConnectionsConcurrently(t *testing.T) {
+	s, _ := newTestServer(t)
+	var stalled []*Connection
+	for _, name := range []string{"web", "db", "cache"} {
+		conn := stalledConnection(t, "agent-"+name, name)
+		conn.LastPing = time.Now().Add(-PongTimeout - time.Second)
+		s.connections[conn.AgentID] = conn
+		stalled = append(stalled, conn)
+	}
+	healthy, received := recordingConnection(t, "agent-api", "api")
+	s.connections[healthy.AgentID] = healthy
+
+	start := time.Now()
+	s.pingAll()
+	if elapsed := time.Since(start); elapsed > drainTimeout+time.Second {
+		t.Fatalf("pingAll took %s closing %d stalled connections", elapsed, len(stalled))
+	}
+
+	for _, conn := range stalled {
+		if _, registered := s.connections[conn.AgentID]; registered {
+			t.Errorf("%s still registered after a ping timeout", conn.AgentName)
+		}
+		if conn.CloseReason() != DisconnectPingTimeout {
+			t.Errorf("%s close reason = %q", conn.AgentName, conn.CloseReason())
+		}
+	}
+	select {
+	case typ := <-received:
+		if typ != protocol.TypePing {
+			t.Fatalf("healthy agent received %s, want a ping", typ)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("healthy agent was not pinged")
+	}
+}
+
+func TestStopSendsDisconnectBeforeClosing(t *testing.T) {
+	s, _ := newTestServer(t)
+	live, received := recordingConnection(t, "agent-api", "api")
+	s.connections[live.AgentID] = live
+	for _, name := range []string{"web", "db"} {
+		conn := stalledConnection(t, "agent-"+name, name)
+		s.connections[conn.AgentID] = conn
+	}
+	live.Send(protocol.Ping())
+
+	start := time.Now()
+	if err := s.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed > drainTimeout+time.Second {
+		t.Fatalf("Stop took %s", elapsed)
+	}
+
+	var got []protocol.MessageType
+	for typ := range received {
+		got = append(got, typ)
+	}
+	if len(got) != 2 || got[0] != protocol.TypePing || got[1] != protocol.TypeDisconnect {
+		t.Fatalf("agent received %v, want the queued ping and then a disconnect", got)
+	}
+	if live.CloseReason() != DisconnectShutdown {
+		t.Fatalf("close reason = %q, want %q", live.CloseReason(), DisconnectShutdown)
+	}
+}
+
+func TestAuthFloodFromUnknownHostIsStored(t *testing.T) {
+	s, store := newTestServer(t)
+	conn := newTestConnection(t, "", "")
+
+	for range AuthFloodThreshold {
+		s.authFailed(conn, "", "bad-token-value", "invalid token")
+	}
+
+	alerts, err := store.GetActiveAlerts()
+	if err != nil {
+		t.Fatalf("GetActiveAlerts: %v", err)
+	}
+	if len(alerts) != 1 || alerts[0].Type != "auth_flood" || alerts[0].AgentID != "" || alerts[0].AgentName != "unknown" {
+		t.Fatalf("alerts = %+v, want one auth_flood alert without an agent", alerts)
+	}
+}
+
+func finishDeployment(t *testing.T, s *Server, store storage.Store, conn *Connection, id string, status models.DeployStatus, output string) {
+	t.Helper()
+	deploy := &models.Deployment{
+		ID:         id,
+		Repository: "api",
+		Branch:     "main",
+		Commit:     "4f2a9c1e8b7d6a5f4e3d2c1b0a9f8e7d6c5b4a39",
+		AgentID:    conn.AgentID,
+		AgentName:  conn.AgentName,
+		Status:     models.DeployRunning,
+		Trigger:    "webhook",
+		StartedAt:  time.Now(),
+	}
+	if err := store.CreateDeployment(deploy); err != nil {
+		t.Fatalf("create deployment: %v", err)
+	}
+	msg, err := protocol.NewMessage(protocol.TypeCommandDone, protocol.CommandDonePayload{
+		CommandID: id,
+		Status:    string(status),
+		Output:    output,
+	})
+	if err != nil {
+		t.Fatalf("new message: %v", err)
+	}
+	s.handleCommandDone(conn, msg)
+}
+
+func deployFailedAlerts(t *testing.T, store storage.Store, agentID string) []models.Alert {
+	t.Helper()
+	alerts, err := store.GetAlertsByAgent(agentID)
+	if err != nil {
+		t.Fatalf("get alerts: %v", err)
+	}
+	var failed []models.Alert
+	for _, a := range alerts {
+		if a.Type == "deploy_failed" {
+			failed = append(failed, a)
+		}
+	}
+	return failed
+}
+
+func TestFailedDeploymentsRaiseOneEscalatingAlert(t *testing.T) {
+	s, store := newTestServer(t)
+	if err := store.CreateAgent(&models.Agent{ID: "agent-1", Name: "web", Status: models.AgentOnline}); err != nil {
+		t.Fatalf("create agent: %v", err)
+	}
+	var notified []*models.Alert
+	s.SetDeployFailedHandler(func(a *models.Alert) { notified = append(notified, a) })
+	conn := newTestConnection(t, "agent-1", "web")
+
+	for i := 1; i <= logic.DeployFailCriticalAfter; i++ {
+		finishDeployment(t, s, store, conn, fmt.Sprintf("deploy-%d", i), models.DeployFailed, "exit status 1")
+
+		alerts := deployFailedAlerts(t, store, "agent-1")
+		if len(alerts) != 1 {
+			t.Fatalf("after %d failures: %d deploy alerts, want 1", i, len(alerts))
+		}
+		want := models.SeverityWarning
+		if i >= logic.DeployFailCriticalAfter {
+			want = models.SeverityCritical
+		}
+		if alerts[0].Severity != want {
+			t.Errorf("after %d failures: severity = %s, want %s", i, alerts[0].Severity, want)
+		}
+		if alerts[0].Resolved {
+			t.Fatalf("after %d failures: alert is resolved", i)
+		}
+	}
+	if len(notified) != logic.DeployFailCriticalAfter {
+		t.Errorf("notified %d times, want %d", len(notified), logic.DeployFailCriticalAfter)
+	}
+
+	s.deployFailed(&models.Deployment{ID: "deploy-dry", Repository: "api", AgentID: "agent-1", DryRun: true})
+	if len(notified) != logic.DeployFailCriticalAfter {
+		t.Errorf("a failed dry run raised a deploy alert")
+	}
+
+	finishDeployment(t, s, store, conn, "deploy-ok", models.DeploySuccess, "")
+	alerts := deployFailedAlerts(t, store, "agent-1")
+	if len(alerts) != 1 || !alerts[0].Resolved {
+		t.Fatalf("deploy alerts after success = %+v, want one resolved alert", alerts)
+	}
+}
+
+func sendAck(t *testing.T, s *Server, conn *Connection, ack protocol.CommandAckPayload) {
+	t.Helper()
+	msg, err := protocol.NewMessage(protocol.TypeCommandAck, ack)
+	if err != nil {
+		t.Fatalf("new message: %v", err)
+	}
+	s.handleCommandAck(conn, msg)
+}
+
+func TestCommandAckUpdatesStatusDetail(t *testing.T) {
+	s, store := newTestServer(t)
+	if err := store.CreateAgent(&models.Agent{ID: "agent-1", Name: "web", Status: models.AgentOnline}); err != nil {
+		t.Fatalf("create agent: %v", err)
+	}
+	if err := store.CreateDeployment(&models.Deployment{
+		ID: "deploy-2", Repository: "api", Branch: "main", AgentID: "agent-1", AgentName: "web",
+		Status: models.DeployPending, StatusDetail: models.DetailSent, StartedAt: time.Now(),
+	}); err != nil {
+		t.Fatalf("create deployment: %v", err)
+	}
+	var events []DeploymentEvent
+	s.SetDeploymentHandler(func(e DeploymentEvent) { events = append(events, e) })
+	conn := newTestConnection(t, "agent-1", "web")
+
+	detail := func() string {
+		t.Helper()
+		d, err := store.GetDeployment("deploy-2")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return d.StatusDetail
+	}
+
+	sendAck(t, s, conn, protocol.CommandAckPayload{CommandID: "deploy-2", Status: protocol.AckReceived})
+	if got := detail(); got != models.DetailAcknowledged {
+		t.Fatalf("detail after ack = %q, want %q", got, models.DetailAcknowledged)
+	}
+	sendAck(t, s, conn, protocol.CommandAckPayload{CommandID: "deploy-2", Status: protocol.AckQueued, Detail: "queued behind deploy-1"})
+	if got := detail(); got != "queued behind deploy-1" {
+		t.Fatalf("detail after queued ack = %q", got)
+	}
+	sendAck(t, s, conn, protocol.CommandAckPayload{CommandID: "deploy-2", Status: protocol.AckQueued, Detail: "queued behind deploy-1"})
+	if len(events) != 2 || events[0].Kind != DeploymentAck || events[1].Kind != DeploymentAck {
+		t.Fatalf("events = %+v, want one ack event per detail change", events)
+	}
+
+	start, err := protocol.NewMessage(protocol.TypeCommandStart, protocol.CommandStartPayload{CommandID: "deploy-2", StartedAt: time.Now().Unix()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.handleCommandStart(conn, start)
+	if got := detail(); got != "" {
+		t.Fatalf("detail after start = %q, want it cleared", got)
+	}
+	sendAck(t, s, conn, protocol.CommandAckPayload{CommandID: "deploy-2", Status: protocol.AckReceived})
+	if got := detail(); got != "" {
+		t.Fatalf("late ack set the detail of a running deployment to %q", got)
+	}
+}
+
+func TestFailUnacknowledgedOnlyFailsSentDeployments(t *testing.T) {
+	s, store := newTestServer(t)
+	s.cfg.Server.AckTimeoutSec = 60
+	if err := store.CreateAgent(&models.Agent{ID: "agent-1", Name: "web", Status: models.AgentOnline}); err != nil {
+		t.Fatalf("create agent: %v", err)
+	}
+	old := time.Now().Add(-2 * time.Minute)
+	for _, d := range []models.Deployment{
+		{ID: "silent", StatusDetail: models.DetailSent, StartedAt: old},
+		{ID: "recent", StatusDetail: models.DetailSent, StartedAt: time.Now()},
+		{ID: "acked", StatusDetail: models.DetailAcknowledged, StartedAt: old},
+		{ID: "queued", StatusDetail: "queued behind silent", StartedAt: old},
+	} {
+		d.Repository, d.Branch, d.AgentID, d.AgentName, d.Status = "api", "main", "agent-1", "web", models.DeployPending
+		if err := store.CreateDeployment(&d); err != nil {
+			t.Fatalf("create deployment %s: %v", d.ID, err)
+		}
+	}
+
+	s.failUnacknowledged()
+
+	for id, want := range map[string]models.DeployStatus{
+		"silent": models.DeployFailed,
+		"recent": models.DeployPending,
+		"acked":  models.DeployPending,
+		"queued": models.DeployPending,
+	} {
+		d, err := store.GetDeployment(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if d.Status != want {
+			t.Errorf("%s: status = %s, want %s", id, d.Status, want)
+		}
+	}
+	d, _ := store.GetDeployment("silent")
+	if d.StatusDetail != models.DetailNoAck || d.EndedAt == nil || !strings.Contains(d.Output, "within 1m0s") {
+		t.Fatalf("unacknowledged deployment = %+v", d)
+	}
+	logs, _ := store.GetDeploymentLogs("silent")
+	if len(logs) != 1 || logs[0].Stream != "stderr" {
+		t.Fatalf("logs = %+v, want one stderr line explaining the failure", logs)
+	}
+
+	s.cfg.Server.AckTimeoutSec = -1
+	if err := store.CreateDeployment(&models.Deployment{
+		ID: "disabled", Repository: "api", AgentID: "agent-1", Status: models.DeployPending,
+		StatusDetail: models.DetailSent, StartedAt: old,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	s.failUnacknowledged()
+	if d, _ := store.GetDeployment("disabled"); d.Status != models.DeployPending {
+		t.Fatalf("watchdog ran with ack_timeout_sec disabled: %s", d.Status)
+	}
+}
+
+type liveAgent struct {
+	s      *Server
+	store  storage.Store
+	addr   string
+	id     string
+	token  string
+	alerts chan *models.Alert
+}
+
+func startLiveServer(t *testing.T, graceSec int, opts ...func(*config.Config)) *liveAgent {
+	t.Helper()
+	store, err := sqlite.New(t.TempDir())
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	cfg := config.Default()
+	cfg.Server.Host = "127.0.0.1"
+	cfg.Server.TCPPort = 0
+	cfg.Server.OfflineGraceSec = graceSec
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	id, token, err := cfg.AddAgent("web")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.CreateAgent(&models.Agent{ID: id, Name: "web", Status: models.AgentOffline}); err != nil {
+		t.Fatal(err)
+	}
+
+	a := &liveAgent{s: NewServer(cfg, store), store: store, id: id, token: token, alerts: make(chan *models.Alert, 10)}
+	a.s.SetAlertHandler(func(alert *models.Alert) { a.alerts <- alert })
+	if err := a.s.Start(); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	t.Cleanup(func() { a.s.Stop() })
+	a.addr = a.s.listeners[0].Addr().String()
+	return a
+}
+
+func (a *liveAgent) connect(t *testing.T) (net.Conn, <-chan struct{}) {
+	t.Helper()
+	conn, err := net.Dial("tcp", a.addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	auth, _ := protocol.NewMessage(protocol.TypeAuth, protocol.AuthPayload{
+		Token: a.token, Hostname: "web-1", MachineID: "machine-web-1", Version: "1.1.0",
+	})
+	if err := protocol.NewWriter(conn).Write(auth); err != nil {
+		t.Fatal(err)
+	}
+	reader := protocol.NewReader(conn)
+	if msg, err := reader.ReadWithTimeout(5 * time.Second); err != nil || msg.Type != protocol.TypeAuthOK {
+		t.Fatalf("auth = %v, %v", msg, err)
+	}
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			if _, err := reader.Read(); err != nil {
+				return
+			}
+		}
+	}()
+	a.waitConnected(t, true)
+	return conn, closed
+}
+
+func (a *liveAgent) waitConnected(t *testing.T, want bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for a.s.IsAgentConnected(a.id) != want {
+		if time.Now().After(deadline) {
+			t.Fatalf("agent connected = %v, want %v", !want, want)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func (a *liveAgent) offlineAlerts(t *testing.T) []models.Alert {
+	t.Helper()
+	alerts, err := a.store.GetAlertsByAgent(a.id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var offline []models.Alert
+	for _, alert := range alerts {
+		if alert.Type == "agent_offline" {
+			offline = append(offline, alert)
+		}
+	}
+	return offline
+}
+
+func TestRapidReconnectStaysOnline(t *testing.T) {
+	a := startLiveServer(t, 1)
+
+	var sessions []<-chan struct{}
+	for i := 0; i < 5; i++ {
+		_, closed := a.connect(t)
+		sessions = append(sessions, closed)
+	}
+	for i, closed := range sessions[:len(sessions)-1] {
+		select {
+		case <-closed:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("superseded session %d was never closed", i)
+		}
+	}
+	time.Sleep(1500 * time.Millisecond)
+
+	if !a.s.IsAgentConnected(a.id) {
+		t.Fatal("agent dropped after superseded sessions closed")
+	}
+	if agent, _ := a.store.GetAgent(a.id); agent == nil || agent.Status != models.AgentOnline {
+		t.Fatalf("agent status = %v, want online", agent)
+	}
+	if alerts := a.offlineAlerts(t); len(alerts) != 0 {
+		t.Fatalf("rapid reconnects raised %d agent_offline alert(s)", len(alerts))
+	}
+	select {
+	case alert := <-a.alerts:
+		t.Fatalf("notified %s alert during rapid reconnects", alert.Type)
+	default:
+	}
+}
+
+func TestReconnectWithinOfflineGrace(t *testing.T) {
+	a := startLiveServer(t, 1)
+	conn, _ := a.connect(t)
+
+	conn.Close()
+	a.waitConnected(t, false)
+	if agent, _ := a.store.GetAgent(a.id); agent == nil || agent.Status != models.AgentOffline {
+		t.Fatalf("agent status = %v, want offline right after the disconnect", agent)
+	}
+	a.connect(t)
+	time.Sleep(1500 * time.Millisecond)
+
+	if alerts := a.offlineAlerts(t); len(alerts) != 0 {
+		t.Fatalf("reconnect within the grace period raised %d agent_offline alert(s)", len(alerts))
+	}
+	select {
+	case alert := <-a.alerts:
+		t.Fatalf("notified %s alert for a reconnect within the grace period", alert.Type)
+	default:
+	}
+}
+
+func TestReconnectAfterOfflineGrace(t *testing.T) {
+	a := startLiveServer(t, 1)
+	conn, _ := a.connect(t)
+
+	conn.Close()
+	a.waitConnected(t, false)
+	select {
+	case alert := <-a.alerts:
+		if alert.Type != "agent_offline" {
+			t.Fatalf("notified %s alert, want agent_offline", alert.Type)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no agent_offline alert after the grace period")
+	}
+	time.Sleep(1500 * time.Millisecond)
+	if alerts := a.offlineAlerts(t); len(alerts) != 1 || alerts[0].Resolved {
+		t.Fatalf("agent_offline alerts = %+v, want exactly one active", alerts)
+	}
+	select {
+	case alert := <-a.alerts:
+		t.Fatalf("notified a second %s alert", alert.Type)
+	default:
+	}
+
+	a.connect(t)
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		alerts := a.offlineAlerts(t)
+		if len(alerts) == 1 && alerts[0].Resolved {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("agent_offline alerts after reconnect = %+v, want the alert resolved", alerts)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestMetricsInventorySyncsContainers(t *testing.T) {
+	s, store := newTestServer(t)
+	if err := store.CreateAgent(&models.Agent{ID: "agent-1", Name: "web", Status: models.AgentOnline}); err != nil {
+		t.Fatal(err)
+	}
+	conn := newTestConnection(t, "agent-1", "web")
+	running := []protocol.Container{
+		{ID: "c1", Name: "uruflow-api", Status: "running"},
+		{ID: "c2", Name: "uruflow-worker", Status: "running"},
+	}
+
+	cases := []struct {
+		name    string
+		payload protocol.MetricsPayload
+		want    []string
+	}{
+		{"inventory", protocol.MetricsPayload{Inventory: true, Containers: running}, []string{"c1", "c2"}},
+		{"docker unavailable keeps containers", protocol.MetricsPayload{}, []string{"c1", "c2"}},
+		{"removed container", protocol.MetricsPayload{Inventory: true, Containers: running[:1]}, []string{"c1"}},
+		{"no containers", protocol.MetricsPayload{Inventory: true, Containers: []protocol.Container{}}, nil},
+	}
+	for _, tc := range cases {
+		msg, err := protocol.NewMessage(protocol.TypeMetrics, tc.payload)
+		if err != nil {
+			t.Fatal(err)
+		}
+		s.handleMetrics(conn, msg)
+
+		containers, err := store.GetContainersByAgent("agent-1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		var ids []string
+		for _, c := range containers {
+			ids = append(ids, c.ID)
+		}
+		slices.Sort(ids)
+		if !slices.Equal(ids, tc.want) {
+			t.Fatalf("%s: containers = %v, want %v", tc.name, ids, tc.want)
+		}
+	}
+}
@@ -0,0 +1,202 @@
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+
+package tcp
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/urustack/uruflow/internal/config"
+	"github.com/urustack/uruflow/internal/tcp/protocol"
+)
+
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	file string
+}
+
+func newTestCA(t *testing.T, name string) *testCA {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	file := filepath.Join(t.TempDir(), "ca.crt")
+	if err := os.WriteFile(file, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return &testCA{cert: cert, key: key, file: file}
+}
+
+func (ca *testCA) pool() *x509.CertPool {
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.cert)
+	return pool
+}
+
+func (ca *testCA) issue(t *testing.T, cn string, usage x509.ExtKeyUsage) (tls.Certificate, string, string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	serial, _ := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 64))
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, _ := x509.MarshalECPrivateKey(key)
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	if err := os.WriteFile(certFile, certPEM, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, keyPEM, 0600); err != nil {
+		t.Fatal(err)
+	}
+	pair, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pair, certFile, keyFile
+}
+
+func startTLSServer(t *testing.T, ca *testCA, verifyCN bool) *liveAgent {
+	t.Helper()
+	_, certFile, keyFile := ca.issue(t, "uruflow-server", x509.ExtKeyUsageServerAuth)
+	return startLiveServer(t, 60, func(cfg *config.Config) {
+		cfg.TLS.Enabled = true
+		cfg.TLS.AutoCert = false
+		cfg.TLS.CertFile = certFile
+		cfg.TLS.KeyFile = keyFile
+		cfg.TLS.ClientCAFile = ca.file
+		cfg.TLS.RequireClientCert = true
+		cfg.TLS.VerifyClientCN = verifyCN
+	})
+}
+
+func dialTLS(a *liveAgent, cfg *tls.Config) (*protocol.Message, error) {
+	conn, err := tls.Dial("tcp", a.addr, cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	auth, _ := protocol.NewMessage(protocol.TypeAuth, protocol.AuthPayload{
+		Token: a.token, Hostname: "web-1", MachineID: "machine-web-1", Version: "1.1.0",
+	})
+	if err := protocol.NewWriter(conn).Write(auth); err != nil {
+		return nil, err
+	}
+	return protocol.NewReader(conn).ReadWithTimeout(5 * time.Second)
+}
+
+func TestTLSClientCertificates(t *testing.T) {
+	ca := newTestCA(t, "uruflow-ca")
+	rogue := newTestCA(t, "rogue-ca")
+	trusted, _, _ := ca.issue(t, "web", x509.ExtKeyUsageClientAuth)
+	untrusted, _, _ := rogue.issue(t, "web", x509.ExtKeyUsageClientAuth)
+	misnamed, _, _ := ca.issue(t, "db", x509.ExtKeyUsageClientAuth)
+
+	cases := []struct {
+		name     string
+		verifyCN bool
+		roots    *x509.CertPool
+		certs    []tls.Certificate
+		wantAuth protocol.MessageType
+		wantErr  string
+	}{
+		{"trusted client", false, ca.pool(), []tls.Certificate{trusted}, protocol.TypeAuthOK, ""},
+		{"trusted client with matching CN", true, ca.pool(), []tls.Certificate{trusted}, protocol.TypeAuthOK, ""},
+		{"CN does not match the agent", true, ca.pool(), []tls.Certificate{misnamed}, protocol.TypeAuthFail, `client certificate CN "db" does not match agent "web"`},
+		{"client cert from an untrusted CA", false, ca.pool(), []tls.Certificate{untrusted}, 0, ""},
+		{"no client cert", false, ca.pool(), nil, 0, ""},
+		{"untrusted server", false, rogue.pool(), []tls.Certificate{trusted}, 0, "certificate signed by unknown authority"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			a := startTLSServer(t, ca, tc.verifyCN)
+			msg, err := dialTLS(a, &tls.Config{RootCAs: tc.roots, Certificates: tc.certs, MinVersion: tls.VersionTLS12})
+
+			if tc.wantAuth == 0 {
+				if err == nil {
+					t.Fatalf("handshake accepted, got %v", msg.Type)
+				}
+				if tc.wantErr != "" && !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("error = %v, want it to mention %q", err, tc.wantErr)
+				}
+				if a.s.IsAgentConnected(a.id) {
+					t.Fatal("rejected client registered as connected")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("connect: %v", err)
+			}
+			if msg.Type != tc.wantAuth {
+				t.Fatalf("auth response = %v, want %v", msg.Type, tc.wantAuth)
+			}
+			if tc.wantErr != "" {
+				var fail protocol.AuthFailPayload
+				msg.Decode(&fail)
+				if fail.Reason != tc.wantErr {
+					t.Fatalf("auth fail reason = %q, want %q", fail.Reason, tc.wantErr)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,125 @@
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+
+package components
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/urustack/uruflow/internal/tui/styles"
+)
+
+type column struct {
+	title string
+	width int
+	min   int
+	drop  int
+	right bool
+}
+
+type columns struct {
+	cols []column
+	keep []bool
+	gap  int
+}
+
+func fitColumns(cols []column, lead, reserve, w int) columns {
+	c := columns{cols: append([]column(nil), cols...), keep: make([]bool, len(cols)), gap: 2}
+	for i := range c.keep {
+		c.keep[i] = true
+	}
+	if w <= 0 {
+		return c
+	}
+	w -= lead + reserve
+
+	for c.total() > w {
+		worst := -1
+		for i, col := range c.cols {
+			if c.keep[i] && col.drop > 0 && (worst < 0 || col.drop > c.cols[worst].drop) {
+				worst = i
+			}
+		}
+		if worst < 0 {
+			break
+		}
+		c.keep[worst] = false
+	}
+	if c.total() > w {
+		c.gap = 1
+	}
+	for i := range c.cols {
+		over := c.total() - w
+		if over <= 0 {
+			break
+		}
+		if col := c.cols[i]; c.keep[i] && col.min > 0 && col.width > col.min {
+			c.cols[i].width = max(col.min, col.width-over)
+		}
+	}
+	return c
+}
+
+func (c columns) total() int {
+	n, t := 0, 0
+	for i, col := range c.cols {
+		if c.keep[i] {
+			t += col.width
+			n++
+		}
+	}
+	if n > 1 {
+		t += (n - 1) * c.gap
+	}
+	return t
+}
+
+func (c columns) width(i int) int {
+	return c.cols[i].width
+}
+
+func (c columns) cell(i int, s string) string {
+	s = styles.Trunc(s, c.cols[i].width)
+	if c.cols[i].right {
+		return styles.PadL(s, c.cols[i].width)
+	}
+	return styles.Pad(s, c.cols[i].width)
+}
+
+func (c columns) join(cells []string) string {
+	var parts []string
+	for i, s := range cells {
+		if i < len(c.keep) && c.keep[i] {
+			parts = append(parts, s)
+		}
+	}
+	return strings.Join(parts, strings.Repeat(" ", c.gap))
+}
+
+func (c columns) header(lead int) string {
+	cells := make([]string, len(c.cols))
+	for i, col := range c.cols {
+		cells[i] = styles.MutedStyle.Render(c.cell(i, col.title))
+	}
+	return strings.Repeat(" ", lead) + c.join(cells)
+}
+
+func badgeWidth(s string) int {
+	return lipgloss.Width(Badge(s)) + 2
+}
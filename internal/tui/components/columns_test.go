@@ -0,0 +1,93 @@
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+
+package components
+
+import (
+	"testing"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+func TestRowGolden(t *testing.T) {
+	const (
+		repoNarrow = "   NAME              BRANCH      AGENT           STATUS    "
+		repoWide   = "   NAME              BRANCH      AGENT           MODE      STATUS      LAST DEPLOY     "
+		agentAll   = "      NAME                    STATUS         CPU     MEM    DISK"
+	)
+	rows := func(w int) []string {
+		return []string{
+			RepoHeader(w),
+			RepoRow("payments-service-api", "release/2026-10", "web-production-01", true, "success", "2026-10-12 09:14", true, false, true, w),
+			RepoRow("api", "main", "web", false, "", "never", false, true, false, w),
+			AgentHeader(w),
+			AgentRow("web-production-eu-west-1", true, 12.5, 48.25, 71, "", true, w),
+			AgentRow("db", false, 0, 0, 0, "0001-01-01 00:00", false, w),
+		}
+	}
+	wide := []string{
+		repoWide,
+		" ▸ payments-servi..  release/..  web-producti..   AUTO      SUCCESS    2026-10-12 09:14   DRIFT ",
+		"   api               main        web              MANUAL    PENDING    never             warm",
+		agentAll,
+		" ▸ ●  web-production-eu-we..   ONLINE      12.5%   48.2%   71.0%",
+		"   ○  db                       OFFLINE    never",
+	}
+	tests := []struct {
+		w    int
+		want []string
+	}{
+		{80, []string{
+			repoNarrow,
+			" ▸ payments-servi..  release/..  web-producti..   SUCCESS     DRIFT ",
+			"   api               main        web              PENDING    warm",
+			agentAll,
+			" ▸ ●  web-production-eu-we..   ONLINE      12.5%   48.2%   71.0%",
+			"   ○  db                       OFFLINE    never",
+		}},
+		{100, wide},
+		{140, wide},
+	}
+	for _, tt := range tests {
+		for i, row := range rows(tt.w) {
+			got := ansi.Strip(row)
+			if got != tt.want[i] {
+				t.Errorf("width %d row %d:\n got %q\nwant %q", tt.w, i, got, tt.want[i])
+			}
+			if ansi.StringWidth(got) > tt.w {
+				t.Errorf("width %d row %d is %d columns wide", tt.w, i, ansi.StringWidth(got))
+			}
+		}
+	}
+}
+
+func TestFitColumnsShrinksBeforeOverflowing(t *testing.T) {
+	for _, w := range []int{50, 60, 70} {
+		l := repoLayout(w)
+		if l.keep[3] || l.keep[5] {
+			t.Errorf("width %d keeps MODE or LAST DEPLOY", w)
+		}
+		if got := ansi.StringWidth(RepoHeader(w)); got > w {
+			t.Errorf("width %d header is %d columns wide", w, got)
+		}
+		row := RepoRow("payments-service-api", "release/2026-10", "web-production-01", true, "success", "", false, false, false, w)
+		if got := ansi.StringWidth(row); got > w {
+			t.Errorf("width %d row is %d columns wide: %q", w, got, ansi.Strip(row))
+		}
+	}
+}
@@ -20,7 +20,9 @@ package components
 
 import (
 	"fmt"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/lipgloss"
 	"github.com/urustack/uruflow/internal/tui/styles"
@@ -121,12 +123,18 @@ func Badge(s string) string {
 		return styles.BadgeOffline.Render("OFFLINE")
 	case "success":
 		return styles.BadgeSuccess.Render("SUCCESS")
+	case "success_with_warnings":
+		return styles.BadgeWarning.Render("WARNINGS")
+	case "dry_run":
+		return styles.BadgeMuted.Render("DRY RUN")
 	case "failed", "error":
 		return styles.BadgeError.Render("FAILED")
 	case "running":
 		return styles.BadgePrimary.Render("RUNNING")
 	case "pending":
 		return styles.BadgeWarning.Render("PENDING")
+	case "awaiting_approval":
+		return styles.BadgeWarning.Render("APPROVAL")
 	case "auto":
 		return styles.BadgeSuccess.Render("AUTO")
 	case "manual":
@@ -139,12 +147,36 @@ func Badge(s string) string {
 		return styles.BadgeError.Render("CRITICAL")
 	case "warning":
 		return styles.BadgeWarning.Render("WARNING")
+	case "info":
+		return styles.BadgeMuted.Render("INFO")
+	case "drift":
+		return styles.BadgeWarning.Render("DRIFT")
+	case "degraded":
+		return styles.BadgeWarning.Render("DEGRADED")
+	case "skew":
+		return styles.BadgeWarning.Render("CLOCK SKEW")
+	case "low_disk":
+		return styles.BadgeWarning.Render("LOW DISK")
+	case "maintenance":
+		return styles.BadgeWarning.Render("MAINTENANCE")
+	case "accepted":
+		return styles.BadgeSuccess.Render("ACCEPTED")
+	case "rejected":
+		return styles.BadgeError.Render("REJECTED")
+	case "ignored":
+		return styles.BadgeMuted.Render("IGNORED")
+	case "coalesced":
+		return styles.BadgeMuted.Render("COALESCED")
+	case "unauthorized":
+		return styles.BadgeError.Render("UNAUTHORIZED")
 	case "compose":
 		return styles.BadgePrimary.Render("COMPOSE")
 	case "dockerfile":
 		return styles.BadgePrimary.Render("DOCKER")
 	case "makefile":
 		return styles.BadgePrimary.Render("MAKE")
+	case "image":
+		return styles.BadgePrimary.Render("IMAGE")
 	default:
 		return styles.BadgeMuted.Render(strings.ToUpper(s))
 	}
@@ -177,6 +209,18 @@ func CenteredLogo(w int) string {
 	return b.String()
 }
 
+var agentColumns = []column{
+	{title: "NAME", width: 22, min: 10},
+	{title: "STATUS", width: 10},
+	{title: "CPU", width: 6, right: true},
+	{title: "MEM", width: 6, right: true},
+	{title: "DISK", width: 6, right: true, drop: 1},
+}
+
+func agentLayout(w int) columns {
+	return fitColumns(agentColumns, 6, badgeWidth("maintenance"), w)
+}
+
 func AgentRow(name string, online bool, cpu, mem, disk float64, lastSeen string, selected bool, w int) string {
 	ptr := "   "
 	if selected {
@@ -198,34 +242,44 @@ func AgentRow(name string, online bool, cpu, mem, disk float64, lastSeen string,
 		status = Badge("online")
 	}
 
-	var metrics string
+	l := agentLayout(w)
+	cells := []string{
+		nameStyle.Render(l.cell(0, name)),
+		styles.Pad(status, l.width(1)),
+	}
 	if online {
-		metrics = fmt.Sprintf("%5.1f%%    %5.1f%%    %5.1f%%", cpu, mem, disk)
+		cells = append(cells,
+			l.cell(2, fmt.Sprintf("%.1f%%", cpu)),
+			l.cell(3, fmt.Sprintf("%.1f%%", mem)),
+			l.cell(4, fmt.Sprintf("%.1f%%", disk)))
 	} else {
 		if lastSeen == "0001-01-01 00:00" || lastSeen == "0001-01-01" {
 			lastSeen = "never"
 		}
-		metrics = styles.MutedStyle.Render(styles.Pad(lastSeen, 24))
+		cells = append(cells, styles.MutedStyle.Render(lastSeen))
 	}
 
-	return fmt.Sprintf("%s%s  %s  %s  %s",
-		ptr,
-		dot,
-		nameStyle.Render(styles.Pad(styles.Trunc(name, 22), 22)),
-		styles.Pad(status, 10),
-		metrics)
+	return ptr + dot + "  " + l.join(cells)
 }
 
 func AgentHeader(w int) string {
-	return fmt.Sprintf("       %s  %s  %s    %s    %s",
-		styles.MutedStyle.Render(styles.Pad("NAME", 22)),
-		styles.MutedStyle.Render(styles.Pad("STATUS", 10)),
-		styles.MutedStyle.Render(styles.Pad("CPU", 5)),
-		styles.MutedStyle.Render(styles.Pad("MEM", 5)),
-		styles.MutedStyle.Render(styles.Pad("DISK", 5)))
+	return agentLayout(w).header(6)
 }
 
-func RepoRow(name, branch, agent string, auto bool, status, lastTime string, selected bool, w int) string {
+var repoColumns = []column{
+	{title: "NAME", width: 16, min: 8},
+	{title: "BRANCH", width: 10, min: 6},
+	{title: "AGENT", width: 14, min: 8},
+	{title: "MODE", width: 8, drop: 2},
+	{title: "STATUS", width: 10},
+	{title: "LAST DEPLOY", width: 16, drop: 1},
+}
+
+func repoLayout(w int) columns {
+	return fitColumns(repoColumns, 3, badgeWidth("drift"), w)
+}
+
+func RepoRow(name, branch, agent string, auto bool, status, lastTime string, drift, warm, selected bool, w int) string {
 	ptr := "   "
 	if selected {
 		ptr = " " + styles.Pointer() + " "
@@ -242,32 +296,29 @@ func RepoRow(name, branch, agent string, auto bool, status, lastTime string, sel
 	}
 
 	st := Badge("pending")
-	if status == "success" {
-		st = Badge("success")
-	} else if status == "failed" {
-		st = Badge("failed")
-	} else if status == "running" {
-		st = Badge("running")
+	if status != "" {
+		st = Badge(status)
 	}
 
-	return fmt.Sprintf("%s%s  %s  %s  %s  %s  %s",
-		ptr,
-		nameStyle.Render(styles.Pad(styles.Trunc(name, 16), 16)),
-		styles.MutedStyle.Render(styles.Pad(styles.Trunc(branch, 10), 10)),
-		styles.Pad(styles.Trunc(agent, 14), 14),
-		styles.Pad(mode, 8),
-		styles.Pad(st, 10),
-		styles.MutedStyle.Render(styles.Pad(lastTime, 14)))
+	l := repoLayout(w)
+	row := ptr + l.join([]string{
+		nameStyle.Render(l.cell(0, name)),
+		styles.MutedStyle.Render(l.cell(1, branch)),
+		l.cell(2, agent),
+		styles.Pad(mode, l.width(3)),
+		styles.Pad(st, l.width(4)),
+		styles.MutedStyle.Render(l.cell(5, lastTime)),
+	})
+	if drift {
+		row += "  " + Badge("drift")
+	} else if warm {
+		row += "  " + styles.MutedStyle.Render("warm")
+	}
+	return row
 }
 
 func RepoHeader(w int) string {
-	return fmt.Sprintf("    %s  %s  %s  %s  %s  %s",
-		styles.MutedStyle.Render(styles.Pad("NAME", 16)),
-		styles.MutedStyle.Render(styles.Pad("BRANCH", 10)),
-		styles.MutedStyle.Render(styles.Pad("AGENT", 14)),
-		styles.MutedStyle.Render(styles.Pad("MODE", 8)),
-		styles.MutedStyle.Render(styles.Pad("STATUS", 10)),
-		styles.MutedStyle.Render(styles.Pad("LAST DEPLOY", 14)))
+	return repoLayout(w).header(3)
 }
 
 func DeployRow(icon, repo, branch, commit, agent, time string, w int) string {
@@ -280,6 +331,32 @@ func DeployRow(icon, repo, branch, commit, agent, time string, w int) string {
 		styles.MutedStyle.Render(time))
 }
 
+func RepoStatsHeader(w int) string {
+	return fmt.Sprintf("  %s  %s  %s  %s  %s",
+		styles.MutedStyle.Render(styles.Pad("REPO", 14)),
+		styles.MutedStyle.Render(styles.Pad("LAST DEPLOY", 12)),
+		styles.MutedStyle.Render(styles.PadL("DEPLOYS", 7)),
+		styles.MutedStyle.Render(styles.PadL("SUCCESS", 7)),
+		styles.MutedStyle.Render(styles.PadL("AVG", 8)))
+}
+
+func RepoStatsRow(repo, last string, deploys int, rate float64, avg string, streak int, w int) string {
+	success := "-"
+	if deploys > 0 {
+		success = fmt.Sprintf("%.0f%%", rate)
+	}
+	row := fmt.Sprintf("  %s  %s  %s  %s  %s",
+		styles.Pad(styles.Trunc(repo, 14), 14),
+		styles.Pad(styles.Trunc(last, 12), 12),
+		styles.PadL(fmt.Sprintf("%d", deploys), 7),
+		styles.PadL(success, 7),
+		styles.PadL(avg, 8))
+	if streak > 0 {
+		return styles.ErrorStyle.Render(row + fmt.Sprintf("  %d failed in a row", streak))
+	}
+	return row
+}
+
 func AlertRow(icon, typ, agent, msg, time string, selected bool, w int) string {
 	ptr := "   "
 	if selected {
@@ -331,11 +408,18 @@ func Progress(steps []ProgressStep, w int) string {
 }
 
 func LogLine(time, content, stream string, w int) string {
+	return LogLineMarked(time, content, stream, "  ", w)
+}
+
+func LogLineMarked(time, content, stream, mark string, w int) string {
+	if w > 0 {
+		content = styles.Trunc(content, w-lipgloss.Width(mark)-lipgloss.Width(time)-2)
+	}
 	c := content
 	if stream == "stderr" {
 		c = styles.ErrorStyle.Render(content)
 	}
-	return fmt.Sprintf("  %s  %s", styles.SubtleStyle.Render(time), c)
+	return fmt.Sprintf("%s%s  %s", mark, styles.SubtleStyle.Render(time), c)
 }
 
 func Select(options []string, cursor int) string {
@@ -462,21 +546,106 @@ func Card(title string, lines []CardLine, selected bool, w int) string {
 type AgentCardData struct {
 	Name       string
 	Host       string
+	MachineID  string
 	Version    string
 	Online     bool
+	Degraded   bool
+	LowDisk    bool
+	ClockSkew  time.Duration
+	SkewWarn   bool
 	CPU        float64
 	Memory     float64
 	Disk       float64
+	Queued     int
+	Labels     string
+	DockerDisk string
+	Cleanup    string
+	Frozen     string
+	SendQueue  string
+	QueueWarn  bool
+	Traffic    string
+	WriteWarn  bool
+	CPUHistory []float64
+	MemHistory []float64
 	Containers []ContainerInfo
+	Events     []EventInfo
 	Selected   bool
 }
 
+type EventInfo struct {
+	Time   string
+	Type   string
+	Detail string
+}
+
 type ContainerInfo struct {
 	Name    string
+	Project string
+	Service string
 	Running bool
 	Healthy bool
 	CPU     float64
 	Memory  string
+	Stale   bool
+}
+
+type ContainerGroup struct {
+	Project    string
+	Containers []ContainerInfo
+	Running    int
+}
+
+func GroupContainers(containers []ContainerInfo) ([]ContainerGroup, []ContainerInfo) {
+	var groups []ContainerGroup
+	var ungrouped []ContainerInfo
+	index := make(map[string]int)
+	for _, c := range containers {
+		if c.Project == "" {
+			ungrouped = append(ungrouped, c)
+			continue
+		}
+		i, ok := index[c.Project]
+		if !ok {
+			i = len(groups)
+			index[c.Project] = i
+			groups = append(groups, ContainerGroup{Project: c.Project})
+		}
+		groups[i].Containers = append(groups[i].Containers, c)
+		if c.Running {
+			groups[i].Running++
+		}
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i].Project < groups[j].Project })
+	for _, g := range groups {
+		sort.SliceStable(g.Containers, func(i, j int) bool {
+			return containerLabel(g.Containers[i]) < containerLabel(g.Containers[j])
+		})
+	}
+	return groups, ungrouped
+}
+
+func containerLabel(c ContainerInfo) string {
+	if c.Service != "" {
+		return c.Service
+	}
+	return c.Name
+}
+
+func containerRow(c ContainerInfo, label, indent string) string {
+	dot := styles.Offline()
+	if c.Running {
+		dot = styles.Online()
+	}
+	h := "unhealthy"
+	if c.Healthy {
+		h = "healthy"
+	}
+	row := fmt.Sprintf("\n%s%s %s  %s  %5.1f%%  %s",
+		indent, dot, styles.Pad(styles.Trunc(label, 14), 14), Badge(h), c.CPU, c.Memory)
+	if c.Stale {
+		row += "  " + styles.MutedStyle.Render("stale")
+	}
+	return row
 }
 
 func AgentCard(d AgentCardData, w int) string {
@@ -485,33 +654,123 @@ func AgentCard(d AgentCardData, w int) string {
 	if d.Online {
 		st = "online"
 	}
-	b.WriteString(styles.TitleStyle.Render(d.Name) + "  " + Badge(st) + "\n")
+	b.WriteString(styles.TitleStyle.Render(d.Name) + "  " + Badge(st))
+	if d.Frozen != "" {
+		b.WriteString("  " + Badge("maintenance"))
+	}
+	b.WriteString("\n")
+	if d.Frozen != "" {
+		b.WriteString("\n" + styles.SubtleStyle.Render("Deploys ") + styles.WarningStyle.Render("webhook and scheduled deploys frozen, "+d.Frozen))
+	}
+	if d.Labels != "" {
+		b.WriteString("\n" + styles.SubtleStyle.Render("Labels  ") + styles.PrimaryStyle.Render(d.Labels))
+	}
+	if d.MachineID != "" {
+		b.WriteString("\n" + styles.SubtleStyle.Render("Machine ") + styles.MutedStyle.Render(d.MachineID))
+	}
 	if d.Online {
 		b.WriteString("\n" + styles.SubtleStyle.Render("Host    ") + d.Host)
 		if d.Version != "" {
 			b.WriteString("\n" + styles.SubtleStyle.Render("Version ") + d.Version)
 		}
-		b.WriteString(fmt.Sprintf("\n\n%s %5.1f%%    %s %5.1f%%    %s %5.1f%%",
-			styles.SubtleStyle.Render("CPU"), d.CPU,
-			styles.SubtleStyle.Render("MEM"), d.Memory,
-			styles.SubtleStyle.Render("DISK"), d.Disk))
+		if d.Degraded {
+			b.WriteString("  " + Badge("degraded") + " " + styles.WarningStyle.Render("older than the server supports"))
+		}
+		if d.ClockSkew != 0 {
+			skew := styles.MutedStyle.Render(fmt.Sprintf("%+v", d.ClockSkew.Round(time.Second)))
+			if d.SkewWarn {
+				skew = styles.WarningStyle.Render(fmt.Sprintf("%+v off from server", d.ClockSkew.Round(time.Second)))
+			}
+			b.WriteString("\n" + styles.SubtleStyle.Render("Clock   ") + skew)
+		}
+		if d.SendQueue != "" {
+			queue := styles.MutedStyle.Render(d.SendQueue)
+			if d.QueueWarn {
+				queue = styles.WarningStyle.Render(d.SendQueue + ", agent is not keeping up")
+			}
+			b.WriteString("\n" + styles.SubtleStyle.Render("Queue   ") + queue)
+		}
+		if d.Traffic != "" {
+			traffic := styles.MutedStyle.Render(d.Traffic)
+			if d.WriteWarn {
+				traffic = styles.WarningStyle.Render(d.Traffic)
+			}
+			b.WriteString("\n" + styles.SubtleStyle.Render("Traffic ") + traffic)
+		}
+		if d.LowDisk {
+			b.WriteString("\n" + styles.SubtleStyle.Render("Disk    ") + Badge("low_disk") + " " + styles.WarningStyle.Render("below the agent's free space threshold, deploys will be refused"))
+		}
+		if len(d.CPUHistory) > 1 || len(d.MemHistory) > 1 {
+			b.WriteString(fmt.Sprintf("\n\n%s %5.1f%%  %s\n%s %5.1f%%  %s\n%s %5.1f%%",
+				styles.SubtleStyle.Render("CPU "), d.CPU, styles.PrimaryStyle.Render(Sparkline(d.CPUHistory)),
+				styles.SubtleStyle.Render("MEM "), d.Memory, styles.PrimaryStyle.Render(Sparkline(d.MemHistory)),
+				styles.SubtleStyle.Render("DISK"), d.Disk))
+		} else {
+			b.WriteString(fmt.Sprintf("\n\n%s %5.1f%%    %s %5.1f%%    %s %5.1f%%",
+				styles.SubtleStyle.Render("CPU"), d.CPU,
+				styles.SubtleStyle.Render("MEM"), d.Memory,
+				styles.SubtleStyle.Render("DISK"), d.Disk))
+		}
+		if d.DockerDisk != "" {
+			b.WriteString("\n\n" + styles.SubtleStyle.Render("Docker  ") + d.DockerDisk)
+		}
+		if d.Cleanup != "" {
+			b.WriteString("\n" + styles.SubtleStyle.Render("Cleanup ") + d.Cleanup)
+		}
+		if d.Queued > 0 {
+			noun := "deploys"
+			if d.Queued == 1 {
+				noun = "deploy"
+			}
+			b.WriteString("\n\n" + styles.WarningStyle.Render(fmt.Sprintf("%d %s queued", d.Queued, noun)))
+		}
 		if len(d.Containers) > 0 {
 			b.WriteString("\n\n" + styles.SubtleStyle.Render("Containers:"))
-			for _, c := range d.Containers {
-				dot := styles.Offline()
-				if c.Running {
-					dot = styles.Online()
+			groups, ungrouped := GroupContainers(d.Containers)
+			for _, g := range groups {
+				count := fmt.Sprintf("%d/%d running", g.Running, len(g.Containers))
+				if g.Running < len(g.Containers) {
+					count = styles.WarningStyle.Render(count)
+				} else {
+					count = styles.MutedStyle.Render(count)
 				}
-				h := "unhealthy"
-				if c.Healthy {
-					h = "healthy"
+				b.WriteString("\n  " + styles.PrimaryStyle.Render(g.Project) + styles.MutedStyle.Render(" — ") + count)
+				for _, c := range g.Containers {
+					b.WriteString(containerRow(c, containerLabel(c), "    "))
 				}
-				b.WriteString(fmt.Sprintf("\n  %s %s  %s  %5.1f%%  %s",
-					dot, styles.Pad(styles.Trunc(c.Name, 14), 14), Badge(h), c.CPU, c.Memory))
+			}
+			if len(groups) > 0 && len(ungrouped) > 0 {
+				b.WriteString("\n  " + styles.MutedStyle.Render("ungrouped"))
+			}
+			for _, c := range ungrouped {
+				indent := "  "
+				if len(groups) > 0 {
+					indent = "    "
+				}
+				b.WriteString(containerRow(c, c.Name, indent))
 			}
 		}
 	} else {
 		b.WriteString("\n" + styles.MutedStyle.Render("Agent is currently offline"))
+		if d.Host != "" {
+			b.WriteString("\n" + styles.SubtleStyle.Render("Last IP ") + d.Host)
+		}
+	}
+	if len(d.Events) > 0 {
+		b.WriteString("\n\n" + styles.SubtleStyle.Render("Events:"))
+		for _, e := range d.Events {
+			typ := styles.MutedStyle.Render(styles.Pad(e.Type, 11))
+			switch e.Type {
+			case "connect":
+				typ = styles.SuccessStyle.Render(styles.Pad(e.Type, 11))
+			case "auth_failed":
+				typ = styles.ErrorStyle.Render(styles.Pad(e.Type, 11))
+			case "disconnect":
+				typ = styles.WarningStyle.Render(styles.Pad(e.Type, 11))
+			}
+			b.WriteString(fmt.Sprintf("\n  %s  %s  %s", styles.SubtleStyle.Render(e.Time), typ,
+				styles.Trunc(e.Detail, w-39)))
+		}
 	}
 	if d.Selected {
 		return WrapSelected(b.String(), w)
@@ -519,18 +778,43 @@ func AgentCard(d AgentCardData, w int) string {
 	return Wrap(b.String(), w)
 }
 
+var sparkBlocks = []rune("▁▂▃▄▅▆▇█")
+
+func Sparkline(percents []float64) string {
+	out := make([]rune, len(percents))
+	for i, p := range percents {
+		idx := int(p / 100 * float64(len(sparkBlocks)))
+		if idx < 0 {
+			idx = 0
+		}
+		if idx >= len(sparkBlocks) {
+			idx = len(sparkBlocks) - 1
+		}
+		out[i] = sparkBlocks[idx]
+	}
+	return string(out)
+}
+
 type RepoCardData struct {
-	Name        string
-	URL         string
-	Branch      string
-	Agent       string
-	AutoDeploy  bool
-	BuildSystem string
-	BuildFile   string
-	LastStatus  string
-	LastCommit  string
-	LastTime    string
-	Selected    bool
+	Name         string
+	URL          string
+	Branch       string
+	Agent        string
+	AutoDeploy   bool
+	BuildSystem  string
+	BuildFile    string
+	BuildCmd     string
+	Image        string
+	LastStatus   string
+	LastCommit   string
+	LastTime     string
+	DryRunCommit string
+	DryRunTime   string
+	Drift        []string
+	Schedule     string
+	NextRun      string
+	Checkout     string
+	Selected     bool
 }
 
 func RepoCard(d RepoCardData, w int) string {
@@ -549,18 +833,37 @@ func RepoCard(d RepoCardData, w int) string {
 		buildInfo += " → " + d.BuildFile
 	}
 	b.WriteString("\n" + styles.SubtleStyle.Render("Build  ") + buildInfo)
+	if d.BuildCmd != "" {
+		b.WriteString("\n" + styles.SubtleStyle.Render("Cmd    ") + styles.Trunc(d.BuildCmd, max(w-7, 8)))
+	}
+	if d.Image != "" {
+		b.WriteString("\n" + styles.SubtleStyle.Render("Image  ") + styles.Trunc(d.Image, max(w-7, 8)))
+	}
+	if d.Schedule != "" {
+		b.WriteString("\n" + styles.SubtleStyle.Render("Cron   ") + d.Schedule)
+		if d.NextRun != "" {
+			b.WriteString(styles.MutedStyle.Render("  next " + d.NextRun))
+		}
+	}
+
+	if d.Checkout != "" {
+		b.WriteString("\n" + styles.SubtleStyle.Render("Warm   ") + styles.MutedStyle.Render(d.Checkout))
+	}
 
 	if d.LastCommit != "" {
-		st := "success"
-		if d.LastStatus == "failed" {
-			st = "failed"
-		} else if d.LastStatus == "running" {
-			st = "running"
-		}
-		b.WriteString("\n\n" + Badge(st) + "  " + d.LastCommit + "  " + styles.MutedStyle.Render(d.LastTime))
+		b.WriteString("\n\n" + Badge(d.LastStatus) + "  " + d.LastCommit + "  " + styles.MutedStyle.Render(d.LastTime))
 	} else {
 		b.WriteString("\n\n" + styles.MutedStyle.Render("No deployments yet"))
 	}
+	if d.DryRunCommit != "" {
+		b.WriteString("\n" + Badge("dry_run") + "  " + d.DryRunCommit + "  " + styles.MutedStyle.Render(d.DryRunTime))
+	}
+	if len(d.Drift) > 0 {
+		b.WriteString("\n\n" + Badge("drift"))
+		for _, f := range d.Drift {
+			b.WriteString("\n" + styles.WarningStyle.Render("  • ") + f)
+		}
+	}
 	if d.Selected {
 		return WrapSelected(b.String(), w)
 	}
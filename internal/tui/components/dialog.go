@@ -19,7 +19,9 @@
 package components
 
 import (
+	"fmt"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/lipgloss"
 	"github.com/urustack/uruflow/internal/tui/styles"
@@ -35,10 +37,17 @@ type Dialog struct {
 	Title    string
 	Message  string
 	Warning  string
+	Options  []DialogOption
 	Selected int
 	Visible  bool
 }
 
+type DialogOption struct {
+	Key     string
+	Label   string
+	Checked bool
+}
+
 func NewDialog(title, message, warning string) Dialog {
 	return Dialog{
 		Title:    title,
@@ -61,15 +70,31 @@ func (d Dialog) IsConfirmed() bool {
 	return d.Selected == 1
 }
 
+func (d *Dialog) ToggleOption(key string) bool {
+	for i := range d.Options {
+		if d.Options[i].Key == key {
+			d.Options[i].Checked = !d.Options[i].Checked
+			return true
+		}
+	}
+	return false
+}
+
+func (d Dialog) Option(key string) bool {
+	for _, o := range d.Options {
+		if o.Key == key {
+			return o.Checked
+		}
+	}
+	return false
+}
+
 func ConfirmDialog(d Dialog, screenWidth, screenHeight int) string {
 	if !d.Visible {
 		return ""
 	}
 
-	dialogWidth := 44
-	if dialogWidth > screenWidth-4 {
-		dialogWidth = screenWidth - 4
-	}
+	dialogWidth := min(44, max(screenWidth-4, 12))
 
 	var content strings.Builder
 
@@ -81,6 +106,17 @@ func ConfirmDialog(d Dialog, screenWidth, screenHeight int) string {
 		content.WriteString(styles.MutedStyle.Render(d.Warning) + "\n")
 	}
 
+	if len(d.Options) > 0 {
+		content.WriteString("\n")
+		for _, o := range d.Options {
+			box := styles.MutedStyle.Render("[ ]")
+			if o.Checked {
+				box = styles.SuccessStyle.Render("[x]")
+			}
+			content.WriteString(box + " " + o.Label + " " + styles.SubtleStyle.Render("("+o.Key+")") + "\n")
+		}
+	}
+
 	content.WriteString("\n")
 
 	noBtn := styles.MutedStyle.Render("[No]")
@@ -137,11 +173,59 @@ func DeleteAgentDialog(agentName string) Dialog {
 }
 
 func DeleteRepoDialog(repoName string) Dialog {
-	return NewDialog(
+	d := NewDialog(
 		"Delete Repository",
 		"Remove repository '"+repoName+"'?",
 		"This cannot be undone.",
 	)
+	d.Options = []DialogOption{
+		{Key: "s", Label: "Also stop containers on agent"},
+		{Key: "w", Label: "Remove working directory"},
+	}
+	return d
+}
+
+func RotateTokenDialog(agentName string, grace time.Duration) Dialog {
+	detail := "The old token stops working immediately."
+	if grace > 0 {
+		detail = fmt.Sprintf("The old token keeps working for %dh.", int(grace.Hours()))
+	}
+	return NewDialog(
+		"Rotate Token",
+		"Issue a new token for '"+agentName+"'?",
+		detail,
+	)
+}
+
+func MaintenanceDialog(agentName string, enable bool) Dialog {
+	if enable {
+		return NewDialog(
+			"Maintenance Mode",
+			"Freeze deploys to '"+agentName+"'?",
+			"Webhook and scheduled deploys are refused until it is turned off.",
+		)
+	}
+	return NewDialog(
+		"Maintenance Mode",
+		"Resume deploys to '"+agentName+"'?",
+		"Maintenance windows from the config still apply.",
+	)
+}
+
+func MaintenanceDeployDialog(repoName, reason string) Dialog {
+	return NewDialog(
+		"Agent In Maintenance",
+		"Deploy "+repoName+" anyway?",
+		"The target agent is frozen: "+reason+".",
+	)
+}
+
+func RollbackDialog(repoName, commit string) Dialog {
+	return NewDialog(
+		"Rollback Deployment",
+		"Redeploy "+repoName+" at "+commit+"?",
+		"The current deployment will be replaced.",
+	)
 }
 
 func ResolveAlertDialog(alertType string) Dialog {
@@ -151,3 +235,11 @@ func ResolveAlertDialog(alertType string) Dialog {
 		"",
 	)
 }
+
+func ResolveAlertsDialog(count int) Dialog {
+	return NewDialog(
+		"Resolve Alerts",
+		fmt.Sprintf("Mark %d selected alerts as resolved?", count),
+		"",
+	)
+}
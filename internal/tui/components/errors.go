@@ -0,0 +1,115 @@
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+
+package components
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/urustack/uruflow/internal/tui/styles"
+)
+
+const maxErrors = 5
+
+type ErrorEntry struct {
+	Op      string
+	Message string
+	Count   int
+	First   time.Time
+	Last    time.Time
+}
+
+type ErrorStack struct {
+	Entries []ErrorEntry
+}
+
+func (s *ErrorStack) Push(op string, err error) {
+	if err == nil {
+		return
+	}
+	msg := err.Error()
+	now := time.Now()
+
+	for i, e := range s.Entries {
+		if e.Op == op && e.Message == msg {
+			e.Count++
+			e.Last = now
+			s.Entries = append(s.Entries[:i], s.Entries[i+1:]...)
+			s.Entries = append([]ErrorEntry{e}, s.Entries...)
+			return
+		}
+	}
+
+	s.Entries = append([]ErrorEntry{{Op: op, Message: msg, Count: 1, First: now, Last: now}}, s.Entries...)
+	if len(s.Entries) > maxErrors {
+		s.Entries = s.Entries[:maxErrors]
+	}
+}
+
+func (s *ErrorStack) Resolve(op string) {
+	kept := s.Entries[:0]
+	for _, e := range s.Entries {
+		if e.Op != op {
+			kept = append(kept, e)
+		}
+	}
+	s.Entries = kept
+}
+
+func (s *ErrorStack) Dismiss() {
+	if len(s.Entries) > 0 {
+		s.Entries = s.Entries[1:]
+	}
+}
+
+func (s *ErrorStack) Clear() {
+	s.Entries = nil
+}
+
+func (s ErrorStack) Len() int {
+	return len(s.Entries)
+}
+
+func (s ErrorStack) View(w int) string {
+	if len(s.Entries) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	for i, e := range s.Entries {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		line := styles.ErrorStyle.Render(styles.IconError) + "  "
+		if e.Op != "" {
+			line += styles.ErrorStyle.Render(e.Op+": ") + e.Message
+		} else {
+			line += e.Message
+		}
+		meta := e.Last.Format("15:04:05")
+		if e.Count > 1 {
+			meta += fmt.Sprintf(" ×%d", e.Count)
+		}
+		b.WriteString(line + "  " + styles.MutedStyle.Render(meta))
+	}
+	b.WriteString("\n\n" + styles.SubtleStyle.Render("z dismiss · Z dismiss all"))
+
+	return WrapError(b.String(), w)
+}
@@ -0,0 +1,188 @@
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+
+package components
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/urustack/uruflow/internal/tui/styles"
+)
+
+type ToastLevel int
+
+const (
+	ToastInfo ToastLevel = iota
+	ToastSuccess
+	ToastWarning
+	ToastError
+)
+
+const (
+	DefaultToastTTL = 4 * time.Second
+	maxActiveToasts = 3
+	maxToastHistory = 20
+)
+
+type Toast struct {
+	Level   ToastLevel
+	Text    string
+	Count   int
+	At      time.Time
+	Expires time.Time
+}
+
+type Toasts struct {
+	Active  []Toast
+	History []Toast
+}
+
+func (t *Toasts) Push(level ToastLevel, text string, ttl time.Duration, now time.Time) {
+	if ttl <= 0 {
+		ttl = DefaultToastTTL
+	}
+
+	for i, a := range t.Active {
+		if a.Level == level && a.Text == text {
+			a.Count++
+			a.At = now
+			a.Expires = now.Add(ttl)
+			t.Active = append(t.Active[:i], t.Active[i+1:]...)
+			t.Active = append(t.Active, a)
+			t.record(a)
+			return
+		}
+	}
+
+	toast := Toast{Level: level, Text: text, Count: 1, At: now, Expires: now.Add(ttl)}
+	t.Active = append(t.Active, toast)
+	if len(t.Active) > maxActiveToasts {
+		t.Active = t.Active[len(t.Active)-maxActiveToasts:]
+	}
+	t.record(toast)
+}
+
+func (t *Toasts) record(toast Toast) {
+	if len(t.History) > 0 {
+		last := t.History[0]
+		if last.Level == toast.Level && last.Text == toast.Text {
+			t.History[0] = toast
+			return
+		}
+	}
+	t.History = append([]Toast{toast}, t.History...)
+	if len(t.History) > maxToastHistory {
+		t.History = t.History[:maxToastHistory]
+	}
+}
+
+func (t *Toasts) Expire(now time.Time) {
+	kept := t.Active[:0]
+	for _, a := range t.Active {
+		if now.Before(a.Expires) {
+			kept = append(kept, a)
+		}
+	}
+	t.Active = kept
+}
+
+func (t *Toasts) Dismiss() {
+	t.Active = nil
+}
+
+func toastLine(toast Toast, w int, stamp bool) string {
+	icon, style := styles.PrimaryStyle.Render("●"), styles.BrightStyle
+	switch toast.Level {
+	case ToastSuccess:
+		icon, style = styles.SuccessStyle.Render(styles.IconSuccess), styles.SuccessStyle
+	case ToastWarning:
+		icon, style = styles.WarningStyle.Render(styles.IconWarning), styles.WarningStyle
+	case ToastError:
+		icon, style = styles.ErrorStyle.Render(styles.IconError), styles.ErrorStyle
+	}
+
+	meta := ""
+	if stamp {
+		meta = toast.At.Format("15:04:05")
+	}
+	if toast.Count > 1 {
+		meta = strings.TrimSpace(meta + fmt.Sprintf(" ×%d", toast.Count))
+	}
+	room := w - 8
+	if meta != "" {
+		room -= len([]rune(meta)) + 2
+	}
+	line := "  " + icon + "  " + style.Render(styles.Trunc(toast.Text, max(room, 8)))
+	if meta != "" {
+		line += "  " + styles.MutedStyle.Render(meta)
+	}
+	return line
+}
+
+func (t Toasts) View(w int) string {
+	lines := make([]string, 0, len(t.Active))
+	for _, a := range t.Active {
+		lines = append(lines, toastLine(a, w, false))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (t Toasts) HistoryView(w, h int) string {
+	var b strings.Builder
+	b.WriteString("\n")
+	b.WriteString(ViewHeader(w, "Notifications") + "\n\n")
+	b.WriteString(Section("RECENT NOTIFICATIONS", w) + "\n\n")
+
+	var list strings.Builder
+	if len(t.History) == 0 {
+		list.WriteString("  " + styles.MutedStyle.Render("No notifications yet"))
+	} else {
+		for i, toast := range t.History {
+			if i > 0 {
+				list.WriteString("\n")
+			}
+			list.WriteString(toastLine(toast, w-4, true))
+		}
+	}
+	b.WriteString(Wrap(list.String(), w) + "\n")
+
+	content := b.String()
+	for i := strings.Count(content, "\n"); i < h-3; i++ {
+		content += "\n"
+	}
+	content += "\n" + styles.Line(w) + "\n"
+	content += Help([][]string{{"H/esc", "close"}})
+	return content
+}
+
+func Overlay(view, overlay string, row int) string {
+	if overlay == "" {
+		return view
+	}
+	lines := strings.Split(view, "\n")
+	for i, line := range strings.Split(overlay, "\n") {
+		if row+i < len(lines) {
+			lines[row+i] = line
+		} else {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
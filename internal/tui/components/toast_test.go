@@ -0,0 +1,107 @@
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+
+package components
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestToastExpiry(t *testing.T) {
+	start := time.Date(2026, 10, 17, 9, 0, 0, 0, time.UTC)
+	var toasts Toasts
+	toasts.Push(ToastInfo, "deploy queued", 2*time.Second, start)
+	toasts.Push(ToastError, "agent offline", 5*time.Second, start)
+	toasts.Push(ToastSuccess, "repository added", 0, start)
+
+	steps := []struct {
+		at   time.Duration
+		want []string
+	}{
+		{time.Second, []string{"deploy queued", "agent offline", "repository added"}},
+		{2 * time.Second, []string{"agent offline", "repository added"}},
+		{DefaultToastTTL, []string{"agent offline"}},
+		{5 * time.Second, nil},
+	}
+	for _, step := range steps {
+		toasts.Expire(start.Add(step.at))
+		if got := toastTexts(toasts.Active); !slices.Equal(got, step.want) {
+			t.Fatalf("active at +%s = %q, want %q", step.at, got, step.want)
+		}
+	}
+	if len(toasts.History) != 3 {
+		t.Fatalf("history has %d entries, want 3", len(toasts.History))
+	}
+}
+
+func TestToastCoalescing(t *testing.T) {
+	start := time.Date(2026, 10, 17, 9, 0, 0, 0, time.UTC)
+	var toasts Toasts
+	toasts.Push(ToastError, "save config: permission denied", 3*time.Second, start)
+	toasts.Push(ToastInfo, "deploy queued", 3*time.Second, start)
+	toasts.Push(ToastError, "save config: permission denied", 3*time.Second, start.Add(2*time.Second))
+	toasts.Push(ToastWarning, "save config: permission denied", 3*time.Second, start.Add(2*time.Second))
+
+	want := []string{"deploy queued", "save config: permission denied", "save config: permission denied"}
+	if got := toastTexts(toasts.Active); !slices.Equal(got, want) {
+		t.Fatalf("active = %q, want %q", got, want)
+	}
+	repeated := toasts.Active[1]
+	if repeated.Level != ToastError || repeated.Count != 2 {
+		t.Fatalf("repeated toast = level %d count %d, want error ×2", repeated.Level, repeated.Count)
+	}
+
+	toasts.Expire(start.Add(4 * time.Second))
+	if got := toastTexts(toasts.Active); len(got) != 2 || toasts.Active[0].Count != 2 {
+		t.Fatalf("a repeat did not extend the toast's TTL: active = %q", got)
+	}
+
+	if len(toasts.History) != 4 {
+		t.Fatalf("history has %d entries, want 4", len(toasts.History))
+	}
+	if h := toasts.History[1]; h.Level != ToastError || h.Count != 2 {
+		t.Fatalf("history entry = level %d count %d, want the coalesced error ×2", h.Level, h.Count)
+	}
+}
+
+func TestToastLimits(t *testing.T) {
+	now := time.Date(2026, 10, 17, 9, 0, 0, 0, time.UTC)
+	var toasts Toasts
+	for i := 0; i < maxToastHistory+5; i++ {
+		toasts.Push(ToastInfo, "deploy "+string(rune('a'+i)), time.Minute, now)
+	}
+	if len(toasts.Active) != maxActiveToasts {
+		t.Fatalf("%d active toasts, want %d", len(toasts.Active), maxActiveToasts)
+	}
+	if last := toasts.Active[maxActiveToasts-1].Text; last != "deploy "+string(rune('a'+maxToastHistory+4)) {
+		t.Fatalf("newest active toast = %q", last)
+	}
+	if len(toasts.History) != maxToastHistory {
+		t.Fatalf("%d history entries, want %d", len(toasts.History), maxToastHistory)
+	}
+}
+
+func toastTexts(toasts []Toast) []string {
+	var texts []string
+	for _, t := range toasts {
+		texts = append(texts, t.Text)
+	}
+	return texts
+}
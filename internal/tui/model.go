@@ -6,13 +6,17 @@
 package tui
 
 import (
+	"fmt"
+	"sync/atomic"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/urustack/uruflow/internal/api"
 	"github.com/urustack/uruflow/internal/config"
 	"github.com/urustack/uruflow/internal/storage"
+	"github.com/urustack/uruflow/internal/tcp"
 	"github.com/urustack/uruflow/internal/tcp/protocol"
+	"github.com/urustack/uruflow/internal/tui/components"
 	"github.com/urustack/uruflow/internal/tui/views"
 )
 
@@ -36,6 +40,17 @@ func waitForContainerLogs() tea.Msg {
 	return <-globalLogChannel
 }
 
+var (
+	deploymentEvents      = make(chan views.DeploymentEventMsg, 256)
+	deploymentEventsDirty atomic.Bool
+)
+
+func waitForDeploymentEvents() tea.Msg {
+	msg := <-deploymentEvents
+	msg.Dirty = deploymentEventsDirty.Swap(false)
+	return msg
+}
+
 type Model struct {
 	ActiveView    ViewState
 	Width         int
@@ -55,19 +70,43 @@ type Model struct {
 	Logs          views.LogsModel
 	ContainerLogs views.ContainerLogsModel
 	InitState     views.InitModel
+	Toasts        components.Toasts
+	ShowToasts    bool
+	clock         func() time.Time
 }
 
 type SpinnerTickMsg struct{}
 
+type toastExpireMsg struct{}
+
 func NewModel(store storage.Store, cfg *config.Config, cfgPath string, server *api.Server) Model {
 	deployService := server.GetDeployService()
 
-	server.GetTCPServer().SetContainerLogHandler(func(agentID string, data protocol.ContainerLogsDataPayload) {
+	server.GetTCPServer().SetContainerLogHandler(func(agentID string, data protocol.ContainerLogsDataPayload) bool {
 		select {
 		case globalLogChannel <- views.ContainerLogsMsg(data):
+			return true
+		default:
+			return false
+		}
+	})
+
+	server.GetTCPServer().SetDeploymentHandler(func(event tcp.DeploymentEvent) {
+		msg := views.DeploymentEventMsg{
+			Kind:         event.Kind,
+			DeploymentID: event.DeploymentID,
+			Status:       string(event.Status),
+			Line:         event.Line,
+		}
+		select {
+		case deploymentEvents <- msg:
 		default:
+			deploymentEventsDirty.Store(true)
 		}
 	})
+	if cfg.Owner() != 0 {
+		server.GetTCPServer().WatchDeployments(2 * time.Second)
+	}
 
 	return Model{
 		ActiveView:    ViewDashboard,
@@ -76,11 +115,11 @@ func NewModel(store storage.Store, cfg *config.Config, cfgPath string, server *a
 		CfgPath:       cfgPath,
 		Server:        server,
 		Dashboard:     views.NewDashboardModel(store),
-		Agents:        views.NewAgentsModel(store, cfg, cfgPath),
+		Agents:        views.NewAgentsModel(store, cfg, cfgPath, server.GetTCPServer()),
 		Repos:         views.NewReposModel(store, cfg, cfgPath, deployService),
 		Alerts:        views.NewAlertsModel(store),
-		Deploy:        views.NewDeployModel(store),
-		Logs:          views.NewLogsModel(store),
+		Deploy:        views.NewDeployModel(store, cfg, deployService),
+		Logs:          views.NewLogsModel(store, deployService),
 		ContainerLogs: views.NewContainerLogsModel(server),
 		InitState:     views.NewInitModel(),
 	}
@@ -94,7 +133,17 @@ func (m *Model) Init() tea.Cmd {
 	if m.ActiveView == ViewInit {
 		return m.InitState.Init()
 	}
-	return tea.Batch(m.Dashboard.Init(), waitForContainerLogs, m.spinnerTick)
+	cmds := []tea.Cmd{m.Dashboard.Init(), waitForContainerLogs, waitForDeploymentEvents, m.spinnerTick}
+	if pid := m.Config.Owner(); pid != 0 {
+		cmds = append(cmds, func() tea.Msg {
+			return views.ToastMsg{
+				Level: components.ToastWarning,
+				Text:  fmt.Sprintf("Attached to server pid %d: config changes and agent commands are disabled", pid),
+				TTL:   10 * time.Second,
+			}
+		})
+	}
+	return tea.Batch(cmds...)
 }
 
 func (m Model) spinnerTick() tea.Msg {
@@ -103,12 +152,18 @@ func (m Model) spinnerTick() tea.Msg {
 }
 
 func (m Model) isInputActive() bool {
-	if m.ActiveView == ViewAgents && m.Agents.Mode == views.AgentModeAdd {
+	if m.ActiveView == ViewAgents && (m.Agents.Mode == views.AgentModeAdd || m.Agents.Mode == views.AgentModeLabels || m.Agents.Mode == views.AgentModeRename || m.Agents.Mode == views.AgentModeExecParams) {
 		return true
 	}
 	if m.ActiveView == ViewRepos && (m.Repos.Mode == views.RepoModeAdd || m.Repos.Mode == views.RepoModeSelectAgent) {
 		return true
 	}
+	if m.ActiveView == ViewLogs && (m.Logs.Mode == views.LogsModeFilter || m.Logs.Searching()) {
+		return true
+	}
+	if m.ActiveView == ViewContainerLogs && m.ContainerLogs.Searching() {
+		return true
+	}
 	if m.ActiveView == ViewInit {
 		return true
 	}
@@ -116,6 +171,15 @@ func (m Model) isInputActive() bool {
 }
 
 func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	prev := m.ActiveView
+	model, cmd := m.update(msg)
+	if prev == ViewContainerLogs && m.ActiveView != ViewContainerLogs {
+		m.ContainerLogs.StopStream()
+	}
+	return model, cmd
+}
+
+func (m *Model) update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmd tea.Cmd
 	var cmds []tea.Cmd
 
@@ -125,6 +189,18 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		cmds = append(cmds, m.spinnerTick)
 		return m, tea.Batch(cmds...)
 
+	case views.ToastMsg:
+		ttl := msg.TTL
+		if ttl <= 0 {
+			ttl = components.DefaultToastTTL
+		}
+		m.Toasts.Push(msg.Level, msg.Text, ttl, m.now())
+		return m, tea.Tick(ttl, func(time.Time) tea.Msg { return toastExpireMsg{} })
+
+	case toastExpireMsg:
+		m.Toasts.Expire(m.now())
+		return m, nil
+
 	case views.ContainerLogsMsg:
 		cmds = append(cmds, waitForContainerLogs)
 		if m.ActiveView == ViewContainerLogs {
@@ -135,9 +211,37 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		return m, tea.Batch(cmds...)
 
+	case views.DeploymentEventMsg:
+		cmds = append(cmds, waitForDeploymentEvents)
+		var newModel tea.Model
+		switch m.ActiveView {
+		case ViewDashboard:
+			newModel, cmd = m.Dashboard.Update(msg)
+			m.Dashboard = newModel.(views.DashboardModel)
+		case ViewDeploy:
+			newModel, cmd = m.Deploy.Update(msg)
+			m.Deploy = newModel.(views.DeployModel)
+		case ViewLogs:
+			newModel, cmd = m.Logs.Update(msg)
+			m.Logs = newModel.(views.LogsModel)
+		}
+		cmds = append(cmds, cmd)
+		return m, tea.Batch(cmds...)
+
 	case tea.KeyMsg:
+		if m.ShowToasts {
+			switch msg.String() {
+			case "ctrl+c":
+				m.ContainerLogs.StopStream()
+				return m, tea.Quit
+			case "H", "esc", "q":
+				m.ShowToasts = false
+			}
+			return m, nil
+		}
 		switch msg.String() {
 		case "ctrl+c":
+			m.ContainerLogs.StopStream()
 			return m, tea.Quit
 		case "?":
 			m.ShowHelp = !m.ShowHelp
@@ -149,22 +253,28 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				if m.ActiveView == ViewRepos && m.Repos.Mode != views.RepoModeList {
 					break
 				}
-				if m.ActiveView == ViewLogs && m.Logs.Mode == views.LogsModeView {
+				if m.ActiveView == ViewLogs && m.Logs.Mode != views.LogsModeSelect {
 					break
 				}
 				if m.ActiveView == ViewContainerLogs && m.ContainerLogs.Mode == 1 {
 					break
 				}
 				m.ActiveView = ViewDashboard
-				m.Dashboard.ClearMessage()
 				return m, m.Dashboard.Init()
 			}
 		}
 
 		if !m.isInputActive() {
 			switch msg.String() {
+			case "H":
+				if m.ActiveView != ViewInit {
+					m.ShowToasts = true
+					m.Toasts.Dismiss()
+					return m, nil
+				}
 			case "q":
 				if m.ActiveView != ViewInit && m.ActiveView != ViewDeploy && m.ActiveView != ViewContainerLogs {
+					m.ContainerLogs.StopStream()
 					return m, tea.Quit
 				}
 			case "tab":
@@ -184,14 +294,12 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 						cmd = m.Logs.Init()
 					case ViewLogs:
 						m.ActiveView = ViewDashboard
-						m.Dashboard.ClearMessage()
 						cmd = m.Dashboard.Init()
 					case ViewContainerLogs:
 						m.ActiveView = ViewDashboard
 						cmd = m.Dashboard.Init()
 					default:
 						m.ActiveView = ViewDashboard
-						m.Dashboard.ClearMessage()
 						cmd = m.Dashboard.Init()
 					}
 					return m, cmd
@@ -221,7 +329,7 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					m.ActiveView = ViewLogs
 					return m, m.Logs.Init()
 				}
-				if m.ActiveView == ViewAgents {
+				if m.ActiveView == ViewAgents && m.Agents.Mode == views.AgentModeList {
 					if len(m.Agents.Agents) > 0 {
 						agent := m.Agents.Agents[m.Agents.Cursor]
 						m.ContainerLogs.SetAgent(agent)
@@ -234,12 +342,12 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	case views.AgentResultMsg:
 		if msg.Success {
-			m.Dashboard.SetMessage("Agent '"+msg.Name+"' created successfully", "success")
+			cmds = append(cmds, m.resultToast("Agent '"+msg.Name+"' created", msg.Error))
 		}
 
 	case views.RepoResultMsg:
 		if msg.Success {
-			m.Dashboard.SetMessage("Repository '"+msg.Name+"' added successfully", "success")
+			cmds = append(cmds, m.resultToast("Repository '"+msg.Name+"' added", msg.Error))
 		}
 
 	case tea.WindowSizeMsg:
@@ -310,28 +418,48 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, tea.Batch(cmds...)
 }
 
+func (m Model) now() time.Time {
+	if m.clock != nil {
+		return m.clock()
+	}
+	return time.Now()
+}
+
+func (m Model) resultToast(text string, err error) tea.Cmd {
+	return func() tea.Msg {
+		if err != nil {
+			return views.ToastMsg{Level: components.ToastWarning, Text: text + ", but " + err.Error(), TTL: 8 * time.Second}
+		}
+		return views.ToastMsg{Level: components.ToastSuccess, Text: text}
+	}
+}
+
 func (m *Model) View() string {
 	if !m.Ready {
 		return ""
 	}
+	if m.ShowToasts {
+		return m.Toasts.HistoryView(m.Width, m.Height)
+	}
+
+	var view string
 	switch m.ActiveView {
-	case ViewDashboard:
-		return m.Dashboard.View()
 	case ViewAgents:
-		return m.Agents.View()
+		view = m.Agents.View()
 	case ViewRepos:
-		return m.Repos.View()
+		view = m.Repos.View()
 	case ViewAlerts:
-		return m.Alerts.View()
+		view = m.Alerts.View()
 	case ViewDeploy:
-		return m.Deploy.View()
+		view = m.Deploy.View()
 	case ViewLogs:
-		return m.Logs.View()
+		view = m.Logs.View()
 	case ViewContainerLogs:
-		return m.ContainerLogs.View()
+		view = m.ContainerLogs.View()
 	case ViewInit:
 		return m.InitState.View()
 	default:
-		return m.Dashboard.View()
+		view = m.Dashboard.View()
 	}
+	return components.Overlay(view, m.Toasts.View(m.Width), 1)
 }
@@ -0,0 +1,91 @@
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+
+package tui
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/urustack/uruflow/internal/tui/components"
+)
+
+func TestToastsFromConcurrentCommands(t *testing.T) {
+	now := time.Date(2026, 10, 17, 9, 0, 0, 0, time.UTC)
+	m := NewInitModel()
+	m.clock = func() time.Time { return now }
+
+	const callers = 8
+	cmds := make([]tea.Cmd, 0, callers+1)
+	for i := 0; i < callers; i++ {
+		cmds = append(cmds, m.resultToast("Repository 'api' added", errors.New("save config: permission denied")))
+	}
+	cmds = append(cmds, m.resultToast("Agent 'web' created", nil))
+
+	msgs := make(chan tea.Msg, len(cmds))
+	var wg sync.WaitGroup
+	for _, cmd := range cmds {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			msgs <- cmd()
+		}()
+	}
+	wg.Wait()
+	close(msgs)
+
+	for msg := range msgs {
+		if _, cmd := m.Update(msg); cmd == nil {
+			t.Fatal("a toast was shown without scheduling its expiry")
+		}
+	}
+
+	if len(m.Toasts.Active) != 2 {
+		t.Fatalf("%d active toasts, want the failure coalesced with the success", len(m.Toasts.Active))
+	}
+	var failure, success components.Toast
+	for _, toast := range m.Toasts.Active {
+		switch toast.Level {
+		case components.ToastWarning:
+			failure = toast
+		case components.ToastSuccess:
+			success = toast
+		}
+	}
+	if failure.Count != callers {
+		t.Fatalf("failure toast count = %d, want %d", failure.Count, callers)
+	}
+	if success.Count != 1 {
+		t.Fatalf("success toast count = %d, want 1", success.Count)
+	}
+
+	now = now.Add(components.DefaultToastTTL)
+	m.Update(toastExpireMsg{})
+	if len(m.Toasts.Active) != 1 || m.Toasts.Active[0].Level != components.ToastWarning {
+		t.Fatalf("after the default TTL, active = %+v, want only the failure", m.Toasts.Active)
+	}
+
+	now = now.Add(8 * time.Second)
+	m.Update(toastExpireMsg{})
+	if len(m.Toasts.Active) != 0 {
+		t.Fatalf("after the failure TTL, %d toasts are still active", len(m.Toasts.Active))
+	}
+}
@@ -0,0 +1,366 @@
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+
+package views
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/urustack/uruflow/internal/models"
+	"github.com/urustack/uruflow/internal/tcp"
+	"github.com/urustack/uruflow/internal/tui/components"
+	"github.com/urustack/uruflow/internal/tui/styles"
+	"github.com/urustack/uruflow/pkg/helper"
+)
+
+const execRecentRuns = 5
+
+type ExecState struct {
+	Agent    AgentData
+	Names    []string
+	Cursor   int
+	Recent   []models.Command
+	Name     string
+	Params   []string
+	Values   map[string]string
+	ParamIdx int
+	ID       string
+	Lines    []string
+	Status   string
+	Offset   int
+}
+
+type execStartedMsg struct {
+	ID     string
+	Result <-chan tcp.ExecResult
+	Err    error
+}
+
+type execTickMsg struct {
+	ID string
+}
+
+type execDoneMsg struct {
+	ID     string
+	Result tcp.ExecResult
+}
+
+func (m AgentsModel) openExec() (tea.Model, tea.Cmd) {
+	if len(m.Agents) == 0 {
+		return m, nil
+	}
+	agent := m.Agents[m.Cursor]
+	if !agent.Online {
+		pushError(&m.errs, fmt.Errorf("agent %s is offline", agent.Name))
+		return m, nil
+	}
+	recent, _ := m.store.GetCommandsByAgent(agent.ID, execRecentRuns)
+	m.Exec = ExecState{Agent: agent, Names: m.tcp.ExecCommands(agent.ID), Recent: recent}
+	m.Mode = AgentModeExec
+	m.err = nil
+	return m, nil
+}
+
+func (m AgentsModel) updateExecMenu(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.Mode = AgentModeList
+	case "up", "k":
+		if m.Exec.Cursor > 0 {
+			m.Exec.Cursor--
+		}
+	case "down", "j":
+		if m.Exec.Cursor < len(m.Exec.Names)-1 {
+			m.Exec.Cursor++
+		}
+	case "enter":
+		if len(m.Exec.Names) == 0 {
+			return m, nil
+		}
+		m.Exec.Name = m.Exec.Names[m.Exec.Cursor]
+		m.Exec.Params = m.tcp.ExecParams(m.Exec.Name)
+		m.Exec.Values = make(map[string]string)
+		m.Exec.ParamIdx = 0
+		m.err = nil
+		if len(m.Exec.Params) > 0 {
+			m.Mode = AgentModeExecParams
+			m.Input = ""
+			return m, nil
+		}
+		return m.runExec()
+	}
+	return m, nil
+}
+
+func (m AgentsModel) updateExecParams(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.Mode = AgentModeExec
+		m.Input = ""
+		m.err = nil
+	case "enter":
+		value := strings.TrimSpace(m.Input)
+		if value == "" {
+			m.err = fmt.Errorf("%s is required", m.Exec.Params[m.Exec.ParamIdx])
+			return m, nil
+		}
+		m.Exec.Values[m.Exec.Params[m.Exec.ParamIdx]] = value
+		m.Exec.ParamIdx++
+		m.Input = ""
+		m.err = nil
+		if m.Exec.ParamIdx < len(m.Exec.Params) {
+			return m, nil
+		}
+		return m.runExec()
+	case "backspace":
+		if len(m.Input) > 0 {
+			m.Input = m.Input[:len(m.Input)-1]
+		}
+	default:
+		inputStr := msg.String()
+		if len(inputStr) == 1 && len(m.Input) < 120 {
+			m.Input += inputStr
+		}
+	}
+	return m, nil
+}
+
+func (m AgentsModel) runExec() (tea.Model, tea.Cmd) {
+	agentID, name, values := m.Exec.Agent.ID, m.Exec.Name, m.Exec.Values
+	m.Mode = AgentModeExecOutput
+	m.Exec.ID = ""
+	m.Exec.Lines = nil
+	m.Exec.Status = "starting"
+	m.Exec.Offset = 0
+	return m, func() tea.Msg {
+		id, result, err := m.tcp.SendExec(agentID, name, values)
+		return execStartedMsg{ID: id, Result: result, Err: err}
+	}
+}
+
+func (m AgentsModel) handleExecMsg(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case execStartedMsg:
+		if msg.Err != nil {
+			m.Exec.Status = "failed"
+			m.Exec.Lines = []string{msg.Err.Error()}
+			return m, nil
+		}
+		m.Exec.ID = msg.ID
+		m.Exec.Status = "running"
+		return m, tea.Batch(waitExec(msg.ID, msg.Result), execTick(msg.ID))
+	case execTickMsg:
+		if msg.ID != m.Exec.ID || m.Exec.Status != "running" {
+			return m, nil
+		}
+		m.Exec.Lines = m.tcp.ExecOutput(msg.ID)
+		return m, execTick(msg.ID)
+	case execDoneMsg:
+		if msg.ID != m.Exec.ID {
+			return m, nil
+		}
+		m.Exec.Lines = msg.Result.Output
+		m.Exec.Status = msg.Result.Status
+	}
+	return m, nil
+}
+
+func execTick(id string) tea.Cmd {
+	return tea.Tick(300*time.Millisecond, func(time.Time) tea.Msg {
+		return execTickMsg{ID: id}
+	})
+}
+
+func waitExec(id string, result <-chan tcp.ExecResult) tea.Cmd {
+	return func() tea.Msg {
+		select {
+		case r := <-result:
+			return execDoneMsg{ID: id, Result: r}
+		case <-time.After(tcp.ExecTimeout + 15*time.Second):
+			return execDoneMsg{ID: id, Result: tcp.ExecResult{
+				Status: "failed", ExitCode: 1, Output: []string{"timed out waiting for agent"},
+			}}
+		}
+	}
+}
+
+func (m AgentsModel) updateExecOutput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.Mode = AgentModeList
+	case "enter":
+		if m.Exec.Status != "running" && m.Exec.Status != "starting" {
+			return m.openExec()
+		}
+	case "up", "k":
+		if m.Exec.Offset > 0 {
+			m.Exec.Offset--
+		}
+	case "down", "j":
+		if m.Exec.Offset < len(m.Exec.Lines)-1 {
+			m.Exec.Offset++
+		}
+	}
+	return m, nil
+}
+
+func (m AgentsModel) viewExecMenu() string {
+	var b strings.Builder
+	w := m.Width
+
+	b.WriteString("\n")
+	b.WriteString(components.ViewHeader(w, "Dashboard", "Agents", m.Exec.Agent.Name, "Exec") + "\n\n")
+	b.WriteString(components.Section("COMMANDS", w) + "\n\n")
+
+	var listContent strings.Builder
+	if len(m.Exec.Names) == 0 {
+		listContent.WriteString("  " + styles.MutedStyle.Render("No commands available on this agent") + "\n")
+		listContent.WriteString("  " + styles.SubtleStyle.Render("Add them under exec: in both the server config and agent.yaml"))
+	} else {
+		options := make([]string, len(m.Exec.Names))
+		for i, name := range m.Exec.Names {
+			options[i] = name
+			if params := m.tcp.ExecParams(name); len(params) > 0 {
+				options[i] += "  " + strings.Join(params, " ")
+			}
+		}
+		listContent.WriteString(strings.TrimRight(components.Select(options, m.Exec.Cursor), "\n"))
+	}
+	b.WriteString(components.Wrap(listContent.String(), w) + "\n\n")
+
+	if len(m.Exec.Recent) > 0 {
+		b.WriteString(components.Section("RECENT RUNS", w) + "\n\n")
+		var recent strings.Builder
+		for _, c := range m.Exec.Recent {
+			name, _ := c.Payload["name"].(string)
+			if c.Type != "exec" {
+				name = c.Type + " " + name
+			}
+			icon := styles.SuccessStyle.Render(styles.IconSuccess)
+			switch c.Status {
+			case models.DeployFailed:
+				icon = styles.ErrorStyle.Render(styles.IconError)
+			case models.DeployPending, models.DeployRunning:
+				icon = styles.WarningStyle.Render(styles.IconSpin)
+			}
+			recent.WriteString(fmt.Sprintf("  %s  %s  %s\n", icon, styles.Pad(name, 24),
+				styles.MutedStyle.Render(helper.FormatTimeAgo(c.CreatedAt))))
+		}
+		b.WriteString(components.Wrap(strings.TrimRight(recent.String(), "\n"), w) + "\n")
+	}
+
+	content := b.String()
+	lines := helper.CountLines(content)
+	for i := 0; i < m.Height-lines-3; i++ {
+		content += "\n"
+	}
+
+	content += "\n" + styles.Line(w) + "\n"
+	content += components.Help([][]string{{"↑↓", "navigate"}, {"enter", "run"}, {"esc", "back"}})
+
+	return content
+}
+
+func (m AgentsModel) viewExecParams() string {
+	var b strings.Builder
+	w := m.Width
+
+	b.WriteString("\n")
+	b.WriteString(components.ViewHeader(w, "Dashboard", "Agents", m.Exec.Agent.Name, "Exec") + "\n\n")
+	b.WriteString(components.Section(strings.ToUpper(m.Exec.Name), w) + "\n\n")
+
+	var formContent strings.Builder
+	param := m.Exec.Params[m.Exec.ParamIdx]
+	label := fmt.Sprintf("%s (%d of %d)", param, m.Exec.ParamIdx+1, len(m.Exec.Params))
+	formContent.WriteString(components.Input(label, m.Input, true, w-8))
+	formContent.WriteString("\n  " + styles.MutedStyle.Render("Passed to the command as a single argument, never through a shell"))
+	if m.err != nil {
+		formContent.WriteString("\n\n" + styles.ErrorStyle.Render(styles.IconError) + "  " + styles.ErrorStyle.Render(m.err.Error()))
+	}
+	b.WriteString(components.Wrap(formContent.String(), w) + "\n")
+
+	content := b.String()
+	lines := helper.CountLines(content)
+	for i := 0; i < m.Height-lines-3; i++ {
+		content += "\n"
+	}
+
+	content += "\n" + styles.Line(w) + "\n"
+	content += components.Help([][]string{{"enter", "next"}, {"esc", "cancel"}})
+
+	return content
+}
+
+func (m AgentsModel) viewExecOutput() string {
+	var b strings.Builder
+	w := m.Width
+
+	b.WriteString("\n")
+	b.WriteString(components.ViewHeader(w, "Dashboard", "Agents", m.Exec.Agent.Name, "Exec") + "\n\n")
+	b.WriteString(components.Section(strings.ToUpper(m.Exec.Name), w) + "\n\n")
+
+	var status string
+	switch m.Exec.Status {
+	case "success":
+		status = styles.SuccessStyle.Render(styles.IconSuccess + " completed")
+	case "failed":
+		status = styles.ErrorStyle.Render(styles.IconError + " failed")
+	default:
+		status = components.Loading(m.SpinnerFrame, m.Exec.Status+"...")
+	}
+	b.WriteString("  " + status + "\n\n")
+
+	visible := m.Height - helper.CountLines(b.String()) - 6
+	if visible < 1 {
+		visible = 1
+	}
+	start := m.Exec.Offset
+	if m.Exec.Status == "running" && len(m.Exec.Lines) > visible {
+		start = len(m.Exec.Lines) - visible
+	}
+	if start > len(m.Exec.Lines) {
+		start = len(m.Exec.Lines)
+	}
+	end := start + visible
+	if end > len(m.Exec.Lines) {
+		end = len(m.Exec.Lines)
+	}
+
+	var out strings.Builder
+	if len(m.Exec.Lines) == 0 {
+		out.WriteString("  " + styles.MutedStyle.Render("No output"))
+	}
+	for _, line := range m.Exec.Lines[start:end] {
+		out.WriteString("  " + styles.Trunc(line, w-10) + "\n")
+	}
+	b.WriteString(components.Wrap(strings.TrimRight(out.String(), "\n"), w) + "\n")
+
+	content := b.String()
+	lines := helper.CountLines(content)
+	for i := 0; i < m.Height-lines-3; i++ {
+		content += "\n"
+	}
+
+	content += "\n" + styles.Line(w) + "\n"
+	content += components.Help([][]string{{"↑↓", "scroll"}, {"enter", "commands"}, {"esc", "back"}})
+
+	return content
+}
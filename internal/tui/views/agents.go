@@ -25,8 +25,11 @@ import (
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/urustack/uruflow/internal/config"
+	"github.com/urustack/uruflow/internal/logic"
 	"github.com/urustack/uruflow/internal/models"
+	"github.com/urustack/uruflow/internal/services"
 	"github.com/urustack/uruflow/internal/storage"
+	"github.com/urustack/uruflow/internal/tcp"
 	"github.com/urustack/uruflow/internal/tui/components"
 	"github.com/urustack/uruflow/internal/tui/styles"
 	"github.com/urustack/uruflow/pkg/helper"
@@ -39,6 +42,14 @@ const (
 	AgentModeAdd
 	AgentModeResult
 	AgentModeConfirmDelete
+	AgentModeConfirmMaintenance
+	AgentModeConfirmRotate
+	AgentModeLabels
+	AgentModeExec
+	AgentModeExecParams
+	AgentModeExecOutput
+	AgentModeRename
+	AgentModeReassign
 )
 
 type AgentResultMsg struct {
@@ -46,11 +57,15 @@ type AgentResultMsg struct {
 	Name    string
 	ID      string
 	Token   string
+	Rotated bool
+	Expires time.Time
 	Error   error
 }
 
 type AgentsModel struct {
 	store        storage.Store
+	tcp          *tcp.Server
+	agents       *services.AgentService
 	cfg          *config.Config
 	cfgPath      string
 	Width        int
@@ -62,19 +77,38 @@ type AgentsModel struct {
 	Input        string
 	Result       AgentAddResult
 	Dialog       components.Dialog
+	Exec         ExecState
+	Reassign     ReassignState
 	Loading      bool
 	SpinnerFrame int
 	err          error
+	errs         components.ErrorStack
+}
+
+type ReassignState struct {
+	Repos   []string
+	Targets []AgentData
+	Cursor  int
 }
 
 type AgentAddResult struct {
-	Name  string
-	ID    string
-	Token string
+	Name    string
+	ID      string
+	Token   string
+	Rotated bool
+	Expires time.Time
 }
 
-func NewAgentsModel(store storage.Store, cfg *config.Config, cfgPath string) AgentsModel {
-	return AgentsModel{store: store, cfg: cfg, cfgPath: cfgPath, Mode: AgentModeList}
+const (
+	sparklineSamples = 40
+	agentEventsShown = 10
+)
+
+func NewAgentsModel(store storage.Store, cfg *config.Config, cfgPath string, tcpServer *tcp.Server) AgentsModel {
+	return AgentsModel{
+		store: store, tcp: tcpServer, agents: services.NewAgentService(cfg, cfgPath, store),
+		cfg: cfg, cfgPath: cfgPath, Mode: AgentModeList,
+	}
 }
 
 func (m AgentsModel) Init() tea.Cmd {
@@ -93,7 +127,25 @@ func (m AgentsModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m.updateResult(msg)
 		case AgentModeConfirmDelete:
 			return m.updateConfirmDelete(msg)
+		case AgentModeConfirmMaintenance:
+			return m.updateConfirmMaintenance(msg)
+		case AgentModeConfirmRotate:
+			return m.updateConfirmRotate(msg)
+		case AgentModeLabels:
+			return m.updateLabels(msg)
+		case AgentModeExec:
+			return m.updateExecMenu(msg)
+		case AgentModeExecParams:
+			return m.updateExecParams(msg)
+		case AgentModeExecOutput:
+			return m.updateExecOutput(msg)
+		case AgentModeRename:
+			return m.updateRename(msg)
+		case AgentModeReassign:
+			return m.updateReassign(msg)
 		}
+	case execStartedMsg, execTickMsg, execDoneMsg:
+		return m.handleExecMsg(msg)
 	case SpinnerTickMsg:
 		m.SpinnerFrame++
 		if m.Loading {
@@ -101,7 +153,7 @@ func (m AgentsModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 	case AgentResultMsg:
 		if msg.Success {
-			m.Result = AgentAddResult{Name: msg.Name, ID: msg.ID, Token: msg.Token}
+			m.Result = AgentAddResult{Name: msg.Name, ID: msg.ID, Token: msg.Token, Rotated: msg.Rotated, Expires: msg.Expires}
 			m.Mode = AgentModeResult
 			m.err = nil
 		} else {
@@ -109,12 +161,37 @@ func (m AgentsModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		m.Loading = false
 		return m, m.fetchAgents
+	case agentMaintenanceMsg:
+		if msg.Err != nil {
+			pushError(&m.errs, opError("setting maintenance mode", msg.Err))
+			return m, nil
+		}
+		return m, m.fetchAgents
+	case agentRenameMsg:
+		if msg.Err != nil {
+			m.err = msg.Err
+			return m, nil
+		}
+		m.Mode = AgentModeList
+		m.Input = ""
+		m.err = nil
+		renamed := func() tea.Msg { return toast(components.ToastSuccess, "Agent renamed to '"+msg.Name+"'") }
+		return m, tea.Sequence(renamed, m.fetchAgents)
+	case agentLabelsMsg:
+		if msg.Err != nil {
+			m.err = msg.Err
+			return m, nil
+		}
+		m.Mode = AgentModeList
+		m.err = nil
+		return m, m.fetchAgents
 	case []AgentData:
 		m.Agents = msg
 		m.Loading = false
+		m.errs.Resolve("loading agents")
 		return m, nil
 	case error:
-		m.err = msg
+		pushError(&m.errs, msg)
 		m.Loading = false
 		return m, nil
 	}
@@ -127,6 +204,9 @@ func (m AgentsModel) spinnerTick() tea.Msg {
 }
 
 func (m AgentsModel) updateList(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if dismissError(&m.errs, msg.String()) {
+		return m, nil
+	}
 	switch msg.String() {
 	case "up", "k":
 		if m.Cursor > 0 {
@@ -144,8 +224,36 @@ func (m AgentsModel) updateList(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		m.err = nil
 	case "-", "delete", "backspace":
 		if len(m.Agents) > 0 {
-			m.Dialog = components.DeleteAgentDialog(m.Agents[m.Cursor].Name)
-			m.Mode = AgentModeConfirmDelete
+			return m.confirmDelete(m.Agents[m.Cursor])
+		}
+	case "N":
+		if len(m.Agents) > 0 {
+			m.Mode = AgentModeRename
+			m.Input = m.Agents[m.Cursor].Name
+			m.err = nil
+		}
+	case "t":
+		if len(m.Agents) > 0 {
+			m.Mode = AgentModeLabels
+			m.Input = models.FormatLabels(m.Agents[m.Cursor].Labels)
+			m.err = nil
+		}
+	case "m":
+		if len(m.Agents) > 0 {
+			a := m.Agents[m.Cursor]
+			m.Dialog = components.MaintenanceDialog(a.Name, !a.Maintenance)
+			m.Mode = AgentModeConfirmMaintenance
+		}
+	case "T":
+		if len(m.Agents) > 0 {
+			m.Dialog = components.RotateTokenDialog(m.Agents[m.Cursor].Name, m.cfg.TokenGrace())
+			m.Mode = AgentModeConfirmRotate
+		}
+	case "!":
+		return m.openExec()
+	case "R":
+		if len(m.Agents) > 0 {
+			return m, m.requestInventory(m.Agents[m.Cursor])
 		}
 	case "r":
 		m.Loading = true
@@ -154,6 +262,226 @@ func (m AgentsModel) updateList(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+type agentMaintenanceMsg struct {
+	Err error
+}
+
+func (m AgentsModel) updateConfirmMaintenance(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc", "n":
+		m.Mode = AgentModeList
+		m.Dialog.Visible = false
+	case "left", "right", "h", "l", "tab":
+		m.Dialog.ToggleSelection()
+	case "enter", "y":
+		confirmed := msg.String() == "y" || m.Dialog.IsConfirmed()
+		m.Mode = AgentModeList
+		m.Dialog.Visible = false
+		if confirmed {
+			a := m.Agents[m.Cursor]
+			return m, m.setMaintenance(a.ID, !a.Maintenance)
+		}
+	}
+	return m, nil
+}
+
+func (m AgentsModel) updateConfirmRotate(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc", "n":
+		m.Mode = AgentModeList
+		m.Dialog.Visible = false
+	case "left", "right", "h", "l", "tab":
+		m.Dialog.ToggleSelection()
+	case "enter", "y":
+		confirmed := msg.String() == "y" || m.Dialog.IsConfirmed()
+		m.Mode = AgentModeList
+		m.Dialog.Visible = false
+		if confirmed {
+			return m, m.rotateToken(m.Agents[m.Cursor])
+		}
+	}
+	return m, nil
+}
+
+func (m AgentsModel) rotateToken(agent AgentData) tea.Cmd {
+	return func() tea.Msg {
+		if m.cfg.GetAgent(agent.ID) == nil {
+			return toastError("rotating the token of "+agent.Name, fmt.Errorf("agent %s not found in config", agent.ID))
+		}
+		snap := m.cfg.Snapshot()
+		token, err := m.cfg.RotateAgentToken(agent.ID)
+		if err != nil {
+			return toastError("rotating the token of "+agent.Name, err)
+		}
+		if err := m.cfg.Save(m.cfgPath); err != nil {
+			m.cfg.Restore(snap)
+			return toastError("rotating the token of "+agent.Name, err)
+		}
+		m.tcp.CloseRetiredSessions()
+		cfgAgent := m.cfg.GetAgent(agent.ID)
+		if cfgAgent == nil {
+			return toastError("rotating the token of "+agent.Name, fmt.Errorf("agent %s not found in config", agent.ID))
+		}
+		return AgentResultMsg{
+			Success: true, Name: agent.Name, ID: agent.ID, Token: token,
+			Rotated: true, Expires: cfgAgent.PreviousTokenExpires,
+		}
+	}
+}
+
+func (m AgentsModel) requestInventory(agent AgentData) tea.Cmd {
+	request := func() tea.Msg {
+		if err := m.tcp.RequestInventory(agent.ID); err != nil {
+			return toastError("refreshing containers of "+agent.Name, err)
+		}
+		return toast(components.ToastSuccess, "Container inventory requested from "+agent.Name)
+	}
+	refresh := func() tea.Msg {
+		time.Sleep(2 * time.Second)
+		return m.fetchAgents()
+	}
+	return tea.Sequence(request, refresh)
+}
+
+func (m AgentsModel) setMaintenance(id string, enabled bool) tea.Cmd {
+	return func() tea.Msg {
+		if !m.cfg.SetAgentMaintenance(id, enabled) {
+			return agentMaintenanceMsg{Err: fmt.Errorf("agent %s not found in config", id)}
+		}
+		if err := m.cfg.Save(m.cfgPath); err != nil {
+			m.cfg.SetAgentMaintenance(id, !enabled)
+			return agentMaintenanceMsg{Err: err}
+		}
+		if err := m.store.SetAgentMaintenance(id, enabled); err != nil {
+			return agentMaintenanceMsg{Err: err}
+		}
+		return agentMaintenanceMsg{}
+	}
+}
+
+type agentLabelsMsg struct {
+	Err error
+}
+
+func (m AgentsModel) updateLabels(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.Mode = AgentModeList
+		m.Input = ""
+		m.err = nil
+	case "enter":
+		labels, err := models.ParseLabels(m.Input)
+		if err != nil {
+			m.err = err
+			return m, nil
+		}
+		return m, m.saveLabels(m.Agents[m.Cursor].ID, labels)
+	case "backspace":
+		if len(m.Input) > 0 {
+			m.Input = m.Input[:len(m.Input)-1]
+		}
+	default:
+		inputStr := msg.String()
+		if len(inputStr) == 1 && len(m.Input) < 120 {
+			m.Input += inputStr
+		}
+	}
+	return m, nil
+}
+
+func (m AgentsModel) saveLabels(id string, labels map[string]string) tea.Cmd {
+	return func() tea.Msg {
+		if !m.cfg.SetAgentLabels(id, labels) {
+			return agentLabelsMsg{Err: fmt.Errorf("agent %s not found in config", id)}
+		}
+		if err := m.cfg.Save(m.cfgPath); err != nil {
+			return agentLabelsMsg{Err: err}
+		}
+		if err := m.store.SetAgentLabels(id, labels); err != nil {
+			return agentLabelsMsg{Err: err}
+		}
+		return agentLabelsMsg{}
+	}
+}
+
+type agentRenameMsg struct {
+	Name string
+	Err  error
+}
+
+func (m AgentsModel) updateRename(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.Mode = AgentModeList
+		m.Input = ""
+		m.err = nil
+	case "enter":
+		if strings.TrimSpace(m.Input) != "" {
+			return m, m.renameAgent(m.Agents[m.Cursor].ID, m.Input)
+		}
+	case "backspace":
+		if len(m.Input) > 0 {
+			m.Input = m.Input[:len(m.Input)-1]
+		}
+	default:
+		inputStr := msg.String()
+		if len(m.Input)+len(inputStr) <= 30 {
+			m.Input += inputStr
+		}
+	}
+	return m, nil
+}
+
+func (m AgentsModel) renameAgent(id, name string) tea.Cmd {
+	return func() tea.Msg {
+		name = strings.TrimSpace(name)
+		return agentRenameMsg{Name: name, Err: m.agents.RenameAgent(id, name)}
+	}
+}
+
+func (m AgentsModel) confirmDelete(agent AgentData) (tea.Model, tea.Cmd) {
+	repos := m.cfg.AgentRepositories(agent.ID)
+	if len(repos) == 0 {
+		m.Dialog = components.DeleteAgentDialog(agent.Name)
+		m.Mode = AgentModeConfirmDelete
+		return m, nil
+	}
+	m.Reassign = ReassignState{Repos: repos}
+	for _, a := range m.Agents {
+		if a.ID != agent.ID {
+			m.Reassign.Targets = append(m.Reassign.Targets, a)
+		}
+	}
+	m.Mode = AgentModeReassign
+	return m, nil
+}
+
+func (m AgentsModel) updateReassign(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.Mode = AgentModeList
+		m.Reassign = ReassignState{}
+	case "up", "k":
+		if m.Reassign.Cursor > 0 {
+			m.Reassign.Cursor--
+		}
+	case "down", "j":
+		if m.Reassign.Cursor < len(m.Reassign.Targets)-1 {
+			m.Reassign.Cursor++
+		}
+	case "enter":
+		if len(m.Reassign.Targets) == 0 {
+			return m, nil
+		}
+		target := m.Reassign.Targets[m.Reassign.Cursor]
+		m.Mode = AgentModeList
+		m.Reassign = ReassignState{}
+		m.Loading = true
+		return m, tea.Batch(m.deleteAgent(m.Agents[m.Cursor], target), m.spinnerTick)
+	}
+	return m, nil
+}
+
 func (m AgentsModel) updateConfirmDelete(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	switch msg.String() {
 	case "esc", "n":
@@ -166,7 +494,7 @@ func (m AgentsModel) updateConfirmDelete(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			m.Dialog.Visible = false
 			m.Mode = AgentModeList
 			m.Loading = true
-			return m, tea.Batch(m.deleteAgent(m.Agents[m.Cursor].ID), m.spinnerTick)
+			return m, tea.Batch(m.deleteAgent(m.Agents[m.Cursor], AgentData{}), m.spinnerTick)
 		} else {
 			m.Mode = AgentModeList
 			m.Dialog.Visible = false
@@ -175,7 +503,7 @@ func (m AgentsModel) updateConfirmDelete(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		m.Dialog.Visible = false
 		m.Mode = AgentModeList
 		m.Loading = true
-		return m, tea.Batch(m.deleteAgent(m.Agents[m.Cursor].ID), m.spinnerTick)
+		return m, tea.Batch(m.deleteAgent(m.Agents[m.Cursor], AgentData{}), m.spinnerTick)
 	}
 	return m, nil
 }
@@ -222,26 +550,33 @@ func (m AgentsModel) addAgent(name string) tea.Cmd {
 			return AgentResultMsg{Success: false, Error: err}
 		}
 		agent := &models.Agent{
-			ID: id, Name: name, Token: token, Status: models.AgentOffline, RegisteredAt: time.Now(),
+			ID: id, Name: name, TokenHash: helper.HashToken(token), Status: models.AgentOffline, RegisteredAt: time.Now(),
+		}
+		result := AgentResultMsg{Success: true, Name: name, ID: id, Token: token}
+		if err := m.store.CreateAgent(agent); err != nil {
+			result.Error = opError("storing agent "+name, err)
 		}
-		m.store.CreateAgent(agent)
-		return AgentResultMsg{Success: true, Name: name, ID: id, Token: token}
+		return result
 	}
 }
 
-func (m AgentsModel) deleteAgent(id string) tea.Cmd {
-	return func() tea.Msg {
-		m.cfg.RemoveAgent(id)
-		m.cfg.Save(m.cfgPath)
-		m.store.DeleteAgent(id)
-		return m.fetchAgents()
+func (m AgentsModel) deleteAgent(agent, reassignTo AgentData) tea.Cmd {
+	remove := func() tea.Msg {
+		if err := m.agents.DeleteAgent(agent.ID, reassignTo.ID); err != nil {
+			return toastError("removing agent "+agent.Name, err)
+		}
+		if reassignTo.ID != "" {
+			return toast(components.ToastSuccess, "Agent '"+agent.Name+"' removed, repositories moved to '"+reassignTo.Name+"'")
+		}
+		return toast(components.ToastSuccess, "Agent '"+agent.Name+"' removed")
 	}
+	return tea.Sequence(remove, m.fetchAgents)
 }
 
 func (m AgentsModel) fetchAgents() tea.Msg {
 	agents, err := m.store.GetAllAgents()
 	if err != nil {
-		return err
+		return opError("loading agents", err)
 	}
 	var data []AgentData
 	for _, a := range agents {
@@ -249,24 +584,54 @@ func (m AgentsModel) fetchAgents() tea.Msg {
 		containerData := make([]ContainerData, len(containers))
 		for i, c := range containers {
 			containerData[i] = ContainerData{
-				Name: c.Name, Running: c.Status == "running", Healthy: c.Health == "healthy",
-				CPU: c.CPUPercent, Memory: fmt.Sprintf("%dMB", c.MemoryUsage/1024/1024),
+				Name: c.Name, Project: c.Project, Service: c.Service, Running: c.Status == "running", Healthy: c.Health == "healthy",
+				CPU: c.CPUPercent, Memory: fmt.Sprintf("%dMB", c.MemoryUsage/1024/1024), Stale: c.StatsStale,
 			}
 		}
 		uptime := time.Since(a.LastHeartbeat).Round(time.Second).String()
 		if a.Status == "offline" {
 			uptime = a.LastHeartbeat.Format("2006-01-02 15:04")
 		}
-		cpu, mem, disk := 0.0, 0.0, 0.0
+		cpu, mem, disk, queued := 0.0, 0.0, 0.0, 0
+		var agentDisk *models.DockerDiskUsage
+		lowDisk := false
 		if a.Metrics != nil {
 			cpu = a.Metrics.CPUPercent
 			mem = a.Metrics.MemoryPercent
 			disk = a.Metrics.DiskPercent
+			queued = a.Metrics.QueuedDeploys
+			agentDisk = a.Metrics.DockerDisk
+			lowDisk = a.Metrics.LowDisk && a.Status == "online"
+		}
+		agent := AgentData{
+			ID: a.ID, Name: a.Name, Host: a.Host, MachineID: a.MachineID, Version: a.Version, Uptime: uptime,
+			Online: a.Status == "online", CPU: cpu, Memory: mem, Disk: disk, Queued: queued, Containers: containerData,
+			Degraded: a.Degraded, LowDisk: lowDisk, ClockSkew: time.Duration(a.ClockSkewMs) * time.Millisecond,
+			Labels: a.Labels, DockerDisk: agentDisk, Reclaimed: a.ReclaimedBytes, CleanedAt: a.LastCleanup,
+			Maintenance: a.Maintenance,
+		}
+		if reason, ok := m.cfg.AgentMaintenance(a.ID, time.Now()); ok {
+			agent.Frozen = reason
+		}
+		if depth, dropped, ok := m.tcp.SendQueueStats(a.ID); ok && (depth > 0 || dropped > 0) {
+			agent.SendQueue = fmt.Sprintf("%d/%d queued, %d dropped", depth, tcp.SendQueueSize, dropped)
+			agent.QueueWarn = depth >= tcp.SendQueueSize/2
+		}
+		if stats, ok := m.tcp.GetConnectionStats(a.ID); ok {
+			agent.Traffic = formatTraffic(stats)
+			agent.WriteErrors = stats.WriteErrors > 0
+		}
+		if history, err := m.store.GetMetricsHistory(a.ID, time.Now().Add(-time.Hour)); err == nil {
+			if len(history) > sparklineSamples {
+				history = history[len(history)-sparklineSamples:]
+			}
+			for _, h := range history {
+				agent.CPUHistory = append(agent.CPUHistory, h.CPU)
+				agent.MemHistory = append(agent.MemHistory, h.Memory)
+			}
 		}
-		data = append(data, AgentData{
-			ID: a.ID, Name: a.Name, Host: a.Host, Version: a.Version, Uptime: uptime,
-			Online: a.Status == "online", CPU: cpu, Memory: mem, Disk: disk, Containers: containerData,
-		})
+		agent.Events, _ = m.store.GetAgentEvents(a.ID, agentEventsShown)
+		data = append(data, agent)
 	}
 	return data
 }
@@ -280,8 +645,20 @@ func (m AgentsModel) View() string {
 		return m.viewAdd()
 	case AgentModeResult:
 		return m.viewResult()
-	case AgentModeConfirmDelete:
+	case AgentModeConfirmDelete, AgentModeConfirmMaintenance, AgentModeConfirmRotate:
 		return m.viewList() + components.ConfirmDialog(m.Dialog, m.Width, m.Height)
+	case AgentModeLabels:
+		return m.viewLabels()
+	case AgentModeRename:
+		return m.viewRename()
+	case AgentModeReassign:
+		return m.viewReassign()
+	case AgentModeExec:
+		return m.viewExecMenu()
+	case AgentModeExecParams:
+		return m.viewExecParams()
+	case AgentModeExecOutput:
+		return m.viewExecOutput()
 	default:
 		return m.viewList()
 	}
@@ -309,8 +686,8 @@ func (m AgentsModel) viewList() string {
 	statsContent.WriteString(components.Stats(online, offline, len(m.Agents)))
 	b.WriteString(components.Wrap(statsContent.String(), w) + "\n\n")
 
-	if m.err != nil {
-		b.WriteString(components.MsgError(m.err.Error(), w) + "\n\n")
+	if m.errs.Len() > 0 {
+		b.WriteString(m.errs.View(w) + "\n\n")
 	}
 
 	b.WriteString(components.Section("AGENT LIST", w) + "\n\n")
@@ -328,18 +705,38 @@ func (m AgentsModel) viewList() string {
 			selected := i == m.Cursor
 			if selected && m.Expanded {
 				card := components.AgentCardData{
-					Name: a.Name, Host: a.Host, Version: a.Version, Online: a.Online,
-					CPU: a.CPU, Memory: a.Memory, Disk: a.Disk, Selected: true,
+					Name: a.Name, Host: a.Host, MachineID: a.MachineID, Version: a.Version, Online: a.Online,
+					Degraded: a.Degraded, LowDisk: a.LowDisk, ClockSkew: a.ClockSkew, SkewWarn: a.ClockSkew.Abs() > logic.ClockSkewThreshold,
+					CPU: a.CPU, Memory: a.Memory, Disk: a.Disk, Queued: a.Queued, Labels: models.FormatLabels(a.Labels), Selected: true,
+					CPUHistory: a.CPUHistory, MemHistory: a.MemHistory, DockerDisk: formatDockerDisk(a.DockerDisk),
+					Cleanup: formatCleanup(a.Reclaimed, a.CleanedAt), Frozen: a.Frozen, SendQueue: a.SendQueue, QueueWarn: a.QueueWarn,
+					Traffic: a.Traffic, WriteWarn: a.WriteErrors,
 					Containers: make([]components.ContainerInfo, len(a.Containers)),
+					Events:     make([]components.EventInfo, len(a.Events)),
+				}
+				for j, e := range a.Events {
+					card.Events[j] = components.EventInfo{Time: e.CreatedAt.Format("01-02 15:04:05"), Type: e.Type, Detail: e.Detail}
 				}
 				for j, c := range a.Containers {
 					card.Containers[j] = components.ContainerInfo{
-						Name: c.Name, Running: c.Running, Healthy: c.Healthy, CPU: c.CPU, Memory: c.Memory,
+						Name: c.Name, Project: c.Project, Service: c.Service, Running: c.Running, Healthy: c.Healthy, CPU: c.CPU, Memory: c.Memory, Stale: c.Stale,
 					}
 				}
 				listContent.WriteString(components.AgentCard(card, w-8) + "\n")
 			} else {
-				row := components.AgentRow(a.Name, a.Online, a.CPU, a.Memory, a.Disk, a.Uptime, selected, w)
+				row := components.AgentRow(a.Name, a.Online, a.CPU, a.Memory, a.Disk, a.Uptime, selected, w-8)
+				if a.Frozen != "" {
+					row += "  " + components.Badge("maintenance")
+				}
+				if a.Degraded {
+					row += "  " + components.Badge("degraded")
+				}
+				if a.ClockSkew.Abs() > logic.ClockSkewThreshold {
+					row += "  " + components.Badge("skew")
+				}
+				if a.LowDisk {
+					row += "  " + components.Badge("low_disk")
+				}
 				if selected {
 					listContent.WriteString(components.SelectedRow(row, true) + "\n")
 				} else {
@@ -360,7 +757,7 @@ func (m AgentsModel) viewList() string {
 
 	content += "\n" + styles.Line(w) + "\n"
 	content += components.Help([][]string{
-		{"↑↓", "navigate"}, {"enter", "expand"}, {"l", "logs"}, {"+", "add"}, {"-", "remove"}, {"r", "refresh"}, {"esc", "back"},
+		{"↑↓", "navigate"}, {"enter", "expand"}, {"l", "logs"}, {"t", "labels"}, {"N", "rename"}, {"T", "rotate token"}, {"m", "maintenance"}, {"!", "exec"}, {"R", "sync containers"}, {"+", "add"}, {"-", "remove"}, {"r", "refresh"}, {"esc", "back"},
 	})
 
 	return content
@@ -394,14 +791,136 @@ func (m AgentsModel) viewAdd() string {
 	return content
 }
 
+func (m AgentsModel) viewLabels() string {
+	var b strings.Builder
+	w := m.Width
+
+	name := ""
+	if m.Cursor < len(m.Agents) {
+		name = m.Agents[m.Cursor].Name
+	}
+
+	b.WriteString("\n")
+	b.WriteString(components.ViewHeader(w, "Dashboard", "Agents", name, "Labels") + "\n\n")
+
+	b.WriteString(components.Section("AGENT LABELS", w) + "\n\n")
+
+	var formContent strings.Builder
+	formContent.WriteString(components.Input("Comma-separated key=value pairs, e.g. env=prod,region=eu", m.Input, true, w-8))
+	formContent.WriteString("\n  " + styles.MutedStyle.Render("Repositories can target agents by label with agent_selector"))
+	if m.err != nil {
+		formContent.WriteString("\n\n" + styles.ErrorStyle.Render(styles.IconError) + "  " + styles.ErrorStyle.Render(m.err.Error()))
+	}
+	b.WriteString(components.Wrap(formContent.String(), w) + "\n")
+
+	content := b.String()
+	lines := helper.CountLines(content)
+	for i := 0; i < m.Height-lines-3; i++ {
+		content += "\n"
+	}
+
+	content += "\n" + styles.Line(w) + "\n"
+	content += components.Help([][]string{{"enter", "save"}, {"esc", "cancel"}})
+
+	return content
+}
+
+func (m AgentsModel) viewRename() string {
+	var b strings.Builder
+	w := m.Width
+
+	name := ""
+	if m.Cursor < len(m.Agents) {
+		name = m.Agents[m.Cursor].Name
+	}
+
+	b.WriteString("\n")
+	b.WriteString(components.ViewHeader(w, "Dashboard", "Agents", name, "Rename") + "\n\n")
+
+	b.WriteString(components.Section("AGENT NAME", w) + "\n\n")
+
+	var formContent strings.Builder
+	formContent.WriteString(components.Input("New name for this agent", m.Input, true, w-8))
+	formContent.WriteString("\n  " + styles.MutedStyle.Render("The agent keeps its ID and token, no reconnect needed"))
+	if m.err != nil {
+		formContent.WriteString("\n\n" + styles.ErrorStyle.Render(styles.IconError) + "  " + styles.ErrorStyle.Render(m.err.Error()))
+	}
+	b.WriteString(components.Wrap(formContent.String(), w) + "\n")
+
+	content := b.String()
+	lines := helper.CountLines(content)
+	for i := 0; i < m.Height-lines-3; i++ {
+		content += "\n"
+	}
+
+	content += "\n" + styles.Line(w) + "\n"
+	content += components.Help([][]string{{"enter", "save"}, {"esc", "cancel"}})
+
+	return content
+}
+
+func (m AgentsModel) viewReassign() string {
+	var b strings.Builder
+	w := m.Width
+
+	name := ""
+	if m.Cursor < len(m.Agents) {
+		name = m.Agents[m.Cursor].Name
+	}
+
+	b.WriteString("\n")
+	b.WriteString(components.ViewHeader(w, "Dashboard", "Agents", name, "Delete") + "\n\n")
+
+	b.WriteString(components.MsgWarning(fmt.Sprintf("'%s' still deploys %d repositories", name, len(m.Reassign.Repos)), w) + "\n\n")
+
+	b.WriteString(components.Section("ASSIGNED REPOSITORIES", w) + "\n\n")
+	var repoContent strings.Builder
+	for _, r := range m.Reassign.Repos {
+		repoContent.WriteString("  " + styles.MutedStyle.Render(styles.IconDash) + " " + r + "\n")
+	}
+	b.WriteString(components.Wrap(repoContent.String(), w) + "\n\n")
+
+	b.WriteString(components.Section("REASSIGN TO", w) + "\n\n")
+	var listContent strings.Builder
+	if len(m.Reassign.Targets) == 0 {
+		listContent.WriteString("  " + styles.MutedStyle.Render("No other agents available") + "\n")
+		listContent.WriteString("  " + styles.SubtleStyle.Render("Add an agent first, or remove the repositories"))
+	} else {
+		for i, a := range m.Reassign.Targets {
+			listContent.WriteString(components.AgentRow(a.Name, a.Online, 0, 0, 0, "", i == m.Reassign.Cursor, w-8) + "\n")
+		}
+	}
+	b.WriteString(components.Wrap(listContent.String(), w) + "\n")
+
+	content := b.String()
+	lines := helper.CountLines(content)
+	for i := 0; i < m.Height-lines-3; i++ {
+		content += "\n"
+	}
+
+	content += "\n" + styles.Line(w) + "\n"
+	if len(m.Reassign.Targets) == 0 {
+		content += components.Help([][]string{{"esc", "cancel"}})
+	} else {
+		content += components.Help([][]string{{"↑↓", "select"}, {"enter", "reassign and delete"}, {"esc", "cancel"}})
+	}
+
+	return content
+}
+
 func (m AgentsModel) viewResult() string {
 	var b strings.Builder
 	w := m.Width
 
+	title, done := "Agent Created", "Agent created successfully"
+	if m.Result.Rotated {
+		title, done = "Token Rotated", "Token rotated successfully"
+	}
+
 	b.WriteString("\n")
-	b.WriteString(components.ViewHeader(w, "Dashboard", "Agents", "Agent Created") + "\n\n")
+	b.WriteString(components.ViewHeader(w, "Dashboard", "Agents", title) + "\n\n")
 
-	b.WriteString(components.MsgSuccess("Agent created successfully", w) + "\n\n")
+	b.WriteString(components.MsgSuccess(done, w) + "\n\n")
 
 	b.WriteString(components.Section("AGENT DETAILS", w) + "\n\n")
 	b.WriteString(components.Card(m.Result.Name, []components.CardLine{
@@ -411,12 +930,23 @@ func (m AgentsModel) viewResult() string {
 	b.WriteString(components.Section("TOKEN", w) + "\n\n")
 	b.WriteString(components.Token(m.Result.Token, w) + "\n\n")
 	b.WriteString(components.MsgWarning("Save this token! It won't be shown again.", w) + "\n\n")
+	if m.Result.Rotated {
+		grace := "The old token no longer works, reconnect the agent with the new one."
+		if !m.Result.Expires.IsZero() && m.Result.Expires.After(time.Now()) {
+			grace = "The old token keeps working until " + m.Result.Expires.Format("2006-01-02 15:04") + ", update the agent before then."
+		}
+		b.WriteString("  " + styles.MutedStyle.Render(grace) + "\n\n")
+	}
 
 	b.WriteString(components.Section("NEXT STEPS", w) + "\n\n")
 	var stepsContent strings.Builder
-	stepsContent.WriteString("  1. Install agent on your server:\n")
-	stepsContent.WriteString("     " + styles.PrimaryStyle.Render("curl -sSL https://uruflow.io/install | sh") + "\n\n")
-	stepsContent.WriteString("  2. Connect with:\n")
+	if m.Result.Rotated {
+		stepsContent.WriteString("  1. Update the token on the agent host:\n")
+	} else {
+		stepsContent.WriteString("  1. Install agent on your server:\n")
+		stepsContent.WriteString("     " + styles.PrimaryStyle.Render("curl -sSL https://uruflow.io/install | sh") + "\n\n")
+		stepsContent.WriteString("  2. Connect with:\n")
+	}
 	tok := m.Result.Token
 	if len(tok) > 16 {
 		tok = tok[:16] + "..."
@@ -435,3 +965,45 @@ func (m AgentsModel) viewResult() string {
 
 	return content
 }
+
+func formatCleanup(reclaimed uint64, at time.Time) string {
+	if at.IsZero() {
+		return ""
+	}
+	return fmt.Sprintf("reclaimed %s, last %s", helper.FormatBytes(reclaimed), helper.FormatTimeAgo(at))
+}
+
+func formatTraffic(s tcp.ConnectionStats) string {
+	last := s.LastActivity()
+	if last.IsZero() {
+		return ""
+	}
+	ago := helper.FormatTimeAgo(last)
+	if since := time.Since(last); since < time.Minute {
+		ago = fmt.Sprintf("%ds ago", int(since.Seconds()))
+	}
+	out := fmt.Sprintf("last msg %s, %s msgs, %s", ago, formatCount(s.MessagesIn+s.MessagesOut), helper.FormatBytes(uint64(s.BytesIn+s.BytesOut)))
+	if s.WriteErrors > 0 {
+		out += fmt.Sprintf(", %d write errors", s.WriteErrors)
+	}
+	return out
+}
+
+func formatCount(n int) string {
+	switch {
+	case n >= 1_000_000:
+		return fmt.Sprintf("%.1fM", float64(n)/1_000_000)
+	case n >= 1000:
+		return fmt.Sprintf("%.1fk", float64(n)/1000)
+	}
+	return fmt.Sprintf("%d", n)
+}
+
+func formatDockerDisk(u *models.DockerDiskUsage) string {
+	if u == nil {
+		return ""
+	}
+	return fmt.Sprintf("%s images  %s containers  %s volumes  %s build cache",
+		helper.FormatBytes(u.Images), helper.FormatBytes(u.Containers),
+		helper.FormatBytes(u.Volumes), helper.FormatBytes(u.BuildCache))
+}
@@ -20,10 +20,12 @@ package views
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/urustack/uruflow/internal/models"
 	"github.com/urustack/uruflow/internal/storage"
 	"github.com/urustack/uruflow/internal/tui/components"
 	"github.com/urustack/uruflow/internal/tui/styles"
@@ -37,23 +39,36 @@ const (
 	AlertsModeConfirmResolve
 )
 
+var alertSeverities = []string{
+	string(models.SeverityInfo),
+	string(models.SeverityWarning),
+	string(models.SeverityCritical),
+}
+
 type AlertsModel struct {
-	store        storage.Store
-	Width        int
-	Height       int
-	Active       []AlertData
-	Recent       []AlertData
-	Cursor       int
-	Expanded     bool
-	Mode         AlertsMode
-	Dialog       components.Dialog
-	Loading      bool
-	SpinnerFrame int
-	err          error
+	store          storage.Store
+	Width          int
+	Height         int
+	Active         []AlertData
+	Recent         []AlertData
+	Cursor         int
+	Expanded       bool
+	ShowWebhooks   bool
+	Webhooks       []WebhookData
+	Mode           AlertsMode
+	Dialog         components.Dialog
+	Loading        bool
+	SpinnerFrame   int
+	Selected       map[string]bool
+	TypeFilter     string
+	SeverityFilter string
+	types          []string
+	pending        []string
+	errs           components.ErrorStack
 }
 
 func NewAlertsModel(store storage.Store) AlertsModel {
-	return AlertsModel{store: store}
+	return AlertsModel{store: store, Selected: make(map[string]bool)}
 }
 
 func (m AlertsModel) Init() tea.Cmd {
@@ -66,6 +81,9 @@ func (m AlertsModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		if m.Mode == AlertsModeConfirmResolve {
 			return m.updateConfirmResolve(msg)
 		}
+		if dismissError(&m.errs, msg.String()) {
+			return m, nil
+		}
 		switch msg.String() {
 		case "up", "k":
 			if m.Cursor > 0 {
@@ -76,13 +94,45 @@ func (m AlertsModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			if m.Cursor < total-1 {
 				m.Cursor++
 			}
-		case "x":
+		case " ":
 			if m.Cursor < len(m.Active) {
+				id := m.Active[m.Cursor].ID
+				if m.Selected[id] {
+					delete(m.Selected, id)
+				} else {
+					m.Selected[id] = true
+				}
+			}
+		case "a":
+			if m.allSelected() {
+				m.Selected = make(map[string]bool)
+			} else {
+				for _, a := range m.Active {
+					m.Selected[a.ID] = true
+				}
+			}
+		case "x":
+			if ids := m.selectedIDs(); len(ids) > 0 {
+				m.pending = ids
+				m.Dialog = components.ResolveAlertsDialog(len(ids))
+				m.Mode = AlertsModeConfirmResolve
+			} else if m.Cursor < len(m.Active) {
+				m.pending = []string{m.Active[m.Cursor].ID}
 				m.Dialog = components.ResolveAlertDialog(m.Active[m.Cursor].Type)
 				m.Mode = AlertsModeConfirmResolve
 			}
+		case "t":
+			m.TypeFilter = cycleFilter(m.types, m.TypeFilter)
+			m.Loading = true
+			return m, tea.Batch(m.fetchAlerts, m.spinnerTick)
+		case "v":
+			m.SeverityFilter = cycleFilter(alertSeverities, m.SeverityFilter)
+			m.Loading = true
+			return m, tea.Batch(m.fetchAlerts, m.spinnerTick)
 		case "e":
 			m.Expanded = !m.Expanded
+		case "w":
+			m.ShowWebhooks = !m.ShowWebhooks
 		case "r":
 			m.Loading = true
 			return m, tea.Batch(m.fetchAlerts, m.spinnerTick)
@@ -93,12 +143,18 @@ func (m AlertsModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, m.spinnerTick
 		}
 	case alertsMsg:
+		id := m.cursorID()
 		m.Active = msg.Active
 		m.Recent = msg.Recent
+		m.Webhooks = msg.Webhooks
+		m.types = mergeTypes(m.types, msg.Types)
+		m.restoreCursor(id)
+		m.pruneSelection()
 		m.Loading = false
+		m.errs.Resolve("loading alerts")
 		return m, nil
 	case error:
-		m.err = msg
+		pushError(&m.errs, msg)
 		m.Loading = false
 		return m, nil
 	}
@@ -119,49 +175,158 @@ func (m AlertsModel) updateConfirmResolve(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		m.Dialog.ToggleSelection()
 	case "enter":
 		if m.Dialog.IsConfirmed() {
-			m.Dialog.Visible = false
-			m.Mode = AlertsModeList
-			m.Loading = true
-			return m, tea.Batch(m.resolveAlert(m.Active[m.Cursor].ID), m.spinnerTick)
-		} else {
-			m.Mode = AlertsModeList
-			m.Dialog.Visible = false
+			return m.confirmResolve()
 		}
-	case "y":
-		m.Dialog.Visible = false
 		m.Mode = AlertsModeList
-		m.Loading = true
-		return m, tea.Batch(m.resolveAlert(m.Active[m.Cursor].ID), m.spinnerTick)
+		m.Dialog.Visible = false
+	case "y":
+		return m.confirmResolve()
 	}
 	return m, nil
 }
 
+func (m AlertsModel) confirmResolve() (tea.Model, tea.Cmd) {
+	m.Dialog.Visible = false
+	m.Mode = AlertsModeList
+	ids := m.pending
+	m.pending = nil
+	for _, id := range ids {
+		delete(m.Selected, id)
+	}
+	m.Loading = true
+	return m, tea.Batch(tea.Sequence(m.resolveAlerts(ids), m.fetchAlerts), m.spinnerTick)
+}
+
+func (m AlertsModel) cursorID() string {
+	if m.Cursor < len(m.Active) {
+		return m.Active[m.Cursor].ID
+	}
+	if i := m.Cursor - len(m.Active); i < len(m.Recent) {
+		return m.Recent[i].ID
+	}
+	return ""
+}
+
+func (m *AlertsModel) restoreCursor(id string) {
+	for i, a := range m.Active {
+		if a.ID == id {
+			m.Cursor = i
+			return
+		}
+	}
+	for i, a := range m.Recent {
+		if a.ID == id {
+			m.Cursor = len(m.Active) + i
+			return
+		}
+	}
+	if total := len(m.Active) + len(m.Recent); m.Cursor >= total {
+		m.Cursor = max(total-1, 0)
+	}
+}
+
+func (m *AlertsModel) pruneSelection() {
+	visible := make(map[string]bool, len(m.Active))
+	for _, a := range m.Active {
+		visible[a.ID] = true
+	}
+	for id := range m.Selected {
+		if !visible[id] {
+			delete(m.Selected, id)
+		}
+	}
+}
+
+func (m AlertsModel) allSelected() bool {
+	if len(m.Active) == 0 {
+		return false
+	}
+	for _, a := range m.Active {
+		if !m.Selected[a.ID] {
+			return false
+		}
+	}
+	return true
+}
+
+func (m AlertsModel) selectedIDs() []string {
+	var ids []string
+	for _, a := range m.Active {
+		if m.Selected[a.ID] {
+			ids = append(ids, a.ID)
+		}
+	}
+	return ids
+}
+
+func cycleFilter(values []string, current string) string {
+	if current == "" {
+		if len(values) == 0 {
+			return ""
+		}
+		return values[0]
+	}
+	for i, v := range values {
+		if v == current && i+1 < len(values) {
+			return values[i+1]
+		}
+	}
+	return ""
+}
+
+func mergeTypes(known, seen []string) []string {
+	set := make(map[string]bool, len(known)+len(seen))
+	var merged []string
+	for _, t := range append(known, seen...) {
+		if !set[t] {
+			set[t] = true
+			merged = append(merged, t)
+		}
+	}
+	sort.Strings(merged)
+	return merged
+}
+
 type alertsMsg struct {
-	Active []AlertData
-	Recent []AlertData
+	Active   []AlertData
+	Recent   []AlertData
+	Webhooks []WebhookData
+	Types    []string
 }
 
-func (m AlertsModel) resolveAlert(id string) tea.Cmd {
+const webhookEventsShown = 10
+
+func (m AlertsModel) resolveAlerts(ids []string) tea.Cmd {
 	return func() tea.Msg {
-		if err := m.store.ResolveAlert(id); err != nil {
-			return err
+		n, err := m.store.ResolveAlerts(ids)
+		if err != nil {
+			return toastError("resolving alerts", err)
+		}
+		text := fmt.Sprintf("Resolved %d alert", n)
+		if n != 1 {
+			text += "s"
+		}
+		if skipped := int64(len(ids)) - n; skipped > 0 {
+			text += fmt.Sprintf(", %d already resolved", skipped)
 		}
-		return m.fetchAlerts()
+		return toast(components.ToastSuccess, text)
 	}
 }
 
 func (m AlertsModel) fetchAlerts() tea.Msg {
-	active, err := m.store.GetActiveAlerts()
+	active, err := m.store.GetActiveAlertsFiltered(m.TypeFilter, m.SeverityFilter)
 	if err != nil {
-		return err
+		return opError("loading alerts", err)
 	}
 	recent, err := m.store.GetRecentAlerts(24)
 	if err != nil {
-		return err
+		return opError("loading alerts", err)
 	}
 
+	var types []string
 	var activeData []AlertData
 	for _, a := range active {
+		types = append(types, a.Type)
 		activeData = append(activeData, AlertData{
 			ID: a.ID, Type: a.Type, Agent: a.AgentName, Message: a.Message,
 			Time:   time.Since(a.CreatedAt).Round(time.Second).String() + " ago",
@@ -171,15 +336,36 @@ func (m AlertsModel) fetchAlerts() tea.Msg {
 
 	var recentData []AlertData
 	for _, a := range recent {
+		types = append(types, a.Type)
+		if m.TypeFilter != "" && a.Type != m.TypeFilter {
+			continue
+		}
+		if m.SeverityFilter != "" && string(a.Severity) != m.SeverityFilter {
+			continue
+		}
 		if a.Resolved {
 			recentData = append(recentData, AlertData{
 				ID: a.ID, Type: a.Type, Agent: a.AgentName, Message: a.Message,
 				Time: a.CreatedAt.Format("15:04"), Active: false, Severity: string(a.Severity),
+				Auto: a.AutoResolved,
 			})
 		}
 	}
 
-	return alertsMsg{Active: activeData, Recent: recentData}
+	events, err := m.store.GetWebhookEvents(webhookEventsShown)
+	if err != nil {
+		return opError("loading webhook events", err)
+	}
+
+	var webhookData []WebhookData
+	for _, e := range events {
+		webhookData = append(webhookData, WebhookData{
+			Source: e.Source, Repo: e.Repo, Branch: e.Branch, Commit: e.Commit,
+			Outcome: e.Outcome, Reason: e.Reason, Time: e.CreatedAt.Format("Jan 02 15:04"),
+		})
+	}
+
+	return alertsMsg{Active: activeData, Recent: recentData, Webhooks: webhookData, Types: types}
 }
 
 func (m AlertsModel) View() string {
@@ -203,10 +389,18 @@ func (m AlertsModel) View() string {
 		statusContent.WriteString("  " + styles.SuccessStyle.Render(styles.IconSuccess) + "  " +
 			styles.SuccessStyle.Render("All systems operational"))
 	}
+	if m.TypeFilter != "" || m.SeverityFilter != "" {
+		statusContent.WriteString("\n  " + styles.MutedStyle.Render("filter") + "  " +
+			styles.PrimaryStyle.Render(orAll(m.TypeFilter)) + styles.MutedStyle.Render(" / ") +
+			styles.PrimaryStyle.Render(orAll(m.SeverityFilter)))
+	}
+	if n := len(m.selectedIDs()); n > 0 {
+		statusContent.WriteString("\n  " + styles.PrimaryStyle.Render(fmt.Sprintf("%d selected", n)))
+	}
 	b.WriteString(components.Wrap(statusContent.String(), w) + "\n\n")
 
-	if m.err != nil {
-		b.WriteString(components.MsgError(m.err.Error(), w) + "\n\n")
+	if m.errs.Len() > 0 {
+		b.WriteString(m.errs.View(w) + "\n\n")
 	}
 
 	b.WriteString(components.Section("ACTIVE ALERTS", w) + "\n\n")
@@ -216,6 +410,10 @@ func (m AlertsModel) View() string {
 	} else {
 		for i, a := range m.Active {
 			selected := i == m.Cursor
+			box := styles.MutedStyle.Render("[ ]")
+			if m.Selected[a.ID] {
+				box = styles.SuccessStyle.Render("[x]")
+			}
 			if selected && m.Expanded {
 				card := components.AlertCardData{
 					Type: a.Type, Agent: a.Agent, Message: a.Message,
@@ -237,8 +435,9 @@ func (m AlertsModel) View() string {
 					typeStyle = styles.PrimaryStyle
 				}
 
-				activeContent.WriteString(fmt.Sprintf("%s%s  %s  %s  %s  %s\n",
+				activeContent.WriteString(fmt.Sprintf("%s%s %s  %s  %s  %s  %s\n",
 					ptr,
+					box,
 					icon,
 					typeStyle.Render(styles.Pad(styles.Trunc(a.Type, 12), 12)),
 					styles.Pad(styles.Trunc(a.Agent, 14), 14),
@@ -267,17 +466,28 @@ func (m AlertsModel) View() string {
 				typeStyle = styles.PrimaryStyle
 			}
 
-			recentContent.WriteString(fmt.Sprintf("%s%s  %s  %s  %s  %s\n",
+			how := styles.SubtleStyle.Render("manual")
+			if a.Auto {
+				how = styles.PrimaryStyle.Render("auto")
+			}
+
+			recentContent.WriteString(fmt.Sprintf("%s    %s  %s  %s  %s  %s  %s\n",
 				ptr,
 				icon,
 				typeStyle.Render(styles.Pad(styles.Trunc(a.Type, 12), 12)),
 				styles.MutedStyle.Render(styles.Pad(styles.Trunc(a.Agent, 14), 14)),
 				styles.MutedStyle.Render(styles.Pad(styles.Trunc(a.Message, 24), 24)),
-				styles.MutedStyle.Render(a.Time)))
+				styles.MutedStyle.Render(styles.Pad(a.Time, 5)),
+				how))
 		}
 	}
 	b.WriteString(components.Wrap(recentContent.String(), w) + "\n")
 
+	if m.ShowWebhooks {
+		b.WriteString("\n" + components.Section("WEBHOOK EVENTS", w) + "\n\n")
+		b.WriteString(components.Wrap(m.renderWebhooks(w), w) + "\n")
+	}
+
 	content := b.String()
 	lines := helper.CountLines(content)
 	for i := 0; i < m.Height-lines-3; i++ {
@@ -286,7 +496,8 @@ func (m AlertsModel) View() string {
 
 	content += "\n" + styles.Line(w) + "\n"
 	content += components.Help([][]string{
-		{"↑↓", "navigate"}, {"e", "expand"}, {"x", "resolve"}, {"r", "refresh"}, {"esc", "back"},
+		{"↑↓", "navigate"}, {"space", "select"}, {"a", "all"}, {"x", "resolve"}, {"t", "type"}, {"v", "severity"},
+		{"e", "expand"}, {"w", "webhooks"}, {"r", "refresh"}, {"esc", "back"},
 	})
 
 	if m.Loading {
@@ -299,3 +510,40 @@ func (m AlertsModel) View() string {
 
 	return content
 }
+
+func orAll(filter string) string {
+	if filter == "" {
+		return "all"
+	}
+	return filter
+}
+
+func (m AlertsModel) renderWebhooks(w int) string {
+	if len(m.Webhooks) == 0 {
+		return "  " + styles.MutedStyle.Render("No webhook deliveries recorded")
+	}
+
+	var b strings.Builder
+	reasonWidth := w - 76
+	if reasonWidth < 12 {
+		reasonWidth = 12
+	}
+	for _, e := range m.Webhooks {
+		repo := e.Repo
+		if repo == "" {
+			repo = "-"
+		}
+		ref := e.Branch
+		if e.Commit != "" {
+			ref += "@" + e.Commit
+		}
+		b.WriteString(fmt.Sprintf("   %s  %s  %s  %s  %s  %s\n",
+			styles.MutedStyle.Render(styles.Pad(e.Time, 12)),
+			styles.Pad(components.Badge(e.Outcome), 14),
+			styles.SubtleStyle.Render(styles.Pad(e.Source, 9)),
+			styles.BrightStyle.Render(styles.Pad(styles.Trunc(repo, 16), 16)),
+			styles.MutedStyle.Render(styles.Pad(styles.Trunc(ref, 18), 18)),
+			styles.MutedStyle.Render(styles.Trunc(e.Reason, reasonWidth))))
+	}
+	return b.String()
+}
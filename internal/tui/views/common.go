@@ -18,9 +18,78 @@
 
 package views
 
-import "time"
+import (
+	"errors"
+	"time"
+
+	"github.com/urustack/uruflow/internal/models"
+	"github.com/urustack/uruflow/internal/tui/components"
+)
 
 type RefreshMsg struct{}
+
+type ToastMsg struct {
+	Level components.ToastLevel
+	Text  string
+	TTL   time.Duration
+}
+
+func toast(level components.ToastLevel, text string) ToastMsg {
+	return ToastMsg{Level: level, Text: text}
+}
+
+func shortCommit(commit string) string {
+	if len(commit) > 7 {
+		return commit[:7]
+	}
+	return commit
+}
+
+func toastError(op string, err error) ToastMsg {
+	return ToastMsg{Level: components.ToastError, Text: opError(op, err).Error(), TTL: 8 * time.Second}
+}
+
+type OpError struct {
+	Op  string
+	Err error
+}
+
+func (e OpError) Error() string {
+	return e.Op + ": " + e.Err.Error()
+}
+
+func (e OpError) Unwrap() error {
+	return e.Err
+}
+
+func opError(op string, err error) error {
+	return OpError{Op: op, Err: err}
+}
+
+func pushError(stack *components.ErrorStack, err error) {
+	var opErr OpError
+	if errors.As(err, &opErr) {
+		stack.Push(opErr.Op, opErr.Err)
+		return
+	}
+	stack.Push("", err)
+}
+
+func dismissError(stack *components.ErrorStack, key string) bool {
+	if stack.Len() == 0 {
+		return false
+	}
+	switch key {
+	case "z":
+		stack.Dismiss()
+		return true
+	case "Z":
+		stack.Clear()
+		return true
+	}
+	return false
+}
+
 type TickMsg time.Time
 
 type DataMsg struct {
@@ -28,51 +97,109 @@ type DataMsg struct {
 	Deployments []DeploymentData
 	Alerts      []AlertData
 	Repos       []RepoData
+	RepoStats   []RepoStatsData
+	Approvals   int
 }
 
 type AgentData struct {
-	ID         string
-	Name       string
-	Host       string
-	Version    string
-	Uptime     string
-	Online     bool
-	CPU        float64
-	Memory     float64
-	Disk       float64
-	Containers []ContainerData
+	ID          string
+	Name        string
+	Host        string
+	MachineID   string
+	Version     string
+	Uptime      string
+	Online      bool
+	Degraded    bool
+	LowDisk     bool
+	ClockSkew   time.Duration
+	CPU         float64
+	Memory      float64
+	Disk        float64
+	Queued      int
+	Labels      map[string]string
+	DockerDisk  *models.DockerDiskUsage
+	Reclaimed   uint64
+	Maintenance bool
+	Frozen      string
+	SendQueue   string
+	QueueWarn   bool
+	Traffic     string
+	WriteErrors bool
+	CleanedAt   time.Time
+	CPUHistory  []float64
+	MemHistory  []float64
+	Containers  []ContainerData
+	Events      []models.AgentEvent
 }
 
 type ContainerData struct {
 	Name    string
+	Project string
+	Service string
 	Running bool
 	Healthy bool
 	CPU     float64
 	Memory  string
+	Stale   bool
 }
 
 type DeploymentData struct {
-	ID     string
-	Repo   string
-	Branch string
-	Commit string
-	Agent  string
-	Status string
-	Time   string
+	ID      string
+	Repo    string
+	Branch  string
+	Tag     string
+	Commit  string
+	Agent   string
+	Status  string
+	Detail  string
+	Time    string
+	Changes string
+	Trigger string
+	By      string
+	Expires string
+	DryRun  bool
+	Exit    *int
+	File    string
+
+	Images         []DeployedImage
+	ImageUnchanged bool
+}
+
+type DeployedImage struct {
+	Name    string
+	Image   string
+	ImageID string
+}
+
+type RepoStatsData struct {
+	Repo        string
+	LastDeploy  string
+	Deploys     int
+	SuccessRate float64
+	AvgDuration string
+	FailStreak  int
 }
 
 type RepoData struct {
-	Name        string
-	URL         string
-	Branch      string
-	Agent       string
-	AgentID     string
-	AutoDeploy  bool
-	BuildSystem string
-	BuildFile   string
-	LastStatus  string
-	LastCommit  string
-	LastTime    string
+	Name         string
+	URL          string
+	Branch       string
+	Agent        string
+	AgentID      string
+	AutoDeploy   bool
+	BuildSystem  string
+	BuildFile    string
+	BuildCmd     string
+	Image        string
+	LastStatus   string
+	LastCommit   string
+	LastTime     string
+	DryRunCommit string
+	DryRunTime   string
+	Drift        []string
+	Schedule     string
+	NextRun      string
+	Checkout     string
 }
 
 type AlertData struct {
@@ -83,9 +210,21 @@ type AlertData struct {
 	Time     string
 	Active   bool
 	Severity string
+	Auto     bool
+}
+
+type WebhookData struct {
+	Source  string
+	Repo    string
+	Branch  string
+	Commit  string
+	Outcome string
+	Reason  string
+	Time    string
 }
 
 type LogData struct {
+	ID      int64
 	Time    string
 	Content string
 	Stream  string
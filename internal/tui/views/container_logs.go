@@ -47,6 +47,18 @@ type ContainerLogsModel struct {
 	Mode          int
 	Containers    []ContainerData
 	Cursor        int
+	Status        string
+	StatusErr     bool
+	streaming     bool
+	interrupted   bool
+	search        logSearch
+}
+
+type containerActionMsg struct {
+	Name   string
+	Action string
+	Output string
+	Err    error
 }
 
 func NewContainerLogsModel(server *api.Server) ContainerLogsModel {
@@ -55,6 +67,7 @@ func NewContainerLogsModel(server *api.Server) ContainerLogsModel {
 		AutoFollow: true,
 		Logs:       []LogData{},
 		Mode:       0,
+		search:     newLogSearch(),
 	}
 }
 
@@ -68,6 +81,7 @@ func (m *ContainerLogsModel) SetAgent(agentData AgentData) {
 	m.Containers = agentData.Containers
 	m.Mode = 0
 	m.Cursor = 0
+	m.Status = ""
 
 	if len(m.Containers) == 1 {
 		m.SetContainer(m.Containers[0].Name, m.Containers[0].Name)
@@ -75,20 +89,47 @@ func (m *ContainerLogsModel) SetAgent(agentData AgentData) {
 }
 
 func (m *ContainerLogsModel) SetContainer(id, name string) {
+	m.StopStream()
 	m.ContainerID = id
 	m.ContainerName = name
 	m.Logs = []LogData{}
 	m.Offset = 0
 	m.AutoFollow = true
 	m.Mode = 1
-
-	m.Server.GetTCPServer().StreamContainerLogs(m.AgentID, m.ContainerID, 100, true)
+	m.Status = ""
+	m.interrupted = false
+	m.search.clear()
+
+	if err := m.Server.GetTCPServer().StreamContainerLogs(m.AgentID, m.ContainerID, 100, true); err != nil {
+		m.Status = fmt.Sprintf("cannot stream logs: %v", err)
+		m.StatusErr = true
+		return
+	}
+	m.streaming = true
 }
 
 func (m *ContainerLogsModel) StopStream() {
-	if m.AgentID != "" && m.ContainerID != "" {
-		m.Server.GetTCPServer().StopContainerLogs(m.AgentID, m.ContainerID)
+	if !m.streaming {
+		return
+	}
+	m.streaming = false
+	m.Server.GetTCPServer().StopContainerLogs(m.AgentID, m.ContainerID)
+}
+
+func (m ContainerLogsModel) Searching() bool {
+	return m.Mode == 1 && m.search.typing
+}
+
+func (m *ContainerLogsModel) jumpTo(idx int) {
+	if idx < 0 {
+		return
 	}
+	visible := m.Height - 12
+	if visible < 1 {
+		visible = 1
+	}
+	m.Offset = offsetFor(idx, len(m.Logs), visible)
+	m.AutoFollow = false
 }
 
 func (m ContainerLogsModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
@@ -96,6 +137,13 @@ func (m ContainerLogsModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
+		if m.Searching() {
+			confirmed, cmd := m.search.update(msg, m.Logs)
+			if confirmed {
+				m.jumpTo(m.search.next(m.Offset, false))
+			}
+			return m, cmd
+		}
 		if m.Mode == 0 {
 			switch msg.String() {
 			case "up", "k":
@@ -112,10 +160,35 @@ func (m ContainerLogsModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					m.SetContainer(c.Name, c.Name)
 					return m, nil
 				}
+			case "s":
+				if len(m.Containers) > 0 {
+					c := m.Containers[m.Cursor]
+					action := "start"
+					if c.Running {
+						action = "stop"
+					}
+					return m.startAction(c.Name, action)
+				}
+			case "R":
+				if len(m.Containers) > 0 {
+					return m.startAction(m.Containers[m.Cursor].Name, "restart")
+				}
 			}
 		} else {
 			switch msg.String() {
+			case "/":
+				return m, m.search.begin()
+			case "n":
+				m.jumpTo(m.search.step(false))
+			case "N":
+				m.jumpTo(m.search.step(true))
+			case "e":
+				m.jumpTo(firstStderr(m.Logs))
 			case "esc":
+				if m.search.query != "" {
+					m.search.clear()
+					return m, nil
+				}
 				m.StopStream()
 				m.Mode = 0
 				return m, nil
@@ -160,11 +233,34 @@ func (m ContainerLogsModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			case "c":
 				m.Logs = []LogData{}
 				m.Offset = 0
+				m.search.reset()
+			}
+		}
+
+	case containerActionMsg:
+		if msg.Err != nil {
+			m.Status = fmt.Sprintf("%s %s failed: %v", msg.Action, msg.Name, msg.Err)
+			m.StatusErr = true
+		} else {
+			m.Status = fmt.Sprintf("%s %s: done", msg.Action, msg.Name)
+			m.StatusErr = false
+			for i := range m.Containers {
+				if m.Containers[i].Name == msg.Name {
+					m.Containers[i].Running = msg.Action != "stop"
+				}
 			}
 		}
 
 	case ContainerLogsMsg:
-		if m.Mode == 1 && msg.ContainerID == m.ContainerID {
+		if m.Mode == 1 && msg.ContainerID == m.ContainerID && msg.Stream == protocol.StreamInterrupted {
+			m.interrupted = true
+		} else if m.Mode == 1 && msg.ContainerID == m.ContainerID && msg.Stream == protocol.StreamResumed {
+			m.interrupted = false
+		} else if m.Mode == 1 && msg.ContainerID == m.ContainerID && msg.Error != "" {
+			m.streaming = false
+			m.Status = fmt.Sprintf("log stream refused: %s", msg.Error)
+			m.StatusErr = true
+		} else if m.Mode == 1 && msg.ContainerID == m.ContainerID {
 			timestamp := time.Unix(msg.Timestamp, 0).Format("15:04:05")
 
 			newLog := LogData{
@@ -175,10 +271,12 @@ func (m ContainerLogsModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.Logs = append(m.Logs, newLog)
 			if len(m.Logs) > 2000 {
 				m.Logs = m.Logs[1:]
+				m.search.dropFront(1)
 				if m.Offset > 0 {
 					m.Offset--
 				}
 			}
+			m.search.extend(m.Logs)
 
 			if m.AutoFollow {
 				visibleLines := m.Height - 12
@@ -194,6 +292,33 @@ func (m ContainerLogsModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, cmd
 }
 
+func (m ContainerLogsModel) startAction(name, action string) (tea.Model, tea.Cmd) {
+	m.Status = fmt.Sprintf("%s %s...", action, name)
+	m.StatusErr = false
+	return m, m.containerAction(name, action)
+}
+
+func (m ContainerLogsModel) containerAction(name, action string) tea.Cmd {
+	agentID := m.AgentID
+	return func() tea.Msg {
+		tcpServer := m.Server.GetTCPServer()
+		result, err := tcpServer.SendContainerAction(agentID, name, action)
+		if err != nil {
+			return containerActionMsg{Name: name, Action: action, Err: err}
+		}
+
+		select {
+		case done := <-result:
+			if done.Status != "success" {
+				return containerActionMsg{Name: name, Action: action, Err: fmt.Errorf("%s", done.Output)}
+			}
+			return containerActionMsg{Name: name, Action: action, Output: done.Output}
+		case <-time.After(75 * time.Second):
+			return containerActionMsg{Name: name, Action: action, Err: fmt.Errorf("timed out waiting for agent")}
+		}
+	}
+}
+
 func (m ContainerLogsModel) View() string {
 	if m.Width == 0 {
 		return ""
@@ -238,13 +363,21 @@ func (m ContainerLogsModel) viewSelect() string {
 	}
 	b.WriteString(components.Wrap(listContent.String(), w) + "\n")
 
+	if m.Status != "" {
+		if m.StatusErr {
+			b.WriteString("\n" + components.MsgError(m.Status, w) + "\n")
+		} else {
+			b.WriteString("\n" + components.MsgInfo(m.Status, w) + "\n")
+		}
+	}
+
 	content := b.String()
 	lines := helper.CountLines(content)
 	for i := 0; i < m.Height-lines-3; i++ {
 		content += "\n"
 	}
 	content += "\n" + styles.Line(w) + "\n"
-	content += components.Help([][]string{{"↑↓", "select"}, {"enter", "view logs"}, {"esc", "back"}})
+	content += components.Help([][]string{{"↑↓", "select"}, {"enter", "view logs"}, {"s", "start/stop"}, {"R", "restart"}, {"esc", "back"}})
 	return content
 }
 
@@ -259,6 +392,9 @@ func (m ContainerLogsModel) viewLogs() string {
 	b.WriteString(components.Section(headerText, w) + "\n\n")
 
 	visibleLines := m.Height - 12
+	if m.search.typing {
+		visibleLines -= 3
+	}
 	if visibleLines < 1 {
 		visibleLines = 1
 	}
@@ -273,12 +409,22 @@ func (m ContainerLogsModel) viewLogs() string {
 			endIdx = len(m.Logs)
 		}
 		for i := m.Offset; i < endIdx; i++ {
-			log := m.Logs[i]
-			logContent.WriteString(components.LogLine(log.Time, log.Content, log.Stream, w) + "\n")
+			logContent.WriteString(m.search.line(i, m.Logs[i], w-8) + "\n")
 		}
 	}
 	b.WriteString(components.Wrap(logContent.String(), w) + "\n")
 
+	if m.search.typing {
+		b.WriteString("\n" + m.search.view(w) + "\n")
+	}
+
+	if m.interrupted {
+		b.WriteString("\n" + components.MsgWarning("Stream interrupted — reconnecting to "+m.AgentName+"...", w) + "\n")
+	}
+	if m.Status != "" && m.StatusErr {
+		b.WriteString("\n" + components.MsgError(m.Status, w) + "\n")
+	}
+
 	content := b.String()
 	lines := helper.CountLines(content)
 	for i := 0; i < m.Height-lines-3; i++ {
@@ -292,10 +438,19 @@ func (m ContainerLogsModel) viewLogs() string {
 		followStatus = styles.SuccessStyle.Render("follow: on")
 	}
 
+	if m.search.typing {
+		content += components.Help([][]string{{"enter", "search"}, {"tab", "toggle regex"}, {"esc", "cancel"}})
+		return content
+	}
+
 	content += components.Help([][]string{
-		{"↑↓", "scroll"}, {"f", "toggle follow"}, {"c", "clear"}, {"esc", "back"},
+		{"↑↓", "scroll"}, {"/", "search"}, {"n/N", "next/prev"}, {"e", "first error"},
+		{"f", "toggle follow"}, {"c", "clear"}, {"esc", "back"},
 	})
 	content += "   " + followStatus
+	if status := m.search.status(); status != "" {
+		content += "   " + status
+	}
 
 	return content
 }
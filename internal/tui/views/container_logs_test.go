@@ -0,0 +1,59 @@
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+
+package views
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/urustack/uruflow/internal/tcp/protocol"
+)
+
+func TestContainerLogsInterruptedBanner(t *testing.T) {
+	var m ContainerLogsModel
+	m.Width, m.Height = 100, 30
+	m.AgentName, m.ContainerID, m.ContainerName = "web", "c1", "api"
+	m.Mode = 1
+
+	update := func(msg ContainerLogsMsg) {
+		next, _ := m.Update(msg)
+		m = next.(ContainerLogsModel)
+	}
+
+	update(ContainerLogsMsg{ContainerID: "c1", Stream: protocol.StreamInterrupted})
+	if !strings.Contains(m.View(), "Stream interrupted") {
+		t.Fatal("interrupted stream shows no banner")
+	}
+	update(ContainerLogsMsg{ContainerID: "c2", Stream: protocol.StreamResumed})
+	if !strings.Contains(m.View(), "Stream interrupted") {
+		t.Fatal("another container's resume cleared the banner")
+	}
+	update(ContainerLogsMsg{ContainerID: "c1", Stream: protocol.StreamResumed})
+	if strings.Contains(m.View(), "Stream interrupted") {
+		t.Fatal("banner still shown after the stream resumed")
+	}
+	if len(m.Logs) != 0 {
+		t.Fatalf("stream state messages were added as log lines: %+v", m.Logs)
+	}
+
+	update(ContainerLogsMsg{ContainerID: "c1", Stream: "stdout", Line: "listening on :8080"})
+	if len(m.Logs) != 1 || m.Logs[0].Content != "listening on :8080" {
+		t.Fatalf("logs = %+v, want the line appended", m.Logs)
+	}
+}
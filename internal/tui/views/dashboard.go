@@ -19,16 +19,21 @@
 package views
 
 import (
+	"fmt"
 	"strings"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/urustack/uruflow/internal/models"
 	"github.com/urustack/uruflow/internal/storage"
+	"github.com/urustack/uruflow/internal/tcp"
 	"github.com/urustack/uruflow/internal/tui/components"
 	"github.com/urustack/uruflow/internal/tui/styles"
 	"github.com/urustack/uruflow/pkg/helper"
 )
 
+const failStreakAlert = 3
+
 type DashboardModel struct {
 	store        storage.Store
 	Width        int
@@ -36,28 +41,19 @@ type DashboardModel struct {
 	Agents       []AgentData
 	Deployments  []DeploymentData
 	Alerts       []AlertData
-	Message      string
-	MessageType  string
 	Loading      bool
 	SpinnerFrame int
 	ShowHelp     bool
-	err          error
+	ShowStats    bool
+	RepoStats    []RepoStatsData
+	Approvals    int
+	errs         components.ErrorStack
 }
 
 func NewDashboardModel(store storage.Store) DashboardModel {
 	return DashboardModel{store: store}
 }
 
-func (m *DashboardModel) SetMessage(msg, t string) {
-	m.Message = msg
-	m.MessageType = t
-}
-
-func (m *DashboardModel) ClearMessage() {
-	m.Message = ""
-	m.MessageType = ""
-}
-
 func (m DashboardModel) Init() tea.Cmd {
 	m.Loading = true
 	return tea.Batch(m.fetchData, m.tick, m.spinnerTick)
@@ -76,6 +72,10 @@ func (m DashboardModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		switch msg.String() {
 		case "?":
 			m.ShowHelp = !m.ShowHelp
+		case "s":
+			m.ShowStats = !m.ShowStats
+		default:
+			dismissError(&m.errs, msg.String())
 		}
 	case SpinnerTickMsg:
 		m.SpinnerFrame++
@@ -85,14 +85,24 @@ func (m DashboardModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case TickMsg:
 		m.Loading = true
 		return m, tea.Batch(m.fetchData, m.tick, m.spinnerTick)
+	case DeploymentEventMsg:
+		if msg.Kind == tcp.DeploymentLog || msg.Kind == tcp.DeploymentStep {
+			if !msg.Dirty {
+				return m, nil
+			}
+		}
+		return m, m.fetchData
 	case DataMsg:
 		m.Agents = msg.Agents
 		m.Deployments = msg.Deployments
 		m.Alerts = msg.Alerts
+		m.RepoStats = msg.RepoStats
+		m.Approvals = msg.Approvals
 		m.Loading = false
+		m.errs.Resolve("loading dashboard")
 		return m, nil
 	case error:
-		m.err = msg
+		pushError(&m.errs, msg)
 		m.Loading = false
 		return m, nil
 	}
@@ -107,15 +117,23 @@ func (m DashboardModel) tick() tea.Msg {
 func (m DashboardModel) fetchData() tea.Msg {
 	agents, err := m.store.GetAllAgents()
 	if err != nil {
-		return err
+		return opError("loading dashboard", err)
 	}
 	deployments, err := m.store.GetRecentDeployments(5)
 	if err != nil {
-		return err
+		return opError("loading dashboard", err)
 	}
 	alerts, err := m.store.GetActiveAlerts()
 	if err != nil {
-		return err
+		return opError("loading dashboard", err)
+	}
+	repoStats, err := m.store.GetRepoStats()
+	if err != nil {
+		return opError("loading dashboard", err)
+	}
+	waiting, err := m.store.GetDeploymentsByStatus(models.DeployAwaitingApproval)
+	if err != nil {
+		return opError("loading dashboard", err)
 	}
 
 	var agentData []AgentData
@@ -140,12 +158,8 @@ func (m DashboardModel) fetchData() tea.Msg {
 
 	var deployData []DeploymentData
 	for _, d := range deployments {
-		commit := d.Commit
-		if len(commit) > 7 {
-			commit = commit[:7]
-		}
 		deployData = append(deployData, DeploymentData{
-			ID: d.ID, Repo: d.Repository, Branch: d.Branch, Commit: commit,
+			ID: d.ID, Repo: d.Repository, Branch: d.Branch, Tag: d.Tag, Commit: shortCommit(d.Commit),
 			Agent: d.AgentName, Status: string(d.Status),
 			Time: time.Since(d.StartedAt).Round(time.Second).String() + " ago",
 		})
@@ -160,7 +174,27 @@ func (m DashboardModel) fetchData() tea.Msg {
 		})
 	}
 
-	return DataMsg{Agents: agentData, Deployments: deployData, Alerts: alertData}
+	var statsData []RepoStatsData
+	for _, r := range repoStats {
+		var last time.Time
+		if r.LastDeploy != nil {
+			last = *r.LastDeploy
+		}
+		avg := "-"
+		if r.AvgDuration > 0 {
+			avg = r.AvgDuration.Round(time.Second).String()
+		}
+		streak := 0
+		if r.FailStreak >= failStreakAlert {
+			streak = r.FailStreak
+		}
+		statsData = append(statsData, RepoStatsData{
+			Repo: r.Repo, LastDeploy: helper.FormatTimeAgo(last), Deploys: r.Deploys,
+			SuccessRate: r.SuccessRate, AvgDuration: avg, FailStreak: streak,
+		})
+	}
+
+	return DataMsg{Agents: agentData, Deployments: deployData, Alerts: alertData, RepoStats: statsData, Approvals: len(waiting)}
 }
 
 func (m DashboardModel) View() string {
@@ -181,17 +215,17 @@ func (m DashboardModel) View() string {
 		}
 	}
 	b.WriteString(components.StatusBar(online, offline, len(m.Alerts), w) + "\n\n")
-	if m.Message != "" {
-		switch m.MessageType {
-		case "success":
-			b.WriteString(components.MsgSuccess(m.Message, w) + "\n\n")
-		case "error":
-			b.WriteString(components.MsgError(m.Message, w) + "\n\n")
-		case "warning":
-			b.WriteString(components.MsgWarning(m.Message, w) + "\n\n")
-		default:
-			b.WriteString(components.MsgInfo(m.Message, w) + "\n\n")
+
+	if m.Approvals > 0 {
+		noun := "deployments"
+		if m.Approvals == 1 {
+			noun = "deployment"
 		}
+		b.WriteString(components.MsgWarning(fmt.Sprintf("%d %s awaiting approval, press d to review", m.Approvals, noun), w) + "\n\n")
+	}
+
+	if m.errs.Len() > 0 {
+		b.WriteString(m.errs.View(w) + "\n\n")
 	}
 
 	if m.Loading && len(m.Agents) == 0 {
@@ -203,10 +237,10 @@ func (m DashboardModel) View() string {
 	if len(m.Agents) == 0 && !m.Loading {
 		agentContent.WriteString("  " + styles.MutedStyle.Render("No agents registered. Press 'a' to add one."))
 	} else if len(m.Agents) > 0 {
-		agentContent.WriteString(components.AgentHeader(w) + "\n")
+		agentContent.WriteString(components.AgentHeader(w-8) + "\n")
 		agentContent.WriteString("  " + styles.Line(w-8) + "\n")
 		for _, a := range m.Agents {
-			agentContent.WriteString(components.AgentRow(a.Name, a.Online, a.CPU, a.Memory, a.Disk, a.Uptime, false, w) + "\n")
+			agentContent.WriteString(components.AgentRow(a.Name, a.Online, a.CPU, a.Memory, a.Disk, a.Uptime, false, w-8) + "\n")
 		}
 	}
 	if agentContent.Len() > 0 {
@@ -225,13 +259,35 @@ func (m DashboardModel) View() string {
 				icon = styles.ErrorStyle.Render(styles.IconError)
 			} else if d.Status == "running" {
 				icon = styles.PrimaryStyle.Render(styles.IconSpin)
-			} else if d.Status == "pending" {
+			} else if d.Status == "pending" || d.Status == "awaiting_approval" {
 				icon = styles.WarningStyle.Render(styles.IconWarning)
+			} else if d.Status == "rejected" {
+				icon = styles.MutedStyle.Render(styles.IconUncheck)
 			}
-			deployContent.WriteString(components.DeployRow(icon, d.Repo, d.Branch, d.Commit, d.Agent, d.Time, w) + "\n")
+			ref := d.Branch
+			if d.Tag != "" {
+				ref = d.Tag
+			}
+			deployContent.WriteString(components.DeployRow(icon, d.Repo, ref, d.Commit, d.Agent, d.Time, w) + "\n")
 		}
 	}
 	b.WriteString(components.Wrap(deployContent.String(), w) + "\n\n")
+
+	if m.ShowStats {
+		b.WriteString(components.Section("STATS (30 DAYS)", w) + "\n\n")
+		var statsContent strings.Builder
+		if len(m.RepoStats) == 0 {
+			statsContent.WriteString("  " + styles.MutedStyle.Render("No repositories configured"))
+		} else {
+			statsContent.WriteString(components.RepoStatsHeader(w) + "\n")
+			statsContent.WriteString("  " + styles.Line(w-8) + "\n")
+			for _, r := range m.RepoStats {
+				statsContent.WriteString(components.RepoStatsRow(r.Repo, r.LastDeploy, r.Deploys, r.SuccessRate, r.AvgDuration, r.FailStreak, w) + "\n")
+			}
+		}
+		b.WriteString(components.Wrap(statsContent.String(), w) + "\n\n")
+	}
+
 	b.WriteString(components.Section("ALERTS", w) + "\n\n")
 
 	var alertContent strings.Builder
@@ -256,7 +312,7 @@ func (m DashboardModel) View() string {
 
 	content += "\n" + styles.Line(w) + "\n"
 	helpItems := [][]string{
-		{"a", "agents"}, {"r", "repos"}, {"x", "alerts"}, {"l", "history"}, {"d", "deploy"}, {"tab", "cycle"}, {"?", "help"}, {"q", "quit"},
+		{"a", "agents"}, {"r", "repos"}, {"x", "alerts"}, {"l", "history"}, {"d", "deploy"}, {"s", "stats"}, {"tab", "cycle"}, {"H", "notifications"}, {"?", "help"}, {"q", "quit"},
 	}
 	content += components.Help(helpItems)
 
@@ -266,7 +322,7 @@ func (m DashboardModel) View() string {
 
 	if m.ShowHelp {
 		content += "\n\n" + styles.MutedStyle.Render("  Navigation: tab to cycle views, esc to return to dashboard")
-		content += "\n" + styles.MutedStyle.Render("  Quick access: a=agents, r=repos, x=alerts, l=logs, d=deploy")
+		content += "\n" + styles.MutedStyle.Render("  Quick access: a=agents, r=repos, x=alerts, l=logs, d=deploy, H=notifications")
 	}
 
 	return content
@@ -24,20 +24,27 @@ import (
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/urustack/uruflow/internal/config"
+	"github.com/urustack/uruflow/internal/services"
 	"github.com/urustack/uruflow/internal/storage"
+	"github.com/urustack/uruflow/internal/tcp"
 	"github.com/urustack/uruflow/internal/tui/components"
 	"github.com/urustack/uruflow/internal/tui/styles"
 	"github.com/urustack/uruflow/pkg/helper"
 )
 
 type DeployModel struct {
-	store      storage.Store
-	Width      int
-	Height     int
-	Deployment DeploymentData
-	Steps      []DeployStep
-	CurrentLog string
-	err        error
+	store         storage.Store
+	cfg           *config.Config
+	deployService *services.DeploymentService
+	Width         int
+	Height        int
+	Deployment    DeploymentData
+	Steps         []DeployStep
+	CurrentLog    string
+	Approvals     []DeploymentData
+	Cursor        int
+	errs          components.ErrorStack
 }
 
 type DeployStep struct {
@@ -46,12 +53,25 @@ type DeployStep struct {
 	Duration string
 }
 
-func NewDeployModel(store storage.Store) DeployModel {
-	return DeployModel{store: store, Deployment: DeploymentData{Status: "idle"}}
+type deployStatusMsg struct {
+	Deployment DeploymentData
+	Steps      []DeployStep
+}
+
+type DeploymentEventMsg struct {
+	Kind         string
+	DeploymentID string
+	Status       string
+	Line         string
+	Dirty        bool
+}
+
+func NewDeployModel(store storage.Store, cfg *config.Config, deployService *services.DeploymentService) DeployModel {
+	return DeployModel{store: store, cfg: cfg, deployService: deployService, Deployment: DeploymentData{Status: "idle"}}
 }
 
 func (m DeployModel) Init() tea.Cmd {
-	return m.pollStatus
+	return tea.Batch(m.fetchApprovals, m.pollStatus)
 }
 
 func (m DeployModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
@@ -59,17 +79,68 @@ func (m DeployModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case tea.KeyMsg:
 		switch msg.String() {
 		case "r":
-			return m, m.fetchStatus
+			return m, tea.Batch(m.fetchStatus, m.fetchApprovals)
+		case "up", "k":
+			if m.Cursor > 0 {
+				m.Cursor--
+			}
+		case "down", "j":
+			if m.Cursor < len(m.Approvals)-1 {
+				m.Cursor++
+			}
+		case "y":
+			if m.Cursor < len(m.Approvals) {
+				return m, m.approve(m.Approvals[m.Cursor].ID)
+			}
+		case "n":
+			if m.Cursor < len(m.Approvals) {
+				return m, m.reject(m.Approvals[m.Cursor].ID)
+			}
+		default:
+			dismissError(&m.errs, msg.String())
 		}
 	case TickMsg:
-		if m.Deployment.Status == "running" || m.Deployment.Status == "pending" {
-			return m, tea.Batch(m.fetchStatus, m.pollStatus)
+		if len(m.Approvals) > 0 {
+			return m, tea.Batch(m.fetchApprovals, m.pollStatus)
+		}
+	case DeploymentEventMsg:
+		if msg.Kind == tcp.DeploymentStatus || msg.Kind == tcp.DeploymentDone || msg.Dirty {
+			if msg.DeploymentID != m.Deployment.ID && !msg.Dirty {
+				return m, m.fetchApprovals
+			}
+			return m, tea.Batch(m.fetchStatus, m.fetchApprovals)
+		}
+		if msg.DeploymentID != m.Deployment.ID {
+			return m, nil
 		}
-	case DeploymentData:
-		m.Deployment = msg
+		if msg.Kind == tcp.DeploymentLog {
+			m.CurrentLog = msg.Line
+			return m, nil
+		}
+		return m, m.fetchStatus
+	case approvalsMsg:
+		m.Approvals = msg
+		if m.Cursor >= len(m.Approvals) {
+			m.Cursor = max(len(m.Approvals)-1, 0)
+		}
+		m.errs.Resolve("loading approvals")
+		return m, nil
+	case approvalDoneMsg:
+		m.errs.Resolve("approving deployment")
+		m.errs.Resolve("rejecting deployment")
+		if msg.Approved {
+			d := msg.Deployment
+			m.SetDeployment(d.ID, d.Repository, d.Branch, d.Commit, d.AgentName)
+			return m, tea.Batch(m.fetchApprovals, m.fetchStatus)
+		}
+		return m, m.fetchApprovals
+	case deployStatusMsg:
+		m.Deployment = msg.Deployment
+		m.Steps = msg.Steps
+		m.errs.Resolve("loading deployment")
 		return m, nil
 	case error:
-		m.err = msg
+		pushError(&m.errs, msg)
 		return m, nil
 	}
 	return m, nil
@@ -86,20 +157,59 @@ func (m DeployModel) fetchStatus() tea.Msg {
 	}
 	d, err := m.store.GetDeployment(m.Deployment.ID)
 	if err != nil {
-		return err
+		return opError("loading deployment", err)
 	}
 	if d == nil {
 		return nil
 	}
-	return DeploymentData{
-		ID: d.ID, Repo: d.Repository, Branch: d.Branch, Commit: d.Commit,
-		Agent: d.AgentName, Status: string(d.Status),
-		Time: time.Since(d.StartedAt).Round(time.Second).String(),
+
+	steps, err := m.store.GetDeploymentSteps(d.ID)
+	if err != nil {
+		return opError("loading deployment steps", err)
+	}
+	containers, err := m.store.GetDeploymentContainers(d.ID)
+	if err != nil {
+		return opError("loading deployment containers", err)
+	}
+	var images []DeployedImage
+	for _, c := range containers {
+		images = append(images, DeployedImage{Name: c.Name, Image: c.Image, ImageID: shortImageID(c.ImageID)})
+	}
+	var stepData []DeployStep
+	for _, s := range steps {
+		duration := time.Duration(s.Duration) * time.Millisecond
+		if s.Status == "running" {
+			duration = time.Since(s.StartedAt)
+		}
+		stepData = append(stepData, DeployStep{
+			Name:     s.Name,
+			Status:   s.Status,
+			Duration: duration.Round(100 * time.Millisecond).String(),
+		})
+	}
+
+	return deployStatusMsg{
+		Deployment: DeploymentData{
+			ID: d.ID, Repo: d.Repository, Branch: d.Branch, Tag: d.Tag, Commit: d.Commit,
+			Agent: d.AgentName, Status: string(d.Status), Detail: d.StatusDetail,
+			Time:    time.Since(d.StartedAt).Round(time.Second).String(),
+			Changes: d.ChangeSummary,
+			Trigger: d.Trigger,
+			By:      d.TriggeredByLabel(),
+			DryRun:  d.DryRun,
+			Exit:    d.ExitCode,
+			File:    d.BuildFile,
+
+			Images:         images,
+			ImageUnchanged: d.ImageUnchanged,
+		},
+		Steps: stepData,
 	}
 }
 
 func (m *DeployModel) SetDeployment(id, repo, branch, commit, agent string) {
 	m.Deployment = DeploymentData{ID: id, Repo: repo, Branch: branch, Commit: commit, Agent: agent, Status: "pending"}
+	m.Steps = nil
 }
 
 func (m DeployModel) View() string {
@@ -113,19 +223,53 @@ func (m DeployModel) View() string {
 	b.WriteString("\n")
 	b.WriteString(components.ViewHeader(w, "Dashboard", "Deployment") + "\n\n")
 
-	if m.Deployment.ID == "" {
+	if m.errs.Len() > 0 {
+		b.WriteString(m.errs.View(w) + "\n\n")
+	}
+
+	if len(m.Approvals) > 0 {
+		b.WriteString(m.viewApprovals(w))
+	}
+
+	if m.Deployment.ID == "" && len(m.Approvals) > 0 {
+		b.WriteString("  " + styles.MutedStyle.Render("Approve to send the deployment to its agent, reject to drop it") + "\n")
+	} else if m.Deployment.ID == "" {
 		b.WriteString(components.Section("STATUS", w) + "\n\n")
 		b.WriteString(components.Empty("No deployment in progress", "Start a deployment from the repositories view", w) + "\n")
 	} else {
 		b.WriteString(components.Section("CURRENT DEPLOYMENT", w) + "\n\n")
 
 		var infoContent strings.Builder
-		infoContent.WriteString(styles.TitleStyle.Render(m.Deployment.Repo) + "  " + components.Badge(m.Deployment.Status) + "\n")
-		infoContent.WriteString("\n" + styles.SubtleStyle.Render("Branch ") + m.Deployment.Branch)
-		infoContent.WriteString("\n" + styles.SubtleStyle.Render("Commit ") + styles.MutedStyle.Render(m.Deployment.Commit))
-		infoContent.WriteString("\n" + styles.SubtleStyle.Render("Agent  ") + m.Deployment.Agent)
+		infoContent.WriteString(styles.TitleStyle.Render(m.Deployment.Repo) + "  " + components.Badge(m.Deployment.Status))
+		if m.Deployment.DryRun && m.Deployment.Status != "dry_run" {
+			infoContent.WriteString(" " + components.Badge("dry_run"))
+		}
+		infoContent.WriteString("\n")
+		if m.Deployment.Detail != "" {
+			infoContent.WriteString(styles.MutedStyle.Render(styles.Trunc(m.Deployment.Detail, w-8)) + "\n")
+		}
+		if m.Deployment.Tag != "" {
+			infoContent.WriteString("\n" + styles.SubtleStyle.Render("Tag    ") + styles.Trunc(m.Deployment.Tag, w-15))
+		} else {
+			infoContent.WriteString("\n" + styles.SubtleStyle.Render("Branch ") + styles.Trunc(m.Deployment.Branch, w-15))
+		}
+		infoContent.WriteString("\n" + styles.SubtleStyle.Render("Commit ") + styles.MutedStyle.Render(shortCommit(m.Deployment.Commit)))
+		infoContent.WriteString("\n" + styles.SubtleStyle.Render("Agent  ") + styles.Trunc(m.Deployment.Agent, w-15))
+		if m.Deployment.File != "" {
+			infoContent.WriteString("\n" + styles.SubtleStyle.Render("File   ") + styles.Trunc(m.Deployment.File, w-15))
+		}
+		if m.Deployment.By != "" {
+			by := styles.Trunc(m.Deployment.By, w-18-len(m.Deployment.Trigger))
+			infoContent.WriteString("\n" + styles.SubtleStyle.Render("By     ") + by +
+				styles.MutedStyle.Render(" ("+m.Deployment.Trigger+")"))
+		}
 		b.WriteString(components.Wrap(infoContent.String(), w) + "\n\n")
 
+		if m.Deployment.Changes != "" {
+			b.WriteString(components.Section("CHANGES", w) + "\n\n")
+			b.WriteString(components.Wrap(renderChanges(m.Deployment.Changes, w-8), w) + "\n\n")
+		}
+
 		if len(m.Steps) > 0 {
 			b.WriteString(components.Section("PROGRESS", w) + "\n\n")
 			steps := make([]components.ProgressStep, len(m.Steps))
@@ -135,14 +279,28 @@ func (m DeployModel) View() string {
 			b.WriteString(components.Wrap(components.Progress(steps, w-8), w) + "\n")
 
 			if m.CurrentLog != "" && m.Deployment.Status == "running" {
-				b.WriteString("\n  " + styles.SubtleStyle.Render(m.CurrentLog) + "\n")
+				b.WriteString("\n  " + styles.SubtleStyle.Render(styles.Trunc(m.CurrentLog, w-4)) + "\n")
+			}
+		}
+
+		if len(m.Deployment.Images) > 0 {
+			b.WriteString("\n" + components.Section("IMAGES", w) + "\n\n")
+			b.WriteString(components.Wrap(renderImages(m.Deployment.Images, w-8), w) + "\n")
+			if m.Deployment.ImageUnchanged {
+				b.WriteString("\n" + components.MsgWarning("No image change since the previous deployment", w) + "\n")
 			}
 		}
 
 		if m.Deployment.Status == "success" {
 			b.WriteString("\n" + components.MsgSuccess(fmt.Sprintf("Deployment completed in %s", m.Deployment.Time), w) + "\n")
+		} else if m.Deployment.Status == "success_with_warnings" {
+			b.WriteString("\n" + components.MsgWarning(fmt.Sprintf("Deployment completed in %s, post-deploy hook failed", m.Deployment.Time), w) + "\n")
 		} else if m.Deployment.Status == "failed" {
-			b.WriteString("\n" + components.MsgError("Deployment failed", w) + "\n")
+			failed := "Deployment failed"
+			if m.Deployment.Exit != nil && *m.Deployment.Exit != 0 {
+				failed = fmt.Sprintf("Deployment failed, exited %d", *m.Deployment.Exit)
+			}
+			b.WriteString("\n" + components.MsgError(failed, w) + "\n")
 		}
 	}
 
@@ -153,12 +311,56 @@ func (m DeployModel) View() string {
 	}
 
 	content += "\n" + styles.Line(w) + "\n"
+	var approvalHelp [][]string
+	if len(m.Approvals) > 0 {
+		approvalHelp = [][]string{{"↑↓", "select"}, {"y", "approve"}, {"n", "reject"}}
+	}
 	if m.Deployment.Status == "running" {
-		content += components.Help([][]string{{"l", "logs"}})
+		content += components.Help(append(approvalHelp, []string{"l", "logs"}))
 		content += "   " + styles.PrimaryStyle.Render(styles.IconSpin) + " " + styles.MutedStyle.Render("deploying...")
 	} else {
-		content += components.Help([][]string{{"l", "logs"}, {"esc", "back"}})
+		content += components.Help(append(approvalHelp, []string{"l", "logs"}, []string{"esc", "back"}))
 	}
 
 	return content
 }
+
+func renderImages(images []DeployedImage, w int) string {
+	nameW := 0
+	for _, img := range images {
+		nameW = max(nameW, len(img.Name))
+	}
+	nameW = min(nameW, w/3)
+
+	lines := make([]string, len(images))
+	for i, img := range images {
+		name := fmt.Sprintf("%-*s", nameW, helper.TruncateString(img.Name, nameW))
+		imageW := max(w-nameW-len(img.ImageID)-4, 8)
+		lines[i] = styles.BrightStyle.Render(name) + "  " + helper.TruncateString(img.Image, imageW) +
+			"  " + styles.MutedStyle.Render(img.ImageID)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func shortImageID(id string) string {
+	id = strings.TrimPrefix(id, "sha256:")
+	if len(id) > 12 {
+		return id[:12]
+	}
+	return id
+}
+
+func renderChanges(summary string, w int) string {
+	lines := strings.Split(summary, "\n")
+	var b strings.Builder
+	b.WriteString(styles.BrightStyle.Render(helper.TruncateString(lines[0], w)))
+	for _, line := range lines[1:] {
+		hash, subject, found := strings.Cut(line, " ")
+		if !found || strings.HasPrefix(line, "…") {
+			b.WriteString("\n" + styles.MutedStyle.Render(helper.TruncateString(line, w)))
+			continue
+		}
+		b.WriteString("\n" + styles.PrimaryStyle.Render(hash) + " " + helper.TruncateString(subject, w-len(hash)-1))
+	}
+	return b.String()
+}
@@ -0,0 +1,114 @@
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+
+package views
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/urustack/uruflow/internal/models"
+	"github.com/urustack/uruflow/internal/tui/components"
+	"github.com/urustack/uruflow/internal/tui/styles"
+	"github.com/urustack/uruflow/pkg/helper"
+)
+
+type approvalsMsg []DeploymentData
+
+type approvalDoneMsg struct {
+	Deployment *models.Deployment
+	Approved   bool
+}
+
+func (m DeployModel) fetchApprovals() tea.Msg {
+	waiting, err := m.store.GetDeploymentsByStatus(models.DeployAwaitingApproval)
+	if err != nil {
+		return opError("loading approvals", err)
+	}
+	window := m.cfg.ApprovalWindow()
+	data := make(approvalsMsg, 0, len(waiting))
+	for _, d := range waiting {
+		expires := ""
+		if window > 0 {
+			left := max(time.Until(d.StartedAt.Add(window)), 0)
+			expires = fmt.Sprintf("%dh %dm", int(left.Hours()), int(left.Minutes())%60)
+		}
+		data = append(data, DeploymentData{
+			ID: d.ID, Repo: d.Repository, Branch: d.Branch, Commit: shortCommit(d.Commit),
+			Agent: d.AgentName, Status: string(d.Status),
+			Time:    helper.FormatTimeAgo(d.StartedAt),
+			Trigger: d.Trigger,
+			By:      d.TriggeredByLabel(),
+			Expires: expires,
+		})
+	}
+	return data
+}
+
+func (m DeployModel) approve(id string) tea.Cmd {
+	return func() tea.Msg {
+		d, err := m.deployService.Approve(id, m.cfg.OperatorName())
+		if err != nil {
+			return opError("approving deployment", err)
+		}
+		return approvalDoneMsg{Deployment: d, Approved: true}
+	}
+}
+
+func (m DeployModel) reject(id string) tea.Cmd {
+	return func() tea.Msg {
+		d, err := m.deployService.Reject(id, m.cfg.OperatorName())
+		if err != nil {
+			return opError("rejecting deployment", err)
+		}
+		return approvalDoneMsg{Deployment: d}
+	}
+}
+
+func (m DeployModel) viewApprovals(w int) string {
+	var b strings.Builder
+	b.WriteString(components.Section(fmt.Sprintf("AWAITING APPROVAL (%d)", len(m.Approvals)), w) + "\n\n")
+
+	var content strings.Builder
+	for i, d := range m.Approvals {
+		ptr := "   "
+		nameStyle := styles.BrightStyle
+		if i == m.Cursor {
+			ptr = " " + styles.Pointer() + " "
+			nameStyle = styles.PrimaryStyle
+		}
+		target := d.Branch
+		if d.Commit != "" {
+			target += "@" + d.Commit
+		}
+		row := fmt.Sprintf("%s%s  %s  %s  %s",
+			ptr,
+			nameStyle.Render(styles.Pad(styles.Trunc(d.Repo, 16), 16)),
+			styles.MutedStyle.Render(styles.Pad(styles.Trunc(target, 20), 20)),
+			styles.Pad(styles.Trunc(d.By, 24), 24),
+			styles.SubtleStyle.Render(d.Time))
+		if d.Expires != "" {
+			row += styles.MutedStyle.Render("  expires in " + d.Expires)
+		}
+		content.WriteString(row + "\n")
+	}
+	b.WriteString(components.Wrap(content.String(), w) + "\n\n")
+	return b.String()
+}
@@ -23,8 +23,12 @@ import (
 	"strings"
 	"time"
 
+	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/urustack/uruflow/internal/models"
+	"github.com/urustack/uruflow/internal/services"
 	"github.com/urustack/uruflow/internal/storage"
+	"github.com/urustack/uruflow/internal/tcp"
 	"github.com/urustack/uruflow/internal/tui/components"
 	"github.com/urustack/uruflow/internal/tui/styles"
 	"github.com/urustack/uruflow/pkg/helper"
@@ -35,46 +39,145 @@ type LogsMode int
 const (
 	LogsModeSelect LogsMode = iota
 	LogsModeView
+	LogsModeConfirmRollback
+	LogsModeFilter
 )
 
+const historyPageSize = 20
+
+const (
+	logWindow   = 2000
+	logPageSize = 500
+)
+
+type logPageKind int
+
+const (
+	logPageTail logPageKind = iota
+	logPageHead
+	logPageNewer
+	logPageOlder
+)
+
+const (
+	filterRepo = iota
+	filterAgent
+	filterStatus
+	filterFieldCount
+)
+
+var filterFieldNames = [filterFieldCount]string{"repo", "agent", "status"}
+
 type LogsModel struct {
-	store        storage.Store
-	Width        int
-	Height       int
-	Mode         LogsMode
-	Deployments  []DeploymentData
-	Cursor       int
+	store         storage.Store
+	deployService *services.DeploymentService
+	Width         int
+	Height        int
+	Mode          LogsMode
+	Deployments   []DeploymentData
+	Cursor        int
+	DeploymentID  string
+	Repo          string
+	Commit        string
+	Logs          []LogData
+	Offset        int
+	AutoFollow    bool
+	olderLogs     bool
+	loadingOlder  bool
+	Dialog        components.Dialog
+	Page          int
+	Total         int
+	filter        [filterFieldCount]string
+	draft         [filterFieldCount]string
+	filterField   int
+	filterErr     string
+	input         textinput.Model
+	search        logSearch
+	errs          components.ErrorStack
+	artifacts     []models.DeploymentArtifact
+	artifact      string
+}
+
+type rollbackMsg struct {
+	Deployment *models.Deployment
+}
+
+type logPageMsg struct {
 	DeploymentID string
-	Repo         string
+	Kind         logPageKind
+	Logs         []LogData
+	Full         bool
 	Commit       string
+	Err          error
+}
+
+type artifactListMsg struct {
+	DeploymentID string
+	Artifacts    []models.DeploymentArtifact
+}
+
+type artifactMsg struct {
+	DeploymentID string
+	Name         string
 	Logs         []LogData
-	Offset       int
-	AutoFollow   bool
-	err          error
 }
 
-func NewLogsModel(store storage.Store) LogsModel {
-	return LogsModel{store: store, AutoFollow: true, Mode: LogsModeSelect}
+type historyPageMsg struct {
+	Deployments []DeploymentData
+	Total       int
+	Page        int
 }
 
-func (m LogsModel) Init() tea.Cmd {
-	return tea.Batch(m.fetchDeployments, m.tick)
+func NewLogsModel(store storage.Store, deployService *services.DeploymentService) LogsModel {
+	ti := textinput.New()
+	ti.Cursor.Style = styles.PrimaryStyle
+	ti.CharLimit = 64
+
+	return LogsModel{
+		store: store, deployService: deployService, AutoFollow: true, Mode: LogsModeSelect,
+		input: ti, search: newLogSearch(),
+	}
 }
 
-func (m LogsModel) tick() tea.Msg {
-	time.Sleep(1 * time.Second)
-	return TickMsg(time.Now())
+func (m LogsModel) Init() tea.Cmd {
+	return m.fetchDeployments
 }
 
 func (m LogsModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
-	case TickMsg:
+	case DeploymentEventMsg:
 		if m.Mode == LogsModeView && m.DeploymentID != "" {
-			return m, tea.Batch(m.fetchLogs, m.tick)
+			var cmds []tea.Cmd
+			if msg.Kind == tcp.DeploymentDone && msg.DeploymentID == m.DeploymentID {
+				cmds = append(cmds, m.fetchArtifacts)
+			}
+			if m.artifact == "" && (msg.DeploymentID == m.DeploymentID || msg.Dirty) {
+				cmds = append(cmds, m.fetchLogs)
+			}
+			return m, tea.Batch(cmds...)
 		}
-		return m, tea.Batch(m.fetchDeployments, m.tick)
+		if msg.Kind == tcp.DeploymentLog && !msg.Dirty {
+			return m, nil
+		}
+		return m, m.fetchDeployments
 
 	case tea.KeyMsg:
+		if m.Mode == LogsModeConfirmRollback {
+			return m.updateConfirmRollback(msg)
+		}
+		if m.Mode == LogsModeFilter {
+			return m.updateFilter(msg)
+		}
+		if m.Mode == LogsModeView && m.search.typing {
+			confirmed, cmd := m.search.update(msg, m.Logs)
+			if confirmed {
+				m.jumpTo(m.search.next(m.Offset, false))
+			}
+			return m, cmd
+		}
+		if dismissError(&m.errs, msg.String()) {
+			return m, nil
+		}
 		switch m.Mode {
 		case LogsModeSelect:
 			return m.updateSelect(msg)
@@ -82,28 +185,113 @@ func (m LogsModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m.updateView(msg)
 		}
 
-	case []DeploymentData:
-		m.Deployments = msg
+	case historyPageMsg:
+		m.Deployments = msg.Deployments
+		m.Total = msg.Total
+		m.Page = msg.Page
+		if m.Cursor >= len(m.Deployments) {
+			m.Cursor = len(m.Deployments) - 1
+		}
+		if m.Cursor < 0 {
+			m.Cursor = 0
+		}
+		m.errs.Resolve("loading deployment history")
 		return m, nil
 
-	case []LogData:
-		m.Logs = msg
-		if m.AutoFollow && len(m.Logs) > 0 {
-			maxOffset := len(m.Logs) - (m.Height - 12)
-			if maxOffset < 0 {
-				maxOffset = 0
+	case logPageMsg:
+		if msg.DeploymentID != m.DeploymentID {
+			return m, nil
+		}
+		if msg.Err != nil {
+			if msg.Kind == logPageOlder {
+				m.loadingOlder = false
 			}
-			m.Offset = maxOffset
+			pushError(&m.errs, opError("loading deployment logs", msg.Err))
+			return m, nil
+		}
+		switch msg.Kind {
+		case logPageTail, logPageHead:
+			m.Logs = msg.Logs
+			m.olderLogs = msg.Kind == logPageTail && msg.Full
+			m.Offset = 0
+			m.search.reset()
+			m.search.extend(m.Logs)
+		case logPageNewer:
+			m.appendLogs(msg.Logs)
+		case logPageOlder:
+			m.loadingOlder = false
+			m.olderLogs = msg.Full
+			m.prependLogs(msg.Logs)
+		}
+		if msg.Commit != "" {
+			m.Commit = msg.Commit
+		}
+		if m.AutoFollow {
+			m.Offset = m.maxOffset()
+		}
+		m.errs.Resolve("loading deployment logs")
+		return m, nil
+
+	case artifactListMsg:
+		if msg.DeploymentID == m.DeploymentID {
+			m.artifacts = msg.Artifacts
+		}
+		return m, nil
+
+	case artifactMsg:
+		if msg.DeploymentID != m.DeploymentID || msg.Name != m.artifact {
+			return m, nil
 		}
+		m.Logs = msg.Logs
+		m.Offset = 0
+		m.search.reset()
+		m.search.extend(m.Logs)
+		m.errs.Resolve("loading deployment artifact")
 		return m, nil
 
+	case rollbackMsg:
+		m.errs.Resolve("rolling back deployment")
+		m.SetDeployment(msg.Deployment.ID, msg.Deployment.Repository, shortCommit(msg.Deployment.Commit))
+		return m, tea.Batch(m.fetchLogs, m.fetchArtifacts)
+
 	case error:
-		m.err = msg
+		pushError(&m.errs, msg)
 		return m, nil
 	}
 	return m, nil
 }
 
+func (m LogsModel) updateConfirmRollback(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc", "n":
+		m.Mode = LogsModeSelect
+		m.Dialog.Visible = false
+	case "left", "right", "h", "l", "tab":
+		m.Dialog.ToggleSelection()
+	case "enter":
+		m.Mode = LogsModeSelect
+		m.Dialog.Visible = false
+		if m.Dialog.IsConfirmed() {
+			return m, m.rollback(m.Deployments[m.Cursor].ID)
+		}
+	case "y":
+		m.Mode = LogsModeSelect
+		m.Dialog.Visible = false
+		return m, m.rollback(m.Deployments[m.Cursor].ID)
+	}
+	return m, nil
+}
+
+func (m LogsModel) rollback(deploymentID string) tea.Cmd {
+	return func() tea.Msg {
+		deploy, err := m.deployService.Rollback(deploymentID)
+		if err != nil {
+			return opError("rolling back deployment", err)
+		}
+		return rollbackMsg{Deployment: deploy}
+	}
+}
+
 func (m LogsModel) updateSelect(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	switch msg.String() {
 	case "up", "k":
@@ -124,7 +312,50 @@ func (m LogsModel) updateSelect(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			m.Logs = nil
 			m.Offset = 0
 			m.AutoFollow = true
-			return m, m.fetchLogs
+			m.olderLogs = false
+			m.loadingOlder = false
+			m.search.clear()
+			m.artifacts = nil
+			m.artifact = ""
+			return m, tea.Batch(m.fetchLogs, m.fetchArtifacts)
+		}
+	case "R":
+		if m.Cursor < len(m.Deployments) {
+			d := m.Deployments[m.Cursor]
+			if !models.DeployStatus(d.Status).Succeeded() {
+				pushError(&m.errs, opError("rolling back deployment", fmt.Errorf("only successful deployments can be redeployed")))
+				return m, nil
+			}
+			m.Dialog = components.RollbackDialog(d.Repo, d.Commit)
+			m.Mode = LogsModeConfirmRollback
+		}
+	case "n", "pgdown":
+		if (m.Page+1)*historyPageSize < m.Total {
+			m.Page++
+			m.Cursor = 0
+			return m, m.fetchDeployments
+		}
+	case "p", "pgup":
+		if m.Page > 0 {
+			m.Page--
+			m.Cursor = 0
+			return m, m.fetchDeployments
+		}
+	case "/":
+		m.draft = m.filter
+		m.filterField = filterRepo
+		m.filterErr = ""
+		m.input.SetValue(m.draft[m.filterField])
+		m.input.CursorEnd()
+		m.input.Focus()
+		m.Mode = LogsModeFilter
+		return m, nil
+	case "c":
+		if m.filter != [filterFieldCount]string{} {
+			m.filter = [filterFieldCount]string{}
+			m.Page = 0
+			m.Cursor = 0
+			return m, m.fetchDeployments
 		}
 	case "r":
 		return m, m.fetchDeployments
@@ -132,51 +363,185 @@ func (m LogsModel) updateSelect(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+func (m LogsModel) updateFilter(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.input.Blur()
+		m.Mode = LogsModeSelect
+		return m, nil
+	case "tab", "shift+tab":
+		m.draft[m.filterField] = strings.TrimSpace(m.input.Value())
+		if msg.String() == "tab" {
+			m.filterField = (m.filterField + 1) % filterFieldCount
+		} else {
+			m.filterField = (m.filterField + filterFieldCount - 1) % filterFieldCount
+		}
+		m.input.SetValue(m.draft[m.filterField])
+		m.input.CursorEnd()
+		return m, nil
+	case "enter":
+		m.draft[m.filterField] = strings.TrimSpace(m.input.Value())
+		switch models.DeployStatus(strings.ToLower(m.draft[filterStatus])) {
+		case "", models.DeploySuccess, models.DeployWarning, models.DeployFailed, models.DeployRunning, models.DeployPending,
+			models.DeployAwaitingApproval, models.DeployRejected, models.DeployDryRun:
+			m.draft[filterStatus] = strings.ToLower(m.draft[filterStatus])
+		default:
+			m.filterErr = "status must be success, success_with_warnings, failed, running, pending, awaiting_approval, rejected or dry_run"
+			m.filterField = filterStatus
+			m.input.SetValue(m.draft[filterStatus])
+			m.input.CursorEnd()
+			return m, nil
+		}
+		m.filter = m.draft
+		m.filterErr = ""
+		m.input.Blur()
+		m.Mode = LogsModeSelect
+		m.Page = 0
+		m.Cursor = 0
+		return m, m.fetchDeployments
+	}
+
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	return m, cmd
+}
+
+func (m LogsModel) filterLabel() string {
+	var parts []string
+	for i, v := range m.filter {
+		if v != "" {
+			parts = append(parts, filterFieldNames[i]+":"+v)
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+func (m LogsModel) Searching() bool {
+	return m.Mode == LogsModeView && m.search.typing
+}
+
+func (m *LogsModel) jumpTo(idx int) {
+	if idx < 0 {
+		return
+	}
+	m.Offset = offsetFor(idx, len(m.Logs), max(m.visibleRows(), 1))
+	m.AutoFollow = false
+}
+
 func (m LogsModel) updateView(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	switch msg.String() {
+	case "/":
+		return m, m.search.begin()
+	case "n":
+		m.jumpTo(m.search.step(false))
+	case "N":
+		m.jumpTo(m.search.step(true))
+	case "e":
+		m.jumpTo(firstStderr(m.Logs))
+	case "a":
+		return m.nextArtifact()
+	case "s":
+		return m, m.exportLogs(m.DeploymentID)
 	case "esc":
+		if m.search.query != "" {
+			m.search.clear()
+			return m, nil
+		}
+		if m.artifact != "" {
+			return m.showArtifact("")
+		}
 		m.Mode = LogsModeSelect
 		m.DeploymentID = ""
 		m.Logs = nil
+		m.artifacts = nil
 		return m, m.fetchDeployments
 	case "up", "k":
 		if m.Offset > 0 {
 			m.Offset--
 			m.AutoFollow = false
+		} else if m.artifact == "" && m.olderLogs && !m.loadingOlder && len(m.Logs) > 0 {
+			m.AutoFollow = false
+			m.loadingOlder = true
+			return m, m.loadLogs(logPageOlder, m.Logs[0].ID, logPageSize)
 		}
 	case "down", "j":
-		maxOffset := len(m.Logs) - (m.Height - 12)
-		if maxOffset < 0 {
-			maxOffset = 0
-		}
-		if m.Offset < maxOffset {
+		if m.Offset < m.maxOffset() {
 			m.Offset++
+		} else if m.artifact == "" && !m.AutoFollow && len(m.Logs) >= logWindow {
+			return m, m.loadLogs(logPageNewer, m.Logs[len(m.Logs)-1].ID, logPageSize)
 		}
 	case "g":
 		m.Offset = 0
 		m.AutoFollow = false
+		if m.artifact == "" && m.olderLogs {
+			return m, m.loadLogs(logPageHead, 0, logPageSize)
+		}
 	case "G":
-		maxOffset := len(m.Logs) - (m.Height - 12)
-		if maxOffset < 0 {
-			maxOffset = 0
+		if m.artifact != "" {
+			m.Offset = m.maxOffset()
+			return m, nil
 		}
-		m.Offset = maxOffset
 		m.AutoFollow = true
+		m.Offset = m.maxOffset()
+		return m, m.loadLogs(logPageTail, 0, logPageSize)
 	case "f":
+		if m.artifact != "" {
+			return m, nil
+		}
 		m.AutoFollow = !m.AutoFollow
 		if m.AutoFollow {
-			maxOffset := len(m.Logs) - (m.Height - 12)
-			if maxOffset < 0 {
-				maxOffset = 0
-			}
-			m.Offset = maxOffset
+			m.Offset = m.maxOffset()
+			return m, m.loadLogs(logPageTail, 0, logPageSize)
 		}
 	case "r":
+		if m.artifact != "" {
+			return m, m.loadArtifact(m.artifact)
+		}
 		return m, m.fetchLogs
 	}
 	return m, nil
 }
 
+func (m LogsModel) exportLogs(deploymentID string) tea.Cmd {
+	return func() tea.Msg {
+		path, err := m.deployService.ExportLogsFile(deploymentID)
+		if err != nil {
+			return toastError("exporting deployment logs", err)
+		}
+		return toast(components.ToastSuccess, "logs exported to "+path)
+	}
+}
+
+func (m LogsModel) nextArtifact() (tea.Model, tea.Cmd) {
+	if len(m.artifacts) == 0 {
+		return m, nil
+	}
+	if m.artifact == "" {
+		return m.showArtifact(m.artifacts[0].Name)
+	}
+	for i, a := range m.artifacts {
+		if a.Name == m.artifact && i+1 < len(m.artifacts) {
+			return m.showArtifact(m.artifacts[i+1].Name)
+		}
+	}
+	return m.showArtifact("")
+}
+
+func (m LogsModel) showArtifact(name string) (tea.Model, tea.Cmd) {
+	m.artifact = name
+	m.Logs = nil
+	m.Offset = 0
+	m.olderLogs = false
+	m.loadingOlder = false
+	m.search.clear()
+	if name == "" {
+		m.AutoFollow = true
+		return m, m.fetchLogs
+	}
+	m.AutoFollow = false
+	return m, m.loadArtifact(name)
+}
+
 func (m *LogsModel) SetDeployment(id, repo, commit string) {
 	m.DeploymentID = id
 	m.Repo = repo
@@ -185,41 +550,190 @@ func (m *LogsModel) SetDeployment(id, repo, commit string) {
 	m.Logs = nil
 	m.Offset = 0
 	m.AutoFollow = true
+	m.olderLogs = false
+	m.loadingOlder = false
+	m.search.clear()
+	m.artifacts = nil
+	m.artifact = ""
+}
+
+func (m LogsModel) visibleRows() int {
+	rows := m.Height - 12
+	if len(m.artifacts) > 0 {
+		rows -= 2
+	}
+	return rows
+}
+
+func (m LogsModel) maxOffset() int {
+	maxOffset := len(m.Logs) - m.visibleRows()
+	if maxOffset < 0 {
+		maxOffset = 0
+	}
+	return maxOffset
+}
+
+func (m *LogsModel) appendLogs(logs []LogData) {
+	if len(m.Logs) > 0 {
+		last := m.Logs[len(m.Logs)-1].ID
+		for len(logs) > 0 && logs[0].ID <= last {
+			logs = logs[1:]
+		}
+	}
+	m.Logs = append(m.Logs, logs...)
+	if over := len(m.Logs) - logWindow; over > 0 {
+		m.Logs = m.Logs[over:]
+		m.search.dropFront(over)
+		m.Offset -= over
+		if m.Offset < 0 {
+			m.Offset = 0
+		}
+		m.olderLogs = true
+	}
+	m.search.extend(m.Logs)
+}
+
+func (m *LogsModel) prependLogs(logs []LogData) {
+	if len(m.Logs) > 0 {
+		first := m.Logs[0].ID
+		for len(logs) > 0 && logs[len(logs)-1].ID >= first {
+			logs = logs[:len(logs)-1]
+		}
+	}
+	if len(logs) == 0 {
+		return
+	}
+	m.Logs = append(append(make([]LogData, 0, len(logs)+len(m.Logs)), logs...), m.Logs...)
+	if len(m.Logs) > logWindow {
+		m.Logs = m.Logs[:logWindow]
+	}
+	m.Offset += len(logs) - 1
+	if m.Offset < 0 {
+		m.Offset = 0
+	}
+	m.search.reset()
+	m.search.extend(m.Logs)
 }
 
 func (m LogsModel) fetchDeployments() tea.Msg {
-	deployments, err := m.store.GetRecentDeployments(20)
+	filter := storage.DeploymentFilter{
+		Repo:   m.filter[filterRepo],
+		Status: models.DeployStatus(m.filter[filterStatus]),
+	}
+	if agent := m.filter[filterAgent]; agent != "" {
+		filter.AgentID = agent
+		agents, err := m.store.GetAllAgents()
+		if err != nil {
+			return opError("loading deployment history", err)
+		}
+		for _, a := range agents {
+			if a.Name == agent {
+				filter.AgentID = a.ID
+				break
+			}
+		}
+	}
+
+	page := m.Page
+	deployments, total, err := m.store.GetDeploymentsPage(page*historyPageSize, historyPageSize, filter)
 	if err != nil {
-		return err
+		return opError("loading deployment history", err)
+	}
+	if len(deployments) == 0 && page > 0 && total > 0 {
+		page = (total - 1) / historyPageSize
+		deployments, total, err = m.store.GetDeploymentsPage(page*historyPageSize, historyPageSize, filter)
+		if err != nil {
+			return opError("loading deployment history", err)
+		}
 	}
+
 	var data []DeploymentData
 	for _, d := range deployments {
-		commit := d.Commit
-		if len(commit) > 7 {
-			commit = commit[:7]
-		}
 		data = append(data, DeploymentData{
-			ID: d.ID, Repo: d.Repository, Branch: d.Branch, Commit: commit,
-			Agent: d.AgentName, Status: string(d.Status),
-			Time: time.Since(d.StartedAt).Round(time.Second).String() + " ago",
+			ID: d.ID, Repo: d.Repository, Branch: d.Branch, Tag: d.Tag, Commit: shortCommit(d.Commit),
+			Agent: d.AgentName, Status: string(d.Status), Detail: d.StatusDetail, By: d.Initiator(),
+			Time: time.Since(d.StartedAt).Round(time.Second).String() + " ago", DryRun: d.DryRun,
 		})
 	}
-	return data
+	return historyPageMsg{Deployments: data, Total: total, Page: page}
 }
 
 func (m LogsModel) fetchLogs() tea.Msg {
 	if m.DeploymentID == "" {
-		return []LogData{}
+		return nil
+	}
+	if len(m.Logs) == 0 {
+		return m.fetchLogPage(logPageTail, 0, logPageSize)
+	}
+	limit := logPageSize
+	if !m.AutoFollow {
+		limit = min(limit, logWindow-len(m.Logs))
+		if limit <= 0 {
+			return nil
+		}
+	}
+	return m.fetchLogPage(logPageNewer, m.Logs[len(m.Logs)-1].ID, limit)
+}
+
+func (m LogsModel) loadLogs(kind logPageKind, cursor int64, limit int) tea.Cmd {
+	return func() tea.Msg {
+		return m.fetchLogPage(kind, cursor, limit)
+	}
+}
+
+func (m LogsModel) fetchLogPage(kind logPageKind, cursor int64, limit int) tea.Msg {
+	var logs []models.DeploymentLog
+	var err error
+	switch kind {
+	case logPageTail, logPageOlder:
+		logs, err = m.store.GetDeploymentLogsBefore(m.DeploymentID, cursor, limit)
+	default:
+		logs, err = m.store.GetDeploymentLogsPage(m.DeploymentID, cursor, limit)
+	}
+	if err != nil {
+		return logPageMsg{DeploymentID: m.DeploymentID, Kind: kind, Err: err}
+	}
+	data := make([]LogData, len(logs))
+	for i, l := range logs {
+		data[i] = LogData{ID: l.ID, Time: l.Timestamp.Format("15:04:05"), Content: l.Line, Stream: l.Stream}
+	}
+	msg := logPageMsg{DeploymentID: m.DeploymentID, Kind: kind, Logs: data, Full: len(logs) == limit}
+	if m.Commit == "" || m.Commit == "HEAD" {
+		if d, _ := m.store.GetDeployment(m.DeploymentID); d != nil {
+			msg.Commit = shortCommit(d.Commit)
+		}
+	}
+	return msg
+}
+
+func (m LogsModel) fetchArtifacts() tea.Msg {
+	if m.DeploymentID == "" {
+		return nil
 	}
-	logs, err := m.store.GetDeploymentLogs(m.DeploymentID)
+	artifacts, err := m.store.GetDeploymentArtifacts(m.DeploymentID)
 	if err != nil {
-		return err
+		return opError("loading deployment artifacts", err)
 	}
-	var data []LogData
-	for _, l := range logs {
-		data = append(data, LogData{Time: l.Timestamp.Format("15:04:05"), Content: l.Line, Stream: l.Stream})
+	return artifactListMsg{DeploymentID: m.DeploymentID, Artifacts: artifacts}
+}
+
+func (m LogsModel) loadArtifact(name string) tea.Cmd {
+	id := m.DeploymentID
+	return func() tea.Msg {
+		a, err := m.store.GetDeploymentArtifact(id, name)
+		if err != nil {
+			return opError("loading deployment artifact", err)
+		}
+		if a == nil {
+			return opError("loading deployment artifact", fmt.Errorf("artifact %s not found", name))
+		}
+		lines := strings.Split(strings.TrimRight(a.Content, "\n"), "\n")
+		data := make([]LogData, len(lines))
+		for i, line := range lines {
+			data[i] = LogData{ID: int64(i + 1), Time: fmt.Sprintf("%4d", i+1), Content: line, Stream: "stdout"}
+		}
+		return artifactMsg{DeploymentID: id, Name: name, Logs: data}
 	}
-	return data
 }
 
 func (m LogsModel) View() string {
@@ -230,6 +744,8 @@ func (m LogsModel) View() string {
 	switch m.Mode {
 	case LogsModeView:
 		return m.viewLogs()
+	case LogsModeConfirmRollback:
+		return m.viewSelect() + components.ConfirmDialog(m.Dialog, m.Width, m.Height)
 	default:
 		return m.viewSelect()
 	}
@@ -241,10 +757,21 @@ func (m LogsModel) viewSelect() string {
 
 	b.WriteString("\n")
 	b.WriteString(components.ViewHeader(w, "Dashboard", "History") + "\n\n")
-	b.WriteString(components.Section("PAST DEPLOYMENTS", w) + "\n\n")
+
+	if m.errs.Len() > 0 {
+		b.WriteString(m.errs.View(w) + "\n\n")
+	}
+	title := "PAST DEPLOYMENTS"
+	if label := m.filterLabel(); label != "" {
+		title += " · " + label
+	}
+	b.WriteString(components.Section(title, w) + "\n\n")
 
 	var listContent strings.Builder
-	if len(m.Deployments) == 0 {
+	if len(m.Deployments) == 0 && m.filterLabel() != "" {
+		listContent.WriteString("  " + styles.MutedStyle.Render("No deployments match the filter") + "\n")
+		listContent.WriteString("  " + styles.SubtleStyle.Render("Press c to clear it"))
+	} else if len(m.Deployments) == 0 {
 		listContent.WriteString("  " + styles.MutedStyle.Render("No history found") + "\n")
 		listContent.WriteString("  " + styles.SubtleStyle.Render("Deploy a repository first"))
 	} else {
@@ -260,26 +787,53 @@ func (m LogsModel) viewSelect() string {
 				icon = styles.ErrorStyle.Render(styles.IconError)
 			} else if d.Status == "running" {
 				icon = styles.PrimaryStyle.Render(styles.IconSpin)
-			} else if d.Status == "pending" {
+			} else if d.Status == "pending" || d.Status == "awaiting_approval" {
 				icon = styles.WarningStyle.Render(styles.IconWarning)
+			} else if d.Status == "rejected" {
+				icon = styles.MutedStyle.Render(styles.IconUncheck)
+			} else if d.Status == "dry_run" {
+				icon = styles.MutedStyle.Render(styles.IconSuccess)
 			}
 
 			nameStyle := styles.BrightStyle
 			if selected {
 				nameStyle = styles.PrimaryStyle
 			}
+			ref := styles.MutedStyle.Render(styles.Pad(styles.Trunc(d.Branch, 10), 10))
+			if d.Tag != "" {
+				ref = styles.PrimaryStyle.Render(styles.Pad(styles.Trunc(d.Tag, 10), 10))
+			}
 
-			listContent.WriteString(fmt.Sprintf("%s%s  %s  %s  %s  %s\n",
+			detail := ""
+			if d.DryRun {
+				detail = "  " + components.Badge("dry_run")
+			}
+			if d.Detail != "" {
+				detail += "  " + styles.WarningStyle.Render(styles.Trunc(d.Detail, 28))
+			}
+
+			listContent.WriteString(fmt.Sprintf("%s%s  %s  %s  %s  %s  %s%s\n",
 				ptr,
 				icon,
 				nameStyle.Render(styles.Pad(styles.Trunc(d.Repo, 16), 16)),
-				styles.MutedStyle.Render(styles.Pad(d.Branch, 10)),
+				ref,
 				styles.Pad(d.Commit, 8),
-				styles.MutedStyle.Render(d.Time)))
+				styles.SubtleStyle.Render(styles.Pad(styles.Trunc(d.By, 12), 12)),
+				styles.MutedStyle.Render(d.Time),
+				detail))
 		}
 	}
 	b.WriteString(components.Wrap(listContent.String(), w) + "\n")
 
+	if m.Total > 0 {
+		pages := (m.Total + historyPageSize - 1) / historyPageSize
+		b.WriteString("  " + styles.MutedStyle.Render(fmt.Sprintf("page %d of %d · %d deployments", m.Page+1, pages, m.Total)) + "\n")
+	}
+
+	if m.Mode == LogsModeFilter {
+		b.WriteString("\n" + m.viewFilter(w) + "\n")
+	}
+
 	content := b.String()
 	lines := helper.CountLines(content)
 	for i := 0; i < m.Height-lines-3; i++ {
@@ -287,13 +841,45 @@ func (m LogsModel) viewSelect() string {
 	}
 
 	content += "\n" + styles.Line(w) + "\n"
+	if m.Mode == LogsModeFilter {
+		content += components.Help([][]string{
+			{"tab", "next field"}, {"enter", "apply"}, {"esc", "cancel"},
+		})
+		return content
+	}
 	content += components.Help([][]string{
-		{"↑↓", "navigate"}, {"enter", "view logs"}, {"r", "refresh"}, {"esc", "back"},
+		{"↑↓", "navigate"}, {"enter", "view logs"}, {"n/p", "page"}, {"/", "filter"}, {"c", "clear filter"},
+		{"R", "rollback"}, {"r", "refresh"}, {"esc", "back"},
 	})
 
 	return content
 }
 
+func (m LogsModel) viewFilter(w int) string {
+	var fields []string
+	for i, name := range filterFieldNames {
+		if i == m.filterField {
+			fields = append(fields, styles.PrimaryStyle.Render("["+name+"]"))
+		} else if m.draft[i] != "" {
+			fields = append(fields, styles.BrightStyle.Render(name+":"+m.draft[i]))
+		} else {
+			fields = append(fields, styles.MutedStyle.Render(name))
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString(components.Section("FILTER", w) + "\n\n")
+	b.WriteString("  " + strings.Join(fields, "  ") + "\n\n")
+	b.WriteString("  " + styles.InputBoxFocused.Width(w-8).Render(m.input.View()) + "\n")
+	if m.filterField == filterStatus {
+		b.WriteString("  " + styles.SubtleStyle.Render("success, failed, running, pending, awaiting_approval or rejected") + "\n")
+	}
+	if m.filterErr != "" {
+		b.WriteString("\n" + components.MsgError(m.filterErr, w) + "\n")
+	}
+	return b.String()
+}
+
 func (m LogsModel) viewLogs() string {
 	var b strings.Builder
 	w := m.Width
@@ -301,6 +887,10 @@ func (m LogsModel) viewLogs() string {
 	b.WriteString("\n")
 	b.WriteString(components.ViewHeader(w, "Dashboard", "History", "Logs") + "\n\n")
 
+	if m.errs.Len() > 0 {
+		b.WriteString(m.errs.View(w) + "\n\n")
+	}
+
 	title := m.Repo
 	if title == "" {
 		title = "Deployment " + m.DeploymentID[:8]
@@ -308,16 +898,27 @@ func (m LogsModel) viewLogs() string {
 	if m.Commit != "" {
 		title += " / " + m.Commit
 	}
+	if m.artifact != "" {
+		title += " / " + m.artifact
+	}
 
 	b.WriteString(components.Section(title, w) + "\n\n")
+	if len(m.artifacts) > 0 {
+		b.WriteString("  " + m.viewArtifacts() + "\n\n")
+	}
 
-	visibleLines := m.Height - 12
+	visibleLines := m.visibleRows()
+	if m.search.typing {
+		visibleLines -= 3
+	}
 	if visibleLines < 1 {
 		visibleLines = 1
 	}
 
 	var logContent strings.Builder
-	if len(m.Logs) == 0 {
+	if len(m.Logs) == 0 && m.artifact != "" {
+		logContent.WriteString("  " + styles.MutedStyle.Render("Loading artifact..."))
+	} else if len(m.Logs) == 0 {
 		logContent.WriteString("  " + styles.MutedStyle.Render("No logs available") + "\n")
 		logContent.WriteString("  " + styles.SubtleStyle.Render("Waiting for deployment output..."))
 	} else {
@@ -326,12 +927,15 @@ func (m LogsModel) viewLogs() string {
 			endIdx = len(m.Logs)
 		}
 		for i := m.Offset; i < endIdx; i++ {
-			log := m.Logs[i]
-			logContent.WriteString(components.LogLine(log.Time, log.Content, log.Stream, w) + "\n")
+			logContent.WriteString(m.search.line(i, m.Logs[i], w-8) + "\n")
 		}
 	}
 	b.WriteString(components.Wrap(logContent.String(), w) + "\n")
 
+	if m.search.typing {
+		b.WriteString("\n" + m.search.view(w) + "\n")
+	}
+
 	content := b.String()
 	lines := helper.CountLines(content)
 	for i := 0; i < m.Height-lines-3; i++ {
@@ -345,10 +949,53 @@ func (m LogsModel) viewLogs() string {
 		followStatus = styles.SuccessStyle.Render("follow: on")
 	}
 
-	content += components.Help([][]string{
-		{"↑↓", "scroll"}, {"g", "top"}, {"G", "bottom"}, {"f", "toggle follow"}, {"r", "refresh"}, {"esc", "back"},
-	})
+	if m.search.typing {
+		content += components.Help([][]string{{"enter", "search"}, {"tab", "toggle regex"}, {"esc", "cancel"}})
+		return content
+	}
+
+	if m.artifact != "" {
+		content += components.Help([][]string{
+			{"↑↓", "scroll"}, {"g", "top"}, {"G", "bottom"}, {"/", "search"}, {"n/N", "next/prev"},
+			{"a", "next artifact"}, {"s", "export logs"}, {"r", "refresh"}, {"esc", "logs"},
+		})
+		if status := m.search.status(); status != "" {
+			content += "   " + status
+		}
+		return content
+	}
+
+	help := [][]string{
+		{"↑↓", "scroll"}, {"g", "top"}, {"G", "bottom"}, {"/", "search"}, {"n/N", "next/prev"}, {"e", "first error"},
+		{"f", "toggle follow"}, {"s", "export"}, {"r", "refresh"},
+	}
+	if len(m.artifacts) > 0 {
+		help = append(help, []string{"a", "artifacts"})
+	}
+	content += components.Help(append(help, []string{"esc", "back"}))
 	content += "   " + followStatus
+	if m.loadingOlder {
+		content += "   " + styles.MutedStyle.Render("loading older lines...")
+	}
+	if status := m.search.status(); status != "" {
+		content += "   " + status
+	}
 
 	return content
 }
+
+func (m LogsModel) viewArtifacts() string {
+	parts := []string{styles.MutedStyle.Render("artifacts:")}
+	for _, a := range m.artifacts {
+		label := fmt.Sprintf("%s (%s)", a.Name, helper.FormatBytes(uint64(a.Size)))
+		if a.Truncated {
+			label += " truncated"
+		}
+		if a.Name == m.artifact {
+			parts = append(parts, styles.PrimaryStyle.Render(label))
+		} else {
+			parts = append(parts, styles.SubtleStyle.Render(label))
+		}
+	}
+	return strings.Join(parts, "  ")
+}
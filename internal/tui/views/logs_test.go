@@ -0,0 +1,171 @@
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+
+package views
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/urustack/uruflow/internal/models"
+	"github.com/urustack/uruflow/internal/storage"
+	"github.com/urustack/uruflow/internal/storage/sqlite"
+)
+
+type countingStore struct {
+	storage.Store
+	t         *testing.T
+	maxRows   int
+	olderErr  error
+	fullReads int
+}
+
+func (s *countingStore) GetDeploymentLogs(deploymentID string) ([]models.DeploymentLog, error) {
+	s.fullReads++
+	return s.Store.GetDeploymentLogs(deploymentID)
+}
+
+func (s *countingStore) GetDeploymentLogsPage(deploymentID string, afterID int64, limit int) ([]models.DeploymentLog, error) {
+	logs, err := s.Store.GetDeploymentLogsPage(deploymentID, afterID, limit)
+	s.maxRows = max(s.maxRows, len(logs))
+	return logs, err
+}
+
+func (s *countingStore) GetDeploymentLogsBefore(deploymentID string, beforeID int64, limit int) ([]models.DeploymentLog, error) {
+	if s.olderErr != nil && beforeID > 0 {
+		return nil, s.olderErr
+	}
+	logs, err := s.Store.GetDeploymentLogsBefore(deploymentID, beforeID, limit)
+	s.maxRows = max(s.maxRows, len(logs))
+	return logs, err
+}
+
+func newLogStore(tb testing.TB, lines int) *countingStore {
+	tb.Helper()
+	store, err := sqlite.New(tb.TempDir())
+	if err != nil {
+		tb.Fatal(err)
+	}
+	tb.Cleanup(func() { store.Close() })
+	if err := store.CreateAgent(&models.Agent{ID: "a1", Name: "web", Status: models.AgentOnline}); err != nil {
+		tb.Fatal(err)
+	}
+	if err := store.CreateDeployment(&models.Deployment{ID: "d1", Repository: "api", AgentID: "a1", Status: models.DeployRunning, StartedAt: time.Now()}); err != nil {
+		tb.Fatal(err)
+	}
+	addLogLines(tb, store, 0, lines)
+	return &countingStore{Store: store}
+}
+
+func addLogLines(tb testing.TB, store storage.Store, from, n int) {
+	tb.Helper()
+	batch := make([]models.DeploymentLog, 0, 1000)
+	for i := from; i < from+n; i++ {
+		batch = append(batch, models.DeploymentLog{DeploymentID: "d1", Timestamp: time.Now(), Stream: "stdout", Line: fmt.Sprintf("line %d", i)})
+		if len(batch) == cap(batch) || i == from+n-1 {
+			if err := store.AddDeploymentLogsBatch(batch); err != nil {
+				tb.Fatal(err)
+			}
+			batch = batch[:0]
+		}
+	}
+}
+
+func update(m LogsModel, msg tea.Msg) (LogsModel, tea.Cmd) {
+	next, cmd := m.Update(msg)
+	return next.(LogsModel), cmd
+}
+
+func TestLogsFetchIsBounded(t *testing.T) {
+	store := newLogStore(t, 50000)
+	m := NewLogsModel(store, nil)
+	m.Height = 40
+	m.SetDeployment("d1", "api", "abc1234")
+
+	m, _ = update(m, m.fetchLogs())
+	if len(m.Logs) != logPageSize || m.Logs[len(m.Logs)-1].Content != "line 49999" {
+		t.Fatalf("initial page has %d lines ending at %q", len(m.Logs), m.Logs[len(m.Logs)-1].Content)
+	}
+
+	for i := 0; i < 10; i++ {
+		addLogLines(t, store.Store, 50000+i*300, 300)
+		m, _ = update(m, m.fetchLogs())
+	}
+	if got := m.Logs[len(m.Logs)-1].Content; got != "line 52999" {
+		t.Fatalf("last line = %q, want line 52999", got)
+	}
+	if len(m.Logs) > logWindow {
+		t.Fatalf("window holds %d lines, want at most %d", len(m.Logs), logWindow)
+	}
+	if store.maxRows > logPageSize {
+		t.Fatalf("a query returned %d rows, want at most %d", store.maxRows, logPageSize)
+	}
+	if store.fullReads > 0 {
+		t.Fatalf("the full log was read %d times", store.fullReads)
+	}
+}
+
+func TestFailedOlderPageCanBeRetried(t *testing.T) {
+	store := newLogStore(t, 2000)
+	m := NewLogsModel(store, nil)
+	m.Height = 40
+	m.SetDeployment("d1", "api", "abc1234")
+	m, _ = update(m, m.fetchLogs())
+	m.Offset = 0
+
+	store.olderErr = errors.New("database is locked")
+	m, cmd := update(m, tea.KeyMsg{Type: tea.KeyUp})
+	if cmd == nil || !m.loadingOlder {
+		t.Fatal("scrolling past the window did not request the previous page")
+	}
+	m, _ = update(m, cmd())
+	if m.loadingOlder {
+		t.Fatal("loadingOlder is still set after the page failed to load")
+	}
+	if m.errs.Len() != 1 {
+		t.Fatalf("%d errors shown, want 1", m.errs.Len())
+	}
+
+	store.olderErr = nil
+	m, cmd = update(m, tea.KeyMsg{Type: tea.KeyUp})
+	if cmd == nil {
+		t.Fatal("the previous page was not requested again")
+	}
+	before := len(m.Logs)
+	m, _ = update(m, cmd())
+	if len(m.Logs) != before+logPageSize {
+		t.Fatalf("window has %d lines after the retry, want %d", len(m.Logs), before+logPageSize)
+	}
+}
+
+func BenchmarkLogsFetchNewLines(b *testing.B) {
+	store := newLogStore(b, 50000)
+	m := NewLogsModel(store, nil)
+	m.SetDeployment("d1", "api", "abc1234")
+	next, _ := m.Update(m.fetchLogs())
+	m = next.(LogsModel)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		m.fetchLogs()
+	}
+	b.ReportMetric(float64(store.maxRows), "max-rows")
+}
@@ -0,0 +1,274 @@
+/*
+ * Copyright (C) 2026 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of uruflow.
+ *
+ * uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the MIT License as described in the
+ * LICENSE file distributed with this project.
+ *
+ * uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * MIT License for more details.
+ *
+ * You should have received a copy of the MIT License
+ * along with uruflow. If not, see the LICENSE file in the project root.
+ */
+
+package views
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/urustack/uruflow/internal/tui/components"
+	"github.com/urustack/uruflow/internal/tui/styles"
+)
+
+type logSearch struct {
+	input   textinput.Model
+	typing  bool
+	query   string
+	regex   bool
+	re      *regexp.Regexp
+	err     string
+	matches []int
+	current int
+	scanned int
+}
+
+func newLogSearch() logSearch {
+	ti := textinput.New()
+	ti.Cursor.Style = styles.PrimaryStyle
+	ti.CharLimit = 128
+	ti.Placeholder = "search"
+	return logSearch{input: ti, current: -1}
+}
+
+func (s *logSearch) begin() tea.Cmd {
+	s.typing = true
+	s.err = ""
+	s.input.SetValue(s.query)
+	s.input.CursorEnd()
+	s.input.Focus()
+	return textinput.Blink
+}
+
+func (s *logSearch) active() bool {
+	return s.query != "" && s.err == ""
+}
+
+func (s *logSearch) reset() {
+	s.scanned = 0
+	s.matches = nil
+	s.current = -1
+}
+
+func (s *logSearch) clear() {
+	s.typing = false
+	s.query = ""
+	s.re = nil
+	s.err = ""
+	s.input.Blur()
+	s.reset()
+}
+
+func (s *logSearch) update(msg tea.KeyMsg, logs []LogData) (confirmed bool, cmd tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		s.clear()
+		return false, nil
+	case "tab":
+		s.regex = !s.regex
+		s.apply(s.input.Value(), logs)
+		return false, nil
+	case "enter":
+		s.apply(s.input.Value(), logs)
+		if s.err != "" {
+			return false, nil
+		}
+		s.typing = false
+		s.input.Blur()
+		return s.query != "", nil
+	}
+
+	prev := s.input.Value()
+	s.input, cmd = s.input.Update(msg)
+	if s.input.Value() != prev {
+		s.apply(s.input.Value(), logs)
+	}
+	return false, cmd
+}
+
+func (s *logSearch) apply(query string, logs []LogData) {
+	s.query = query
+	s.re = nil
+	s.err = ""
+	s.reset()
+	if query == "" {
+		return
+	}
+	if s.regex {
+		re, err := regexp.Compile("(?i)" + query)
+		if err != nil {
+			s.err = "invalid regex"
+			return
+		}
+		s.re = re
+	} else {
+		s.query = strings.ToLower(query)
+	}
+	s.extend(logs)
+}
+
+func (s *logSearch) extend(logs []LogData) {
+	if len(logs) < s.scanned {
+		s.reset()
+	}
+	if !s.active() {
+		s.scanned = len(logs)
+		return
+	}
+	for i := s.scanned; i < len(logs); i++ {
+		if s.match(logs[i].Content) {
+			s.matches = append(s.matches, i)
+		}
+	}
+	s.scanned = len(logs)
+}
+
+func (s *logSearch) dropFront(n int) {
+	s.scanned -= n
+	if s.scanned < 0 {
+		s.scanned = 0
+	}
+	kept := s.matches[:0]
+	dropped := 0
+	for _, idx := range s.matches {
+		if idx-n >= 0 {
+			kept = append(kept, idx-n)
+		} else {
+			dropped++
+		}
+	}
+	s.matches = kept
+	if s.current >= 0 {
+		s.current -= dropped
+		if s.current < 0 && len(s.matches) > 0 {
+			s.current = 0
+		}
+	}
+}
+
+func (s *logSearch) match(line string) bool {
+	if s.re != nil {
+		return s.re.MatchString(line)
+	}
+	return strings.Contains(strings.ToLower(line), s.query)
+}
+
+func (s *logSearch) isMatch(idx int) bool {
+	i := sort.SearchInts(s.matches, idx)
+	return i < len(s.matches) && s.matches[i] == idx
+}
+
+func (s *logSearch) isCurrent(idx int) bool {
+	return s.current >= 0 && s.current < len(s.matches) && s.matches[s.current] == idx
+}
+
+func (s *logSearch) next(from int, backwards bool) int {
+	if len(s.matches) == 0 {
+		s.current = -1
+		return -1
+	}
+	if backwards {
+		i := sort.SearchInts(s.matches, from) - 1
+		if i < 0 {
+			i = len(s.matches) - 1
+		}
+		s.current = i
+	} else {
+		i := sort.SearchInts(s.matches, from)
+		if i >= len(s.matches) {
+			i = 0
+		}
+		s.current = i
+	}
+	return s.matches[s.current]
+}
+
+func (s *logSearch) step(backwards bool) int {
+	if len(s.matches) == 0 {
+		return -1
+	}
+	if s.current < 0 || s.current >= len(s.matches) {
+		return s.next(0, backwards)
+	}
+	if backwards {
+		s.current = (s.current - 1 + len(s.matches)) % len(s.matches)
+	} else {
+		s.current = (s.current + 1) % len(s.matches)
+	}
+	return s.matches[s.current]
+}
+
+func (s *logSearch) status() string {
+	switch {
+	case s.err != "":
+		return styles.ErrorStyle.Render(s.err)
+	case s.query == "":
+		return ""
+	case len(s.matches) == 0:
+		return styles.WarningStyle.Render("no matches")
+	case s.current < 0:
+		return styles.PrimaryStyle.Render(fmt.Sprintf("%d matches", len(s.matches)))
+	default:
+		return styles.PrimaryStyle.Render(fmt.Sprintf("match %d/%d", s.current+1, len(s.matches)))
+	}
+}
+
+func (s *logSearch) view(w int) string {
+	mode := styles.MutedStyle.Render("text")
+	if s.regex {
+		mode = styles.PrimaryStyle.Render("regex")
+	}
+	line := "  " + styles.InputBoxFocused.Width(w-8).Render(s.input.View())
+	return line + "\n  " + styles.SubtleStyle.Render("mode: ") + mode + "   " + s.status()
+}
+
+func (s *logSearch) line(idx int, l LogData, w int) string {
+	switch {
+	case s.isCurrent(idx):
+		return components.LogLineMarked(l.Time, l.Content, l.Stream, styles.PrimaryStyle.Render("▶ "), w)
+	case s.active() && s.isMatch(idx):
+		return components.LogLineMarked(l.Time, l.Content, l.Stream, styles.WarningStyle.Render("▍ "), w)
+	default:
+		return components.LogLine(l.Time, l.Content, l.Stream, w)
+	}
+}
+
+func firstStderr(logs []LogData) int {
+	for i, l := range logs {
+		if l.Stream == "stderr" {
+			return i
+		}
+	}
+	return -1
+}
+
+func offsetFor(idx, total, visible int) int {
+	off := idx - visible/3
+	maxOffset := total - visible
+	if off > maxOffset {
+		off = maxOffset
+	}
+	if off < 0 {
+		off = 0
+	}
+	return off
+}
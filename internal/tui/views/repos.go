@@ -19,7 +19,9 @@
 package views
 
 import (
+	"context"
 	"fmt"
+	"sort"
 	"strings"
 	"time"
 
@@ -41,6 +43,8 @@ const (
 	RepoModeAdd
 	RepoModeSelectAgent
 	RepoModeConfirmDelete
+	RepoModeConfirmDeploy
+	RepoModeCheckRemote
 )
 
 const (
@@ -48,13 +52,24 @@ const (
 	RepoStepURL        = 1
 	RepoStepBranch     = 2
 	RepoStepBuild      = 3
-	RepoStepBuildFile  = 4
-	RepoStepPath       = 5
-	RepoStepAutoDeploy = 6
-	RepoStepTotal      = 7
+	RepoStepImage      = 4
+	RepoStepBuildFile  = 5
+	RepoStepContainer  = 6
+	RepoStepPorts      = 7
+	RepoStepPath       = 8
+	RepoStepEnv        = 9
+	RepoStepAutoDeploy = 10
+	RepoStepSchedule   = 11
+	RepoStepSecret     = 12
+	RepoStepTotal      = 13
 )
 
-var buildSystems = []string{"compose", "dockerfile", "makefile"}
+var buildSystems = []string{"compose", "dockerfile", "makefile", "image"}
+
+type remoteCheckMsg struct {
+	Seq   int
+	Check services.RemoteCheck
+}
 
 type RepoResultMsg struct {
 	Success bool
@@ -77,13 +92,18 @@ type ReposModel struct {
 	AddStep       int
 	NewRepo       NewRepoData
 	AgentCursor   int
+	ByLabel       bool
 	BuildCursor   int
 	Dialog        components.Dialog
 	Loading       bool
 	SpinnerFrame  int
+	Checking      bool
+	Remote        services.RemoteCheck
+	checkSeq      int
 	input         textinput.Model
 
-	err error
+	err  error
+	errs components.ErrorStack
 }
 
 type NewRepoData struct {
@@ -93,9 +113,53 @@ type NewRepoData struct {
 	Path        string
 	AgentID     string
 	AgentName   string
+	Selector    string
 	AutoDeploy  bool
 	BuildSystem string
 	BuildFile   string
+	Image       string
+	Container   string
+	Ports       []string
+	Schedule    string
+	Secret      string
+	Env         map[string]string
+}
+
+func (r NewRepoData) steps() []int {
+	steps := []int{RepoStepName, RepoStepURL, RepoStepBranch, RepoStepBuild}
+	if r.BuildSystem == "image" {
+		steps = append(steps, RepoStepImage, RepoStepBuildFile)
+		if r.BuildFile == "" {
+			steps = append(steps, RepoStepContainer, RepoStepPorts)
+		}
+	} else {
+		steps = append(steps, RepoStepBuildFile)
+	}
+	return append(steps, RepoStepPath, RepoStepEnv, RepoStepAutoDeploy, RepoStepSchedule, RepoStepSecret)
+}
+
+func (r NewRepoData) stepIndex(step int) int {
+	for i, s := range r.steps() {
+		if s == step {
+			return i
+		}
+	}
+	return 0
+}
+
+func (r NewRepoData) nextStep(step int) int {
+	steps := r.steps()
+	if i := r.stepIndex(step); i+1 < len(steps) {
+		return steps[i+1]
+	}
+	return step
+}
+
+func (r NewRepoData) prevStep(step int) int {
+	if i := r.stepIndex(step); i > 0 {
+		return r.steps()[i-1]
+	}
+	return step
 }
 
 func NewReposModel(store storage.Store, cfg *config.Config, cfgPath string, deployService *services.DeploymentService) ReposModel {
@@ -130,12 +194,26 @@ func (m ReposModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m.updateSelectAgent(msg)
 		case RepoModeConfirmDelete:
 			return m.updateConfirmDelete(msg)
+		case RepoModeConfirmDeploy:
+			return m.updateConfirmDeploy(msg)
+		case RepoModeCheckRemote:
+			return m.updateCheckRemote(msg)
 		}
 	case SpinnerTickMsg:
 		m.SpinnerFrame++
-		if m.Loading {
+		if m.Loading || m.Checking {
 			return m, m.spinnerTick
 		}
+	case remoteCheckMsg:
+		if msg.Seq != m.checkSeq || m.Mode != RepoModeCheckRemote {
+			return m, nil
+		}
+		m.Checking = false
+		m.Remote = msg.Check
+		if m.Remote.OK() {
+			return m.selectAgent(), nil
+		}
+		return m, nil
 	case RepoResultMsg:
 		if msg.Success {
 			m.Mode = RepoModeList
@@ -148,12 +226,14 @@ func (m ReposModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case []RepoData:
 		m.Repos = msg
 		m.Loading = false
+		m.errs.Resolve("loading repositories")
 		return m, nil
 	case []AgentData:
 		m.Agents = msg
+		m.errs.Resolve("loading agents")
 		return m, nil
 	case error:
-		m.err = msg
+		pushError(&m.errs, msg)
 		m.Loading = false
 		return m, nil
 	}
@@ -178,12 +258,14 @@ func (m ReposModel) updateConfirmDelete(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		m.Dialog.Visible = false
 	case "left", "right", "h", "l", "tab":
 		m.Dialog.ToggleSelection()
+	case "s", "w":
+		m.Dialog.ToggleOption(msg.String())
 	case "enter":
 		if m.Dialog.IsConfirmed() {
 			m.Dialog.Visible = false
 			m.Mode = RepoModeList
 			m.Loading = true
-			return m, tea.Batch(m.deleteRepo(m.Repos[m.Cursor].Name), m.spinnerTick)
+			return m, tea.Batch(m.deleteRepo(m.Repos[m.Cursor].Name, m.Dialog.Option("s"), m.Dialog.Option("w")), m.spinnerTick)
 		} else {
 			m.Mode = RepoModeList
 			m.Dialog.Visible = false
@@ -192,12 +274,55 @@ func (m ReposModel) updateConfirmDelete(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		m.Dialog.Visible = false
 		m.Mode = RepoModeList
 		m.Loading = true
-		return m, tea.Batch(m.deleteRepo(m.Repos[m.Cursor].Name), m.spinnerTick)
+		return m, tea.Batch(m.deleteRepo(m.Repos[m.Cursor].Name, m.Dialog.Option("s"), m.Dialog.Option("w")), m.spinnerTick)
+	}
+	return m, nil
+}
+
+func (m ReposModel) updateConfirmDeploy(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc", "n":
+		m.Mode = RepoModeList
+		m.Dialog.Visible = false
+	case "left", "right", "h", "l", "tab":
+		m.Dialog.ToggleSelection()
+	case "enter", "y":
+		confirmed := msg.String() == "y" || m.Dialog.IsConfirmed()
+		m.Mode = RepoModeList
+		m.Dialog.Visible = false
+		if confirmed {
+			return m, m.triggerDeploy(m.Cursor)
+		}
 	}
 	return m, nil
 }
 
+func (m ReposModel) maintenanceReason(repo RepoData) string {
+	now := time.Now()
+	if repo.AgentID != "" {
+		reason, _ := m.cfg.AgentMaintenance(repo.AgentID, now)
+		return reason
+	}
+	cfgRepo := m.cfg.GetRepository(repo.Name)
+	if cfgRepo == nil || cfgRepo.AgentSelector == "" {
+		return ""
+	}
+	selector, err := models.ParseLabels(cfgRepo.AgentSelector)
+	if err != nil {
+		return ""
+	}
+	for _, a := range m.cfg.AgentsMatching(selector) {
+		if reason, ok := a.InMaintenance(now); ok {
+			return a.Name + " " + reason
+		}
+	}
+	return ""
+}
+
 func (m ReposModel) updateList(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if dismissError(&m.errs, msg.String()) {
+		return m, nil
+	}
 	switch msg.String() {
 	case "up", "k":
 		if m.Cursor > 0 {
@@ -209,8 +334,17 @@ func (m ReposModel) updateList(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		}
 	case "enter":
 		if len(m.Repos) > 0 {
+			if reason := m.maintenanceReason(m.Repos[m.Cursor]); reason != "" {
+				m.Dialog = components.MaintenanceDeployDialog(m.Repos[m.Cursor].Name, reason)
+				m.Mode = RepoModeConfirmDeploy
+				return m, nil
+			}
 			return m, m.triggerDeploy(m.Cursor)
 		}
+	case "D":
+		if len(m.Repos) > 0 {
+			return m, tea.Sequence(m.dryRun(m.Cursor), m.fetchRepos)
+		}
 	case "+", "n":
 		m.Mode = RepoModeAdd
 		m.AddStep = 0
@@ -232,29 +366,45 @@ func (m ReposModel) updateList(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		return m, tea.Batch(m.fetchRepos, m.spinnerTick)
 	case "e":
 		m.Expanded = !m.Expanded
+	case "c":
+		if len(m.Repos) > 0 {
+			return m, m.checkDrift(m.Cursor)
+		}
 	}
 	return m, nil
 }
 
 func (m ReposModel) updateAdd(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
-	isSelectionStep := (m.AddStep == 3 || m.AddStep == 6)
+	isSelectionStep := (m.AddStep == RepoStepBuild || m.AddStep == RepoStepAutoDeploy)
 
 	switch msg.String() {
 	case "esc":
 		if m.AddStep > 0 {
-			m.AddStep--
+			m.AddStep = m.NewRepo.prevStep(m.AddStep)
 			switch m.AddStep {
-			case 0:
+			case RepoStepName:
 				m.input.SetValue(m.NewRepo.Name)
-			case 1:
+			case RepoStepURL:
 				m.input.SetValue(m.NewRepo.URL)
-			case 2:
+			case RepoStepBranch:
 				m.input.SetValue(m.NewRepo.Branch)
-			case 4:
+			case RepoStepImage:
+				m.input.SetValue(m.NewRepo.Image)
+			case RepoStepBuildFile:
 				m.input.SetValue(m.NewRepo.BuildFile)
-			case 5:
+			case RepoStepContainer:
+				m.input.SetValue(m.NewRepo.Container)
+			case RepoStepPorts:
+				m.input.SetValue(strings.Join(m.NewRepo.Ports, ", "))
+			case RepoStepPath:
 				m.input.SetValue(m.NewRepo.Path)
+			case RepoStepEnv:
+				m.input.SetValue("")
+			case RepoStepSchedule:
+				m.input.SetValue(m.NewRepo.Schedule)
 			}
+			m.input.EchoMode = textinput.EchoNormal
+			m.err = nil
 		} else {
 			m.Mode = RepoModeList
 		}
@@ -269,36 +419,109 @@ func (m ReposModel) updateAdd(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			}
 			m.NewRepo.Name = val
 		case RepoStepURL:
-			if val == "" {
-				return m, nil
-			}
-			m.NewRepo.URL = val
+			m.NewRepo.URL = strings.TrimSpace(val)
 		case RepoStepBranch:
 			m.NewRepo.Branch = val
 		case RepoStepBuild:
 			m.NewRepo.BuildSystem = buildSystems[m.BuildCursor]
+			if m.NewRepo.BuildSystem != "image" && m.NewRepo.URL == "" {
+				m.err = fmt.Errorf("%s repositories are built from git, enter a URL", m.NewRepo.BuildSystem)
+				m.AddStep = RepoStepURL
+				m.input.SetValue("")
+				return m, nil
+			}
+		case RepoStepImage:
+			repo := models.Repository{Image: strings.TrimSpace(val)}
+			if err := repo.ValidateImage(); err != nil {
+				m.err = err
+				return m, nil
+			}
+			m.NewRepo.Image = repo.Image
 		case RepoStepBuildFile:
 			m.NewRepo.BuildFile = val
+		case RepoStepContainer:
+			repo := models.Repository{Image: m.NewRepo.Image, Container: strings.TrimSpace(val)}
+			if err := repo.ValidateImage(); err != nil {
+				m.err = err
+				return m, nil
+			}
+			m.NewRepo.Container = repo.Container
+		case RepoStepPorts:
+			repo := models.Repository{Image: m.NewRepo.Image, Ports: models.SplitBranches(val)}
+			if err := repo.ValidateImage(); err != nil {
+				m.err = err
+				return m, nil
+			}
+			m.NewRepo.Ports = repo.Ports
 		case RepoStepPath:
 			m.NewRepo.Path = val
+		case RepoStepEnv:
+			if val != "" {
+				key, value, ok := strings.Cut(val, "=")
+				key = strings.TrimSpace(key)
+				if !ok || key == "" || strings.ContainsAny(key, " \t") {
+					m.err = fmt.Errorf("expected KEY=VALUE")
+					return m, nil
+				}
+				if m.NewRepo.Env == nil {
+					m.NewRepo.Env = make(map[string]string)
+				}
+				m.NewRepo.Env[key] = value
+				m.err = nil
+				m.input.SetValue("")
+				return m, nil
+			}
+		case RepoStepSchedule:
+			val = strings.TrimSpace(val)
+			if val != "" {
+				if _, err := models.ParseSchedule(val); err != nil {
+					m.err = err
+					return m, nil
+				}
+			}
+			m.NewRepo.Schedule = val
+		case RepoStepSecret:
+			m.NewRepo.Secret = val
 		}
+		m.err = nil
 
-		if m.AddStep < RepoStepAutoDeploy {
-			m.AddStep++
+		if m.AddStep < RepoStepSecret {
+			m.AddStep = m.NewRepo.nextStep(m.AddStep)
 			m.input.SetValue("")
 			switch m.AddStep {
 			case RepoStepURL:
 				m.input.Placeholder = "https://github.com/user/repo.git"
 			case RepoStepBranch:
 				m.input.SetValue("main")
+			case RepoStepImage:
+				m.input.Placeholder = "registry.example.com/app:{commit}"
+				m.input.SetValue(m.NewRepo.Image)
 			case RepoStepBuildFile:
 				m.input.Placeholder = "docker-compose.yml"
+			case RepoStepContainer:
+				m.input.Placeholder = "uruflow-" + m.NewRepo.Name
+				m.input.SetValue(m.NewRepo.Container)
+			case RepoStepPorts:
+				m.input.Placeholder = "8080:80"
+				m.input.SetValue(strings.Join(m.NewRepo.Ports, ", "))
 			case RepoStepPath:
 				m.input.Placeholder = "./"
+			case RepoStepEnv:
+				m.input.Placeholder = "KEY=VALUE"
+			case RepoStepSchedule:
+				m.input.Placeholder = "0 3 * * *"
+				m.input.SetValue(m.NewRepo.Schedule)
+			case RepoStepSecret:
+				m.input.Placeholder = "leave empty to use the global secret"
+				m.input.SetValue(m.NewRepo.Secret)
+				m.input.EchoMode = textinput.EchoPassword
 			}
 		} else {
-			m.Mode = RepoModeSelectAgent
-			m.AgentCursor = 0
+			m.input.EchoMode = textinput.EchoNormal
+			if m.NewRepo.URL == "" {
+				return m.selectAgent(), nil
+			}
+			return m.startRemoteCheck()
 		}
 		return m, nil
 
@@ -323,11 +546,68 @@ func (m ReposModel) updateAdd(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	return m, cmd
 }
 
+func (m ReposModel) startRemoteCheck() (tea.Model, tea.Cmd) {
+	m.Mode = RepoModeCheckRemote
+	m.Checking = true
+	m.Remote = services.RemoteCheck{}
+	m.checkSeq++
+	return m, tea.Batch(m.checkRemote(m.checkSeq, m.NewRepo.URL, m.NewRepo.Branch), m.spinnerTick)
+}
+
+func (m ReposModel) checkRemote(seq int, url, branches string) tea.Cmd {
+	return func() tea.Msg {
+		return remoteCheckMsg{Seq: seq, Check: m.deployService.CheckRemote(context.Background(), url, branches)}
+	}
+}
+
+func (m ReposModel) selectAgent() ReposModel {
+	m.Mode = RepoModeSelectAgent
+	m.Checking = false
+	m.AgentCursor = 0
+	m.ByLabel = false
+	return m
+}
+
+func (m ReposModel) updateCheckRemote(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "s":
+		m.checkSeq++
+		return m.selectAgent(), nil
+	case "r":
+		if !m.Checking {
+			return m.startRemoteCheck()
+		}
+	case "esc":
+		m.checkSeq++
+		m.Checking = false
+		m.Mode = RepoModeAdd
+		if m.Remote.Reachable {
+			m.AddStep = RepoStepBranch
+			m.input.SetValue(m.NewRepo.Branch)
+		} else {
+			m.AddStep = RepoStepURL
+			m.input.SetValue(m.NewRepo.URL)
+		}
+	}
+	return m, nil
+}
+
 func (m ReposModel) updateSelectAgent(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.ByLabel {
+		return m.updateSelectLabel(msg)
+	}
 	switch msg.String() {
+	case "tab":
+		m.ByLabel = true
+		m.err = nil
+		m.input.SetValue(m.NewRepo.Selector)
+		m.input.Placeholder = "env=staging"
+		return m, textinput.Blink
 	case "esc":
 		m.Mode = RepoModeAdd
-		m.AddStep = 6
+		m.AddStep = RepoStepSecret
+		m.input.SetValue(m.NewRepo.Secret)
+		m.input.EchoMode = textinput.EchoPassword
 	case "up", "k":
 		if m.AgentCursor > 0 {
 			m.AgentCursor--
@@ -340,32 +620,112 @@ func (m ReposModel) updateSelectAgent(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		if len(m.Agents) > 0 {
 			m.NewRepo.AgentID = m.Agents[m.AgentCursor].ID
 			m.NewRepo.AgentName = m.Agents[m.AgentCursor].Name
+			m.NewRepo.Selector = ""
 			return m, m.addRepo()
 		}
 	}
 	return m, nil
 }
 
+func (m ReposModel) updateSelectLabel(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "tab", "esc":
+		m.ByLabel = false
+		m.err = nil
+		return m, nil
+	case "enter":
+		selector, err := models.ParseLabels(m.input.Value())
+		if err != nil {
+			m.err = err
+			return m, nil
+		}
+		if len(selector) == 0 {
+			m.err = fmt.Errorf("enter at least one key=value label")
+			return m, nil
+		}
+		m.NewRepo.Selector = models.FormatLabels(selector)
+		m.NewRepo.AgentID = ""
+		m.NewRepo.AgentName = ""
+		return m, m.addRepo()
+	}
+
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	return m, cmd
+}
+
+func (m ReposModel) labelMatches() ([]AgentData, error) {
+	selector, err := models.ParseLabels(m.input.Value())
+	if err != nil {
+		return nil, err
+	}
+	var matches []AgentData
+	for _, a := range m.Agents {
+		if models.MatchLabels(a.Labels, selector) {
+			matches = append(matches, a)
+		}
+	}
+	return matches, nil
+}
+
 func (m ReposModel) triggerDeploy(index int) tea.Cmd {
 	return func() tea.Msg {
 		if index >= len(m.Repos) {
 			return nil
 		}
 		repo := m.Repos[index]
-		_, err := m.deployService.TriggerDeploy(repo.AgentID, repo.Name, repo.Branch, "HEAD", "manual")
+		_, err := m.deployService.TriggerDeploy(repo.AgentID, repo.Name, repo.Branch, "HEAD", services.TriggerOptions{
+			Trigger:     "manual",
+			TriggeredBy: m.cfg.OperatorName(),
+		})
+		if err != nil {
+			return opError("deploying "+repo.Name, err)
+		}
+		return m.fetchRepos()
+	}
+}
+
+func (m ReposModel) dryRun(index int) tea.Cmd {
+	return func() tea.Msg {
+		if index >= len(m.Repos) {
+			return nil
+		}
+		repo := m.Repos[index]
+		_, err := m.deployService.TriggerDeploy(repo.AgentID, repo.Name, repo.Branch, "HEAD", services.TriggerOptions{
+			Trigger:     "manual",
+			TriggeredBy: m.cfg.OperatorName(),
+			DryRun:      true,
+		})
 		if err != nil {
-			return err
+			return toastError("dry run of "+repo.Name, err)
+		}
+		return toast(components.ToastSuccess, "Dry run of '"+repo.Name+"' sent, results appear in deployment history")
+	}
+}
+
+func (m ReposModel) checkDrift(index int) tea.Cmd {
+	return func() tea.Msg {
+		if index >= len(m.Repos) {
+			return nil
+		}
+		repo := m.Repos[index]
+		if err := m.deployService.CheckDrift(repo.AgentID, repo.Name); err != nil {
+			return opError("checking drift for "+repo.Name, err)
 		}
+		time.Sleep(2 * time.Second)
 		return m.fetchRepos()
 	}
 }
 
 func (m ReposModel) addRepo() tea.Cmd {
 	return func() tea.Msg {
+		branch, branches := splitBranchInput(m.NewRepo.Branch)
 		repo := models.Repository{
-			Name: m.NewRepo.Name, URL: m.NewRepo.URL, Branch: m.NewRepo.Branch,
-			Path: m.NewRepo.Path, AgentID: m.NewRepo.AgentID, AutoDeploy: m.NewRepo.AutoDeploy,
+			Name: m.NewRepo.Name, URL: m.NewRepo.URL, Branch: branch, Branches: branches,
+			Path: m.NewRepo.Path, AgentID: m.NewRepo.AgentID, AgentSelector: m.NewRepo.Selector, AutoDeploy: m.NewRepo.AutoDeploy,
 			BuildSystem: models.BuildSystem(m.NewRepo.BuildSystem), BuildFile: m.NewRepo.BuildFile,
+			Image: m.NewRepo.Image, Container: m.NewRepo.Container, Ports: m.NewRepo.Ports,
+			Schedule: m.NewRepo.Schedule, Secret: m.NewRepo.Secret, Env: m.NewRepo.Env,
 		}
 		if err := m.cfg.AddRepository(repo); err != nil {
 			return RepoResultMsg{Success: false, Error: err}
@@ -373,61 +733,108 @@ func (m ReposModel) addRepo() tea.Cmd {
 		if err := m.cfg.Save(m.cfgPath); err != nil {
 			return RepoResultMsg{Success: false, Error: err}
 		}
-		m.store.CreateRepository(&repo)
-		return RepoResultMsg{Success: true, Name: repo.Name}
+		result := RepoResultMsg{Success: true, Name: repo.Name}
+		if err := m.store.CreateRepository(&repo); err != nil {
+			result.Error = opError("storing repository "+repo.Name, err)
+		}
+		m.deployService.Prefetch(&repo)
+		return result
 	}
 }
 
-func (m ReposModel) deleteRepo(name string) tea.Cmd {
-	return func() tea.Msg {
+func (m ReposModel) deleteRepo(name string, teardown, removeDir bool) tea.Cmd {
+	remove := func() tea.Msg {
+		var repo models.Repository
+		if r := m.cfg.GetRepository(name); r != nil {
+			repo = *r
+		}
+
 		m.cfg.RemoveRepository(name)
-		m.cfg.Save(m.cfgPath)
-		m.store.DeleteRepository(name)
-		return m.fetchRepos()
+		if err := m.cfg.Save(m.cfgPath); err != nil {
+			return toastError("removing "+name, err)
+		}
+		if err := m.store.DeleteRepository(name); err != nil {
+			return toastError("removing "+name, err)
+		}
+
+		if teardown && repo.Name != "" {
+			if _, err := m.deployService.Teardown(repo, removeDir); err != nil {
+				return toastError("tearing down "+name, err)
+			}
+			return toast(components.ToastSuccess, "Repository '"+name+"' removed, teardown sent")
+		}
+		return toast(components.ToastSuccess, "Repository '"+name+"' removed")
 	}
+	return tea.Sequence(remove, m.fetchRepos)
 }
 
 func (m ReposModel) fetchRepos() tea.Msg {
 	repos, err := m.store.GetAllRepositories()
 	if err != nil {
-		return err
+		return opError("loading repositories", err)
 	}
 	var data []RepoData
 	for _, r := range repos {
-		deployments, _ := m.store.GetDeploymentsByRepo(r.Name, 1)
 		lastStatus, lastCommit, lastTime := "", "", ""
-		if len(deployments) > 0 {
-			d := deployments[0]
+		if d, _ := m.store.GetLastDeployment(r.Name); d != nil {
 			lastStatus = string(d.Status)
-			if len(d.Commit) > 7 {
-				lastCommit = d.Commit[:7]
-			} else {
-				lastCommit = d.Commit
-			}
+			lastCommit = shortCommit(d.Commit)
 			lastTime = time.Since(d.StartedAt).Round(time.Second).String() + " ago"
 		}
+		dryRunCommit, dryRunTime := "", ""
+		if recent, _ := m.store.GetDeploymentsByRepo(r.Name, 1); len(recent) > 0 && recent[0].DryRun {
+			dryRunCommit = shortCommit(recent[0].Commit)
+			dryRunTime = time.Since(recent[0].StartedAt).Round(time.Second).String() + " ago"
+		}
 		agentName := r.AgentID
-		agent, _ := m.store.GetAgent(r.AgentID)
-		if agent != nil {
+		if r.AgentSelector != "" {
+			agentName = r.AgentSelector
+		} else if agent, _ := m.store.GetAgent(r.AgentID); agent != nil {
 			agentName = agent.Name
 		}
+		schedule, nextRun, image := "", "", ""
+		if cr := m.cfg.GetRepository(r.Name); cr != nil {
+			image = cr.Image
+			if cr.Schedule != "" {
+				schedule = cr.Schedule
+				if next, ok := cr.NextRun(time.Now()); ok {
+					nextRun = next.Format("2006-01-02 15:04")
+				}
+			}
+		}
 		data = append(data, RepoData{
-			Name: r.Name, URL: r.URL, Branch: r.Branch, Agent: agentName, AgentID: r.AgentID,
-			AutoDeploy: r.AutoDeploy, BuildSystem: string(r.BuildSystem), BuildFile: r.BuildFile,
-			LastStatus: lastStatus, LastCommit: lastCommit, LastTime: lastTime,
+			Name: r.Name, URL: r.URL, Branch: strings.Join(r.BranchPatterns(), ","), Agent: agentName, AgentID: r.AgentID,
+			AutoDeploy: r.AutoDeploy, BuildSystem: string(r.BuildSystem), BuildFile: r.BuildFile, BuildCmd: r.BuildCmd, Image: image,
+			LastStatus: lastStatus, LastCommit: lastCommit, LastTime: lastTime, DryRunCommit: dryRunCommit, DryRunTime: dryRunTime, Drift: r.Drift,
+			Schedule: schedule, NextRun: nextRun, Checkout: m.warmCheckout(r),
 		})
 	}
 	return data
 }
 
+func (m ReposModel) warmCheckout(r models.Repository) string {
+	checkouts, _ := m.store.GetCheckoutsByRepo(r.Name)
+	for _, c := range checkouts {
+		if !c.Matches(&r) || (r.AgentSelector == "" && c.AgentID != r.AgentID) {
+			continue
+		}
+		warm := shortCommit(c.Commit)
+		if !c.FetchedAt.IsZero() {
+			warm += ", fetched " + helper.FormatTimeAgo(c.FetchedAt)
+		}
+		return warm
+	}
+	return ""
+}
+
 func (m ReposModel) fetchAgents() tea.Msg {
 	agents, err := m.store.GetAllAgents()
 	if err != nil {
-		return err
+		return opError("loading agents", err)
 	}
 	var data []AgentData
 	for _, a := range agents {
-		data = append(data, AgentData{ID: a.ID, Name: a.Name, Online: a.Status == "online"})
+		data = append(data, AgentData{ID: a.ID, Name: a.Name, Online: a.Status == "online", Labels: a.Labels})
 	}
 	return data
 }
@@ -441,7 +848,9 @@ func (m ReposModel) View() string {
 		return m.viewAdd()
 	case RepoModeSelectAgent:
 		return m.viewSelectAgent()
-	case RepoModeConfirmDelete:
+	case RepoModeCheckRemote:
+		return m.viewCheckRemote()
+	case RepoModeConfirmDelete, RepoModeConfirmDeploy:
 		return m.viewList() + components.ConfirmDialog(m.Dialog, m.Width, m.Height)
 	default:
 		return m.viewList()
@@ -462,8 +871,8 @@ func (m ReposModel) viewList() string {
 		styles.MutedStyle.Render("repositories configured")))
 	b.WriteString(components.Wrap(statsContent.String(), w) + "\n\n")
 
-	if m.err != nil {
-		b.WriteString(components.MsgError(m.err.Error(), w) + "\n\n")
+	if m.errs.Len() > 0 {
+		b.WriteString(m.errs.View(w) + "\n\n")
 	}
 
 	b.WriteString(components.Section("REPOSITORY LIST", w) + "\n\n")
@@ -477,19 +886,20 @@ func (m ReposModel) viewList() string {
 		listContent.WriteString("  " + styles.MutedStyle.Render("No repositories configured") + "\n")
 		listContent.WriteString("  " + styles.SubtleStyle.Render("Press '+' to add your first repository"))
 	} else if len(m.Repos) > 0 {
-		listContent.WriteString(components.RepoHeader(w) + "\n")
+		listContent.WriteString(components.RepoHeader(w-8) + "\n")
 		listContent.WriteString("  " + styles.Line(w-8) + "\n")
 		for i, r := range m.Repos {
 			selected := i == m.Cursor
 			if selected && m.Expanded {
 				card := components.RepoCardData{
 					Name: r.Name, URL: r.URL, Branch: r.Branch, Agent: r.Agent,
-					AutoDeploy: r.AutoDeploy, BuildSystem: r.BuildSystem, BuildFile: r.BuildFile,
-					LastStatus: r.LastStatus, LastCommit: r.LastCommit, LastTime: r.LastTime, Selected: true,
+					AutoDeploy: r.AutoDeploy, BuildSystem: r.BuildSystem, BuildFile: r.BuildFile, BuildCmd: r.BuildCmd, Image: r.Image,
+					LastStatus: r.LastStatus, LastCommit: r.LastCommit, LastTime: r.LastTime, DryRunCommit: r.DryRunCommit, DryRunTime: r.DryRunTime, Drift: r.Drift,
+					Schedule: r.Schedule, NextRun: r.NextRun, Checkout: r.Checkout, Selected: true,
 				}
 				listContent.WriteString(components.RepoCard(card, w-8) + "\n")
 			} else {
-				row := components.RepoRow(r.Name, r.Branch, r.Agent, r.AutoDeploy, r.LastStatus, r.LastTime, selected, w)
+				row := components.RepoRow(r.Name, r.Branch, r.Agent, r.AutoDeploy, r.LastStatus, r.LastTime, len(r.Drift) > 0, r.Checkout != "", selected, w-8)
 				if selected {
 					listContent.WriteString(components.SelectedRow(row, true) + "\n")
 				} else {
@@ -510,7 +920,7 @@ func (m ReposModel) viewList() string {
 
 	content += "\n" + styles.Line(w) + "\n"
 	content += components.Help([][]string{
-		{"↑↓", "navigate"}, {"enter", "deploy"}, {"+", "add"}, {"-", "remove"}, {"e", "expand"}, {"esc", "back"},
+		{"↑↓", "navigate"}, {"enter", "deploy"}, {"D", "dry run"}, {"+", "add"}, {"-", "remove"}, {"c", "drift"}, {"e", "expand"}, {"esc", "back"},
 	})
 
 	return content
@@ -519,21 +929,31 @@ func (m ReposModel) viewList() string {
 func (m ReposModel) viewAdd() string {
 	var b strings.Builder
 	w := m.Width
-	stepNames := []string{"Name", "URL", "Branch", "Build System", "Build File", "Path", "Auto Deploy"}
+	stepNames := []string{"Name", "URL", "Branch", "Build System", "Image", "Build File", "Container", "Ports", "Path", "Environment", "Auto Deploy", "Schedule", "Webhook Secret"}
 	currentStepName := stepNames[m.AddStep]
 	b.WriteString("\n")
 	b.WriteString(components.ViewHeader(w, "Dashboard", "Repositories", "Add Repository", currentStepName) + "\n\n")
-	stepperSteps := []components.StepperStep{
+	allSteps := []components.StepperStep{
 		{Label: "Repository Name", Value: m.NewRepo.Name},
 		{Label: "Git URL", Value: m.NewRepo.URL},
 		{Label: "Branch", Value: m.NewRepo.Branch},
 		{Label: "Build System", Value: m.NewRepo.BuildSystem},
+		{Label: "Image", Value: m.NewRepo.Image},
 		{Label: "Build File", Value: m.NewRepo.BuildFile},
+		{Label: "Container", Value: m.NewRepo.Container},
+		{Label: "Ports", Value: strings.Join(m.NewRepo.Ports, ", ")},
 		{Label: "Deploy Path", Value: m.NewRepo.Path},
+		{Label: "Environment", Value: envSummary(m.NewRepo.Env)},
 		{Label: "Auto Deploy", Value: fmt.Sprintf("%v", m.NewRepo.AutoDeploy)},
+		{Label: "Schedule", Value: m.NewRepo.Schedule},
+		{Label: "Webhook Secret", Value: maskSecret(m.NewRepo.Secret)},
+	}
+	var stepperSteps []components.StepperStep
+	for _, step := range m.NewRepo.steps() {
+		stepperSteps = append(stepperSteps, allSteps[step])
 	}
 
-	b.WriteString(components.FormStepper(stepperSteps, m.AddStep, w) + "\n")
+	b.WriteString(components.FormStepper(stepperSteps, m.NewRepo.stepIndex(m.AddStep), w) + "\n")
 	var formContent strings.Builder
 	switch m.AddStep {
 	case RepoStepBuild:
@@ -557,10 +977,41 @@ func (m ReposModel) viewAdd() string {
 		formContent.WriteString("\n  " + inputView)
 
 		switch m.AddStep {
+		case RepoStepURL:
+			formContent.WriteString("\n  " + styles.MutedStyle.Render("Git URL (optional for image repositories)"))
+		case RepoStepBranch:
+			formContent.WriteString("\n  " + styles.MutedStyle.Render("Comma-separated branches or globs, e.g. main, release/*"))
+		case RepoStepImage:
+			formContent.WriteString("\n  " + styles.MutedStyle.Render("Image to pull, {commit}, {short_commit}, {tag} and {branch} are filled in per deploy"))
 		case RepoStepBuildFile:
-			formContent.WriteString("\n  " + styles.MutedStyle.Render("e.g. docker-compose.prod.yml (optional)"))
+			if m.NewRepo.BuildSystem == "image" {
+				formContent.WriteString("\n  " + styles.MutedStyle.Render("Compose file already on the agent that uses ${URUFLOW_IMAGE}, empty for a single container"))
+			} else {
+				formContent.WriteString("\n  " + styles.MutedStyle.Render("e.g. docker-compose.prod.yml (optional)"))
+			}
+		case RepoStepContainer:
+			formContent.WriteString("\n  " + styles.MutedStyle.Render("Container name (optional, defaults to uruflow-"+m.NewRepo.Name+")"))
+		case RepoStepPorts:
+			formContent.WriteString("\n  " + styles.MutedStyle.Render("Comma-separated port mappings, e.g. 8080:80 (optional)"))
 		case RepoStepPath:
 			formContent.WriteString("\n  " + styles.MutedStyle.Render("Relative path to deploy directory (optional)"))
+		case RepoStepEnv:
+			formContent.WriteString("\n  " + styles.MutedStyle.Render("Add KEY=VALUE pairs one at a time, empty enter to continue"))
+			keys := make([]string, 0, len(m.NewRepo.Env))
+			for k := range m.NewRepo.Env {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			for _, k := range keys {
+				formContent.WriteString("\n    " + styles.BrightStyle.Render(k) + styles.MutedStyle.Render("="+maskSecret(m.NewRepo.Env[k])))
+			}
+		case RepoStepSchedule:
+			formContent.WriteString("\n  " + styles.MutedStyle.Render("Cron expression or @daily to redeploy HEAD on a timer (optional)"))
+			if next, ok := (&models.Repository{Schedule: strings.TrimSpace(m.input.Value())}).NextRun(time.Now()); ok {
+				formContent.WriteString("\n  " + styles.SubtleStyle.Render("Next run ") + next.Format("Mon 2006-01-02 15:04"))
+			}
+		case RepoStepSecret:
+			formContent.WriteString("\n  " + styles.MutedStyle.Render("Per-repository webhook secret (optional)"))
 		}
 	}
 
@@ -581,6 +1032,98 @@ func (m ReposModel) viewAdd() string {
 	return content
 }
 
+func (m ReposModel) viewCheckRemote() string {
+	var b strings.Builder
+	w := m.Width
+
+	b.WriteString("\n")
+	b.WriteString(components.ViewHeader(w, "Dashboard", "Repositories", "Add Repository", "Check Remote") + "\n\n")
+	b.WriteString(components.Section("REMOTE CHECK", w) + "\n\n")
+
+	var content strings.Builder
+	content.WriteString(styles.SubtleStyle.Render("URL    ") + m.NewRepo.URL + "\n")
+	content.WriteString(styles.SubtleStyle.Render("Branch ") + m.NewRepo.Branch + "\n\n")
+
+	switch {
+	case m.Checking:
+		content.WriteString(components.Loading(m.SpinnerFrame, "Running git ls-remote from the server..."))
+	case !m.Remote.Reachable:
+		content.WriteString(styles.ErrorStyle.Render(styles.IconError+"  Server cannot reach the repository") + "\n")
+		content.WriteString("   " + styles.MutedStyle.Render(helper.TruncateString(m.Remote.Error, w-12)) + "\n\n")
+		content.WriteString(styles.SubtleStyle.Render("Private repositories may only be reachable from agents — press s to skip"))
+	default:
+		content.WriteString(styles.SuccessStyle.Render(styles.IconSuccess) + "  " +
+			fmt.Sprintf("Reachable, %d branches", len(m.Remote.Branches)) + "\n")
+		for _, missing := range m.Remote.Missing {
+			content.WriteString(styles.WarningStyle.Render(styles.IconWarning+"  No branch matches "+missing) + "\n")
+		}
+		if len(m.Remote.Branches) > 0 {
+			content.WriteString("\n" + styles.SubtleStyle.Render("Available branches") + "\n")
+			const shown = 12
+			for i, branch := range m.Remote.Branches {
+				if i == shown {
+					content.WriteString("   " + styles.MutedStyle.Render(fmt.Sprintf("… and %d more", len(m.Remote.Branches)-shown)) + "\n")
+					break
+				}
+				content.WriteString("   " + branch + "\n")
+			}
+		}
+	}
+	b.WriteString(components.Wrap(strings.TrimRight(content.String(), "\n"), w) + "\n")
+
+	result := b.String()
+	lines := helper.CountLines(result)
+	for i := 0; i < m.Height-lines-3; i++ {
+		result += "\n"
+	}
+
+	result += "\n" + styles.Line(w) + "\n"
+	help := [][]string{{"s", "skip"}, {"esc", "edit"}}
+	if !m.Checking {
+		help = [][]string{{"s", "skip"}, {"r", "retry"}, {"esc", "edit"}}
+	}
+	result += components.Help(help)
+
+	return result
+}
+
+func splitBranchInput(input string) (string, []string) {
+	patterns := models.SplitBranches(input)
+	if len(patterns) == 0 {
+		return "main", nil
+	}
+
+	branch := ""
+	for _, p := range patterns {
+		if !models.IsBranchPattern(p) {
+			branch = p
+			break
+		}
+	}
+	if branch == "" {
+		branch = "main"
+	}
+
+	if len(patterns) == 1 && patterns[0] == branch {
+		return branch, nil
+	}
+	return branch, patterns
+}
+
+func envSummary(env map[string]string) string {
+	if len(env) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%d variable(s)", len(env))
+}
+
+func maskSecret(secret string) string {
+	if secret == "" {
+		return ""
+	}
+	return strings.Repeat("•", 8)
+}
+
 func (m ReposModel) viewSelectAgent() string {
 	var b strings.Builder
 	w := m.Width
@@ -591,13 +1134,15 @@ func (m ReposModel) viewSelectAgent() string {
 	b.WriteString(components.Section("CHOOSE DEPLOYMENT TARGET", w) + "\n\n")
 
 	var listContent strings.Builder
-	if len(m.Agents) == 0 {
+	if m.ByLabel {
+		listContent.WriteString(m.viewSelectLabel())
+	} else if len(m.Agents) == 0 {
 		listContent.WriteString("  " + styles.MutedStyle.Render("No agents available") + "\n")
 		listContent.WriteString("  " + styles.SubtleStyle.Render("Add an agent first"))
 	} else {
 		for i, a := range m.Agents {
 			selected := i == m.AgentCursor
-			listContent.WriteString(components.AgentRow(a.Name, a.Online, 0, 0, 0, "", selected, w) + "\n")
+			listContent.WriteString(components.AgentRow(a.Name, a.Online, 0, 0, 0, "", selected, w-8) + "\n")
 		}
 	}
 	b.WriteString(components.Wrap(listContent.String(), w) + "\n")
@@ -609,7 +1154,38 @@ func (m ReposModel) viewSelectAgent() string {
 	}
 
 	content += "\n" + styles.Line(w) + "\n"
-	content += components.Help([][]string{{"↑↓", "select"}, {"enter", "confirm"}, {"esc", "back"}})
+	if m.ByLabel {
+		content += components.Help([][]string{{"enter", "confirm"}, {"tab", "pick agent"}, {"esc", "back"}})
+	} else {
+		content += components.Help([][]string{{"↑↓", "select"}, {"enter", "confirm"}, {"tab", "by label"}, {"esc", "back"}})
+	}
 
 	return content
 }
+
+func (m ReposModel) viewSelectLabel() string {
+	w := m.Width
+
+	var formContent strings.Builder
+	inputView := styles.InputBoxFocused.Width(w - 8).Render(m.input.View())
+	formContent.WriteString("  " + styles.SubtleStyle.Render("Deploy to the first connected agent matching these labels") + "\n")
+	formContent.WriteString("  " + inputView + "\n\n")
+
+	matches, err := m.labelMatches()
+	switch {
+	case err != nil:
+		formContent.WriteString("  " + styles.MutedStyle.Render(err.Error()))
+	case len(matches) == 0:
+		formContent.WriteString("  " + styles.WarningStyle.Render("No agents match yet"))
+	default:
+		for _, a := range matches {
+			formContent.WriteString(components.AgentRow(a.Name, a.Online, 0, 0, 0, "", false, w-8) +
+				"  " + styles.MutedStyle.Render(models.FormatLabels(a.Labels)) + "\n")
+		}
+	}
+
+	if m.err != nil {
+		formContent.WriteString("\n\n" + styles.ErrorStyle.Render(styles.IconError) + "  " + styles.ErrorStyle.Render(m.err.Error()))
+	}
+	return formContent.String()
+}
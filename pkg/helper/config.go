@@ -20,10 +20,14 @@ package helper
 
 import (
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/hex"
 	"os"
+	"strings"
 )
 
+const tokenHashPrefix = "sha256:"
+
 func GenerateSecret() string {
 	bytes := make([]byte, 16)
 	rand.Read(bytes)
@@ -36,6 +40,15 @@ func GenerateToken() string {
 	return hex.EncodeToString(bytes)
 }
 
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return tokenHashPrefix + hex.EncodeToString(sum[:])
+}
+
+func IsTokenHash(s string) bool {
+	return strings.HasPrefix(s, tokenHashPrefix) && len(s) == len(tokenHashPrefix)+sha256.Size*2
+}
+
 func GenerateID() string {
 	bytes := make([]byte, 8)
 	rand.Read(bytes)
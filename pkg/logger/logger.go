@@ -1,10 +1,14 @@
 package logger
 
 import (
+	"encoding/json"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
 	"time"
 )
 
@@ -17,6 +21,11 @@ const (
 	ERROR
 )
 
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
 var levelNames = map[Level]string{
 	DEBUG: "DEBUG",
 	INFO:  "INFO",
@@ -24,17 +33,38 @@ var levelNames = map[Level]string{
 	ERROR: "ERROR",
 }
 
+var componentTag = regexp.MustCompile(`^\[([A-Za-z0-9_-]+)\]\s*`)
+
+type Options struct {
+	Path       string
+	Level      string
+	Format     string
+	MaxSizeMB  int
+	MaxBackups int
+	Stdout     bool
+}
+
 type Logger struct {
 	level      Level
 	fileOutput io.Writer
 	prefix     string
+	format     string
+	component  string
 }
 
-var std *Logger
+var (
+	std    *Logger
+	closer io.Closer
+	mu     sync.Mutex
+)
 
 func Init(logPath string, level string) error {
+	return Configure(Options{Path: logPath, Level: level})
+}
+
+func Configure(opts Options) error {
 	logLevel := INFO
-	switch level {
+	switch opts.Level {
 	case "debug":
 		logLevel = DEBUG
 	case "warn":
@@ -43,75 +73,136 @@ func Init(logPath string, level string) error {
 		logLevel = ERROR
 	}
 
-	if logPath == "" {
-		std = &Logger{
-			level:      logLevel,
-			fileOutput: os.Stdout,
-			prefix:     "[URUFLOW] ",
-		}
-		return nil
+	format := FormatText
+	if opts.Format == FormatJSON {
+		format = FormatJSON
 	}
 
-	if err := os.MkdirAll(filepath.Dir(logPath), 0755); err != nil {
-		return fmt.Errorf("create log directory: %w", err)
-	}
+	var out io.Writer = os.Stdout
+	var c io.Closer
+	if opts.Path != "" {
+		if err := os.MkdirAll(filepath.Dir(opts.Path), 0755); err != nil {
+			return fmt.Errorf("create log directory: %w", err)
+		}
 
-	file, err := os.OpenFile(logPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
-	if err != nil {
-		return fmt.Errorf("open log file: %w", err)
+		r, err := newRotator(opts.Path, int64(opts.MaxSizeMB)*1024*1024, opts.MaxBackups)
+		if err != nil {
+			return fmt.Errorf("open log file: %w", err)
+		}
+		out, c = r, r
+		if opts.Stdout {
+			out = io.MultiWriter(r, os.Stdout)
+		}
 	}
 
+	mu.Lock()
+	prev := closer
 	std = &Logger{
 		level:      logLevel,
-		fileOutput: file,
+		fileOutput: out,
 		prefix:     "[URUFLOW] ",
+		format:     format,
 	}
+	closer = c
+	mu.Unlock()
 
+	if prev != nil {
+		prev.Close()
+	}
 	return nil
 }
 
+func ValidLevel(level string) bool {
+	switch level {
+	case "", "debug", "info", "warn", "error":
+		return true
+	}
+	return false
+}
+
+func ValidFormat(format string) bool {
+	return format == "" || format == FormatText || format == FormatJSON
+}
+
 func (l *Logger) log(level Level, format string, args ...interface{}) {
 	if level < l.level {
 		return
 	}
 
-	timestamp := time.Now().Format("2006-01-02 15:04:05")
+	now := time.Now()
 	levelStr := levelNames[level]
 	message := fmt.Sprintf(format, args...)
-	fmt.Fprintf(l.fileOutput, "%s %-5s %s%s\n", timestamp, levelStr, l.prefix, message)
+
+	if l.format != FormatJSON {
+		fmt.Fprintf(l.fileOutput, "%s %-5s %s%s\n", now.Format("2006-01-02 15:04:05"), levelStr, l.prefix, message)
+		return
+	}
+
+	component := l.component
+	if m := componentTag.FindStringSubmatch(message); m != nil {
+		if component == "" {
+			component = m[1]
+		}
+		message = message[len(m[0]):]
+	}
+
+	line, err := json.Marshal(struct {
+		Level     string `json:"level"`
+		TS        string `json:"ts"`
+		Component string `json:"component,omitempty"`
+		Message   string `json:"msg"`
+	}{
+		Level:     strings.ToLower(levelStr),
+		TS:        now.Format(time.RFC3339Nano),
+		Component: component,
+		Message:   message,
+	})
+	if err != nil {
+		return
+	}
+	l.fileOutput.Write(append(line, '\n'))
+}
+
+func current() *Logger {
+	mu.Lock()
+	defer mu.Unlock()
+	return std
 }
 
 func Debug(format string, args ...interface{}) {
-	if std != nil {
-		std.log(DEBUG, format, args...)
+	if l := current(); l != nil {
+		l.log(DEBUG, format, args...)
 	}
 }
 
 func Info(format string, args ...interface{}) {
-	if std != nil {
-		std.log(INFO, format, args...)
+	if l := current(); l != nil {
+		l.log(INFO, format, args...)
 	}
 }
 
 func Warn(format string, args ...interface{}) {
-	if std != nil {
-		std.log(WARN, format, args...)
+	if l := current(); l != nil {
+		l.log(WARN, format, args...)
 	}
 }
 
 func Error(format string, args ...interface{}) {
-	if std != nil {
-		std.log(ERROR, format, args...)
+	if l := current(); l != nil {
+		l.log(ERROR, format, args...)
 	}
 }
 
 func With(prefix string) *Logger {
-	if std == nil {
+	l := current()
+	if l == nil {
 		return nil
 	}
 	return &Logger{
-		level:      std.level,
-		fileOutput: std.fileOutput,
-		prefix:     fmt.Sprintf("%s[%s] ", std.prefix, prefix),
+		level:      l.level,
+		fileOutput: l.fileOutput,
+		prefix:     fmt.Sprintf("%s[%s] ", l.prefix, prefix),
+		format:     l.format,
+		component:  prefix,
 	}
 }